@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package defaulting provides test helpers verifying that the external types of a
+// scheme have defaulting functions wired up.
+package defaulting
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// Coverage is the defaulting coverage of the external kinds of a scheme.
+type Coverage struct {
+	// Missing are the kinds without a registered defaulting function.
+	Missing []schema.GroupVersionKind
+	// Ineffective are the kinds with a defaulting function which modified
+	// neither a zero object nor any of the fuzzed objects.
+	Ineffective []schema.GroupVersionKind
+}
+
+var metav1PkgPath = reflect.TypeOf(metav1.Status{}).PkgPath()
+
+// DefaultingCoverage returns the defaulting coverage of the external kinds of
+// scheme, excluding lists and the types of the meta API which are registered in
+// every group. The defaulting function of each kind is applied to a zero object,
+// and to the given number of objects fuzzed with f.
+func DefaultingCoverage(scheme *runtime.Scheme, f *fuzz.Fuzzer, iterations int) (Coverage, error) {
+	var coverage Coverage
+	for gvk, t := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || strings.HasSuffix(gvk.Kind, "List") || t.PkgPath() == metav1PkgPath {
+			continue
+		}
+		obj, err := scheme.New(gvk)
+		if err != nil {
+			return Coverage{}, err
+		}
+		if !scheme.HasTypeDefaultingFunc(obj) {
+			coverage.Missing = append(coverage.Missing, gvk)
+			continue
+		}
+		modified := modifiedByDefaulting(scheme, obj)
+		for i := 0; i < iterations && !modified; i++ {
+			f.Fuzz(obj)
+			modified = modifiedByDefaulting(scheme, obj)
+		}
+		if !modified {
+			coverage.Ineffective = append(coverage.Ineffective, gvk)
+		}
+	}
+	sortKinds(coverage.Missing)
+	sortKinds(coverage.Ineffective)
+	return coverage, nil
+}
+
+// modifiedByDefaulting applies the defaulting function of the type of obj to obj,
+// and returns true if it modified obj.
+func modifiedByDefaulting(scheme *runtime.Scheme, obj runtime.Object) bool {
+	original := obj.DeepCopyObject()
+	scheme.Default(obj)
+	return !apiequality.Semantic.DeepEqual(original, obj)
+}
+
+func sortKinds(kinds []schema.GroupVersionKind) {
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+}
+
+// VerifyDefaultingCoverage fails the test for each external kind of scheme which
+// has no defaulting function, or whose defaulting function appears to do nothing,
+// as reported by DefaultingCoverage. Objects are fuzzed with the given funcs on top
+// of the fuzzer funcs of the meta types. Kinds which are not expected to be
+// defaulted can be excluded with exceptions.
+func VerifyDefaultingCoverage(t *testing.T, scheme *runtime.Scheme, fuzzingFuncs fuzzer.FuzzerFuncs, exceptions map[schema.GroupVersionKind]bool) {
+	t.Helper()
+	f := fuzzer.FuzzerFor(
+		fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzingFuncs),
+		rand.NewSource(rand.Int63()),
+		runtimeserializer.NewCodecFactory(scheme),
+	)
+	coverage, err := DefaultingCoverage(scheme, f, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gvk := range coverage.Missing {
+		if !exceptions[gvk] {
+			t.Errorf("%v has no defaulting function registered, is RegisterDefaults called for its group version?", gvk)
+		}
+	}
+	for _, gvk := range coverage.Ineffective {
+		if !exceptions[gvk] {
+			t.Errorf("the defaulting function of %v did not modify any zero or fuzzed object", gvk)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaulting
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type testObject struct {
+	metav1.TypeMeta `json:",inline"`
+	Value           string `json:"value,omitempty"`
+}
+
+func (o *testObject) DeepCopyObject() runtime.Object {
+	out := *o
+	return &out
+}
+
+type defaultedObject struct{ testObject }
+type noopDefaultedObject struct{ testObject }
+type undefaultedObject struct{ testObject }
+
+func (o *defaultedObject) DeepCopyObject() runtime.Object {
+	out := *o
+	return &out
+}
+
+func (o *noopDefaultedObject) DeepCopyObject() runtime.Object {
+	out := *o
+	return &out
+}
+
+func (o *undefaultedObject) DeepCopyObject() runtime.Object {
+	out := *o
+	return &out
+}
+
+func TestDefaultingCoverage(t *testing.T) {
+	gv := schema.GroupVersion{Group: "test.group", Version: "v1"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gv, &defaultedObject{}, &noopDefaultedObject{}, &undefaultedObject{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: "test.group", Version: runtime.APIVersionInternal}, &undefaultedObject{})
+	metav1.AddToGroupVersion(scheme, gv)
+	scheme.AddTypeDefaultingFunc(&defaultedObject{}, func(obj interface{}) {
+		if o := obj.(*defaultedObject); o.Value == "" {
+			o.Value = "default"
+		}
+	})
+	scheme.AddTypeDefaultingFunc(&noopDefaultedObject{}, func(obj interface{}) {})
+
+	coverage, err := DefaultingCoverage(scheme, fuzz.New().RandSource(rand.NewSource(1)), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Coverage{
+		Missing:     []schema.GroupVersionKind{gv.WithKind("undefaultedObject")},
+		Ineffective: []schema.GroupVersionKind{gv.WithKind("noopDefaultedObject")},
+	}
+	if !reflect.DeepEqual(expected, coverage) {
+		t.Errorf("expected %v, got %v", expected, coverage)
+	}
+
+	VerifyDefaultingCoverage(t, scheme, nil, map[schema.GroupVersionKind]bool{
+		gv.WithKind("undefaultedObject"):   true,
+		gv.WithKind("noopDefaultedObject"): true,
+	})
+}
@@ -128,38 +128,9 @@ func (c *CompatibilityTestOptions) Complete(t *testing.T) *CompatibilityTestOpti
 
 	// Populate kinds
 	if len(c.Kinds) == 0 {
-		gvks := []schema.GroupVersionKind{}
-		for gvk := range c.Scheme.AllKnownTypes() {
-			if gvk.Version == "" || gvk.Version == runtime.APIVersionInternal {
-				// only test external types
-				continue
-			}
-			if strings.HasSuffix(gvk.Kind, "List") {
-				// omit list types
-				continue
-			}
-			if gvk.Group != "" && coreKinds.Has(gvk.Kind) {
-				// only test options types in the core API group
-				continue
-			}
-			gvks = append(gvks, gvk)
-		}
-		c.Kinds = gvks
+		c.Kinds = compatibilityKinds(c.Scheme)
 	}
-
-	// Sort kinds to get deterministic test order
-	sort.Slice(c.Kinds, func(i, j int) bool {
-		if c.Kinds[i].Group != c.Kinds[j].Group {
-			return c.Kinds[i].Group < c.Kinds[j].Group
-		}
-		if c.Kinds[i].Version != c.Kinds[j].Version {
-			return c.Kinds[i].Version < c.Kinds[j].Version
-		}
-		if c.Kinds[i].Kind != c.Kinds[j].Kind {
-			return c.Kinds[i].Kind < c.Kinds[j].Kind
-		}
-		return false
-	})
+	sortKinds(c.Kinds)
 
 	// Fill any missing objects
 	if c.FilledObjects == nil {
@@ -193,6 +164,44 @@ func (c *CompatibilityTestOptions) Complete(t *testing.T) *CompatibilityTestOpti
 	return c
 }
 
+// compatibilityKinds returns the external kinds of scheme which are tested by
+// default.
+func compatibilityKinds(scheme *runtime.Scheme) []schema.GroupVersionKind {
+	gvks := []schema.GroupVersionKind{}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == "" || gvk.Version == runtime.APIVersionInternal {
+			// only test external types
+			continue
+		}
+		if strings.HasSuffix(gvk.Kind, "List") {
+			// omit list types
+			continue
+		}
+		if gvk.Group != "" && coreKinds.Has(gvk.Kind) {
+			// only test options types in the core API group
+			continue
+		}
+		gvks = append(gvks, gvk)
+	}
+	return gvks
+}
+
+// sortKinds sorts kinds to get deterministic test order.
+func sortKinds(kinds []schema.GroupVersionKind) {
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		if kinds[i].Version != kinds[j].Version {
+			return kinds[i].Version < kinds[j].Version
+		}
+		if kinds[i].Kind != kinds[j].Kind {
+			return kinds[i].Kind < kinds[j].Kind
+		}
+		return false
+	})
+}
+
 func (c *CompatibilityTestOptions) Run(t *testing.T) {
 	usedHEADFixtures := sets.NewString()
 
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+type microTimeObject struct {
+	metav1.TypeMeta `json:",inline"`
+	Event           metav1.MicroTime   `json:"event"`
+	Renewed         *metav1.MicroTime  `json:"renewed,omitempty"`
+	Series          []metav1.MicroTime `json:"series"`
+	Observed        metav1.Time        `json:"observed"`
+}
+
+func (o *microTimeObject) DeepCopyObject() runtime.Object {
+	out := *o
+	if o.Renewed != nil {
+		renewed := *o.Renewed
+		out.Renewed = &renewed
+	}
+	out.Series = append([]metav1.MicroTime(nil), o.Series...)
+	return &out
+}
+
+// TestRoundTripMicroTime ensures that all serializers preserve the microsecond
+// precision of MicroTime, with and without epoch-based CBOR timestamps.
+func TestRoundTripMicroTime(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "microTimeObject"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gvk.GroupVersion(), &microTimeObject{})
+
+	serializers := map[string]runtime.Serializer{
+		"json":                 json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{}),
+		"yaml":                 json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true}),
+		"cbor":                 cbor.NewSerializer(scheme, scheme),
+		"cbor epoch":           cbor.NewSerializer(scheme, scheme, cbor.EpochTimestamps(true), cbor.EpochMicroTimestamps(true)),
+		"cbor epoch microtime": cbor.NewSerializer(scheme, scheme, cbor.EpochMicroTimestamps(true)),
+	}
+	f := fuzz.New().NilChance(.2).NumElements(0, 3).Funcs(func(t *metav1.Time, c fuzz.Continue) {
+		// Time has second precision
+		*t = metav1.Unix(c.Int63n(1000*365*24*60*60), 0)
+	})
+	for i := 0; i < 50; i++ {
+		object := &microTimeObject{}
+		f.Fuzz(object)
+		object.GetObjectKind().SetGroupVersionKind(gvk)
+		for name, serializer := range serializers {
+			t.Run(name, func(t *testing.T) {
+				roundTrip(t, scheme, serializer, object)
+			})
+		}
+	}
+}
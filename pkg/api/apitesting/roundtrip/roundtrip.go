@@ -19,6 +19,7 @@ package roundtrip
 import (
 	"bytes"
 	"encoding/hex"
+	gojson "encoding/json"
 	"math/rand"
 	"reflect"
 	"strings"
@@ -29,12 +30,14 @@ import (
 	"github.com/google/go-cmp/cmp"
 	fuzz "github.com/google/gofuzz"
 	flag "github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
 
 	apitesting "k8s.io/apimachinery/pkg/api/apitesting"
 	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
@@ -88,6 +91,12 @@ func RoundTripProtobufTestForScheme(t *testing.T, scheme *runtime.Scheme, fuzzin
 
 var FuzzIters = flag.Int("fuzz-iters", defaultFuzzIters, "How many fuzzing iterations to do.")
 
+// RoundTripYAMLAndLists enables additional round trips of objects through YAML,
+// and embedded in the items of JSON and YAML Lists. It is disabled by default,
+// since types that do not survive the conversion between JSON and YAML would
+// otherwise start failing.
+var RoundTripYAMLAndLists = flag.Bool("roundtrip-yaml-and-lists", false, "Also round trip objects through YAML and embedded in Lists.")
+
 // globalNonRoundTrippableTypes are kinds that are effectively reserved across all GroupVersions
 // They don't roundtrip
 var globalNonRoundTrippableTypes = sets.NewString(
@@ -253,7 +262,13 @@ func roundTripToAllExternalVersions(t *testing.T, scheme *runtime.Scheme, codecF
 		}
 		t.Logf("\tround tripping to %v %v", externalGVK, externalGoType)
 
-		roundTrip(t, scheme, apitesting.TestCodec(codecFactory, externalGVK.GroupVersion()), object)
+		codec := apitesting.TestCodec(codecFactory, externalGVK.GroupVersion())
+		roundTrip(t, scheme, codec, object)
+		if *RoundTripYAMLAndLists {
+			yamlSerializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true})
+			yamlCodec := codecFactory.CodecForVersions(yamlSerializer, yamlSerializer, externalGVK.GroupVersion(), nil)
+			roundTripYAMLAndLists(t, scheme, codec, yamlCodec, object)
+		}
 
 		// TODO remove this hack after we're past the intermediate steps
 		if !skipProtobuf && externalGVK.Group != "kubeadm.k8s.io" {
@@ -279,7 +294,12 @@ func roundTripOfExternalType(t *testing.T, scheme *runtime.Scheme, codecFactory
 	typeAcc.SetKind(externalGVK.Kind)
 	typeAcc.SetAPIVersion(externalGVK.GroupVersion().String())
 
-	roundTrip(t, scheme, json.NewSerializer(json.DefaultMetaFactory, scheme, scheme, false), object)
+	jsonSerializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+	roundTrip(t, scheme, jsonSerializer, object)
+	if *RoundTripYAMLAndLists {
+		yamlSerializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true})
+		roundTripYAMLAndLists(t, scheme, jsonSerializer, yamlSerializer, object)
+	}
 
 	// TODO remove this hack after we're past the intermediate steps
 	if !skipProtobuf {
@@ -299,6 +319,37 @@ func roundTripOfExternalType(t *testing.T, scheme *runtime.Scheme, codecFactory
 //
 //	external -> json/protobuf -> external.
 func roundTrip(t *testing.T, scheme *runtime.Scheme, codec runtime.Codec, object runtime.Object) {
+	roundTripWithEquality(t, scheme, codec, object, apiequality.Semantic)
+}
+
+// deepEqualer compares objects, like apiequality.Semantic.
+type deepEqualer interface {
+	DeepEqual(a, b interface{}) bool
+}
+
+// yamlEquality compares objects like apiequality.Semantic, except for raw
+// extensions holding JSON, which are equal if their JSON values are: their
+// bytes are re-encoded when converted to and from YAML.
+var yamlEquality = apiequality.SemanticWithOptions(apiequality.Options{
+	EqualityFuncs: []interface{}{
+		func(a, b runtime.RawExtension) bool {
+			if !apiequality.Semantic.DeepEqual(a.Object, b.Object) {
+				return false
+			}
+			if bytes.Equal(a.Raw, b.Raw) {
+				return true
+			}
+			var aValue, bValue interface{}
+			if gojson.Unmarshal(a.Raw, &aValue) != nil || gojson.Unmarshal(b.Raw, &bValue) != nil {
+				return false
+			}
+			return reflect.DeepEqual(aValue, bValue)
+		},
+	},
+})
+
+// roundTripWithEquality is roundTrip, comparing objects with equality.
+func roundTripWithEquality(t *testing.T, scheme *runtime.Scheme, codec runtime.Codec, object runtime.Object, equality deepEqualer) {
 	original := object
 
 	// deep copy the original object
@@ -356,7 +407,7 @@ func roundTrip(t *testing.T, scheme *runtime.Scheme, codec runtime.Codec, object
 
 	// ensure that the object produced from decoding the encoded data is equal
 	// to the original object
-	if !apiequality.Semantic.DeepEqual(original, obj2) {
+	if !equality.DeepEqual(original, obj2) {
 		t.Errorf("%v: diff: %v\nCodec: %#v\nSource:\n\n%#v\n\nEncoded:\n\n%s\n\nFinal:\n\n%#v", name, cmp.Diff(original, obj2), codec, dump.Pretty(original), dataAsString(data), dump.Pretty(obj2))
 		return
 	}
@@ -394,7 +445,7 @@ func roundTrip(t *testing.T, scheme *runtime.Scheme, codec runtime.Codec, object
 
 	// ensure that the new runtime object is equal to the original after being
 	// decoded into
-	if !apiequality.Semantic.DeepEqual(object, obj3) {
+	if !equality.DeepEqual(object, obj3) {
 		t.Errorf("%v: diff: %v\nCodec: %#v", name, cmp.Diff(object, obj3), codec)
 		return
 	}
@@ -403,12 +454,76 @@ func roundTrip(t *testing.T, scheme *runtime.Scheme, codec runtime.Codec, object
 	// the deep-copy was actually only a shallow copy. Then original and obj3 will be different after fuzzing.
 	// NOTE: we use the encoding+decoding here as an alternative, guaranteed deep-copy to compare against.
 	fuzzer.ValueFuzz(object)
-	if !apiequality.Semantic.DeepEqual(original, obj3) {
+	if !equality.DeepEqual(original, obj3) {
 		t.Errorf("%v: fuzzing a copy altered the original, diff: %v", name, cmp.Diff(original, obj3))
 		return
 	}
 }
 
+// roundTripYAMLAndLists round trips object through YAML with yamlCodec, and
+// embedded in Lists with the items encoded by jsonCodec and yamlCodec. Since
+// the Lists are converted between JSON and YAML, objects are compared with
+// yamlEquality.
+func roundTripYAMLAndLists(t *testing.T, scheme *runtime.Scheme, jsonCodec, yamlCodec runtime.Codec, object runtime.Object) {
+	roundTripWithEquality(t, scheme, yamlCodec, object, yamlEquality)
+	roundTripInLists(t, jsonCodec, object, yamlEquality)
+	roundTripInLists(t, yamlCodec, object, yamlEquality)
+}
+
+// roundTripInLists ensures that an object can be embedded in the items of a
+// List, as clients like kubectl do, and back without loss of data, with the
+// item encoded by the given text codec, and the List encoded both to JSON and
+// YAML. Objects are compared with equality.
+func roundTripInLists(t *testing.T, codec runtime.Codec, object runtime.Object, equality deepEqualer) {
+	name := reflect.TypeOf(object).Elem().Name()
+
+	data, err := runtime.Encode(codec, object.DeepCopyObject())
+	if err != nil {
+		if !runtime.IsNotRegisteredError(err) {
+			t.Errorf("%v: %v (%s)", name, err, dump.Pretty(object))
+		}
+		return
+	}
+	// items are embedded as JSON, whatever the format of the List
+	item, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		t.Errorf("%v: item cannot be converted to JSON: %v\nData: %s", name, err, dataAsString(data))
+		return
+	}
+
+	for _, listFormat := range []string{"json", "yaml"} {
+		list, err := gojson.Marshal(&metav1.List{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+			Items:    []runtime.RawExtension{{Raw: item}},
+		})
+		if err == nil && listFormat == "yaml" {
+			list, err = yaml.JSONToYAML(list)
+		}
+		if err != nil {
+			t.Errorf("%v: %s list cannot be encoded: %v", name, listFormat, err)
+			continue
+		}
+
+		var decodedList metav1.List
+		if err := yaml.Unmarshal(list, &decodedList); err != nil {
+			t.Errorf("%v: %s list cannot be decoded: %v\nList:\n\n%s", name, listFormat, err, list)
+			continue
+		}
+		if len(decodedList.Items) != 1 {
+			t.Errorf("%v: %s list has %d items, expected 1\nList:\n\n%s", name, listFormat, len(decodedList.Items), list)
+			continue
+		}
+		obj, err := runtime.Decode(codec, decodedList.Items[0].Raw)
+		if err != nil {
+			t.Errorf("%v: item of %s list cannot be decoded: %v\nList:\n\n%s", name, listFormat, err, list)
+			continue
+		}
+		if !equality.DeepEqual(object, obj) {
+			t.Errorf("%v: item of %s list differs, diff: %v\nCodec: %#v\nList:\n\n%s", name, listFormat, cmp.Diff(object, obj), codec, list)
+		}
+	}
+}
+
 func internalAndExternalKind(scheme *runtime.Scheme, object runtime.Object) (bool, error) {
 	kinds, _, err := scheme.ObjectKinds(object)
 	if err != nil {
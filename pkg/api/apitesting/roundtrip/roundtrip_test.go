@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+type listItemObject struct {
+	metav1.TypeMeta `json:",inline"`
+	Name            string            `json:"name"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Values          []int64           `json:"values"`
+	Created         metav1.Time       `json:"created"`
+}
+
+func (o *listItemObject) DeepCopyObject() runtime.Object {
+	out := *o
+	if o.Labels != nil {
+		out.Labels = make(map[string]string, len(o.Labels))
+		for k, v := range o.Labels {
+			out.Labels[k] = v
+		}
+	}
+	out.Values = append([]int64(nil), o.Values...)
+	return &out
+}
+
+// TestRoundTripYAMLAndLists ensures that objects round trip through YAML and
+// embedded in Lists once RoundTripYAMLAndLists is set.
+func TestRoundTripYAMLAndLists(t *testing.T) {
+	defer func(enabled bool) { *RoundTripYAMLAndLists = enabled }(*RoundTripYAMLAndLists)
+	*RoundTripYAMLAndLists = true
+
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "listItemObject"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gvk.GroupVersion(), &listItemObject{})
+	f := fuzz.New().NilChance(.2).NumElements(0, 3).Funcs(func(t *metav1.Time, c fuzz.Continue) {
+		// Time has second precision
+		*t = metav1.Unix(c.Int63n(1000*365*24*60*60), 0)
+	})
+	for i := 0; i < 20; i++ {
+		roundTripOfExternalType(t, scheme, runtimeserializer.NewCodecFactory(scheme), f, gvk, true)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	gojson "encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FieldShape describes the serialized form of a field of an API type.
+type FieldShape struct {
+	// Type is the JSON type of the field: "object", "list" and "map" for
+	// structs, slices and maps whose content is described by further fields,
+	// the Go type for types with custom marshaling such as "v1.Time", "bytes"
+	// for byte slices, and the kind of the Go type otherwise, such as "string"
+	// or "int32".
+	Type string
+	// OmitEmpty is true if the field is omitted from the JSON encoding when empty.
+	OmitEmpty bool
+}
+
+func (s FieldShape) String() string {
+	if s.OmitEmpty {
+		return s.Type + " omitempty"
+	}
+	return s.Type
+}
+
+// TypeShape returns the shape of the JSON encoding of values of type t, by
+// JSON path of each field. Path elements are JSON field names separated by
+// dots, with "[]" denoting the items of lists and "{}" the values of maps, as
+// in "spec.containers[].ports[].containerPort". Pointers are treated as the
+// type they point to, and types which recursively contain themselves are
+// described down to their first recursion.
+func TypeShape(t reflect.Type) map[string]FieldShape {
+	shape := map[string]FieldShape{}
+	addTypeShape(shape, "", t, false, nil)
+	return shape
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*gojson.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func addTypeShape(shape map[string]FieldShape, path string, t reflect.Type, omitEmpty bool, parents []reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	set := func(typ string) {
+		if path != "" {
+			shape[path] = FieldShape{Type: typ, OmitEmpty: omitEmpty}
+		}
+	}
+	ptr := reflect.PointerTo(t)
+	if t.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || ptr.Implements(textMarshalerType) {
+		set(t.String())
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		set("object")
+		if containsType(parents, t) {
+			return
+		}
+		parents = append(parents, t)
+		for i := 0; i < t.NumField(); i++ {
+			addFieldShape(shape, path, t.Field(i), parents)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			set("bytes")
+			return
+		}
+		set("list")
+		addTypeShape(shape, path+"[]", t.Elem(), false, parents)
+	case reflect.Map:
+		set("map")
+		addTypeShape(shape, path+"{}", t.Elem(), false, parents)
+	default:
+		set(t.Kind().String())
+	}
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, other := range types {
+		if other == t {
+			return true
+		}
+	}
+	return false
+}
+
+func addFieldShape(shape map[string]FieldShape, path string, field reflect.StructField, parents []reflect.Type) {
+	if !field.IsExported() && !field.Anonymous {
+		return
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" && field.Anonymous {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			// embedded structs are inlined, unless they marshal themselves
+			if !reflect.PointerTo(fieldType).Implements(jsonMarshalerType) {
+				addTypeShape(shape, path, fieldType, false, parents)
+				return
+			}
+		}
+	}
+	if !field.IsExported() {
+		return
+	}
+	if name == "" {
+		name = field.Name
+	}
+	if path != "" {
+		name = path + "." + name
+	}
+	addTypeShape(shape, name, field.Type, strings.Contains(","+opts+",", ",omitempty,"), parents)
+}
+
+// ShapeTestOptions holds configuration for checking that the serialized shape
+// of the types of a scheme stays compatible with a fixture recorded earlier.
+//
+// The shape of each kind, as returned by TypeShape, is compared with the
+// fixture `<TestDataDir>/<group>.<version>.<kind>.shape`. Fields which were
+// removed or changed their type are reported as breaking changes. Fields which
+// were added or changed omitempty are reported as an outdated fixture. In both
+// cases the fixture can be updated by re-running the test with
+// UPDATE_COMPATIBILITY_FIXTURE_DATA=true.
+//
+// Example use: `NewShapeTestOptions(scheme).Complete(t).Run(t)`
+type ShapeTestOptions struct {
+	// Scheme is used to create new objects of the tested kinds.
+	// Required.
+	Scheme *runtime.Scheme
+
+	// TestDataDir points to a directory containing the shape fixtures.
+	// Complete() populates this with "testdata/shapes" if unset.
+	TestDataDir string
+
+	// Kinds is a list of fully qualified kinds to test.
+	// Complete() populates this with the same kinds as CompatibilityTestOptions if unset.
+	Kinds []schema.GroupVersionKind
+}
+
+func NewShapeTestOptions(scheme *runtime.Scheme) *ShapeTestOptions {
+	return &ShapeTestOptions{Scheme: scheme}
+}
+
+func (c *ShapeTestOptions) Complete(t *testing.T) *ShapeTestOptions {
+	t.Helper()
+
+	if c.Scheme == nil {
+		t.Fatal("scheme is required")
+	}
+	if c.TestDataDir == "" {
+		c.TestDataDir = filepath.Join("testdata", "shapes")
+	}
+	if len(c.Kinds) == 0 {
+		c.Kinds = compatibilityKinds(c.Scheme)
+	}
+	sortKinds(c.Kinds)
+	return c
+}
+
+func (c *ShapeTestOptions) Run(t *testing.T) {
+	for _, gvk := range c.Kinds {
+		t.Run(makeName(gvk), func(t *testing.T) {
+			c.runShapeTest(t, gvk)
+		})
+	}
+}
+
+func (c *ShapeTestOptions) runShapeTest(t *testing.T, gvk schema.GroupVersionKind) {
+	obj, err := c.Scheme.New(gvk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := TypeShape(reflect.TypeOf(obj))
+
+	file := filepath.Join(c.TestDataDir, makeName(gvk)+".shape")
+	data, err := os.ReadFile(file)
+	needsUpdate := false
+	switch {
+	case os.IsNotExist(err):
+		t.Errorf("shape fixture %s did not exist", file)
+		needsUpdate = true
+	case err != nil:
+		t.Fatal(err)
+	default:
+		recorded, err := parseShape(data)
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", file, err)
+		}
+		breaking, compatible := compareShapes(recorded, current)
+		if len(breaking) > 0 {
+			t.Errorf("breaking changes to the serialized form of %v:\n%s", gvk, strings.Join(breaking, "\n"))
+		}
+		if len(compatible) > 0 {
+			t.Errorf("shape fixture %s is out of date:\n%s", file, strings.Join(compatible, "\n"))
+		}
+		needsUpdate = len(breaking) > 0 || len(compatible) > 0
+	}
+
+	if needsUpdate {
+		const updateEnvVar = "UPDATE_COMPATIBILITY_FIXTURE_DATA"
+		if os.Getenv(updateEnvVar) == "true" {
+			writeFile(t, c.TestDataDir, gvk, "", "shape", formatShape(current))
+			t.Logf("wrote expected shape data... verify, commit, and rerun tests")
+		} else {
+			t.Logf("if the change is intended, re-run with %s=true to update the shape data", updateEnvVar)
+		}
+	}
+}
+
+// compareShapes returns the breaking and the compatible differences from the
+// recorded to the current shape, sorted by path.
+func compareShapes(recorded, current map[string]FieldShape) (breaking, compatible []string) {
+	for _, path := range sortedPaths(recorded) {
+		was := recorded[path]
+		is, ok := current[path]
+		switch {
+		case !ok:
+			breaking = append(breaking, fmt.Sprintf("%s: removed", path))
+		case was.Type != is.Type:
+			breaking = append(breaking, fmt.Sprintf("%s: type changed from %s to %s", path, was.Type, is.Type))
+		case was.OmitEmpty != is.OmitEmpty:
+			compatible = append(compatible, fmt.Sprintf("%s: changed from %s to %s", path, was, is))
+		}
+	}
+	for _, path := range sortedPaths(current) {
+		if _, ok := recorded[path]; !ok {
+			compatible = append(compatible, fmt.Sprintf("%s: added", path))
+		}
+	}
+	return breaking, compatible
+}
+
+func sortedPaths(shape map[string]FieldShape) []string {
+	paths := make([]string, 0, len(shape))
+	for path := range shape {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// formatShape formats a shape as lines of "<path> <type>[ omitempty]", sorted
+// by path.
+func formatShape(shape map[string]FieldShape) []byte {
+	var buf bytes.Buffer
+	for _, path := range sortedPaths(shape) {
+		fmt.Fprintf(&buf, "%s %s\n", path, shape[path])
+	}
+	return buf.Bytes()
+}
+
+func parseShape(data []byte) (map[string]FieldShape, error) {
+	shape := map[string]FieldShape{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) == 2:
+			shape[fields[0]] = FieldShape{Type: fields[1]}
+		case len(fields) == 3 && fields[2] == "omitempty":
+			shape[fields[0]] = FieldShape{Type: fields[1], OmitEmpty: true}
+		default:
+			return nil, fmt.Errorf("line %d: expected \"<path> <type>[ omitempty]\", got %q", line, scanner.Text())
+		}
+	}
+	return shape, scanner.Err()
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type shapeTestItem struct {
+	Name     string            `json:"name"`
+	Data     []byte            `json:"data,omitempty"`
+	Children []*shapeTestItem  `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	ignored  string
+	Skipped  string `json:"-"`
+}
+
+type shapeTestObject struct {
+	metav1.TypeMeta `json:",inline"`
+	Spec            shapeTestSpec `json:"spec"`
+}
+
+type shapeTestSpec struct {
+	Created  metav1.Time     `json:"created,omitempty"`
+	Replicas *int32          `json:"replicas,omitempty"`
+	Items    []shapeTestItem `json:"items"`
+	Untagged bool
+}
+
+func (o *shapeTestObject) DeepCopyObject() runtime.Object {
+	panic("not implemented")
+}
+
+func TestTypeShape(t *testing.T) {
+	expected := map[string]FieldShape{
+		"apiVersion":              {Type: "string", OmitEmpty: true},
+		"kind":                    {Type: "string", OmitEmpty: true},
+		"spec":                    {Type: "object"},
+		"spec.created":            {Type: "v1.Time", OmitEmpty: true},
+		"spec.replicas":           {Type: "int32", OmitEmpty: true},
+		"spec.items":              {Type: "list"},
+		"spec.items[]":            {Type: "object"},
+		"spec.items[].name":       {Type: "string"},
+		"spec.items[].data":       {Type: "bytes", OmitEmpty: true},
+		"spec.items[].children":   {Type: "list", OmitEmpty: true},
+		"spec.items[].children[]": {Type: "object"},
+		"spec.items[].labels":     {Type: "map", OmitEmpty: true},
+		"spec.items[].labels{}":   {Type: "string"},
+		"spec.Untagged":           {Type: "bool"},
+	}
+	if diff := cmp.Diff(expected, TypeShape(reflect.TypeOf(&shapeTestObject{}))); diff != "" {
+		t.Errorf("unexpected shape (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareShapes(t *testing.T) {
+	recorded := map[string]FieldShape{
+		"a": {Type: "string"},
+		"b": {Type: "int32"},
+		"c": {Type: "bool"},
+		"d": {Type: "string", OmitEmpty: true},
+	}
+	current := map[string]FieldShape{
+		"a": {Type: "string"},
+		"b": {Type: "int64"},
+		"d": {Type: "string"},
+		"e": {Type: "object"},
+	}
+	breaking, compatible := compareShapes(recorded, current)
+	if expected := []string{"b: type changed from int32 to int64", "c: removed"}; !reflect.DeepEqual(expected, breaking) {
+		t.Errorf("expected breaking changes %q, got %q", expected, breaking)
+	}
+	if expected := []string{"d: changed from string omitempty to string", "e: added"}; !reflect.DeepEqual(expected, compatible) {
+		t.Errorf("expected compatible changes %q, got %q", expected, compatible)
+	}
+
+	parsed, err := parseShape(formatShape(current))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(current, parsed); diff != "" {
+		t.Errorf("unexpected shape after round trip (-want +got):\n%s", diff)
+	}
+}
+
+func TestShapeTestOptions(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "shape.test", Version: "v1", Kind: "ShapeTestObject"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &shapeTestObject{})
+
+	dir := t.TempDir()
+	shape := TypeShape(reflect.TypeOf(&shapeTestObject{}))
+	if err := os.WriteFile(filepath.Join(dir, "shape.test.v1.ShapeTestObject.shape"), formatShape(shape), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := NewShapeTestOptions(scheme)
+	opts.TestDataDir = dir
+	opts.Complete(t).Run(t)
+
+	if !strings.Contains(string(formatShape(shape)), "spec.created v1.Time omitempty\n") {
+		t.Errorf("unexpected fixture format:\n%s", formatShape(shape))
+	}
+}
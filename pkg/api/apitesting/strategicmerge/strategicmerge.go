@@ -0,0 +1,257 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package strategicmerge provides a test harness checking the invariants of
+// strategic merge patches on fuzzed objects of a scheme.
+package strategicmerge
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/apitesting/roundtrip"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// PatchMetaFunc returns the patch metadata for objects of a kind.
+type PatchMetaFunc func(gvk schema.GroupVersionKind, obj runtime.Object) (strategicpatch.LookupPatchMeta, error)
+
+// PatchMetaFromStruct is a PatchMetaFunc reading the patch metadata from the
+// struct tags of the Go type of the object.
+func PatchMetaFromStruct(_ schema.GroupVersionKind, obj runtime.Object) (strategicpatch.LookupPatchMeta, error) {
+	return strategicpatch.NewPatchMetaFromStruct(obj)
+}
+
+// Options configures the strategic merge patch invariant tests.
+type Options struct {
+	// PatchMeta returns the patch metadata used to create and apply patches.
+	// It defaults to PatchMetaFromStruct, and can be set to read the patch
+	// metadata from an OpenAPI schema instead.
+	PatchMeta PatchMetaFunc
+	// SkipKinds are kinds which are not tested.
+	SkipKinds map[schema.GroupVersionKind]bool
+	// Iterations is the number of fuzzed object pairs tested per kind. It
+	// defaults to the value of the fuzz-iters flag.
+	Iterations int
+}
+
+// StrategicMergePatchTestForScheme checks the strategic merge patch invariants
+// for all external kinds of the scheme, fuzzing objects with the given funcs on
+// top of the fuzzer funcs of the meta types.
+func StrategicMergePatchTestForScheme(t *testing.T, scheme *runtime.Scheme, fuzzingFuncs fuzzer.FuzzerFuncs, opts Options) {
+	f := fuzzer.FuzzerFor(
+		fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzingFuncs),
+		rand.NewSource(rand.Int63()),
+		runtimeserializer.NewCodecFactory(scheme),
+	)
+	nonRoundTrippableTypes := roundtrip.GlobalNonRoundTrippableTypes()
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || nonRoundTrippableTypes.Has(gvk.Kind) || opts.SkipKinds[gvk] {
+			continue
+		}
+		t.Run(gvk.Group+"."+gvk.Version+"."+gvk.Kind, func(t *testing.T) {
+			StrategicMergePatchTestForKind(t, scheme, gvk, f, opts)
+		})
+	}
+}
+
+// StrategicMergePatchTestForKind checks the strategic merge patch invariants
+// for pairs of fuzzed objects of the given kind:
+//
+//   - applying the two-way patch from an original to a modified object yields
+//     the modified object,
+//   - applying the three-way patch from an original to a modified object onto
+//     either of them yields the modified object, and
+//   - applying any of these patches again does not change the result.
+//
+// Objects are compared after decoding them from JSON, with semantic equality.
+// Fuzzed objects are adjusted to meet the preconditions of strategic merge
+// patches before the patches are created: items with duplicate or missing
+// merge keys are dropped from lists merged by key, and duplicate values are
+// dropped from lists of primitives with the merge strategy.
+func StrategicMergePatchTestForKind(t *testing.T, scheme *runtime.Scheme, gvk schema.GroupVersionKind, f *fuzz.Fuzzer, opts Options) {
+	patchMetaFunc := opts.PatchMeta
+	if patchMetaFunc == nil {
+		patchMetaFunc = PatchMetaFromStruct
+	}
+	iterations := opts.Iterations
+	if iterations == 0 {
+		iterations = *roundtrip.FuzzIters
+	}
+
+	for i := 0; i < iterations && !t.Failed(); i++ {
+		original, modified := fuzzObject(t, scheme, gvk, f), fuzzObject(t, scheme, gvk, f)
+		patchMeta, err := patchMetaFunc(gvk, original)
+		if err != nil {
+			t.Fatalf("Failed to get patch metadata for %v: %v", gvk, err)
+		}
+		originalJSON := normalizedJSON(t, original, patchMeta)
+		modifiedJSON := normalizedJSON(t, modified, patchMeta)
+		objType := reflect.TypeOf(original).Elem()
+
+		patch, err := strategicpatch.CreateTwoWayMergePatchUsingLookupPatchMeta(originalJSON, modifiedJSON, patchMeta)
+		if err != nil {
+			t.Fatalf("Failed to create two-way patch: %v\noriginal: %s\nmodified: %s", err, originalJSON, modifiedJSON)
+		}
+		checkPatch(t, "two-way", objType, patchMeta, originalJSON, patch, modifiedJSON)
+
+		for _, current := range []struct {
+			name string
+			data []byte
+		}{{"original", originalJSON}, {"modified", modifiedJSON}} {
+			patch, err := strategicpatch.CreateThreeWayMergePatch(originalJSON, modifiedJSON, current.data, patchMeta, true)
+			if err != nil {
+				t.Fatalf("Failed to create three-way patch onto the %s object: %v\noriginal: %s\nmodified: %s", current.name, err, originalJSON, modifiedJSON)
+			}
+			checkPatch(t, "three-way onto "+current.name, objType, patchMeta, current.data, patch, modifiedJSON)
+		}
+	}
+}
+
+// checkPatch applies patch to data and checks that the result is expected, and
+// that applying the patch to the result again does not change it.
+func checkPatch(t *testing.T, name string, objType reflect.Type, patchMeta strategicpatch.LookupPatchMeta, data, patch, expected []byte) {
+	t.Helper()
+	patched, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(data, patch, patchMeta)
+	if err != nil {
+		t.Errorf("Failed to apply %s patch: %v\npatch: %s\nobject: %s", name, err, patch, data)
+		return
+	}
+	if diff := semanticDiff(t, objType, expected, patched); diff != "" {
+		t.Errorf("Applying %s patch did not yield the modified object:\n%s\npatch: %s\nobject: %s", name, diff, patch, data)
+		return
+	}
+	repatched, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(patched, patch, patchMeta)
+	if err != nil {
+		t.Errorf("Failed to re-apply %s patch: %v\npatch: %s\nobject: %s", name, err, patch, patched)
+		return
+	}
+	if diff := semanticDiff(t, objType, patched, repatched); diff != "" {
+		t.Errorf("Re-applying %s patch changed the object:\n%s\npatch: %s\nobject: %s", name, diff, patch, patched)
+	}
+}
+
+func fuzzObject(t *testing.T, scheme *runtime.Scheme, gvk schema.GroupVersionKind, f *fuzz.Fuzzer) runtime.Object {
+	obj, err := scheme.New(gvk)
+	if err != nil {
+		t.Fatalf("Couldn't make a %v? %v", gvk, err)
+	}
+	f.Fuzz(obj)
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return obj
+}
+
+// normalizedJSON returns the JSON encoding of obj, adjusted to meet the
+// preconditions of strategic merge patches.
+func normalizedJSON(t *testing.T, obj runtime.Object, patchMeta strategicpatch.LookupPatchMeta) []byte {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("Failed to marshal %#v: %v", obj, err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", data, err)
+	}
+	normalizeMap(m, patchMeta)
+	if data, err = json.Marshal(m); err != nil {
+		t.Fatalf("Failed to marshal %#v: %v", m, err)
+	}
+	return data
+}
+
+func normalizeMap(m map[string]interface{}, patchMeta strategicpatch.LookupPatchMeta) {
+	for key, value := range m {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if sub, _, err := patchMeta.LookupPatchMetadataForStruct(key); err == nil {
+				normalizeMap(value, sub)
+			}
+		case []interface{}:
+			sub, meta, err := patchMeta.LookupPatchMetadataForSlice(key)
+			if err != nil {
+				continue
+			}
+			m[key] = normalizeList(value, sub, meta)
+		}
+	}
+}
+
+func normalizeList(list []interface{}, patchMeta strategicpatch.LookupPatchMeta, meta strategicpatch.PatchMeta) []interface{} {
+	merge := false
+	for _, strategy := range meta.GetPatchStrategies() {
+		merge = merge || strategy == "merge"
+	}
+	mergeKey := meta.GetPatchMergeKey()
+	seen := map[interface{}]bool{}
+	var out []interface{}
+	for _, item := range list {
+		if item, ok := item.(map[string]interface{}); ok {
+			if patchMeta != nil {
+				normalizeMap(item, patchMeta)
+			}
+			if merge && mergeKey != "" {
+				key, ok := item[mergeKey]
+				if !ok || !isPrimitive(key) || seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+		} else if merge && isPrimitive(item) {
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+		}
+		out = append(out, item)
+	}
+	if out == nil {
+		out = []interface{}{}
+	}
+	return out
+}
+
+func isPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case string, float64, bool:
+		return true
+	}
+	return false
+}
+
+// semanticDiff decodes the JSON documents a and b into objects of type objType
+// and returns their differences, or "" if they are semantically equal.
+func semanticDiff(t *testing.T, objType reflect.Type, a, b []byte) string {
+	t.Helper()
+	objA, objB := reflect.New(objType).Interface(), reflect.New(objType).Interface()
+	if err := json.Unmarshal(a, objA); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, objB); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", b, err)
+	}
+	return diff.SemanticDiff(apiequality.Semantic.Equalities, objA, objB, diff.SemanticOptions{})
+}
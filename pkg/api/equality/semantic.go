@@ -17,6 +17,9 @@ limitations under the License.
 package equality
 
 import (
+	"math"
+	"time"
+
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/conversion"
@@ -26,6 +29,10 @@ import (
 
 // Semantic can do semantic deep equality checks for api objects.
 // Example: apiequality.Semantic.DeepEqual(aPod, aPodWithNonNilButEmptyMaps) == true
+//
+// Semantic.Diff returns the fields which differ between two objects, with
+// the same semantics.
+// Example: for _, d := range apiequality.Semantic.Diff(oldPod, newPod) { klog.Info(d.String()) }
 var Semantic = conversion.EqualitiesOrDie(
 	func(a, b resource.Quantity) bool {
 		// Ignore formatting, only care that numeric value stayed the same.
@@ -47,3 +54,93 @@ var Semantic = conversion.EqualitiesOrDie(
 		return a.String() == b.String()
 	},
 )
+
+// Options are the options of SemanticWithOptions.
+type Options struct {
+	// IgnorePaths are the field paths which are not compared, along with
+	// everything below them, such as "metadata.resourceVersion" or
+	// "status.conditions[*].lastTransitionTime". Paths use the JSON names of
+	// fields, with list indexes and map keys in brackets, and "*" matching any
+	// element.
+	IgnorePaths []string
+	// QuantityTolerance is the relative tolerance within which quantities are
+	// equal, e.g. 0.01 for quantities differing by at most 1% of the larger
+	// one. By default, quantities are equal if their values are equal.
+	QuantityTolerance float64
+	// TimeTolerance is the duration within which times and micro times are
+	// equal. By default, times are equal if they are the same instant.
+	TimeTolerance time.Duration
+	// EqualityFuncs are additional equality funcs, of the form
+	// func(a, b T) bool, which take precedence over those of Semantic for T
+	// and over the tolerances.
+	EqualityFuncs []interface{}
+}
+
+// SemanticEquality compares objects like Semantic, with the options given to
+// SemanticWithOptions.
+type SemanticEquality struct {
+	equalities  conversion.Equalities
+	ignorePaths []string
+}
+
+// SemanticWithOptions returns a SemanticEquality comparing objects like
+// Semantic, with the given options. Semantic itself is not modified. It panics
+// if an equality func is invalid.
+// Example: apiequality.SemanticWithOptions(apiequality.Options{IgnorePaths: []string{"metadata.resourceVersion"}}).DeepEqual(desired, actual)
+func SemanticWithOptions(opts Options) SemanticEquality {
+	equalities := Semantic.Copy()
+	if opts.QuantityTolerance > 0 {
+		tolerance := opts.QuantityTolerance
+		if err := equalities.AddFunc(func(a, b resource.Quantity) bool {
+			if a.Cmp(b) == 0 {
+				return true
+			}
+			fa, fb := a.AsApproximateFloat64(), b.AsApproximateFloat64()
+			return math.Abs(fa-fb) <= tolerance*math.Max(math.Abs(fa), math.Abs(fb))
+		}); err != nil {
+			panic(err)
+		}
+	}
+	if opts.TimeTolerance > 0 {
+		tolerance := opts.TimeTolerance
+		withinTolerance := func(a, b time.Time) bool {
+			d := a.Sub(b)
+			return d <= tolerance && d >= -tolerance
+		}
+		if err := equalities.AddFuncs(
+			func(a, b metav1.Time) bool {
+				return withinTolerance(a.Time, b.Time)
+			},
+			func(a, b metav1.MicroTime) bool {
+				return withinTolerance(a.Time, b.Time)
+			},
+		); err != nil {
+			panic(err)
+		}
+	}
+	if err := equalities.AddFuncs(opts.EqualityFuncs...); err != nil {
+		panic(err)
+	}
+	return SemanticEquality{
+		equalities:  equalities,
+		ignorePaths: append([]string(nil), opts.IgnorePaths...),
+	}
+}
+
+// DeepEqual returns true if a and b are semantically equal, ignoring the
+// differences at the ignored paths. Like Semantic.DeepEqual, nil and empty
+// slices and maps are equal.
+func (s SemanticEquality) DeepEqual(a, b interface{}) bool {
+	return s.equalities.DeepEqualIgnoringPaths(a, b, s.ignorePaths)
+}
+
+// FieldDifference is a difference between two objects, found by Semantic.Diff
+// or SemanticEquality.Diff.
+type FieldDifference = conversion.FieldDifference
+
+// Diff returns the differences between a and b at the deepest differing
+// fields, ignoring the differences at the ignored paths, or nil if they are
+// semantically equal.
+func (s SemanticEquality) Diff(a, b interface{}) []FieldDifference {
+	return s.equalities.DiffIgnoringPaths(a, b, s.ignorePaths)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package equality
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Memory            resource.Quantity  `json:"memory"`
+	Conditions        []metav1.Condition `json:"conditions,omitempty"`
+}
+
+func TestSemanticWithOptions(t *testing.T) {
+	now := metav1.Now()
+	desired := &testObject{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "1"},
+		Memory:     resource.MustParse("1Gi"),
+		Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: now}},
+	}
+	actual := copyTestObject(desired)
+	actual.ResourceVersion = "2"
+	actual.Memory = resource.MustParse("1024Mi")
+	actual.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(time.Minute))
+
+	if Semantic.DeepEqual(desired, actual) {
+		t.Fatal("expected objects to differ without options")
+	}
+	equality := SemanticWithOptions(Options{IgnorePaths: []string{"metadata.resourceVersion", "conditions[*].lastTransitionTime"}})
+	if !equality.DeepEqual(desired, actual) {
+		t.Error("expected objects to be equal ignoring paths")
+	}
+
+	actual.Name = "b"
+	if equality.DeepEqual(desired, actual) {
+		t.Error("expected objects with different names to differ")
+	}
+	caseInsensitive := SemanticWithOptions(Options{
+		IgnorePaths: []string{"metadata.resourceVersion", "conditions[*].lastTransitionTime"},
+		EqualityFuncs: []interface{}{func(a, b string) bool {
+			return strings.EqualFold(a, b)
+		}},
+	})
+	actual.Name = "A"
+	if !caseInsensitive.DeepEqual(desired, actual) {
+		t.Error("expected additional equality funcs to be used")
+	}
+	if Semantic.DeepEqual("a", "A") {
+		t.Error("expected Semantic not to be modified")
+	}
+}
+
+func TestSemanticWithTolerances(t *testing.T) {
+	now := metav1.Now()
+	desired := &testObject{
+		Memory:     resource.MustParse("1000Mi"),
+		Conditions: []metav1.Condition{{Type: "Ready", LastTransitionTime: now}},
+	}
+	actual := copyTestObject(desired)
+	actual.Memory = resource.MustParse("1001Mi")
+	actual.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Second))
+
+	if Semantic.DeepEqual(desired, actual) {
+		t.Fatal("expected objects to differ without tolerances")
+	}
+	if !SemanticWithOptions(Options{QuantityTolerance: 0.01, TimeTolerance: time.Second}).DeepEqual(desired, actual) {
+		t.Error("expected objects to be equal within tolerances")
+	}
+	if SemanticWithOptions(Options{QuantityTolerance: 0.0001, TimeTolerance: time.Second}).DeepEqual(desired, actual) {
+		t.Error("expected quantities to differ beyond the tolerance")
+	}
+	if SemanticWithOptions(Options{QuantityTolerance: 0.01, TimeTolerance: time.Millisecond}).DeepEqual(desired, actual) {
+		t.Error("expected times to differ beyond the tolerance")
+	}
+
+	equality := SemanticWithOptions(Options{QuantityTolerance: 0.01, TimeTolerance: time.Second})
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{"1000m", "1", true},
+		{"0", "0", true},
+		{"0", "1m", false},
+		{"-100", "-100.5", true},
+		{"-100", "100", false},
+		{"1Ei", "1.005Ei", true},
+	} {
+		if equal := equality.DeepEqual(resource.MustParse(tc.a), resource.MustParse(tc.b)); equal != tc.equal {
+			t.Errorf("%s and %s: expected equal=%t", tc.a, tc.b, tc.equal)
+		}
+	}
+	micro := metav1.NewMicroTime(now.Time)
+	if !equality.DeepEqual(micro, metav1.NewMicroTime(now.Add(500*time.Millisecond))) {
+		t.Error("expected micro times to be equal within the tolerance")
+	}
+	if equality.DeepEqual(micro, metav1.NewMicroTime(now.Add(2*time.Second))) {
+		t.Error("expected micro times to differ beyond the tolerance")
+	}
+}
+
+func copyTestObject(o *testObject) *testObject {
+	out := *o
+	o.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Memory = o.Memory.DeepCopy()
+	out.Conditions = make([]metav1.Condition, len(o.Conditions))
+	for i := range o.Conditions {
+		o.Conditions[i].DeepCopyInto(&out.Conditions[i])
+	}
+	return &out
+}
+
+func TestSemanticDiff(t *testing.T) {
+	a := &testObject{ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "1"}, Memory: resource.MustParse("1Gi")}
+	b := &testObject{ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "2", Labels: map[string]string{}}, Memory: resource.MustParse("2Gi")}
+
+	diffs := Semantic.Diff(a, b)
+	if len(diffs) != 2 || diffs[0].Path != "metadata.resourceVersion" || diffs[1].Path != "memory" {
+		t.Fatalf("unexpected differences: %v", diffs)
+	}
+	if s := diffs[1].String(); s != "memory: 1Gi -> 2Gi" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	diffs = SemanticWithOptions(Options{IgnorePaths: []string{"metadata.resourceVersion"}}).Diff(a, b)
+	if len(diffs) != 1 || diffs[0].Path != "memory" {
+		t.Errorf("unexpected differences: %v", diffs)
+	}
+	b.Memory = resource.MustParse("1024Mi")
+	b.ResourceVersion = "1"
+	if diffs := Semantic.Diff(a, b); diffs != nil {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+}
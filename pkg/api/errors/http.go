@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxStatusBodyBytes limits the size of the response bodies read by
+// FromHTTPResponse.
+const maxStatusBodyBytes = 1 << 20
+
+// WriteHTTPResponse writes err to w as a Status, for servers which do not use
+// the API server libraries. Errors which do not carry a Status, see APIStatus,
+// are written as internal errors. The response has the status code of the
+// Status, a Retry-After header if the Status suggests a delay, and is encoded
+// with the serializer of negotiator which best matches the Accept header of
+// req, falling back to JSON, so that clients receive errors in the format
+// they accept, e.g. CBOR. Nothing is written if the Status cannot be encoded.
+func WriteHTTPResponse(w http.ResponseWriter, req *http.Request, negotiator runtime.NegotiatedSerializer, err error) error {
+	var status metav1.Status
+	if apiStatus, ok := err.(APIStatus); ok || errors.As(err, &apiStatus) {
+		status = apiStatus.Status()
+	} else {
+		status = NewInternalError(err).Status()
+	}
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+	if status.Code == 0 {
+		status.Code = http.StatusInternalServerError
+	}
+
+	info, ok := negotiateSerializer(req.Header.Get("Accept"), negotiator.SupportedMediaTypes())
+	if !ok {
+		return fmt.Errorf("no serializer is available to encode the error")
+	}
+	var buf bytes.Buffer
+	if err := info.Serializer.Encode(&status, &buf); err != nil {
+		return err
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", info.MediaType)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if status.Details != nil && status.Details.RetryAfterSeconds > 0 {
+		header.Set("Retry-After", strconv.Itoa(int(status.Details.RetryAfterSeconds)))
+	}
+	w.WriteHeader(int(status.Code))
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// negotiateSerializer returns the serializer in supported best matching the
+// media types of accept, by decreasing quality, JSON or the first serializer
+// if none matches.
+func negotiateSerializer(accept string, supported []runtime.SerializerInfo) (runtime.SerializerInfo, bool) {
+	type accepted struct {
+		mediaType string
+		quality   float64
+	}
+	var acceptedTypes []accepted
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality <= 0 {
+				continue
+			}
+		}
+		acceptedTypes = append(acceptedTypes, accepted{mediaType: mediaType, quality: quality})
+	}
+	sort.SliceStable(acceptedTypes, func(i, j int) bool {
+		return acceptedTypes[i].quality > acceptedTypes[j].quality
+	})
+
+	for _, a := range acceptedTypes {
+		if a.mediaType == "*/*" {
+			break
+		}
+		for _, info := range supported {
+			if info.MediaType == a.mediaType || a.mediaType == info.MediaTypeType+"/*" {
+				return info, true
+			}
+		}
+	}
+	if info, ok := runtime.SerializerInfoForMediaType(supported, runtime.ContentTypeJSON); ok {
+		return info, true
+	}
+	if len(supported) > 0 {
+		return supported[0], true
+	}
+	return runtime.SerializerInfo{}, false
+}
+
+// FromHTTPResponse returns the error described by resp, for clients which do
+// not use client-go. The body of resp, which is read but not closed, is
+// decoded with the serializer of negotiator matching its Content-Type. A
+// failure Status in the body is returned as it is, with the status code of
+// resp if it has none. Other unsuccessful responses result in errors based on
+// their status code, like NewGenericServerResponse, with the body as the
+// message. The errors are *StatusError values, which APIStatus and the Is
+// functions of this package recognize. Returns nil for successful responses
+// which do not hold a failure Status.
+func FromHTTPResponse(resp *http.Response, negotiator runtime.NegotiatedSerializer) error {
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxStatusBodyBytes))
+	successful := resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+
+	if readErr == nil && len(body) > 0 {
+		if statusErr := decodeStatus(body, resp.Header.Get("Content-Type"), negotiator); statusErr != nil {
+			if statusErr.ErrStatus.Code == 0 {
+				statusErr.ErrStatus.Code = int32(resp.StatusCode)
+			}
+			if successful && statusErr.ErrStatus.Status != metav1.StatusFailure {
+				return nil
+			}
+			return statusErr
+		}
+	}
+	if successful {
+		return nil
+	}
+
+	verb := ""
+	if resp.Request != nil {
+		verb = resp.Request.Method
+	}
+	retryAfterSeconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	message := strings.TrimSpace(string(body))
+	if readErr != nil {
+		message = readErr.Error()
+	}
+	return NewGenericServerResponse(resp.StatusCode, verb, schema.GroupResource{}, "", message, retryAfterSeconds, true)
+}
+
+// decodeStatus decodes the Status in body, or returns nil if body is not a
+// Status encoded in a media type supported by negotiator.
+func decodeStatus(body []byte, contentType string, negotiator runtime.NegotiatedSerializer) *StatusError {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	info, ok := runtime.SerializerInfoForMediaType(negotiator.SupportedMediaTypes(), mediaType)
+	if !ok || info.MediaType != mediaType {
+		return nil
+	}
+	obj, _, err := info.Serializer.Decode(body, nil, &unstructured.Unstructured{})
+	if err != nil {
+		return nil
+	}
+	statusErr, _ := FromObject(obj).(*StatusError)
+	return statusErr
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+)
+
+func TestHTTPResponseRoundTrip(t *testing.T) {
+	codecs := serializer.NewCodecFactory(runtime.NewScheme(), serializer.WithSerializer(cbor.NewSerializerInfo))
+	notFound := NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web")
+
+	testCases := []struct {
+		name                string
+		accept              string
+		err                 error
+		expectedContentType string
+		expectedCode        int
+		expectedReason      metav1.StatusReason
+	}{
+		{
+			name:                "json by default",
+			err:                 notFound,
+			expectedContentType: "application/json",
+			expectedCode:        http.StatusNotFound,
+			expectedReason:      metav1.StatusReasonNotFound,
+		},
+		{
+			name:                "cbor",
+			accept:              "application/cbor",
+			err:                 notFound,
+			expectedContentType: "application/cbor",
+			expectedCode:        http.StatusNotFound,
+			expectedReason:      metav1.StatusReasonNotFound,
+		},
+		{
+			name:                "by quality",
+			accept:              "application/json;q=0.5, application/cbor;q=0.9, application/yaml;q=0",
+			err:                 notFound,
+			expectedContentType: "application/cbor",
+			expectedCode:        http.StatusNotFound,
+			expectedReason:      metav1.StatusReasonNotFound,
+		},
+		{
+			name:                "unsupported falls back to json",
+			accept:              "text/html",
+			err:                 NewTooManyRequests("slow down", 5),
+			expectedContentType: "application/json",
+			expectedCode:        http.StatusTooManyRequests,
+			expectedReason:      metav1.StatusReasonTooManyRequests,
+		},
+		{
+			name:                "internal error",
+			accept:              "*/*",
+			err:                 io.ErrUnexpectedEOF,
+			expectedContentType: "application/json",
+			expectedCode:        http.StatusInternalServerError,
+			expectedReason:      metav1.StatusReasonInternalError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/apis/apps/v1/deployments/web", nil)
+			if len(tc.accept) > 0 {
+				req.Header.Set("Accept", tc.accept)
+			}
+			recorder := httptest.NewRecorder()
+			if err := WriteHTTPResponse(recorder, req, codecs, tc.err); err != nil {
+				t.Fatal(err)
+			}
+			resp := recorder.Result()
+			if resp.StatusCode != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("expected content type %s, got %s", tc.expectedContentType, contentType)
+			}
+
+			resp.Request = req
+			var statusErr *StatusError
+			if err := FromHTTPResponse(resp, codecs); !errors.As(err, &statusErr) {
+				t.Fatalf("expected a StatusError, got %v", err)
+			}
+			if statusErr.ErrStatus.Reason != tc.expectedReason || int(statusErr.ErrStatus.Code) != tc.expectedCode {
+				t.Errorf("unexpected status %#v", statusErr.ErrStatus)
+			}
+			if delay, ok := SuggestsClientDelay(tc.err); ok {
+				if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "5" || delay != 5 {
+					t.Errorf("expected a Retry-After header of 5, got %q", retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	codecs := serializer.NewCodecFactory(runtime.NewScheme())
+	newResponse := func(code int, contentType, body string) *http.Response {
+		return &http.Response{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": []string{contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    &http.Request{Method: http.MethodPost},
+		}
+	}
+
+	if err := FromHTTPResponse(newResponse(http.StatusOK, "application/json", `{"kind":"Status","apiVersion":"v1","status":"Success"}`), codecs); err != nil {
+		t.Errorf("expected no error for a success Status, got %v", err)
+	}
+	if err := FromHTTPResponse(newResponse(http.StatusOK, "application/json", `{"kind":"Deployment","apiVersion":"apps/v1"}`), codecs); err != nil {
+		t.Errorf("expected no error for a successful response, got %v", err)
+	}
+
+	err := FromHTTPResponse(newResponse(http.StatusConflict, "application/json", `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"AlreadyExists","message":"exists"}`), codecs)
+	if status := APIStatus(nil); !errors.As(err, &status) || !IsAlreadyExists(err) || status.Status().Code != http.StatusConflict || status.Status().Message != "exists" {
+		t.Errorf("expected the Status of the response with its status code, got %#v", err)
+	}
+
+	err = FromHTTPResponse(newResponse(http.StatusConflict, "text/plain", "already there\n"), codecs)
+	if !IsAlreadyExists(err) || !IsUnexpectedServerError(err) {
+		t.Errorf("expected an unexpected already exists error, got %#v", err)
+	}
+
+	resp := newResponse(http.StatusServiceUnavailable, "application/json", `{"not":"a status"}`)
+	resp.Header.Set("Retry-After", "3")
+	err = FromHTTPResponse(resp, codecs)
+	if delay, ok := SuggestsClientDelay(err); !IsServiceUnavailable(err) || !ok || delay != 3 {
+		t.Errorf("expected a service unavailable error with a delay, got %#v", err)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// WarningCode is the code of warnings rendered by Warnings.HeaderValues,
+// meaning a miscellaneous persistent warning.
+const WarningCode = 299
+
+// Warning is a non-fatal finding about a request or an object, e.g. the use of
+// a deprecated field, which is returned to clients in a Warning header.
+type Warning struct {
+	// Field is the path of the field the warning is about, if any.
+	Field string
+	// Message describes the finding.
+	Message string
+	// Origin identifies the rule or check which produced the warning, e.g. for
+	// metrics. It is not part of the rendered warning.
+	Origin string
+}
+
+// NewWarning returns a Warning about the field at fldPath, which may be nil.
+func NewWarning(fldPath *field.Path, origin, message string) Warning {
+	w := Warning{Message: message, Origin: origin}
+	if fldPath != nil {
+		w.Field = fldPath.String()
+	}
+	return w
+}
+
+// String renders the warning as "<field>: <message>", or as the message if it
+// is not about a field.
+func (w Warning) String() string {
+	if len(w.Field) == 0 {
+		return w.Message
+	}
+	return w.Field + ": " + w.Message
+}
+
+// Warnings is a list of warnings.
+type Warnings []Warning
+
+// WarningsFromFieldErrors returns warnings for errors found by validation which
+// are not treated as fatal, with the error type as origin.
+func WarningsFromFieldErrors(errs field.ErrorList) Warnings {
+	if len(errs) == 0 {
+		return nil
+	}
+	warnings := make(Warnings, 0, len(errs))
+	for _, err := range errs {
+		warnings = append(warnings, Warning{Field: err.Field, Message: err.ErrorBody(), Origin: string(err.Type)})
+	}
+	return warnings
+}
+
+// WarningsFromStrictDecodingError returns warnings for the violations of a
+// strict decoding error, e.g. unknown or duplicate fields, with origin
+// "StrictDecoding". nil is returned for other errors.
+func WarningsFromStrictDecodingError(err error) Warnings {
+	strictErr, ok := runtime.AsStrictDecodingError(err)
+	if !ok {
+		return nil
+	}
+	var warnings Warnings
+	for _, err := range strictErr.Errors() {
+		warnings = append(warnings, Warning{Message: err.Error(), Origin: "StrictDecoding"})
+	}
+	return warnings
+}
+
+// MergeWarnings returns the warnings of all lists, in order and without
+// duplicates.
+func MergeWarnings(lists ...Warnings) Warnings {
+	var merged Warnings
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	return merged.Deduplicate()
+}
+
+// Deduplicate returns the warnings without those rendering to the same text as
+// an earlier one, regardless of their origin.
+func (w Warnings) Deduplicate() Warnings {
+	if len(w) == 0 {
+		return w
+	}
+	seen := make(map[string]bool, len(w))
+	result := make(Warnings, 0, len(w))
+	for _, warning := range w {
+		text := warning.String()
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+		result = append(result, warning)
+	}
+	return result
+}
+
+// Limit returns at most maxCount warnings, with messages truncated so that the
+// rendered warnings are at most maxLength bytes long. If the field of a warning
+// leaves no room for its message, the field is truncated and the message
+// dropped. A maxCount or maxLength of 0 or less means no limit. The number of dropped warnings is returned, e.g.
+// to add a warning about it.
+func (w Warnings) Limit(maxCount, maxLength int) (Warnings, int) {
+	dropped := 0
+	if maxCount > 0 && len(w) > maxCount {
+		dropped = len(w) - maxCount
+		w = w[:maxCount]
+	}
+	if maxLength <= 0 {
+		return w, dropped
+	}
+	result := make(Warnings, len(w))
+	for i, warning := range w {
+		length := len(warning.String())
+		switch {
+		case length <= maxLength:
+		case length-len(warning.Message) <= maxLength:
+			warning.Message = truncate(warning.Message, len(warning.Message)-(length-maxLength))
+		default:
+			warning.Field = truncate(warning.Field, maxLength-len(": "))
+			warning.Message = ""
+			if len(warning.Field) == 0 {
+				// too short for a field, render the message instead
+				warning.Message = truncate(w[i].Message, maxLength)
+			}
+		}
+		result[i] = warning
+	}
+	return result, dropped
+}
+
+// truncate returns s cut to at most n bytes on a rune boundary, ending with
+// "..." if it is cut and n leaves room for it.
+func truncate(s string, n int) string {
+	const ellipsis = "..."
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	suffix := ""
+	if n > len(ellipsis) {
+		suffix = ellipsis
+		n -= len(ellipsis)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + suffix
+}
+
+// Strings returns the rendered warnings.
+func (w Warnings) Strings() []string {
+	if len(w) == 0 {
+		return nil
+	}
+	result := make([]string, len(w))
+	for i, warning := range w {
+		result[i] = warning.String()
+	}
+	return result
+}
+
+// HeaderValues returns the warnings as values of the HTTP Warning header, with
+// code WarningCode and the given agent, "-" if empty. Invalid UTF-8 and control
+// characters in the warnings are replaced with spaces. An error is returned if
+// agent is invalid.
+func (w Warnings) HeaderValues(agent string) ([]string, error) {
+	values := make([]string, 0, len(w))
+	for _, warning := range w {
+		value, err := utilnet.NewWarningHeader(WarningCode, agent, sanitizeWarningText(warning.String()))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func sanitizeWarningText(text string) string {
+	if utf8.ValidString(text) && strings.IndexFunc(text, unicode.IsControl) < 0 {
+		return text
+	}
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, strings.ToValidUTF8(text, " "))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestWarnings(t *testing.T) {
+	deprecated := NewWarning(field.NewPath("spec", "old"), "deprecation", "deprecated, use spec.new")
+	unknown := NewWarning(nil, "StrictDecoding", `unknown field "foo"`)
+	duplicate := NewWarning(field.NewPath("spec", "old"), "other", "deprecated, use spec.new")
+
+	if s := deprecated.String(); s != "spec.old: deprecated, use spec.new" {
+		t.Errorf("unexpected rendering %q", s)
+	}
+	if s := unknown.String(); s != `unknown field "foo"` {
+		t.Errorf("unexpected rendering %q", s)
+	}
+
+	merged := MergeWarnings(Warnings{deprecated, unknown}, Warnings{duplicate, unknown})
+	if expected := (Warnings{deprecated, unknown}); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+
+	limited, dropped := merged.Limit(1, 20)
+	if dropped != 1 || len(limited) != 1 {
+		t.Fatalf("expected 1 warning and 1 dropped, got %v, %d", limited, dropped)
+	}
+	if s := limited[0].String(); s != "spec.old: depreca..." {
+		t.Errorf("unexpected truncated warning %q", s)
+	}
+	if merged[0].Message != deprecated.Message {
+		t.Errorf("limiting modified the original warnings")
+	}
+	if unlimited, dropped := merged.Limit(0, 0); dropped != 0 || !reflect.DeepEqual(unlimited, merged) {
+		t.Errorf("expected no limit, got %v, %d", unlimited, dropped)
+	}
+	longField := Warning{Field: "spec.template.spec.containers[0].image", Message: "deprecated"}
+	for maxLength, expected := range map[int]string{20: "spec.template.s...: ", 2: "de"} {
+		limited, _ := Warnings{longField}.Limit(0, maxLength)
+		if s := limited[0].String(); s != expected || len(s) > maxLength {
+			t.Errorf("expected %q for a long field limited to %d bytes, got %q", expected, maxLength, s)
+		}
+	}
+	if s := truncate("aéb", 2); s != "a" {
+		t.Errorf("expected truncation on rune boundary, got %q", s)
+	}
+
+	values, err := Warnings{deprecated, NewWarning(nil, "", "bad\ttext\xff")}.HeaderValues("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, errs := utilnet.ParseWarningHeaders(values)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	expected := []utilnet.WarningHeader{
+		{Code: WarningCode, Agent: "-", Text: "spec.old: deprecated, use spec.new"},
+		{Code: WarningCode, Agent: "-", Text: "bad text "},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %v, got %v", expected, parsed)
+	}
+	if _, err := (Warnings{deprecated}).HeaderValues("bad agent"); err == nil {
+		t.Errorf("expected error for invalid agent")
+	}
+}
+
+func TestWarningsFromErrors(t *testing.T) {
+	fieldWarnings := WarningsFromFieldErrors(field.ErrorList{field.Required(field.NewPath("spec", "name"), "")})
+	if expected := (Warnings{{Field: "spec.name", Message: "Required value", Origin: string(field.ErrorTypeRequired)}}); !reflect.DeepEqual(fieldWarnings, expected) {
+		t.Errorf("expected %v, got %v", expected, fieldWarnings)
+	}
+
+	strictWarnings := WarningsFromStrictDecodingError(runtime.NewStrictDecodingError([]error{errors.New(`unknown field "foo"`)}))
+	if expected := (Warnings{{Message: `unknown field "foo"`, Origin: "StrictDecoding"}}); !reflect.DeepEqual(strictWarnings, expected) {
+		t.Errorf("expected %v, got %v", expected, strictWarnings)
+	}
+	if w := WarningsFromStrictDecodingError(errors.New("other")); w != nil {
+		t.Errorf("expected no warnings for other errors, got %v", w)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// FromWatchErrorEvent returns the error carried by a watch.Error event as a
+// StatusError, or nil if event is not an error event. Status objects, typed or
+// unstructured, are converted as they are; any other object results in an
+// internal error describing it.
+func FromWatchErrorEvent(event watch.Event) *StatusError {
+	if event.Type != watch.Error {
+		return nil
+	}
+	return statusErrorFromObject(event.Object)
+}
+
+func statusErrorFromObject(obj runtime.Object) *StatusError {
+	if obj == nil {
+		return NewInternalError(fmt.Errorf("watch error event without object"))
+	}
+	err := FromObject(obj)
+	if statusErr, ok := err.(*StatusError); ok {
+		return statusErr
+	}
+	return NewInternalError(err)
+}
+
+// ErrorTerminatingWatcher wraps a watch.Interface, passing its events on until
+// the first watch.Error event. On an error event, the wrapped watch is stopped,
+// the result channel is closed without passing the event on, and the error is
+// available from Err.
+type ErrorTerminatingWatcher struct {
+	incoming watch.Interface
+	result   chan watch.Event
+	done     chan struct{}
+	stopOnce sync.Once
+
+	lock sync.Mutex
+	err  *StatusError
+}
+
+var _ watch.Interface = &ErrorTerminatingWatcher{}
+
+// NewErrorTerminatingWatcher returns an ErrorTerminatingWatcher wrapping w.
+func NewErrorTerminatingWatcher(w watch.Interface) *ErrorTerminatingWatcher {
+	ew := &ErrorTerminatingWatcher{
+		incoming: w,
+		result:   make(chan watch.Event),
+		done:     make(chan struct{}),
+	}
+	go ew.loop()
+	return ew
+}
+
+// ResultChan returns a channel which receives the events of the wrapped watch
+// up to the first error event.
+func (ew *ErrorTerminatingWatcher) ResultChan() <-chan watch.Event {
+	return ew.result
+}
+
+// Stop stops the wrapped watch, which eventually closes the result channel.
+func (ew *ErrorTerminatingWatcher) Stop() {
+	ew.stopOnce.Do(func() {
+		close(ew.done)
+		ew.incoming.Stop()
+	})
+}
+
+// Err returns the error of the error event which terminated the watch, or nil
+// if the watch has not been terminated by an error event. Once the result
+// channel is closed, the returned value does not change anymore. If non-nil,
+// the error is a *StatusError.
+func (ew *ErrorTerminatingWatcher) Err() error {
+	ew.lock.Lock()
+	defer ew.lock.Unlock()
+	if ew.err == nil {
+		return nil
+	}
+	return ew.err
+}
+
+func (ew *ErrorTerminatingWatcher) loop() {
+	defer close(ew.result)
+	for {
+		select {
+		case event, ok := <-ew.incoming.ResultChan():
+			if !ok {
+				return
+			}
+			if err := FromWatchErrorEvent(event); err != nil {
+				ew.lock.Lock()
+				ew.err = err
+				ew.lock.Unlock()
+				ew.Stop()
+				return
+			}
+			select {
+			case ew.result <- event:
+			case <-ew.done:
+				return
+			}
+		case <-ew.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestFromWatchErrorEvent(t *testing.T) {
+	gone := NewResourceExpired("too old resource version")
+	unstructuredGone := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"status":     metav1.StatusFailure,
+		"code":       int64(http.StatusGone),
+		"reason":     string(metav1.StatusReasonExpired),
+		"message":    "too old resource version",
+	}}
+
+	if err := FromWatchErrorEvent(watch.Event{Type: watch.Added, Object: &gone.ErrStatus}); err != nil {
+		t.Errorf("expected no error for non-error event, got %v", err)
+	}
+	if err := FromWatchErrorEvent(watch.Event{Type: watch.Error, Object: &gone.ErrStatus}); !IsResourceExpired(err) || err.Error() != gone.Error() {
+		t.Errorf("expected %v, got %v", gone, err)
+	}
+	if err := FromWatchErrorEvent(watch.Event{Type: watch.Error, Object: unstructuredGone}); !IsResourceExpired(err) || err.Error() != gone.Error() {
+		t.Errorf("expected %v from unstructured status, got %v", gone, err)
+	}
+	if err := FromWatchErrorEvent(watch.Event{Type: watch.Error, Object: &metav1.List{}}); !IsInternalError(err) {
+		t.Errorf("expected internal error for unexpected object, got %v", err)
+	}
+	if err := FromWatchErrorEvent(watch.Event{Type: watch.Error}); !IsInternalError(err) {
+		t.Errorf("expected internal error for missing object, got %v", err)
+	}
+}
+
+func TestErrorTerminatingWatcher(t *testing.T) {
+	fake := watch.NewFake()
+	w := NewErrorTerminatingWatcher(fake)
+
+	obj := &metav1.Status{Message: "added"}
+	go func() {
+		fake.Add(obj)
+		fake.Error(&NewNotFound(schema.GroupResource{Resource: "pods"}, "foo").ErrStatus)
+	}()
+
+	event, ok := <-w.ResultChan()
+	if !ok || event.Type != watch.Added || event.Object != obj {
+		t.Fatalf("expected added event, got %#v, %v", event, ok)
+	}
+	if event, ok := <-w.ResultChan(); ok {
+		t.Fatalf("expected closed result channel, got %#v", event)
+	}
+	if err := w.Err(); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if !fake.IsStopped() {
+		t.Errorf("expected wrapped watch to be stopped")
+	}
+}
+
+func TestErrorTerminatingWatcherStop(t *testing.T) {
+	fake := watch.NewFake()
+	w := NewErrorTerminatingWatcher(fake)
+	w.Stop()
+	w.Stop()
+	if _, ok := <-w.ResultChan(); ok {
+		t.Fatalf("expected closed result channel")
+	}
+	if err := w.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if !fake.IsStopped() {
+		t.Errorf("expected wrapped watch to be stopped")
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionAggregationPolicy determines how the conditions of child objects
+// are combined into a condition of their parent.
+type ConditionAggregationPolicy string
+
+const (
+	// AggregateAll sets the parent condition to True if the condition of every
+	// child has the expected status, e.g. "AllReplicasReady".
+	AggregateAll ConditionAggregationPolicy = "All"
+	// AggregateAny sets the parent condition to True if the condition of at
+	// least one child has the expected status, e.g. "AnyDegraded".
+	AggregateAny ConditionAggregationPolicy = "Any"
+)
+
+const (
+	// ConditionReasonAggregated is the default reason of aggregated conditions
+	// whose status is not propagated from a child.
+	ConditionReasonAggregated = "Aggregated"
+	// ConditionReasonMissing is the reason of aggregated conditions which are
+	// Unknown because children do not report the condition.
+	ConditionReasonMissing = "ConditionMissing"
+
+	// maxAggregatedNames is the maximum number of child names listed in the
+	// message of an aggregated condition.
+	maxAggregatedNames = 3
+)
+
+// ChildConditions holds the conditions of a child object.
+type ChildConditions struct {
+	// Name identifies the child in the messages of aggregated conditions.
+	Name       string
+	Conditions []metav1.Condition
+}
+
+// ConditionAggregation describes a parent condition computed from a condition
+// of the children.
+type ConditionAggregation struct {
+	// Type is the type of the parent condition.
+	Type string
+	// ChildType is the type of the child condition which is aggregated.
+	ChildType string
+	// Status is the status of the child condition being counted. Defaults to
+	// True.
+	Status metav1.ConditionStatus
+	// Policy determines how the child conditions are combined.
+	Policy ConditionAggregationPolicy
+	// Reason is the reason of the parent condition when it is True under the
+	// AggregateAll policy, or False under the AggregateAny policy. Defaults to
+	// ConditionReasonAggregated. In all other cases the reason of the first
+	// child determining the status is propagated.
+	Reason string
+}
+
+// AggregateConditions computes the parent conditions described by aggregations
+// from the conditions of children. Children are considered in the order of
+// their names, so that the reasons and messages of the results are stable.
+//
+// Under the AggregateAll policy, the parent condition is False if the condition
+// of any child has a status other than the expected one and is not Unknown,
+// Unknown if any child reports an Unknown condition or none at all, and True
+// otherwise, including when there are no children. Under the AggregateAny
+// policy, the parent condition is True if the condition of any child has the
+// expected status, Unknown if any child reports an Unknown condition or none
+// at all, and False otherwise.
+//
+// The LastTransitionTime of the returned conditions is not set, so that they
+// can be applied with SetStatusCondition, see SetAggregatedConditions.
+func AggregateConditions(children []ChildConditions, aggregations ...ConditionAggregation) []metav1.Condition {
+	sorted := make([]ChildConditions, len(children))
+	copy(sorted, children)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	conditions := make([]metav1.Condition, 0, len(aggregations))
+	for _, aggregation := range aggregations {
+		conditions = append(conditions, aggregateCondition(sorted, aggregation))
+	}
+	return conditions
+}
+
+// SetAggregatedConditions computes the parent conditions described by
+// aggregations with AggregateConditions, and sets them in conditions with
+// observedGeneration. It returns true if the conditions are changed by this
+// call.
+func SetAggregatedConditions(conditions *[]metav1.Condition, observedGeneration int64, children []ChildConditions, aggregations ...ConditionAggregation) (changed bool) {
+	for _, condition := range AggregateConditions(children, aggregations...) {
+		condition.ObservedGeneration = observedGeneration
+		if SetStatusCondition(conditions, condition) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func aggregateCondition(children []ChildConditions, aggregation ConditionAggregation) metav1.Condition {
+	expected := aggregation.Status
+	if len(expected) == 0 {
+		expected = metav1.ConditionTrue
+	}
+	reason := aggregation.Reason
+	if len(reason) == 0 {
+		reason = ConditionReasonAggregated
+	}
+
+	// matching, mismatching and unknown hold the children by the status of
+	// their condition, with the first condition of each group. Children
+	// without the condition are unknown too, so firstUnknownName records the
+	// child which reports firstUnknown.
+	var matching, mismatching, unknown []string
+	var firstMatching, firstMismatching, firstUnknown *metav1.Condition
+	var firstUnknownName string
+	for i := range children {
+		condition := FindStatusCondition(children[i].Conditions, aggregation.ChildType)
+		switch {
+		case condition == nil || condition.Status == metav1.ConditionUnknown:
+			if firstUnknown == nil && condition != nil {
+				firstUnknown, firstUnknownName = condition, children[i].Name
+			}
+			unknown = append(unknown, children[i].Name)
+		case condition.Status == expected:
+			if firstMatching == nil {
+				firstMatching = condition
+			}
+			matching = append(matching, children[i].Name)
+		default:
+			if firstMismatching == nil {
+				firstMismatching = condition
+			}
+			mismatching = append(mismatching, children[i].Name)
+		}
+	}
+
+	result := metav1.Condition{Type: aggregation.Type}
+	switch aggregation.Policy {
+	case AggregateAny:
+		switch {
+		case len(matching) > 0:
+			result.Status = metav1.ConditionTrue
+			result.Reason, result.Message = propagate(firstMatching, matching[0], matching, len(children), aggregation.ChildType, expected)
+		case len(unknown) > 0:
+			result.Status = metav1.ConditionUnknown
+			result.Reason, result.Message = propagateUnknown(firstUnknown, firstUnknownName, unknown, len(children), aggregation.ChildType)
+		default:
+			result.Status = metav1.ConditionFalse
+			result.Reason = reason
+			result.Message = fmt.Sprintf("no children with %s=%s", aggregation.ChildType, expected)
+		}
+	default:
+		switch {
+		case len(mismatching) > 0:
+			result.Status = metav1.ConditionFalse
+			result.Reason, result.Message = propagate(firstMismatching, mismatching[0], mismatching, len(children), aggregation.ChildType, firstMismatching.Status)
+		case len(unknown) > 0:
+			result.Status = metav1.ConditionUnknown
+			result.Reason, result.Message = propagateUnknown(firstUnknown, firstUnknownName, unknown, len(children), aggregation.ChildType)
+		default:
+			result.Status = metav1.ConditionTrue
+			result.Reason = reason
+			result.Message = fmt.Sprintf("all %d children with %s=%s", len(children), aggregation.ChildType, expected)
+		}
+	}
+	return result
+}
+
+// propagate returns the reason of the first child determining the status of
+// an aggregated condition, and a message listing the children. firstName is
+// the name of the child reporting first; its message is prefixed with the
+// name unless it is the first child listed.
+func propagate(first *metav1.Condition, firstName string, names []string, total int, childType string, status metav1.ConditionStatus) (string, string) {
+	message := fmt.Sprintf("%d of %d children with %s=%s: %s", len(names), total, childType, status, joinNames(names))
+	if firstName != names[0] {
+		message += ": " + firstName
+	}
+	if len(first.Message) > 0 {
+		message += ": " + first.Message
+	}
+	reason := first.Reason
+	if len(reason) == 0 {
+		reason = ConditionReasonAggregated
+	}
+	return reason, message
+}
+
+// propagateUnknown is like propagate for Unknown conditions, and returns
+// ConditionReasonMissing if no child reports the condition.
+func propagateUnknown(first *metav1.Condition, firstName string, names []string, total int, childType string) (string, string) {
+	if first == nil {
+		return ConditionReasonMissing, fmt.Sprintf("%d of %d children without %s: %s", len(names), total, childType, joinNames(names))
+	}
+	return propagate(first, firstName, names, total, childType, metav1.ConditionUnknown)
+}
+
+func joinNames(names []string) string {
+	if len(names) <= maxAggregatedNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxAggregatedNames], ", "), len(names)-maxAggregatedNames)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregateConditions(t *testing.T) {
+	ready := func(name string, status metav1.ConditionStatus, reason, message string) ChildConditions {
+		return ChildConditions{Name: name, Conditions: []metav1.Condition{{Type: "Ready", Status: status, Reason: reason, Message: message}}}
+	}
+	allReady := ConditionAggregation{Type: "AllReplicasReady", ChildType: "Ready", Policy: AggregateAll, Reason: "ReplicasReady"}
+	anyDegraded := ConditionAggregation{Type: "AnyDegraded", ChildType: "Ready", Status: metav1.ConditionFalse, Policy: AggregateAny}
+
+	tests := []struct {
+		name        string
+		children    []ChildConditions
+		aggregation ConditionAggregation
+		expected    metav1.Condition
+	}{
+		{
+			name:        "all-true",
+			children:    []ChildConditions{ready("b", metav1.ConditionTrue, "Up", ""), ready("a", metav1.ConditionTrue, "Up", "")},
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionTrue, Reason: "ReplicasReady", Message: "all 2 children with Ready=True"},
+		},
+		{
+			name:        "all-no-children",
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionTrue, Reason: "ReplicasReady", Message: "all 0 children with Ready=True"},
+		},
+		{
+			name: "all-false-propagates-reason",
+			children: []ChildConditions{
+				ready("c", metav1.ConditionFalse, "CrashLoop", "back-off"),
+				ready("a", metav1.ConditionTrue, "Up", ""),
+				ready("b", metav1.ConditionFalse, "ImagePull", "not found"),
+				{Name: "d"},
+			},
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionFalse, Reason: "ImagePull", Message: "2 of 4 children with Ready=False: b, c: not found"},
+		},
+		{
+			name:        "all-missing",
+			children:    []ChildConditions{ready("a", metav1.ConditionTrue, "Up", ""), {Name: "b"}},
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionUnknown, Reason: ConditionReasonMissing, Message: "1 of 2 children without Ready: b"},
+		},
+		{
+			name: "any-true",
+			children: []ChildConditions{
+				ready("a", metav1.ConditionTrue, "Up", ""),
+				ready("b", metav1.ConditionFalse, "CrashLoop", ""),
+				ready("c", metav1.ConditionUnknown, "Pending", ""),
+			},
+			aggregation: anyDegraded,
+			expected:    metav1.Condition{Type: "AnyDegraded", Status: metav1.ConditionTrue, Reason: "CrashLoop", Message: "1 of 3 children with Ready=False: b"},
+		},
+		{
+			name:        "any-unknown",
+			children:    []ChildConditions{ready("a", metav1.ConditionTrue, "Up", ""), ready("b", metav1.ConditionUnknown, "Pending", "scheduling")},
+			aggregation: anyDegraded,
+			expected:    metav1.Condition{Type: "AnyDegraded", Status: metav1.ConditionUnknown, Reason: "Pending", Message: "1 of 2 children with Ready=Unknown: b: scheduling"},
+		},
+		{
+			name: "unknown-after-missing",
+			children: []ChildConditions{
+				{Name: "a"},
+				ready("b", metav1.ConditionUnknown, "Pending", "scheduling"),
+				ready("c", metav1.ConditionTrue, "Up", ""),
+			},
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionUnknown, Reason: "Pending", Message: "2 of 3 children with Ready=Unknown: a, b: b: scheduling"},
+		},
+		{
+			name:        "any-false",
+			children:    []ChildConditions{ready("a", metav1.ConditionTrue, "Up", "")},
+			aggregation: anyDegraded,
+			expected:    metav1.Condition{Type: "AnyDegraded", Status: metav1.ConditionFalse, Reason: ConditionReasonAggregated, Message: "no children with Ready=False"},
+		},
+		{
+			name: "many-names",
+			children: []ChildConditions{
+				ready("a", metav1.ConditionFalse, "Down", ""),
+				ready("b", metav1.ConditionFalse, "Down", ""),
+				ready("c", metav1.ConditionFalse, "Down", ""),
+				ready("d", metav1.ConditionFalse, "Down", ""),
+				ready("e", metav1.ConditionFalse, "Down", ""),
+			},
+			aggregation: allReady,
+			expected:    metav1.Condition{Type: "AllReplicasReady", Status: metav1.ConditionFalse, Reason: "Down", Message: "5 of 5 children with Ready=False: a, b, c and 2 more"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := AggregateConditions(test.children, test.aggregation)
+			if len(actual) != 1 || !reflect.DeepEqual(actual[0], test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetAggregatedConditions(t *testing.T) {
+	children := []ChildConditions{{Name: "a", Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Up"}}}}
+	aggregation := ConditionAggregation{Type: "AllReplicasReady", ChildType: "Ready", Policy: AggregateAll}
+
+	var conditions []metav1.Condition
+	if !SetAggregatedConditions(&conditions, 3, children, aggregation) {
+		t.Errorf("expected the conditions to change")
+	}
+	condition := FindStatusCondition(conditions, "AllReplicasReady")
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 3 || condition.LastTransitionTime.IsZero() {
+		t.Errorf("unexpected condition %#v", condition)
+	}
+	if SetAggregatedConditions(&conditions, 3, children, aggregation) {
+		t.Errorf("expected the conditions not to change")
+	}
+}
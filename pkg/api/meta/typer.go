@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// typedObjectTyperName is the scheme name reported by errors of a
+// TypedObjectTyper.
+const typedObjectTyperName = "TypedObjectTyper"
+
+// TypedObjectTyper is an ObjectTyper and ObjectCreater for a fixed set of Go
+// types bound to their kinds with AddTypedKind, for tools like webhooks and
+// converters which handle a few types and do not need the conversion and
+// defaulting of a runtime.Scheme. Unstructured objects are typed by the kind
+// they declare. It is safe for concurrent use.
+type TypedObjectTyper struct {
+	lock  sync.RWMutex
+	kinds map[reflect.Type][]schema.GroupVersionKind
+	types map[schema.GroupVersionKind]func() runtime.Object
+}
+
+var _ runtime.ObjectTyper = &TypedObjectTyper{}
+var _ runtime.ObjectCreater = &TypedObjectTyper{}
+
+// NewTypedObjectTyper returns a TypedObjectTyper without any kinds.
+func NewTypedObjectTyper() *TypedObjectTyper {
+	return &TypedObjectTyper{
+		kinds: map[reflect.Type][]schema.GroupVersionKind{},
+		types: map[schema.GroupVersionKind]func() runtime.Object{},
+	}
+}
+
+// AddTypedKind binds the Go type T to gvk in typer, so that objects of type
+// *T are typed as gvk, and New(gvk) returns a new *T. A type may be bound to
+// several kinds, the first one being reported first by ObjectKinds. An error
+// is returned if gvk is already bound to another type.
+func AddTypedKind[T any, PT interface {
+	*T
+	runtime.Object
+}](typer *TypedObjectTyper, gvk schema.GroupVersionKind) error {
+	if len(gvk.Version) == 0 || len(gvk.Kind) == 0 {
+		return fmt.Errorf("version and kind are required, got %v", gvk)
+	}
+	t := reflect.TypeOf(PT(nil))
+
+	typer.lock.Lock()
+	defer typer.lock.Unlock()
+	if _, exists := typer.types[gvk]; exists {
+		for _, existing := range typer.kinds[t] {
+			if existing == gvk {
+				return nil
+			}
+		}
+		return fmt.Errorf("%v is already bound to another type than %v", gvk, t)
+	}
+	typer.types[gvk] = func() runtime.Object {
+		return PT(new(T))
+	}
+	typer.kinds[t] = append(typer.kinds[t], gvk)
+	return nil
+}
+
+// MustAddTypedKind is like AddTypedKind, but panics on errors.
+func MustAddTypedKind[T any, PT interface {
+	*T
+	runtime.Object
+}](typer *TypedObjectTyper, gvk schema.GroupVersionKind) {
+	if err := AddTypedKind[T, PT](typer, gvk); err != nil {
+		panic(err)
+	}
+}
+
+// ObjectKinds returns the kinds bound to the type of obj, or the kind declared
+// by unstructured objects. The returned bool is always false, since
+// TypedObjectTyper has no unversioned types.
+func (typer *TypedObjectTyper) ObjectKinds(obj runtime.Object) ([]schema.GroupVersionKind, bool, error) {
+	if _, ok := obj.(runtime.Unstructured); ok {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if len(gvk.Kind) == 0 {
+			return nil, false, runtime.NewMissingKindErr("unstructured object has no kind")
+		}
+		if len(gvk.Version) == 0 {
+			return nil, false, runtime.NewMissingVersionErr("unstructured object has no version")
+		}
+		return []schema.GroupVersionKind{gvk}, false, nil
+	}
+
+	t := reflect.TypeOf(obj)
+	typer.lock.RLock()
+	defer typer.lock.RUnlock()
+	kinds, ok := typer.kinds[t]
+	if !ok {
+		return nil, false, runtime.NewNotRegisteredErrForType(typedObjectTyperName, t)
+	}
+	return append([]schema.GroupVersionKind(nil), kinds...), false, nil
+}
+
+// Recognizes returns true if gvk is bound to a type.
+func (typer *TypedObjectTyper) Recognizes(gvk schema.GroupVersionKind) bool {
+	typer.lock.RLock()
+	defer typer.lock.RUnlock()
+	_, ok := typer.types[gvk]
+	return ok
+}
+
+// New returns a new object of the type bound to gvk.
+func (typer *TypedObjectTyper) New(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	typer.lock.RLock()
+	newFunc, ok := typer.types[gvk]
+	typer.lock.RUnlock()
+	if !ok {
+		return nil, runtime.NewNotRegisteredErrForKind(typedObjectTyperName, gvk)
+	}
+	return newFunc(), nil
+}
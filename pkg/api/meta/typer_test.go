@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+func TestTypedObjectTyper(t *testing.T) {
+	statusV1 := schema.GroupVersionKind{Version: "v1", Kind: "Status"}
+	statusV2 := schema.GroupVersionKind{Group: "example.com", Version: "v2", Kind: "Status"}
+	listV1 := schema.GroupVersionKind{Version: "v1", Kind: "List"}
+
+	typer := NewTypedObjectTyper()
+	MustAddTypedKind[metav1.Status](typer, statusV1)
+	MustAddTypedKind[metav1.Status](typer, statusV2)
+	MustAddTypedKind[metav1.List](typer, listV1)
+
+	if err := AddTypedKind[metav1.Status](typer, statusV1); err != nil {
+		t.Errorf("expected binding the same kind again to succeed, got %v", err)
+	}
+	if err := AddTypedKind[metav1.List](typer, statusV1); err == nil {
+		t.Error("expected binding a kind to another type to fail")
+	}
+	if err := AddTypedKind[metav1.List](typer, schema.GroupVersionKind{Kind: "List"}); err == nil {
+		t.Error("expected binding a kind without version to fail")
+	}
+
+	kinds, unversioned, err := typer.ObjectKinds(&metav1.Status{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unversioned || !reflect.DeepEqual(kinds, []schema.GroupVersionKind{statusV1, statusV2}) {
+		t.Errorf("unexpected kinds %v, unversioned %t", kinds, unversioned)
+	}
+	if _, _, err := typer.ObjectKinds(&metav1.PartialObjectMetadata{}); !runtime.IsNotRegisteredError(err) {
+		t.Errorf("expected a not registered error, got %v", err)
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "other.example.com", Version: "v1", Kind: "Other"})
+	if kinds, _, err := typer.ObjectKinds(u); err != nil || !reflect.DeepEqual(kinds, []schema.GroupVersionKind{u.GroupVersionKind()}) {
+		t.Errorf("expected the kind of the unstructured object, got %v, %v", kinds, err)
+	}
+	if _, _, err := typer.ObjectKinds(&unstructured.Unstructured{}); !runtime.IsMissingKind(err) {
+		t.Errorf("expected a missing kind error, got %v", err)
+	}
+
+	if !typer.Recognizes(listV1) || typer.Recognizes(listV1.GroupKind().WithVersion("v2")) {
+		t.Error("unexpected recognized kinds")
+	}
+	obj, err := typer.New(listV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*metav1.List); !ok {
+		t.Errorf("expected a new list, got %T", obj)
+	}
+	if _, err := typer.New(listV1.GroupKind().WithVersion("v2")); !runtime.IsNotRegisteredError(err) {
+		t.Errorf("expected a not registered error, got %v", err)
+	}
+}
+
+func TestTypedObjectTyperWithSerializer(t *testing.T) {
+	typer := NewTypedObjectTyper()
+	MustAddTypedKind[metav1.Status](typer, schema.GroupVersionKind{Version: "v1", Kind: "Status"})
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, typer, typer, json.SerializerOptions{})
+
+	obj, gvk, err := serializer.Decode([]byte(`{"apiVersion":"v1","kind":"Status","message":"hello"}`), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, ok := obj.(*metav1.Status)
+	if !ok || status.Message != "hello" || gvk.Kind != "Status" {
+		t.Errorf("unexpected decoded object %#v of kind %v", obj, gvk)
+	}
+}
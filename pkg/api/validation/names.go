@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// GenerateNamePolicy controls whether objects of a resource may be created with
+// metadata.generateName.
+type GenerateNamePolicy int
+
+const (
+	// GenerateNameAllowed allows generateName, validating it as a name prefix.
+	GenerateNameAllowed GenerateNamePolicy = iota
+	// GenerateNameForbidden rejects objects setting generateName.
+	GenerateNameForbidden
+)
+
+// NameValidation configures how the names of the objects of a resource are
+// validated.
+type NameValidation struct {
+	// Name validates metadata.name. Defaults to NameIsDNSSubdomain.
+	Name ValidateNameFunc
+	// GenerateName validates metadata.generateName, which is called with
+	// prefix set to true. Defaults to Name.
+	GenerateName ValidateNameFunc
+	// GenerateNamePolicy controls whether generateName may be set.
+	GenerateNamePolicy GenerateNamePolicy
+}
+
+// nameFunc returns the ValidateNameFunc for names.
+func (v NameValidation) nameFunc() ValidateNameFunc {
+	if v.Name != nil {
+		return v.Name
+	}
+	return NameIsDNSSubdomain
+}
+
+// generateNameFunc returns the ValidateNameFunc for generateName prefixes.
+func (v NameValidation) generateNameFunc() ValidateNameFunc {
+	if v.GenerateName != nil {
+		return v.GenerateName
+	}
+	return v.nameFunc()
+}
+
+// ObjectMetaValidationOptions configures ValidateObjectMetaAccessorWithOptions.
+type ObjectMetaValidationOptions struct {
+	// RequiresNamespace is true for namespaced resources, whose objects must
+	// have a namespace, and false for cluster-scoped resources, whose objects
+	// must not.
+	RequiresNamespace bool
+	// Names configures the validation of name and generateName.
+	Names NameValidation
+}
+
+// ValidateObjectMetaAccessorWithOptions validates an object's metadata on
+// creation, like ValidateObjectMetaAccessor, validating names as configured by
+// opts.
+func ValidateObjectMetaAccessorWithOptions(meta metav1.Object, opts ObjectMetaValidationOptions, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(meta.GetGenerateName()) != 0 && opts.Names.GenerateNamePolicy == GenerateNameForbidden {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("generateName"), "not allowed on this type"))
+	}
+	nameFn := func(name string, prefix bool) []string {
+		if prefix {
+			if opts.Names.GenerateNamePolicy == GenerateNameForbidden {
+				return nil
+			}
+			return opts.Names.generateNameFunc()(name, true)
+		}
+		return opts.Names.nameFunc()(name, false)
+	}
+	return append(allErrs, ValidateObjectMetaAccessor(meta, opts.RequiresNamespace, nameFn, fldPath)...)
+}
+
+// NameValidationRegistry holds the name validation of resources, so that API
+// servers serving many resources configure it in one place, and resources with
+// special naming rules, e.g. virtual resources whose names contain colons, can
+// register their own validation. It is safe for concurrent use.
+type NameValidationRegistry struct {
+	lock      sync.RWMutex
+	resources map[schema.GroupResource]NameValidation
+	// defaults applies to resources which were not registered.
+	defaults NameValidation
+}
+
+// NewNameValidationRegistry returns a NameValidationRegistry validating the
+// names of resources which are not registered with defaults.
+func NewNameValidationRegistry(defaults NameValidation) *NameValidationRegistry {
+	return &NameValidationRegistry{
+		resources: map[schema.GroupResource]NameValidation{},
+		defaults:  defaults,
+	}
+}
+
+// Register sets the name validation of resource. It returns an error if the
+// resource is already registered.
+func (r *NameValidationRegistry) Register(resource schema.GroupResource, validation NameValidation) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, exists := r.resources[resource]; exists {
+		return fmt.Errorf("name validation for %v is already registered", resource)
+	}
+	r.resources[resource] = validation
+	return nil
+}
+
+// NameValidationFor returns the name validation of resource, or the defaults of
+// the registry if resource is not registered.
+func (r *NameValidationRegistry) NameValidationFor(resource schema.GroupResource) NameValidation {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if validation, ok := r.resources[resource]; ok {
+		return validation
+	}
+	return r.defaults
+}
+
+// ValidateObjectMetaAccessor validates the metadata of an object of resource on
+// creation, with the name validation registered for resource.
+func (r *NameValidationRegistry) ValidateObjectMetaAccessor(resource schema.GroupResource, meta metav1.Object, requiresNamespace bool, fldPath *field.Path) field.ErrorList {
+	return ValidateObjectMetaAccessorWithOptions(meta, ObjectMetaValidationOptions{
+		RequiresNamespace: requiresNamespace,
+		Names:             r.NameValidationFor(resource),
+	}, fldPath)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestNameValidationRegistry(t *testing.T) {
+	virtual := schema.GroupResource{Group: "example.com", Resource: "virtuals"}
+	singletons := schema.GroupResource{Group: "example.com", Resource: "singletons"}
+	registry := NewNameValidationRegistry(NameValidation{Name: NameIsDNSLabel})
+	if err := registry.Register(virtual, NameValidation{Name: path.ValidatePathSegmentName}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(singletons, NameValidation{GenerateNamePolicy: GenerateNameForbidden}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(virtual, NameValidation{}); err == nil {
+		t.Errorf("expected an error registering a resource twice")
+	}
+
+	testCases := []struct {
+		name         string
+		resource     schema.GroupResource
+		meta         metav1.ObjectMeta
+		expectedErrs field.ErrorList
+	}{
+		{
+			name:     "default allows labels",
+			resource: schema.GroupResource{Resource: "other"},
+			meta:     metav1.ObjectMeta{Name: "foo"},
+		},
+		{
+			name:     "default rejects subdomains",
+			resource: schema.GroupResource{Resource: "other"},
+			meta:     metav1.ObjectMeta{Name: "foo.bar"},
+			expectedErrs: field.ErrorList{
+				field.Invalid(field.NewPath("metadata", "name"), "foo.bar", ""),
+			},
+		},
+		{
+			name:     "custom validator allows colons",
+			resource: virtual,
+			meta:     metav1.ObjectMeta{Name: "system:foo", GenerateName: "system:"},
+		},
+		{
+			name:     "custom validator rejects slashes",
+			resource: virtual,
+			meta:     metav1.ObjectMeta{Name: "foo/bar"},
+			expectedErrs: field.ErrorList{
+				field.Invalid(field.NewPath("metadata", "name"), "foo/bar", ""),
+			},
+		},
+		{
+			name:     "generateName forbidden",
+			resource: singletons,
+			meta:     metav1.ObjectMeta{Name: "foo.bar", GenerateName: "foo-"},
+			expectedErrs: field.ErrorList{
+				field.Forbidden(field.NewPath("metadata", "generateName"), ""),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := registry.ValidateObjectMetaAccessor(tc.resource, &tc.meta, false, field.NewPath("metadata"))
+			if len(errs) != len(tc.expectedErrs) {
+				t.Fatalf("expected %v, got %v", tc.expectedErrs, errs)
+			}
+			for i := range errs {
+				if errs[i].Type != tc.expectedErrs[i].Type || errs[i].Field != tc.expectedErrs[i].Field {
+					t.Errorf("expected %v, got %v", tc.expectedErrs[i], errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateObjectMetaAccessorWithOptionsDefaults(t *testing.T) {
+	meta := &metav1.ObjectMeta{Name: "foo.bar", GenerateName: "foo-", Namespace: "ns"}
+	if errs := ValidateObjectMetaAccessorWithOptions(meta, ObjectMetaValidationOptions{RequiresNamespace: true}, field.NewPath("metadata")); len(errs) != 0 {
+		t.Errorf("expected DNS subdomain names to be valid by default, got %v", errs)
+	}
+	meta.GenerateName = "Foo"
+	opts := ObjectMetaValidationOptions{RequiresNamespace: true, Names: NameValidation{GenerateName: path.ValidatePathSegmentName}}
+	if errs := ValidateObjectMetaAccessorWithOptions(meta, opts, field.NewPath("metadata")); len(errs) != 0 {
+		t.Errorf("expected generateName to be validated with its own func, got %v", errs)
+	}
+}
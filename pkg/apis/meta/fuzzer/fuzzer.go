@@ -172,6 +172,45 @@ func randomLabelKey(c fuzz.Continue) string {
 	return prefixPart + namePart
 }
 
+// fuzzObjectMeta fuzzes ObjectMeta with values which survive roundtrips.
+func fuzzObjectMeta(j *metav1.ObjectMeta, c fuzz.Continue) {
+	c.FuzzNoCustom(j)
+
+	j.ResourceVersion = strconv.FormatUint(c.RandUint64(), 10)
+	j.UID = types.UID(c.RandString())
+
+	// Fuzzing sec and nsec in a smaller range (uint32 instead of int64),
+	// so that the result Unix time is a valid date and can be parsed into RFC3339 format.
+	var sec, nsec uint32
+	c.Fuzz(&sec)
+	c.Fuzz(&nsec)
+	j.CreationTimestamp = metav1.Unix(int64(sec), int64(nsec)).Rfc3339Copy()
+
+	if j.DeletionTimestamp != nil {
+		c.Fuzz(&sec)
+		c.Fuzz(&nsec)
+		t := metav1.Unix(int64(sec), int64(nsec)).Rfc3339Copy()
+		j.DeletionTimestamp = &t
+	}
+
+	if len(j.Labels) == 0 {
+		j.Labels = nil
+	} else {
+		delete(j.Labels, "")
+	}
+	if len(j.Annotations) == 0 {
+		j.Annotations = nil
+	} else {
+		delete(j.Annotations, "")
+	}
+	if len(j.OwnerReferences) == 0 {
+		j.OwnerReferences = nil
+	}
+	if len(j.Finalizers) == 0 {
+		j.Finalizers = nil
+	}
+}
+
 func v1FuzzerFuncs(codecs runtimeserializer.CodecFactory) []interface{} {
 
 	return []interface{}{
@@ -181,43 +220,7 @@ func v1FuzzerFuncs(codecs runtimeserializer.CodecFactory) []interface{} {
 			j.APIVersion = ""
 			j.Kind = ""
 		},
-		func(j *metav1.ObjectMeta, c fuzz.Continue) {
-			c.FuzzNoCustom(j)
-
-			j.ResourceVersion = strconv.FormatUint(c.RandUint64(), 10)
-			j.UID = types.UID(c.RandString())
-
-			// Fuzzing sec and nsec in a smaller range (uint32 instead of int64),
-			// so that the result Unix time is a valid date and can be parsed into RFC3339 format.
-			var sec, nsec uint32
-			c.Fuzz(&sec)
-			c.Fuzz(&nsec)
-			j.CreationTimestamp = metav1.Unix(int64(sec), int64(nsec)).Rfc3339Copy()
-
-			if j.DeletionTimestamp != nil {
-				c.Fuzz(&sec)
-				c.Fuzz(&nsec)
-				t := metav1.Unix(int64(sec), int64(nsec)).Rfc3339Copy()
-				j.DeletionTimestamp = &t
-			}
-
-			if len(j.Labels) == 0 {
-				j.Labels = nil
-			} else {
-				delete(j.Labels, "")
-			}
-			if len(j.Annotations) == 0 {
-				j.Annotations = nil
-			} else {
-				delete(j.Annotations, "")
-			}
-			if len(j.OwnerReferences) == 0 {
-				j.OwnerReferences = nil
-			}
-			if len(j.Finalizers) == 0 {
-				j.Finalizers = nil
-			}
-		},
+		fuzzObjectMeta,
 		func(j *metav1.ResourceVersionMatch, c fuzz.Continue) {
 			matches := []metav1.ResourceVersionMatch{"", metav1.ResourceVersionMatchExact, metav1.ResourceVersionMatchNotOlderThan}
 			*j = matches[c.Rand.Intn(len(matches))]
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzzer
+
+import (
+	"strings"
+
+	fuzz "github.com/google/gofuzz"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Options selects which values are fuzzed to be valid rather than random. The
+// zero value fuzzes like Funcs.
+type Options struct {
+	// ValidObjectMeta fuzzes ObjectMeta to pass ObjectMeta validation for
+	// namespaced objects: names and namespaces are DNS names, labels,
+	// annotation keys and finalizers are qualified names, owner references are
+	// complete with at most one controller, and managed fields are cleared.
+	ValidObjectMeta bool
+	// ValidSelectors fuzzes the label and field selectors of ListOptions to
+	// parseable selectors.
+	ValidSelectors bool
+	// ValidQuantities fuzzes quantities in all formats, with values in their
+	// canonical form.
+	ValidQuantities bool
+}
+
+// FuncsWithOptions returns Funcs, overridden by fuzzer funcs producing valid
+// values as selected by options.
+func FuncsWithOptions(options Options) fuzzer.FuzzerFuncs {
+	return fuzzer.MergeFuzzerFuncs(Funcs, func(codecs runtimeserializer.CodecFactory) []interface{} {
+		var funcs []interface{}
+		if options.ValidObjectMeta {
+			funcs = append(funcs, func(j *metav1.ObjectMeta, c fuzz.Continue) {
+				fuzzObjectMeta(j, c)
+				validateObjectMeta(j, c)
+			})
+		}
+		if options.ValidSelectors {
+			funcs = append(funcs, func(j *metav1.ListOptions, c fuzz.Continue) {
+				c.FuzzNoCustom(j)
+				j.LabelSelector = RandomLabelSelector(c).String()
+				j.FieldSelector = RandomFieldSelector(c).String()
+			})
+		}
+		if options.ValidQuantities {
+			funcs = append(funcs, func(q *resource.Quantity, c fuzz.Continue) {
+				*q = RandomQuantity(c)
+			})
+		}
+		return funcs
+	})
+}
+
+// validateObjectMeta replaces the fuzzed values of j which fail validation.
+func validateObjectMeta(j *metav1.ObjectMeta, c fuzz.Continue) {
+	j.Name = RandomDNSSubdomain(c)
+	j.Namespace = randomDNSLabel(c)
+	if c.RandBool() {
+		j.GenerateName = randomDNSLabel(c) + "-"
+	} else {
+		j.GenerateName = ""
+	}
+	if j.Generation < 0 {
+		j.Generation = -j.Generation
+	}
+	j.Labels = RandomLabels(c)
+	if len(j.Annotations) > 0 {
+		annotations := make(map[string]string, len(j.Annotations))
+		for _, v := range j.Annotations {
+			annotations[randomLabelKey(c)] = v
+		}
+		j.Annotations = annotations
+	}
+	hasController := false
+	for i := range j.OwnerReferences {
+		ref := &j.OwnerReferences[i]
+		ref.APIVersion = randomDNSLabel(c) + "/v1"
+		ref.Kind = "Kind" + randomLabelPart(c, false)
+		ref.Name = RandomDNSSubdomain(c)
+		ref.UID = types.UID(randomDNSLabel(c))
+		if ref.Controller != nil && *ref.Controller {
+			if hasController {
+				*ref.Controller = false
+			}
+			hasController = true
+		}
+	}
+	for i := range j.Finalizers {
+		j.Finalizers[i] = randomLabelKey(c)
+	}
+	j.ManagedFields = nil
+}
+
+// RandomDNSSubdomain returns a random valid DNS subdomain, as required by the
+// names of most objects.
+func RandomDNSSubdomain(c fuzz.Continue) string {
+	parts := make([]string, c.Rand.Intn(3)+1)
+	for i := range parts {
+		parts[i] = randomDNSLabel(c)
+	}
+	return strings.Join(parts, ".")
+}
+
+// RandomLabels returns a random valid label map, or nil.
+func RandomLabels(c fuzz.Continue) map[string]string {
+	n := c.Rand.Intn(4)
+	if n == 0 {
+		return nil
+	}
+	result := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		result[randomLabelKey(c)] = randomLabelPart(c, true)
+	}
+	return result
+}
+
+// RandomLabelSelector returns a random valid label selector, which may be empty.
+func RandomLabelSelector(c fuzz.Continue) labels.Selector {
+	selector := labels.SelectorFromSet(RandomLabels(c))
+	if c.RandBool() {
+		if requirement, err := labels.NewRequirement(randomLabelKey(c), selection.Exists, nil); err == nil {
+			selector = selector.Add(*requirement)
+		}
+	}
+	return selector
+}
+
+// RandomFieldSelector returns a random valid field selector on metadata.name or
+// metadata.namespace, which may be empty.
+func RandomFieldSelector(c fuzz.Continue) fields.Selector {
+	set := fields.Set{}
+	if c.RandBool() {
+		set["metadata.name"] = RandomDNSSubdomain(c)
+	}
+	if c.RandBool() {
+		set["metadata.namespace"] = randomDNSLabel(c)
+	}
+	return fields.SelectorFromSet(set)
+}
+
+// RandomQuantity returns a random quantity in one of the quantity formats, in
+// the canonical form the quantity is parsed into from its string.
+func RandomQuantity(c fuzz.Continue) resource.Quantity {
+	var q *resource.Quantity
+	switch c.Rand.Intn(4) {
+	case 0:
+		q = resource.NewQuantity(c.Int63n(1000), resource.DecimalSI)
+	case 1:
+		q = resource.NewMilliQuantity(c.Int63n(100000), resource.DecimalSI)
+	case 2:
+		q = resource.NewQuantity(c.Int63n(1024)<<(10*c.Rand.Intn(4)), resource.BinarySI)
+	default:
+		q = resource.NewScaledQuantity(c.Int63n(1000), resource.Scale(3*(c.Rand.Intn(7)-3)))
+		q.Format = resource.DecimalExponent
+	}
+	return resource.MustParse(q.String())
+}
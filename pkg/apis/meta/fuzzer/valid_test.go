@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzzer
+
+import (
+	"math/rand"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestFuncsWithOptions(t *testing.T) {
+	codecs := runtimeserializer.NewCodecFactory(runtime.NewScheme())
+	f := fuzzer.FuzzerFor(FuncsWithOptions(Options{ValidObjectMeta: true, ValidSelectors: true, ValidQuantities: true}), rand.NewSource(rand.Int63()), codecs)
+
+	for i := 0; i < 200; i++ {
+		objectMeta := metav1.ObjectMeta{}
+		f.Fuzz(&objectMeta)
+		if errs := validation.ValidateObjectMeta(&objectMeta, true, validation.NameIsDNSSubdomain, field.NewPath("metadata")); len(errs) > 0 {
+			t.Fatalf("invalid fuzzed metadata %#v: %v", objectMeta, errs)
+		}
+
+		options := metav1.ListOptions{}
+		f.Fuzz(&options)
+		if _, err := labels.Parse(options.LabelSelector); err != nil {
+			t.Fatalf("invalid fuzzed label selector %q: %v", options.LabelSelector, err)
+		}
+		if _, err := fields.ParseSelector(options.FieldSelector); err != nil {
+			t.Fatalf("invalid fuzzed field selector %q: %v", options.FieldSelector, err)
+		}
+
+		var q resource.Quantity
+		f.Fuzz(&q)
+		parsed, err := resource.ParseQuantity(q.String())
+		if err != nil {
+			t.Fatalf("invalid fuzzed quantity %q: %v", q.String(), err)
+		}
+		if parsed.Cmp(q) != 0 || parsed.Format != q.Format {
+			t.Fatalf("fuzzed quantity %#v is not canonical, parsed as %#v", q, parsed)
+		}
+	}
+}
+
+func TestFuncsWithoutOptions(t *testing.T) {
+	codecs := runtimeserializer.NewCodecFactory(runtime.NewScheme())
+	f := fuzzer.FuzzerFor(FuncsWithOptions(Options{}), rand.NewSource(1), codecs)
+	reference := fuzzer.FuzzerFor(Funcs, rand.NewSource(1), codecs)
+
+	for i := 0; i < 10; i++ {
+		var objectMeta, expected metav1.ObjectMeta
+		f.Fuzz(&objectMeta)
+		reference.Fuzz(&expected)
+		if objectMeta.Name != expected.Name || objectMeta.UID != expected.UID {
+			t.Fatalf("expected the same fuzzing as Funcs without options, got %#v and %#v", objectMeta, expected)
+		}
+	}
+}
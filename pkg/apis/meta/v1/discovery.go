@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// APIResourceIndex indexes discovered resources by their GroupVersionResource.
+// Subresources are indexed with resources like "pods/status".
+//
+// +k8s:deepcopy-gen=false
+// +protobuf=false
+type APIResourceIndex map[schema.GroupVersionResource]APIResource
+
+// APIResourceConflictPolicy decides how resources discovered with the same
+// GroupVersionResource but different details are merged.
+type APIResourceConflictPolicy int
+
+const (
+	// APIResourceConflictError fails merging on conflicts.
+	APIResourceConflictError APIResourceConflictPolicy = iota
+	// APIResourceConflictKeepFirst keeps the resource found first.
+	APIResourceConflictKeepFirst
+	// APIResourceConflictKeepLast keeps the resource found last.
+	APIResourceConflictKeepLast
+)
+
+// IndexAPIResourceLists returns an index of the resources of lists. Identical
+// resources found in several lists are indexed once; an error is returned if
+// a list has an invalid group version, or if resources conflict.
+func IndexAPIResourceLists(lists ...*APIResourceList) (APIResourceIndex, error) {
+	return MergeAPIResourceLists(APIResourceConflictError, lists...)
+}
+
+// MergeAPIResourceLists returns an index of the resources of lists, e.g. as
+// discovered from several servers, resolving conflicts between resources with
+// the same GroupVersionResource according to policy.
+func MergeAPIResourceLists(policy APIResourceConflictPolicy, lists ...*APIResourceList) (APIResourceIndex, error) {
+	index := APIResourceIndex{}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range list.APIResources {
+			gvr := gv.WithResource(resource.Name)
+			existing, found := index[gvr]
+			if found && !apiResourcesEqual(existing, resource) {
+				switch policy {
+				case APIResourceConflictKeepFirst:
+					continue
+				case APIResourceConflictKeepLast:
+				default:
+					return nil, fmt.Errorf("conflicting discovery information for %v", gvr)
+				}
+			}
+			index[gvr] = resource
+		}
+	}
+	return index, nil
+}
+
+// Lookup returns the resource with the given GroupVersionResource.
+func (i APIResourceIndex) Lookup(gvr schema.GroupVersionResource) (APIResource, bool) {
+	resource, ok := i[gvr]
+	return resource, ok
+}
+
+// ResourcesForKind returns the sorted GroupVersionResources of the resources,
+// not including subresources, serving the given kind.
+func (i APIResourceIndex) ResourcesForKind(gvk schema.GroupVersionKind) []schema.GroupVersionResource {
+	result := schema.NewGroupVersionResourceSet()
+	for gvr, resource := range i {
+		if strings.Contains(gvr.Resource, "/") || resource.Kind != gvk.Kind {
+			continue
+		}
+		group, version := gvr.Group, gvr.Version
+		if len(resource.Group) > 0 || len(resource.Version) > 0 {
+			group, version = resource.Group, resource.Version
+		}
+		if group == gvk.Group && version == gvk.Version {
+			result.Insert(gvr)
+		}
+	}
+	return result.List()
+}
+
+// GroupVersionResources returns the sorted GroupVersionResources of the index.
+func (i APIResourceIndex) GroupVersionResources() []schema.GroupVersionResource {
+	return schema.GroupVersionResourceMap[APIResource](i).SortedKeys()
+}
+
+// APIResourceLists returns the resources of the index as one list per group
+// version, sorted by group version and resource.
+func (i APIResourceIndex) APIResourceLists() []*APIResourceList {
+	var lists []*APIResourceList
+	var current *APIResourceList
+	for _, gvr := range i.GroupVersionResources() {
+		groupVersion := gvr.GroupVersion().String()
+		if current == nil || current.GroupVersion != groupVersion {
+			current = &APIResourceList{GroupVersion: groupVersion}
+			lists = append(lists, current)
+		}
+		current.APIResources = append(current.APIResources, i[gvr])
+	}
+	return lists
+}
+
+// APIResourceDiff lists the resources which differ between two discovery
+// snapshots.
+//
+// +k8s:deepcopy-gen=false
+// +protobuf=false
+type APIResourceDiff struct {
+	// Added are the resources only found in the new snapshot.
+	Added []schema.GroupVersionResource
+	// Removed are the resources only found in the old snapshot.
+	Removed []schema.GroupVersionResource
+	// Changed are the resources found in both snapshots, with different details,
+	// e.g. verbs.
+	Changed []schema.GroupVersionResource
+}
+
+// Empty returns true if the snapshots have the same resources.
+func (d APIResourceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffAPIResources returns the sorted differences from the old to the new
+// discovery snapshot.
+func DiffAPIResources(oldIndex, newIndex APIResourceIndex) APIResourceDiff {
+	added, removed, changed := schema.NewGroupVersionResourceSet(), schema.NewGroupVersionResourceSet(), schema.NewGroupVersionResourceSet()
+	for gvr, newResource := range newIndex {
+		oldResource, found := oldIndex[gvr]
+		switch {
+		case !found:
+			added.Insert(gvr)
+		case !apiResourcesEqual(oldResource, newResource):
+			changed.Insert(gvr)
+		}
+	}
+	for gvr := range oldIndex {
+		if _, found := newIndex[gvr]; !found {
+			removed.Insert(gvr)
+		}
+	}
+	return APIResourceDiff{Added: nilIfEmpty(added.List()), Removed: nilIfEmpty(removed.List()), Changed: nilIfEmpty(changed.List())}
+}
+
+// MergeAPIGroupLists merges group lists, e.g. as discovered from several
+// servers. Groups are kept in the order in which they are first found, with
+// the union of their versions. The preferred version and server addresses of
+// a group are those of its first occurrence.
+func MergeAPIGroupLists(lists ...*APIGroupList) *APIGroupList {
+	merged := &APIGroupList{}
+	positions := map[string]int{}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, group := range list.Groups {
+			position, found := positions[group.Name]
+			if !found {
+				positions[group.Name] = len(merged.Groups)
+				group.Versions = append([]GroupVersionForDiscovery(nil), group.Versions...)
+				merged.Groups = append(merged.Groups, group)
+				continue
+			}
+			existing := &merged.Groups[position]
+			for _, version := range group.Versions {
+				if !hasGroupVersion(existing.Versions, version) {
+					existing.Versions = append(existing.Versions, version)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+func hasGroupVersion(versions []GroupVersionForDiscovery, version GroupVersionForDiscovery) bool {
+	for _, v := range versions {
+		if v.GroupVersion == version.GroupVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// apiResourcesEqual compares resources, treating nil and empty slices as equal.
+func apiResourcesEqual(a, b APIResource) bool {
+	if !stringSlicesEqual(a.Verbs, b.Verbs) || !stringSlicesEqual(a.ShortNames, b.ShortNames) || !stringSlicesEqual(a.Categories, b.Categories) {
+		return false
+	}
+	a.Verbs, a.ShortNames, a.Categories = nil, nil, nil
+	b.Verbs, b.ShortNames, b.Categories = nil, nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func nilIfEmpty(gvrs []schema.GroupVersionResource) []schema.GroupVersionResource {
+	if len(gvrs) == 0 {
+		return nil
+	}
+	return gvrs
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAPIResourceIndex(t *testing.T) {
+	pods := APIResource{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: Verbs{"get", "list"}}
+	podsStatus := APIResource{Name: "pods/status", Namespaced: true, Kind: "Pod", Verbs: Verbs{"get"}}
+	deployments := APIResource{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: Verbs{"get"}}
+	scale := APIResource{Name: "deployments/scale", Namespaced: true, Group: "autoscaling", Version: "v1", Kind: "Scale"}
+	core := &APIResourceList{GroupVersion: "v1", APIResources: []APIResource{podsStatus, pods}}
+	apps := &APIResourceList{GroupVersion: "apps/v1", APIResources: []APIResource{deployments, scale}}
+
+	index, err := IndexAPIResourceLists(core, apps, core)
+	if err != nil {
+		t.Fatal(err)
+	}
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	if resource, ok := index.Lookup(podsGVR); !ok || !reflect.DeepEqual(resource, pods) {
+		t.Errorf("expected %v, got %v, %v", pods, resource, ok)
+	}
+	expectedGVRs := []schema.GroupVersionResource{
+		podsGVR,
+		{Version: "v1", Resource: "pods/status"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Group: "apps", Version: "v1", Resource: "deployments/scale"},
+	}
+	if gvrs := index.GroupVersionResources(); !reflect.DeepEqual(gvrs, expectedGVRs) {
+		t.Errorf("expected %v, got %v", expectedGVRs, gvrs)
+	}
+	if gvrs := index.ResourcesForKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}); !reflect.DeepEqual(gvrs, []schema.GroupVersionResource{podsGVR}) {
+		t.Errorf("expected pods for kind Pod, got %v", gvrs)
+	}
+	if gvrs := index.ResourcesForKind(schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"}); len(gvrs) != 0 {
+		t.Errorf("expected no resources for subresource kind, got %v", gvrs)
+	}
+	expectedLists := []*APIResourceList{
+		{GroupVersion: "v1", APIResources: []APIResource{pods, podsStatus}},
+		{GroupVersion: "apps/v1", APIResources: []APIResource{deployments, scale}},
+	}
+	if lists := index.APIResourceLists(); !reflect.DeepEqual(lists, expectedLists) {
+		t.Errorf("expected %v, got %v", expectedLists, lists)
+	}
+
+	if _, err := IndexAPIResourceLists(&APIResourceList{GroupVersion: "a/b/c"}); err == nil {
+		t.Errorf("expected error for invalid group version")
+	}
+}
+
+func TestMergeAPIResourceLists(t *testing.T) {
+	first := &APIResourceList{GroupVersion: "v1", APIResources: []APIResource{{Name: "pods", Kind: "Pod", Verbs: Verbs{"get"}}}}
+	last := &APIResourceList{GroupVersion: "v1", APIResources: []APIResource{{Name: "pods", Kind: "Pod", Verbs: Verbs{"get", "list"}}}}
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	if _, err := MergeAPIResourceLists(APIResourceConflictError, first, last); err == nil {
+		t.Errorf("expected conflict error")
+	}
+	index, err := MergeAPIResourceLists(APIResourceConflictKeepFirst, first, nil, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbs := index[podsGVR].Verbs; !reflect.DeepEqual(verbs, Verbs{"get"}) {
+		t.Errorf("expected the first resource, got verbs %v", verbs)
+	}
+	index, err = MergeAPIResourceLists(APIResourceConflictKeepLast, first, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbs := index[podsGVR].Verbs; !reflect.DeepEqual(verbs, Verbs{"get", "list"}) {
+		t.Errorf("expected the last resource, got verbs %v", verbs)
+	}
+}
+
+func TestDiffAPIResources(t *testing.T) {
+	oldIndex := APIResourceIndex{
+		{Version: "v1", Resource: "pods"}:     {Name: "pods", Verbs: Verbs{"get"}},
+		{Version: "v1", Resource: "services"}: {Name: "services", ShortNames: []string{}},
+		{Version: "v1", Resource: "secrets"}:  {Name: "secrets"},
+	}
+	newIndex := APIResourceIndex{
+		{Version: "v1", Resource: "pods"}:                     {Name: "pods", Verbs: Verbs{"get", "watch"}},
+		{Version: "v1", Resource: "services"}:                 {Name: "services"},
+		{Group: "apps", Version: "v1", Resource: "replicas"}:  {Name: "replicas"},
+		{Group: "apps", Version: "v1", Resource: "daemonset"}: {Name: "daemonset"},
+	}
+	expected := APIResourceDiff{
+		Added: []schema.GroupVersionResource{
+			{Group: "apps", Version: "v1", Resource: "daemonset"},
+			{Group: "apps", Version: "v1", Resource: "replicas"},
+		},
+		Removed: []schema.GroupVersionResource{{Version: "v1", Resource: "secrets"}},
+		Changed: []schema.GroupVersionResource{{Version: "v1", Resource: "pods"}},
+	}
+	if diff := DiffAPIResources(oldIndex, newIndex); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %+v, got %+v", expected, diff)
+	}
+	if diff := DiffAPIResources(oldIndex, oldIndex); !diff.Empty() {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+}
+
+func TestMergeAPIGroupLists(t *testing.T) {
+	v1 := GroupVersionForDiscovery{GroupVersion: "apps/v1", Version: "v1"}
+	v2 := GroupVersionForDiscovery{GroupVersion: "apps/v2", Version: "v2"}
+	batch := GroupVersionForDiscovery{GroupVersion: "batch/v1", Version: "v1"}
+	first := &APIGroupList{Groups: []APIGroup{{Name: "apps", Versions: []GroupVersionForDiscovery{v1}, PreferredVersion: v1}}}
+	second := &APIGroupList{Groups: []APIGroup{
+		{Name: "batch", Versions: []GroupVersionForDiscovery{batch}, PreferredVersion: batch},
+		{Name: "apps", Versions: []GroupVersionForDiscovery{v2, v1}, PreferredVersion: v2},
+	}}
+	expected := &APIGroupList{Groups: []APIGroup{
+		{Name: "apps", Versions: []GroupVersionForDiscovery{v1, v2}, PreferredVersion: v1},
+		{Name: "batch", Versions: []GroupVersionForDiscovery{batch}, PreferredVersion: batch},
+	}}
+	if merged := MergeAPIGroupLists(first, nil, second); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+	if len(first.Groups[0].Versions) != 1 {
+		t.Errorf("merging modified the input lists")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WalkFieldsV1 calls fn for each field path encoded in f, depth first with
+// the children of each path in sorted order, until fn returns false. Paths
+// are passed as their elements, formatted as in sigs.k8s.io/structured-merge-diff:
+//
+//	".name"               a struct field or map key ("f:name")
+//	"[name=\"nginx\"]"    a list item by its key fields ("k:{\"name\":\"nginx\"}")
+//	"[=\"value\"]"        a list item by its value ("v:\"value\"")
+//	"[0]"                 a list item by its index ("i:0")
+//
+// owned is true if the path itself is in the set, rather than only paths below
+// it. The path slice is reused between calls, so fn must copy it to keep it.
+func WalkFieldsV1(f *FieldsV1, fn func(path []string, owned bool) bool) error {
+	if f == nil || len(f.Raw) == 0 {
+		return nil
+	}
+	_, err := walkFieldsV1(f.Raw, nil, fn)
+	return err
+}
+
+// FieldsV1Paths returns the paths in the set encoded in f, each as the
+// concatenation of its elements as formatted by WalkFieldsV1, such as
+// ".metadata.labels.app" or ".spec.containers[name=\"nginx\"].image".
+func FieldsV1Paths(f *FieldsV1) ([]string, error) {
+	var paths []string
+	err := WalkFieldsV1(f, func(path []string, owned bool) bool {
+		if owned {
+			paths = append(paths, strings.Join(path, ""))
+		}
+		return true
+	})
+	return paths, err
+}
+
+// FieldsV1Tree renders the paths encoded in f as an indented tree for humans,
+// with one path element per line:
+//
+//	.metadata
+//	  .labels
+//	    .app
+//	.spec
+//	  .containers
+//	    [name="nginx"]
+//	      .image
+func FieldsV1Tree(f *FieldsV1) (string, error) {
+	var b strings.Builder
+	err := WalkFieldsV1(f, func(path []string, owned bool) bool {
+		b.WriteString(strings.Repeat("  ", len(path)-1))
+		b.WriteString(path[len(path)-1])
+		b.WriteByte('\n')
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// walkFieldsV1 walks the children of the set node raw at path. It returns
+// false if fn stopped the walk.
+func walkFieldsV1(raw json.RawMessage, path []string, fn func([]string, bool) bool) (bool, error) {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return false, fmt.Errorf("invalid FieldsV1 at %q: %w", strings.Join(path, ""), err)
+	}
+	if node == nil {
+		return false, fmt.Errorf("invalid FieldsV1 at %q: expected an object", strings.Join(path, ""))
+	}
+	keys := make([]string, 0, len(node))
+	for k := range node {
+		if k != "." {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		element, err := formatFieldsV1Key(k)
+		if err != nil {
+			return false, fmt.Errorf("invalid FieldsV1 at %q: %w", strings.Join(path, ""), err)
+		}
+		child := node[k]
+		owned, err := isOwnedFieldsV1Node(child)
+		if err != nil {
+			return false, fmt.Errorf("invalid FieldsV1 at %q: %w", strings.Join(path, "")+element, err)
+		}
+		path = append(path, element)
+		if !fn(path, owned) {
+			return false, nil
+		}
+		if cont, err := walkFieldsV1(child, path, fn); err != nil || !cont {
+			return false, err
+		}
+		path = path[:len(path)-1]
+	}
+	return true, nil
+}
+
+// isOwnedFieldsV1Node returns true if the set node raw is a member of the
+// set: it is a leaf, or has a "." key.
+func isOwnedFieldsV1Node(raw json.RawMessage) (bool, error) {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return false, err
+	}
+	_, dot := node["."]
+	return len(node) == 0 || dot, nil
+}
+
+// formatFieldsV1Key formats a key of a FieldsV1 set node as a path element.
+func formatFieldsV1Key(k string) (string, error) {
+	prefix, value, ok := strings.Cut(k, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid key %q", k)
+	}
+	switch prefix {
+	case "f":
+		return "." + value, nil
+	case "i":
+		return "[" + value + "]", nil
+	case "v":
+		compact, err := compactJSON(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value in key %q: %w", k, err)
+		}
+		return "[=" + compact + "]", nil
+	case "k":
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return "", fmt.Errorf("invalid keys in key %q: %w", k, err)
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			compact, err := compactJSON(string(fields[name]))
+			if err != nil {
+				return "", err
+			}
+			parts[i] = name + "=" + compact
+		}
+		return "[" + strings.Join(parts, ",") + "]", nil
+	default:
+		return "", fmt.Errorf("invalid key %q", k)
+	}
+}
+
+func compactJSON(s string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testFieldsV1 = `{"f:metadata":{"f:labels":{".":{},"f:app":{}}},"f:spec":{"f:containers":{"k:{\"port\":80,\"name\":\"nginx\"}":{".":{},"f:image":{}}},"f:finalizers":{"v:\"a\"":{},"i:1":{}}}}`
+
+func TestFieldsV1Paths(t *testing.T) {
+	paths, err := FieldsV1Paths(&FieldsV1{Raw: []byte(testFieldsV1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		".metadata.labels",
+		".metadata.labels.app",
+		`.spec.containers[name="nginx",port=80]`,
+		`.spec.containers[name="nginx",port=80].image`,
+		".spec.finalizers[1]",
+		`.spec.finalizers[="a"]`,
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %q, got %q", expected, paths)
+	}
+
+	if paths, err := FieldsV1Paths(nil); err != nil || paths != nil {
+		t.Errorf("expected no paths, got %v, %v", paths, err)
+	}
+	for _, invalid := range []string{`[]`, `{"x":{}}`, `{"f:a":[]}`, `{"k:{":{}}`, `{"f:a":{"v:x":{}}}`} {
+		if _, err := FieldsV1Paths(&FieldsV1{Raw: []byte(invalid)}); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestWalkFieldsV1Stop(t *testing.T) {
+	var visited []string
+	err := WalkFieldsV1(&FieldsV1{Raw: []byte(testFieldsV1)}, func(path []string, owned bool) bool {
+		visited = append(visited, path[len(path)-1])
+		return len(visited) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{".metadata", ".labels"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("expected %q, got %q", expected, visited)
+	}
+}
+
+func TestFieldsV1Tree(t *testing.T) {
+	tree, err := FieldsV1Tree(&FieldsV1{Raw: []byte(testFieldsV1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.metadata
+  .labels
+    .app
+.spec
+  .containers
+    [name="nginx",port=80]
+      .image
+  .finalizers
+    [1]
+    [="a"]
+`
+	if tree != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, tree)
+	}
+}
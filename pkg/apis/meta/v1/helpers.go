@@ -252,6 +252,110 @@ func NewRVDeletionPrecondition(rv string) *DeleteOptions {
 	return &DeleteOptions{Preconditions: &p}
 }
 
+// DeleteOptionsWithUIDPrecondition returns a DeleteOptions with a UID
+// precondition set, which only deletes the object if it has the given UID.
+// Further options can be set with the With methods of DeleteOptions, e.g.
+// metav1.DeleteOptionsWithUIDPrecondition(uid).WithPropagation(metav1.DeletePropagationForeground)
+func DeleteOptionsWithUIDPrecondition(uid types.UID) *DeleteOptions {
+	return (&DeleteOptions{}).WithUIDPrecondition(uid)
+}
+
+// DeleteOptionsWithResourceVersionPrecondition returns a DeleteOptions with a
+// ResourceVersion precondition set, which only deletes the object if it has
+// not changed since the given resource version.
+func DeleteOptionsWithResourceVersionPrecondition(rv string) *DeleteOptions {
+	return (&DeleteOptions{}).WithResourceVersionPrecondition(rv)
+}
+
+// WithUIDPrecondition sets a UID precondition, keeping any ResourceVersion
+// precondition, and returns o.
+func (o *DeleteOptions) WithUIDPrecondition(uid types.UID) *DeleteOptions {
+	if o.Preconditions == nil {
+		o.Preconditions = &Preconditions{}
+	}
+	o.Preconditions.UID = &uid
+	return o
+}
+
+// WithResourceVersionPrecondition sets a ResourceVersion precondition,
+// keeping any UID precondition, and returns o.
+func (o *DeleteOptions) WithResourceVersionPrecondition(rv string) *DeleteOptions {
+	if o.Preconditions == nil {
+		o.Preconditions = &Preconditions{}
+	}
+	o.Preconditions.ResourceVersion = &rv
+	return o
+}
+
+// WithPropagation sets the propagation policy and returns o. The deprecated
+// OrphanDependents field must not be set along with it.
+func (o *DeleteOptions) WithPropagation(policy DeletionPropagation) *DeleteOptions {
+	o.PropagationPolicy = &policy
+	return o
+}
+
+// WithGracePeriod sets the grace period in seconds, zero meaning immediate
+// deletion, and returns o.
+func (o *DeleteOptions) WithGracePeriod(seconds int64) *DeleteOptions {
+	o.GracePeriodSeconds = &seconds
+	return o
+}
+
+// WithDryRun requests a dry run of the deletion and returns o.
+func (o *DeleteOptions) WithDryRun() *DeleteOptions {
+	o.DryRun = []string{DryRunAll}
+	return o
+}
+
+// GetOptionsWithResourceVersion returns a GetOptions with the given
+// ResourceVersion: "" requests the most recent version of the object, "0" any
+// version, e.g. from a cache, and other values a version at least as recent as
+// the given one.
+func GetOptionsWithResourceVersion(rv string) *GetOptions {
+	return &GetOptions{ResourceVersion: rv}
+}
+
+// NewPatchOptions returns a PatchOptions for patches made by fieldManager.
+// Further options can be set with the With methods of PatchOptions, e.g.
+// metav1.NewPatchOptions("my-controller").WithFieldValidation(metav1.FieldValidationStrict)
+func NewPatchOptions(fieldManager string) *PatchOptions {
+	return &PatchOptions{FieldManager: fieldManager}
+}
+
+// WithForce sets whether conflicts of an apply patch are forced, and returns o.
+// It must only be set for apply patches.
+func (o *PatchOptions) WithForce(force bool) *PatchOptions {
+	o.Force = &force
+	return o
+}
+
+// WithFieldValidation sets the field validation directive, one of
+// FieldValidationIgnore, FieldValidationWarn and FieldValidationStrict, and
+// returns o.
+func (o *PatchOptions) WithFieldValidation(fieldValidation string) *PatchOptions {
+	o.FieldValidation = fieldValidation
+	return o
+}
+
+// WithDryRun requests a dry run of the patch and returns o.
+func (o *PatchOptions) WithDryRun() *PatchOptions {
+	o.DryRun = []string{DryRunAll}
+	return o
+}
+
+// NewApplyOptions returns an ApplyOptions for applies made by fieldManager,
+// which is required. If force is true, conflicting fields owned by other
+// managers are taken over rather than failing the apply.
+func NewApplyOptions(fieldManager string, force bool) *ApplyOptions {
+	return &ApplyOptions{FieldManager: fieldManager, Force: force}
+}
+
+// WithDryRun requests a dry run of the apply and returns o.
+func (o *ApplyOptions) WithDryRun() *ApplyOptions {
+	o.DryRun = []string{DryRunAll}
+	return o
+}
+
 // HasObjectMetaSystemFieldValues returns true if fields that are managed by the system on ObjectMeta have values.
 func HasObjectMetaSystemFieldValues(meta Object) bool {
 	return !meta.GetCreationTimestamp().Time.IsZero() ||
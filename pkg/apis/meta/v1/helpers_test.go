@@ -249,3 +249,61 @@ func TestSetMetaDataLabel(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteOptionsBuilders(t *testing.T) {
+	uid := types.UID("uid")
+	rv := "42"
+	foreground := DeletePropagationForeground
+	zero := int64(0)
+
+	got := DeleteOptionsWithUIDPrecondition(uid).
+		WithResourceVersionPrecondition(rv).
+		WithPropagation(DeletePropagationForeground).
+		WithGracePeriod(0).
+		WithDryRun()
+	want := &DeleteOptions{
+		GracePeriodSeconds: &zero,
+		Preconditions:      &Preconditions{UID: &uid, ResourceVersion: &rv},
+		PropagationPolicy:  &foreground,
+		DryRun:             []string{DryRunAll},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected options (-want +got):\n%s", diff)
+	}
+
+	got = DeleteOptionsWithResourceVersionPrecondition(rv)
+	want = &DeleteOptions{Preconditions: &Preconditions{ResourceVersion: &rv}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected options (-want +got):\n%s", diff)
+	}
+}
+
+func TestPatchAndApplyOptionsBuilders(t *testing.T) {
+	force := true
+
+	gotPatch := NewPatchOptions("manager").
+		WithForce(true).
+		WithFieldValidation(FieldValidationStrict).
+		WithDryRun()
+	wantPatch := &PatchOptions{
+		FieldManager:    "manager",
+		Force:           &force,
+		FieldValidation: FieldValidationStrict,
+		DryRun:          []string{DryRunAll},
+	}
+	if diff := cmp.Diff(wantPatch, gotPatch); diff != "" {
+		t.Errorf("unexpected patch options (-want +got):\n%s", diff)
+	}
+
+	gotApply := NewApplyOptions("manager", true).WithDryRun()
+	wantApply := &ApplyOptions{FieldManager: "manager", Force: true, DryRun: []string{DryRunAll}}
+	if diff := cmp.Diff(wantApply, gotApply); diff != "" {
+		t.Errorf("unexpected apply options (-want +got):\n%s", diff)
+	}
+
+	gotGet := GetOptionsWithResourceVersion("0")
+	wantGet := &GetOptions{ResourceVersion: "0"}
+	if diff := cmp.Diff(wantGet, gotGet); diff != "" {
+		t.Errorf("unexpected get options (-want +got):\n%s", diff)
+	}
+}
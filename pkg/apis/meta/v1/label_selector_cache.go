@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/lru"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultLabelSelectorCacheSize is the size of the cache used by
+// CachedLabelSelectorAsSelector.
+const DefaultLabelSelectorCacheSize = 4096
+
+var defaultLabelSelectorCache = NewLabelSelectorCache(DefaultLabelSelectorCacheSize)
+
+// CachedLabelSelectorAsSelector is like LabelSelectorAsSelector, but memoizes
+// the converted selectors in a process-wide cache holding up to
+// DefaultLabelSelectorCacheSize selectors. See LabelSelectorCache.
+func CachedLabelSelectorAsSelector(ps *LabelSelector) (labels.Selector, error) {
+	return defaultLabelSelectorCache.AsSelector(ps)
+}
+
+// LabelSelectorCache memoizes the conversion of LabelSelectors into
+// labels.Selectors, for callers which convert the same selectors repeatedly,
+// such as controllers on every reconcile. Selectors are keyed by their
+// content, so equal LabelSelectors share a cache entry regardless of their
+// identity. It is safe for concurrent use.
+//
+// The returned selectors are shared between callers, which is safe since
+// labels.Selector methods do not modify the selector.
+type LabelSelectorCache struct {
+	cache *lru.Cache
+}
+
+// NewLabelSelectorCache returns a LabelSelectorCache holding up to maxSize
+// selectors, evicting the least recently used ones beyond that.
+func NewLabelSelectorCache(maxSize int) *LabelSelectorCache {
+	return &LabelSelectorCache{cache: lru.New(maxSize)}
+}
+
+// AsSelector converts ps like LabelSelectorAsSelector, returning a cached
+// selector if ps was converted before. Errors are not cached.
+func (c *LabelSelectorCache) AsSelector(ps *LabelSelector) (labels.Selector, error) {
+	if ps == nil {
+		return labels.Nothing(), nil
+	}
+	if len(ps.MatchLabels)+len(ps.MatchExpressions) == 0 {
+		return labels.Everything(), nil
+	}
+	key := labelSelectorCacheKey(ps)
+	if selector, ok := c.cache.Get(key); ok {
+		return selector.(labels.Selector), nil
+	}
+	selector, err := LabelSelectorAsSelector(ps)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Add(key, selector)
+	return selector, nil
+}
+
+// Len returns the number of cached selectors.
+func (c *LabelSelectorCache) Len() int {
+	return c.cache.Len()
+}
+
+// labelSelectorCacheKey returns an unambiguous encoding of the content of ps,
+// with counts terminated by ';' and length-prefixed strings. Match labels are
+// sorted since their order is not significant.
+func labelSelectorCacheKey(ps *LabelSelector) string {
+	var b strings.Builder
+	writeCount := func(n int) {
+		b.WriteString(strconv.Itoa(n))
+		b.WriteByte(';')
+	}
+	writeString := func(s string) {
+		b.WriteString(strconv.Itoa(len(s)))
+		b.WriteByte(':')
+		b.WriteString(s)
+	}
+
+	keys := make([]string, 0, len(ps.MatchLabels))
+	for k := range ps.MatchLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	writeCount(len(keys))
+	for _, k := range keys {
+		writeString(k)
+		writeString(ps.MatchLabels[k])
+	}
+
+	writeCount(len(ps.MatchExpressions))
+	for _, expr := range ps.MatchExpressions {
+		writeString(expr.Key)
+		writeString(string(expr.Operator))
+		writeCount(len(expr.Values))
+		for _, v := range expr.Values {
+			writeString(v)
+		}
+	}
+	return b.String()
+}
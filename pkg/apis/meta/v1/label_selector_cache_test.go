@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestLabelSelectorCache(t *testing.T) {
+	c := NewLabelSelectorCache(2)
+	selector := func() *LabelSelector {
+		return &LabelSelector{
+			MatchLabels: map[string]string{"foo": "bar", "baz": "qux"},
+			MatchExpressions: []LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: LabelSelectorOpIn,
+				Values:   []string{"prod", "staging"},
+			}},
+		}
+	}
+
+	first, err := c.AsSelector(selector())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := LabelSelectorAsSelector(selector())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != expected.String() {
+		t.Errorf("expected %q, got %q", expected, first)
+	}
+	second, err := c.AsSelector(selector())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.String() != first.String() || c.Len() != 1 {
+		t.Errorf("expected an equal selector to be cached, got %q and %d entries", second, c.Len())
+	}
+
+	different := selector()
+	different.MatchExpressions[0].Values = []string{"prod"}
+	if s, err := c.AsSelector(different); err != nil || s.String() == first.String() {
+		t.Errorf("expected a different selector, got %q, %v", s, err)
+	}
+
+	// keys and values must not be confused by the encoding
+	ambiguous := []*LabelSelector{
+		{MatchLabels: map[string]string{"a": "bc"}},
+		{MatchLabels: map[string]string{"ab": "c"}},
+	}
+	a, _ := c.AsSelector(ambiguous[0])
+	b, _ := c.AsSelector(ambiguous[1])
+	if a.String() == b.String() {
+		t.Errorf("expected different selectors, got %q", a)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected the cache to be bounded to 2 entries, got %d", c.Len())
+	}
+
+	// counts are delimited from the length prefixes of the strings following them
+	key := labelSelectorCacheKey(&LabelSelector{
+		MatchLabels:      map[string]string{"a": "b"},
+		MatchExpressions: []LabelSelectorRequirement{{Key: "c", Operator: LabelSelectorOpIn, Values: []string{"12"}}},
+	})
+	if expected := "1;1:a1:b1;1:c2:In1;2:12"; key != expected {
+		t.Errorf("expected key %q, got %q", expected, key)
+	}
+
+	if s, err := c.AsSelector(nil); err != nil || s.Matches(labels.Set{}) {
+		t.Errorf("expected nothing, got %q, %v", s, err)
+	}
+	if s, err := c.AsSelector(&LabelSelector{}); err != nil || !s.Empty() {
+		t.Errorf("expected everything, got %q, %v", s, err)
+	}
+	invalid := &LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "a", Operator: "bad"}}}
+	if _, err := c.AsSelector(invalid); err == nil {
+		t.Error("expected an error for an invalid operator")
+	}
+}
+
+func BenchmarkCachedLabelSelectorAsSelector(b *testing.B) {
+	selector := &LabelSelector{
+		MatchLabels: map[string]string{
+			"foo": "foo",
+			"bar": "bar",
+		},
+		MatchExpressions: []LabelSelectorRequirement{{
+			Key:      "baz",
+			Operator: LabelSelectorOpExists,
+		}},
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := CachedLabelSelectorAsSelector(selector)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLabelSelectorCacheMisses(b *testing.B) {
+	c := NewLabelSelectorCache(16)
+	selectors := make([]*LabelSelector, 64)
+	for i := range selectors {
+		selectors[i] = &LabelSelector{MatchLabels: map[string]string{"app": fmt.Sprintf("app-%d", i)}}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.AsSelector(selectors[i%len(selectors)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
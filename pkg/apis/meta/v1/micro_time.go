@@ -103,6 +103,69 @@ func (t *MicroTime) EqualTime(u *Time) bool {
 	return false
 }
 
+// AddMicro returns the time t+d as a MicroTime, by wrapping time.Time.Add.
+func (t MicroTime) AddMicro(d time.Duration) MicroTime {
+	return MicroTime{t.Time.Add(d)}
+}
+
+// SubMicroTime returns the duration t-u, ignoring any monotonic clock
+// readings, so that times read from the API and times obtained locally can be
+// subtracted consistently. A nil u is the zero time.
+func (t MicroTime) SubMicroTime(u *MicroTime) time.Duration {
+	var start time.Time
+	if u != nil {
+		start = u.Time.Round(0)
+	}
+	return t.Time.Round(0).Sub(start)
+}
+
+// EqualWithin reports whether the time instants t and u are at most tolerance
+// apart. Like Equal, two nil times are equal and a nil time is not equal to a
+// non-nil one. Any monotonic clock readings are ignored.
+func (t *MicroTime) EqualWithin(u *MicroTime, tolerance time.Duration) bool {
+	if t == nil || u == nil {
+		return t == nil && u == nil
+	}
+	d := t.Time.Round(0).Sub(u.Time.Round(0))
+	if d < 0 {
+		d = -d
+	}
+	return d <= tolerance
+}
+
+// TruncateMicro returns the result of rounding t down to a multiple of d since
+// the zero time as a MicroTime, without a monotonic clock reading, by wrapping
+// time.Time.Truncate. MicroTimes are serialized at microsecond precision, so
+// truncating to a microsecond gives the MicroTime as it is after serialization.
+func (t MicroTime) TruncateMicro(d time.Duration) MicroTime {
+	return MicroTime{t.Time.Truncate(d)}
+}
+
+// Elapsed returns the wall clock time elapsed between t and now, which is
+// negative if t is after now. Like Time.Elapsed, monotonic clock readings are
+// ignored on both sides, and a zero or nil t yields the time elapsed since the
+// zero time.
+func (t *MicroTime) Elapsed(now time.Time) time.Duration {
+	var start time.Time
+	if t != nil {
+		start = t.Time.Round(0)
+	}
+	return now.Round(0).Sub(start)
+}
+
+// ParseMicroTimeLenient parses str, an RFC 3339 time with any number of
+// fractional digits, rounded to the nearest microsecond. The unmarshalers of
+// MicroTime only accept the RFC3339Micro format MicroTimes are serialized
+// with; this accepts times written by other encoders, e.g. epoch timestamps
+// converted to strings.
+func ParseMicroTimeLenient(str string) (MicroTime, error) {
+	pt, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return MicroTime{}, err
+	}
+	return MicroTime{pt.Round(time.Microsecond).Local()}, nil
+}
+
 // UnixMicro returns the local time corresponding to the given Unix time
 // by wrapping time.Unix.
 func UnixMicro(sec int64, nsec int64) MicroTime {
@@ -132,6 +195,17 @@ func (t *MicroTime) UnmarshalJSON(b []byte) error {
 }
 
 func (t *MicroTime) UnmarshalCBOR(b []byte) error {
+	if isCBORTimeTag(b) {
+		var tagged time.Time
+		if err := cbor.Unmarshal(b, &tagged); err != nil {
+			return err
+		}
+		// epoch times with fractional seconds are floating-point numbers,
+		// which do not represent all microseconds exactly
+		t.Time = tagged.Round(time.Microsecond).Local()
+		return nil
+	}
+
 	var s *string
 	if err := cbor.Unmarshal(b, &s); err != nil {
 		return err
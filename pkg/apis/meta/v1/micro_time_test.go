@@ -150,6 +150,7 @@ func TestMicroTimeUnmarshalCBOR(t *testing.T) {
 		{name: "null", in: []byte{0xf6}, out: MicroTime{}}, // null
 		{name: "valid", in: []byte("\x58\x1b1998-05-05T05:05:05.000000Z"), out: MicroTime{Time: Date(1998, time.May, 5, 5, 5, 5, 0, time.UTC).Local()}},                                    // '1998-05-05T05:05:05.000000Z'
 		{name: "invalid cbor type", in: []byte{0x07}, out: MicroTime{}, errMessage: "cbor: cannot unmarshal positive integer into Go value of type string"},                                // 7
+		{name: "epoch-based date/time", in: []byte("\xc1\xfb\x41\xca\xa7\x4f\x00\x80\x04\x08"), out: MicroTime{Time: Date(1998, time.May, 5, 5, 5, 5, 123000, time.UTC).Local()}},          // 1(894344705.000123)
 		{name: "malformed timestamp", in: []byte("\x45hello"), out: MicroTime{}, errMessage: `parsing time "hello" as "2006-01-02T15:04:05.000000Z07:00": cannot parse "hello" as "2006"`}, // 'hello'
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -400,3 +401,88 @@ func TestMicroTimeRoundtripCBOR(t *testing.T) {
 		}
 	}
 }
+
+func TestMicroTimeArithmetic(t *testing.T) {
+	t1 := DateMicro(2024, time.January, 1, 0, 0, 0, 1500, time.UTC)
+	t2 := t1.AddMicro(time.Millisecond)
+	if d := t2.SubMicroTime(&t1); d != time.Millisecond {
+		t.Errorf("Expected 1ms, got %v", d)
+	}
+	if d := t1.SubMicroTime(nil); d != t1.Time.Sub(time.Time{}) {
+		t.Errorf("Expected the time since the zero time, got %v", d)
+	}
+
+	truncated := t1.TruncateMicro(time.Microsecond)
+	if expected := DateMicro(2024, time.January, 1, 0, 0, 0, 1000, time.UTC); !truncated.Equal(&expected) {
+		t.Errorf("Expected %v, got %v", expected, truncated)
+	}
+	if !t1.EqualWithin(&truncated, time.Microsecond) || t1.EqualWithin(&t2, time.Microsecond) {
+		t.Errorf("Unexpected EqualWithin results")
+	}
+	var nilTime *MicroTime
+	if !nilTime.EqualWithin(nil, 0) || nilTime.EqualWithin(&t1, time.Hour) {
+		t.Errorf("Unexpected EqualWithin results for nil times")
+	}
+
+	now := time.Now()
+	t3 := NewMicroTime(now.Add(-10 * time.Second).Round(0))
+	if elapsed := t3.Elapsed(now.Add(5 * time.Second)); elapsed != 15*time.Second {
+		t.Errorf("Expected 15s, got %v", elapsed)
+	}
+}
+
+func TestMicroTimeTruncateRoundtrip(t *testing.T) {
+	t1 := NowMicro()
+	data, err := json.Marshal(t1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var t2 MicroTime
+	if err := json.Unmarshal(data, &t2); err != nil {
+		t.Fatal(err)
+	}
+	if truncated := t1.TruncateMicro(time.Microsecond); !truncated.Equal(&t2) {
+		t.Errorf("Expected %v to equal the round trip result %v", truncated, t2)
+	}
+}
+
+func TestParseMicroTimeLenient(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected MicroTime
+	}{
+		{"1998-05-05T05:05:05Z", DateMicro(1998, time.May, 5, 5, 5, 5, 0, time.UTC)},
+		{"1998-05-05T05:05:05.5Z", DateMicro(1998, time.May, 5, 5, 5, 5, 500000000, time.UTC)},
+		{"1998-05-05T05:05:05.123456999Z", DateMicro(1998, time.May, 5, 5, 5, 5, 123457000, time.UTC)},
+		{"1998-05-05T05:05:05.000001Z", DateMicro(1998, time.May, 5, 5, 5, 5, 1000, time.UTC)},
+	}
+	for _, c := range cases {
+		result, err := ParseMicroTimeLenient(c.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.input, err)
+			continue
+		}
+		if !result.Equal(&c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.input, c.expected, result)
+		}
+	}
+	if _, err := ParseMicroTimeLenient("1998-05-05"); err == nil {
+		t.Errorf("Expected an error for a date without time")
+	}
+}
+
+func TestMicroTimeUnmarshalRejectsOtherPrecisions(t *testing.T) {
+	for _, input := range []string{
+		`"1998-05-05T05:05:05Z"`,
+		`"1998-05-05T05:05:05.5Z"`,
+		`"1998-05-05T05:05:05.123456999Z"`,
+	} {
+		var result MicroTime
+		if err := json.Unmarshal([]byte(input), &result); err == nil {
+			t.Errorf("%s: expected an error, got %v", input, result)
+		}
+		if err := result.UnmarshalQueryParameter(input[1 : len(input)-1]); err == nil {
+			t.Errorf("%s: expected an error from UnmarshalQueryParameter, got %v", input, result)
+		}
+	}
+}
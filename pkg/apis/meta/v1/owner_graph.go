@@ -0,0 +1,260 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OwnerGraph is the graph of the owner references between a set of objects,
+// e.g. the objects of a backup, linking owners and dependents by UID.
+// References to owners outside of the set are dangling.
+//
+// +k8s:deepcopy-gen=false
+// +protobuf=false
+type OwnerGraph struct {
+	// objects holds the objects of the set in their original order.
+	objects []Object
+	index   map[types.UID]int
+	// owners and dependents hold, for each object, the indexes of its owners
+	// and dependents in the set.
+	owners     [][]int
+	dependents [][]int
+}
+
+// NewOwnerGraph builds the owner graph of objects. Objects without a UID
+// cannot be owners, and objects sharing a UID are counted once, the first
+// one being retained.
+func NewOwnerGraph(objects []Object) *OwnerGraph {
+	g := &OwnerGraph{index: make(map[types.UID]int, len(objects))}
+	for _, obj := range objects {
+		uid := obj.GetUID()
+		if len(uid) != 0 {
+			if _, found := g.index[uid]; found {
+				continue
+			}
+			g.index[uid] = len(g.objects)
+		}
+		g.objects = append(g.objects, obj)
+	}
+	g.owners = make([][]int, len(g.objects))
+	g.dependents = make([][]int, len(g.objects))
+	for i, obj := range g.objects {
+		for _, ref := range obj.GetOwnerReferences() {
+			owner, found := g.index[ref.UID]
+			if !found || containsIndex(g.owners[i], owner) {
+				continue
+			}
+			g.owners[i] = append(g.owners[i], owner)
+			g.dependents[owner] = append(g.dependents[owner], i)
+		}
+	}
+	return g
+}
+
+func containsIndex(indexes []int, i int) bool {
+	for _, j := range indexes {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the object of the set with the given UID.
+func (g *OwnerGraph) Get(uid types.UID) (Object, bool) {
+	i, found := g.index[uid]
+	if !found {
+		return nil, false
+	}
+	return g.objects[i], true
+}
+
+// Owners returns the owners of the object with the given UID which are in the
+// set, in the order of its owner references.
+func (g *OwnerGraph) Owners(uid types.UID) []Object {
+	i, found := g.index[uid]
+	if !found {
+		return nil
+	}
+	return g.objectsAt(g.owners[i])
+}
+
+// Dependents returns the objects of the set owned by the object with the
+// given UID, in the order of the set.
+func (g *OwnerGraph) Dependents(uid types.UID) []Object {
+	i, found := g.index[uid]
+	if !found {
+		return nil
+	}
+	return g.objectsAt(g.dependents[i])
+}
+
+// Orphans returns the objects which have owner references, none of which
+// refers to an object of the set. Restored without their owners, such objects
+// would be deleted by the garbage collector.
+func (g *OwnerGraph) Orphans() []Object {
+	var orphans []Object
+	for i, obj := range g.objects {
+		if len(g.owners[i]) == 0 && len(obj.GetOwnerReferences()) != 0 {
+			orphans = append(orphans, obj)
+		}
+	}
+	return orphans
+}
+
+// Cycles returns the cycles of owner references, each as the objects involved
+// in it. Cycles and their objects are in the order of the set. An object
+// owning itself is a cycle.
+func (g *OwnerGraph) Cycles() [][]Object {
+	// Tarjan's strongly connected components algorithm.
+	index := make([]int, len(g.objects))
+	lowlink := make([]int, len(g.objects))
+	onStack := make([]bool, len(g.objects))
+	var stack []int
+	next := 1
+	var components [][]int
+
+	var visit func(i int)
+	visit = func(i int) {
+		index[i], lowlink[i] = next, next
+		next++
+		stack = append(stack, i)
+		onStack[i] = true
+		for _, owner := range g.owners[i] {
+			if index[owner] == 0 {
+				visit(owner)
+				lowlink[i] = min(lowlink[i], lowlink[owner])
+			} else if onStack[owner] {
+				lowlink[i] = min(lowlink[i], index[owner])
+			}
+		}
+		if lowlink[i] != index[i] {
+			return
+		}
+		var component []int
+		for {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[j] = false
+			component = append(component, j)
+			if j == i {
+				break
+			}
+		}
+		if len(component) > 1 || containsIndex(g.owners[i], i) {
+			components = append(components, component)
+		}
+	}
+	for i := range g.objects {
+		if index[i] == 0 {
+			visit(i)
+		}
+	}
+
+	// Restore the order of the set, within and across cycles.
+	for _, component := range components {
+		sort.Ints(component)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	cycles := make([][]Object, len(components))
+	for i, component := range components {
+		cycles[i] = g.objectsAt(component)
+	}
+	return cycles
+}
+
+// DeletionOrder returns the objects of the set in the order a foreground
+// cascading deletion removes them: every object comes after all its
+// dependents. Objects are otherwise kept in the order of the set. Reversed,
+// it is an order in which objects can be created with their owners existing.
+// Returns an error if owner references form a cycle.
+func (g *OwnerGraph) DeletionOrder() ([]Object, error) {
+	pending := make([]int, len(g.objects))
+	for i := range g.objects {
+		pending[i] = len(g.dependents[i])
+	}
+	// Always take the first object of the set without pending dependents, so
+	// that the order of the set is kept where possible.
+	ready := &indexHeap{}
+	for i := range g.objects {
+		if pending[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	order := make([]Object, 0, len(g.objects))
+	for ready.Len() > 0 {
+		next := heap.Pop(ready).(int)
+		order = append(order, g.objects[next])
+		for _, owner := range g.owners[next] {
+			if pending[owner]--; pending[owner] == 0 {
+				heap.Push(ready, owner)
+			}
+		}
+	}
+	if len(order) < len(g.objects) {
+		var names []string
+		for _, cycle := range g.Cycles() {
+			names = append(names, objectNames(cycle))
+		}
+		return nil, fmt.Errorf("owner references form cycles: %s", strings.Join(names, "; "))
+	}
+	return order, nil
+}
+
+func (g *OwnerGraph) objectsAt(indexes []int) []Object {
+	if len(indexes) == 0 {
+		return nil
+	}
+	objects := make([]Object, len(indexes))
+	for i, j := range indexes {
+		objects[i] = g.objects[j]
+	}
+	return objects
+}
+
+// objectNames formats the namespaced names of objects.
+func objectNames(objects []Object) string {
+	names := make([]string, len(objects))
+	for i, obj := range objects {
+		if ns := obj.GetNamespace(); len(ns) != 0 {
+			names[i] = ns + "/" + obj.GetName()
+		} else {
+			names[i] = obj.GetName()
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// indexHeap is a min-heap of indexes.
+type indexHeap []int
+
+func (h indexHeap) Len() int            { return len(h) }
+func (h indexHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h indexHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *indexHeap) Push(x interface{}) { *h = append(*h, x.(int)) }
+func (h *indexHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
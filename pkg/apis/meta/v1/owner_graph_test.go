@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func ownedObject(name string, owners ...string) Object {
+	obj := &ObjectMeta{Name: name, UID: types.UID(name)}
+	for _, owner := range owners {
+		obj.OwnerReferences = append(obj.OwnerReferences, OwnerReference{Name: owner, UID: types.UID(owner)})
+	}
+	return obj
+}
+
+func objectNamesOf(objects []Object) []string {
+	names := make([]string, len(objects))
+	for i, obj := range objects {
+		names[i] = obj.GetName()
+	}
+	return names
+}
+
+func TestOwnerGraph(t *testing.T) {
+	g := NewOwnerGraph([]Object{
+		ownedObject("deployment"),
+		ownedObject("pod-a", "replicaset"),
+		ownedObject("replicaset", "deployment"),
+		ownedObject("pod-b", "replicaset", "missing"),
+		ownedObject("orphan", "missing"),
+		ownedObject("config"),
+	})
+
+	if owners := objectNamesOf(g.Owners("pod-b")); !reflect.DeepEqual(owners, []string{"replicaset"}) {
+		t.Errorf("unexpected owners %v", owners)
+	}
+	if dependents := objectNamesOf(g.Dependents("replicaset")); !reflect.DeepEqual(dependents, []string{"pod-a", "pod-b"}) {
+		t.Errorf("unexpected dependents %v", dependents)
+	}
+	if _, found := g.Get("missing"); found {
+		t.Error("expected a dangling owner not to be found")
+	}
+	if orphans := objectNamesOf(g.Orphans()); !reflect.DeepEqual(orphans, []string{"orphan"}) {
+		t.Errorf("unexpected orphans %v", orphans)
+	}
+	if cycles := g.Cycles(); len(cycles) != 0 {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+
+	order, err := g.DeletionOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"pod-a", "pod-b", "replicaset", "deployment", "orphan", "config"}
+	if names := objectNamesOf(order); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected deletion order %v, got %v", expected, names)
+	}
+}
+
+func TestOwnerGraphCycles(t *testing.T) {
+	g := NewOwnerGraph([]Object{
+		ownedObject("a", "c"),
+		ownedObject("b", "a"),
+		ownedObject("self", "self"),
+		ownedObject("c", "b"),
+		ownedObject("d", "a"),
+	})
+
+	var cycles [][]string
+	for _, cycle := range g.Cycles() {
+		cycles = append(cycles, objectNamesOf(cycle))
+	}
+	if !reflect.DeepEqual(cycles, [][]string{{"a", "b", "c"}, {"self"}}) {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+	if _, err := g.DeletionOrder(); err == nil || !strings.Contains(err.Error(), "a, b, c") {
+		t.Errorf("expected an error reporting the cycle, got %v", err)
+	}
+}
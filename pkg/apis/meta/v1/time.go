@@ -84,6 +84,44 @@ func (t *Time) Equal(u *Time) bool {
 	return false
 }
 
+// EqualWithin reports whether the time instants t and u are at most tolerance
+// apart. Times are serialized at second precision, so a Time compared with the
+// result of its own round trip through the API should use a tolerance of a
+// second. Like Equal, two nil times are equal and a nil time is not equal to a
+// non-nil one. Any monotonic clock readings are ignored.
+func (t *Time) EqualWithin(u *Time, tolerance time.Duration) bool {
+	if t == nil || u == nil {
+		return t == nil && u == nil
+	}
+	d := t.Time.Round(0).Sub(u.Time.Round(0))
+	if d < 0 {
+		d = -d
+	}
+	return d <= tolerance
+}
+
+// TruncateTime returns the result of rounding t down to a multiple of d since
+// the zero time as a Time, without a monotonic clock reading, by wrapping
+// time.Time.Truncate. Truncating to a second gives the Time as it is after
+// serialization.
+func (t Time) TruncateTime(d time.Duration) Time {
+	return Time{t.Time.Truncate(d)}
+}
+
+// Elapsed returns the wall clock time elapsed between t and now, which is
+// negative if t is after now. Times read from the API have no monotonic clock
+// reading, while the result of time.Now does, so Elapsed ignores monotonic
+// readings on both sides to measure e.g. the time since a lastTransitionTime
+// consistently, whether or not t went through serialization. A zero or nil t
+// yields the time elapsed since the zero time.
+func (t *Time) Elapsed(now time.Time) time.Duration {
+	var start time.Time
+	if t != nil {
+		start = t.Time.Round(0)
+	}
+	return now.Round(0).Sub(start)
+}
+
 // Unix returns the local time corresponding to the given Unix time
 // by wrapping time.Unix.
 func Unix(sec int64, nsec int64) Time {
@@ -119,6 +157,15 @@ func (t *Time) UnmarshalJSON(b []byte) error {
 }
 
 func (t *Time) UnmarshalCBOR(b []byte) error {
+	if isCBORTimeTag(b) {
+		var tagged time.Time
+		if err := cbor.Unmarshal(b, &tagged); err != nil {
+			return err
+		}
+		t.Time = tagged.Local()
+		return nil
+	}
+
 	var s *string
 	if err := cbor.Unmarshal(b, &s); err != nil {
 		return err
@@ -172,6 +219,13 @@ func (t Time) MarshalJSON() ([]byte, error) {
 	return buf, nil
 }
 
+// isCBORTimeTag returns true if b starts with the head of a CBOR standard
+// date/time string (tag 0) or epoch-based date/time (tag 1), e.g. as written
+// by the CBOR serializer encoding timestamps as epoch-based date/times.
+func isCBORTimeTag(b []byte) bool {
+	return len(b) > 0 && (b[0] == 0xc0 || b[0] == 0xc1)
+}
+
 func (t Time) MarshalCBOR() ([]byte, error) {
 	if t.IsZero() {
 		return cbor.Marshal(nil)
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -188,6 +189,8 @@ func TestTimeUnmarshalCBOR(t *testing.T) {
 		{name: "no fractional seconds", in: []byte("\x58\x141998-05-05T05:05:05Z"), out: Time{Time: Date(1998, time.May, 5, 5, 5, 5, 0, time.UTC).Local()}},                    // '1998-05-05T05:05:05Z'
 		{name: "fractional seconds", in: []byte("\x58\x1e1998-05-05T05:05:05.123456789Z"), out: Time{Time: Date(1998, time.May, 5, 5, 5, 5, 123456789, time.UTC).Local()}},     // '1998-05-05T05:05:05.123456789Z'
 		{name: "invalid cbor type", in: []byte{0x07}, out: Time{}, errMessage: "cbor: cannot unmarshal positive integer into Go value of type string"},                         // 7
+		{name: "epoch-based date/time", in: []byte("\xc1\x1a\x35\x4e\x9e\x01"), out: Time{Time: Date(1998, time.May, 5, 5, 5, 5, 0, time.UTC).Local()}},                        // 1(894344705)
+		{name: "standard date/time string", in: []byte("\xc0\x741998-05-05T05:05:05Z"), out: Time{Time: Date(1998, time.May, 5, 5, 5, 5, 0, time.UTC).Local()}},                // 0("1998-05-05T05:05:05Z")
 		{name: "malformed timestamp", in: []byte("\x45hello"), out: Time{}, errMessage: `parsing time "hello" as "2006-01-02T15:04:05Z07:00": cannot parse "hello" as "2006"`}, // 'hello'
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -258,6 +261,69 @@ func TestTimeEqual(t *testing.T) {
 	}
 }
 
+func TestTimeEqualWithin(t *testing.T) {
+	t1 := Date(2024, time.January, 1, 0, 0, 0, 500*int(time.Millisecond), time.UTC)
+	t2 := t1.TruncateTime(time.Second)
+	t3 := NewTime(t1.Add(2 * time.Second))
+	cases := []struct {
+		name   string
+		x      *Time
+		y      *Time
+		result bool
+	}{
+		{"nil =? nil", nil, nil, true},
+		{"nil =? !nil", nil, &t1, false},
+		{"!nil =? nil", &t1, nil, false},
+		{"within tolerance", &t1, &t2, true},
+		{"within tolerance reversed", &t2, &t1, true},
+		{"outside tolerance", &t1, &t3, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := c.x.EqualWithin(c.y, time.Second)
+			if result != c.result {
+				t.Errorf("Failed equality test for '%v', '%v': expected %+v, got %+v", c.x, c.y, c.result, result)
+			}
+		})
+	}
+}
+
+func TestTimeTruncateRoundtrip(t *testing.T) {
+	t1 := Now()
+	data, err := json.Marshal(t1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var t2 Time
+	if err := json.Unmarshal(data, &t2); err != nil {
+		t.Fatal(err)
+	}
+	if truncated := t1.TruncateTime(time.Second); !truncated.Equal(&t2) {
+		t.Errorf("Expected %v to equal the round trip result %v", truncated, t2)
+	}
+	if strings.Contains(t1.TruncateTime(time.Second).String(), "m=") {
+		t.Errorf("Expected no monotonic clock reading after Truncate")
+	}
+}
+
+func TestTimeElapsed(t *testing.T) {
+	now := time.Now()
+	// a time read from the API has no monotonic clock reading
+	t1 := NewTime(now.Add(-10 * time.Second).Round(0))
+	if elapsed := t1.Elapsed(now.Add(5 * time.Second)); elapsed != 15*time.Second {
+		t.Errorf("Expected 15s, got %v", elapsed)
+	}
+	t2 := NewTime(now.Add(time.Minute))
+	if elapsed := t2.Elapsed(now); elapsed != -time.Minute {
+		t.Errorf("Expected -1m, got %v", elapsed)
+	}
+	var t3 *Time
+	if elapsed := t3.Elapsed(time.Time{}.Add(time.Hour)); elapsed != time.Hour {
+		t.Errorf("Expected 1h, got %v", elapsed)
+	}
+}
+
 func TestTimeBefore(t *testing.T) {
 	t1 := NewTime(time.Now())
 	cases := []struct {
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstructured
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultMaxInternedStrings is the number of distinct strings a StringInterner
+// remembers if no other limit is given.
+const DefaultMaxInternedStrings = 16 * 1024
+
+// StringInterner deduplicates strings, so that equal strings share memory. It
+// remembers a bounded number of strings in two generations of equal size: once
+// the newer generation is full, the older one is dropped, and strings found in
+// it are moved to the newer one, so that the strings which keep being interned
+// are retained while the others are evicted. It is safe for concurrent use.
+type StringInterner struct {
+	lock     sync.Mutex
+	current  map[string]string
+	previous map[string]string
+	// generationSize is the number of strings of each generation.
+	generationSize int
+}
+
+// NewStringInterner returns a StringInterner remembering up to maxEntries
+// distinct strings, rounded up to an even number, or DefaultMaxInternedStrings
+// if maxEntries is not positive.
+func NewStringInterner(maxEntries int) *StringInterner {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxInternedStrings
+	}
+	return &StringInterner{current: map[string]string{}, generationSize: (maxEntries + 1) / 2}
+}
+
+// Intern returns a string equal to s, which is shared with previous calls
+// with an equal string, unless it was evicted in between.
+func (i *StringInterner) Intern(s string) string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if interned, ok := i.current[s]; ok {
+		return interned
+	}
+	interned, ok := i.previous[s]
+	if !ok {
+		// clone s, so that interning a substring does not retain the whole string
+		interned = strings.Clone(s)
+	}
+	if len(i.current) >= i.generationSize {
+		i.previous, i.current = i.current, make(map[string]string, i.generationSize)
+	}
+	i.current[interned] = interned
+	return interned
+}
+
+// Len returns the number of distinct strings remembered.
+func (i *StringInterner) Len() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	n := len(i.current)
+	for s := range i.previous {
+		if _, ok := i.current[s]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// internedValueFields are the fields whose string values are interned, besides
+// all keys. Most other values, such as names, uids and resourceVersions, are
+// unique to an object, and interning them would only grow the interner.
+var internedValueFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+}
+
+// NewCompactUnstructured returns an Unstructured holding a compact deep copy of
+// obj, for callers holding many similar objects in memory, such as informer
+// caches. Maps are allocated with their exact size, and all slices of the
+// object share a single allocation, a slab holding their elements; maps cannot
+// share allocations in Go. All keys, such as field names like "metadata" and
+// "name" and label and annotation keys, and the values of apiVersion and kind
+// are interned with interner, so that they are stored once for all objects
+// compacted with the same interner.
+//
+// The returned object can be used and modified like any other Unstructured.
+// Appending to its slices copies them out of the slab.
+func NewCompactUnstructured(obj map[string]interface{}, interner *StringInterner) *Unstructured {
+	return &Unstructured{Object: CompactJSONObject(obj, interner)}
+}
+
+// NewCompactUnstructuredList returns an UnstructuredList holding compact deep
+// copies of the content and the items of list, like NewCompactUnstructured.
+func NewCompactUnstructuredList(list *UnstructuredList, interner *StringInterner) *UnstructuredList {
+	out := &UnstructuredList{Object: CompactJSONObject(list.Object, interner)}
+	if list.Items != nil {
+		out.Items = make([]Unstructured, len(list.Items))
+		for i := range list.Items {
+			out.Items[i].Object = CompactJSONObject(list.Items[i].Object, interner)
+		}
+	}
+	return out
+}
+
+// CompactJSONObject returns a compact deep copy of the JSON-compatible object
+// obj, as described for NewCompactUnstructured.
+func CompactJSONObject(obj map[string]interface{}, interner *StringInterner) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	c := compactor{interner: interner, slab: make([]interface{}, countSliceElements(obj))}
+	return c.object(obj)
+}
+
+// compactor copies a JSON-compatible object, carving its slices from slab.
+type compactor struct {
+	interner *StringInterner
+	slab     []interface{}
+}
+
+func (c *compactor) object(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		if s, ok := value.(string); ok && internedValueFields[key] {
+			value = c.interner.Intern(s)
+		}
+		out[c.interner.Intern(key)] = c.value(value)
+	}
+	return out
+}
+
+func (c *compactor) value(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if value == nil {
+			return value
+		}
+		return c.object(value)
+	case []interface{}:
+		if value == nil {
+			return value
+		}
+		// limit the capacity, so that appending does not overwrite the
+		// following slices
+		out := c.slab[:len(value):len(value)]
+		c.slab = c.slab[len(value):]
+		for i := range value {
+			out[i] = c.value(value[i])
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// countSliceElements returns the number of elements of all slices in value.
+func countSliceElements(value interface{}) int {
+	n := 0
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, v := range value {
+			n += countSliceElements(v)
+		}
+	case []interface{}:
+		n = len(value)
+		for _, v := range value {
+			n += countSliceElements(v)
+		}
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstructured
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func newCompactTestObject(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":   name,
+			"labels": map[string]interface{}{"app": "web"},
+			"annotations": map[string]interface{}{
+				"note": strings.Repeat("x", 2),
+			},
+		},
+		"data":    []interface{}{int64(1), 1.5, true, nil, "web"},
+		"ports":   []interface{}{map[string]interface{}{"names": []interface{}{"http"}}},
+		"empty":   []interface{}(nil),
+		"nothing": map[string]interface{}(nil),
+	}
+}
+
+func sameString(a, b string) bool {
+	return unsafe.StringData(a) == unsafe.StringData(b)
+}
+
+func TestNewCompactUnstructured(t *testing.T) {
+	interner := NewStringInterner(0)
+	first := newCompactTestObject("first")
+	second := newCompactTestObject("second")
+	compactFirst := NewCompactUnstructured(first, interner)
+	compactSecond := NewCompactUnstructured(second, interner)
+
+	if !reflect.DeepEqual(compactFirst.Object, first) || !reflect.DeepEqual(compactSecond.Object, second) {
+		t.Fatalf("expected compact objects to equal the originals")
+	}
+	if compactFirst.GetName() != "first" || compactFirst.GetLabels()["app"] != "web" {
+		t.Errorf("expected the compact object to be usable as an Unstructured, got %v", compactFirst.Object)
+	}
+
+	if !sameString(compactFirst.GetKind(), compactSecond.GetKind()) {
+		t.Errorf("expected equal kinds to be shared")
+	}
+	note := func(u *Unstructured) string {
+		return u.Object["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["note"].(string)
+	}
+	if sameString(note(compactFirst), note(compactSecond)) || interner.Len() != 15 {
+		t.Errorf("expected only keys, apiVersion and kind to be interned, got %d strings", interner.Len())
+	}
+	for key := range compactSecond.Object {
+		if interned := interner.Intern(key); !sameString(interned, key) {
+			t.Errorf("expected key %q to be interned", key)
+		}
+	}
+
+	// slices are carved from one slab, and appending to them copies them
+	data := compactFirst.Object["data"].([]interface{})
+	ports := compactFirst.Object["ports"].([]interface{})
+	names := ports[0].(map[string]interface{})["names"].([]interface{})
+	if cap(data) != len(data) || cap(names) != len(names) {
+		t.Errorf("expected slices to be capped at their length")
+	}
+	_ = append(data, "appended")
+	if !reflect.DeepEqual(compactFirst.Object, first) {
+		t.Errorf("expected appending to a slice not to modify the others")
+	}
+
+	// modifying the compact object does not affect the original
+	compactFirst.SetName("changed")
+	if first["metadata"].(map[string]interface{})["name"] != "first" {
+		t.Errorf("expected the original object not to be modified")
+	}
+}
+
+func TestNewCompactUnstructuredList(t *testing.T) {
+	list := &UnstructuredList{
+		Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMapList"},
+		Items:  []Unstructured{{Object: newCompactTestObject("a")}, {Object: newCompactTestObject("b")}},
+	}
+	compact := NewCompactUnstructuredList(list, NewStringInterner(0))
+	if !reflect.DeepEqual(compact, list) {
+		t.Errorf("expected the compact list to equal the original")
+	}
+}
+
+func TestStringInternerMaxEntries(t *testing.T) {
+	interner := NewStringInterner(2)
+	a := interner.Intern(strings.Repeat("a", 2))
+	if interned := interner.Intern(strings.Repeat("a", 2)); !sameString(a, interned) {
+		t.Errorf("expected the first string to be interned")
+	}
+	b := interner.Intern(strings.Repeat("b", 2))
+	// a is moved to the newer generation when interned again
+	if interned := interner.Intern(strings.Repeat("a", 2)); !sameString(a, interned) {
+		t.Errorf("expected the first string to be retained")
+	}
+	interner.Intern(strings.Repeat("c", 2))
+	if interner.Len() > 2 {
+		t.Errorf("expected at most 2 strings, got %d", interner.Len())
+	}
+	if interned := interner.Intern(strings.Repeat("a", 2)); !sameString(a, interned) {
+		t.Errorf("expected the recently interned string to be retained")
+	}
+	if interned := interner.Intern(strings.Repeat("b", 2)); sameString(b, interned) {
+		t.Errorf("expected the least recently interned string to be evicted")
+	}
+
+	if NewStringInterner(0).generationSize != DefaultMaxInternedStrings/2 {
+		t.Errorf("expected the default limit without a positive maxEntries")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstructured
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionsFields is the path of the conditions in the status of objects.
+var conditionsFields = []string{"status", "conditions"}
+
+// GetConditions returns the conditions found in .status.conditions of obj.
+// Entries are parsed tolerantly: entries which are not objects or have no type
+// are skipped, fields of unexpected types are ignored, boolean statuses are
+// converted to True or False, and generations may be numbers of any type or
+// strings.
+// Returns false if the conditions are missing, and an error if they are not a
+// list.
+func GetConditions(obj map[string]interface{}) ([]metav1.Condition, bool, error) {
+	entries, found, err := NestedSlice(obj, conditionsFields...)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	conditions := make([]metav1.Condition, 0, len(entries))
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition := extractCondition(m); len(condition.Type) != 0 {
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions, true, nil
+}
+
+// SetCondition sets the condition of the type of newCondition in
+// .status.conditions of obj, and returns true if obj is changed by this call.
+// Like meta.SetStatusCondition, the LastTransitionTime of the condition is set
+// to now if it is unset and the status of the condition changes or the
+// condition is added. Fields of the existing entry which are not part of
+// metav1.Condition are preserved.
+// Returns an error if the status is not an object or the conditions are not a
+// list.
+func SetCondition(obj map[string]interface{}, newCondition metav1.Condition) (bool, error) {
+	if len(newCondition.Type) == 0 {
+		return false, fmt.Errorf("condition type is required")
+	}
+	val, found, err := NestedFieldNoCopy(obj, conditionsFields...)
+	if err != nil {
+		return false, err
+	}
+	var entries []interface{}
+	if found && val != nil {
+		var ok bool
+		if entries, ok = val.([]interface{}); !ok {
+			return false, fmt.Errorf("%v accessor error: %v is of the type %T, expected []interface{}", jsonPath(conditionsFields), val, val)
+		}
+	}
+
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		existing := extractCondition(m)
+		if existing.Type != newCondition.Type {
+			continue
+		}
+
+		changed := false
+		if existing.Status != newCondition.Status {
+			m["status"] = string(newCondition.Status)
+			transitionTime := newCondition.LastTransitionTime
+			if transitionTime.IsZero() {
+				transitionTime = metav1.NewTime(time.Now())
+			}
+			m["lastTransitionTime"] = formatConditionTime(transitionTime)
+			changed = true
+		}
+		if existing.Reason != newCondition.Reason {
+			m["reason"] = newCondition.Reason
+			changed = true
+		}
+		if existing.Message != newCondition.Message {
+			m["message"] = newCondition.Message
+			changed = true
+		}
+		if existing.ObservedGeneration != newCondition.ObservedGeneration {
+			m["observedGeneration"] = newCondition.ObservedGeneration
+			changed = true
+		}
+		return changed, nil
+	}
+
+	if newCondition.LastTransitionTime.IsZero() {
+		newCondition.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+	m := map[string]interface{}{
+		"type":               newCondition.Type,
+		"status":             string(newCondition.Status),
+		"lastTransitionTime": formatConditionTime(newCondition.LastTransitionTime),
+		"reason":             newCondition.Reason,
+		"message":            newCondition.Message,
+	}
+	if newCondition.ObservedGeneration != 0 {
+		m["observedGeneration"] = newCondition.ObservedGeneration
+	}
+	if err := setNestedFieldNoCopy(obj, append(entries, m), conditionsFields...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// extractCondition tolerantly parses a condition entry.
+func extractCondition(m map[string]interface{}) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    getNestedString(m, "type"),
+		Reason:  getNestedString(m, "reason"),
+		Message: getNestedString(m, "message"),
+	}
+	switch status := m["status"].(type) {
+	case string:
+		condition.Status = metav1.ConditionStatus(status)
+	case bool:
+		if status {
+			condition.Status = metav1.ConditionTrue
+		} else {
+			condition.Status = metav1.ConditionFalse
+		}
+	}
+	switch generation := m["observedGeneration"].(type) {
+	case int64:
+		condition.ObservedGeneration = generation
+	case int:
+		condition.ObservedGeneration = int64(generation)
+	case float64:
+		condition.ObservedGeneration = int64(generation)
+	case string:
+		if i, err := strconv.ParseInt(generation, 10, 64); err == nil {
+			condition.ObservedGeneration = i
+		}
+	}
+	if s, ok := m["lastTransitionTime"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			condition.LastTransitionTime = metav1.NewTime(t.Local())
+		}
+	}
+	return condition
+}
+
+// formatConditionTime formats t the way metav1.Time is serialized.
+func formatConditionTime(t metav1.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstructured
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConditions(t *testing.T) {
+	obj := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "Ready",
+					"status":             "True",
+					"reason":             "AllGood",
+					"message":            "ready",
+					"observedGeneration": int64(3),
+					"lastTransitionTime": "2024-03-01T10:00:00Z",
+					"custom":             "kept",
+				},
+				map[string]interface{}{
+					"type":               "Synced",
+					"status":             false,
+					"observedGeneration": "4",
+					"lastTransitionTime": "yesterday",
+					"reason":             int64(1),
+				},
+				map[string]interface{}{"type": "Scaled", "status": "Unknown", "observedGeneration": float64(5)},
+				map[string]interface{}{"status": "True"},
+				"Ready",
+			},
+		},
+	}
+	conditions, found, err := GetConditions(obj)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, conditions, 3)
+	assert.Equal(t, metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		Reason:             "AllGood",
+		Message:            "ready",
+		ObservedGeneration: 3,
+		LastTransitionTime: metav1.NewTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC).Local()),
+	}, conditions[0])
+	assert.Equal(t, metav1.Condition{Type: "Synced", Status: metav1.ConditionFalse, ObservedGeneration: 4}, conditions[1])
+	assert.Equal(t, metav1.Condition{Type: "Scaled", Status: metav1.ConditionUnknown, ObservedGeneration: 5}, conditions[2])
+
+	_, found, err = GetConditions(map[string]interface{}{"status": map[string]interface{}{}})
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	_, _, err = GetConditions(map[string]interface{}{"status": map[string]interface{}{"conditions": "Ready"}})
+	assert.Error(t, err)
+}
+
+func TestSetCondition(t *testing.T) {
+	obj := map[string]interface{}{}
+	transitionTime := metav1.NewTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))
+
+	changed, err := SetCondition(obj, metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Starting", LastTransitionTime: transitionTime})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"type":               "Ready",
+			"status":             "False",
+			"reason":             "Starting",
+			"message":            "",
+			"lastTransitionTime": "2024-03-01T10:00:00Z",
+		},
+	}, obj["status"].(map[string]interface{})["conditions"])
+
+	changed, err = SetCondition(obj, metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Starting"})
+	require.NoError(t, err)
+	assert.False(t, changed, "expected setting the same condition to be a no-op")
+
+	entry := obj["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})
+	entry["custom"] = "kept"
+	before := time.Now().Add(-time.Second)
+	changed, err = SetCondition(obj, metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Started", ObservedGeneration: 2})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	conditions, _, err := GetConditions(obj)
+	require.NoError(t, err)
+	require.Len(t, conditions, 1)
+	assert.Equal(t, metav1.ConditionTrue, conditions[0].Status)
+	assert.Equal(t, "Started", conditions[0].Reason)
+	assert.Equal(t, int64(2), conditions[0].ObservedGeneration)
+	assert.True(t, conditions[0].LastTransitionTime.After(before), "expected the transition time to be updated")
+	assert.Equal(t, "kept", entry["custom"])
+
+	changed, err = SetCondition(obj, metav1.Condition{Type: "Synced", Status: metav1.ConditionTrue})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	conditions, _, err = GetConditions(obj)
+	require.NoError(t, err)
+	require.Len(t, conditions, 2)
+	assert.Equal(t, "Synced", conditions[1].Type)
+	assert.False(t, conditions[1].LastTransitionTime.IsZero())
+
+	// Objects must remain deep-copyable.
+	assert.Equal(t, obj, (&Unstructured{Object: obj}).DeepCopy().Object)
+
+	_, err = SetCondition(obj, metav1.Condition{Status: metav1.ConditionTrue})
+	assert.Error(t, err)
+	_, err = SetCondition(map[string]interface{}{"status": "Ready"}, metav1.Condition{Type: "Ready"})
+	assert.Error(t, err)
+	_, err = SetCondition(map[string]interface{}{"status": map[string]interface{}{"conditions": "Ready"}}, metav1.Condition{Type: "Ready"})
+	assert.Error(t, err)
+}
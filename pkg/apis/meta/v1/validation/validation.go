@@ -19,6 +19,8 @@ package validation
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"unicode"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -92,6 +94,97 @@ func ValidateLabelSelectorRequirement(sr metav1.LabelSelectorRequirement, opts L
 	return allErrs
 }
 
+// labelSelectorKeyRequirement is a requirement of a label selector on a key,
+// from either matchLabels or matchExpressions.
+type labelSelectorKeyRequirement struct {
+	path     *field.Path
+	operator metav1.LabelSelectorOperator
+	values   []string
+}
+
+// WarningsForLabelSelector returns warnings about a label selector which may
+// be valid but is likely not what was intended: keys with requirements in
+// more than one place, values which are both required and excluded, and
+// contradictory requirements which make the selector match no objects. Unlike
+// the errors of ValidateLabelSelector, they are meant to be returned as
+// admission warnings rather than to reject the selector.
+func WarningsForLabelSelector(ps *metav1.LabelSelector, fldPath *field.Path) []string {
+	if ps == nil {
+		return nil
+	}
+	requirements := map[string][]labelSelectorKeyRequirement{}
+	var keys []string
+	addRequirement := func(key string, r labelSelectorKeyRequirement) {
+		if _, ok := requirements[key]; !ok {
+			keys = append(keys, key)
+		}
+		requirements[key] = append(requirements[key], r)
+	}
+	matchLabelKeys := make([]string, 0, len(ps.MatchLabels))
+	for k := range ps.MatchLabels {
+		matchLabelKeys = append(matchLabelKeys, k)
+	}
+	sort.Strings(matchLabelKeys)
+	for _, k := range matchLabelKeys {
+		addRequirement(k, labelSelectorKeyRequirement{fldPath.Child("matchLabels").Key(k), metav1.LabelSelectorOpIn, []string{ps.MatchLabels[k]}})
+	}
+	for i, expr := range ps.MatchExpressions {
+		addRequirement(expr.Key, labelSelectorKeyRequirement{fldPath.Child("matchExpressions").Index(i), expr.Operator, expr.Values})
+	}
+
+	var warnings []string
+	for _, key := range keys {
+		reqs := requirements[key]
+		if len(reqs) > 1 {
+			others := make([]string, 0, len(reqs)-1)
+			for _, r := range reqs[1:] {
+				others = append(others, r.path.String())
+			}
+			warnings = append(warnings, fmt.Sprintf("%s: key %q also has requirements at %s", reqs[0].path, key, strings.Join(others, ", ")))
+		}
+
+		var mustExist, mustNotExist bool
+		// allowed is the intersection of the allowed values, or nil if no
+		// requirement restricts the values
+		var allowed sets.Set[string]
+		excluded := sets.New[string]()
+		for _, r := range reqs {
+			switch r.operator {
+			case metav1.LabelSelectorOpIn:
+				mustExist = true
+				if allowed == nil {
+					allowed = sets.New(r.values...)
+				} else {
+					allowed = allowed.Intersection(sets.New(r.values...))
+				}
+			case metav1.LabelSelectorOpNotIn:
+				excluded.Insert(r.values...)
+			case metav1.LabelSelectorOpExists:
+				mustExist = true
+			case metav1.LabelSelectorOpDoesNotExist:
+				mustNotExist = true
+			}
+		}
+		for _, r := range reqs {
+			if r.operator != metav1.LabelSelectorOpIn {
+				continue
+			}
+			for _, v := range r.values {
+				if excluded.Has(v) {
+					warnings = append(warnings, fmt.Sprintf("%s: value %q of key %q is both required and excluded", r.path, v, key))
+				}
+			}
+		}
+		switch {
+		case mustExist && mustNotExist:
+			warnings = append(warnings, fmt.Sprintf("%s: selector matches no objects: key %q is required to both exist and not exist", reqs[0].path, key))
+		case allowed != nil && allowed.Difference(excluded).Len() == 0:
+			warnings = append(warnings, fmt.Sprintf("%s: selector matches no objects: no value of key %q satisfies all its requirements", reqs[0].path, key))
+		}
+	}
+	return warnings
+}
+
 // ValidateLabelName validates that the label name is correctly defined.
 func ValidateLabelName(labelName string, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -129,6 +222,27 @@ func ValidateDeleteOptions(options *metav1.DeleteOptions) field.ErrorList {
 	return allErrs
 }
 
+// ValidateDeleteOptionsStrict validates DeleteOptions like ValidateDeleteOptions,
+// and additionally rejects combinations which the API server accepts but which
+// are unlikely to be intended: negative grace periods, and preconditions on an
+// empty UID or ResourceVersion, which no object satisfies. It is meant for
+// clients checking the options they built before sending them.
+func ValidateDeleteOptionsStrict(options *metav1.DeleteOptions) field.ErrorList {
+	allErrs := ValidateDeleteOptions(options)
+	if options.GracePeriodSeconds != nil && *options.GracePeriodSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("gracePeriodSeconds"), *options.GracePeriodSeconds, "must be greater than or equal to 0"))
+	}
+	if p := options.Preconditions; p != nil {
+		if p.UID != nil && len(*p.UID) == 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("preconditions", "uid"), *p.UID, "must not be empty"))
+		}
+		if p.ResourceVersion != nil && len(*p.ResourceVersion) == 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("preconditions", "resourceVersion"), *p.ResourceVersion, "must not be empty"))
+		}
+	}
+	return allErrs
+}
+
 func ValidateCreateOptions(options *metav1.CreateOptions) field.ErrorList {
 	allErrs := field.ErrorList{}
 	allErrs = append(allErrs, ValidateFieldManager(options.FieldManager, field.NewPath("fieldManager"))...)
@@ -163,6 +277,27 @@ func ValidatePatchOptions(options *metav1.PatchOptions, patchType types.PatchTyp
 	return allErrs
 }
 
+var supportedPatchTypes = sets.NewString(string(types.JSONPatchType), string(types.MergePatchType), string(types.StrategicMergePatchType), string(types.ApplyPatchType))
+
+// ValidatePatchOptionsStrict validates PatchOptions like ValidatePatchOptions,
+// and additionally rejects unknown patch types, which the API server answers
+// with an unsupported media type error. It is meant for clients checking the
+// options they built before sending them.
+func ValidatePatchOptionsStrict(options *metav1.PatchOptions, patchType types.PatchType) field.ErrorList {
+	allErrs := ValidatePatchOptions(options, patchType)
+	if !supportedPatchTypes.Has(string(patchType)) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("patchType"), patchType, supportedPatchTypes.List()))
+	}
+	return allErrs
+}
+
+// ValidateApplyOptions validates ApplyOptions like the PatchOptions of an apply
+// patch: the field manager is required.
+func ValidateApplyOptions(options *metav1.ApplyOptions) field.ErrorList {
+	patchOptions := options.ToPatchOptions()
+	return ValidatePatchOptions(&patchOptions, types.ApplyPatchType)
+}
+
 var FieldManagerMaxLength = 128
 
 // ValidateFieldManager valides that the fieldManager is the proper length and
@@ -18,6 +18,7 @@ package validation
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -402,6 +403,109 @@ func TestValidateConditions(t *testing.T) {
 	}
 }
 
+func TestWarningsForLabelSelector(t *testing.T) {
+	testCases := []struct {
+		name          string
+		labelSelector *metav1.LabelSelector
+		want          []string
+	}{{
+		name: "no warnings",
+		labelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "nginx"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"dev"},
+			}},
+		},
+	}, {
+		name: "nil",
+	}, {
+		name: "duplicate key",
+		labelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "nginx"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"nginx", "httpd"},
+			}},
+		},
+		want: []string{`spec.selector.matchLabels[app]: key "app" also has requirements at spec.selector.matchExpressions[0]`},
+	}, {
+		name: "In and NotIn on the same value",
+		labelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"prod", "staging"},
+			}, {
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"staging"},
+			}},
+		},
+		want: []string{
+			`spec.selector.matchExpressions[0]: key "env" also has requirements at spec.selector.matchExpressions[1]`,
+			`spec.selector.matchExpressions[0]: value "staging" of key "env" is both required and excluded`,
+		},
+	}, {
+		name: "always false In and NotIn",
+		labelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"env": "prod"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"prod"},
+			}},
+		},
+		want: []string{
+			`spec.selector.matchLabels[env]: key "env" also has requirements at spec.selector.matchExpressions[0]`,
+			`spec.selector.matchLabels[env]: value "prod" of key "env" is both required and excluded`,
+			`spec.selector.matchLabels[env]: selector matches no objects: no value of key "env" satisfies all its requirements`,
+		},
+	}, {
+		name: "always false disjoint In",
+		labelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"prod"},
+			}, {
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"dev"},
+			}},
+		},
+		want: []string{
+			`spec.selector.matchExpressions[0]: key "env" also has requirements at spec.selector.matchExpressions[1]`,
+			`spec.selector.matchExpressions[0]: selector matches no objects: no value of key "env" satisfies all its requirements`,
+		},
+	}, {
+		name: "always false Exists and DoesNotExist",
+		labelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpExists,
+			}, {
+				Key:      "env",
+				Operator: metav1.LabelSelectorOpDoesNotExist,
+			}},
+		},
+		want: []string{
+			`spec.selector.matchExpressions[0]: key "env" also has requirements at spec.selector.matchExpressions[1]`,
+			`spec.selector.matchExpressions[0]: selector matches no objects: key "env" is required to both exist and not exist`,
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := WarningsForLabelSelector(tc.labelSelector, field.NewPath("spec", "selector"))
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("expected warnings:\n%s\ngot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
 func TestLabelSelectorMatchExpression(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -515,3 +619,102 @@ func errorsAsString(errs field.ErrorList) string {
 	}
 	return strings.Join(messages, "\n")
 }
+
+func TestValidateDeleteOptionsStrict(t *testing.T) {
+	orphan := true
+	tests := []struct {
+		name       string
+		opts       *metav1.DeleteOptions
+		wantFields []string
+	}{{
+		name: "valid",
+		opts: metav1.DeleteOptionsWithUIDPrecondition("uid").WithResourceVersionPrecondition("1").WithPropagation(metav1.DeletePropagationBackground).WithGracePeriod(30),
+	}, {
+		name:       "empty preconditions",
+		opts:       metav1.DeleteOptionsWithUIDPrecondition("").WithResourceVersionPrecondition(""),
+		wantFields: []string{"preconditions.uid", "preconditions.resourceVersion"},
+	}, {
+		name:       "negative grace period",
+		opts:       (&metav1.DeleteOptions{}).WithGracePeriod(-1),
+		wantFields: []string{"gracePeriodSeconds"},
+	}, {
+		name:       "orphan with propagation",
+		opts:       (&metav1.DeleteOptions{OrphanDependents: &orphan}).WithPropagation(metav1.DeletePropagationForeground),
+		wantFields: []string{"propagationPolicy"},
+	}, {
+		name:       "invalid propagation",
+		opts:       (&metav1.DeleteOptions{}).WithPropagation("Sideways"),
+		wantFields: []string{"propagationPolicy"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidateDeleteOptionsStrict(tc.opts)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Errorf("expected errors for %v, got %v", tc.wantFields, errs)
+			}
+		})
+	}
+}
+
+func TestValidatePatchOptionsStrict(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       *metav1.PatchOptions
+		patchType  types.PatchType
+		wantFields []string
+	}{{
+		name:      "valid apply",
+		opts:      metav1.NewPatchOptions("manager").WithForce(true).WithFieldValidation(metav1.FieldValidationStrict),
+		patchType: types.ApplyPatchType,
+	}, {
+		name:      "valid merge patch",
+		opts:      metav1.NewPatchOptions("").WithDryRun(),
+		patchType: types.MergePatchType,
+	}, {
+		name:       "apply without field manager",
+		opts:       metav1.NewPatchOptions(""),
+		patchType:  types.ApplyPatchType,
+		wantFields: []string{"fieldManager"},
+	}, {
+		name:       "force for non-apply patch",
+		opts:       metav1.NewPatchOptions("manager").WithForce(false),
+		patchType:  types.StrategicMergePatchType,
+		wantFields: []string{"force"},
+	}, {
+		name:       "invalid field validation",
+		opts:       metav1.NewPatchOptions("manager").WithFieldValidation("Loose"),
+		patchType:  types.JSONPatchType,
+		wantFields: []string{"fieldValidation"},
+	}, {
+		name:       "unknown patch type",
+		opts:       metav1.NewPatchOptions("manager"),
+		patchType:  "application/xml-patch+xml",
+		wantFields: []string{"patchType"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidatePatchOptionsStrict(tc.opts, tc.patchType)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Errorf("expected errors for %v, got %v", tc.wantFields, errs)
+			}
+		})
+	}
+}
+
+func TestValidateApplyOptions(t *testing.T) {
+	if errs := ValidateApplyOptions(metav1.NewApplyOptions("manager", true)); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	errs := ValidateApplyOptions(metav1.NewApplyOptions("", false).WithDryRun())
+	if len(errs) != 1 || errs[0].Field != "fieldManager" || errs[0].Type != field.ErrorTypeRequired {
+		t.Errorf("expected the field manager to be required, got %v", errs)
+	}
+}
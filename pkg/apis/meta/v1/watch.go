@@ -17,9 +17,13 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"io"
+
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
@@ -87,3 +91,82 @@ func (e *InternalEvent) DeepCopyObject() runtime.Object {
 		return nil
 	}
 }
+
+// WatchEventEncoder writes watch events to a stream as WatchEvent objects.
+type WatchEventEncoder struct {
+	encoder  streaming.Encoder
+	embedded runtime.Encoder
+}
+
+// NewWatchEventEncoder returns a WatchEventEncoder writing to w with the framing and
+// the stream serializer of info, such as JSON objects separated by newlines or a CBOR
+// Sequence. The objects of the events are encoded with embedded, which should encode
+// with info.Serializer so that objects are embedded in the format of the stream.
+func NewWatchEventEncoder(w io.Writer, info runtime.SerializerInfo, embedded runtime.Encoder) (*WatchEventEncoder, error) {
+	if info.StreamSerializer == nil {
+		return nil, fmt.Errorf("serializer for %s does not support streaming", info.MediaType)
+	}
+	return &WatchEventEncoder{
+		encoder:  streaming.NewEncoder(info.StreamSerializer.NewFrameWriter(w), info.StreamSerializer.Serializer),
+		embedded: embedded,
+	}, nil
+}
+
+// Encode writes event to the stream.
+func (e *WatchEventEncoder) Encode(event watch.Event) error {
+	out := &WatchEvent{Type: string(event.Type)}
+	if event.Object != nil {
+		data, err := runtime.Encode(e.embedded, event.Object)
+		if err != nil {
+			return err
+		}
+		out.Object.Raw = data
+	}
+	return e.encoder.Encode(out)
+}
+
+// WatchEventDecoder reads watch events from a stream of WatchEvent objects.
+type WatchEventDecoder struct {
+	decoder  streaming.Decoder
+	embedded runtime.Decoder
+}
+
+// NewWatchEventDecoder returns a WatchEventDecoder reading from r with the framing and
+// the stream serializer of info. The objects of the events are decoded with embedded.
+func NewWatchEventDecoder(r io.ReadCloser, info runtime.SerializerInfo, embedded runtime.Decoder) (*WatchEventDecoder, error) {
+	if info.StreamSerializer == nil {
+		return nil, fmt.Errorf("serializer for %s does not support streaming", info.MediaType)
+	}
+	return &WatchEventDecoder{
+		decoder:  streaming.NewDecoder(info.StreamSerializer.NewFrameReader(r), info.StreamSerializer.Serializer),
+		embedded: embedded,
+	}, nil
+}
+
+// Decode blocks until it can return the next event in the stream, and returns io.EOF
+// at the end of the stream.
+func (d *WatchEventDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var got WatchEvent
+	res, _, err := d.decoder.Decode(nil, &got)
+	if err != nil {
+		return "", nil, err
+	}
+	if res != &got {
+		return "", nil, fmt.Errorf("unable to decode to metav1.WatchEvent")
+	}
+	switch watch.EventType(got.Type) {
+	case watch.Added, watch.Modified, watch.Deleted, watch.Error, watch.Bookmark:
+	default:
+		return "", nil, fmt.Errorf("got invalid watch event type: %v", got.Type)
+	}
+	obj, err := runtime.Decode(d.embedded, got.Object.Raw)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decode watch event: %v", err)
+	}
+	return watch.EventType(got.Type), obj, nil
+}
+
+// Close closes the underlying stream.
+func (d *WatchEventDecoder) Close() error {
+	return d.decoder.Close()
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestWatchEventStreams(t *testing.T) {
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "test.k8s.io", Version: "v1"})
+	codecs := serializer.NewCodecFactory(scheme, serializer.WithSerializer(cbor.NewSerializerInfo))
+
+	events := []watch.Event{
+		{Type: watch.Added, Object: &metav1.Status{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}, Message: "added"}},
+		{Type: watch.Error, Object: &metav1.Status{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}, Message: "error", Code: 500}},
+	}
+
+	for _, tc := range []struct {
+		streamMediaType   string
+		expectedMediaType string
+		expectedPrefix    []byte
+	}{
+		{streamMediaType: runtime.ContentTypeJSON, expectedMediaType: runtime.ContentTypeJSON, expectedPrefix: []byte("{")},
+		{streamMediaType: runtime.ContentTypeCBORSeq, expectedMediaType: runtime.ContentTypeCBOR, expectedPrefix: []byte{0xd9, 0xd9, 0xf7}},
+	} {
+		t.Run(tc.streamMediaType, func(t *testing.T) {
+			info, ok := runtime.StreamSerializerInfoForMediaType(codecs.SupportedMediaTypes(), tc.streamMediaType)
+			if !ok {
+				t.Fatalf("no stream serializer for %s", tc.streamMediaType)
+			}
+			if info.MediaType != tc.expectedMediaType {
+				t.Fatalf("expected serializer for %s, got %s", tc.expectedMediaType, info.MediaType)
+			}
+
+			var buf bytes.Buffer
+			encoder, err := metav1.NewWatchEventEncoder(&buf, info, info.Serializer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range events {
+				if err := encoder.Encode(event); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.HasPrefix(buf.Bytes(), tc.expectedPrefix) {
+				t.Errorf("expected stream to start with %x, got %x", tc.expectedPrefix, buf.Bytes())
+			}
+
+			decoder, err := metav1.NewWatchEventDecoder(io.NopCloser(&buf), info, codecs.UniversalDeserializer())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer decoder.Close()
+			for _, expected := range events {
+				eventType, obj, err := decoder.Decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if eventType != expected.Type || !apiequality.Semantic.DeepEqual(obj, expected.Object) {
+					t.Errorf("expected %v %#v, got %v %#v", expected.Type, expected.Object, eventType, obj)
+				}
+			}
+			if _, _, err := decoder.Decode(); err != io.EOF {
+				t.Errorf("expected io.EOF at the end of the stream, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWatchEventEmbeddedCBOR(t *testing.T) {
+	// objects embedded in CBOR watch events decode to JSON
+	data, err := cbordirect.Marshal(&metav1.WatchEvent{Type: string(watch.Added), Object: runtime.RawExtension{Raw: []byte{0xd9, 0xd9, 0xf7, 0xa1, 0x61, 'a', 0x01}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in metav1.WatchEvent
+	if err := cbordirect.Unmarshal(data, &in); err != nil {
+		t.Fatal(err)
+	}
+	var out watch.Event
+	if err := metav1.Convert_v1_WatchEvent_To_watch_Event(&in, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if unknown, ok := out.Object.(*runtime.Unknown); !ok || unknown.ContentType != runtime.ContentTypeJSON || string(unknown.Raw) != `{"a":1}` {
+		t.Errorf("expected an Unknown object with JSON content, got %#v", out.Object)
+	}
+}
@@ -189,6 +189,21 @@ func (c *Converter) RegisterIgnoredConversion(from, to interface{}) error {
 	return nil
 }
 
+// HasConversionFunc returns true if a conversion function, generated or not,
+// or an ignored conversion is registered from the type of src to the type of
+// dest, i.e. if Convert knows how to translate src to dest.
+func (c *Converter) HasConversionFunc(src, dest interface{}) bool {
+	pair := typePair{reflect.TypeOf(src), reflect.TypeOf(dest)}
+	if _, ok := c.ignoredUntypedConversions[pair]; ok {
+		return true
+	}
+	if _, ok := c.conversionFuncs.untyped[pair]; ok {
+		return true
+	}
+	_, ok := c.generatedConversionFuncs.untyped[pair]
+	return ok
+}
+
 // Convert will translate src to dest if it knows how. Both must be pointers.
 // If no conversion func is registered and the default copying mechanism
 // doesn't work on this type pair, an error will be returned.
@@ -45,3 +45,6 @@ func (e Equalities) Copy() Equalities {
 
 	return result
 }
+
+// FieldDifference is a difference between two values found by Equalities.Diff.
+type FieldDifference = reflect.FieldDifference
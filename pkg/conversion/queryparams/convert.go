@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -129,9 +130,34 @@ func addListOfParams(values url.Values, tag string, omitempty bool, list reflect
 	}
 }
 
+// addMapOfParams adds a parameter of the form key=value for each entry of m,
+// sorted by key.
+func addMapOfParams(values url.Values, tag string, m reflect.Value) {
+	keys := make([]string, 0, m.Len())
+	for _, key := range m.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+		values.Add(tag, key+"="+fmt.Sprintf("%v", value.Interface()))
+	}
+}
+
+// isStringMap returns true for the map types encoded by Convert: maps with
+// string keys and simple values.
+func isStringMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && isValueKind(t.Elem().Kind())
+}
+
 // Convert takes an object and converts it to a url.Values object using JSON tags as
-// parameter names. Only top-level simple values, arrays, and slices are serialized.
-// Embedded structs, maps, etc. will not be serialized.
+// parameter names. Simple values are serialized as a parameter, and arrays and
+// slices of simple values as a parameter repeated for each item. Maps with
+// string keys and simple values are serialized as a parameter repeated for
+// each entry, with the value key=value, by order of keys. The fields of nested
+// structs are serialized as parameters of the outer object, unless the struct
+// implements Marshaler; embedded structs without a name in their JSON tag and
+// other types are not serialized. Decode converts the parameters back.
 func Convert(obj interface{}) (url.Values, error) {
 	result := url.Values{}
 	if obj == nil {
@@ -183,6 +209,8 @@ func convertStruct(result url.Values, st reflect.Type, sv reflect.Value) {
 			if isValueKind(ft.Elem().Kind()) {
 				addListOfParams(result, tag, omitempty, field)
 			}
+		case isStringMap(ft):
+			addMapOfParams(result, tag, field)
 		case isStructKind(kind) && !(zeroValue(field) && omitempty):
 			if marshalValue, ok := customMarshalValue(field); ok {
 				addParam(result, tag, omitempty, marshalValue)
@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryparams
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Decode sets the fields of the struct obj points to from values, the inverse
+// of Convert. Parameters are matched to fields by their JSON tags. Simple
+// values are parsed from the first value of their parameter, slices from all
+// its values, and maps from values of the form key=value. Fields of types
+// implementing Unmarshaler are set by UnmarshalQueryParameter, and the fields
+// of other nested structs are decoded from the parameters of the outer
+// object: fields of different nested structs with the same name are decoded
+// from the same parameter. Pointers are allocated for the parameters present, except for empty
+// values of non-string types, which Convert writes for nil pointers. Fields
+// without parameter are left unchanged, and parameters without field are
+// ignored, see UnknownParameters.
+func Decode(values url.Values, obj interface{}) error {
+	sv, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	return decodeStruct(values, sv)
+}
+
+// UnknownParameters returns the names of the parameters of values which do
+// not match any field of obj, as encoded by Convert, sorted, e.g. to reject
+// requests with misspelled parameters.
+func UnknownParameters(values url.Values, obj interface{}) ([]string, error) {
+	sv, err := structValue(obj)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	addParameterNames(known, sv.Type(), map[reflect.Type]bool{})
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+func structValue(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expecting a pointer to a struct, got %T", obj)
+	}
+	return v.Elem(), nil
+}
+
+// addParameterNames adds the names of the parameters of the fields of the
+// struct type t to names.
+func addParameterNames(names map[string]bool, t reflect.Type, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		tag, _ := jsonTag(t.Field(i))
+		if len(tag) == 0 {
+			continue
+		}
+		ft := t.Field(i).Type
+		if isPointerKind(ft.Kind()) {
+			ft = ft.Elem()
+		}
+		switch {
+		case isValueKind(ft.Kind()), isStringMap(ft), reflect.PointerTo(ft).Implements(unmarshalerType):
+			names[tag] = true
+		case ft.Kind() == reflect.Array || ft.Kind() == reflect.Slice:
+			if isValueKind(ft.Elem().Kind()) {
+				names[tag] = true
+			}
+		case isStructKind(ft.Kind()):
+			addParameterNames(names, ft, visiting)
+		}
+	}
+}
+
+func decodeStruct(values url.Values, sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		tag, _ := jsonTag(st.Field(i))
+		if len(tag) == 0 || !sv.Field(i).CanSet() {
+			continue
+		}
+		field := sv.Field(i)
+		ft := field.Type()
+		params, present := values[tag]
+
+		if isPointerKind(ft.Kind()) {
+			elemType := ft.Elem()
+			if isStructKind(elemType.Kind()) && !reflect.PointerTo(elemType).Implements(unmarshalerType) {
+				// nested structs are allocated if any of their fields is set
+				nested := reflect.New(elemType)
+				if field.IsNil() {
+					if err := decodeStruct(values, nested.Elem()); err != nil {
+						return err
+					}
+					if !nested.Elem().IsZero() {
+						field.Set(nested)
+					}
+					continue
+				}
+				if err := decodeStruct(values, field.Elem()); err != nil {
+					return err
+				}
+				continue
+			}
+			if !present || len(params) == 0 || (len(params[0]) == 0 && elemType.Kind() != reflect.String) {
+				continue
+			}
+			if field.IsNil() {
+				field.Set(reflect.New(elemType))
+			}
+			field = field.Elem()
+			ft = elemType
+		}
+
+		switch {
+		case reflect.PointerTo(ft).Implements(unmarshalerType):
+			if present && len(params) > 0 {
+				if err := field.Addr().Interface().(Unmarshaler).UnmarshalQueryParameter(params[0]); err != nil {
+					return fmt.Errorf("invalid value %q for query parameter %q: %v", params[0], tag, err)
+				}
+			}
+		case isValueKind(ft.Kind()):
+			if present && len(params) > 0 {
+				if err := setValue(field, params[0]); err != nil {
+					return fmt.Errorf("invalid value %q for query parameter %q: %v", params[0], tag, err)
+				}
+			}
+		case ft.Kind() == reflect.Slice && isValueKind(ft.Elem().Kind()):
+			if !present {
+				continue
+			}
+			slice := reflect.MakeSlice(ft, len(params), len(params))
+			for j, param := range params {
+				if err := setValue(slice.Index(j), param); err != nil {
+					return fmt.Errorf("invalid value %q for query parameter %q: %v", param, tag, err)
+				}
+			}
+			field.Set(slice)
+		case ft.Kind() == reflect.Array && isValueKind(ft.Elem().Kind()):
+			for j := 0; j < len(params) && j < ft.Len(); j++ {
+				if err := setValue(field.Index(j), params[j]); err != nil {
+					return fmt.Errorf("invalid value %q for query parameter %q: %v", params[j], tag, err)
+				}
+			}
+		case isStringMap(ft):
+			if !present {
+				continue
+			}
+			m := reflect.MakeMapWithSize(ft, len(params))
+			for _, param := range params {
+				key, value, found := strings.Cut(param, "=")
+				if !found {
+					return fmt.Errorf("invalid value %q for query parameter %q: expected key=value", param, tag)
+				}
+				elem := reflect.New(ft.Elem()).Elem()
+				if err := setValue(elem, value); err != nil {
+					return fmt.Errorf("invalid value %q for query parameter %q: %v", param, tag, err)
+				}
+				m.SetMapIndex(reflect.ValueOf(key).Convert(ft.Key()), elem)
+			}
+			field.Set(m)
+		case isStructKind(ft.Kind()):
+			if err := decodeStruct(values, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setValue parses s into v, a value of a simple kind. Empty strings leave
+// values other than strings unchanged.
+func setValue(v reflect.Value, s string) error {
+	if len(s) == 0 && v.Kind() != reflect.String {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		c, err := strconv.ParseComplex(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetComplex(c)
+	default:
+		return fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryparams_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion/queryparams"
+)
+
+type nestedOptions struct {
+	Limit  int64 `json:"limit,omitempty"`
+	DryRun bool  `json:"dryRun,omitempty"`
+}
+
+type pagingOptions struct {
+	Continue string `json:"continue,omitempty"`
+}
+
+type decodeTarget struct {
+	Name      string            `json:"name"`
+	Ports     []int32           `json:"ports,omitempty"`
+	Weights   []float64         `json:"weights,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Limits    map[string]int64  `json:"limits,omitempty"`
+	Options   nestedOptions     `json:"options"`
+	Paging    *pagingOptions    `json:"paging,omitempty"`
+	Since     *metav1.Time      `json:"since,omitempty"`
+	Count     *int              `json:"count"`
+	Untagged  string
+	unexposed string
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	since := metav1.NewTime(time.Date(2000, 1, 1, 12, 34, 56, 0, time.UTC).Local())
+	testCases := []decodeTarget{
+		{Name: "empty"},
+		{
+			Name:     "all",
+			Ports:    []int32{80, 443},
+			Weights:  []float64{0.5, 1.25},
+			Selector: map[string]string{"app": "web", "tier": "a=b"},
+			Limits:   map[string]int64{"cpu": 2},
+			Options:  nestedOptions{Limit: 10, DryRun: true},
+			Paging:   &pagingOptions{Continue: "token"},
+			Since:    &since,
+			Count:    intp(0),
+		},
+	}
+	for _, expected := range testCases {
+		values, err := queryparams.Convert(&expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual decodeTarget
+		if err := queryparams.Decode(values, &actual); err != nil {
+			t.Fatalf("%s: %v", expected.Name, err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: expected %#v from %v, got %#v", expected.Name, expected, values, actual)
+		}
+		unknown, err := queryparams.UnknownParameters(values, &actual)
+		if err != nil || len(unknown) != 0 {
+			t.Errorf("%s: unexpected unknown parameters %v, %v", expected.Name, unknown, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	values := url.Values{
+		"name":     {"first", "second"},
+		"ports":    {"1", "2", "3"},
+		"selector": {"a=1", "b="},
+		"limit":    {"5"},
+		"continue": {"token"},
+		"count":    {""},
+	}
+	var actual decodeTarget
+	if err := queryparams.Decode(values, &actual); err != nil {
+		t.Fatal(err)
+	}
+	expected := decodeTarget{
+		Name:     "first",
+		Ports:    []int32{1, 2, 3},
+		Selector: map[string]string{"a": "1", "b": ""},
+		Options:  nestedOptions{Limit: 5},
+		Paging:   &pagingOptions{Continue: "token"},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	for _, invalid := range []url.Values{
+		{"ports": {"80", "http"}},
+		{"ports": {"1.5"}},
+		{"selector": {"novalue"}},
+		{"limits": {"cpu=lots"}},
+		{"dryRun": {"maybe"}},
+		{"since": {"yesterday"}},
+	} {
+		if err := queryparams.Decode(invalid, &decodeTarget{}); err == nil {
+			t.Errorf("expected an error decoding %v", invalid)
+		}
+	}
+	if err := queryparams.Decode(values, decodeTarget{}); err == nil {
+		t.Errorf("expected an error decoding into a struct which is not a pointer")
+	}
+}
+
+func TestUnknownParameters(t *testing.T) {
+	values := url.Values{"name": {"x"}, "limit": {"1"}, "Untagged": {"y"}, "lables": {"z"}, "since": {""}}
+	unknown, err := queryparams.UnknownParameters(values, &decodeTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"Untagged", "lables"}; !reflect.DeepEqual(expected, unknown) {
+		t.Errorf("expected %v, got %v", expected, unknown)
+	}
+}
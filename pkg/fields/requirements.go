@@ -18,6 +18,12 @@ package fields
 
 import "k8s.io/apimachinery/pkg/selection"
 
+// OrOperator is the operator of the requirements of selectors built by
+// OrSelectors, which hold alternatives instead of a field and value. It is
+// not a selection operator supported by servers, so consumers which do not
+// handle alternatives reject these requirements as unsupported.
+const OrOperator selection.Operator = "or"
+
 // Requirements is AND of all requirements.
 type Requirements []Requirement
 
@@ -27,4 +33,8 @@ type Requirement struct {
 	Operator selection.Operator
 	Field    string
 	Value    string
+	// Alternatives holds the requirements of the alternatives of a selector
+	// built by OrSelectors, at least one of which must be satisfied. It is only
+	// set when Operator is OrOperator, in which case Field and Value are empty.
+	Alternatives []Requirements
 }
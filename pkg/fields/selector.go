@@ -19,6 +19,7 @@ package fields
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -238,11 +239,7 @@ func (t andTerm) Requirements() Requirements {
 }
 
 func (t andTerm) String() string {
-	var terms []string
-	for _, q := range t {
-		terms = append(terms, q.String())
-	}
-	return strings.Join(terms, ",")
+	return selectorString(t, containsAlternatives(t))
 }
 
 func (t andTerm) DeepCopySelector() Selector {
@@ -256,6 +253,118 @@ func (t andTerm) DeepCopySelector() Selector {
 	return andTerm(out)
 }
 
+type orTerm []Selector
+
+func (t orTerm) Matches(ls Fields) bool {
+	for _, q := range t {
+		if q.Matches(ls) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t orTerm) Empty() bool {
+	for i := range t {
+		if t[i].Empty() {
+			return true
+		}
+	}
+	return false
+}
+
+func (t orTerm) RequiresExactMatch(field string) (string, bool) {
+	if len(t) == 0 {
+		return "", false
+	}
+	value, found := t[0].RequiresExactMatch(field)
+	if !found {
+		return "", false
+	}
+	for i := range t[1:] {
+		if v, found := t[i+1].RequiresExactMatch(field); !found || v != value {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+func (t orTerm) Transform(fn TransformFunc) (Selector, error) {
+	next := make([]Selector, 0, len(t))
+	for _, s := range t {
+		n, err := s.Transform(fn)
+		if err != nil {
+			return nil, err
+		}
+		if n.Empty() {
+			return Everything(), nil
+		}
+		next = append(next, n)
+	}
+	return orTerm(next), nil
+}
+
+// Requirements returns a single OrOperator requirement holding the
+// requirements of the alternatives, or nil if one of them does not restrict the
+// selection space.
+func (t orTerm) Requirements() Requirements {
+	if t.Empty() {
+		return nil
+	}
+	alternatives := make([]Requirements, 0, len(t))
+	for _, s := range t {
+		alternatives = append(alternatives, s.Requirements())
+	}
+	return []Requirement{{
+		Operator:     OrOperator,
+		Alternatives: alternatives,
+	}}
+}
+
+func (t orTerm) String() string {
+	return selectorString(t, true)
+}
+
+func (t orTerm) DeepCopySelector() Selector {
+	if t == nil {
+		return nil
+	}
+	out := make([]Selector, len(t))
+	for i := range t {
+		out[i] = t[i].DeepCopySelector()
+	}
+	return orTerm(out)
+}
+
+// rawTerm is a term parsed by ParseSelectorExact, which remembers its original
+// text, so that String returns it unchanged.
+type rawTerm struct {
+	Selector
+	raw string
+}
+
+func (t *rawTerm) Transform(fn TransformFunc) (Selector, error) {
+	n, err := t.Selector.Transform(fn)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(n.Requirements(), t.Selector.Requirements()) {
+		return &rawTerm{Selector: n, raw: t.raw}, nil
+	}
+	return n, nil
+}
+
+func (t *rawTerm) String() string {
+	return t.raw
+}
+
+func (t *rawTerm) DeepCopySelector() Selector {
+	if t == nil {
+		return nil
+	}
+	return &rawTerm{Selector: t.Selector.DeepCopySelector(), raw: t.raw}
+}
+
 // SelectorFromSet returns a Selector which will match exactly the given Set. A
 // nil Set is considered equivalent to Everything().
 func SelectorFromSet(ls Set) Selector {
@@ -281,6 +390,62 @@ var valueEscaper = strings.NewReplacer(
 	`=`, `\=`,
 )
 
+// alternativeEscaper prefixes ()| characters with a backslash, to allow
+// unambiguous parsing of selectors with alternatives by
+// ParseSelectorWithAlternatives
+var alternativeEscaper = strings.NewReplacer(
+	`(`, `\(`,
+	`)`, `\)`,
+	`|`, `\|`,
+)
+
+// containsAlternatives returns true if s is or contains an OR of selectors.
+func containsAlternatives(s Selector) bool {
+	switch t := s.(type) {
+	case orTerm:
+		return true
+	case andTerm:
+		for _, q := range t {
+			if containsAlternatives(q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectorString returns the String of s. If alternatives is true, ()|
+// characters are escaped as well, in the form parsed by
+// ParseSelectorWithAlternatives.
+func selectorString(s Selector, alternatives bool) string {
+	escape := func(s string) string {
+		if alternatives {
+			return alternativeEscaper.Replace(s)
+		}
+		return s
+	}
+	switch t := s.(type) {
+	case *hasTerm:
+		return escape(t.field) + "=" + escape(EscapeValue(t.value))
+	case *notHasTerm:
+		return escape(t.field) + "!=" + escape(EscapeValue(t.value))
+	case andTerm:
+		terms := make([]string, 0, len(t))
+		for _, q := range t {
+			terms = append(terms, selectorString(q, alternatives))
+		}
+		return strings.Join(terms, ",")
+	case orTerm:
+		terms := make([]string, 0, len(t))
+		for _, q := range t {
+			terms = append(terms, "("+selectorString(q, true)+")")
+		}
+		return strings.Join(terms, "|")
+	default:
+		return escape(s.String())
+	}
+}
+
 // EscapeValue escapes an arbitrary literal string for use as a fieldSelector value
 func EscapeValue(s string) string {
 	return valueEscaper.Replace(s)
@@ -427,31 +592,39 @@ func splitTerm(term string) (lhs, op, rhs string, ok bool) {
 	return "", "", "", false
 }
 
+// ParseSelectorExact is like ParseSelector, but the returned selector keeps the
+// order and the exact text of the terms, including the operator used and the
+// escaping of values, so that its String method returns selector unchanged,
+// apart from empty terms. Terms are kept as they are by Transform unless their
+// field or value is changed. This is useful for proxies rewriting some terms
+// of selectors before forwarding them.
+func ParseSelectorExact(selector string) (Selector, error) {
+	return parseSelectorTerms(selector, func(lhs, rhs string) (newLhs, newRhs string, err error) {
+		return lhs, rhs, nil
+	}, true)
+}
+
 func parseSelector(selector string, fn TransformFunc) (Selector, error) {
+	return parseSelectorTerms(selector, fn, false)
+}
+
+func parseSelectorTerms(selector string, fn TransformFunc, exact bool) (Selector, error) {
 	parts := splitTerms(selector)
-	sort.StringSlice(parts).Sort()
+	if !exact {
+		sort.StringSlice(parts).Sort()
+	}
 	var items []Selector
 	for _, part := range parts {
 		if part == "" {
 			continue
 		}
-		lhs, op, rhs, ok := splitTerm(part)
-		if !ok {
-			return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
-		}
-		unescapedRHS, err := UnescapeValue(rhs)
+		item, err := parseTerm(selector, part)
 		if err != nil {
 			return nil, err
 		}
-		switch op {
-		case notEqualOperator:
-			items = append(items, &notHasTerm{field: lhs, value: unescapedRHS})
-		case doubleEqualOperator:
-			items = append(items, &hasTerm{field: lhs, value: unescapedRHS})
-		case equalOperator:
-			items = append(items, &hasTerm{field: lhs, value: unescapedRHS})
-		default:
-			return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
+		items = append(items, item)
+		if exact {
+			items[len(items)-1] = &rawTerm{Selector: items[len(items)-1], raw: part}
 		}
 	}
 	if len(items) == 1 {
@@ -460,6 +633,155 @@ func parseSelector(selector string, fn TransformFunc) (Selector, error) {
 	return andTerm(items).Transform(fn)
 }
 
+// parseTerm parses a single term of selector.
+func parseTerm(selector, part string) (Selector, error) {
+	lhs, op, rhs, ok := splitTerm(part)
+	if !ok {
+		return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
+	}
+	unescapedRHS, err := UnescapeValue(rhs)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case notEqualOperator:
+		return &notHasTerm{field: lhs, value: unescapedRHS}, nil
+	case doubleEqualOperator, equalOperator:
+		return &hasTerm{field: lhs, value: unescapedRHS}, nil
+	default:
+		return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
+	}
+}
+
+// ParseSelectorWithAlternatives is like ParseSelector, but also parses the
+// alternatives of selectors built by OrSelectors, as returned by their String
+// method. Alternatives are separated by |, which binds more tightly than the
+// commas separating terms, and can be grouped in parentheses:
+//
+//	(a=1)|(b=2),c=3    matches objects where a=1 or b=2, and c=3
+//	(a=1,b=2)|(c=3)    matches objects where a=1 and b=2, or c=3
+//
+// ()| characters in fields and values must be escaped with a backslash. Unlike
+// ParseSelector, the terms are kept in order, so that the String method of the
+// returned selector returns selector unchanged. Field selectors sent to
+// servers do not support alternatives, and must be parsed with ParseSelector.
+func ParseSelectorWithAlternatives(selector string) (Selector, error) {
+	if selector == "" {
+		return Everything(), nil
+	}
+	p := &alternativesParser{selector: selector}
+	s, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(selector) {
+		return nil, fmt.Errorf("invalid selector: '%s'; unexpected '%c'", selector, selector[p.pos])
+	}
+	return s, nil
+}
+
+// alternativesParser parses selectors with alternatives by recursive descent.
+type alternativesParser struct {
+	selector string
+	pos      int
+}
+
+// peek returns the next byte of the selector, or 0 at the end.
+func (p *alternativesParser) peek() byte {
+	if p.pos < len(p.selector) {
+		return p.selector[p.pos]
+	}
+	return 0
+}
+
+// parseAnd parses terms separated by commas.
+func (p *alternativesParser) parseAnd() (Selector, error) {
+	var items []Selector
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return andTerm(items), nil
+}
+
+// parseOr parses alternatives separated by |.
+func (p *alternativesParser) parseOr() (Selector, error) {
+	var items []Selector
+	for {
+		item, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	return OrSelectors(items...), nil
+}
+
+// parseAlternative parses a parenthesized selector, or a single term.
+func (p *alternativesParser) parseAlternative() (Selector, error) {
+	if p.peek() != '(' {
+		return p.parseTerm()
+	}
+	p.pos++
+	s, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, fmt.Errorf("invalid selector: '%s'; missing ')'", p.selector)
+	}
+	p.pos++
+	return s, nil
+}
+
+// parseTerm parses the term ending at the next unescaped ,|() character.
+// Backslash-escaped ()| characters are unescaped, while other escape sequences
+// are left to UnescapeValue.
+func (p *alternativesParser) parseTerm() (Selector, error) {
+	var term strings.Builder
+	inSlash := false
+	for ; p.pos < len(p.selector); p.pos++ {
+		c := p.selector[p.pos]
+		if inSlash {
+			if !strings.ContainsRune("()|", rune(c)) {
+				term.WriteByte('\\')
+			}
+			term.WriteByte(c)
+			inSlash = false
+			continue
+		}
+		if c == '\\' {
+			inSlash = true
+			continue
+		}
+		if strings.ContainsRune(",|()", rune(c)) {
+			break
+		}
+		term.WriteByte(c)
+	}
+	if inSlash {
+		term.WriteByte('\\')
+	}
+	if term.Len() == 0 {
+		return nil, fmt.Errorf("invalid selector: '%s'; empty term at position %d", p.selector, p.pos)
+	}
+	return parseTerm(p.selector, term.String())
+}
+
 // OneTermEqualSelector returns an object that matches objects where one field/field equals one value.
 // Cannot return an error.
 func OneTermEqualSelector(k, v string) Selector {
@@ -476,3 +798,57 @@ func OneTermNotEqualSelector(k, v string) Selector {
 func AndSelectors(selectors ...Selector) Selector {
 	return andTerm(selectors)
 }
+
+// OrSelectors creates a selector that is the logical OR of all the given
+// selectors. An OR of no selectors matches nothing, and an OR of a single
+// selector is that selector.
+//
+// The String method of the returned selector encloses the alternatives in
+// parentheses separated by |, e.g. "(a=1)|(b=2)", which
+// ParseSelectorWithAlternatives parses back, but ParseSelector does not. Its
+// Requirements consist of a single OrOperator requirement holding the
+// requirements of the alternatives.
+func OrSelectors(selectors ...Selector) Selector {
+	switch len(selectors) {
+	case 0:
+		return Nothing()
+	case 1:
+		return selectors[0]
+	}
+	return orTerm(selectors)
+}
+
+// OneTermInSelector returns a selector matching objects where the field equals
+// one of values.
+func OneTermInSelector(k string, values ...string) Selector {
+	items := make([]Selector, 0, len(values))
+	for _, v := range values {
+		items = append(items, &hasTerm{field: k, value: v})
+	}
+	return OrSelectors(items...)
+}
+
+// OneTermNotInSelector returns a selector matching objects where the field
+// equals none of values. Unlike OneTermInSelector, the returned selector can
+// be serialized with String, as an AND of != terms.
+func OneTermNotInSelector(k string, values ...string) Selector {
+	items := make([]Selector, 0, len(values))
+	for _, v := range values {
+		items = append(items, &notHasTerm{field: k, value: v})
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return andTerm(items)
+}
+
+// TransformValues returns a copy of selector with the field and value of every
+// term replaced by the result of fn, e.g. to rewrite namespaces. Terms for
+// which fn returns an empty field and value are dropped. An error is only
+// returned if the Transform method of a custom Selector fails.
+func TransformValues(selector Selector, fn func(field, value string) (string, string)) (Selector, error) {
+	return selector.Transform(func(field, value string) (string, string, error) {
+		newField, newValue := fn(field, value)
+		return newField, newValue, nil
+	})
+}
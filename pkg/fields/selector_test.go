@@ -17,8 +17,11 @@ limitations under the License.
 package fields
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 func TestSplitTerms(t *testing.T) {
@@ -395,3 +398,259 @@ func TestTransform(t *testing.T) {
 	}
 
 }
+
+func TestOrSelectors(t *testing.T) {
+	s := OrSelectors(OneTermEqualSelector("a", "1"), OneTermEqualSelector("b", "2"))
+	for fields, expected := range map[string]bool{"a=1": true, "b=2": true, "a=2,b=1": false} {
+		set := Set{}
+		for _, term := range ParseSelectorOrDie(fields).Requirements() {
+			set[term.Field] = term.Value
+		}
+		if s.Matches(set) != expected {
+			t.Errorf("expected %s to match %v: %t", s, set, expected)
+		}
+	}
+	if s.Empty() {
+		t.Errorf("expected an OR of terms not to be empty")
+	}
+	expectedRequirements := Requirements{{
+		Operator: OrOperator,
+		Alternatives: []Requirements{
+			{{Operator: selection.Equals, Field: "a", Value: "1"}},
+			{{Operator: selection.Equals, Field: "b", Value: "2"}},
+		},
+	}}
+	if !reflect.DeepEqual(s.Requirements(), expectedRequirements) {
+		t.Errorf("expected requirements %#v, got %#v", expectedRequirements, s.Requirements())
+	}
+	if !OrSelectors(OneTermEqualSelector("a", "1"), Everything()).Empty() {
+		t.Errorf("expected an OR with everything to be empty")
+	}
+	if OrSelectors().Matches(Set{}) {
+		t.Errorf("expected an empty OR to match nothing")
+	}
+
+	in := OneTermInSelector("metadata.namespace", "x", "x")
+	if value, found := in.RequiresExactMatch("metadata.namespace"); !found || value != "x" {
+		t.Errorf("expected an exact match on x, got %q, %t", value, found)
+	}
+	if _, found := OneTermInSelector("metadata.namespace", "x", "y").RequiresExactMatch("metadata.namespace"); found {
+		t.Errorf("expected no exact match for alternative values")
+	}
+
+	notIn := OneTermNotInSelector("status.phase", "Failed", "Succeeded")
+	if notIn.String() != "status.phase!=Failed,status.phase!=Succeeded" {
+		t.Errorf("unexpected selector %s", notIn)
+	}
+	if notIn.Matches(Set{"status.phase": "Failed"}) || !notIn.Matches(Set{"status.phase": "Running"}) {
+		t.Errorf("unexpected matches for %s", notIn)
+	}
+}
+
+func TestParseSelectorExact(t *testing.T) {
+	for _, selector := range []string{
+		"b==x,a=y",
+		`z!=a\,b,metadata.name=c\=d\\`,
+		"a=",
+	} {
+		s, err := ParseSelectorExact(selector)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", selector, err)
+		}
+		if s.String() != selector {
+			t.Errorf("expected %q, got %q", selector, s.String())
+		}
+		if s.DeepCopySelector().String() != selector {
+			t.Errorf("expected the copy of %q to be unchanged, got %q", selector, s.DeepCopySelector().String())
+		}
+	}
+
+	s, err := ParseSelectorExact(`metadata.namespace==tenant,metadata.name==a\,b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Matches(Set{"metadata.namespace": "tenant", "metadata.name": "a,b"}) {
+		t.Errorf("expected %s to match", s)
+	}
+	rewritten, err := TransformValues(s, func(field, value string) (string, string) {
+		if field == "metadata.namespace" {
+			return field, "prefix-" + value
+		}
+		return field, value
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `metadata.namespace=prefix-tenant,metadata.name==a\,b`; rewritten.String() != expected {
+		t.Errorf("expected %q, got %q", expected, rewritten.String())
+	}
+	if s.String() != `metadata.namespace==tenant,metadata.name==a\,b` {
+		t.Errorf("expected the original selector to be unchanged, got %q", s.String())
+	}
+}
+
+func TestTransformValues(t *testing.T) {
+	s, err := TransformValues(ParseSelectorOrDie("a=b,c!=d"), func(field, value string) (string, string) {
+		if field == "a" {
+			return "", ""
+		}
+		return field, value + "x"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "c!=dx" {
+		t.Errorf("unexpected selector %s", s)
+	}
+
+	s, err = TransformValues(OneTermInSelector("metadata.namespace", "a", "b"), func(field, value string) (string, string) {
+		return field, "prefix-" + value
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "(metadata.namespace=prefix-a)|(metadata.namespace=prefix-b)"; s.String() != expected {
+		t.Errorf("expected %q, got %q", expected, s.String())
+	}
+
+	if _, err := TransformValues(failingSelector{}, func(field, value string) (string, string) {
+		return field, value
+	}); err == nil {
+		t.Errorf("expected the error of the selector to be returned")
+	}
+}
+
+type failingSelector struct {
+	Selector
+}
+
+func (failingSelector) Transform(fn TransformFunc) (Selector, error) {
+	return nil, fmt.Errorf("transform failed")
+}
+
+func TestOrSelectorsRoundTrip(t *testing.T) {
+	testCases := []struct {
+		selector Selector
+		expected string
+		matches  []Set
+		others   []Set
+	}{
+		{
+			selector: OneTermInSelector("metadata.namespace", "a", "b"),
+			expected: "(metadata.namespace=a)|(metadata.namespace=b)",
+			matches:  []Set{{"metadata.namespace": "a"}, {"metadata.namespace": "b"}},
+			others:   []Set{{"metadata.namespace": "c"}, {}},
+		},
+		{
+			selector: OrSelectors(ParseSelectorOrDie("a=1,b!=2"), OneTermEqualSelector("c", "3")),
+			expected: "(a=1,b!=2)|(c=3)",
+			matches:  []Set{{"a": "1"}, {"c": "3"}},
+			others:   []Set{{"a": "1", "b": "2"}, {"c": "4"}},
+		},
+		{
+			selector: OneTermInSelector("metadata.name", `x)|(y`, `a\,b=c`, ""),
+			expected: `(metadata.name=x\)\|\(y)|(metadata.name=a\\\,b\=c)|(metadata.name=)`,
+			matches:  []Set{{"metadata.name": `x)|(y`}, {"metadata.name": `a\,b=c`}, {}},
+			others:   []Set{{"metadata.name": "x"}, {"metadata.name": "y"}},
+		},
+		{
+			selector: OrSelectors(OneTermInSelector("a", "1", "2"), OneTermEqualSelector("b", "(3)")),
+			expected: `((a=1)|(a=2))|(b=\(3\))`,
+			matches:  []Set{{"a": "1"}, {"a": "2"}, {"b": "(3)"}},
+			others:   []Set{{"a": "3"}, {"b": "3"}},
+		},
+		{
+			selector: AndSelectors(OneTermInSelector("a", "1", "2"), OneTermEqualSelector("c", "3")),
+			expected: "(a=1)|(a=2),c=3",
+			matches:  []Set{{"a": "1", "c": "3"}, {"a": "2", "c": "3"}},
+			others:   []Set{{"a": "1"}, {"c": "3"}, {"a": "3", "c": "3"}},
+		},
+		{
+			selector: AndSelectors(OneTermNotEqualSelector("c", "x|y"), OneTermInSelector("a", "1", "2")),
+			expected: `c!=x\|y,(a=1)|(a=2)`,
+			matches:  []Set{{"a": "1"}, {"a": "2", "c": "z"}},
+			others:   []Set{{"a": "1", "c": "x|y"}, {"a": "3"}},
+		},
+		{
+			selector: OrSelectors(AndSelectors(OneTermInSelector("a", "1", "2"), OneTermEqualSelector("b", "1")), OneTermEqualSelector("c", "3")),
+			expected: `((a=1)|(a=2),b=1)|(c=3)`,
+			matches:  []Set{{"a": "2", "b": "1"}, {"c": "3"}},
+			others:   []Set{{"a": "2"}, {"b": "1"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if tc.selector.String() != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, tc.selector.String())
+			}
+			for _, parse := range []func(string) (Selector, error){ParseSelectorWithAlternatives, parseReparsed} {
+				parsed, err := parse(tc.selector.String())
+				if err != nil {
+					t.Fatalf("unexpected error parsing %q: %v", tc.selector.String(), err)
+				}
+				if parsed.String() != tc.expected {
+					t.Errorf("expected %q to round-trip, got %q", tc.expected, parsed.String())
+				}
+				if !reflect.DeepEqual(parsed.Requirements(), tc.selector.Requirements()) {
+					t.Errorf("expected requirements %#v, got %#v", tc.selector.Requirements(), parsed.Requirements())
+				}
+				for _, set := range tc.matches {
+					if !parsed.Matches(set) {
+						t.Errorf("expected %s to match %v", parsed, set)
+					}
+				}
+				for _, set := range tc.others {
+					if parsed.Matches(set) {
+						t.Errorf("expected %s not to match %v", parsed, set)
+					}
+				}
+			}
+		})
+	}
+
+	for _, selector := range []string{
+		"(a=1",
+		"(a=1)|",
+		"(a=1)(b=2)",
+		"(a=(1))",
+		`(a=\1)`,
+		"a=1)",
+		",a=1",
+		"a=1||b=2",
+	} {
+		if _, err := ParseSelectorWithAlternatives(selector); err == nil {
+			t.Errorf("expected an error parsing %q", selector)
+		}
+	}
+
+	// alternatives can be written without parentheses around single terms
+	s, err := ParseSelectorWithAlternatives("a=1|b=2,c!=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "(a=1)|(b=2),c!=3" || !s.Matches(Set{"b": "2"}) || s.Matches(Set{"b": "2", "c": "3"}) {
+		t.Errorf("unexpected selector %s", s)
+	}
+
+	// the grammar of ParseSelector is unchanged
+	s, err = ParseSelector("(a=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, found := s.RequiresExactMatch("(a"); !found || value != "1" {
+		t.Errorf("expected ParseSelector to read (a as a field, got %s", s)
+	}
+	if _, err := ParseSelector("(a=1)|(b=2)"); err == nil {
+		t.Errorf("expected ParseSelector not to parse alternatives")
+	}
+}
+
+// parseReparsed parses selector with ParseSelectorWithAlternatives twice,
+// going through String in between.
+func parseReparsed(selector string) (Selector, error) {
+	s, err := ParseSelectorWithAlternatives(selector)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSelectorWithAlternatives(s.String())
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// With returns a Set with the label key set to value. If the label already has
+// this value, ls itself is returned, otherwise ls is copied and left unchanged.
+func (ls Set) With(key, value string) Set {
+	if current, ok := ls[key]; ok && current == value {
+		return ls
+	}
+	out := make(Set, len(ls)+1)
+	for k, v := range ls {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// Without returns a Set without the label key. If ls does not have the label,
+// ls itself is returned, otherwise ls is copied and left unchanged.
+func (ls Set) Without(key string) Set {
+	if _, ok := ls[key]; !ok {
+		return ls
+	}
+	out := make(Set, len(ls))
+	for k, v := range ls {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Builder accumulates changes to a Set, validating the keys and values of the
+// labels which are set. The Set it starts from is copied on the first change
+// only, so that deriving labels which turn out to be unchanged does not allocate.
+//
+// The zero value is a Builder starting from an empty Set.
+type Builder struct {
+	set Set
+	// copied is true once set was first copied.
+	copied bool
+	// owned is true while set is a copy which was not returned by Result, and
+	// may therefore be modified in place.
+	owned bool
+	errs  field.ErrorList
+	path  *field.Path
+}
+
+// NewBuilder returns a Builder starting from base, which is never modified.
+// Validation errors refer to the labels as fields under path, which may be nil.
+func NewBuilder(base Set, path *field.Path) *Builder {
+	return &Builder{set: base, path: path}
+}
+
+// Set sets the label key to value, and returns b. Invalid keys and values are
+// recorded as errors returned by Result, and are not set.
+func (b *Builder) Set(key, value string) *Builder {
+	if err := validateLabelKey(key, b.path.Key(key)); err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	if err := validateLabelValue(key, value, b.path); err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	if current, ok := b.set[key]; ok && current == value {
+		return b
+	}
+	b.copyOnWrite()
+	b.set[key] = value
+	return b
+}
+
+// SetAll sets all labels of ls, and returns b.
+func (b *Builder) SetAll(ls Set) *Builder {
+	for k, v := range ls {
+		b.Set(k, v)
+	}
+	return b
+}
+
+// Delete removes the label key, and returns b.
+func (b *Builder) Delete(key string) *Builder {
+	if _, ok := b.set[key]; !ok {
+		return b
+	}
+	b.copyOnWrite()
+	delete(b.set, key)
+	return b
+}
+
+// Result returns the resulting Set, and the aggregate of the validation errors
+// of all invalid labels that were set, if any. If there were no changes, the Set
+// the Builder started from is returned. Changes made after Result copy the Set
+// again, so the returned Set is never modified by the Builder.
+func (b *Builder) Result() (Set, error) {
+	b.owned = false
+	return b.set, b.errs.ToAggregate()
+}
+
+// Changed returns true if any label was added, changed or deleted, in which
+// case Result returns a copy of the Set the Builder started from.
+func (b *Builder) Changed() bool {
+	return b.copied
+}
+
+func (b *Builder) copyOnWrite() {
+	if b.owned {
+		return
+	}
+	set := make(Set, len(b.set)+1)
+	for k, v := range b.set {
+		set[k] = v
+	}
+	b.set = set
+	b.copied = true
+	b.owned = true
+}
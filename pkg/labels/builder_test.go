@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// sameSet returns true if a and b are the same map.
+func sameSet(a, b Set) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func TestSetWithWithout(t *testing.T) {
+	base := Set{"a": "1", "b": "2"}
+
+	if got := base.With("a", "1"); !sameSet(got, base) {
+		t.Errorf("Expected With of an unchanged label to return the same set")
+	}
+	if got := base.With("a", "3"); sameSet(got, base) || !Equals(got, Set{"a": "3", "b": "2"}) {
+		t.Errorf("Expected a copy with a=3, got %v", got)
+	}
+	if got := base.With("c", ""); !Equals(got, Set{"a": "1", "b": "2", "c": ""}) {
+		t.Errorf("Expected a copy with c, got %v", got)
+	}
+	if got := base.Without("c"); !sameSet(got, base) {
+		t.Errorf("Expected Without of a missing label to return the same set")
+	}
+	if got := base.Without("a"); sameSet(got, base) || !Equals(got, Set{"b": "2"}) {
+		t.Errorf("Expected a copy without a, got %v", got)
+	}
+	if !Equals(base, Set{"a": "1", "b": "2"}) {
+		t.Errorf("Expected the base set to be unchanged, got %v", base)
+	}
+	if got := Set(nil).With("a", "1"); !Equals(got, Set{"a": "1"}) {
+		t.Errorf("Expected a new set, got %v", got)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	base := Set{"app": "web", "tier": "frontend"}
+
+	b := NewBuilder(base, field.NewPath("metadata", "labels")).Set("app", "web").Delete("missing")
+	if got, err := b.Result(); err != nil || !sameSet(got, base) || b.Changed() {
+		t.Errorf("Expected the base set to be returned without changes, got %v, %v", got, err)
+	}
+
+	b.Set("version", "v1").Delete("tier").SetAll(Set{"app": "api"})
+	got, err := b.Result()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !b.Changed() || !Equals(got, Set{"app": "api", "version": "v1"}) {
+		t.Errorf("Unexpected result %v", got)
+	}
+	if !Equals(base, Set{"app": "web", "tier": "frontend"}) {
+		t.Errorf("Expected the base set to be unchanged, got %v", base)
+	}
+
+	b.Set("app", "db").Delete("version")
+	if next, _ := b.Result(); !Equals(got, Set{"app": "api", "version": "v1"}) || !Equals(next, Set{"app": "db"}) {
+		t.Errorf("Expected changes after Result not to modify the earlier result, got %v and %v", got, next)
+	}
+
+	b = NewBuilder(nil, field.NewPath("metadata", "labels")).
+		Set("good", "value").
+		Set("bad key", "value").
+		Set("key", strings.Repeat("x", 64))
+	got, err = b.Result()
+	if !Equals(got, Set{"good": "value"}) {
+		t.Errorf("Expected only valid labels to be set, got %v", got)
+	}
+	if err == nil {
+		t.Fatalf("Expected validation errors")
+	}
+	for _, expected := range []string{`metadata.labels[bad key]`, `metadata.labels[key]`, "63"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+
+	var zero Builder
+	if got, err := zero.Set("a", "b").Result(); err != nil || !Equals(got, Set{"a": "b"}) {
+		t.Errorf("Expected the zero Builder to start from an empty set, got %v, %v", got, err)
+	}
+}
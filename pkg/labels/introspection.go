@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// VisitRequirements calls visit with each requirement of selector, in the order
+// returned by Requirements, until visit returns false. A selector created from
+// a Set has an Equals requirement per label, sorted by key. Selectors which
+// select nothing have no requirements. The requirements of selectors created
+// from a Set are built while visiting them, which allocates for every label.
+func VisitRequirements(selector Selector, visit func(r *Requirement) bool) {
+	switch s := selector.(type) {
+	case internalSelector:
+		for i := range s {
+			if !visit(&s[i]) {
+				return
+			}
+		}
+	case nothingSelector:
+	case ValidatedSetSelector:
+		for _, key := range sortedKeys(s) {
+			r := Requirement{key: key, operator: selection.Equals, strValues: []string{s[key]}}
+			if !visit(&r) {
+				return
+			}
+		}
+	default:
+		requirements, selectable := selector.Requirements()
+		if !selectable {
+			return
+		}
+		for i := range requirements {
+			if !visit(&requirements[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the sorted, distinct keys of the requirements of selector. A
+// selector with a single key can be matched by looking up that key only.
+func Keys(selector Selector) []string {
+	var keys []string
+	VisitRequirements(selector, func(r *Requirement) bool {
+		keys = append(keys, r.key)
+		return true
+	})
+	if len(keys) < 2 {
+		return keys
+	}
+	sort.Strings(keys)
+	distinct := keys[:1]
+	for _, key := range keys[1:] {
+		if key != distinct[len(distinct)-1] {
+			distinct = append(distinct, key)
+		}
+	}
+	return distinct
+}
+
+// IsEverything returns true if selector matches all labels, like the selector
+// returned by Everything, because it has no requirements.
+func IsEverything(selector Selector) bool {
+	switch s := selector.(type) {
+	case internalSelector:
+		return len(s) == 0
+	case nothingSelector:
+		return false
+	case ValidatedSetSelector:
+		return len(s) == 0
+	}
+	requirements, selectable := selector.Requirements()
+	return selectable && len(requirements) == 0
+}
+
+// IsNothing returns true if selector is known to match no labels, like the
+// selector returned by Nothing. Selectors whose requirements contradict each
+// other, such as "a,!a", are not detected.
+func IsNothing(selector Selector) bool {
+	switch selector.(type) {
+	case internalSelector, ValidatedSetSelector:
+		return false
+	case nothingSelector:
+		return true
+	}
+	_, selectable := selector.Requirements()
+	return !selectable
+}
+
+func sortedKeys(s ValidatedSetSelector) []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"reflect"
+	"testing"
+)
+
+// wrappedSelector hides the concrete type of a selector, like selectors
+// implemented outside of this package.
+type wrappedSelector struct {
+	Selector
+}
+
+func TestSelectorIntrospection(t *testing.T) {
+	parsed, err := Parse("b=2,a in (1,3),!c,b!=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name         string
+		selector     Selector
+		requirements []string
+		keys         []string
+		everything   bool
+		nothing      bool
+	}{
+		{
+			name:       "everything",
+			selector:   Everything(),
+			everything: true,
+		},
+		{
+			name:     "nothing",
+			selector: Nothing(),
+			nothing:  true,
+		},
+		{
+			name:         "parsed",
+			selector:     parsed,
+			requirements: []string{"a in (1,3)", "b=2", "b!=4", "!c"},
+			keys:         []string{"a", "b", "c"},
+		},
+		{
+			name:         "set",
+			selector:     SelectorFromValidatedSet(Set{"y": "2", "x": "1"}),
+			requirements: []string{"x=1", "y=2"},
+			keys:         []string{"x", "y"},
+		},
+		{
+			name:       "empty set",
+			selector:   SelectorFromValidatedSet(nil),
+			everything: true,
+		},
+		{
+			name:         "single key",
+			selector:     SelectorFromSet(Set{"x": "1"}),
+			requirements: []string{"x=1"},
+			keys:         []string{"x"},
+		},
+		{
+			name:         "wrapped",
+			selector:     wrappedSelector{parsed},
+			requirements: []string{"a in (1,3)", "b=2", "b!=4", "!c"},
+			keys:         []string{"a", "b", "c"},
+		},
+		{
+			name:       "wrapped everything",
+			selector:   wrappedSelector{Everything()},
+			everything: true,
+		},
+		{
+			name:     "wrapped nothing",
+			selector: wrappedSelector{Nothing()},
+			nothing:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requirements []string
+			VisitRequirements(tc.selector, func(r *Requirement) bool {
+				requirements = append(requirements, r.String())
+				return true
+			})
+			if !reflect.DeepEqual(requirements, tc.requirements) {
+				t.Errorf("expected requirements %q, got %q", tc.requirements, requirements)
+			}
+			if keys := Keys(tc.selector); !reflect.DeepEqual(keys, tc.keys) {
+				t.Errorf("expected keys %q, got %q", tc.keys, keys)
+			}
+			if everything := IsEverything(tc.selector); everything != tc.everything {
+				t.Errorf("expected IsEverything to be %v, got %v", tc.everything, everything)
+			}
+			if nothing := IsNothing(tc.selector); nothing != tc.nothing {
+				t.Errorf("expected IsNothing to be %v, got %v", tc.nothing, nothing)
+			}
+		})
+	}
+}
+
+func TestVisitRequirementsStops(t *testing.T) {
+	for _, selector := range []Selector{
+		SelectorFromValidatedSet(Set{"a": "1", "b": "2", "c": "3"}),
+		SelectorFromSet(Set{"a": "1", "b": "2", "c": "3"}),
+		wrappedSelector{SelectorFromSet(Set{"a": "1", "b": "2", "c": "3"})},
+	} {
+		var keys []string
+		VisitRequirements(selector, func(r *Requirement) bool {
+			keys = append(keys, r.Key())
+			return len(keys) < 2
+		})
+		if expected := []string{"a", "b"}; !reflect.DeepEqual(keys, expected) {
+			t.Errorf("%T: expected to visit %q, got %q", selector, expected, keys)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/conversion/queryparams"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -129,11 +130,28 @@ func (n NoopDecoder) Decode(data []byte, gvk *schema.GroupVersionKind, into Obje
 
 // NewParameterCodec creates a ParameterCodec capable of transforming url values into versioned objects and back.
 func NewParameterCodec(scheme *Scheme) ParameterCodec {
+	return NewParameterCodecWithOptions(scheme, ParameterCodecOptions{})
+}
+
+// ParameterCodecOptions holds the options of the ParameterCodecs created by
+// NewParameterCodecWithOptions.
+type ParameterCodecOptions struct {
+	// Strict makes DecodeParameters return a strict decoding error, see
+	// IsStrictDecodingError, if parameters do not match any field of the
+	// object they are decoded into. The object is decoded nonetheless.
+	Strict bool
+}
+
+// NewParameterCodecWithOptions creates a ParameterCodec like NewParameterCodec,
+// configured by options.
+func NewParameterCodecWithOptions(scheme *Scheme, options ParameterCodecOptions) ParameterCodec {
 	return &parameterCodec{
 		typer:     scheme,
 		convertor: scheme,
 		creator:   scheme,
 		defaulter: scheme,
+		converter: scheme.Converter(),
+		strict:    options.Strict,
 	}
 }
 
@@ -143,12 +161,16 @@ type parameterCodec struct {
 	convertor ObjectConvertor
 	creator   ObjectCreater
 	defaulter ObjectDefaulter
+	converter *conversion.Converter
+	strict    bool
 }
 
 var _ ParameterCodec = &parameterCodec{}
 
 // DecodeParameters converts the provided url.Values into an object of type From with the kind of into, and then
 // converts that object to into (if necessary). Returns an error if the operation cannot be completed.
+// Types without conversion function from url.Values are decoded as encoded by EncodeParameters, see
+// queryparams.Decode.
 func (c *parameterCodec) DecodeParameters(parameters url.Values, from schema.GroupVersion, into Object) error {
 	if len(parameters) == 0 {
 		return nil
@@ -159,14 +181,14 @@ func (c *parameterCodec) DecodeParameters(parameters url.Values, from schema.Gro
 	}
 	for i := range targetGVKs {
 		if targetGVKs[i].GroupVersion() == from {
-			if err := c.convertor.Convert(&parameters, into, nil); err != nil {
+			if err := c.convertParameters(parameters, into); err != nil {
 				return err
 			}
 			// in the case where we going into the same object we're receiving, default on the outbound object
 			if c.defaulter != nil {
 				c.defaulter.Default(into)
 			}
-			return nil
+			return c.checkUnknownParameters(parameters, into)
 		}
 	}
 
@@ -174,14 +196,43 @@ func (c *parameterCodec) DecodeParameters(parameters url.Values, from schema.Gro
 	if err != nil {
 		return err
 	}
-	if err := c.convertor.Convert(&parameters, input, nil); err != nil {
+	if err := c.convertParameters(parameters, input); err != nil {
 		return err
 	}
 	// if we have defaulter, default the input before converting to output
 	if c.defaulter != nil {
 		c.defaulter.Default(input)
 	}
-	return c.convertor.Convert(input, into, nil)
+	if err := c.convertor.Convert(input, into, nil); err != nil {
+		return err
+	}
+	return c.checkUnknownParameters(parameters, input)
+}
+
+// convertParameters converts parameters into obj, with the conversion function
+// registered for the type of obj if any.
+func (c *parameterCodec) convertParameters(parameters url.Values, obj Object) error {
+	if c.converter != nil && !c.converter.HasConversionFunc(&parameters, obj) {
+		return queryparams.Decode(parameters, obj)
+	}
+	return c.convertor.Convert(&parameters, obj, nil)
+}
+
+// checkUnknownParameters returns a strict decoding error listing the
+// parameters which do not match any field of obj, in strict mode.
+func (c *parameterCodec) checkUnknownParameters(parameters url.Values, obj Object) error {
+	if !c.strict {
+		return nil
+	}
+	unknown, err := queryparams.UnknownParameters(parameters, obj)
+	if err != nil || len(unknown) == 0 {
+		return err
+	}
+	errs := make([]error, 0, len(unknown))
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("unknown query parameter %q", name))
+	}
+	return NewStrictDecodingError(errs)
 }
 
 // EncodeParameters converts the provided object into the to version, then converts that object to url.Values.
@@ -275,6 +326,25 @@ func SerializerInfoForMediaType(types []SerializerInfo, mediaType string) (Seria
 	return SerializerInfo{}, false
 }
 
+// StreamSerializerInfoForMediaType returns the first info in types that has a stream serializer for the
+// media type of a stream, or false if no type matches. The media type matches the media type of the stream,
+// if set, or else the media type of the info, and cannot include media-type parameters.
+func StreamSerializerInfoForMediaType(types []SerializerInfo, mediaType string) (SerializerInfo, bool) {
+	for _, info := range types {
+		if info.StreamSerializer == nil {
+			continue
+		}
+		streamMediaType := info.StreamSerializer.MediaType
+		if len(streamMediaType) == 0 {
+			streamMediaType = info.MediaType
+		}
+		if streamMediaType == mediaType {
+			return info, true
+		}
+	}
+	return SerializerInfo{}, false
+}
+
 var (
 	// InternalGroupVersioner will always prefer the internal version for a given group version kind.
 	InternalGroupVersioner GroupVersioner = internalGroupVersioner{}
@@ -18,8 +18,12 @@ package runtime_test
 
 import (
 	"io"
+	"net/url"
+	"reflect"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/conversion"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
@@ -102,3 +106,84 @@ func TestCacheableObject(t *testing.T) {
 	serializer := runtime.NewBase64Serializer(&mockEncoder{}, nil)
 	runtimetesting.CacheableObjectTest(t, serializer)
 }
+
+type testPaging struct {
+	Limit    int64  `json:"limit,omitempty"`
+	Continue string `json:"continue,omitempty"`
+}
+
+type testParameters struct {
+	runtime.TypeMeta `json:",inline"`
+	Name             string            `json:"name,omitempty"`
+	Ports            []int32           `json:"ports,omitempty"`
+	Selector         map[string]string `json:"selector,omitempty"`
+	Paging           *testPaging       `json:"paging,omitempty"`
+}
+
+func (p *testParameters) DeepCopyObject() runtime.Object {
+	panic("unimplemented")
+}
+
+type convertedParameters struct {
+	runtime.TypeMeta `json:",inline"`
+	Name             string `json:"name,omitempty"`
+}
+
+func (p *convertedParameters) DeepCopyObject() runtime.Object {
+	panic("unimplemented")
+}
+
+func TestParameterCodec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gv("test.group", "v1"), &testParameters{}, &convertedParameters{})
+	if err := scheme.AddConversionFunc((*url.Values)(nil), (*convertedParameters)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		b.(*convertedParameters).Name = "converted " + (*a.(*url.Values)).Get("name")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &testParameters{
+		Name:     "web",
+		Ports:    []int32{80, 443},
+		Selector: map[string]string{"app": "web"},
+		Paging:   &testPaging{Limit: 10, Continue: "token"},
+	}
+	codec := runtime.NewParameterCodec(scheme)
+	values, err := codec.EncodeParameters(expected, gv("test.group", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedValues := url.Values{"name": {"web"}, "ports": {"80", "443"}, "selector": {"app=web"}, "limit": {"10"}, "continue": {"token"}}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("expected %v, got %v", expectedValues, values)
+	}
+	values.Add("unknown", "x")
+
+	actual := &testParameters{}
+	if err := codec.DecodeParameters(values, gv("test.group", "v1"), actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	strictCodec := runtime.NewParameterCodecWithOptions(scheme, runtime.ParameterCodecOptions{Strict: true})
+	actual = &testParameters{}
+	err = strictCodec.DecodeParameters(values, gv("test.group", "v1"), actual)
+	if !runtime.IsStrictDecodingError(err) {
+		t.Errorf("expected a strict decoding error, got %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %#v despite unknown parameters, got %#v", expected, actual)
+	}
+
+	// registered conversion functions take precedence
+	converted := &convertedParameters{}
+	if err := strictCodec.DecodeParameters(url.Values{"name": {"web"}}, gv("test.group", "v1"), converted); err != nil {
+		t.Fatal(err)
+	}
+	if converted.Name != "converted web" {
+		t.Errorf("expected the conversion function to be used, got %q", converted.Name)
+	}
+}
@@ -41,6 +41,18 @@ func DefaultMetaV1FieldSelectorConversion(label, value string) (string, string,
 	}
 }
 
+// defaultFieldLabels are the field labels accepted by DefaultMetaV1FieldSelectorConversion.
+var defaultFieldLabels = []string{"metadata.name", "metadata.namespace"}
+
+// quotedList formats items like "a", "b".
+func quotedList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // JSONKeyMapper uses the struct tags on a conversion to determine the key value for
 // the other side. Use when mapping from a map[string]* to a struct or vice versa.
 func JSONKeyMapper(key string, sourceTag, destTag reflect.StructTag) (string, string) {
@@ -71,6 +71,7 @@ func newFieldsCache() *fieldsCache {
 var (
 	mapStringInterfaceType = reflect.TypeOf(map[string]interface{}{})
 	stringType             = reflect.TypeOf(string(""))
+	jsonNumberType         = reflect.TypeOf(encodingjson.Number(""))
 	fieldCache             = newFieldsCache()
 
 	// DefaultUnstructuredConverter performs unstructured to Go typed object conversions.
@@ -104,6 +105,61 @@ type unstructuredConverter struct {
 	mismatchDetection bool
 	// comparison is the default test logic used to compare
 	comparison conversion.Equalities
+	// hooks holds the registered conversion hooks, replaced on registration.
+	hooks atomic.Pointer[conversionHooks]
+}
+
+// UnstructuredConversionHook converts values of a Go type to and from their
+// unstructured representation, instead of the reflection based conversion or
+// a round trip through the JSON marshaller and unmarshaller of the type. The
+// results must match the JSON representation of the type.
+type UnstructuredConversionHook struct {
+	// ToUnstructured returns the unstructured representation of obj, which is a
+	// value of the type: nil, bool, int64, float64, string, []interface{} or
+	// map[string]interface{}.
+	ToUnstructured func(obj interface{}) (interface{}, error)
+	// FromUnstructured sets the value pointed to by obj, which is a pointer to
+	// the type, from the non-nil unstructured value u.
+	FromUnstructured func(u interface{}, obj interface{}) error
+}
+
+// conversionHooks maps Go types to their conversion hooks.
+type conversionHooks map[reflect.Type]UnstructuredConversionHook
+
+// RegisterConversionHook registers hook for converting values of the type of
+// sample, e.g. resource.Quantity{}, replacing any hook registered for the type
+// before. Hooks apply wherever values of the type appear, including behind
+// pointers, in slices and maps, and in fields. Both functions of the hook are
+// required.
+//
+// Hooks should be registered during initialization, before the converter is
+// used concurrently.
+func (c *unstructuredConverter) RegisterConversionHook(sample interface{}, hook UnstructuredConversionHook) error {
+	t := reflect.TypeOf(sample)
+	if t == nil {
+		return fmt.Errorf("cannot register a conversion hook for nil")
+	}
+	if hook.ToUnstructured == nil || hook.FromUnstructured == nil {
+		return fmt.Errorf("conversion hook for %v requires both ToUnstructured and FromUnstructured", t)
+	}
+	// copy on write, so that conversions in progress are not affected
+	hooks := conversionHooks{}
+	if current := c.hooks.Load(); current != nil {
+		for k, v := range *current {
+			hooks[k] = v
+		}
+	}
+	hooks[t] = hook
+	c.hooks.Store(&hooks)
+	return nil
+}
+
+// loadHooks returns the registered conversion hooks, or nil.
+func (c *unstructuredConverter) loadHooks() conversionHooks {
+	if hooks := c.hooks.Load(); hooks != nil {
+		return *hooks
+	}
+	return nil
 }
 
 // NewTestUnstructuredConverter creates an UnstructuredConverter that accepts JSON typed maps and translates them
@@ -148,6 +204,8 @@ type fromUnstructuredContext struct {
 	// the full path to each unknown field in the
 	// object.
 	unknownFieldErrors []error
+	// hooks are the conversion hooks of the converter.
+	hooks conversionHooks
 }
 
 // pushMatchedKeyTracker adds a placeholder set for tracking
@@ -243,6 +301,7 @@ func (c *unstructuredConverter) FromUnstructuredWithValidation(u map[string]inte
 
 	fromUnstructuredContext := &fromUnstructuredContext{
 		returnUnknownFields: returnUnknownFields,
+		hooks:               c.loadHooks(),
 	}
 	err := fromUnstructured(reflect.ValueOf(u), value.Elem(), fromUnstructuredContext)
 	if c.mismatchDetection {
@@ -290,6 +349,27 @@ func fromUnstructured(sv, dv reflect.Value, ctx *fromUnstructuredContext) error
 	}
 	st, dt := sv.Type(), dv.Type()
 
+	if hook, ok := ctx.hooks[dt]; ok {
+		return hookFromUnstructured(hook, sv, dv)
+	}
+	if dt.Kind() == reflect.Pointer {
+		if _, ok := ctx.hooks[dt.Elem()]; ok {
+			// bypass the JSON unmarshaller of the pointer type
+			return pointerFromUnstructured(sv, dv, ctx)
+		}
+	}
+
+	if st == jsonNumberType && isNumberKind(dt.Kind()) {
+		// numbers kept as json.Number, e.g. by json.UnmarshalWithOptions, are
+		// converted like the numbers decoded by json.Unmarshal
+		n, err := json.ConvertNumbersWithOptions(sv.Interface(), json.DecodeOptions{Numbers: json.NumberModeInt64OrFloat64})
+		if err != nil {
+			return fmt.Errorf("cannot convert %s to %s: %v", sv.String(), dt.String(), err)
+		}
+		sv = reflect.ValueOf(n)
+		st = sv.Type()
+	}
+
 	switch dt.Kind() {
 	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Struct, reflect.Interface:
 		// Those require non-trivial conversion.
@@ -365,6 +445,16 @@ func fromUnstructured(sv, dv reflect.Value, ctx *fromUnstructuredContext) error
 
 }
 
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 func fieldInfoFromField(structType reflect.Type, field int) *fieldInfo {
 	fieldCacheMap := fieldCache.value.Load().(fieldsCacheMap)
 	if info, ok := fieldCacheMap[structField{structType, field}]; ok {
@@ -583,7 +673,7 @@ func (c *unstructuredConverter) ToUnstructured(obj interface{}) (map[string]inte
 			return nil, fmt.Errorf("ToUnstructured requires a non-nil pointer to an object, got %v", t)
 		}
 		u = map[string]interface{}{}
-		err = toUnstructured(value.Elem(), reflect.ValueOf(&u).Elem())
+		err = toUnstructured(value.Elem(), reflect.ValueOf(&u).Elem(), c.loadHooks())
 	}
 	if c.mismatchDetection {
 		newUnstr := map[string]interface{}{}
@@ -648,7 +738,16 @@ func toUnstructuredViaJSON(obj interface{}, u *map[string]interface{}) error {
 	return json.Unmarshal(data, u)
 }
 
-func toUnstructured(sv, dv reflect.Value) error {
+func toUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
+	if hook, ok := hooks[sv.Type()]; ok && sv.CanInterface() {
+		return hookToUnstructured(hook, sv, dv)
+	}
+	if sv.Kind() == reflect.Pointer {
+		if _, ok := hooks[sv.Type().Elem()]; ok {
+			// bypass the JSON marshaller of the pointer type
+			return pointerToUnstructured(sv, dv, hooks)
+		}
+	}
 	// Check if the object has a custom string converter.
 	entry := value.TypeReflectEntryOf(sv.Type())
 	if entry.CanConvertToUnstructured() {
@@ -683,21 +782,21 @@ func toUnstructured(sv, dv reflect.Value) error {
 		dv.Set(reflect.ValueOf(sv.Float()))
 		return nil
 	case reflect.Map:
-		return mapToUnstructured(sv, dv)
+		return mapToUnstructured(sv, dv, hooks)
 	case reflect.Slice:
-		return sliceToUnstructured(sv, dv)
+		return sliceToUnstructured(sv, dv, hooks)
 	case reflect.Pointer:
-		return pointerToUnstructured(sv, dv)
+		return pointerToUnstructured(sv, dv, hooks)
 	case reflect.Struct:
-		return structToUnstructured(sv, dv)
+		return structToUnstructured(sv, dv, hooks)
 	case reflect.Interface:
-		return interfaceToUnstructured(sv, dv)
+		return interfaceToUnstructured(sv, dv, hooks)
 	default:
 		return fmt.Errorf("unrecognized type: %v", st.Kind())
 	}
 }
 
-func mapToUnstructured(sv, dv reflect.Value) error {
+func mapToUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
 	st, dt := sv.Type(), dv.Type()
 	if sv.IsNil() {
 		dv.Set(reflect.Zero(dt))
@@ -720,7 +819,7 @@ func mapToUnstructured(sv, dv reflect.Value) error {
 
 	for _, key := range sv.MapKeys() {
 		value := reflect.New(dt.Elem()).Elem()
-		if err := toUnstructured(sv.MapIndex(key), value); err != nil {
+		if err := toUnstructured(sv.MapIndex(key), value, hooks); err != nil {
 			return err
 		}
 		if st.Key().AssignableTo(dt.Key()) {
@@ -732,7 +831,7 @@ func mapToUnstructured(sv, dv reflect.Value) error {
 	return nil
 }
 
-func sliceToUnstructured(sv, dv reflect.Value) error {
+func sliceToUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
 	st, dt := sv.Type(), dv.Type()
 	if sv.IsNil() {
 		dv.Set(reflect.Zero(dt))
@@ -760,19 +859,19 @@ func sliceToUnstructured(sv, dv reflect.Value) error {
 		return fmt.Errorf("cannot convert slice to: %v", dt.Kind())
 	}
 	for i := 0; i < sv.Len(); i++ {
-		if err := toUnstructured(sv.Index(i), dv.Index(i)); err != nil {
+		if err := toUnstructured(sv.Index(i), dv.Index(i), hooks); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func pointerToUnstructured(sv, dv reflect.Value) error {
+func pointerToUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
 	if sv.IsNil() {
 		// We're done - we don't need to store anything.
 		return nil
 	}
-	return toUnstructured(sv.Elem(), dv)
+	return toUnstructured(sv.Elem(), dv, hooks)
 }
 
 func isZero(v reflect.Value) bool {
@@ -796,7 +895,7 @@ func isZero(v reflect.Value) bool {
 	return false
 }
 
-func structToUnstructured(sv, dv reflect.Value) error {
+func structToUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
 	st, dt := sv.Type(), dv.Type()
 	if dt.Kind() == reflect.Interface && dv.NumMethod() == 0 {
 		dv.Set(reflect.MakeMapWithSize(mapStringInterfaceType, st.NumField()))
@@ -822,11 +921,19 @@ func structToUnstructured(sv, dv reflect.Value) error {
 		}
 		if len(fieldInfo.name) == 0 {
 			// This field is inlined.
-			if err := toUnstructured(fv, dv); err != nil {
+			if err := toUnstructured(fv, dv, hooks); err != nil {
 				return err
 			}
 			continue
 		}
+		if _, ok := hooks[fv.Type()]; ok {
+			subv := reflect.New(dt.Elem()).Elem()
+			if err := toUnstructured(fv, subv, hooks); err != nil {
+				return err
+			}
+			dv.SetMapIndex(fieldInfo.nameValue, subv)
+			continue
+		}
 		switch fv.Type().Kind() {
 		case reflect.String:
 			realMap[fieldInfo.name] = fv.String()
@@ -840,7 +947,7 @@ func structToUnstructured(sv, dv reflect.Value) error {
 			realMap[fieldInfo.name] = fv.Float()
 		default:
 			subv := reflect.New(dt.Elem()).Elem()
-			if err := toUnstructured(fv, subv); err != nil {
+			if err := toUnstructured(fv, subv, hooks); err != nil {
 				return err
 			}
 			dv.SetMapIndex(fieldInfo.nameValue, subv)
@@ -849,10 +956,35 @@ func structToUnstructured(sv, dv reflect.Value) error {
 	return nil
 }
 
-func interfaceToUnstructured(sv, dv reflect.Value) error {
+func hookToUnstructured(hook UnstructuredConversionHook, sv, dv reflect.Value) error {
+	v, err := hook.ToUnstructured(sv.Interface())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+	dv.Set(reflect.ValueOf(v))
+	return nil
+}
+
+func hookFromUnstructured(hook UnstructuredConversionHook, sv, dv reflect.Value) error {
+	if dv.CanAddr() {
+		return hook.FromUnstructured(sv.Interface(), dv.Addr().Interface())
+	}
+	v := reflect.New(dv.Type())
+	if err := hook.FromUnstructured(sv.Interface(), v.Interface()); err != nil {
+		return err
+	}
+	dv.Set(v.Elem())
+	return nil
+}
+
+func interfaceToUnstructured(sv, dv reflect.Value, hooks conversionHooks) error {
 	if !sv.IsValid() || sv.IsNil() {
 		dv.Set(reflect.Zero(dv.Type()))
 		return nil
 	}
-	return toUnstructured(sv.Elem(), dv)
+	return toUnstructured(sv.Elem(), dv, hooks)
 }
@@ -947,6 +947,31 @@ func TestIntFloatConversion(t *testing.T) {
 	}
 }
 
+func TestJSONNumberConversion(t *testing.T) {
+	data := []byte(`{"cd":12,"ce":{"x":3},"cg":[1,20],"ch":2.5}`)
+	var unstr map[string]interface{}
+	if err := json.UnmarshalWithOptions(data, &unstr, json.DecodeOptions{Numbers: json.NumberModeJSONNumber}); err != nil {
+		t.Fatal(err)
+	}
+
+	var obj C
+	if err := runtime.NewTestUnstructuredConverter(simpleEquality).FromUnstructured(unstr, &obj); err != nil {
+		t.Fatalf("Unexpected error in FromUnstructured: %v", err)
+	}
+	var unmarshalled C
+	if err := json.Unmarshal(data, &unmarshalled); err != nil {
+		t.Fatalf("Error when unmarshaling to object: %v", err)
+	}
+	if !reflect.DeepEqual(obj, unmarshalled) {
+		t.Errorf("Incorrect conversion, diff: %v", cmp.Diff(obj, unmarshalled))
+	}
+
+	unstr = map[string]interface{}{"cd": encodingjson.Number("1.5")}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstr, &obj); err == nil {
+		t.Errorf("Expected an error converting a fraction to an integer")
+	}
+}
+
 func TestCustomToUnstructured(t *testing.T) {
 	testcases := []struct {
 		Data     string
@@ -998,3 +1023,98 @@ func TestCustomToUnstructuredTopLevel(t *testing.T) {
 		})
 	}
 }
+
+// hookedValue is encoded as a "<value>" JSON string.
+type hookedValue struct {
+	Value int64
+}
+
+func (h hookedValue) MarshalJSON() ([]byte, error) {
+	return encodingjson.Marshal(fmt.Sprintf("<%d>", h.Value))
+}
+
+func (h *hookedValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := encodingjson.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	_, err := fmt.Sscanf(s, "<%d>", &h.Value)
+	return err
+}
+
+// upperString is encoded as an upper case JSON string.
+type upperString string
+
+func (u upperString) MarshalJSON() ([]byte, error) {
+	return encodingjson.Marshal(strings.ToUpper(string(u)))
+}
+
+type hookedObject struct {
+	Value    hookedValue            `json:"value"`
+	Pointer  *hookedValue           `json:"pointer,omitempty"`
+	Slice    []hookedValue          `json:"slice,omitempty"`
+	Map      map[string]hookedValue `json:"map,omitempty"`
+	Upper    upperString            `json:"upper"`
+	Ordinary string                 `json:"ordinary"`
+}
+
+func TestUnstructuredConversionHooks(t *testing.T) {
+	converter := runtime.NewTestUnstructuredConverterWithValidation(simpleEquality)
+	var toCalls, fromCalls int
+	require.NoError(t, converter.RegisterConversionHook(hookedValue{}, runtime.UnstructuredConversionHook{
+		ToUnstructured: func(obj interface{}) (interface{}, error) {
+			toCalls++
+			return fmt.Sprintf("<%d>", obj.(hookedValue).Value), nil
+		},
+		FromUnstructured: func(u interface{}, obj interface{}) error {
+			fromCalls++
+			s, ok := u.(string)
+			if !ok {
+				return fmt.Errorf("expected string, got %T", u)
+			}
+			_, err := fmt.Sscanf(s, "<%d>", &obj.(*hookedValue).Value)
+			return err
+		},
+	}))
+	require.NoError(t, converter.RegisterConversionHook(upperString(""), runtime.UnstructuredConversionHook{
+		ToUnstructured: func(obj interface{}) (interface{}, error) {
+			return strings.ToUpper(string(obj.(upperString))), nil
+		},
+		FromUnstructured: func(u interface{}, obj interface{}) error {
+			*obj.(*upperString) = upperString(u.(string))
+			return nil
+		},
+	}))
+	require.Error(t, converter.RegisterConversionHook(nil, runtime.UnstructuredConversionHook{}))
+	require.Error(t, converter.RegisterConversionHook(hookedValue{}, runtime.UnstructuredConversionHook{}))
+
+	obj := &hookedObject{
+		Value:    hookedValue{1},
+		Pointer:  &hookedValue{2},
+		Slice:    []hookedValue{{3}},
+		Map:      map[string]hookedValue{"a": {4}},
+		Upper:    "upper",
+		Ordinary: "ordinary",
+	}
+	u, err := converter.ToUnstructured(obj)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"value":    "<1>",
+		"pointer":  "<2>",
+		"slice":    []interface{}{"<3>"},
+		"map":      map[string]interface{}{"a": "<4>"},
+		"upper":    "UPPER",
+		"ordinary": "ordinary",
+	}, u)
+	assert.Equal(t, 4, toCalls)
+
+	out := &hookedObject{}
+	require.NoError(t, converter.FromUnstructured(u, out))
+	assert.Equal(t, 4, fromCalls)
+	obj.Upper = "UPPER"
+	assert.Equal(t, obj, out)
+
+	err = converter.FromUnstructured(map[string]interface{}{"value": int64(1)}, out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected string")
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// deepCopyIntoMethods caches, per pointer type, the index of its generated
+// DeepCopyInto method, or -1 if it has none.
+var deepCopyIntoMethods sync.Map
+
+// DeepCopyIntoTopLevel deep copies src into dst, which must be non-nil pointers
+// of the same type. Only the top-level object dst points to is reused rather
+// than allocated as by DeepCopyObject, e.g. to hand objects from an informer to
+// workers through a pool of objects (see ObjectPool). Nested slices, maps and
+// pointers are not reused: objects whose type has a generated DeepCopyInto
+// method are copied with it, which allocates them anew, and other objects are
+// deep copied with DeepCopyObject before being assigned to dst. This only saves
+// allocating the object itself, and the generated method is called through
+// reflection, so copying is slower than with DeepCopyObject: it pays off for
+// objects whose top-level struct is large compared to their nested data (see
+// BenchmarkDeepCopyIntoTopLevel).
+//
+// Any references held by dst are overwritten, so dst must not be shared.
+func DeepCopyIntoTopLevel(dst, src Object) error {
+	if src == nil || dst == nil {
+		return fmt.Errorf("cannot deep copy %T into %T", src, dst)
+	}
+	srcValue, dstValue := reflect.ValueOf(src), reflect.ValueOf(dst)
+	t := srcValue.Type()
+	if t != dstValue.Type() {
+		return fmt.Errorf("cannot deep copy %T into %T, the types differ", src, dst)
+	}
+	if t.Kind() != reflect.Pointer {
+		return fmt.Errorf("cannot deep copy into %T, which is not a pointer", dst)
+	}
+	if srcValue.IsNil() || dstValue.IsNil() {
+		return fmt.Errorf("cannot deep copy %T from or into a nil pointer", src)
+	}
+	if srcValue.Pointer() == dstValue.Pointer() {
+		return nil
+	}
+
+	if index := deepCopyIntoMethod(t); index >= 0 {
+		srcValue.Method(index).Call([]reflect.Value{dstValue})
+		return nil
+	}
+	dstValue.Elem().Set(reflect.ValueOf(src.DeepCopyObject()).Elem())
+	return nil
+}
+
+// CopyOrReuse returns a deep copy of src, copied into dst with
+// DeepCopyIntoTopLevel if dst is a non-nil object of the same type as src, e.g.
+// an object obtained from a pool, and newly allocated with DeepCopyObject
+// otherwise. A nil src returns nil.
+func CopyOrReuse(dst, src Object) Object {
+	if src == nil {
+		return nil
+	}
+	if dst != nil && DeepCopyIntoTopLevel(dst, src) == nil {
+		return dst
+	}
+	return src.DeepCopyObject()
+}
+
+// deepCopyIntoMethod returns the index of the method DeepCopyInto(t) of the
+// pointer type t, or -1 if t has no such method.
+func deepCopyIntoMethod(t reflect.Type) int {
+	if index, ok := deepCopyIntoMethods.Load(t); ok {
+		return index.(int)
+	}
+	index := -1
+	if method, ok := t.MethodByName("DeepCopyInto"); ok {
+		// the method type includes the receiver
+		if method.Type.NumIn() == 2 && method.Type.In(1) == t && method.Type.NumOut() == 0 {
+			index = method.Index
+		}
+	}
+	deepCopyIntoMethods.Store(t, index)
+	return index
+}
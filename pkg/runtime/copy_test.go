@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+// copyOnlyObject has no DeepCopyInto method.
+type copyOnlyObject struct {
+	runtime.TypeMeta
+	Items []string
+}
+
+func (o *copyOnlyObject) DeepCopyObject() runtime.Object {
+	out := &copyOnlyObject{TypeMeta: o.TypeMeta}
+	out.Items = append([]string(nil), o.Items...)
+	return out
+}
+
+func TestDeepCopyIntoTopLevel(t *testing.T) {
+	src := &runtime.Unknown{Raw: []byte("abc"), ContentType: "application/json"}
+	dst := &runtime.Unknown{Raw: []byte("previous"), ContentEncoding: "gzip"}
+	if err := runtime.DeepCopyIntoTopLevel(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("expected %#v, got %#v", src, dst)
+	}
+	src.Raw[0] = 'x'
+	if string(dst.Raw) != "abc" {
+		t.Errorf("expected the copy not to share memory with the source, got %q", dst.Raw)
+	}
+
+	copyOnlySrc := &copyOnlyObject{Items: []string{"a", "b"}}
+	copyOnlyDst := &copyOnlyObject{Items: []string{"c"}}
+	if err := runtime.DeepCopyIntoTopLevel(copyOnlyDst, copyOnlySrc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(copyOnlyDst, copyOnlySrc) {
+		t.Errorf("expected %#v, got %#v", copyOnlySrc, copyOnlyDst)
+	}
+	copyOnlySrc.Items[0] = "x"
+	if copyOnlyDst.Items[0] != "a" {
+		t.Errorf("expected the copy not to share memory with the source, got %v", copyOnlyDst.Items)
+	}
+
+	for name, tc := range map[string]struct {
+		dst, src runtime.Object
+	}{
+		"nil source":      {dst: &runtimetesting.ExternalSimple{}, src: nil},
+		"nil destination": {dst: nil, src: &runtimetesting.ExternalSimple{}},
+		"nil pointer":     {dst: (*runtimetesting.ExternalSimple)(nil), src: &runtimetesting.ExternalSimple{}},
+		"different types": {dst: &runtimetesting.InternalSimple{}, src: &runtimetesting.ExternalSimple{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := runtime.DeepCopyIntoTopLevel(tc.dst, tc.src); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCopyOrReuse(t *testing.T) {
+	src := &runtimetesting.ExternalSimple{TestString: "a"}
+
+	dst := &runtimetesting.ExternalSimple{TestString: "b"}
+	if out := runtime.CopyOrReuse(dst, src); out != runtime.Object(dst) {
+		t.Errorf("expected the destination to be reused, got %#v", out)
+	}
+	if dst.TestString != "a" {
+		t.Errorf("expected the destination to be overwritten, got %#v", dst)
+	}
+
+	other := &runtimetesting.InternalSimple{TestString: "b"}
+	out := runtime.CopyOrReuse(other, src)
+	if out == src || !reflect.DeepEqual(out, src) {
+		t.Errorf("expected a new copy of the source, got %#v", out)
+	}
+	if other.TestString != "b" {
+		t.Errorf("expected a destination of another type not to be modified, got %#v", other)
+	}
+
+	if out := runtime.CopyOrReuse(nil, src); out == src || !reflect.DeepEqual(out, src) {
+		t.Errorf("expected a new copy of the source, got %#v", out)
+	}
+	if out := runtime.CopyOrReuse(dst, nil); out != nil {
+		t.Errorf("expected nil, got %#v", out)
+	}
+}
+
+var copySink runtime.Object
+
+func BenchmarkDeepCopyIntoTopLevel(b *testing.B) {
+	src := &runtime.Unknown{
+		TypeMeta:    runtime.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		Raw:         []byte(`{"metadata":{"name":"a"}}`),
+		ContentType: "application/json",
+	}
+	b.Run("DeepCopyObject", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copySink = src.DeepCopyObject()
+		}
+	})
+	b.Run("DeepCopyIntoTopLevel", func(b *testing.B) {
+		dst := &runtime.Unknown{}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := runtime.DeepCopyIntoTopLevel(dst, src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
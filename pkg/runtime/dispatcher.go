@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AnyGroupOrVersion is the wildcard group or version of the kinds handlers are
+// registered for in a Dispatcher.
+const AnyGroupOrVersion = "*"
+
+// Dispatcher maps kinds to handlers of type T, e.g. admission webhooks,
+// converters or printers, and resolves the handler of objects from their
+// kinds. Handlers may be registered for a kind in any group or version, with
+// AnyGroupOrVersion, as a fallback for kinds without a more specific handler.
+// It is safe for concurrent use.
+type Dispatcher[T any] struct {
+	typer ObjectTyper
+
+	lock sync.RWMutex
+	// handlers holds the handlers by kind, in order of decreasing priority.
+	handlers map[string][]dispatchEntry[T]
+}
+
+type dispatchEntry[T any] struct {
+	group, version string
+	priority       int
+	handler        T
+}
+
+// specificity orders the entries matching a kind: the entries with a group and
+// a version first, then the entries with a group, and then the entries with a
+// version.
+func (e dispatchEntry[T]) specificity() int {
+	s := 0
+	if e.group != AnyGroupOrVersion {
+		s += 2
+	}
+	if e.version != AnyGroupOrVersion {
+		s++
+	}
+	return s
+}
+
+func (e dispatchEntry[T]) matches(gvk schema.GroupVersionKind) bool {
+	return (e.group == AnyGroupOrVersion || e.group == gvk.Group) &&
+		(e.version == AnyGroupOrVersion || e.version == gvk.Version)
+}
+
+// NewDispatcher returns a dispatcher resolving the kinds of objects with typer,
+// usually a Scheme.
+func NewDispatcher[T any](typer ObjectTyper) *Dispatcher[T] {
+	return &Dispatcher[T]{
+		typer:    typer,
+		handlers: map[string][]dispatchEntry[T]{},
+	}
+}
+
+// Register registers handler for gvk, whose group and version may be
+// AnyGroupOrVersion. When several handlers match a kind equally specifically,
+// the handler with the highest priority is used, and on equal priorities the
+// handler registered first.
+func (d *Dispatcher[T]) Register(gvk schema.GroupVersionKind, priority int, handler T) error {
+	if len(gvk.Kind) == 0 || gvk.Kind == AnyGroupOrVersion {
+		return fmt.Errorf("cannot register a handler for %v: the kind is required", gvk)
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	entries := d.handlers[gvk.Kind]
+	i := 0
+	for i < len(entries) && entries[i].priority >= priority {
+		i++
+	}
+	entry := dispatchEntry[T]{group: gvk.Group, version: gvk.Version, priority: priority, handler: handler}
+	entries = append(entries, dispatchEntry[T]{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	d.handlers[gvk.Kind] = entries
+	return nil
+}
+
+// Lookup returns the handler of gvk: the handler with the highest priority
+// among the most specific handlers matching gvk.
+func (d *Dispatcher[T]) Lookup(gvk schema.GroupVersionKind) (T, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	entry, found := d.lookup(gvk)
+	return entry.handler, found
+}
+
+func (d *Dispatcher[T]) lookup(gvk schema.GroupVersionKind) (dispatchEntry[T], bool) {
+	var best dispatchEntry[T]
+	found := false
+	for _, entry := range d.handlers[gvk.Kind] {
+		if entry.matches(gvk) && (!found || entry.specificity() > best.specificity()) {
+			best, found = entry, true
+		}
+	}
+	return best, found
+}
+
+// Dispatch returns the handler of obj and the kind it was resolved for. The
+// kind set on obj is tried first if it is one of the kinds of obj according to
+// the typer; otherwise the most specific handler of its kinds is used, the
+// first kind winning on equal specificity and priority. Returns an error if obj
+// has no kind or no handler matches its kinds.
+func (d *Dispatcher[T]) Dispatch(obj Object) (T, schema.GroupVersionKind, error) {
+	var zero T
+	kinds, _, err := d.typer.ObjectKinds(obj)
+	if err != nil {
+		return zero, schema.GroupVersionKind{}, err
+	}
+
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	if set := obj.GetObjectKind().GroupVersionKind(); !set.Empty() {
+		for _, kind := range kinds {
+			if kind == set {
+				if entry, found := d.lookup(kind); found {
+					return entry.handler, kind, nil
+				}
+				break
+			}
+		}
+	}
+	var best dispatchEntry[T]
+	var bestKind schema.GroupVersionKind
+	found := false
+	for _, kind := range kinds {
+		entry, ok := d.lookup(kind)
+		if !ok {
+			continue
+		}
+		if !found || entry.specificity() > best.specificity() ||
+			(entry.specificity() == best.specificity() && entry.priority > best.priority) {
+			best, bestKind, found = entry, kind, true
+		}
+	}
+	if !found {
+		return zero, schema.GroupVersionKind{}, fmt.Errorf("no handler registered for the kinds %v of %T", kinds, obj)
+	}
+	return best.handler, bestKind, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestDispatcherLookup(t *testing.T) {
+	d := runtime.NewDispatcher[string](runtime.NewScheme())
+	for _, r := range []struct {
+		gvk      schema.GroupVersionKind
+		priority int
+		handler  string
+	}{
+		{gvk: schema.GroupVersionKind{Group: "*", Version: "*", Kind: "Simple"}, handler: "any"},
+		{gvk: schema.GroupVersionKind{Group: "*", Version: "v1", Kind: "Simple"}, handler: "any group v1"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "*", Kind: "Simple"}, handler: "apps"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v2", Kind: "Simple"}, handler: "apps v2"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v2", Kind: "Simple"}, priority: 10, handler: "apps v2 high"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v2", Kind: "Simple"}, priority: 10, handler: "apps v2 high later"},
+	} {
+		if err := d.Register(r.gvk, r.priority, r.handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Register(schema.GroupVersionKind{Group: "apps", Version: "v1"}, 0, "no kind"); err == nil {
+		t.Error("expected registering a handler without kind to fail")
+	}
+
+	testCases := []struct {
+		gvk      schema.GroupVersionKind
+		expected string
+		found    bool
+	}{
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v2", Kind: "Simple"}, expected: "apps v2 high", found: true},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Simple"}, expected: "apps", found: true},
+		{gvk: schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Simple"}, expected: "any group v1", found: true},
+		{gvk: schema.GroupVersionKind{Group: "batch", Version: "v3", Kind: "Simple"}, expected: "any", found: true},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Other"}},
+	}
+	for _, tc := range testCases {
+		handler, found := d.Lookup(tc.gvk)
+		if handler != tc.expected || found != tc.found {
+			t.Errorf("%v: expected %q, %t, got %q, %t", tc.gvk, tc.expected, tc.found, handler, found)
+		}
+	}
+}
+
+func TestDispatcherDispatch(t *testing.T) {
+	v1 := schema.GroupVersion{Group: "test.group", Version: "v1"}
+	v2 := schema.GroupVersion{Group: "test.group", Version: "v2"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(v1, &runtimetesting.ExternalSimple{})
+	scheme.AddKnownTypeWithName(v2.WithKind("ExternalSimple"), &runtimetesting.ExternalSimple{})
+
+	d := runtime.NewDispatcher[string](scheme)
+	if err := d.Register(schema.GroupVersionKind{Group: "test.group", Version: "*", Kind: "ExternalSimple"}, 0, "any version"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Register(v2.WithKind("ExternalSimple"), 0, "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the most specific handler of the kinds of the object
+	handler, gvk, err := d.Dispatch(&runtimetesting.ExternalSimple{})
+	if err != nil || handler != "v2" || gvk != v2.WithKind("ExternalSimple") {
+		t.Errorf("unexpected handler %q for %v, error %v", handler, gvk, err)
+	}
+
+	// the kind set on the object
+	obj := &runtimetesting.ExternalSimple{}
+	obj.GetObjectKind().SetGroupVersionKind(v1.WithKind("ExternalSimple"))
+	handler, gvk, err = d.Dispatch(obj)
+	if err != nil || handler != "any version" || gvk != v1.WithKind("ExternalSimple") {
+		t.Errorf("unexpected handler %q for %v, error %v", handler, gvk, err)
+	}
+
+	if _, _, err := d.Dispatch(&runtimetesting.InternalSimple{}); !runtime.IsNotRegisteredError(err) {
+		t.Errorf("expected a not registered error, got %v", err)
+	}
+	scheme.AddKnownTypes(v1, &runtimetesting.ExternalComplex{})
+	if _, _, err := d.Dispatch(&runtimetesting.ExternalComplex{}); err == nil {
+		t.Error("expected an error for an object without handler")
+	}
+}
@@ -20,6 +20,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+
+	cbor "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 )
 
 func (re *RawExtension) UnmarshalJSON(in []byte) error {
@@ -46,6 +49,71 @@ func (re RawExtension) MarshalJSON() ([]byte, error) {
 		}
 		return []byte("null"), nil
 	}
+	if bytes.HasPrefix(re.Raw, selfDescribedCBOR) {
+		// Raw was embedded by the CBOR serializer, so it is transcoded to match the encoding of
+		// the enclosing object, as is the case for conversion to unstructured content.
+		var u interface{}
+		if err := cbor.Unmarshal(re.Raw, &u); err != nil {
+			return nil, err
+		}
+		return json.Marshal(u)
+	}
 	// TODO: Check whether ContentType is actually JSON before returning it.
 	return re.Raw, nil
 }
+
+// selfDescribedCBOR is the head of the CBOR tag 55799 "Self-Described CBOR" (RFC 8949 Section
+// 3.4.6), which the CBOR serializer prefixes its output with.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// cborNull is the CBOR encoding of null.
+var cborNull = []byte{0xf6}
+
+// UnmarshalCBOR stores the data item in Raw transcoded to JSON, which Raw holds when decoded
+// from the other formats, so that consumers of Raw do not need to tell formats apart.
+func (re *RawExtension) UnmarshalCBOR(in []byte) error {
+	if re == nil {
+		return errors.New("runtime.RawExtension: UnmarshalCBOR on nil pointer")
+	}
+	if bytes.Equal(in, cborNull) {
+		return nil
+	}
+	var u interface{}
+	if err := cbor.Unmarshal(in, &u); err != nil {
+		return err
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	re.Raw = append(re.Raw[0:0], data...)
+	return nil
+}
+
+// MarshalCBOR embeds Raw as is if it is self-described CBOR, as produced by the CBOR
+// serializer for the objects of watch events, and otherwise transcodes Raw from JSON.
+// Embedded data items always start with the self-described CBOR tag.
+func (re RawExtension) MarshalCBOR() ([]byte, error) {
+	if re.Raw == nil {
+		if re.Object != nil {
+			data, err := cbor.Marshal(re.Object)
+			if err != nil {
+				return nil, err
+			}
+			return append(append([]byte{}, selfDescribedCBOR...), data...), nil
+		}
+		return cborNull, nil
+	}
+	if bytes.HasPrefix(re.Raw, selfDescribedCBOR) {
+		return re.Raw, nil
+	}
+	var u interface{}
+	if err := utiljson.Unmarshal(re.Raw, &u); err != nil {
+		return nil, err
+	}
+	data, err := cbor.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, selfDescribedCBOR...), data...), nil
+}
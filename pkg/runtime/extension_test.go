@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	cbor "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 func TestEmbeddedRawExtensionMarshal(t *testing.T) {
@@ -111,3 +112,78 @@ func TestEmbeddedRawExtensionRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestEmbeddedRawExtensionCBORRoundTrip(t *testing.T) {
+	type test struct {
+		Ext runtime.RawExtension `json:"ext"`
+	}
+
+	fromJSON, err := cbor.Marshal(test{Ext: runtime.RawExtension{Raw: []byte(`{"foo":"bar","n":1}`)}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded test
+	if err := cbor.Unmarshal(fromJSON, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Raw is transcoded to JSON, as held when decoded from other formats
+	if string(decoded.Ext.Raw) != `{"foo":"bar","n":1}` {
+		t.Errorf("expected JSON, got %q", decoded.Ext.Raw)
+	}
+
+	again, err := cbor.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(fromJSON, again) {
+		t.Errorf("expected %x, got %x", fromJSON, again)
+	}
+
+	null, err := cbor.Marshal(test{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded = test{}
+	if err := cbor.Unmarshal(null, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Ext.Raw != nil {
+		t.Errorf("expected nil Raw for null, got %x", decoded.Ext.Raw)
+	}
+}
+
+func TestEmbeddedRawExtensionCBORToUnstructured(t *testing.T) {
+	type test struct {
+		Ext runtime.RawExtension `json:"ext"`
+	}
+
+	raw, err := cbor.Marshal(map[string]interface{}{"foo": "bar", "n": int64(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj := &test{Ext: runtime.RawExtension{Raw: append([]byte{0xd9, 0xd9, 0xf7}, raw...)}}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"ext":{"foo":"bar","n":1}}` {
+		t.Errorf("unexpected data: %s", string(data))
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]interface{}{"ext": map[string]interface{}{"foo": "bar", "n": int64(1)}}; !reflect.DeepEqual(expected, u) {
+		t.Errorf("expected %v, got %v", expected, u)
+	}
+
+	var back test
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, &back); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(back.Ext.Raw) != `{"foo":"bar","n":1}` {
+		t.Errorf("unexpected raw data: %s", back.Ext.Raw)
+	}
+}
@@ -153,6 +153,10 @@ type SerializerInfo struct {
 
 // StreamSerializerInfo contains information about a specific stream serialization format
 type StreamSerializerInfo struct {
+	// MediaType, if set, is the media type of the stream where it differs from
+	// the media type of the individual objects, such as "application/cbor-seq"
+	// for a stream of "application/cbor" objects.
+	MediaType string
 	// EncodesAsText indicates this serializer can be encoded to UTF-8 safely.
 	EncodesAsText bool
 	// Serializer is the top level object serializer for this type when streaming
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectPool is an ObjectCreater which recycles the objects of selected kinds. Decoders
+// constructed with an ObjectPool as their creater, such as the JSON, YAML and protobuf
+// serializers, decode into recycled objects when the caller does not provide an object
+// to decode into.
+//
+// Objects returned to the pool with Put are reset to their zero value, so an object
+// returned by New is indistinguishable from a newly allocated one, and the pool does not
+// retain any references held by recycled objects.
+type ObjectPool interface {
+	ObjectCreater
+	// Put returns obj to the pool once the caller, and anybody the caller passed obj to,
+	// is done with it. Objects of kinds which are not pooled are ignored.
+	Put(obj Object)
+}
+
+// objectPool pools objects by their Go type.
+type objectPool struct {
+	creater ObjectCreater
+	// types holds the Go types of the pooled kinds.
+	types map[schema.GroupVersionKind]reflect.Type
+	// pools holds a pool of pointers to zero values per Go type.
+	pools map[reflect.Type]*sync.Pool
+}
+
+// NewObjectPool returns an ObjectPool recycling objects of the given kinds, and creating
+// objects of other kinds with creater. Objects of the pooled kinds must be pointers to
+// structs, as created by a Scheme.
+func NewObjectPool(creater ObjectCreater, kinds ...schema.GroupVersionKind) (ObjectPool, error) {
+	p := &objectPool{
+		creater: creater,
+		types:   make(map[schema.GroupVersionKind]reflect.Type, len(kinds)),
+		pools:   make(map[reflect.Type]*sync.Pool, len(kinds)),
+	}
+	for _, gvk := range kinds {
+		obj, err := creater.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+		t := reflect.TypeOf(obj)
+		if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("objects of kind %v must be pointers to structs to be pooled, got %v", gvk, t)
+		}
+		p.types[gvk] = t
+		if _, ok := p.pools[t]; !ok {
+			p.pools[t] = &sync.Pool{}
+		}
+	}
+	return p, nil
+}
+
+// New returns a recycled object if gvk is pooled, and a new object otherwise.
+func (p *objectPool) New(gvk schema.GroupVersionKind) (Object, error) {
+	t, ok := p.types[gvk]
+	if !ok {
+		return p.creater.New(gvk)
+	}
+	if obj, ok := p.pools[t].Get().(Object); ok {
+		return obj, nil
+	}
+	return p.creater.New(gvk)
+}
+
+// Put resets obj and returns it to the pool of its type.
+func (p *objectPool) Put(obj Object) {
+	if obj == nil {
+		return
+	}
+	v := reflect.ValueOf(obj)
+	pool, ok := p.pools[v.Type()]
+	if !ok || v.IsNil() {
+		return
+	}
+	v.Elem().Set(reflect.Zero(v.Type().Elem()))
+	pool.Put(obj)
+}
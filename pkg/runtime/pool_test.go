@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestObjectPool(t *testing.T) {
+	simpleGVK := gvk("test.group", "v1", "Simple")
+	otherGVK := gvk("test.group", "v1", "Other")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(simpleGVK, &runtimetesting.ExternalSimple{})
+	scheme.AddKnownTypeWithName(otherGVK, &runtimetesting.ExternalComplex{})
+
+	pool, err := runtime.NewObjectPool(scheme, simpleGVK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, pool, scheme, json.SerializerOptions{})
+
+	obj, _, err := serializer.Decode([]byte(`{"apiVersion":"test.group/v1","kind":"Simple","testString":"a"}`), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simple, ok := obj.(*runtimetesting.ExternalSimple)
+	if !ok || simple.TestString != "a" {
+		t.Fatalf("unexpected object %#v", obj)
+	}
+
+	pool.Put(simple)
+	if !reflect.DeepEqual(simple, &runtimetesting.ExternalSimple{}) {
+		t.Errorf("expected the pooled object to be reset, got %#v", simple)
+	}
+
+	// decoding again yields a fresh object, whether or not it is recycled
+	obj, _, err = serializer.Decode([]byte(`{"apiVersion":"test.group/v1","kind":"Simple"}`), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: "test.group/v1", Kind: "Simple"}}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("expected %#v, got %#v", expected, obj)
+	}
+
+	// other kinds are created by the scheme, and ignored by Put
+	other, err := pool.New(otherGVK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.(*runtimetesting.ExternalComplex).String = "kept"
+	pool.Put(other)
+	if other.(*runtimetesting.ExternalComplex).String != "kept" {
+		t.Errorf("expected objects of other kinds not to be reset")
+	}
+	pool.Put(nil)
+
+	if _, err := runtime.NewObjectPool(scheme, gvk("test.group", "v1", "Unknown")); err == nil {
+		t.Errorf("expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// GroupVersionKindSet is a set of GroupVersionKinds.
+type GroupVersionKindSet sets.Set[GroupVersionKind]
+
+// NewGroupVersionKindSet returns a GroupVersionKindSet holding items.
+func NewGroupVersionKindSet(items ...GroupVersionKind) GroupVersionKindSet {
+	return GroupVersionKindSet(sets.New(items...))
+}
+
+// Insert adds items to the set and returns it.
+func (s GroupVersionKindSet) Insert(items ...GroupVersionKind) GroupVersionKindSet {
+	sets.Set[GroupVersionKind](s).Insert(items...)
+	return s
+}
+
+// Delete removes items from the set and returns it.
+func (s GroupVersionKindSet) Delete(items ...GroupVersionKind) GroupVersionKindSet {
+	sets.Set[GroupVersionKind](s).Delete(items...)
+	return s
+}
+
+// Has returns true if item is contained in the set.
+func (s GroupVersionKindSet) Has(item GroupVersionKind) bool {
+	_, contained := s[item]
+	return contained
+}
+
+// Len returns the size of the set.
+func (s GroupVersionKindSet) Len() int {
+	return len(s)
+}
+
+// Clone returns a new set which is a copy of the current set.
+func (s GroupVersionKindSet) Clone() GroupVersionKindSet {
+	return GroupVersionKindSet(sets.Set[GroupVersionKind](s).Clone())
+}
+
+// Union returns a new set which includes the items in either s or other.
+func (s GroupVersionKindSet) Union(other GroupVersionKindSet) GroupVersionKindSet {
+	return GroupVersionKindSet(sets.Set[GroupVersionKind](s).Union(sets.Set[GroupVersionKind](other)))
+}
+
+// Intersection returns a new set which includes the items in both s and other.
+func (s GroupVersionKindSet) Intersection(other GroupVersionKindSet) GroupVersionKindSet {
+	return GroupVersionKindSet(sets.Set[GroupVersionKind](s).Intersection(sets.Set[GroupVersionKind](other)))
+}
+
+// Difference returns a new set of the items in s which are not in other.
+func (s GroupVersionKindSet) Difference(other GroupVersionKindSet) GroupVersionKindSet {
+	return GroupVersionKindSet(sets.Set[GroupVersionKind](s).Difference(sets.Set[GroupVersionKind](other)))
+}
+
+// Equal returns true if s and other contain the same items.
+func (s GroupVersionKindSet) Equal(other GroupVersionKindSet) bool {
+	return sets.Set[GroupVersionKind](s).Equal(sets.Set[GroupVersionKind](other))
+}
+
+// FilterGroup returns a new set of the items in s belonging to group.
+func (s GroupVersionKindSet) FilterGroup(group string) GroupVersionKindSet {
+	out := GroupVersionKindSet{}
+	for gvk := range s {
+		if gvk.Group == group {
+			out[gvk] = sets.Empty{}
+		}
+	}
+	return out
+}
+
+// Groups returns the set of groups of the items in s.
+func (s GroupVersionKindSet) Groups() sets.Set[string] {
+	groups := sets.New[string]()
+	for gvk := range s {
+		groups.Insert(gvk.Group)
+	}
+	return groups
+}
+
+// List returns the items of the set sorted by group, version and kind.
+func (s GroupVersionKindSet) List() []GroupVersionKind {
+	list := sets.Set[GroupVersionKind](s).UnsortedList()
+	sortGroupVersionKinds(list)
+	return list
+}
+
+// GroupVersionResourceSet is a set of GroupVersionResources.
+type GroupVersionResourceSet sets.Set[GroupVersionResource]
+
+// NewGroupVersionResourceSet returns a GroupVersionResourceSet holding items.
+func NewGroupVersionResourceSet(items ...GroupVersionResource) GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.New(items...))
+}
+
+// Insert adds items to the set and returns it.
+func (s GroupVersionResourceSet) Insert(items ...GroupVersionResource) GroupVersionResourceSet {
+	sets.Set[GroupVersionResource](s).Insert(items...)
+	return s
+}
+
+// Delete removes items from the set and returns it.
+func (s GroupVersionResourceSet) Delete(items ...GroupVersionResource) GroupVersionResourceSet {
+	sets.Set[GroupVersionResource](s).Delete(items...)
+	return s
+}
+
+// Has returns true if item is contained in the set.
+func (s GroupVersionResourceSet) Has(item GroupVersionResource) bool {
+	_, contained := s[item]
+	return contained
+}
+
+// Len returns the size of the set.
+func (s GroupVersionResourceSet) Len() int {
+	return len(s)
+}
+
+// Clone returns a new set which is a copy of the current set.
+func (s GroupVersionResourceSet) Clone() GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.Set[GroupVersionResource](s).Clone())
+}
+
+// Union returns a new set which includes the items in either s or other.
+func (s GroupVersionResourceSet) Union(other GroupVersionResourceSet) GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.Set[GroupVersionResource](s).Union(sets.Set[GroupVersionResource](other)))
+}
+
+// Intersection returns a new set which includes the items in both s and other.
+func (s GroupVersionResourceSet) Intersection(other GroupVersionResourceSet) GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.Set[GroupVersionResource](s).Intersection(sets.Set[GroupVersionResource](other)))
+}
+
+// Difference returns a new set of the items in s which are not in other.
+func (s GroupVersionResourceSet) Difference(other GroupVersionResourceSet) GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.Set[GroupVersionResource](s).Difference(sets.Set[GroupVersionResource](other)))
+}
+
+// Equal returns true if s and other contain the same items.
+func (s GroupVersionResourceSet) Equal(other GroupVersionResourceSet) bool {
+	return sets.Set[GroupVersionResource](s).Equal(sets.Set[GroupVersionResource](other))
+}
+
+// FilterGroup returns a new set of the items in s belonging to group.
+func (s GroupVersionResourceSet) FilterGroup(group string) GroupVersionResourceSet {
+	out := GroupVersionResourceSet{}
+	for gvr := range s {
+		if gvr.Group == group {
+			out[gvr] = sets.Empty{}
+		}
+	}
+	return out
+}
+
+// Groups returns the set of groups of the items in s.
+func (s GroupVersionResourceSet) Groups() sets.Set[string] {
+	groups := sets.New[string]()
+	for gvr := range s {
+		groups.Insert(gvr.Group)
+	}
+	return groups
+}
+
+// List returns the items of the set sorted by group, version and resource.
+func (s GroupVersionResourceSet) List() []GroupVersionResource {
+	list := sets.Set[GroupVersionResource](s).UnsortedList()
+	sortGroupVersionResources(list)
+	return list
+}
+
+// GroupVersionKindMap is a map keyed by GroupVersionKind.
+type GroupVersionKindMap[V any] map[GroupVersionKind]V
+
+// KeySet returns the set of keys of m.
+func (m GroupVersionKindMap[V]) KeySet() GroupVersionKindSet {
+	return GroupVersionKindSet(sets.KeySet(m))
+}
+
+// SortedKeys returns the keys of m sorted by group, version and kind, for
+// iterating over m in a stable order.
+func (m GroupVersionKindMap[V]) SortedKeys() []GroupVersionKind {
+	keys := make([]GroupVersionKind, 0, len(m))
+	for gvk := range m {
+		keys = append(keys, gvk)
+	}
+	sortGroupVersionKinds(keys)
+	return keys
+}
+
+// FilterGroup returns a new map of the entries of m whose key belongs to group.
+func (m GroupVersionKindMap[V]) FilterGroup(group string) GroupVersionKindMap[V] {
+	out := GroupVersionKindMap[V]{}
+	for gvk, v := range m {
+		if gvk.Group == group {
+			out[gvk] = v
+		}
+	}
+	return out
+}
+
+// GroupVersionResourceMap is a map keyed by GroupVersionResource.
+type GroupVersionResourceMap[V any] map[GroupVersionResource]V
+
+// KeySet returns the set of keys of m.
+func (m GroupVersionResourceMap[V]) KeySet() GroupVersionResourceSet {
+	return GroupVersionResourceSet(sets.KeySet(m))
+}
+
+// SortedKeys returns the keys of m sorted by group, version and resource, for
+// iterating over m in a stable order.
+func (m GroupVersionResourceMap[V]) SortedKeys() []GroupVersionResource {
+	keys := make([]GroupVersionResource, 0, len(m))
+	for gvr := range m {
+		keys = append(keys, gvr)
+	}
+	sortGroupVersionResources(keys)
+	return keys
+}
+
+// FilterGroup returns a new map of the entries of m whose key belongs to group.
+func (m GroupVersionResourceMap[V]) FilterGroup(group string) GroupVersionResourceMap[V] {
+	out := GroupVersionResourceMap[V]{}
+	for gvr, v := range m {
+		if gvr.Group == group {
+			out[gvr] = v
+		}
+	}
+	return out
+}
+
+func sortGroupVersionKinds(list []GroupVersionKind) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Group != list[j].Group {
+			return list[i].Group < list[j].Group
+		}
+		if list[i].Version != list[j].Version {
+			return list[i].Version < list[j].Version
+		}
+		return list[i].Kind < list[j].Kind
+	})
+}
+
+func sortGroupVersionResources(list []GroupVersionResource) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Group != list[j].Group {
+			return list[i].Group < list[j].Group
+		}
+		if list[i].Version != list[j].Version {
+			return list[i].Version < list[j].Version
+		}
+		return list[i].Resource < list[j].Resource
+	})
+}
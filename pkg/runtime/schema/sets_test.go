@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupVersionKindSet(t *testing.T) {
+	pod := GroupVersionKind{Version: "v1", Kind: "Pod"}
+	deploymentV1 := GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	deploymentV1beta1 := GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"}
+	daemonSet := GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}
+
+	s1 := NewGroupVersionKindSet(deploymentV1beta1, pod, deploymentV1)
+	s2 := NewGroupVersionKindSet(daemonSet, deploymentV1)
+
+	if expected := []GroupVersionKind{pod, daemonSet, deploymentV1, deploymentV1beta1}; !reflect.DeepEqual(s1.Union(s2).List(), expected) {
+		t.Errorf("expected %v, got %v", expected, s1.Union(s2).List())
+	}
+	if !s1.Intersection(s2).Equal(NewGroupVersionKindSet(deploymentV1)) {
+		t.Errorf("unexpected intersection %v", s1.Intersection(s2).List())
+	}
+	if !s1.Difference(s2).Equal(NewGroupVersionKindSet(pod, deploymentV1beta1)) {
+		t.Errorf("unexpected difference %v", s1.Difference(s2).List())
+	}
+	if apps := s1.FilterGroup("apps"); apps.Len() != 2 || apps.Has(pod) {
+		t.Errorf("unexpected apps kinds %v", apps.List())
+	}
+	if groups := s1.Groups(); groups.Len() != 2 || !groups.Has("") || !groups.Has("apps") {
+		t.Errorf("unexpected groups %v", groups)
+	}
+
+	clone := s1.Clone().Delete(pod)
+	if !s1.Has(pod) || clone.Has(pod) {
+		t.Errorf("expected the clone to be independent of the original set")
+	}
+}
+
+func TestGroupVersionResourceSet(t *testing.T) {
+	pods := GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	s := NewGroupVersionResourceSet().Insert(deployments, pods)
+	if expected := []GroupVersionResource{pods, deployments}; !reflect.DeepEqual(s.List(), expected) {
+		t.Errorf("expected %v, got %v", expected, s.List())
+	}
+	if !s.FilterGroup("apps").Equal(NewGroupVersionResourceSet(deployments)) {
+		t.Errorf("unexpected apps resources %v", s.FilterGroup("apps").List())
+	}
+	if !s.Union(NewGroupVersionResourceSet(pods)).Equal(s) || s.Intersection(GroupVersionResourceSet{}).Len() != 0 {
+		t.Errorf("unexpected set operations on %v", s.List())
+	}
+}
+
+func TestGroupVersionKindMap(t *testing.T) {
+	m := GroupVersionKindMap[string]{
+		{Group: "apps", Version: "v1", Kind: "Deployment"}: "deployments",
+		{Version: "v1", Kind: "Pod"}:                       "pods",
+		{Group: "apps", Version: "v1", Kind: "DaemonSet"}:  "daemonsets",
+	}
+	var resources []string
+	for _, gvk := range m.SortedKeys() {
+		resources = append(resources, m[gvk])
+	}
+	if expected := []string{"pods", "daemonsets", "deployments"}; !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected %v, got %v", expected, resources)
+	}
+	if apps := m.FilterGroup("apps"); len(apps) != 2 || !apps.KeySet().Equal(m.KeySet().FilterGroup("apps")) {
+		t.Errorf("unexpected apps entries %v", apps)
+	}
+
+	r := GroupVersionResourceMap[int]{{Resource: "b"}: 2, {Resource: "a"}: 1}
+	if keys := r.SortedKeys(); keys[0].Resource != "a" || !r.KeySet().Has(GroupVersionResource{Resource: "b"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
@@ -64,6 +64,10 @@ type Scheme struct {
 	// resource field labels in that version to internal version.
 	fieldLabelConversionFuncs map[schema.GroupVersionKind]FieldLabelConversionFunc
 
+	// selectableFieldLabels holds the field labels declared as selectable for
+	// kinds whose field label conversion was registered with the labels it accepts.
+	selectableFieldLabels map[schema.GroupVersionKind]sets.Set[string]
+
 	// defaulterFuncs is a map to funcs to be called with an object to provide defaulting
 	// the provided object must be a pointer.
 	defaulterFuncs map[reflect.Type]func(interface{})
@@ -95,6 +99,7 @@ func NewScheme() *Scheme {
 		unversionedTypes:          map[reflect.Type]schema.GroupVersionKind{},
 		unversionedKinds:          map[string]reflect.Type{},
 		fieldLabelConversionFuncs: map[schema.GroupVersionKind]FieldLabelConversionFunc{},
+		selectableFieldLabels:     map[schema.GroupVersionKind]sets.Set[string]{},
 		defaulterFuncs:            map[reflect.Type]func(interface{}){},
 		versionPriority:           map[string][]string{},
 		schemeName:                naming.GetNameFromCallsite(internalPackages...),
@@ -326,11 +331,60 @@ func (s *Scheme) AddGeneratedConversionFunc(a, b interface{}, fn conversion.Conv
 
 // AddFieldLabelConversionFunc adds a conversion function to convert field selectors
 // of the given kind from the given version to internal version representation.
+// The field labels accepted by conversionFunc are not known to the scheme, see
+// AddFieldLabelConversionFuncWithLabels.
 func (s *Scheme) AddFieldLabelConversionFunc(gvk schema.GroupVersionKind, conversionFunc FieldLabelConversionFunc) error {
 	s.fieldLabelConversionFuncs[gvk] = conversionFunc
+	delete(s.selectableFieldLabels, gvk)
+	return nil
+}
+
+// AddFieldLabelConversionFuncWithLabels is like AddFieldLabelConversionFunc, but
+// declares the field labels which can be selected for the given kind, in addition
+// to metadata.name and metadata.namespace. Selectors on other labels are rejected
+// without calling conversionFunc, and SelectableFieldLabels returns the labels.
+func (s *Scheme) AddFieldLabelConversionFuncWithLabels(gvk schema.GroupVersionKind, labels []string, conversionFunc FieldLabelConversionFunc) error {
+	selectable := sets.New(defaultFieldLabels...)
+	for _, label := range labels {
+		if len(label) == 0 {
+			return fmt.Errorf("empty field label for %v", gvk)
+		}
+		selectable.Insert(label)
+	}
+	s.fieldLabelConversionFuncs[gvk] = func(label, value string) (string, string, error) {
+		if !selectable.Has(label) {
+			return "", "", fmt.Errorf("%q is not a known field selector: only %s", label, quotedList(sets.List(selectable)))
+		}
+		return conversionFunc(label, value)
+	}
+	s.selectableFieldLabels[gvk] = selectable
 	return nil
 }
 
+// AddFieldLabels declares the field labels which can be selected for the given
+// kind, in addition to metadata.name and metadata.namespace. Labels and values
+// are passed on unchanged, i.e. the labels are the same in all versions of the kind.
+func (s *Scheme) AddFieldLabels(gvk schema.GroupVersionKind, labels ...string) error {
+	return s.AddFieldLabelConversionFuncWithLabels(gvk, labels, func(label, value string) (string, string, error) {
+		return label, value, nil
+	})
+}
+
+// SelectableFieldLabels returns the sorted field labels which can be selected
+// for the given kind. Kinds without a field label conversion support
+// metadata.name and metadata.namespace. false is returned if the kind has a
+// field label conversion function registered without its labels, see
+// AddFieldLabelConversionFunc.
+func (s *Scheme) SelectableFieldLabels(gvk schema.GroupVersionKind) ([]string, bool) {
+	if labels, ok := s.selectableFieldLabels[gvk]; ok {
+		return sets.List(labels), true
+	}
+	if _, ok := s.fieldLabelConversionFuncs[gvk]; ok {
+		return nil, false
+	}
+	return sets.List(sets.New(defaultFieldLabels...)), true
+}
+
 // AddTypeDefaultingFunc registers a function that is passed a pointer to an
 // object and can default fields on the object. These functions will be invoked
 // when Default() is called. The function will never be called unless the
@@ -340,6 +394,13 @@ func (s *Scheme) AddTypeDefaultingFunc(srcType Object, fn func(interface{})) {
 	s.defaulterFuncs[reflect.TypeOf(srcType)] = fn
 }
 
+// HasTypeDefaultingFunc returns true if a defaulting function is registered for
+// the type of obj.
+func (s *Scheme) HasTypeDefaultingFunc(obj Object) bool {
+	_, ok := s.defaulterFuncs[reflect.TypeOf(obj)]
+	return ok
+}
+
 // Default sets defaults on the provided Object.
 func (s *Scheme) Default(src Object) {
 	if fn, ok := s.defaulterFuncs[reflect.TypeOf(src)]; ok {
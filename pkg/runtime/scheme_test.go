@@ -1009,3 +1009,59 @@ func TestMetaValuesUnregisteredConvert(t *testing.T) {
 		t.Errorf("Expected %v, got %v", e, a)
 	}
 }
+
+func TestFieldLabels(t *testing.T) {
+	s := runtime.NewScheme()
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	nodeGVK := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	eventGVK := schema.GroupVersionKind{Version: "v1", Kind: "Event"}
+	secretGVK := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+	if err := s.AddFieldLabels(podGVK, "spec.nodeName", "status.phase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddFieldLabelConversionFuncWithLabels(nodeGVK, []string{"spec.unschedulable"}, func(label, value string) (string, string, error) {
+		return label, strings.ToLower(value), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddFieldLabelConversionFunc(eventGVK, runtime.DefaultMetaV1FieldSelectorConversion); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddFieldLabels(podGVK, ""); err == nil {
+		t.Errorf("expected error for empty label")
+	}
+
+	labels, ok := s.SelectableFieldLabels(podGVK)
+	if expected := []string{"metadata.name", "metadata.namespace", "spec.nodeName", "status.phase"}; !ok || !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected %v, got %v, %v", expected, labels, ok)
+	}
+	labels, ok = s.SelectableFieldLabels(secretGVK)
+	if expected := []string{"metadata.name", "metadata.namespace"}; !ok || !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected default labels %v, got %v, %v", expected, labels, ok)
+	}
+	if labels, ok := s.SelectableFieldLabels(eventGVK); ok {
+		t.Errorf("expected unknown labels for conversion function, got %v", labels)
+	}
+
+	if label, value, err := s.ConvertFieldLabel(podGVK, "status.phase", "Running"); err != nil || label != "status.phase" || value != "Running" {
+		t.Errorf("unexpected conversion: %q, %q, %v", label, value, err)
+	}
+	if label, value, err := s.ConvertFieldLabel(podGVK, "metadata.name", "foo"); err != nil || label != "metadata.name" || value != "foo" {
+		t.Errorf("unexpected conversion: %q, %q, %v", label, value, err)
+	}
+	if _, _, err := s.ConvertFieldLabel(podGVK, "spec.unschedulable", "true"); err == nil || !strings.Contains(err.Error(), `"spec.nodeName"`) {
+		t.Errorf("expected error listing the selectable labels, got %v", err)
+	}
+	if label, value, err := s.ConvertFieldLabel(nodeGVK, "spec.unschedulable", "TRUE"); err != nil || label != "spec.unschedulable" || value != "true" {
+		t.Errorf("unexpected conversion: %q, %q, %v", label, value, err)
+	}
+
+	// registering a conversion function without labels forgets the declared labels
+	if err := s.AddFieldLabelConversionFunc(podGVK, runtime.DefaultMetaV1FieldSelectorConversion); err != nil {
+		t.Fatal(err)
+	}
+	if labels, ok := s.SelectableFieldLabels(podGVK); ok {
+		t.Errorf("expected unknown labels after overriding conversion function, got %v", labels)
+	}
+}
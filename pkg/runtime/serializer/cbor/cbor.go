@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
 	"k8s.io/apimachinery/pkg/runtime/serializer/recognizer"
+	"k8s.io/apimachinery/pkg/util/framer"
 	util "k8s.io/apimachinery/pkg/util/runtime"
 
 	"github.com/fxamacker/cbor/v2"
@@ -61,7 +63,9 @@ type Serializer interface {
 var _ Serializer = &serializer{}
 
 type options struct {
-	strict bool
+	strict               bool
+	epochTimestamps      bool
+	epochMicroTimestamps bool
 }
 
 type Option func(*options)
@@ -83,6 +87,24 @@ func NewSerializer(creater runtime.ObjectCreater, typer runtime.ObjectTyper, opt
 	return newSerializer(&defaultMetaFactory{}, creater, typer, options...)
 }
 
+// NewSerializerInfo returns the SerializerInfo of the CBOR serializer, for registration with a
+// CodecFactory. Objects are streamed as a CBOR Sequence, and the objects embedded in streamed
+// watch events are encoded as CBOR as well.
+func NewSerializerInfo(creater runtime.ObjectCreater, typer runtime.ObjectTyper) runtime.SerializerInfo {
+	return runtime.SerializerInfo{
+		MediaType:        runtime.ContentTypeCBOR,
+		MediaTypeType:    "application",
+		MediaTypeSubType: "cbor",
+		Serializer:       NewSerializer(creater, typer),
+		StrictSerializer: NewSerializer(creater, typer, Strict(true)),
+		StreamSerializer: &runtime.StreamSerializerInfo{
+			MediaType:  runtime.ContentTypeCBORSeq,
+			Framer:     Framer,
+			Serializer: NewSerializer(creater, typer),
+		},
+	}
+}
+
 func newSerializer(metaFactory metaFactory, creater runtime.ObjectCreater, typer runtime.ObjectTyper, options ...Option) *serializer {
 	s := &serializer{
 		metaFactory: metaFactory,
@@ -96,7 +118,14 @@ func newSerializer(metaFactory metaFactory, creater runtime.ObjectCreater, typer
 }
 
 func (s *serializer) Identifier() runtime.Identifier {
-	return "cbor"
+	identifier := "cbor"
+	if s.options.epochTimestamps {
+		identifier += "-epoch-timestamps"
+	}
+	if s.options.epochMicroTimestamps {
+		identifier += "-epoch-micro-timestamps"
+	}
+	return runtime.Identifier(identifier)
 }
 
 func (s *serializer) Encode(obj runtime.Object, w io.Writer) error {
@@ -108,6 +137,24 @@ func (s *serializer) Encode(obj runtime.Object, w io.Writer) error {
 	if u, ok := obj.(runtime.Unstructured); ok {
 		return e.Encode(u.UnstructuredContent())
 	}
+	var kinds timestampKinds
+	if s.options.epochTimestamps {
+		kinds |= timeKind
+	}
+	if s.options.epochMicroTimestamps {
+		kinds |= microTimeKind
+	}
+	if kinds != 0 {
+		data, err := modes.Encode.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if data, err = transcodeEpochTimestamps(data, reflect.TypeOf(obj), kinds); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
 	return e.Encode(obj)
 }
 
@@ -218,6 +265,11 @@ func (s *serializer) unmarshal(data []byte, into interface{}) (strict, lax error
 			}
 		}()
 		into = &content
+
+		// malformed data is left to the decoder to report
+		if transcoded, err := transcodeFractionalEpochDateTimes(data); err == nil {
+			data = transcoded
+		}
 	}
 
 	if !s.options.strict {
@@ -311,3 +363,20 @@ var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
 func (s *serializer) RecognizesData(data []byte) (ok, unknown bool, err error) {
 	return bytes.HasPrefix(data, selfDescribedCBOR), false, nil
 }
+
+// Framer splits a stream of CBOR-encoded objects, written one after the other as a CBOR Sequence
+// (RFC 8742), into individual data items.
+var Framer = cborFramer{}
+
+type cborFramer struct{}
+
+// NewFrameWriter implements stream framing for this serializer
+func (cborFramer) NewFrameWriter(w io.Writer) io.Writer {
+	// CBOR data items are self-delimiting, so they can be written directly to the writer
+	return w
+}
+
+// NewFrameReader implements stream framing for this serializer
+func (cborFramer) NewFrameReader(r io.ReadCloser) io.ReadCloser {
+	return framer.NewCBORSequenceFrameReader(r)
+}
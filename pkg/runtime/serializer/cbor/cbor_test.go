@@ -638,3 +638,38 @@ type stubMetaFactory struct {
 func (mf stubMetaFactory) Interpret([]byte) (*schema.GroupVersionKind, error) {
 	return mf.gvk, mf.err
 }
+
+func TestFramer(t *testing.T) {
+	s := NewSerializer(nil, nil)
+	objs := []runtime.Object{
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v", "kind": "k", "foo": int64(1)}},
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v", "kind": "k", "foo": "bar"}},
+	}
+
+	var b bytes.Buffer
+	w := Framer.NewFrameWriter(&b)
+	for _, obj := range objs {
+		if err := s.Encode(obj, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := Framer.NewFrameReader(io.NopCloser(&b))
+	for i, want := range objs {
+		buf := make([]byte, 256)
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("frame %d: unexpected error: %v", i, err)
+		}
+		got, _, err := s.Decode(buf[:n], nil, &unstructured.Unstructured{})
+		if err != nil {
+			t.Fatalf("frame %d: unexpected error: %v", i, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("frame %d: unexpected diff:\n%s", i, diff)
+		}
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
@@ -0,0 +1,581 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// EpochTimestamps makes the serializer encode metav1.Time values as CBOR
+// epoch-based date/times (tag 1), integral seconds since the epoch, instead of
+// RFC 3339 text strings. The encoding is smaller and cheaper to parse, e.g. for
+// objects with many timestamps like Events. Decoding accepts both encodings
+// regardless of this option, and unstructured objects decode epoch-based
+// date/times to RFC 3339 strings.
+//
+// metav1.MicroTime values are still encoded as text, see EpochMicroTimestamps.
+// Unstructured objects are encoded as they are, since their timestamps are
+// strings which cannot be told apart from other strings.
+func EpochTimestamps(e bool) Option {
+	return func(opts *options) {
+		opts.epochTimestamps = e
+	}
+}
+
+// EpochMicroTimestamps makes the serializer encode metav1.MicroTime values as
+// CBOR epoch-based date/times (tag 1) with fractional seconds, the counterpart
+// of EpochTimestamps for metav1.Time. Fractional seconds are floating-point
+// numbers, which do not represent all microseconds exactly: MicroTime rounds
+// them to the nearest microsecond when decoding, and the values whose
+// microseconds would not survive that, e.g. in the far future, are encoded as
+// text. Either way, the microsecond precision of MicroTime is preserved, as it
+// is by the other serializers. Unstructured objects decode epoch-based
+// date/times with fractional seconds to RFC 3339 strings with microseconds,
+// the format MicroTime is written in as text.
+func EpochMicroTimestamps(e bool) Option {
+	return func(opts *options) {
+		opts.epochMicroTimestamps = e
+	}
+}
+
+// timestampKinds is a set of timestamp types.
+type timestampKinds uint8
+
+const (
+	// timeKind is metav1.Time.
+	timeKind timestampKinds = 1 << iota
+	// microTimeKind is metav1.MicroTime.
+	microTimeKind
+)
+
+var (
+	timeType      = reflect.TypeOf(metav1.Time{})
+	microTimeType = reflect.TypeOf(metav1.MicroTime{})
+	marshalerType = reflect.TypeOf((*cbor.Marshaler)(nil)).Elem()
+)
+
+// CBOR major types, see https://www.rfc-editor.org/rfc/rfc8949.html#section-3.1.
+const (
+	majorTypeUnsignedInt = 0
+	majorTypeNegativeInt = 1
+	majorTypeByteString  = 2
+	majorTypeTextString  = 3
+	majorTypeArray       = 4
+	majorTypeMap         = 5
+	majorTypeTag         = 6
+	majorTypeSimple      = 7
+
+	// additionalInformationIndefinite is the additional information of the
+	// heads of indefinite-length items.
+	additionalInformationIndefinite = 31
+	// tagEpochDateTime is the tag number of epoch-based date/times.
+	tagEpochDateTime = 1
+	// simpleFloat64 is the additional information of the heads of
+	// double-precision floating-point numbers.
+	simpleFloat64 = 27
+)
+
+var errMalformed = errors.New("malformed CBOR data item")
+
+// epochTranscoder rewrites the encoded timestamps of typed objects as
+// epoch-based date/times. It walks the encoded data item along with the Go
+// type it was encoded from, so that only values of timestamp types are
+// rewritten.
+type epochTranscoder struct {
+	data  []byte
+	out   []byte
+	kinds timestampKinds
+}
+
+// timestampTypes caches, per Go type, the kinds of timestamps values of the
+// type may contain.
+var timestampTypes sync.Map
+
+// fieldTypesCache caches the types of the encoded fields of struct types,
+// keyed by their names.
+var fieldTypesCache sync.Map
+
+// transcodeEpochTimestamps returns data, the encoding of a value of type t,
+// with its timestamps of the given kinds encoded as epoch-based date/times.
+func transcodeEpochTimestamps(data []byte, t reflect.Type, kinds timestampKinds) ([]byte, error) {
+	if timestampKindsOf(t, map[reflect.Type]bool{})&kinds == 0 {
+		return data, nil
+	}
+	tc := &epochTranscoder{data: data, out: make([]byte, 0, len(data)), kinds: kinds}
+	end, err := tc.transcode(0, t)
+	if err != nil {
+		return nil, err
+	}
+	if end != len(data) {
+		return nil, fmt.Errorf("unexpected %d bytes after CBOR data item", len(data)-end)
+	}
+	return tc.out, nil
+}
+
+// transcode copies the data item at offset i, encoded from a value of type t,
+// to the output, and returns the offset following it.
+func (tc *epochTranscoder) transcode(i int, t reflect.Type) (int, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if i >= len(tc.data) {
+		return 0, errMalformed
+	}
+	if t == timeType && tc.kinds&timeKind != 0 {
+		return tc.transcodeTimestamp(i)
+	}
+	if t == microTimeType && tc.kinds&microTimeKind != 0 {
+		return tc.transcodeMicroTimestamp(i)
+	}
+	if timestampKindsOf(t, map[reflect.Type]bool{})&tc.kinds == 0 || reflect.PointerTo(t).Implements(marshalerType) {
+		return tc.copyItem(i)
+	}
+
+	major, arg, headLength, err := readHead(tc.data, i)
+	if err != nil {
+		return 0, err
+	}
+	if arg < 0 {
+		// the serializer does not encode indefinite-length items
+		return tc.copyItem(i)
+	}
+	switch {
+	case t.Kind() == reflect.Struct && major == majorTypeMap:
+		fields := fieldTypes(t)
+		tc.out = append(tc.out, tc.data[i:i+headLength]...)
+		i += headLength
+		for n := 0; n < arg; n++ {
+			keyStart := i
+			if i, err = tc.copyItem(i); err != nil {
+				return 0, err
+			}
+			fieldType, ok := fields[keyString(tc.data[keyStart:i])]
+			if !ok {
+				if i, err = tc.copyItem(i); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			if i, err = tc.transcode(i, fieldType); err != nil {
+				return 0, err
+			}
+		}
+		return i, nil
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && major == majorTypeArray:
+		tc.out = append(tc.out, tc.data[i:i+headLength]...)
+		i += headLength
+		for n := 0; n < arg; n++ {
+			if i, err = tc.transcode(i, t.Elem()); err != nil {
+				return 0, err
+			}
+		}
+		return i, nil
+	case t.Kind() == reflect.Map && major == majorTypeMap:
+		tc.out = append(tc.out, tc.data[i:i+headLength]...)
+		i += headLength
+		for n := 0; n < arg; n++ {
+			if i, err = tc.copyItem(i); err != nil {
+				return 0, err
+			}
+			if i, err = tc.transcode(i, t.Elem()); err != nil {
+				return 0, err
+			}
+		}
+		return i, nil
+	default:
+		return tc.copyItem(i)
+	}
+}
+
+// transcodeTimestamp writes the timestamp at offset i, an RFC 3339 string as
+// written by metav1.Time, as an epoch-based date/time. Other data items, e.g.
+// null for zero timestamps, are copied as they are.
+func (tc *epochTranscoder) transcodeTimestamp(i int) (int, error) {
+	major, arg, headLength, err := readHead(tc.data, i)
+	if err != nil {
+		return 0, err
+	}
+	if (major != majorTypeByteString && major != majorTypeTextString) || arg < 0 {
+		return tc.copyItem(i)
+	}
+	end := i + headLength + arg
+	if end > len(tc.data) {
+		return 0, errMalformed
+	}
+	parsed, err := time.Parse(time.RFC3339, string(tc.data[i+headLength:end]))
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode timestamp as epoch-based date/time: %w", err)
+	}
+
+	tc.out = appendHead(tc.out, majorTypeTag, tagEpochDateTime)
+	if seconds := parsed.Unix(); seconds >= 0 {
+		tc.out = appendHead(tc.out, majorTypeUnsignedInt, uint64(seconds))
+	} else {
+		tc.out = appendHead(tc.out, majorTypeNegativeInt, uint64(-1-seconds))
+	}
+	return end, nil
+}
+
+// transcodeMicroTimestamp writes the timestamp at offset i, an RFC 3339 string
+// with microseconds as written by metav1.MicroTime, as an epoch-based
+// date/time with fractional seconds, if it decodes back to the same
+// microsecond. Other data items and timestamps are copied as they are.
+func (tc *epochTranscoder) transcodeMicroTimestamp(i int) (int, error) {
+	major, arg, headLength, err := readHead(tc.data, i)
+	if err != nil {
+		return 0, err
+	}
+	if (major != majorTypeByteString && major != majorTypeTextString) || arg < 0 {
+		return tc.copyItem(i)
+	}
+	end := i + headLength + arg
+	if end > len(tc.data) {
+		return 0, errMalformed
+	}
+	parsed, err := time.Parse(metav1.RFC3339Micro, string(tc.data[i+headLength:end]))
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode timestamp as epoch-based date/time: %w", err)
+	}
+
+	seconds := float64(parsed.Unix()) + float64(parsed.Nanosecond())/float64(time.Second)
+	item := appendHead(nil, majorTypeTag, tagEpochDateTime)
+	item = binary.BigEndian.AppendUint64(append(item, majorTypeSimple<<5|simpleFloat64), math.Float64bits(seconds))
+	var decoded time.Time
+	if err := modes.Decode.Unmarshal(item, &decoded); err != nil || !decoded.Round(time.Microsecond).Equal(parsed) {
+		return tc.copyItem(i)
+	}
+	tc.out = append(tc.out, item...)
+	return end, nil
+}
+
+// transcodeFractionalEpochDateTimes returns data with its epoch-based
+// date/times with fractional seconds, as written for metav1.MicroTime by
+// EpochMicroTimestamps, rewritten as RFC 3339 strings with microseconds.
+// Decoding them into unstructured objects as they are yields strings with the
+// nanoseconds of the nearest floating-point number, which MicroTime does not
+// parse.
+func transcodeFractionalEpochDateTimes(data []byte) ([]byte, error) {
+	if bytes.IndexByte(data, majorTypeTag<<5|tagEpochDateTime) < 0 {
+		return data, nil
+	}
+	tc := &epochTranscoder{data: data, out: make([]byte, 0, len(data))}
+	i := 0
+	for i < len(data) {
+		var err error
+		if i, err = tc.transcodeFractional(i); err != nil {
+			return nil, err
+		}
+	}
+	return tc.out, nil
+}
+
+// transcodeFractional copies the data item at offset i to the output, with its
+// epoch-based date/times with fractional seconds written as RFC 3339 strings
+// with microseconds, and returns the offset following it.
+func (tc *epochTranscoder) transcodeFractional(i int) (int, error) {
+	major, arg, headLength, err := readHead(tc.data, i)
+	if err != nil {
+		return 0, err
+	}
+	switch major {
+	case majorTypeTag:
+		if tc.data[i] == majorTypeTag<<5|tagEpochDateTime && i+1 < len(tc.data) && tc.data[i+1]>>5 == majorTypeSimple && tc.data[i+1]&0x1f >= 25 && tc.data[i+1]&0x1f <= simpleFloat64 {
+			end, err := skipItem(tc.data, i)
+			if err != nil {
+				return 0, err
+			}
+			var decoded time.Time
+			if err := modes.Decode.Unmarshal(tc.data[i:end], &decoded); err != nil {
+				return 0, err
+			}
+			text := decoded.Round(time.Microsecond).UTC().Format(metav1.RFC3339Micro)
+			tc.out = append(appendHead(tc.out, majorTypeTextString, uint64(len(text))), text...)
+			return end, nil
+		}
+		tc.out = append(tc.out, tc.data[i:i+headLength]...)
+		return tc.transcodeFractional(i + headLength)
+	case majorTypeArray, majorTypeMap:
+		tc.out = append(tc.out, tc.data[i:i+headLength]...)
+		i += headLength
+		count := arg
+		if major == majorTypeMap {
+			count *= 2
+		}
+		for n := 0; arg < 0 || n < count; n++ {
+			if arg < 0 {
+				if i >= len(tc.data) {
+					return 0, errMalformed
+				}
+				if tc.data[i] == 0xff {
+					tc.out = append(tc.out, 0xff)
+					return i + 1, nil
+				}
+			}
+			if i, err = tc.transcodeFractional(i); err != nil {
+				return 0, err
+			}
+		}
+		return i, nil
+	default:
+		return tc.copyItem(i)
+	}
+}
+
+// copyItem copies the data item at offset i to the output as it is, and
+// returns the offset following it.
+func (tc *epochTranscoder) copyItem(i int) (int, error) {
+	end, err := skipItem(tc.data, i)
+	if err != nil {
+		return 0, err
+	}
+	tc.out = append(tc.out, tc.data[i:end]...)
+	return end, nil
+}
+
+// readHead returns the major type and argument of the head of the data item
+// at offset i, and the length of the head. The argument is -1 for the heads
+// of indefinite-length items.
+func readHead(data []byte, i int) (major byte, arg int, length int, err error) {
+	if i >= len(data) {
+		return 0, 0, 0, errMalformed
+	}
+	major, info := data[i]>>5, data[i]&0x1f
+	var size int
+	switch {
+	case info < 24:
+		return major, int(info), 1, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == additionalInformationIndefinite:
+		return major, -1, 1, nil
+	default:
+		return 0, 0, 0, errMalformed
+	}
+	if i+1+size > len(data) {
+		return 0, 0, 0, errMalformed
+	}
+	var value uint64
+	for _, b := range data[i+1 : i+1+size] {
+		value = value<<8 | uint64(b)
+	}
+	switch major {
+	case majorTypeByteString, majorTypeTextString, majorTypeArray, majorTypeMap:
+		// neither lengths nor counts of nested items can exceed the length
+		// of the data
+		if value > uint64(len(data)) {
+			return 0, 0, 0, errMalformed
+		}
+		return major, int(value), 1 + size, nil
+	default:
+		// the arguments of integers, tags and simple values are not used
+		return major, 0, 1 + size, nil
+	}
+}
+
+// skipItem returns the offset following the data item at offset i.
+func skipItem(data []byte, i int) (int, error) {
+	major, arg, headLength, err := readHead(data, i)
+	if err != nil {
+		return 0, err
+	}
+	i += headLength
+	switch major {
+	case majorTypeUnsignedInt, majorTypeNegativeInt:
+		return i, nil
+	case majorTypeSimple:
+		if arg < 0 {
+			// a break outside of an indefinite-length item
+			return 0, errMalformed
+		}
+		return i, nil
+	case majorTypeTag:
+		return skipItem(data, i)
+	case majorTypeByteString, majorTypeTextString:
+		if arg >= 0 {
+			if i+arg > len(data) {
+				return 0, errMalformed
+			}
+			return i + arg, nil
+		}
+	}
+
+	count := arg
+	if major == majorTypeMap && count > 0 {
+		count *= 2
+	}
+	for n := 0; arg < 0 || n < count; n++ {
+		if arg < 0 {
+			if i >= len(data) {
+				return 0, errMalformed
+			}
+			if data[i] == 0xff {
+				return i + 1, nil
+			}
+		}
+		if i, err = skipItem(data, i); err != nil {
+			return 0, err
+		}
+		if arg < 0 && major == majorTypeMap {
+			if i, err = skipItem(data, i); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return i, nil
+}
+
+// appendHead appends the head of a data item with the given major type and
+// argument to out.
+func appendHead(out []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(out, major<<5|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(out, major<<5|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(out, major<<5|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(out, major<<5|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, major<<5|27), arg)
+	}
+}
+
+// keyString returns the content of the encoded map key, a byte or text
+// string, or "" for other keys.
+func keyString(key []byte) string {
+	major, arg, headLength, err := readHead(key, 0)
+	if err != nil || arg < 0 || (major != majorTypeByteString && major != majorTypeTextString) || headLength+arg != len(key) {
+		return ""
+	}
+	return string(key[headLength:])
+}
+
+// timestampKindsOf returns the kinds of timestamps values of type t may
+// contain, which are not encoded by other cbor.Marshaler implementations.
+func timestampKindsOf(t reflect.Type, visiting map[reflect.Type]bool) timestampKinds {
+	if cached, ok := timestampTypes.Load(t); ok {
+		return cached.(timestampKinds)
+	}
+	if visiting[t] {
+		// recursive types are resolved by their outermost occurrence
+		return 0
+	}
+	visiting[t] = true
+	var kinds timestampKinds
+	switch {
+	case t == timeType:
+		kinds = timeKind
+	case t == microTimeType:
+		kinds = microTimeKind
+	case reflect.PointerTo(t).Implements(marshalerType):
+	default:
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			kinds = timestampKindsOf(t.Elem(), visiting)
+		case reflect.Struct:
+			for _, fieldType := range fieldTypes(t) {
+				kinds |= timestampKindsOf(fieldType, visiting)
+			}
+		}
+	}
+	delete(visiting, t)
+	if len(visiting) == 0 || kinds == timeKind|microTimeKind {
+		timestampTypes.Store(t, kinds)
+	}
+	return kinds
+}
+
+// fieldTypes returns the types of the fields of the struct type t, keyed by
+// the names they are encoded with. Like the CBOR encoder, names are taken
+// from cbor struct tags, falling back to json struct tags and field names,
+// and the fields of embedded structs without a name are promoted.
+func fieldTypes(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := fieldTypesCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+	fields := map[string]reflect.Type{}
+	addFieldTypes(fields, t, map[reflect.Type]bool{})
+	fieldTypesCache.Store(t, fields)
+	return fields
+}
+
+func addFieldTypes(fields map[string]reflect.Type, t reflect.Type, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	// fields of embedded structs are added last, since they are shadowed by
+	// the fields of t
+	var embedded []reflect.Type
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		tag, ok := field.Tag.Lookup("cbor")
+		if !ok {
+			tag = field.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && len(name) == 0 {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	for _, embeddedType := range embedded {
+		promoted := map[string]reflect.Type{}
+		addFieldTypes(promoted, embeddedType, visited)
+		for name, fieldType := range promoted {
+			if _, shadowed := fields[name]; !shadowed {
+				fields[name] = fieldType
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type EmbeddedTimestamps struct {
+	Observed metav1.Time `json:"observed"`
+}
+
+type timestampsObject struct {
+	metav1.TypeMeta `json:",inline"`
+	EmbeddedTimestamps
+	Event    metav1.MicroTime       `json:"event"`
+	Far      *metav1.MicroTime      `json:"far,omitempty"`
+	Last     *metav1.Time           `json:"last,omitempty"`
+	Series   []metav1.Time          `json:"series"`
+	ByName   map[string]metav1.Time `json:"byName"`
+	Unset    metav1.Time            `json:"unset"`
+	NotATime string                 `json:"notATime"`
+}
+
+func (o *timestampsObject) DeepCopyObject() runtime.Object {
+	panic("unimplemented")
+}
+
+func TestEncodeEpochTimestamps(t *testing.T) {
+	last := metav1.NewTime(time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC))
+	in := &timestampsObject{
+		EmbeddedTimestamps: EmbeddedTimestamps{Observed: metav1.NewTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))},
+		Event:              metav1.NewMicroTime(time.Date(2024, time.March, 1, 10, 0, 0, 123456000, time.UTC)),
+		Last:               &last,
+		Series:             []metav1.Time{metav1.NewTime(time.Unix(1000, 0)), metav1.NewTime(time.Unix(2000, 0))},
+		ByName:             map[string]metav1.Time{"a": metav1.NewTime(time.Unix(3000, 0))},
+		NotATime:           "2024-03-01T10:00:00Z",
+	}
+
+	var text, epoch bytes.Buffer
+	if err := NewSerializer(nil, nil).Encode(in, &text); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSerializer(nil, nil, EpochTimestamps(true))
+	if err := s.Encode(in, &epoch); err != nil {
+		t.Fatal(err)
+	}
+	if epoch.Len() >= text.Len() {
+		t.Errorf("expected epoch timestamps to be smaller than text timestamps, got %d and %d bytes", epoch.Len(), text.Len())
+	}
+
+	diag, err := modes.Diagnostic.Diagnose(epoch.Bytes()[len(selfDescribedCBOR):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`'observed': 1(1709287200)`,
+		`'event': '2024-03-01T10:00:00.123456Z'`,
+		`'last': 1(-315619200)`,
+		`'series': [1(1000), 1(2000)]`,
+		`'byName': {'a': 1(3000)}`,
+		`'unset': null`,
+		`'notATime': '2024-03-01T10:00:00Z'`,
+	} {
+		if !strings.Contains(diag, expected) {
+			t.Errorf("expected %s in the encoding, got %s", expected, diag)
+		}
+	}
+
+	var out timestampsObject
+	if err := modes.Decode.Unmarshal(epoch.Bytes()[len(selfDescribedCBOR):], &out); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, &out, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("unexpected decoded object (-want +got):\n%s", diff)
+	}
+
+	var content map[string]interface{}
+	if err := modes.Decode.Unmarshal(epoch.Bytes()[len(selfDescribedCBOR):], &content); err != nil {
+		t.Fatal(err)
+	}
+	if content["last"] != "1960-01-01T00:00:00Z" || content["observed"] != "2024-03-01T10:00:00Z" {
+		t.Errorf("expected unstructured timestamps to decode to RFC 3339 strings, got %v", content)
+	}
+
+	if s.Identifier() == NewSerializer(nil, nil).Identifier() {
+		t.Errorf("expected the identifier to differ from the identifier without epoch timestamps")
+	}
+}
+
+func TestEncodeEpochMicroTimestamps(t *testing.T) {
+	far := metav1.NewMicroTime(time.Date(9000, time.January, 1, 0, 0, 0, 1000, time.UTC))
+	in := &timestampsObject{
+		EmbeddedTimestamps: EmbeddedTimestamps{Observed: metav1.NewTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))},
+		Event:              metav1.NewMicroTime(time.Date(2024, time.March, 1, 10, 0, 0, 123456000, time.UTC)),
+		Far:                &far,
+	}
+
+	s := NewSerializer(nil, nil, EpochMicroTimestamps(true))
+	var epoch bytes.Buffer
+	if err := s.Encode(in, &epoch); err != nil {
+		t.Fatal(err)
+	}
+	diag, err := modes.Diagnostic.Diagnose(epoch.Bytes()[len(selfDescribedCBOR):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`'observed': '2024-03-01T10:00:00Z'`,
+		`'event': 1(1709287200.123456`,
+		`'far': '9000-01-01T00:00:00.000001Z'`,
+	} {
+		if !strings.Contains(diag, expected) {
+			t.Errorf("expected %s in the encoding, got %s", expected, diag)
+		}
+	}
+
+	var out timestampsObject
+	if err := modes.Decode.Unmarshal(epoch.Bytes()[len(selfDescribedCBOR):], &out); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, &out, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("unexpected decoded object (-want +got):\n%s", diff)
+	}
+
+	both := NewSerializer(nil, nil, EpochTimestamps(true), EpochMicroTimestamps(true)).Identifier()
+	if s.Identifier() == NewSerializer(nil, nil).Identifier() || s.Identifier() == both || both == NewSerializer(nil, nil, EpochTimestamps(true)).Identifier() {
+		t.Errorf("expected the identifiers to differ by options")
+	}
+}
+
+func TestDecodeEpochMicroTimestampsToUnstructured(t *testing.T) {
+	last := metav1.NewTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))
+	in := &timestampsObject{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Timestamps"},
+		Event:    metav1.NewMicroTime(time.Date(2024, time.March, 1, 10, 0, 0, 123456000, time.UTC)),
+		Last:     &last,
+		Series:   []metav1.Time{},
+		ByName:   map[string]metav1.Time{},
+	}
+
+	var epoch bytes.Buffer
+	if err := NewSerializer(nil, nil, EpochTimestamps(true), EpochMicroTimestamps(true)).Encode(in, &epoch); err != nil {
+		t.Fatal(err)
+	}
+
+	u := &unstructured.Unstructured{}
+	if _, _, err := NewSerializer(nil, nil).Decode(epoch.Bytes(), nil, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Object["event"] != "2024-03-01T10:00:00.123456Z" || u.Object["last"] != "2024-03-01T10:00:00Z" {
+		t.Errorf("expected unstructured timestamps in the text formats of the timestamp types, got %v", u.Object)
+	}
+
+	var out timestampsObject
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &out); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, &out, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+		t.Errorf("unexpected converted object (-want +got):\n%s", diff)
+	}
+}
+
+func TestTranscodeFractionalEpochDateTimes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in       []byte
+		expected string
+	}{
+		{
+			name:     "float64 seconds",
+			in:       []byte{0xc1, 0xfb, 0x41, 0xd9, 0x78, 0x69, 0xc8, 0x07, 0xe6, 0xb4},
+			expected: `"2024-03-01T10:00:00.123456Z"`,
+		},
+		{
+			name:     "integral seconds are kept",
+			in:       []byte{0xc1, 0x19, 0x03, 0xe8},
+			expected: `1(1000)`,
+		},
+		{
+			name:     "nested in indefinite-length containers",
+			in:       []byte{0xbf, 0x61, 'a', 0x9f, 0xc1, 0xf9, 0x3e, 0x00, 0xff, 0xff},
+			expected: `{_ "a": [_ "1970-01-01T00:00:01.500000Z"]}`,
+		},
+		{
+			name:     "other tags",
+			in:       []byte{0xd9, 0xd9, 0xf7, 0xc1, 0xfa, 0x3f, 0xc0, 0x00, 0x00},
+			expected: `55799("1970-01-01T00:00:01.500000Z")`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := transcodeFractionalEpochDateTimes(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diag, err := modes.Diagnostic.Diagnose(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diag != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, diag)
+			}
+		})
+	}
+}
+
+func TestEncodeEpochTimestampsWithoutTimestamps(t *testing.T) {
+	in := anyObject{Value: "2024-03-01T10:00:00Z"}
+	var text, epoch bytes.Buffer
+	if err := NewSerializer(nil, nil).Encode(in, &text); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSerializer(nil, nil, EpochTimestamps(true)).Encode(in, &epoch); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(text.Bytes(), epoch.Bytes()); diff != "" {
+		t.Errorf("expected objects without timestamps to be encoded as they are:\n%s", diff)
+	}
+}
+
+func TestSkipItem(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      []byte
+		wantErr bool
+	}{
+		{name: "unsigned integer", in: []byte{0x1b, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{name: "float", in: []byte{0xfb, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		{name: "byte string", in: []byte{0x43, 'a', 'b', 'c'}},
+		{name: "indefinite-length text string", in: []byte{0x7f, 0x61, 'a', 0x61, 'b', 0xff}},
+		{name: "nested containers", in: []byte{0xa1, 0x41, 'a', 0x82, 0xc1, 0x01, 0xf6}},
+		{name: "indefinite-length map", in: []byte{0xbf, 0x41, 'a', 0x9f, 0x01, 0xff, 0xff}},
+		{name: "truncated string", in: []byte{0x43, 'a'}, wantErr: true},
+		{name: "truncated array", in: []byte{0x82, 0x01}, wantErr: true},
+		{name: "oversized length", in: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, wantErr: true},
+		{name: "stray break", in: []byte{0xff}, wantErr: true},
+		{name: "reserved additional information", in: []byte{0x1c}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			end, err := skipItem(tc.in, 0)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got end offset %d", end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if end != len(tc.in) {
+				t.Errorf("expected end offset %d, got %d", len(tc.in), end)
+			}
+		})
+	}
+}
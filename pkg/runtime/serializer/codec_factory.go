@@ -17,6 +17,7 @@ limitations under the License.
 package serializer
 
 import (
+	"fmt"
 	"mime"
 	"strings"
 
@@ -112,14 +113,42 @@ func newSerializersForScheme(scheme *runtime.Scheme, mf json.MetaFactory, option
 		},
 	}
 
+	for _, fn := range options.serializers {
+		serializers = append(serializers, serializerTypeFromInfo(fn(scheme, scheme)))
+	}
+
 	for _, fn := range serializerExtensions {
 		if serializer, ok := fn(scheme); ok {
 			serializers = append(serializers, serializer)
 		}
 	}
+
+	if options.DecodeLimits != (DecodeLimits{}) {
+		for i := range serializers {
+			serializers[i] = limitSerializerType(serializers[i], options.DecodeLimits)
+		}
+	}
 	return serializers
 }
 
+// serializerTypeFromInfo returns the serializerType of a serializer registered with WithSerializer.
+func serializerTypeFromInfo(info runtime.SerializerInfo) serializerType {
+	t := serializerType{
+		AcceptContentTypes: []string{info.MediaType},
+		ContentType:        info.MediaType,
+		EncodesAsText:      info.EncodesAsText,
+		Serializer:         info.Serializer,
+		PrettySerializer:   info.PrettySerializer,
+		StrictSerializer:   info.StrictSerializer,
+	}
+	if info.StreamSerializer != nil {
+		t.StreamContentType = info.StreamSerializer.MediaType
+		t.Framer = info.StreamSerializer.Framer
+		t.StreamSerializer = info.StreamSerializer.Serializer
+	}
+	return t
+}
+
 // CodecFactory provides methods for retrieving codecs and serializers for specific
 // versions and content types.
 type CodecFactory struct {
@@ -128,6 +157,17 @@ type CodecFactory struct {
 	accepts   []runtime.SerializerInfo
 
 	legacySerializer runtime.Serializer
+
+	// mediaTypeRestrictions holds the media types supported by restricted kinds.
+	mediaTypeRestrictions map[schema.GroupVersionKind][]string
+	// encoderMediaTypes maps the identifiers of the serializers to their media
+	// type.
+	encoderMediaTypes map[runtime.Identifier]string
+
+	// serializers and decodeLimits are kept to register serializers after
+	// construction.
+	serializers  []serializerType
+	decodeLimits DecodeLimits
 }
 
 // CodecFactoryOptions holds the options for configuring CodecFactory behavior
@@ -136,6 +176,12 @@ type CodecFactoryOptions struct {
 	Strict bool
 	// Pretty includes a pretty serializer along with the non-pretty one
 	Pretty bool
+	// DecodeLimits are enforced by all serializers
+	DecodeLimits DecodeLimits
+
+	serializers []func(runtime.ObjectCreater, runtime.ObjectTyper) runtime.SerializerInfo
+	// mediaTypeRestrictions holds the media types supported by restricted kinds.
+	mediaTypeRestrictions map[schema.GroupVersionKind][]string
 }
 
 // CodecFactoryOptionsMutator takes a pointer to an options struct and then modifies it.
@@ -162,6 +208,15 @@ func DisableStrict(options *CodecFactoryOptions) {
 	options.Strict = false
 }
 
+// WithSerializer configures a serializer to be supported in addition to the default serializers,
+// such as cbor.NewSerializerInfo. The serializer is negotiated by its media type, and, if it has
+// a StreamSerializer, by the media type of its streams for watches.
+func WithSerializer(f func(runtime.ObjectCreater, runtime.ObjectTyper) runtime.SerializerInfo) CodecFactoryOptionsMutator {
+	return func(options *CodecFactoryOptions) {
+		options.serializers = append(options.serializers, f)
+	}
+}
+
 // NewCodecFactory provides methods for retrieving serializers for the supported wire formats
 // and conversion wrappers to define preferred internal and external versions. In the future,
 // as the internal version is used less, callers may instead use a defaulting serializer and
@@ -180,7 +235,74 @@ func NewCodecFactory(scheme *runtime.Scheme, mutators ...CodecFactoryOptionsMuta
 	}
 
 	serializers := newSerializersForScheme(scheme, json.DefaultMetaFactory, options)
-	return newCodecFactory(scheme, serializers)
+	f := newCodecFactory(scheme, serializers)
+	f.mediaTypeRestrictions = options.mediaTypeRestrictions
+	f.decodeLimits = options.DecodeLimits
+	return f
+}
+
+// RegisterSerializer adds a serializer to the factory after its construction,
+// e.g. for extensions providing experimental encodings. The serializer is
+// negotiated by its media type, and, if it has a StreamSerializer, by the
+// media type of its streams for watches, which defaults to its media type,
+// like serializers configured with WithSerializer, and is subject to the same
+// decode limits. An error is
+// returned, and the factory is left unchanged, if the media types are invalid
+// or already supported, or if an identifier of the serializers is already
+// used by another serializer of the factory.
+//
+// Copies of the factory made before the registration, including the
+// NegotiatedSerializers returned by WithoutConversion, are not modified.
+// RegisterSerializer must not be called concurrently with other methods.
+func (f *CodecFactory) RegisterSerializer(info runtime.SerializerInfo) error {
+	if _, _, err := mime.ParseMediaType(info.MediaType); err != nil {
+		return fmt.Errorf("invalid media type %q: %w", info.MediaType, err)
+	}
+	if info.Serializer == nil {
+		return fmt.Errorf("no serializer for media type %q", info.MediaType)
+	}
+	if info.StreamSerializer != nil {
+		stream := *info.StreamSerializer
+		if stream.MediaType == "" {
+			// a stream of objects of the same media type
+			stream.MediaType = info.MediaType
+		} else if _, _, err := mime.ParseMediaType(stream.MediaType); err != nil {
+			return fmt.Errorf("invalid stream media type %q: %w", stream.MediaType, err)
+		}
+		info.StreamSerializer = &stream
+	}
+	for _, existing := range f.accepts {
+		if existing.MediaType == info.MediaType {
+			return fmt.Errorf("media type %q is already supported", info.MediaType)
+		}
+		if info.StreamSerializer != nil && existing.StreamSerializer != nil && existing.StreamSerializer.MediaType == info.StreamSerializer.MediaType {
+			return fmt.Errorf("stream media type %q is already supported", info.StreamSerializer.MediaType)
+		}
+	}
+	encoders := []runtime.Serializer{info.Serializer, info.PrettySerializer, info.StrictSerializer}
+	if info.StreamSerializer != nil {
+		encoders = append(encoders, info.StreamSerializer.Serializer)
+	}
+	for _, encoder := range encoders {
+		if encoder == nil {
+			continue
+		}
+		if mediaType, ok := f.encoderMediaTypes[encoder.Identifier()]; ok {
+			return fmt.Errorf("serializer identifier %q is already used by media type %q", encoder.Identifier(), mediaType)
+		}
+	}
+
+	t := serializerTypeFromInfo(info)
+	if f.decodeLimits != (DecodeLimits{}) {
+		t = limitSerializerType(t, f.decodeLimits)
+	}
+	serializers := make([]serializerType, 0, len(f.serializers)+1)
+	serializers = append(append(serializers, f.serializers...), t)
+	registered := newCodecFactory(f.scheme, serializers)
+	registered.mediaTypeRestrictions = f.mediaTypeRestrictions
+	registered.decodeLimits = f.decodeLimits
+	*f = registered
+	return nil
 }
 
 // newCodecFactory is a helper for testing that allows a different metafactory to be specified.
@@ -190,8 +312,21 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 	alreadyAccepted := make(map[string]struct{})
 
 	var legacySerializer runtime.Serializer
+	encoderMediaTypes := make(map[runtime.Identifier]string)
 	for _, d := range serializers {
 		decoders = append(decoders, d.Serializer)
+		for _, s := range []runtime.Serializer{d.Serializer, d.PrettySerializer, d.StrictSerializer} {
+			if s != nil {
+				if _, ok := encoderMediaTypes[s.Identifier()]; !ok {
+					encoderMediaTypes[s.Identifier()] = d.ContentType
+				}
+			}
+		}
+		if d.StreamSerializer != nil {
+			if _, ok := encoderMediaTypes[d.StreamSerializer.Identifier()]; !ok {
+				encoderMediaTypes[d.StreamSerializer.Identifier()] = d.ContentType
+			}
+		}
 		for _, mediaType := range d.AcceptContentTypes {
 			if _, ok := alreadyAccepted[mediaType]; ok {
 				continue
@@ -215,6 +350,7 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 
 			if d.StreamSerializer != nil {
 				info.StreamSerializer = &runtime.StreamSerializerInfo{
+					MediaType:     d.StreamContentType,
 					Serializer:    d.StreamSerializer,
 					EncodesAsText: d.EncodesAsText,
 					Framer:        d.Framer,
@@ -237,6 +373,10 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 		accepts: accepts,
 
 		legacySerializer: legacySerializer,
+
+		encoderMediaTypes: encoderMediaTypes,
+
+		serializers: serializers,
 	}
 }
 
@@ -261,7 +401,7 @@ func (f CodecFactory) SupportedMediaTypes() []runtime.SerializerInfo {
 // TODO: make this call exist only in pkg/api, and initialize it with the set of default versions.
 // All other callers will be forced to request a Codec directly.
 func (f CodecFactory) LegacyCodec(version ...schema.GroupVersion) runtime.Codec {
-	return versioning.NewDefaultingCodecForScheme(f.scheme, f.legacySerializer, f.universal, schema.GroupVersions(version), runtime.InternalGroupVersioner)
+	return versioning.NewDefaultingCodecForScheme(f.scheme, f.restrictEncoder(f.legacySerializer), f.universal, schema.GroupVersions(version), runtime.InternalGroupVersioner)
 }
 
 // UniversalDeserializer can convert any stored data recognized by this factory into a Go object that satisfies
@@ -300,7 +440,7 @@ func (f CodecFactory) CodecForVersions(encoder runtime.Encoder, decoder runtime.
 	if decode == nil {
 		decode = runtime.InternalGroupVersioner
 	}
-	return versioning.NewDefaultingCodecForScheme(f.scheme, encoder, decoder, encode, decode)
+	return versioning.NewDefaultingCodecForScheme(f.scheme, f.restrictEncoder(encoder), decoder, encode, decode)
 }
 
 // DecoderToVersion returns a decoder that targets the provided group version.
@@ -325,7 +465,7 @@ type WithoutConversionCodecFactory struct {
 func (f WithoutConversionCodecFactory) EncoderForVersion(serializer runtime.Encoder, version runtime.GroupVersioner) runtime.Encoder {
 	return runtime.WithVersionEncoder{
 		Version:     version,
-		Encoder:     serializer,
+		Encoder:     f.CodecFactory.restrictEncoder(serializer),
 		ObjectTyper: f.CodecFactory.scheme,
 	}
 }
@@ -20,15 +20,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -368,3 +371,204 @@ func TestDirectCodec(t *testing.T) {
 		t.Fatalf("expect %v, got %v", e, a)
 	}
 }
+
+func TestMediaTypeRestriction(t *testing.T) {
+	s, _ := GetTestScheme()
+	restricted := schema.GroupVersionKind{Version: "v1", Kind: "TestType1"}
+	cf := NewCodecFactory(s, WithMediaTypeRestriction(restricted, runtime.ContentTypeJSON))
+
+	var mediaTypes []string
+	for _, info := range cf.SupportedMediaTypesForKind(restricted) {
+		mediaTypes = append(mediaTypes, info.MediaType)
+	}
+	if !reflect.DeepEqual(mediaTypes, []string{runtime.ContentTypeJSON}) {
+		t.Errorf("expected only JSON to be supported, got %v", mediaTypes)
+	}
+	if len(cf.SupportedMediaTypesForKind(restricted.GroupVersion().WithKind("TestType2"))) != len(cf.SupportedMediaTypes()) {
+		t.Errorf("expected all media types to be supported for unrestricted kinds")
+	}
+	if err := cf.CheckMediaTypeForKind(restricted, "application/json;stream=watch"); err != nil {
+		t.Errorf("expected media type parameters to be ignored, got %v", err)
+	}
+
+	jsonInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	yamlInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeYAML)
+	v1 := schema.GroupVersion{Version: "v1"}
+
+	if _, err := runtime.Encode(cf.EncoderForVersion(jsonInfo.Serializer, v1), &runtimetesting.TestType1{}); err != nil {
+		t.Errorf("unexpected error encoding to JSON: %v", err)
+	}
+	if _, err := runtime.Encode(cf.EncoderForVersion(yamlInfo.Serializer, v1), &runtimetesting.TestType2{}); err != nil {
+		t.Errorf("unexpected error encoding an unrestricted kind to YAML: %v", err)
+	}
+	for name, tc := range map[string]struct {
+		encoder runtime.Encoder
+		obj     runtime.Object
+	}{
+		"conversion":    {cf.EncoderForVersion(yamlInfo.Serializer, v1), &runtimetesting.TestType1{}},
+		"no conversion": {cf.WithoutConversion().EncoderForVersion(yamlInfo.Serializer, v1), &runtimetesting.ExternalTestType1{}},
+		"list item": {cf.WithoutConversion().EncoderForVersion(yamlInfo.Serializer, v1), &metav1.List{
+			Items: []runtime.RawExtension{{Object: &runtimetesting.ExternalTestType2{}}, {Object: &runtimetesting.ExternalTestType1{}}},
+		}},
+	} {
+		_, err := runtime.Encode(tc.encoder, tc.obj)
+		if !IsMediaTypeNotSupportedForKind(err) {
+			t.Errorf("%s: expected a media type error, got %v", name, err)
+			continue
+		}
+		status, ok := err.(interface{ Status() metav1.Status })
+		if !ok || status.Status().Code != http.StatusNotAcceptable {
+			t.Errorf("%s: expected a 406 status, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	s, _ := GetTestScheme()
+	cf := NewCodecFactory(s, WithSerializer(cbor.NewSerializerInfo), WithDecodeLimits(DecodeLimits{MaxObjectBytes: 128, MaxMapEntries: 3}))
+
+	within := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Unknown",
+	}}
+	tooManyEntries := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Unknown",
+		"items":      []interface{}{map[string]interface{}{"a": "1", "b": "2", "c": "3", "d": "4"}},
+	}}
+	tooLarge := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       strings.Repeat("k", 128),
+	}}
+
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeYAML, runtime.ContentTypeCBOR} {
+		t.Run(mediaType, func(t *testing.T) {
+			info, ok := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), mediaType)
+			if !ok {
+				t.Fatalf("no serializer for %s", mediaType)
+			}
+			for name, serializer := range map[string]runtime.Serializer{"default": info.Serializer, "strict": info.StrictSerializer} {
+				encode := func(obj runtime.Object) []byte {
+					data, err := runtime.Encode(info.Serializer, obj)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return data
+				}
+
+				if _, _, err := serializer.Decode(encode(within), nil, &unstructured.Unstructured{}); err != nil {
+					t.Errorf("%s: unexpected error: %v", name, err)
+				}
+				_, _, err := serializer.Decode(encode(tooManyEntries), nil, &unstructured.Unstructured{})
+				if !IsDecodeLimitExceeded(err) || !strings.Contains(err.Error(), ".items[0]") {
+					t.Errorf("%s: expected a decode limit error for .items[0], got %v", name, err)
+				}
+				_, _, err = serializer.Decode(encode(tooLarge), nil, &unstructured.Unstructured{})
+				if !IsDecodeLimitExceeded(err) {
+					t.Errorf("%s: expected a decode limit error, got %v", name, err)
+				}
+				status, ok := err.(interface{ Status() metav1.Status })
+				if !ok || status.Status().Code != http.StatusRequestEntityTooLarge {
+					t.Errorf("%s: expected a 413 status, got %v", name, err)
+				}
+			}
+		})
+	}
+
+	// maps of typed objects are checked too
+	typed := []byte(`{"apiVersion":"v1","kind":"TestType1","M":{"a":1,"b":2,"c":3,"d":4}}`)
+	if _, _, err := cf.UniversalDeserializer().Decode(typed, nil, nil); !IsDecodeLimitExceeded(err) || !strings.Contains(err.Error(), " .M ") {
+		t.Errorf("expected a decode limit error for .M of a typed object, got %v", err)
+	}
+
+	protobufInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+	if _, _, err := protobufInfo.Serializer.Decode(make([]byte, 129), nil, nil); !IsDecodeLimitExceeded(err) {
+		t.Errorf("expected a decode limit error for protobuf, got %v", err)
+	}
+	if _, _, err := protobufInfo.StreamSerializer.Serializer.Decode(make([]byte, 129), nil, nil); !IsDecodeLimitExceeded(err) {
+		t.Errorf("expected a decode limit error for protobuf streams, got %v", err)
+	}
+	if _, _, err := cf.UniversalDeserializer().Decode([]byte(`{"apiVersion":"v1","kind":"`+strings.Repeat("k", 128)+`"}`), nil, nil); !IsDecodeLimitExceeded(err) {
+		t.Errorf("expected a decode limit error from the universal deserializer, got %v", err)
+	}
+}
+
+func TestRegisterSerializer(t *testing.T) {
+	s, _ := GetTestScheme()
+	cf := NewCodecFactory(s, WithDecodeLimits(DecodeLimits{MaxObjectBytes: 1024}))
+	before := cf
+
+	cborInfo := cbor.NewSerializerInfo(s, s)
+	if err := cf.RegisterSerializer(cborInfo); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeCBOR)
+	if !ok || info.StreamSerializer == nil || info.StreamSerializer.MediaType != cborInfo.StreamSerializer.MediaType {
+		t.Fatalf("expected the registered serializer to be supported, got %#v", info)
+	}
+	if _, ok := runtime.SerializerInfoForMediaType(before.SupportedMediaTypes(), runtime.ContentTypeCBOR); ok {
+		t.Error("expected copies made before the registration not to be modified")
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Unknown", "a": "a"}}
+	data, err := runtime.Encode(info.Serializer, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := cf.UniversalDeserializer().Decode(data, nil, &unstructured.Unstructured{})
+	if err != nil {
+		t.Fatalf("expected the universal deserializer to recognize the registered serializer: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, obj) {
+		t.Errorf("unexpected decoded object %#v", decoded)
+	}
+	if _, _, err := info.Serializer.Decode(make([]byte, 1025), nil, nil); !IsDecodeLimitExceeded(err) {
+		t.Errorf("expected the registered serializer to be subject to the decode limits, got %v", err)
+	}
+
+	// streams default to the media type of their objects
+	streamInfo := runtime.SerializerInfo{
+		MediaType:  "application/x-cbor-epoch",
+		Serializer: cbor.NewSerializer(s, s, cbor.EpochTimestamps(true)),
+		StreamSerializer: &runtime.StreamSerializerInfo{
+			Serializer: cbor.NewSerializer(s, s, cbor.EpochTimestamps(true), cbor.EpochMicroTimestamps(true)),
+			Framer:     cborInfo.StreamSerializer.Framer,
+		},
+	}
+	if err := cf.RegisterSerializer(streamInfo); err != nil {
+		t.Fatal(err)
+	}
+	if streamInfo.StreamSerializer.MediaType != "" {
+		t.Errorf("expected the registered info not to be modified")
+	}
+	info, ok = runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), streamInfo.MediaType)
+	if !ok || info.StreamSerializer == nil || info.StreamSerializer.MediaType != streamInfo.MediaType {
+		t.Fatalf("expected the stream media type to default to the media type, got %#v", info.StreamSerializer)
+	}
+
+	jsonInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	for name, conflicting := range map[string]runtime.SerializerInfo{
+		"same media type":    cborInfo,
+		"invalid media type": {MediaType: "application/", Serializer: cborInfo.Serializer},
+		"no serializer":      {MediaType: "application/x-none"},
+		"same identifier":    {MediaType: "application/x-json", Serializer: jsonInfo.Serializer},
+		"invalid stream media type": {
+			MediaType:        "application/x-invalid-stream",
+			Serializer:       cbor.NewSerializer(s, s, cbor.EpochMicroTimestamps(true)),
+			StreamSerializer: &runtime.StreamSerializerInfo{MediaType: "application/", Serializer: cborInfo.StreamSerializer.Serializer},
+		},
+		"same stream media type": {
+			MediaType:        "application/x-other",
+			Serializer:       cbor.NewSerializer(s, s, cbor.EpochTimestamps(true)),
+			StreamSerializer: cborInfo.StreamSerializer,
+		},
+	} {
+		supported := len(cf.SupportedMediaTypes())
+		if err := cf.RegisterSerializer(conflicting); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if len(cf.SupportedMediaTypes()) != supported {
+			t.Errorf("%s: expected the factory not to be modified", name)
+		}
+	}
+}
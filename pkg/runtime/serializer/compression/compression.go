@@ -0,0 +1,310 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compression provides encoders and decoders applying a content-encoding,
+// e.g. gzip, around any runtime.Encoder and runtime.Decoder, so that the same
+// compression can be used for HTTP responses and for storage.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Compressor implements a content-encoding. Other encodings than gzip, e.g.
+// zstd, can be supported by implementing Compressor.
+type Compressor interface {
+	// ContentEncoding returns the name of the encoding as used in the
+	// Content-Encoding and Accept-Encoding HTTP headers, e.g. "gzip".
+	ContentEncoding() string
+	// NewWriter returns a writer compressing to w. The compressed data is
+	// complete once the writer is closed.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader decompressing r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+	// IsCompressed returns true if data starts like data compressed by the
+	// compressor, e.g. with its magic number.
+	IsCompressed(data []byte) bool
+}
+
+// Flusher is implemented by writers returned by Compressor.NewWriter which can
+// write out the data compressed so far before they are closed.
+type Flusher interface {
+	Flush() error
+}
+
+// ContentEncodingGzip is the content-encoding of the gzip compressor.
+const ContentEncodingGzip = "gzip"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type gzipCompressor struct {
+	level int
+}
+
+// NewGzipCompressor returns a gzip Compressor writing with the given
+// compression level, e.g. gzip.DefaultCompression.
+func NewGzipCompressor(level int) (Compressor, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid gzip compression level %d", level)
+	}
+	return gzipCompressor{level: level}, nil
+}
+
+func (gzipCompressor) ContentEncoding() string {
+	return ContentEncodingGzip
+}
+
+func (c gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipCompressor) IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+// Negotiate returns the compressor among compressors preferred by the client
+// according to the value of an Accept-Encoding header, or nil if the client
+// accepts none of them, or accepts them with q=0 only. Among encodings of equal
+// quality, the first one in compressors is returned.
+func Negotiate(acceptEncoding string, compressors ...Compressor) Compressor {
+	var best Compressor
+	bestQuality := 0.0
+	for _, compressor := range compressors {
+		if quality := acceptQuality(acceptEncoding, compressor.ContentEncoding()); quality > bestQuality {
+			best, bestQuality = compressor, quality
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality with which encoding is accepted by an
+// Accept-Encoding header value.
+func acceptQuality(acceptEncoding, encoding string) float64 {
+	wildcard := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		switch {
+		case strings.EqualFold(name, encoding):
+			return q
+		case name == "*":
+			wildcard = q
+		}
+	}
+	return wildcard
+}
+
+type encoder struct {
+	encoder    runtime.Encoder
+	compressor Compressor
+	threshold  int
+	identifier runtime.Identifier
+}
+
+var _ runtime.EncoderWithAllocator = &encoder{}
+
+// NewEncoder returns an encoder compressing the output of e with compressor,
+// unless the encoded object is smaller than threshold bytes, in which case the
+// output of e is written as it is. Decoders returned by NewDecoder handle both.
+//
+// Callers serving HTTP responses must only set the Content-Encoding header if
+// the output is compressed, which can be checked with compressor.IsCompressed,
+// or use a threshold of 0.
+func NewEncoder(e runtime.Encoder, compressor Compressor, threshold int) runtime.Encoder {
+	return &encoder{
+		encoder:    e,
+		compressor: compressor,
+		threshold:  threshold,
+		identifier: identifier(e, compressor, threshold),
+	}
+}
+
+type encoderIdentifier struct {
+	Name            string `json:"name"`
+	Encoder         string `json:"encoder"`
+	ContentEncoding string `json:"contentEncoding"`
+	Threshold       int    `json:"threshold"`
+}
+
+func identifier(e runtime.Encoder, compressor Compressor, threshold int) runtime.Identifier {
+	result := encoderIdentifier{
+		Name:            "compression",
+		Encoder:         string(e.Identifier()),
+		ContentEncoding: compressor.ContentEncoding(),
+		Threshold:       threshold,
+	}
+	identifier, err := json.Marshal(result)
+	if err != nil {
+		panic(fmt.Sprintf("failed marshaling identifier for compression encoder: %v", err))
+	}
+	return runtime.Identifier(identifier)
+}
+
+func (e *encoder) Encode(obj runtime.Object, w io.Writer) error {
+	return e.encode(obj, w, nil)
+}
+
+func (e *encoder) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	return e.encode(obj, w, memAlloc)
+}
+
+func (e *encoder) encode(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	buf := &bytes.Buffer{}
+	var err error
+	if withAllocator, ok := e.encoder.(runtime.EncoderWithAllocator); ok && memAlloc != nil {
+		err = withAllocator.EncodeWithAllocator(obj, buf, memAlloc)
+	} else {
+		err = e.encoder.Encode(obj, buf)
+	}
+	if err != nil {
+		return err
+	}
+	if buf.Len() < e.threshold {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	compressed, err := e.compressor.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if _, err := compressed.Write(buf.Bytes()); err != nil {
+		compressed.Close()
+		return err
+	}
+	return compressed.Close()
+}
+
+func (e *encoder) Identifier() runtime.Identifier {
+	return e.identifier
+}
+
+type decoder struct {
+	decoder     runtime.Decoder
+	compressors []Compressor
+}
+
+// NewDecoder returns a decoder decompressing data compressed by any of the
+// compressors before passing it on to d. Data which is not compressed is passed
+// on as it is.
+func NewDecoder(d runtime.Decoder, compressors ...Compressor) runtime.Decoder {
+	return &decoder{decoder: d, compressors: compressors}
+}
+
+func (d *decoder) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	for _, compressor := range d.compressors {
+		if !compressor.IsCompressed(data) {
+			continue
+		}
+		decompressed, err := decompress(compressor, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress %s data: %w", compressor.ContentEncoding(), err)
+		}
+		data = decompressed
+		break
+	}
+	return d.decoder.Decode(data, defaults, into)
+}
+
+func decompress(compressor Compressor, data []byte) ([]byte, error) {
+	r, err := compressor.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// NewStreamWriter returns a writer compressing to w for streams of objects, e.g.
+// watch events written by a streaming.Encoder. If the compressor supports it,
+// the compressed data is flushed to w after each write, so that each object is
+// received without waiting for the following ones. Closing the returned writer
+// completes the compressed stream, but does not close w.
+func NewStreamWriter(w io.Writer, compressor Compressor) (io.WriteCloser, error) {
+	compressed, err := compressor.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	flusher, _ := compressed.(Flusher)
+	return &streamWriter{WriteCloser: compressed, flusher: flusher}, nil
+}
+
+type streamWriter struct {
+	io.WriteCloser
+	flusher Flusher
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	n, err := s.WriteCloser.Write(p)
+	if err != nil || s.flusher == nil {
+		return n, err
+	}
+	return n, s.flusher.Flush()
+}
+
+// NewStreamReader returns a reader decompressing a stream written by a writer
+// returned by NewStreamWriter, e.g. to be read by a streaming.Decoder. Reading
+// from r starts with the first read, since compressed streams may only start
+// with the first object. Closing the returned reader closes r.
+func NewStreamReader(r io.ReadCloser, compressor Compressor) io.ReadCloser {
+	return &streamReader{source: r, compressor: compressor}
+}
+
+type streamReader struct {
+	source     io.ReadCloser
+	compressor Compressor
+
+	decompressed io.ReadCloser
+	err          error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	if s.decompressed == nil && s.err == nil {
+		s.decompressed, s.err = s.compressor.NewReader(s.source)
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.decompressed.Read(p)
+}
+
+func (s *streamReader) Close() error {
+	if s.decompressed != nil {
+		s.decompressed.Close()
+	}
+	return s.source.Close()
+}
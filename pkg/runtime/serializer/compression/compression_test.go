@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func newSerializer() *json.Serializer {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(schema.GroupVersion{Version: "v1"}, &runtimetesting.ExternalSimple{})
+	return json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+}
+
+func newGzip(t *testing.T) Compressor {
+	compressor, err := NewGzipCompressor(gzip.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compressor
+}
+
+func newObject(s string) *runtimetesting.ExternalSimple {
+	obj := &runtimetesting.ExternalSimple{TestString: s}
+	obj.APIVersion, obj.Kind = "v1", "ExternalSimple"
+	return obj
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	serializer := newSerializer()
+	compressor := newGzip(t)
+	encoder := NewEncoder(serializer, compressor, 100)
+	decoder := NewDecoder(serializer, compressor)
+
+	if encoder.Identifier() == serializer.Identifier() {
+		t.Errorf("expected identifier to differ from the wrapped encoder")
+	}
+	if NewEncoder(serializer, compressor, 200).Identifier() == encoder.Identifier() {
+		t.Errorf("expected identifier to depend on the threshold")
+	}
+
+	for _, tc := range []struct {
+		name       string
+		value      string
+		compressed bool
+	}{
+		{name: "below threshold", value: "small"},
+		{name: "above threshold", value: strings.Repeat("large", 100), compressed: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := encoder.Encode(newObject(tc.value), buf); err != nil {
+				t.Fatal(err)
+			}
+			if compressor.IsCompressed(buf.Bytes()) != tc.compressed {
+				t.Errorf("expected compressed=%v, got %q", tc.compressed, buf.String())
+			}
+			obj, _, err := decoder.Decode(buf.Bytes(), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := obj.(*runtimetesting.ExternalSimple).TestString; s != tc.value {
+				t.Errorf("expected %q, got %q", tc.value, s)
+			}
+		})
+	}
+
+	if _, _, err := decoder.Decode([]byte{0x1f, 0x8b, 0}, nil, nil); err == nil || !strings.Contains(err.Error(), "gzip") {
+		t.Errorf("expected decompression error, got %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	serializer := newSerializer()
+	compressor := newGzip(t)
+
+	buf := &bytes.Buffer{}
+	w, err := NewStreamWriter(buf, compressor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder := streaming.NewEncoder(w, serializer)
+	if err := encoder.Encode(newObject("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first object is readable before the stream is closed
+	reader := NewStreamReader(io.NopCloser(bytes.NewReader(buf.Bytes())), compressor)
+	decoder := streaming.NewDecoder(reader, serializer)
+	obj, _, err := decoder.Decode(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := obj.(*runtimetesting.ExternalSimple).TestString; s != "first" {
+		t.Errorf("expected first object, got %q", s)
+	}
+
+	if err := encoder.Encode(newObject("second")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	decoder = streaming.NewDecoder(NewStreamReader(io.NopCloser(bytes.NewReader(buf.Bytes())), compressor), serializer)
+	for _, expected := range []string{"first", "second"} {
+		obj, _, err := decoder.Decode(nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := obj.(*runtimetesting.ExternalSimple).TestString; s != expected {
+			t.Errorf("expected %q, got %q", expected, s)
+		}
+	}
+	if _, _, err := decoder.Decode(nil, nil); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	gzipCompressor := newGzip(t)
+	for _, tc := range []struct {
+		acceptEncoding string
+		expected       Compressor
+	}{
+		{acceptEncoding: "", expected: nil},
+		{acceptEncoding: "gzip", expected: gzipCompressor},
+		{acceptEncoding: "deflate, GZIP;q=0.5", expected: gzipCompressor},
+		{acceptEncoding: "gzip;q=0", expected: nil},
+		{acceptEncoding: "*", expected: gzipCompressor},
+		{acceptEncoding: "*, gzip;q=0", expected: nil},
+		{acceptEncoding: "br", expected: nil},
+	} {
+		if compressor := Negotiate(tc.acceptEncoding, gzipCompressor); compressor != tc.expected {
+			t.Errorf("%q: expected %v, got %v", tc.acceptEncoding, tc.expected, compressor)
+		}
+	}
+
+	if _, err := NewGzipCompressor(42); err == nil {
+		t.Errorf("expected error for invalid level")
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/recognizer"
+)
+
+// DecodeLimits holds limits enforced by all the decoders of a codec factory,
+// whatever their media type. Zero values mean no limit.
+//
+// MaxObjectBytes is checked before decoding, and so bounds the resources spent
+// decoding an object. The other limits are validated once an object has been
+// decoded: they keep oversized objects from being returned to callers, but do
+// not bound the memory used while decoding them, which only MaxObjectBytes
+// does.
+type DecodeLimits struct {
+	// MaxObjectBytes is the maximum size of the encoded objects, or of the
+	// frames of streams.
+	MaxObjectBytes int
+	// MaxMapEntries is the maximum number of entries of any map in decoded
+	// objects, checked after decoding: the maps of the content of
+	// unstructured objects, and the map fields of typed objects, such as
+	// labels and annotations, whatever the media type they were decoded from.
+	MaxMapEntries int
+}
+
+// WithDecodeLimits configures limits enforced by all the decoders of the codec
+// factory, including the decoders of serializers registered with
+// WithSerializer. Decoding data exceeding them fails with an error for which
+// IsDecodeLimitExceeded returns true.
+func WithDecodeLimits(limits DecodeLimits) CodecFactoryOptionsMutator {
+	return func(options *CodecFactoryOptions) {
+		options.DecodeLimits = limits
+	}
+}
+
+type decodeLimitExceededErr struct {
+	message string
+}
+
+func (e *decodeLimitExceededErr) Error() string {
+	return e.message
+}
+
+func (e *decodeLimitExceededErr) Status() metav1.Status {
+	return metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusRequestEntityTooLarge,
+		Reason:  metav1.StatusReasonRequestEntityTooLarge,
+		Message: e.Error(),
+	}
+}
+
+// IsDecodeLimitExceeded returns true if err indicates that data could not be
+// decoded because it exceeds the decode limits, see WithDecodeLimits.
+func IsDecodeLimitExceeded(err error) bool {
+	var target *decodeLimitExceededErr
+	return errors.As(err, &target)
+}
+
+// limitSerializerType wraps the serializers of t with serializers enforcing
+// limits.
+func limitSerializerType(t serializerType, limits DecodeLimits) serializerType {
+	t.Serializer = limitSerializer(t.Serializer, limits)
+	t.PrettySerializer = limitSerializer(t.PrettySerializer, limits)
+	t.StrictSerializer = limitSerializer(t.StrictSerializer, limits)
+	t.StreamSerializer = limitSerializer(t.StreamSerializer, limits)
+	return t
+}
+
+func limitSerializer(serializer runtime.Serializer, limits DecodeLimits) runtime.Serializer {
+	if serializer == nil {
+		return nil
+	}
+	return &limitedSerializer{serializer: serializer, limits: limits}
+}
+
+// limitedSerializer checks the decode limits around the decoding of its
+// serializer: the size of the data before decoding, and the content of the
+// decoded object after. Encoding is left unchanged, so it shares the
+// identifier of its serializer.
+type limitedSerializer struct {
+	serializer runtime.Serializer
+	limits     DecodeLimits
+}
+
+var _ recognizer.RecognizingDecoder = &limitedSerializer{}
+var _ runtime.EncoderWithAllocator = &limitedSerializer{}
+
+func (s *limitedSerializer) Decode(data []byte, gvk *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	if s.limits.MaxObjectBytes > 0 && len(data) > s.limits.MaxObjectBytes {
+		return nil, nil, &decodeLimitExceededErr{message: fmt.Sprintf("object of %d bytes exceeds the limit of %d bytes", len(data), s.limits.MaxObjectBytes)}
+	}
+	obj, actual, err := s.serializer.Decode(data, gvk, into)
+	if err != nil || obj == nil || s.limits.MaxMapEntries <= 0 {
+		return obj, actual, err
+	}
+	var value reflect.Value
+	if u, ok := obj.(runtime.Unstructured); ok {
+		value = reflect.ValueOf(u.UnstructuredContent())
+	} else {
+		value = reflect.ValueOf(obj)
+	}
+	if path, entries, exceeded := exceedsMapEntries(value, s.limits.MaxMapEntries); exceeded {
+		if len(path) == 0 {
+			path = "."
+		}
+		return nil, actual, &decodeLimitExceededErr{message: fmt.Sprintf("map with %d entries at %s exceeds the limit of %d entries", entries, path, s.limits.MaxMapEntries)}
+	}
+	return obj, actual, nil
+}
+
+func (s *limitedSerializer) Encode(obj runtime.Object, w io.Writer) error {
+	return s.serializer.Encode(obj, w)
+}
+
+func (s *limitedSerializer) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	if encoder, ok := s.serializer.(runtime.EncoderWithAllocator); ok {
+		return encoder.EncodeWithAllocator(obj, w, memAlloc)
+	}
+	return s.serializer.Encode(obj, w)
+}
+
+func (s *limitedSerializer) Identifier() runtime.Identifier {
+	return s.serializer.Identifier()
+}
+
+func (s *limitedSerializer) RecognizesData(data []byte) (ok, unknown bool, err error) {
+	if r, ok := s.serializer.(recognizer.RecognizingDecoder); ok {
+		return r.RecognizesData(data)
+	}
+	return false, true, nil
+}
+
+// exceedsMapEntries returns the path and the number of entries of the first
+// map in value with more than maxEntries entries, if any. Struct fields are
+// named by their JSON names. The path is only built for the exceeding map.
+func exceedsMapEntries(value reflect.Value, maxEntries int) (string, int, bool) {
+	if !value.IsValid() || !mayContainMaps(value.Type()) {
+		return "", 0, false
+	}
+	switch value.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if value.IsNil() {
+			return "", 0, false
+		}
+		return exceedsMapEntries(value.Elem(), maxEntries)
+	case reflect.Map:
+		if value.Len() > maxEntries {
+			return "", value.Len(), true
+		}
+		for iter := value.MapRange(); iter.Next(); {
+			if path, entries, exceeded := exceedsMapEntries(iter.Value(), maxEntries); exceeded {
+				return fmt.Sprintf(".%v%s", iter.Key(), path), entries, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if path, entries, exceeded := exceedsMapEntries(value.Index(i), maxEntries); exceeded {
+				return fmt.Sprintf("[%d]%s", i, path), entries, true
+			}
+		}
+	case reflect.Struct:
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if path, entries, exceeded := exceedsMapEntries(value.Field(i), maxEntries); exceeded {
+				if name, inline := jsonFieldName(field); !inline {
+					path = "." + name + path
+				}
+				return path, entries, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// jsonFieldName returns the JSON name of a struct field, or true if the field
+// is inlined in its parent.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if strings.Contains(","+options+",", ",inline,") || (f.Anonymous && name == "") {
+		return "", true
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+// mapTypes caches, per type, whether values of the type may contain maps.
+var mapTypes sync.Map
+
+// mayContainMaps returns true if values of type t may contain maps, so that
+// values which cannot, like strings or byte slices, are not walked.
+func mayContainMaps(t reflect.Type) bool {
+	if cached, ok := mapTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	may := typeMayContainMaps(t, map[reflect.Type]bool{})
+	mapTypes.Store(t, may)
+	return may
+}
+
+func typeMayContainMaps(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		// recursive types contain maps if any of their other parts do
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Map, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return typeMayContainMaps(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() && typeMayContainMaps(t.Field(i).Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"strconv"
+	"strings"
 
 	kjson "sigs.k8s.io/json"
 	"sigs.k8s.io/yaml"
@@ -36,7 +38,7 @@ import (
 // is not nil, the object has the group, version, and kind fields set.
 // Deprecated: use NewSerializerWithOptions instead.
 func NewSerializer(meta MetaFactory, creater runtime.ObjectCreater, typer runtime.ObjectTyper, pretty bool) *Serializer {
-	return NewSerializerWithOptions(meta, creater, typer, SerializerOptions{false, pretty, false})
+	return NewSerializerWithOptions(meta, creater, typer, SerializerOptions{Yaml: false, Pretty: pretty, Strict: false})
 }
 
 // NewYAMLSerializer creates a YAML serializer that handles encoding versioned objects into the proper YAML form. If typer
@@ -44,7 +46,7 @@ func NewSerializer(meta MetaFactory, creater runtime.ObjectCreater, typer runtim
 // matches JSON, and will error if constructs are used that do not serialize to JSON.
 // Deprecated: use NewSerializerWithOptions instead.
 func NewYAMLSerializer(meta MetaFactory, creater runtime.ObjectCreater, typer runtime.ObjectTyper) *Serializer {
-	return NewSerializerWithOptions(meta, creater, typer, SerializerOptions{true, false, false})
+	return NewSerializerWithOptions(meta, creater, typer, SerializerOptions{Yaml: true, Pretty: false, Strict: false})
 }
 
 // NewSerializerWithOptions creates a JSON/YAML serializer that handles encoding versioned objects into the proper JSON/YAML
@@ -68,6 +70,13 @@ func identifier(options SerializerOptions) runtime.Identifier {
 		"pretty": strconv.FormatBool(options.Pretty),
 		"strict": strconv.FormatBool(options.Strict),
 	}
+	// only include the pretty-print settings when set, to keep existing identifiers stable
+	if options.PrettyIndent > 0 {
+		result["prettyIndent"] = strconv.Itoa(options.PrettyIndent)
+	}
+	if options.SortKeys {
+		result["sortKeys"] = "true"
+	}
 	identifier, err := json.Marshal(result)
 	if err != nil {
 		klog.Fatalf("Failed marshaling identifier for json Serializer: %v", err)
@@ -90,6 +99,17 @@ type SerializerOptions struct {
 	// This option is silently ignored when `Yaml` is `true`.
 	Pretty bool
 
+	// PrettyIndent: the number of spaces per indentation level of human-readable output.
+	// Defaults to 2. This option is ignored unless `Pretty` is `true`.
+	PrettyIndent int
+
+	// SortKeys: configures a JSON enabled Serializer(`Yaml: false`) to write the fields of all
+	// objects sorted by name. The keys of maps, including the content of unstructured objects,
+	// are always sorted, while the fields of typed objects are otherwise written in the order
+	// of the Go struct. This is slower than encoding without it, and meant for output which is
+	// compared or reviewed by humans.
+	SortKeys bool
+
 	// Strict: configures the Serializer to return strictDecodingError's when duplicate fields are present decoding JSON or YAML.
 	// Note that enabling this option is not as performant as the non-strict variant, and should not be used in fast paths.
 	Strict bool
@@ -234,8 +254,26 @@ func (s *Serializer) doEncode(obj runtime.Object, w io.Writer) error {
 		return err
 	}
 
+	if s.options.SortKeys {
+		data, err := sortedJSON(obj)
+		if err != nil {
+			return err
+		}
+		if s.options.Pretty {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, data, "", s.indent()); err != nil {
+				return err
+			}
+			data = buf.Bytes()
+		} else {
+			data = append(data, '\n')
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
 	if s.options.Pretty {
-		data, err := json.MarshalIndent(obj, "", "  ")
+		data, err := json.MarshalIndent(obj, "", s.indent())
 		if err != nil {
 			return err
 		}
@@ -246,6 +284,31 @@ func (s *Serializer) doEncode(obj runtime.Object, w io.Writer) error {
 	return encoder.Encode(obj)
 }
 
+// indent returns the indentation of each level of human-readable output.
+func (s *Serializer) indent() string {
+	if s.options.PrettyIndent > 0 {
+		return strings.Repeat(" ", s.options.PrettyIndent)
+	}
+	return "  "
+}
+
+// sortedJSON returns the JSON encoding of obj with the fields of all objects sorted by
+// name. Numbers are preserved as written.
+func sortedJSON(obj runtime.Object) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	// encoding/json writes the keys of maps sorted
+	return json.Marshal(value)
+}
+
 // IsStrict indicates whether the serializer
 // uses strict decoding or not
 func (s *Serializer) IsStrict() bool {
@@ -1042,3 +1042,52 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodePrettyOptions(t *testing.T) {
+	unstructuredObj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Test", "apiVersion": "v1", "b": int64(1), "a": "x"}}
+	for _, tc := range []struct {
+		name    string
+		options json.SerializerOptions
+		in      runtime.Object
+		want    string
+	}{
+		{
+			name:    "sorted keys",
+			options: json.SerializerOptions{SortKeys: true},
+			in:      &testDecodable{Other: "other", Value: 12345678901234567},
+			want:    `{"Other":"other","interface":null,"spec":{"A":0,"B":0,"C":0,"D":0,"E":0,"F":0,"G":0,"h":0,"i":0,"j":0,"k":0,"l":0,"m":0,"n":0,"o":0},"value":12345678901234567}` + "\n",
+		},
+		{
+			name:    "pretty with indent width",
+			options: json.SerializerOptions{Pretty: true, PrettyIndent: 4},
+			in:      unstructuredObj,
+			want:    "{\n    \"a\": \"x\",\n    \"apiVersion\": \"v1\",\n    \"b\": 1,\n    \"kind\": \"Test\"\n}",
+		},
+		{
+			name:    "pretty with sorted keys",
+			options: json.SerializerOptions{Pretty: true, SortKeys: true},
+			in:      &testDecodable{Interface: map[string]interface{}{"b": 1, "a": 2}},
+			want:    "{\n  \"Other\": \"\",\n  \"interface\": {\n    \"a\": 2,\n    \"b\": 1\n  },\n  \"spec\": {\n    \"A\": 0,\n    \"B\": 0,\n    \"C\": 0,\n    \"D\": 0,\n    \"E\": 0,\n    \"F\": 0,\n    \"G\": 0,\n    \"h\": 0,\n    \"i\": 0,\n    \"j\": 0,\n    \"k\": 0,\n    \"l\": 0,\n    \"m\": 0,\n    \"n\": 0,\n    \"o\": 0\n  },\n  \"value\": 0\n}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var dst bytes.Buffer
+			s := json.NewSerializerWithOptions(json.DefaultMetaFactory, nil, nil, tc.options)
+			if err := s.Encode(tc.in, &dst); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, dst.String()); diff != "" {
+				t.Errorf("unexpected output:\n%s", diff)
+			}
+		})
+	}
+
+	defaultIdentifier := json.NewSerializerWithOptions(json.DefaultMetaFactory, nil, nil, json.SerializerOptions{Pretty: true}).Identifier()
+	if want := `{"name":"json","pretty":"true","strict":"false","yaml":"false"}`; string(defaultIdentifier) != want {
+		t.Errorf("expected identifier %s, got %s", want, defaultIdentifier)
+	}
+	sortedIdentifier := json.NewSerializerWithOptions(json.DefaultMetaFactory, nil, nil, json.SerializerOptions{Pretty: true, SortKeys: true}).Identifier()
+	if sortedIdentifier == defaultIdentifier {
+		t.Errorf("expected the identifier to depend on SortKeys")
+	}
+}
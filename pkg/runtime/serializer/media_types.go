@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WithMediaTypeRestriction declares that objects of the given kind can only be
+// encoded to the given media types, e.g. because the kind cannot be represented
+// in protobuf. Encoders of the codec factory fail to encode such objects to
+// other media types with an error for which IsMediaTypeNotSupportedForKind
+// returns true, and SupportedMediaTypesForKind omits them, so that servers can
+// fail negotiation before encoding.
+func WithMediaTypeRestriction(gvk schema.GroupVersionKind, mediaTypes ...string) CodecFactoryOptionsMutator {
+	return func(options *CodecFactoryOptions) {
+		if options.mediaTypeRestrictions == nil {
+			options.mediaTypeRestrictions = map[schema.GroupVersionKind][]string{}
+		}
+		options.mediaTypeRestrictions[gvk] = append(options.mediaTypeRestrictions[gvk], mediaTypes...)
+	}
+}
+
+type mediaTypeNotSupportedErr struct {
+	gvk       schema.GroupVersionKind
+	mediaType string
+	supported []string
+}
+
+func (e *mediaTypeNotSupportedErr) Error() string {
+	return fmt.Sprintf("%s cannot be encoded as %s, supported media types: %s", e.gvk, e.mediaType, strings.Join(e.supported, ", "))
+}
+
+func (e *mediaTypeNotSupportedErr) Status() metav1.Status {
+	return metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusNotAcceptable,
+		Reason:  metav1.StatusReasonNotAcceptable,
+		Message: e.Error(),
+	}
+}
+
+// IsMediaTypeNotSupportedForKind returns true if err indicates that an object
+// could not be encoded because its kind is restricted to other media types,
+// see WithMediaTypeRestriction.
+func IsMediaTypeNotSupportedForKind(err error) bool {
+	var target *mediaTypeNotSupportedErr
+	return errors.As(err, &target)
+}
+
+// CheckMediaTypeForKind returns an error for which IsMediaTypeNotSupportedForKind
+// returns true if objects of kind gvk cannot be encoded to mediaType. Parameters
+// of mediaType are ignored.
+func (f CodecFactory) CheckMediaTypeForKind(gvk schema.GroupVersionKind, mediaType string) error {
+	supported, restricted := f.mediaTypeRestrictions[gvk]
+	if !restricted {
+		return nil
+	}
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+	for _, s := range supported {
+		if s == mediaType {
+			return nil
+		}
+	}
+	return &mediaTypeNotSupportedErr{gvk: gvk, mediaType: mediaType, supported: supported}
+}
+
+// SupportedMediaTypesForKind returns the subset of SupportedMediaTypes which
+// objects of kind gvk can be encoded to.
+func (f CodecFactory) SupportedMediaTypesForKind(gvk schema.GroupVersionKind) []runtime.SerializerInfo {
+	if _, restricted := f.mediaTypeRestrictions[gvk]; !restricted {
+		return f.accepts
+	}
+	var infos []runtime.SerializerInfo
+	for _, info := range f.accepts {
+		if f.CheckMediaTypeForKind(gvk, info.MediaType) == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// restrictEncoder wraps encoder, if it is one of the serializers of the factory
+// and any kind is restricted, with an encoder checking the kind of the encoded
+// objects.
+func (f CodecFactory) restrictEncoder(encoder runtime.Encoder) runtime.Encoder {
+	if encoder == nil || len(f.mediaTypeRestrictions) == 0 {
+		return encoder
+	}
+	mediaType, ok := f.encoderMediaTypes[encoder.Identifier()]
+	if !ok {
+		return encoder
+	}
+	restrictions := make([]string, 0, len(f.mediaTypeRestrictions))
+	for gvk, mediaTypes := range f.mediaTypeRestrictions {
+		restrictions = append(restrictions, fmt.Sprintf("%s=%s", gvk, strings.Join(mediaTypes, "|")))
+	}
+	sort.Strings(restrictions)
+	return &restrictedEncoder{
+		encoder:    encoder,
+		mediaType:  mediaType,
+		factory:    f,
+		identifier: runtime.Identifier(fmt.Sprintf("restricted(%s,%s)", encoder.Identifier(), strings.Join(restrictions, ";"))),
+	}
+}
+
+// restrictedEncoder fails to encode objects whose kind cannot be encoded to
+// its media type.
+type restrictedEncoder struct {
+	encoder   runtime.Encoder
+	mediaType string
+	factory   CodecFactory
+	// identifier differs from the identifier of encoder, since the outputs of
+	// both differ for restricted kinds.
+	identifier runtime.Identifier
+}
+
+var _ runtime.EncoderWithAllocator = &restrictedEncoder{}
+
+func (e *restrictedEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	if err := e.check(obj); err != nil {
+		return err
+	}
+	return e.encoder.Encode(obj, w)
+}
+
+func (e *restrictedEncoder) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	if err := e.check(obj); err != nil {
+		return err
+	}
+	if encoder, ok := e.encoder.(runtime.EncoderWithAllocator); ok {
+		return encoder.EncodeWithAllocator(obj, w, memAlloc)
+	}
+	return e.encoder.Encode(obj, w)
+}
+
+func (e *restrictedEncoder) Identifier() runtime.Identifier {
+	return e.identifier
+}
+
+// check returns an error if the kind of obj, or of any item of obj if it is a
+// list, cannot be encoded to the media type of the encoder.
+func (e *restrictedEncoder) check(obj runtime.Object) error {
+	if err := e.checkKind(obj); err != nil {
+		return err
+	}
+	if !meta.IsListType(obj) {
+		return nil
+	}
+	return meta.EachListItem(obj, e.checkKind)
+}
+
+func (e *restrictedEncoder) checkKind(obj runtime.Object) error {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return e.factory.CheckMediaTypeForKind(gvk, e.mediaType)
+	}
+	gvks, _, err := e.factory.scheme.ObjectKinds(obj)
+	if err != nil {
+		// leave reporting unregistered types to the encoder
+		return nil
+	}
+	for _, gvk := range gvks {
+		if err := e.factory.CheckMediaTypeForKind(gvk, e.mediaType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
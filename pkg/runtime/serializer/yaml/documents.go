@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Source is the location of an object decoded by a DocumentDecoder.
+type Source struct {
+	// Document is the 0-based index of the document in the stream.
+	Document int
+	// Line is the 1-based line of the stream at which the document starts.
+	Line int
+	// Item is the 0-based index of the object in the items of the list held
+	// by the document, or -1 if the document is not a list.
+	Item int
+}
+
+func (s Source) String() string {
+	if s.Item < 0 {
+		return fmt.Sprintf("document %d (line %d)", s.Document, s.Line)
+	}
+	return fmt.Sprintf("item %d of document %d (line %d)", s.Item, s.Document, s.Line)
+}
+
+// DocumentError is an error decoding an object of a stream.
+type DocumentError struct {
+	Source Source
+	Err    error
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Source, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// DocumentDecoder decodes the objects of a stream of YAML documents or JSON
+// objects, such as manifests. A stream of JSON objects may continue with YAML
+// documents after a document separator. Documents holding lists, whose kind is
+// List or ends with List and which have items, are flattened into their items.
+// Objects are decoded with a decoder, typically the universal deserializer of
+// a scheme, and as unstructured objects if their kind is not registered.
+// Empty documents are skipped.
+type DocumentDecoder struct {
+	decoder runtime.Decoder
+
+	// jsonDecoder reads the JSON objects at the start of JSON streams, and is
+	// nil once the stream continues with YAML documents.
+	jsonDecoder *json.Decoder
+	lines       *yaml.LineTracker
+	// yamlReader reads the YAML documents of the stream, starting at yamlLine.
+	yamlReader *yaml.YAMLReader
+	yamlLine   int
+	// err is the error which ended the stream.
+	err error
+
+	// document is the index of the next document.
+	document int
+	// items are the items of the list being flattened.
+	items  []json.RawMessage
+	source Source
+}
+
+// documentBufferSize is how far into streams DocumentDecoder looks to tell
+// JSON streams from YAML ones.
+const documentBufferSize = 4096
+
+// NewDocumentDecoder returns a DocumentDecoder reading r. decoder may be nil,
+// in which case all the objects are decoded as unstructured objects.
+func NewDocumentDecoder(r io.Reader, decoder runtime.Decoder) *DocumentDecoder {
+	d := &DocumentDecoder{decoder: decoder}
+	buffered, _, isJSON := yaml.GuessJSONStream(r, documentBufferSize)
+	if isJSON {
+		d.lines = yaml.NewLineTracker(buffered)
+		d.jsonDecoder = json.NewDecoder(d.lines)
+	} else {
+		d.yamlReader = yaml.NewYAMLReader(bufio.NewReader(buffered))
+		d.yamlLine = 1
+	}
+	return d
+}
+
+// Next returns the next object of the stream and its source, or io.EOF at the
+// end of the stream. Errors decoding documents are *DocumentError, and
+// decoding may continue with the next document. Errors reading the stream are
+// *DocumentError locating the document being read, and end the stream: Next
+// returns io.EOF after them.
+func (d *DocumentDecoder) Next() (runtime.Object, Source, error) {
+	for {
+		if len(d.items) > 0 {
+			item := d.items[0]
+			d.items = d.items[1:]
+			source := d.source
+			d.source.Item++
+			obj, err := d.decode(item, source)
+			return obj, source, err
+		}
+
+		if d.err != nil {
+			return nil, Source{}, io.EOF
+		}
+		data, line, err := d.read()
+		source := Source{Document: d.document, Line: line, Item: -1}
+		if err != nil {
+			d.err = err
+			if err == io.EOF {
+				return nil, Source{}, err
+			}
+			return nil, source, &DocumentError{Source: source, Err: err}
+		}
+		d.document++
+
+		data, err = yaml.ToJSON(data)
+		if err != nil {
+			return nil, source, &DocumentError{Source: source, Err: err}
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+			continue
+		}
+
+		var list struct {
+			Kind  string            `json:"kind"`
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &list); err == nil && list.Items != nil && strings.HasSuffix(list.Kind, "List") {
+			d.items = list.Items
+			d.source = source
+			d.source.Item = 0
+			continue
+		}
+		obj, err := d.decode(data, source)
+		return obj, source, err
+	}
+}
+
+// decode decodes the JSON data of an object.
+func (d *DocumentDecoder) decode(data []byte, source Source) (runtime.Object, error) {
+	if d.decoder != nil {
+		obj, _, err := d.decoder.Decode(data, nil, nil)
+		if err == nil {
+			return obj, nil
+		}
+		if !runtime.IsNotRegisteredError(err) {
+			return nil, &DocumentError{Source: source, Err: err}
+		}
+	}
+	obj, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, &DocumentError{Source: source, Err: err}
+	}
+	return obj, nil
+}
+
+// read returns the next document of the stream and the line at which it
+// starts, or the line at which reading it failed.
+func (d *DocumentDecoder) read() ([]byte, int, error) {
+	if d.jsonDecoder != nil {
+		var raw json.RawMessage
+		err := d.jsonDecoder.Decode(&raw)
+		if err == nil {
+			end := d.jsonDecoder.InputOffset()
+			line, _ := d.lines.Position(end - int64(len(raw)))
+			d.lines.Forget(end)
+			return raw, line, nil
+		}
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			// locate the start of the document being read
+			unread, _ := io.ReadAll(d.jsonDecoder.Buffered())
+			whitespace := len(unread) - len(bytes.TrimLeftFunc(unread, unicode.IsSpace))
+			line, _ := d.lines.Position(d.jsonDecoder.InputOffset() + int64(whitespace))
+			return nil, line, err
+		}
+		// syntax errors are sticky, so the stream ends unless it continues
+		// with YAML documents
+		if !d.continueWithYAML() {
+			// the offset counts the bytes read up to and including the invalid one
+			line, _ := d.lines.Position(syntaxErr.Offset - 1)
+			return nil, line, err
+		}
+	}
+	data, err := d.yamlReader.Read()
+	return data, d.yamlLine + d.yamlReader.DocumentLine() - 1, err
+}
+
+// continueWithYAML switches a JSON stream to reading YAML documents if the
+// data after the last JSON object starts with a document separator.
+func (d *DocumentDecoder) continueWithYAML() bool {
+	line, _ := d.lines.Position(d.jsonDecoder.InputOffset())
+	rest := bufio.NewReader(io.MultiReader(d.jsonDecoder.Buffered(), d.lines))
+	for {
+		next, err := rest.Peek(1)
+		if err != nil || !unicode.IsSpace(rune(next[0])) {
+			break
+		}
+		if next[0] == '\n' {
+			line++
+		}
+		rest.Discard(1) //nolint:errcheck
+	}
+	if separator, _ := rest.Peek(3); string(separator) != "---" {
+		return false
+	}
+	// like between YAML documents, only a comment may follow the separator,
+	// and the next document starts on the next line
+	separator, err := rest.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	if trailing := strings.TrimSpace(separator[3:]); len(trailing) > 0 && trailing[0] != '#' {
+		return false
+	}
+	d.jsonDecoder = nil
+	d.yamlReader = yaml.NewYAMLReader(rest)
+	d.yamlLine = line + 1
+	return true
+}
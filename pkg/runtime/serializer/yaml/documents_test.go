@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+func documentTestDecoder() runtime.Decoder {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(schema.GroupVersion{Version: "v1"}, &metav1.Status{})
+	return json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+}
+
+type decodedDocument struct {
+	kind   string
+	name   string
+	typed  bool
+	source Source
+}
+
+func decodeDocuments(t *testing.T, d *DocumentDecoder) ([]decodedDocument, []error) {
+	var documents []decodedDocument
+	var errs []error
+	for {
+		obj, source, err := d.Next()
+		if err == io.EOF {
+			return documents, errs
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		document := decodedDocument{kind: obj.GetObjectKind().GroupVersionKind().Kind, source: source}
+		switch typed := obj.(type) {
+		case *metav1.Status:
+			document.typed, document.name = true, typed.Message
+		case *unstructured.Unstructured:
+			document.name = typed.GetName()
+		default:
+			t.Fatalf("unexpected object %T", obj)
+		}
+		documents = append(documents, document)
+	}
+}
+
+func TestDocumentDecoderYAML(t *testing.T) {
+	stream := `apiVersion: v1
+kind: Status
+message: first
+---
+# empty document
+---
+{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "second"}}
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: third
+- apiVersion: v1
+  kind: Status
+  message: fourth
+---
+kind: Widget
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: fifth
+`
+	documents, errs := decodeDocuments(t, NewDocumentDecoder(strings.NewReader(stream), documentTestDecoder()))
+	expected := []decodedDocument{
+		{kind: "Status", name: "first", typed: true, source: Source{Document: 0, Line: 1, Item: -1}},
+		{kind: "Widget", name: "second", source: Source{Document: 2, Line: 7, Item: -1}},
+		{kind: "Widget", name: "third", source: Source{Document: 3, Line: 9, Item: 0}},
+		{kind: "Status", name: "fourth", typed: true, source: Source{Document: 3, Line: 9, Item: 1}},
+		{kind: "Widget", name: "fifth", source: Source{Document: 5, Line: 22, Item: -1}},
+	}
+	if !reflect.DeepEqual(documents, expected) {
+		t.Errorf("expected %+v, got %+v", expected, documents)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected a single error, got %v", errs)
+	}
+	var documentErr *DocumentError
+	if !errors.As(errs[0], &documentErr) || documentErr.Source != (Source{Document: 4, Line: 20, Item: -1}) {
+		t.Errorf("expected an error locating the document without apiVersion, got %v", errs[0])
+	}
+}
+
+func TestDocumentDecoderJSON(t *testing.T) {
+	stream := `{"apiVersion": "v1", "kind": "Status", "message": "first"}
+{
+  "apiVersion": "v1",
+  "kind": "StatusList",
+  "items": [{"apiVersion": "v1", "kind": "Status", "message": "second"}]
+}
+
+{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "third"}}
+`
+	documents, errs := decodeDocuments(t, NewDocumentDecoder(strings.NewReader(stream), nil))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	expected := []decodedDocument{
+		{kind: "Status", name: "", source: Source{Document: 0, Line: 1, Item: -1}},
+		{kind: "Status", name: "", source: Source{Document: 1, Line: 2, Item: 0}},
+		{kind: "Widget", name: "third", source: Source{Document: 2, Line: 8, Item: -1}},
+	}
+	if !reflect.DeepEqual(documents, expected) {
+		t.Errorf("expected %+v, got %+v", expected, documents)
+	}
+}
+
+func TestDocumentDecoderJSONThenYAML(t *testing.T) {
+	stream := `{"apiVersion": "v1", "kind": "Status", "message": "first"}
+{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "second"}}
+
+--- # the rest is YAML
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: third
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: fourth
+`
+	documents, errs := decodeDocuments(t, NewDocumentDecoder(strings.NewReader(stream), nil))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	expected := []decodedDocument{
+		{kind: "Status", name: "", source: Source{Document: 0, Line: 1, Item: -1}},
+		{kind: "Widget", name: "second", source: Source{Document: 1, Line: 2, Item: -1}},
+		{kind: "Widget", name: "third", source: Source{Document: 2, Line: 5, Item: -1}},
+		{kind: "Widget", name: "fourth", source: Source{Document: 3, Line: 10, Item: -1}},
+	}
+	if !reflect.DeepEqual(documents, expected) {
+		t.Errorf("expected %+v, got %+v", expected, documents)
+	}
+}
+
+func TestDocumentDecoderReadErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stream   string
+		expected Source
+	}{
+		{
+			name: "json syntax error",
+			stream: `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "first"}}
+{
+  "apiVersion": "example.com/v1"
+  "kind": "Widget"
+}
+{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "second"}}
+`,
+			expected: Source{Document: 1, Line: 4, Item: -1},
+		},
+		{
+			name: "json followed by an invalid separator",
+			stream: `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "first"}}
+--- kind: Widget
+`,
+			expected: Source{Document: 1, Line: 2, Item: -1},
+		},
+		{
+			name: "truncated json",
+			stream: `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "first"}}
+{"apiVersion": "example.com/v1",
+`,
+			expected: Source{Document: 1, Line: 2, Item: -1},
+		},
+		{
+			name: "invalid yaml separator",
+			stream: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: first
+---
+kind: Widget
+--- kind: Widget
+`,
+			expected: Source{Document: 1, Line: 6, Item: -1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDocumentDecoder(strings.NewReader(tc.stream), nil)
+			if _, _, err := d.Next(); err != nil {
+				t.Fatalf("unexpected error decoding the first document: %v", err)
+			}
+			_, source, err := d.Next()
+			var documentErr *DocumentError
+			if !errors.As(err, &documentErr) {
+				t.Fatalf("expected a DocumentError, got %v", err)
+			}
+			if source != tc.expected || documentErr.Source != tc.expected {
+				t.Errorf("expected the error at %v, got %v (%v)", tc.expected, source, err)
+			}
+			// read errors end the stream
+			if _, _, err := d.Next(); err != io.EOF {
+				t.Errorf("expected io.EOF after the error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// labelSelectorParam and fieldSelectorParam are the parameters of the
+	// string form of Combined, named like the parameters of list requests.
+	labelSelectorParam = "labelSelector"
+	fieldSelectorParam = "fieldSelector"
+)
+
+// Combined selects objects matching both a label selector and a field
+// selector. A nil selector selects everything.
+type Combined struct {
+	Labels labels.Selector
+	Fields fields.Selector
+}
+
+// Everything returns a selector that matches all objects.
+func Everything() Combined {
+	return Combined{Labels: labels.Everything(), Fields: fields.Everything()}
+}
+
+// Matches returns true if objLabels match the label selector and objFields
+// match the field selector.
+func (c Combined) Matches(objLabels labels.Labels, objFields fields.Fields) bool {
+	if c.Labels != nil && !c.Labels.Matches(objLabels) {
+		return false
+	}
+	if c.Fields != nil && !c.Fields.Matches(objFields) {
+		return false
+	}
+	return true
+}
+
+// Empty returns true if the selector matches all objects.
+func (c Combined) Empty() bool {
+	return (c.Labels == nil || c.Labels.Empty()) && (c.Fields == nil || c.Fields.Empty())
+}
+
+// DeepCopy returns a deep copy of the selector.
+func (c Combined) DeepCopy() Combined {
+	out := Combined{}
+	if c.Labels != nil {
+		out.Labels = c.Labels.DeepCopySelector()
+	}
+	if c.Fields != nil {
+		out.Fields = c.Fields.DeepCopySelector()
+	}
+	return out
+}
+
+// String returns the canonical form of the selector, a query string holding
+// the labelSelector and fieldSelector parameters, as in list requests. Empty
+// selectors are omitted, so that selectors matching all objects are "". The
+// requirements of field selectors are sorted, so that equivalent selectors
+// have the same form and it can be used as a cache key.
+func (c Combined) String() string {
+	values := url.Values{}
+	if c.Labels != nil && !c.Labels.Empty() {
+		values.Set(labelSelectorParam, c.Labels.String())
+	}
+	if c.Fields != nil && !c.Fields.Empty() {
+		values.Set(fieldSelectorParam, canonicalFields(c.Fields))
+	}
+	return values.Encode()
+}
+
+// canonicalFields returns the string form of selector with its requirements
+// sorted, if its requirements represent it, or its string form otherwise,
+// e.g. for alternatives.
+func canonicalFields(selector fields.Selector) string {
+	reqs := selector.Requirements()
+	if len(reqs) == 0 {
+		return selector.String()
+	}
+	terms := make([]fields.Selector, 0, len(reqs))
+	for _, req := range reqs {
+		switch req.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			terms = append(terms, fields.OneTermEqualSelector(req.Field, req.Value))
+		case selection.NotEquals:
+			terms = append(terms, fields.OneTermNotEqualSelector(req.Field, req.Value))
+		default:
+			return selector.String()
+		}
+	}
+	if fields.AndSelectors(terms...).String() != selector.String() {
+		return selector.String()
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].String() < terms[j].String()
+	})
+	return fields.AndSelectors(terms...).String()
+}
+
+// Parse parses the string form of a selector, see Combined.String. Missing
+// parameters select everything.
+func Parse(s string) (Combined, error) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return Combined{}, fmt.Errorf("invalid selector %q: %v", s, err)
+	}
+	c := Everything()
+	for param, value := range values {
+		if len(value) != 1 {
+			return Combined{}, fmt.Errorf("invalid selector %q: %s is set %d times", s, param, len(value))
+		}
+		switch param {
+		case labelSelectorParam:
+			if c.Labels, err = labels.Parse(value[0]); err != nil {
+				return Combined{}, err
+			}
+		case fieldSelectorParam:
+			if c.Fields, err = fields.ParseSelector(value[0]); err != nil {
+				return Combined{}, err
+			}
+		default:
+			return Combined{}, fmt.Errorf("invalid selector %q: unknown parameter %s", s, param)
+		}
+	}
+	return c, nil
+}
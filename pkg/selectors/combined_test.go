@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCombinedMatches(t *testing.T) {
+	objLabels := labels.Set{"app": "web", "tier": "frontend"}
+	objFields := fields.Set{"metadata.name": "web-1", "status.phase": "Running"}
+
+	testCases := []struct {
+		selector string
+		matches  bool
+	}{
+		{selector: "", matches: true},
+		{selector: "labelSelector=app%3Dweb", matches: true},
+		{selector: "fieldSelector=status.phase%3DRunning", matches: true},
+		{selector: "labelSelector=app%3Dweb&fieldSelector=status.phase%3DRunning", matches: true},
+		{selector: "labelSelector=app%3Dweb&fieldSelector=status.phase%3DPending", matches: false},
+		{selector: "labelSelector=app%3Ddb&fieldSelector=status.phase%3DRunning", matches: false},
+		{selector: "labelSelector=tier+in+%28frontend%2Cbackend%29", matches: true},
+	}
+	for _, tc := range testCases {
+		c, err := Parse(tc.selector)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.selector, err)
+			continue
+		}
+		if matches := c.Matches(objLabels, objFields); matches != tc.matches {
+			t.Errorf("%q: expected matches %t, got %t", tc.selector, tc.matches, matches)
+		}
+	}
+
+	if !(Combined{}).Matches(objLabels, objFields) || !(Combined{}).Empty() {
+		t.Error("expected the zero selector to match everything")
+	}
+}
+
+func TestCombinedString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector Combined
+		expected string
+	}{
+		{name: "zero", selector: Combined{}, expected: ""},
+		{name: "everything", selector: Everything(), expected: ""},
+		{
+			name:     "labels",
+			selector: Combined{Labels: labels.SelectorFromSet(labels.Set{"b": "2", "a": "1"})},
+			expected: "labelSelector=a%3D1%2Cb%3D2",
+		},
+		{
+			name: "sorted fields",
+			selector: Combined{
+				Labels: labels.SelectorFromSet(labels.Set{"a": "1"}),
+				Fields: fields.ParseSelectorOrDie("status.phase!=Failed,metadata.name=x"),
+			},
+			expected: "fieldSelector=metadata.name%3Dx%2Cstatus.phase%21%3DFailed&labelSelector=a%3D1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.selector.String()
+			if s != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, s)
+			}
+			parsed, err := Parse(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.String() != s {
+				t.Errorf("expected %q after parsing, got %q", s, parsed.String())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"labelSelector=a%3D1&labelSelector=b%3D2",
+		"namespace=default",
+		"labelSelector=a+in+%28",
+		"fieldSelector=a",
+		"%zz",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
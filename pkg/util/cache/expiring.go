@@ -18,12 +18,34 @@ package cache
 
 import (
 	"container/heap"
+	"context"
 	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/clock"
 )
 
+// Expiring is a TypedExpiring with untyped keys and values.
+type Expiring = TypedExpiring[interface{}, interface{}]
+
+// ExpiringOptions configures an expiring cache.
+type ExpiringOptions struct {
+	// Clock is used to obtain the current time. Defaults to the real clock.
+	Clock clock.Clock
+	// DefaultTTL is the TTL of entries added with SetDefault.
+	DefaultTTL time.Duration
+	// JitterFactor, if positive, randomly lengthens every TTL by up to
+	// JitterFactor*TTL, so that entries set together do not all expire at once.
+	JitterFactor float64
+	// GCInterval is the minimum time between the garbage collections run during
+	// Set. Zero collects expired entries on every Set.
+	GCInterval time.Duration
+	// DisableInlineGC disables garbage collection during Set, so that expired
+	// entries are only removed by calls to GC, e.g. from Run.
+	DisableInlineGC bool
+}
+
 // NewExpiring returns an initialized expiring cache.
 func NewExpiring() *Expiring {
 	return NewExpiringWithClock(clock.RealClock{})
@@ -32,14 +54,44 @@ func NewExpiring() *Expiring {
 // NewExpiringWithClock is like NewExpiring but allows passing in a custom
 // clock for testing.
 func NewExpiringWithClock(clock clock.Clock) *Expiring {
-	return &Expiring{
-		clock: clock,
-		cache: make(map[interface{}]entry),
+	return NewTypedExpiringWithClock[interface{}, interface{}](clock)
+}
+
+// NewExpiringWithOptions returns an initialized expiring cache configured by opts.
+func NewExpiringWithOptions(opts ExpiringOptions) *Expiring {
+	return NewTypedExpiringWithOptions[interface{}, interface{}](opts)
+}
+
+// NewTypedExpiring returns an initialized expiring cache.
+func NewTypedExpiring[K comparable, V any]() *TypedExpiring[K, V] {
+	return NewTypedExpiringWithClock[K, V](clock.RealClock{})
+}
+
+// NewTypedExpiringWithClock is like NewTypedExpiring but allows passing in a
+// custom clock for testing.
+func NewTypedExpiringWithClock[K comparable, V any](clock clock.Clock) *TypedExpiring[K, V] {
+	return NewTypedExpiringWithOptions[K, V](ExpiringOptions{Clock: clock})
+}
+
+// NewTypedExpiringWithOptions returns an initialized expiring cache configured
+// by opts.
+func NewTypedExpiringWithOptions[K comparable, V any](opts ExpiringOptions) *TypedExpiring[K, V] {
+	c := opts.Clock
+	if c == nil {
+		c = clock.RealClock{}
+	}
+	return &TypedExpiring[K, V]{
+		clock:        c,
+		defaultTTL:   opts.DefaultTTL,
+		jitterFactor: opts.JitterFactor,
+		gcInterval:   opts.GCInterval,
+		inlineGC:     !opts.DisableInlineGC,
+		cache:        make(map[K]entry[K, V]),
 	}
 }
 
-// Expiring is a map whose entries expire after a per-entry timeout.
-type Expiring struct {
+// TypedExpiring is a map whose entries expire after a per-entry timeout.
+type TypedExpiring[K comparable, V any] struct {
 	// AllowExpiredGet causes the expiration check to be skipped on Get.
 	// It should only be used when a key always corresponds to the exact same value.
 	// Thus when this field is true, expired keys are considered valid
@@ -47,12 +99,16 @@ type Expiring struct {
 	// It may not be changed concurrently with calls to Get.
 	AllowExpiredGet bool
 
-	clock clock.Clock
+	clock        clock.Clock
+	defaultTTL   time.Duration
+	jitterFactor float64
+	gcInterval   time.Duration
+	inlineGC     bool
 
 	// mu protects the below fields
 	mu sync.RWMutex
 	// cache is the internal map that backs the cache.
-	cache map[interface{}]entry
+	cache map[K]entry[K, V]
 	// generation is used as a cheap resource version for cache entries. Cleanups
 	// are scheduled with a key and generation. When the cleanup runs, it first
 	// compares its generation with the current generation of the entry. It
@@ -63,26 +119,52 @@ type Expiring struct {
 	// The integer value of the generation of an entry is meaningless.
 	generation uint64
 
-	heap expiringHeap
+	// heap holds a single entry per key of cache, which is updated in place
+	// when the key is set or renewed.
+	heap expiringHeap[K]
+	// lastGC is the time of the last garbage collection.
+	lastGC time.Time
 }
 
-type entry struct {
-	val        interface{}
+type entry[K comparable, V any] struct {
+	val        V
 	expiry     time.Time
+	ttl        time.Duration
 	generation uint64
+	// cleanup is the entry of the key in the heap.
+	cleanup *expiringHeapEntry[K]
 }
 
 // Get looks up an entry in the cache.
-func (c *Expiring) Get(key interface{}) (val interface{}, ok bool) {
+func (c *TypedExpiring[K, V]) Get(key K) (val V, ok bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	e, ok := c.cache[key]
 	if !ok {
-		return nil, false
+		return val, false
 	}
 	if !c.AllowExpiredGet && !c.clock.Now().Before(e.expiry) {
-		return nil, false
+		return val, false
+	}
+	return e.val, true
+}
+
+// GetAndRenew looks up an entry in the cache and, if it has not expired yet,
+// extends its expiry by the TTL it was last set with.
+func (c *TypedExpiring[K, V]) GetAndRenew(key K) (val V, ok bool) {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.cache[key]
+	if !ok {
+		return val, false
 	}
+	if !c.AllowExpiredGet && !now.Before(e.expiry) {
+		return val, false
+	}
+	c.set(now, key, e.val, e.ttl)
 	return e.val, true
 }
 
@@ -92,33 +174,59 @@ func (c *Expiring) Get(key interface{}) (val interface{}, ok bool) {
 // collection of expired entries occurs during calls to Set(), however calls to
 // Get() will not return expired entries that have not yet been garbage
 // collected.
-func (c *Expiring) Set(key interface{}, val interface{}, ttl time.Duration) {
+func (c *TypedExpiring[K, V]) Set(key K, val V, ttl time.Duration) {
 	now := c.clock.Now()
-	expiry := now.Add(ttl)
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.generation++
+	c.set(now, key, val, ttl)
+}
 
-	c.cache[key] = entry{
-		val:        val,
-		expiry:     expiry,
-		generation: c.generation,
+// SetDefault is like Set, using the default TTL of the cache.
+func (c *TypedExpiring[K, V]) SetDefault(key K, val V) {
+	c.Set(key, val, c.defaultTTL)
+}
+
+// set must be called under the write lock.
+func (c *TypedExpiring[K, V]) set(now time.Time, key K, val V, ttl time.Duration) {
+	expiry := now.Add(ttl)
+	if c.jitterFactor > 0 {
+		expiry = now.Add(wait.Jitter(ttl, c.jitterFactor))
 	}
 
-	// Run GC inline before pushing the new entry.
-	c.gc(now)
+	// Run GC inline before adding the new entry.
+	if c.inlineGC && !now.Before(c.lastGC.Add(c.gcInterval)) {
+		c.gc(now)
+	}
+
+	c.generation++
 
-	heap.Push(&c.heap, &expiringHeapEntry{
-		key:        key,
+	e := entry[K, V]{
+		val:        val,
 		expiry:     expiry,
+		ttl:        ttl,
 		generation: c.generation,
-	})
+	}
+	if existing, ok := c.cache[key]; ok && existing.cleanup.index >= 0 {
+		// Reschedule the cleanup of the key rather than adding another one.
+		e.cleanup = existing.cleanup
+		e.cleanup.expiry = expiry
+		e.cleanup.generation = c.generation
+		heap.Fix(&c.heap, e.cleanup.index)
+	} else {
+		e.cleanup = &expiringHeapEntry[K]{
+			key:        key,
+			expiry:     expiry,
+			generation: c.generation,
+		}
+		heap.Push(&c.heap, e.cleanup)
+	}
+	c.cache[key] = e
 }
 
 // Delete deletes an entry in the map.
-func (c *Expiring) Delete(key interface{}) {
+func (c *TypedExpiring[K, V]) Delete(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.del(key, 0)
@@ -129,27 +237,80 @@ func (c *Expiring) Delete(key interface{}) {
 // changed (e.g. if a set has occurred on an existing element but the old
 // cleanup still runs), this is a noop. If the generation argument is 0, the
 // entry's generation is ignored and the entry is deleted.
+// It returns true if an entry was deleted.
 //
 // del must be called under the write lock.
-func (c *Expiring) del(key interface{}, generation uint64) {
+func (c *TypedExpiring[K, V]) del(key K, generation uint64) bool {
 	e, ok := c.cache[key]
 	if !ok {
-		return
+		return false
 	}
 	if generation != 0 && generation != e.generation {
-		return
+		return false
 	}
 	delete(c.cache, key)
+	if e.cleanup.index >= 0 {
+		// Without inline GC, the cleanups of deleted keys would pile up.
+		heap.Remove(&c.heap, e.cleanup.index)
+	}
+	return true
 }
 
-// Len returns the number of items in the cache.
-func (c *Expiring) Len() int {
+// Len returns the number of items in the cache, including expired items which
+// have not been garbage collected yet.
+func (c *TypedExpiring[K, V]) Len() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return len(c.cache)
 }
 
-func (c *Expiring) gc(now time.Time) {
+// Keys returns a snapshot of the keys of the entries which Get would return,
+// in no particular order.
+func (c *TypedExpiring[K, V]) Keys() []K {
+	now := c.clock.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]K, 0, len(c.cache))
+	for key, e := range c.cache {
+		if c.AllowExpiredGet || now.Before(e.expiry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GC removes the expired entries from the cache, and returns the number of
+// entries removed.
+func (c *TypedExpiring[K, V]) GC() int {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gc(now)
+}
+
+// Run calls GC every period, as measured by the clock of the cache, until ctx
+// is done. It is meant to be used with DisableInlineGC, or with a GCInterval,
+// so that expired entries are removed even if Set is not called.
+func (c *TypedExpiring[K, V]) Run(ctx context.Context, period time.Duration) {
+	for {
+		t := c.clock.NewTimer(period)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+			c.GC()
+		}
+	}
+}
+
+// gc must be called under the write lock.
+func (c *TypedExpiring[K, V]) gc(now time.Time) (removed int) {
+	c.lastGC = now
 	for {
 		// Return from gc if the heap is empty or the next element is not yet
 		// expired.
@@ -159,44 +320,54 @@ func (c *Expiring) gc(now time.Time) {
 		// heap.Pop() swaps the first entry with the last entry of the heap, then
 		// calls (*expiringHeap).Pop() which returns the last element.
 		if len(c.heap) == 0 || now.Before(c.heap[0].expiry) {
-			return
+			return removed
+		}
+		cleanup := heap.Pop(&c.heap).(*expiringHeapEntry[K])
+		if c.del(cleanup.key, cleanup.generation) {
+			removed++
 		}
-		cleanup := heap.Pop(&c.heap).(*expiringHeapEntry)
-		c.del(cleanup.key, cleanup.generation)
 	}
 }
 
-type expiringHeapEntry struct {
-	key        interface{}
+type expiringHeapEntry[K comparable] struct {
+	key        K
 	expiry     time.Time
 	generation uint64
+	// index is the index of the entry in the heap, or -1 once it is removed.
+	index int
 }
 
 // expiringHeap is a min-heap ordered by expiration time of its entries. The
 // expiring cache uses this as a priority queue to efficiently organize entries
 // which will be garbage collected once they expire.
-type expiringHeap []*expiringHeapEntry
+type expiringHeap[K comparable] []*expiringHeapEntry[K]
 
-var _ heap.Interface = &expiringHeap{}
+var _ heap.Interface = &expiringHeap[string]{}
 
-func (cq expiringHeap) Len() int {
+func (cq expiringHeap[K]) Len() int {
 	return len(cq)
 }
 
-func (cq expiringHeap) Less(i, j int) bool {
+func (cq expiringHeap[K]) Less(i, j int) bool {
 	return cq[i].expiry.Before(cq[j].expiry)
 }
 
-func (cq expiringHeap) Swap(i, j int) {
+func (cq expiringHeap[K]) Swap(i, j int) {
 	cq[i], cq[j] = cq[j], cq[i]
+	cq[i].index = i
+	cq[j].index = j
 }
 
-func (cq *expiringHeap) Push(c interface{}) {
-	*cq = append(*cq, c.(*expiringHeapEntry))
+func (cq *expiringHeap[K]) Push(c interface{}) {
+	e := c.(*expiringHeapEntry[K])
+	e.index = cq.Len()
+	*cq = append(*cq, e)
 }
 
-func (cq *expiringHeap) Pop() interface{} {
+func (cq *expiringHeap[K]) Pop() interface{} {
 	c := (*cq)[cq.Len()-1]
+	c.index = -1
+	(*cq)[cq.Len()-1] = nil
 	*cq = (*cq)[:cq.Len()-1]
 	return c
 }
@@ -319,3 +319,141 @@ func TestStressExpiringCache(t *testing.T) {
 		t.Errorf("unexpected cache size: got=%d, want=1", cache.Len())
 	}
 }
+
+func TestTypedExpiringGetAndRenew(t *testing.T) {
+	fc := &testingclock.FakeClock{}
+	c := NewTypedExpiringWithOptions[string, int](ExpiringOptions{Clock: fc, DefaultTTL: time.Second})
+
+	c.SetDefault("a", 1)
+	c.Set("b", 2, 3*time.Second)
+
+	fc.Step(500 * time.Millisecond)
+	if v, ok := c.GetAndRenew("a"); !ok || v != 1 {
+		t.Errorf("Expected 1, true, got %v, %v", v, ok)
+	}
+
+	// the renewal pushed the expiry of "a" past its original deadline
+	fc.Step(800 * time.Millisecond)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Expected renewed entry 1, true, got %v, %v", v, ok)
+	}
+
+	// without renewal "a" expires, while "b" keeps its own TTL
+	fc.Step(800 * time.Millisecond)
+	if v, ok := c.GetAndRenew("a"); ok || v != 0 {
+		t.Errorf("Expected expired entry not to be renewed, got %v, %v", v, ok)
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Errorf("Expected 2, true, got %v, %v", v, ok)
+	}
+	if v, ok := c.GetAndRenew("missing"); ok || v != 0 {
+		t.Errorf("Expected 0, false, got %v, %v", v, ok)
+	}
+
+	// renewals reschedule the cleanup of the entry rather than adding others
+	c.SetDefault("a", 1)
+	for i := 0; i < 100; i++ {
+		c.GetAndRenew("a")
+	}
+	if len(c.heap) != c.Len() {
+		t.Errorf("Expected a cleanup per entry, got %d for %d entries", len(c.heap), c.Len())
+	}
+	fc.Step(time.Second)
+	c.GC()
+	if c.Len() != 0 || len(c.heap) != 0 {
+		t.Errorf("Expected all entries to be collected once expired, got %d entries and %d cleanups", c.Len(), len(c.heap))
+	}
+}
+
+func TestExpiringJitter(t *testing.T) {
+	fc := &testingclock.FakeClock{}
+	c := NewTypedExpiringWithOptions[int, int](ExpiringOptions{Clock: fc, JitterFactor: 1})
+
+	for i := 0; i < 100; i++ {
+		c.Set(i, i, time.Second)
+	}
+	// no entry expires before its TTL
+	fc.Step(time.Second - time.Nanosecond)
+	for i := 0; i < 100; i++ {
+		if _, ok := c.Get(i); !ok {
+			t.Fatalf("Expected entry %d to not be expired before its TTL", i)
+		}
+	}
+	// and every entry expires within the jittered TTL
+	fc.Step(time.Second + time.Nanosecond)
+	for i := 0; i < 100; i++ {
+		if _, ok := c.Get(i); ok {
+			t.Fatalf("Expected entry %d to be expired after twice its TTL", i)
+		}
+	}
+}
+
+func TestExpiringGCControls(t *testing.T) {
+	fc := &testingclock.FakeClock{}
+	c := NewTypedExpiringWithOptions[string, int](ExpiringOptions{Clock: fc, DisableInlineGC: true})
+
+	c.Set("a", 1, time.Second)
+	c.Set("b", 2, 2*time.Second)
+	fc.Step(time.Second)
+	c.Set("c", 3, time.Second)
+	if c.Len() != 3 {
+		t.Errorf("expected expired entries to be kept without inline GC, got %d entries", c.Len())
+	}
+	if keys := c.Keys(); len(keys) != 2 {
+		t.Errorf("expected the keys of unexpired entries, got %v", keys)
+	}
+	if removed := c.GC(); removed != 1 || c.Len() != 2 {
+		t.Errorf("expected GC to remove 1 entry, removed %d and kept %d", removed, c.Len())
+	}
+
+	c = NewTypedExpiringWithOptions[string, int](ExpiringOptions{Clock: fc, GCInterval: time.Minute})
+	c.Set("a", 1, time.Second)
+	fc.Step(time.Second)
+	c.Set("b", 2, time.Second)
+	if c.Len() != 2 {
+		t.Errorf("expected no GC within the GC interval, got %d entries", c.Len())
+	}
+	fc.Step(time.Minute)
+	c.Set("c", 3, time.Second)
+	if c.Len() != 1 {
+		t.Errorf("expected GC after the GC interval, got %d entries", c.Len())
+	}
+}
+
+func TestExpiringHeapBoundedWithoutInlineGC(t *testing.T) {
+	fc := &testingclock.FakeClock{}
+	c := NewTypedExpiringWithOptions[int, int](ExpiringOptions{Clock: fc, DisableInlineGC: true})
+
+	for i := 0; i < 1000; i++ {
+		c.Set(i%10, i, time.Minute)
+		if i%3 == 0 {
+			c.Delete(i % 10)
+		}
+	}
+	if len(c.heap) != c.Len() {
+		t.Errorf("Expected a cleanup per entry, got %d for %d entries", len(c.heap), c.Len())
+	}
+}
+
+func TestExpiringRun(t *testing.T) {
+	fc := testingclock.NewFakeClock(time.Now())
+	c := NewTypedExpiringWithOptions[string, int](ExpiringOptions{Clock: fc, DisableInlineGC: true})
+	c.Set("a", 1, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx, time.Minute)
+	}()
+
+	for !fc.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	fc.Step(time.Minute)
+	for c.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
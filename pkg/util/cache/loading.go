@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// LoaderFunc loads the value for a key.
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// LoadingOptions configures a Loading cache.
+type LoadingOptions struct {
+	// TTL is how long a successfully loaded value is cached. Zero means values
+	// are not cached, and only concurrent loads of the same key are shared.
+	TTL time.Duration
+	// NegativeTTL is how long a load error is cached. Zero means errors are not
+	// cached and the next Get loads the key again.
+	NegativeTTL time.Duration
+	// Clock is used to obtain the current time. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Loading is a cache that loads missing values with a loader function. At most
+// one load per key runs at a time: concurrent callers of Get for a key that is
+// being loaded wait for, and share, the result of the load in flight.
+type Loading[K comparable, V any] struct {
+	loader      LoaderFunc[K, V]
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// lock protects results and inflight.
+	lock     sync.Mutex
+	results  *TypedExpiring[K, loadResult[V]]
+	inflight map[K]*loadCall[V]
+}
+
+type loadResult[V any] struct {
+	val V
+	err error
+}
+
+// loadCall is a load in flight. done is closed once result is set.
+type loadCall[V any] struct {
+	done   chan struct{}
+	result loadResult[V]
+}
+
+// NewLoading returns a Loading cache using loader to load missing keys.
+func NewLoading[K comparable, V any](loader LoaderFunc[K, V], opts LoadingOptions) *Loading[K, V] {
+	return &Loading[K, V]{
+		loader:      loader,
+		ttl:         opts.TTL,
+		negativeTTL: opts.NegativeTTL,
+		results:     NewTypedExpiringWithOptions[K, loadResult[V]](ExpiringOptions{Clock: opts.Clock}),
+		inflight:    make(map[K]*loadCall[V]),
+	}
+}
+
+// Get returns the cached value or error for key, loading it if necessary.
+//
+// The load runs with a context that is not canceled along with ctx, so that
+// other callers waiting for the same key are not failed by a caller giving up.
+// If ctx is done before the load completes, Get returns the context error while
+// the load continues in the background and its result is cached as usual.
+func (c *Loading[K, V]) Get(ctx context.Context, key K) (V, error) {
+	c.lock.Lock()
+	if result, ok := c.results.Get(key); ok {
+		c.lock.Unlock()
+		return result.val, result.err
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &loadCall[V]{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.load(context.WithoutCancel(ctx), key, call)
+	}
+	c.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result.val, call.result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs the loader for key and publishes its result to the waiters of call.
+// Panics of the loader are not recovered, so a panicking loader crashes the
+// process. The waiters are only released with an error if the loader exits its
+// goroutine without returning, with runtime.Goexit.
+func (c *Loading[K, V]) load(ctx context.Context, key K, call *loadCall[V]) {
+	returned := false
+	defer func() {
+		if !returned {
+			call.result = loadResult[V]{err: fmt.Errorf("loading %v did not complete", key)}
+			c.forget(key, call)
+		}
+		close(call.done)
+	}()
+
+	val, err := c.loader(ctx, key)
+	returned = true
+	call.result = loadResult[V]{val: val, err: err}
+	c.finish(key, call)
+}
+
+// forget removes call from the loads in flight without caching its result.
+func (c *Loading[K, V]) forget(key K, call *loadCall[V]) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.inflight[key] == call {
+		delete(c.inflight, key)
+	}
+}
+
+// finish caches the result of call, unless the key was invalidated while the
+// load was in flight.
+func (c *Loading[K, V]) finish(key K, call *loadCall[V]) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.inflight[key] != call {
+		return
+	}
+	delete(c.inflight, key)
+	switch {
+	case call.result.err == nil && c.ttl > 0:
+		c.results.Set(key, call.result, c.ttl)
+	case call.result.err != nil && c.negativeTTL > 0:
+		c.results.Set(key, call.result, c.negativeTTL)
+	}
+}
+
+// Invalidate removes the cached result for key. A load of key already in flight
+// still returns its result to its waiters, but that result is not cached, and
+// later calls to Get start a new load.
+func (c *Loading[K, V]) Invalidate(key K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.results.Delete(key)
+	delete(c.inflight, key)
+}
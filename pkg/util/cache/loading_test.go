@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestLoadingSingleFlight(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	c := NewLoading(func(ctx context.Context, key string) (string, error) {
+		loads.Add(1)
+		<-release
+		return key + "-value", nil
+	}, LoadingOptions{TTL: time.Hour})
+
+	var wg sync.WaitGroup
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Get(context.Background(), "key")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- v
+		}()
+	}
+	// let the callers pile up on the load in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "key-value" {
+			t.Errorf("Expected key-value, got %q", v)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("Expected a single load, got %d", n)
+	}
+	// the value is now cached
+	if _, err := c.Get(context.Background(), "key"); err != nil {
+		t.Fatal(err)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("Expected cached value to be returned, got %d loads", n)
+	}
+}
+
+func TestLoadingTTL(t *testing.T) {
+	fc := testingclock.NewFakeClock(time.Now())
+	loadErr := errors.New("load failed")
+	var loads int
+	fail := true
+	c := NewLoading(func(ctx context.Context, key string) (int, error) {
+		loads++
+		if fail {
+			return 0, loadErr
+		}
+		return loads, nil
+	}, LoadingOptions{TTL: time.Minute, NegativeTTL: time.Second, Clock: fc})
+
+	if _, err := c.Get(context.Background(), "key"); err != loadErr {
+		t.Fatalf("Expected load error, got %v", err)
+	}
+	// errors are cached for the negative TTL
+	fail = false
+	if _, err := c.Get(context.Background(), "key"); err != loadErr {
+		t.Fatalf("Expected cached load error, got %v", err)
+	}
+	fc.Step(time.Second)
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != 2 {
+		t.Fatalf("Expected 2, nil, got %v, %v", v, err)
+	}
+	// values are cached for the TTL
+	fc.Step(30 * time.Second)
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != 2 {
+		t.Fatalf("Expected cached 2, nil, got %v, %v", v, err)
+	}
+	fc.Step(30 * time.Second)
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != 3 {
+		t.Fatalf("Expected 3, nil, got %v, %v", v, err)
+	}
+	c.Invalidate("key")
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != 4 {
+		t.Fatalf("Expected 4, nil after invalidation, got %v, %v", v, err)
+	}
+}
+
+func TestLoadingNoNegativeCaching(t *testing.T) {
+	var loads int
+	c := NewLoading(func(ctx context.Context, key string) (int, error) {
+		loads++
+		return 0, errors.New("load failed")
+	}, LoadingOptions{TTL: time.Hour})
+
+	c.Get(context.Background(), "key")
+	c.Get(context.Background(), "key")
+	if loads != 2 {
+		t.Errorf("Expected errors not to be cached, got %d loads", loads)
+	}
+}
+
+func TestLoadingCallerCanceled(t *testing.T) {
+	release := make(chan struct{})
+	c := NewLoading(func(ctx context.Context, key string) (string, error) {
+		<-release
+		return "value", ctx.Err()
+	}, LoadingOptions{TTL: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx, "key"); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	// the load is not canceled along with the caller that started it
+	close(release)
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "value" {
+		t.Fatalf("Expected value, nil, got %v, %v", v, err)
+	}
+}
+
+func TestLoadingLoaderDoesNotReturn(t *testing.T) {
+	calls := 0
+	c := NewLoading(func(ctx context.Context, key string) (string, error) {
+		calls++
+		if calls == 1 {
+			// like a panic, this unwinds the loader without returning
+			runtime.Goexit()
+		}
+		return "value", nil
+	}, LoadingOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+
+	if _, err := c.Get(context.Background(), "key"); err == nil {
+		t.Fatal("Expected a loader which did not return to return an error")
+	}
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "value" {
+		t.Fatalf("Expected the incomplete load not to be cached, got %v, %v", v, err)
+	}
+}
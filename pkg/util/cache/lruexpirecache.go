@@ -32,6 +32,50 @@ type realClock struct{}
 
 func (realClock) Now() time.Time { return time.Now() }
 
+// EvictionReason describes why an entry left an LRUExpireCache.
+type EvictionReason string
+
+const (
+	// EvictionReasonCapacity means the entry was evicted to stay within the
+	// size or cost limits of the cache.
+	EvictionReasonCapacity EvictionReason = "Capacity"
+	// EvictionReasonExpired means the entry was found past its ttl.
+	EvictionReasonExpired EvictionReason = "Expired"
+	// EvictionReasonRemoved means the entry was removed by Remove or RemoveAll.
+	EvictionReasonRemoved EvictionReason = "Removed"
+)
+
+// LRUExpireCacheOptions configures an LRUExpireCache.
+type LRUExpireCacheOptions struct {
+	// MaxSize is the maximum number of entries. Zero means no entry limit.
+	MaxSize int
+	// MaxCost is the maximum total cost of all entries, as computed by Cost.
+	// Zero means no cost limit.
+	MaxCost int64
+	// Cost returns the cost of an entry, e.g. its size in bytes. It is required
+	// if MaxCost is set.
+	Cost func(key, value interface{}) int64
+	// OnEvict, if set, is called for every entry leaving the cache, except for
+	// values replaced by Add. It is called without holding the cache lock.
+	OnEvict func(key, value interface{}, reason EvictionReason)
+	// Clock is used to obtain the current time. Defaults to the real clock.
+	Clock Clock
+}
+
+// LRUExpireCacheStats holds the counters of an LRUExpireCache.
+type LRUExpireCacheStats struct {
+	// Hits is the number of Get calls that returned a value.
+	Hits uint64
+	// Misses is the number of Get calls that returned no value.
+	Misses uint64
+	// Evictions is the number of entries evicted for capacity or expiry.
+	Evictions uint64
+	// Len is the current number of entries, including expired ones not yet evicted.
+	Len int
+	// Cost is the current total cost of all entries.
+	Cost int64
+}
+
 // LRUExpireCache is a cache that ensures the mostly recently accessed keys are returned with
 // a ttl beyond which keys are forcibly expired.
 type LRUExpireCache struct {
@@ -41,8 +85,16 @@ type LRUExpireCache struct {
 	lock sync.Mutex
 
 	maxSize      int
+	maxCost      int64
+	costFunc     func(key, value interface{}) int64
+	onEvict      func(key, value interface{}, reason EvictionReason)
 	evictionList list.List
 	entries      map[interface{}]*list.Element
+
+	cost      int64
+	hits      uint64
+	misses    uint64
+	evictions uint64
 }
 
 // NewLRUExpireCache creates an expiring cache with the given size
@@ -56,10 +108,33 @@ func NewLRUExpireCacheWithClock(maxSize int, clock Clock) *LRUExpireCache {
 		panic("maxSize must be > 0")
 	}
 
+	return NewLRUExpireCacheWithOptions(LRUExpireCacheOptions{MaxSize: maxSize, Clock: clock})
+}
+
+// NewLRUExpireCacheWithOptions creates an expiring cache bounded by entry count,
+// total cost, or both.
+func NewLRUExpireCacheWithOptions(opts LRUExpireCacheOptions) *LRUExpireCache {
+	if opts.MaxSize < 0 || opts.MaxCost < 0 {
+		panic("MaxSize and MaxCost must be >= 0")
+	}
+	if opts.MaxSize == 0 && opts.MaxCost == 0 {
+		panic("one of MaxSize or MaxCost must be > 0")
+	}
+	if opts.MaxCost > 0 && opts.Cost == nil {
+		panic("Cost is required when MaxCost is set")
+	}
+	clock := opts.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+
 	return &LRUExpireCache{
-		clock:   clock,
-		maxSize: maxSize,
-		entries: map[interface{}]*list.Element{},
+		clock:    clock,
+		maxSize:  opts.MaxSize,
+		maxCost:  opts.MaxCost,
+		costFunc: opts.Cost,
+		onEvict:  opts.OnEvict,
+		entries:  map[interface{}]*list.Element{},
 	}
 }
 
@@ -67,63 +142,131 @@ type cacheEntry struct {
 	key        interface{}
 	value      interface{}
 	expireTime time.Time
+	cost       int64
+}
+
+// evicted is an entry removed from the cache, to be passed to the eviction
+// callback once the lock is released.
+type evicted struct {
+	entry  *cacheEntry
+	reason EvictionReason
+}
+
+// notify calls the eviction callback for the given entries.
+func (c *LRUExpireCache) notify(evictedEntries []evicted) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range evictedEntries {
+		c.onEvict(e.entry.key, e.entry.value, e.reason)
+	}
+}
+
+// removeElement removes element from the cache. Must be called with the lock held.
+func (c *LRUExpireCache) removeElement(element *list.Element, reason EvictionReason, evictedEntries []evicted) []evicted {
+	entry := element.Value.(*cacheEntry)
+	c.evictionList.Remove(element)
+	delete(c.entries, entry.key)
+	c.cost -= entry.cost
+	if reason != EvictionReasonRemoved {
+		c.evictions++
+	}
+	if c.onEvict == nil {
+		return evictedEntries
+	}
+	return append(evictedEntries, evicted{entry: entry, reason: reason})
+}
+
+// overCapacity returns true if the cache exceeds its limits. Must be called with
+// the lock held.
+func (c *LRUExpireCache) overCapacity() bool {
+	return (c.maxSize > 0 && c.evictionList.Len() > c.maxSize) ||
+		(c.maxCost > 0 && c.cost > c.maxCost)
 }
 
 // Add adds the value to the cache at key with the specified maximum duration.
+// If the cache is cost-bounded, a value whose cost alone exceeds the cost limit
+// is not cached, and any previous value at key is removed.
 func (c *LRUExpireCache) Add(key interface{}, value interface{}, ttl time.Duration) {
+	var evictedEntries []evicted
+	defer func() { c.notify(evictedEntries) }()
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	// Key already exists
+	var cost int64
+	if c.costFunc != nil {
+		cost = c.costFunc(key, value)
+	}
+
 	oldElement, ok := c.entries[key]
-	if ok {
-		c.evictionList.MoveToFront(oldElement)
-		oldElement.Value.(*cacheEntry).value = value
-		oldElement.Value.(*cacheEntry).expireTime = c.clock.Now().Add(ttl)
+	if c.maxCost > 0 && cost > c.maxCost {
+		if ok {
+			evictedEntries = c.removeElement(oldElement, EvictionReasonCapacity, evictedEntries)
+		}
 		return
 	}
 
-	// Make space if necessary
-	if c.evictionList.Len() >= c.maxSize {
-		toEvict := c.evictionList.Back()
-		c.evictionList.Remove(toEvict)
-		delete(c.entries, toEvict.Value.(*cacheEntry).key)
+	if ok {
+		// Key already exists
+		c.evictionList.MoveToFront(oldElement)
+		entry := oldElement.Value.(*cacheEntry)
+		c.cost += cost - entry.cost
+		entry.value = value
+		entry.expireTime = c.clock.Now().Add(ttl)
+		entry.cost = cost
+	} else {
+		// Add new entry
+		entry := &cacheEntry{
+			key:        key,
+			value:      value,
+			expireTime: c.clock.Now().Add(ttl),
+			cost:       cost,
+		}
+		element := c.evictionList.PushFront(entry)
+		c.entries[key] = element
+		c.cost += cost
 	}
 
-	// Add new entry
-	entry := &cacheEntry{
-		key:        key,
-		value:      value,
-		expireTime: c.clock.Now().Add(ttl),
+	// Make space if necessary. The entry just added is at the front and fits
+	// on its own, so it is never evicted here.
+	for c.overCapacity() {
+		evictedEntries = c.removeElement(c.evictionList.Back(), EvictionReasonCapacity, evictedEntries)
 	}
-	element := c.evictionList.PushFront(entry)
-	c.entries[key] = element
 }
 
 // Get returns the value at the specified key from the cache if it exists and is not
 // expired, or returns false.
 func (c *LRUExpireCache) Get(key interface{}) (interface{}, bool) {
+	var evictedEntries []evicted
+	defer func() { c.notify(evictedEntries) }()
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	element, ok := c.entries[key]
 	if !ok {
+		c.misses++
 		return nil, false
 	}
 
 	if c.clock.Now().After(element.Value.(*cacheEntry).expireTime) {
-		c.evictionList.Remove(element)
-		delete(c.entries, key)
+		evictedEntries = c.removeElement(element, EvictionReasonExpired, evictedEntries)
+		c.misses++
 		return nil, false
 	}
 
 	c.evictionList.MoveToFront(element)
+	c.hits++
 
 	return element.Value.(*cacheEntry).value, true
 }
 
 // Remove removes the specified key from the cache if it exists
 func (c *LRUExpireCache) Remove(key interface{}) {
+	var evictedEntries []evicted
+	defer func() { c.notify(evictedEntries) }()
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -132,19 +275,20 @@ func (c *LRUExpireCache) Remove(key interface{}) {
 		return
 	}
 
-	c.evictionList.Remove(element)
-	delete(c.entries, key)
+	evictedEntries = c.removeElement(element, EvictionReasonRemoved, evictedEntries)
 }
 
 // RemoveAll removes all keys that match predicate.
 func (c *LRUExpireCache) RemoveAll(predicate func(key any) bool) {
+	var evictedEntries []evicted
+	defer func() { c.notify(evictedEntries) }()
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	for key, element := range c.entries {
 		if predicate(key) {
-			c.evictionList.Remove(element)
-			delete(c.entries, key)
+			evictedEntries = c.removeElement(element, EvictionReasonRemoved, evictedEntries)
 		}
 	}
 }
@@ -171,3 +315,17 @@ func (c *LRUExpireCache) Keys() []interface{} {
 
 	return val
 }
+
+// Stats returns the current counters of the cache.
+func (c *LRUExpireCache) Stats() LRUExpireCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return LRUExpireCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Len:       c.evictionList.Len(),
+		Cost:      c.cost,
+	}
+}
@@ -160,3 +160,87 @@ func TestLRUKeys(t *testing.T) {
 
 	assertKeys(t, c.Keys(), []interface{}{"elem1", "elem2", "elem3", "elem4"})
 }
+
+func stringCost(key, value interface{}) int64 {
+	return int64(len(value.(string)))
+}
+
+func TestLRUCostOverflow(t *testing.T) {
+	c := NewLRUExpireCacheWithOptions(LRUExpireCacheOptions{MaxCost: 10, Cost: stringCost})
+	c.Add("elem1", "1234", 10*time.Hour)
+	c.Add("elem2", "1234", 10*time.Hour)
+	c.Add("elem3", "1234", 10*time.Hour)
+
+	assertKeys(t, c.Keys(), []interface{}{"elem2", "elem3"})
+
+	// replacing a value updates the total cost
+	c.Add("elem2", "1", 10*time.Hour)
+	c.Add("elem4", "12345", 10*time.Hour)
+	assertKeys(t, c.Keys(), []interface{}{"elem3", "elem2", "elem4"})
+	if cost := c.Stats().Cost; cost != 10 {
+		t.Errorf("Expected cost 10, got %d", cost)
+	}
+
+	// a value larger than the limit is not cached and drops the previous value
+	c.Add("elem3", "12345678901", 10*time.Hour)
+	assertKeys(t, c.Keys(), []interface{}{"elem2", "elem4"})
+	expectNotEntry(t, c, "elem3")
+}
+
+func TestLRUSizeAndCost(t *testing.T) {
+	c := NewLRUExpireCacheWithOptions(LRUExpireCacheOptions{MaxSize: 2, MaxCost: 100, Cost: stringCost})
+	c.Add("elem1", "1", 10*time.Hour)
+	c.Add("elem2", "2", 10*time.Hour)
+	c.Add("elem3", "3", 10*time.Hour)
+
+	assertKeys(t, c.Keys(), []interface{}{"elem2", "elem3"})
+}
+
+func TestLRUEvictionCallback(t *testing.T) {
+	type eviction struct {
+		key    interface{}
+		reason EvictionReason
+	}
+	var evictions []eviction
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	c := NewLRUExpireCacheWithOptions(LRUExpireCacheOptions{
+		MaxSize: 2,
+		Clock:   fakeClock,
+		OnEvict: func(key, value interface{}, reason EvictionReason) {
+			evictions = append(evictions, eviction{key: key, reason: reason})
+		},
+	})
+	c.Add("elem1", "1", 10*time.Hour)
+	c.Add("elem2", "2", time.Millisecond)
+	c.Add("elem2", "2-new", time.Millisecond)
+	c.Add("elem3", "3", 10*time.Hour)
+	fakeClock.Step(2 * time.Millisecond)
+	expectNotEntry(t, c, "elem2")
+	c.Remove("elem3")
+
+	want := []eviction{
+		{key: "elem1", reason: EvictionReasonCapacity},
+		{key: "elem2", reason: EvictionReasonExpired},
+		{key: "elem3", reason: EvictionReasonRemoved},
+	}
+	if diff := cmp.Diff(want, evictions, cmp.AllowUnexported(eviction{})); diff != "" {
+		t.Errorf("Wrong evictions: diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestLRUStats(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	c := NewLRUExpireCacheWithClock(2, fakeClock)
+	c.Add("elem1", "1", 10*time.Hour)
+	c.Add("elem2", "2", time.Millisecond)
+	c.Add("elem3", "3", 10*time.Hour)
+	expectEntry(t, c, "elem3", "3")
+	expectNotEntry(t, c, "elem1")
+	fakeClock.Step(2 * time.Millisecond)
+	expectNotEntry(t, c, "elem2")
+
+	want := LRUExpireCacheStats{Hits: 1, Misses: 2, Evictions: 2, Len: 1}
+	if diff := cmp.Diff(want, c.Stats()); diff != "" {
+		t.Errorf("Wrong stats: diff (-want +got):\n%s", diff)
+	}
+}
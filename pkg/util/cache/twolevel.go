@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Store is the persistent second level of a TwoLevel cache, e.g. files on
+// disk or a remote cache, which survives restarts.
+type Store[K comparable, V any] interface {
+	// Load returns the value stored for key and the time it was loaded at, or
+	// false if there is none.
+	Load(ctx context.Context, key K) (val V, loadedAt time.Time, found bool, err error)
+	// Store stores the value of key, loaded at loadedAt.
+	Store(ctx context.Context, key K, val V, loadedAt time.Time) error
+	// Delete removes the value of key, if any.
+	Delete(ctx context.Context, key K) error
+}
+
+// TwoLevelOptions configures a TwoLevel cache.
+type TwoLevelOptions[K comparable] struct {
+	// MaxSize is the maximum number of values held in memory. Required.
+	MaxSize int
+	// FreshFor is how long a loaded value is returned as it is.
+	FreshFor time.Duration
+	// StaleFor is how long a value is still returned once it is no longer
+	// fresh, while it is refreshed in the background. Older values are not
+	// returned: Get waits for a new value to be loaded.
+	StaleFor time.Duration
+	// OnRefresh, if set, is called at the end of each background refresh,
+	// with the error of the load or of the store, if any.
+	OnRefresh func(key K, err error)
+	// OnStoreError, if set, is called when the store fails to load, store or
+	// delete a value. Values which cannot be loaded from the store are loaded
+	// with the loader, and values which cannot be stored are still returned.
+	OnStoreError func(key K, err error)
+	// Clock is used to obtain the current time. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// TwoLevel is a cache for data which is slow to load and tolerates brief
+// staleness, such as discovery or OpenAPI documents. Values are held in an
+// in-memory LRUExpireCache in front of a persistent Store, and loaded with a
+// loader function when neither has them. Loaded values are written through to
+// the store, so that they survive restarts.
+//
+// Values are fresh for FreshFor after they were loaded, then stale for
+// StaleFor: stale values are returned while a single background load refreshes
+// them (stale-while-revalidate). At most one load per key runs at a time.
+type TwoLevel[K comparable, V any] struct {
+	memory       *LRUExpireCache
+	store        Store[K, V]
+	loader       LoaderFunc[K, V]
+	freshFor     time.Duration
+	staleFor     time.Duration
+	onRefresh    func(key K, err error)
+	onStoreError func(key K, err error)
+	clock        clock.Clock
+
+	// lock protects inflight and keys.
+	lock     sync.Mutex
+	inflight map[K]*loadCall[V]
+	keys     map[K]*twoLevelKey
+}
+
+// twoLevelKey orders writing the values of a key to the store with the
+// invalidation of the key. It exists while loads or invalidations of the key
+// hold references to it.
+type twoLevelKey struct {
+	// lock is held while writing the value of the key to memory and the
+	// store, and while removing it.
+	lock sync.Mutex
+	// generation is incremented by Invalidate, so that the values of loads
+	// started before are not written. It is protected by the lock of the
+	// cache.
+	generation uint64
+	// refs is the number of loads and invalidations of the key in progress.
+	// It is protected by the lock of the cache.
+	refs int
+}
+
+// twoLevelEntry is a value held in memory.
+type twoLevelEntry[V any] struct {
+	val      V
+	loadedAt time.Time
+}
+
+// NewTwoLevel returns a TwoLevel cache in front of store, loading missing and
+// expired values with loader.
+func NewTwoLevel[K comparable, V any](store Store[K, V], loader LoaderFunc[K, V], opts TwoLevelOptions[K]) *TwoLevel[K, V] {
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	return &TwoLevel[K, V]{
+		memory:       NewLRUExpireCacheWithOptions(LRUExpireCacheOptions{MaxSize: opts.MaxSize, Clock: clk}),
+		store:        store,
+		loader:       loader,
+		freshFor:     opts.FreshFor,
+		staleFor:     opts.StaleFor,
+		onRefresh:    opts.OnRefresh,
+		onStoreError: opts.OnStoreError,
+		clock:        clk,
+		inflight:     make(map[K]*loadCall[V]),
+		keys:         make(map[K]*twoLevelKey),
+	}
+}
+
+// Get returns the value of key, from memory, from the store, or loaded with
+// the loader, in that order. Stale values are returned as they are and
+// refreshed in the background.
+//
+// Like Loading.Get, loads run with a context that is not canceled along with
+// ctx, and Get returns the context error if ctx is done before the load
+// completes.
+func (c *TwoLevel[K, V]) Get(ctx context.Context, key K) (V, error) {
+	now := c.clock.Now()
+	if cached, ok := c.memory.Get(key); ok {
+		entry := cached.(twoLevelEntry[V])
+		if now.Sub(entry.loadedAt) >= c.freshFor {
+			c.startLoad(ctx, key, true)
+		}
+		return entry.val, nil
+	}
+
+	val, loadedAt, found, err := c.store.Load(ctx, key)
+	if err != nil {
+		c.storeError(key, err)
+	} else if found {
+		if age := now.Sub(loadedAt); age < c.freshFor+c.staleFor {
+			c.memory.Add(key, twoLevelEntry[V]{val: val, loadedAt: loadedAt}, c.freshFor+c.staleFor-age)
+			if age >= c.freshFor {
+				c.startLoad(ctx, key, true)
+			}
+			return val, nil
+		}
+	}
+
+	call := c.startLoad(ctx, key, false)
+	select {
+	case <-call.done:
+		return call.result.val, call.result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Invalidate removes the value of key from memory and from the store. A load
+// of key already in flight still returns its result to its waiters, but that
+// result is not cached.
+func (c *TwoLevel[K, V]) Invalidate(ctx context.Context, key K) error {
+	c.lock.Lock()
+	k := c.acquireKeyLocked(key)
+	k.generation++
+	delete(c.inflight, key)
+	c.lock.Unlock()
+	defer c.releaseKey(key, k)
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	c.memory.Remove(key)
+	return c.store.Delete(ctx, key)
+}
+
+// acquireKeyLocked returns the state of key, adding a reference to it. The
+// lock of the cache must be held.
+func (c *TwoLevel[K, V]) acquireKeyLocked(key K) *twoLevelKey {
+	k, ok := c.keys[key]
+	if !ok {
+		k = &twoLevelKey{}
+		c.keys[key] = k
+	}
+	k.refs++
+	return k
+}
+
+// releaseKey removes a reference to the state of key, which is dropped with
+// the last reference.
+func (c *TwoLevel[K, V]) releaseKey(key K, k *twoLevelKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.releaseKeyLocked(key, k)
+}
+
+func (c *TwoLevel[K, V]) releaseKeyLocked(key K, k *twoLevelKey) {
+	k.refs--
+	if k.refs == 0 {
+		delete(c.keys, key)
+	}
+}
+
+// startLoad returns the load of key in flight, starting it if necessary.
+// Background loads report their result to OnRefresh.
+func (c *TwoLevel[K, V]) startLoad(ctx context.Context, key K, background bool) *loadCall[V] {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &loadCall[V]{done: make(chan struct{})}
+		c.inflight[key] = call
+		k := c.acquireKeyLocked(key)
+		go c.load(context.WithoutCancel(ctx), key, call, k, k.generation, background)
+	}
+	return call
+}
+
+// load runs the loader for key, writes its value through to memory and the
+// store unless the key was invalidated since generation, and publishes its
+// result to the waiters of call.
+//
+// Panics of the loader are not recovered, so a panicking loader crashes the
+// process. The waiters are only released with an error if the loader exits
+// its goroutine without returning, with runtime.Goexit.
+func (c *TwoLevel[K, V]) load(ctx context.Context, key K, call *loadCall[V], k *twoLevelKey, generation uint64, background bool) {
+	defer close(call.done)
+	returned := false
+	var storeErr error
+	defer func() {
+		if !returned {
+			call.result = loadResult[V]{err: fmt.Errorf("loading %v did not complete", key)}
+		}
+		c.lock.Lock()
+		if c.inflight[key] == call {
+			delete(c.inflight, key)
+		}
+		c.releaseKeyLocked(key, k)
+		c.lock.Unlock()
+		if returned && background && c.onRefresh != nil {
+			err := call.result.err
+			if err == nil {
+				err = storeErr
+			}
+			c.onRefresh(key, err)
+		}
+	}()
+
+	val, err := c.loader(ctx, key)
+	returned = true
+	call.result = loadResult[V]{val: val, err: err}
+	if err != nil {
+		return
+	}
+	if storeErr = c.writeThrough(ctx, key, k, generation, val); storeErr != nil {
+		c.storeError(key, storeErr)
+	}
+}
+
+// writeThrough adds the value of key loaded in generation to memory and the
+// store, unless key was invalidated while loading. The store is written with
+// the lock of the key held, so that Invalidate cannot remove the value before
+// it is stored, while other keys are written and invalidated concurrently.
+func (c *TwoLevel[K, V]) writeThrough(ctx context.Context, key K, k *twoLevelKey, generation uint64, val V) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	c.lock.Lock()
+	invalidated := k.generation != generation
+	c.lock.Unlock()
+	if invalidated {
+		return nil
+	}
+	loadedAt := c.clock.Now()
+	if c.freshFor+c.staleFor > 0 {
+		c.memory.Add(key, twoLevelEntry[V]{val: val, loadedAt: loadedAt}, c.freshFor+c.staleFor)
+	}
+	return c.store.Store(ctx, key, val, loadedAt)
+}
+
+func (c *TwoLevel[K, V]) storeError(key K, err error) {
+	if c.onStoreError != nil {
+		c.onStoreError(key, err)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// memoryStore is a Store persisting values in a map.
+type memoryStore struct {
+	lock   sync.Mutex
+	values map[string]twoLevelEntry[string]
+	err    error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: map[string]twoLevelEntry[string]{}}
+}
+
+func (s *memoryStore) Load(ctx context.Context, key string) (string, time.Time, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return "", time.Time{}, false, s.err
+	}
+	entry, ok := s.values[key]
+	return entry.val, entry.loadedAt, ok, nil
+}
+
+func (s *memoryStore) Store(ctx context.Context, key string, val string, loadedAt time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = twoLevelEntry[string]{val: val, loadedAt: loadedAt}
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.values, key)
+	return nil
+}
+
+func (s *memoryStore) get(key string) (twoLevelEntry[string], bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, ok := s.values[key]
+	return entry, ok
+}
+
+func TestTwoLevelWriteThrough(t *testing.T) {
+	fc := testingclock.NewFakeClock(time.Now())
+	store := newMemoryStore()
+	var loads atomic.Int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		n := loads.Add(1)
+		return key + "-" + string(rune('0'+n)), nil
+	}
+	opts := TwoLevelOptions[string]{MaxSize: 10, FreshFor: time.Minute, StaleFor: time.Hour, Clock: fc}
+	c := NewTwoLevel[string, string](store, loader, opts)
+
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "key-1" {
+		t.Fatalf("Expected key-1, got %q, %v", v, err)
+	}
+	if entry, ok := store.get("key"); !ok || entry.val != "key-1" || !entry.loadedAt.Equal(fc.Now()) {
+		t.Errorf("Expected the value to be written through to the store, got %#v", entry)
+	}
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "key-1" || loads.Load() != 1 {
+		t.Errorf("Expected the value to be served from memory, got %q, %v after %d loads", v, err, loads.Load())
+	}
+
+	// A new cache, as after a restart, serves the stored value.
+	fc.Step(30 * time.Second)
+	restarted := NewTwoLevel[string, string](store, loader, opts)
+	if v, err := restarted.Get(context.Background(), "key"); err != nil || v != "key-1" || loads.Load() != 1 {
+		t.Errorf("Expected the value to be served from the store, got %q, %v after %d loads", v, err, loads.Load())
+	}
+
+	// Values older than FreshFor+StaleFor are loaded again.
+	fc.Step(2 * time.Hour)
+	restarted = NewTwoLevel[string, string](store, loader, opts)
+	if v, err := restarted.Get(context.Background(), "key"); err != nil || v != "key-2" {
+		t.Errorf("Expected key-2, got %q, %v", v, err)
+	}
+
+	if err := restarted.Invalidate(context.Background(), "key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.get("key"); ok {
+		t.Error("Expected the value to be deleted from the store")
+	}
+	if v, err := restarted.Get(context.Background(), "key"); err != nil || v != "key-3" {
+		t.Errorf("Expected key-3, got %q, %v", v, err)
+	}
+}
+
+func TestTwoLevelInvalidateWhileLoading(t *testing.T) {
+	store := newMemoryStore()
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func(ctx context.Context, key string) (string, error) {
+		close(started)
+		<-release
+		return "value", nil
+	}
+	c := NewTwoLevel[string, string](store, loader, TwoLevelOptions[string]{MaxSize: 10, FreshFor: time.Minute})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), "key")
+		result <- err
+	}()
+	<-started
+	if err := c.Invalidate(context.Background(), "key"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-result; err != nil {
+		t.Fatalf("Expected the load in flight to return its value, got %v", err)
+	}
+	if _, ok := store.get("key"); ok {
+		t.Error("Expected the value loaded before Invalidate not to be stored")
+	}
+}
+
+// blockingStore is a memoryStore whose Store blocks for the key "slow" until
+// release is closed.
+type blockingStore struct {
+	*memoryStore
+	storing chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Store(ctx context.Context, key string, val string, loadedAt time.Time) error {
+	if key == "slow" {
+		close(s.storing)
+		<-s.release
+	}
+	return s.memoryStore.Store(ctx, key, val, loadedAt)
+}
+
+func TestTwoLevelSlowStoreDoesNotBlockOtherKeys(t *testing.T) {
+	store := &blockingStore{memoryStore: newMemoryStore(), storing: make(chan struct{}), release: make(chan struct{})}
+	loader := func(ctx context.Context, key string) (string, error) {
+		return key + "-value", nil
+	}
+	c := NewTwoLevel[string, string](store, loader, TwoLevelOptions[string]{MaxSize: 10, FreshFor: time.Minute})
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), "slow")
+		slow <- err
+	}()
+	<-store.storing
+
+	// other keys are loaded, stored and invalidated while "slow" is stored
+	if v, err := c.Get(context.Background(), "fast"); err != nil || v != "fast-value" {
+		t.Errorf("Expected fast-value, got %q, %v", v, err)
+	}
+	if err := c.Invalidate(context.Background(), "fast"); err != nil {
+		t.Fatal(err)
+	}
+
+	close(store.release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := store.get("slow"); !ok || entry.val != "slow-value" {
+		t.Errorf("Expected slow-value to be stored, got %v", entry)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.keys) != 0 {
+		t.Errorf("Expected the state of keys to be dropped, got %d keys", len(c.keys))
+	}
+}
+
+func TestTwoLevelStaleWhileRevalidate(t *testing.T) {
+	fc := testingclock.NewFakeClock(time.Now())
+	store := newMemoryStore()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	refreshed := make(chan error, 10)
+	c := NewTwoLevel[string, string](store, func(ctx context.Context, key string) (string, error) {
+		if loads.Add(1) == 1 {
+			return "old", nil
+		}
+		<-release
+		return "new", nil
+	}, TwoLevelOptions[string]{
+		MaxSize:   10,
+		FreshFor:  time.Minute,
+		StaleFor:  time.Hour,
+		OnRefresh: func(key string, err error) { refreshed <- err },
+		Clock:     fc,
+	})
+
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "old" {
+		t.Fatalf("Expected old, got %q, %v", v, err)
+	}
+	fc.Step(2 * time.Minute)
+	// Stale values are served while a single refresh is in flight.
+	for i := 0; i < 3; i++ {
+		if v, err := c.Get(context.Background(), "key"); err != nil || v != "old" {
+			t.Fatalf("Expected the stale value, got %q, %v", v, err)
+		}
+	}
+	close(release)
+	select {
+	case err := <-refreshed:
+		if err != nil {
+			t.Errorf("Unexpected refresh error: %v", err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("Timed out waiting for the refresh")
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("Expected a single refresh, got %d loads", n)
+	}
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "new" {
+		t.Errorf("Expected the refreshed value, got %q, %v", v, err)
+	}
+	if entry, _ := store.get("key"); entry.val != "new" {
+		t.Errorf("Expected the refreshed value to be stored, got %q", entry.val)
+	}
+}
+
+func TestTwoLevelStoreErrors(t *testing.T) {
+	store := newMemoryStore()
+	store.err = errors.New("disk full")
+	var storeErrors atomic.Int32
+	c := NewTwoLevel[string, string](store, func(ctx context.Context, key string) (string, error) {
+		return "value", nil
+	}, TwoLevelOptions[string]{
+		MaxSize:      10,
+		FreshFor:     time.Minute,
+		OnStoreError: func(key string, err error) { storeErrors.Add(1) },
+	})
+
+	// The store failing to load and to store the value is not fatal.
+	if v, err := c.Get(context.Background(), "key"); err != nil || v != "value" {
+		t.Errorf("Expected value, got %q, %v", v, err)
+	}
+	if n := storeErrors.Load(); n != 2 {
+		t.Errorf("Expected 2 store errors, got %d", n)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OperationType is the type of an Operation.
+type OperationType string
+
+const (
+	// OperationAdd adds a value that only exists in the new object.
+	OperationAdd OperationType = "add"
+	// OperationRemove removes a value that only exists in the old object.
+	OperationRemove OperationType = "remove"
+	// OperationReplace replaces a value that differs between the objects.
+	OperationReplace OperationType = "replace"
+)
+
+// Operation is a single change between two objects, as an RFC 6902 JSON Patch
+// operation.
+type Operation struct {
+	// Op is the type of the operation.
+	Op OperationType
+	// Path is the JSON pointer (RFC 6901) to the changed value, in the JSON
+	// representation of the objects.
+	Path string
+	// Value is the new value for add and replace operations, in the form
+	// returned by encoding/json for an interface{} with numbers decoded as
+	// json.Number.
+	Value interface{}
+}
+
+// MarshalJSON encodes the operation as a JSON Patch operation.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == OperationRemove {
+		return json.Marshal(struct {
+			Op   OperationType `json:"op"`
+			Path string        `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    OperationType `json:"op"`
+		Path  string        `json:"path"`
+		Value interface{}   `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// String returns a single line description of the operation.
+func (o Operation) String() string {
+	if o.Op == OperationRemove {
+		return fmt.Sprintf("%s %s", o.Op, o.Path)
+	}
+	value, err := json.Marshal(o.Value)
+	if err != nil {
+		return fmt.Sprintf("%s %s: %v", o.Op, o.Path, o.Value)
+	}
+	return fmt.Sprintf("%s %s: %s", o.Op, o.Path, value)
+}
+
+// ObjectOperations compares the JSON representations of a and b, which may be
+// typed objects, unstructured objects, or any other values that can be
+// marshaled to JSON, and returns the operations transforming a into b. The
+// operations are in the order they must be applied: map keys are visited in
+// sorted order, and elements are removed from the end of lists first. Lists
+// are compared element by element, without detecting moved elements.
+func ObjectOperations(a, b interface{}) ([]Operation, error) {
+	aValue, err := toJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bValue, err := toJSONValue(b)
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	diffValues(&ops, "", aValue, bValue)
+	return ops, nil
+}
+
+// ObjectJSONPatch returns an RFC 6902 JSON Patch transforming the JSON
+// representation of a into that of b, with the operations of
+// ObjectOperations. If there are no differences the patch is "[]".
+func ObjectJSONPatch(a, b interface{}) ([]byte, error) {
+	ops, err := ObjectOperations(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if ops == nil {
+		ops = []Operation{}
+	}
+	return json.Marshal(ops)
+}
+
+// toJSONValue returns the JSON representation of v as an interface{}.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func diffValues(ops *[]Operation, path string, a, b interface{}) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffMaps(ops, path, a, b)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffSlices(ops, path, a, b)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, Operation{Op: OperationReplace, Path: path, Value: b})
+	}
+}
+
+func diffMaps(ops *[]Operation, path string, a, b map[string]interface{}) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		childPath := path + "/" + escapePointerToken(k)
+		aValue, inA := a[k]
+		bValue, inB := b[k]
+		switch {
+		case !inB:
+			*ops = append(*ops, Operation{Op: OperationRemove, Path: childPath})
+		case !inA:
+			*ops = append(*ops, Operation{Op: OperationAdd, Path: childPath, Value: bValue})
+		default:
+			diffValues(ops, childPath, aValue, bValue)
+		}
+	}
+}
+
+func diffSlices(ops *[]Operation, path string, a, b []interface{}) {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+	for i := 0; i < common; i++ {
+		diffValues(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+	for i := len(a) - 1; i >= common; i-- {
+		*ops = append(*ops, Operation{Op: OperationRemove, Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(b); i++ {
+		*ops = append(*ops, Operation{Op: OperationAdd, Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+	}
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointerToken escapes a reference token of a JSON pointer.
+func escapePointerToken(token string) string {
+	return pointerEscaper.Replace(token)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type testObject struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Items  []int             `json:"items,omitempty"`
+	Nested *testObject       `json:"nested,omitempty"`
+}
+
+func TestObjectOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b interface{}
+		want []Operation
+		// skipApply skips applying the patch, for patches of the root which
+		// are not supported by json-patch
+		skipApply bool
+	}{
+		{
+			name: "equal",
+			a:    testObject{Name: "a", Items: []int{1}},
+			b:    testObject{Name: "a", Items: []int{1}},
+		},
+		{
+			name: "fields",
+			a:    testObject{Name: "a", Labels: map[string]string{"x": "1", "a/b": "2"}},
+			b:    testObject{Name: "b", Labels: map[string]string{"y": "1", "a/b": "3"}, Nested: &testObject{Name: "n"}},
+			want: []Operation{
+				{Op: OperationReplace, Path: "/labels/a~1b", Value: "3"},
+				{Op: OperationRemove, Path: "/labels/x"},
+				{Op: OperationAdd, Path: "/labels/y", Value: "1"},
+				{Op: OperationReplace, Path: "/name", Value: "b"},
+				{Op: OperationAdd, Path: "/nested", Value: map[string]interface{}{"name": "n"}},
+			},
+		},
+		{
+			name: "shorter list",
+			a:    testObject{Items: []int{1, 2, 3}},
+			b:    testObject{Items: []int{1, 4}},
+			want: []Operation{
+				{Op: OperationReplace, Path: "/items/1", Value: json.Number("4")},
+				{Op: OperationRemove, Path: "/items/2"},
+			},
+		},
+		{
+			name: "longer list",
+			a:    testObject{Items: []int{1}},
+			b:    testObject{Items: []int{1, 2, 3}},
+			want: []Operation{
+				{Op: OperationAdd, Path: "/items/1", Value: json.Number("2")},
+				{Op: OperationAdd, Path: "/items/2", Value: json.Number("3")},
+			},
+		},
+		{
+			name: "typed and unstructured",
+			a:    testObject{Name: "a", Items: []int{1}},
+			b:    &unstructured.Unstructured{Object: map[string]interface{}{"name": "a", "items": "none"}},
+			want: []Operation{
+				{Op: OperationReplace, Path: "/items", Value: "none"},
+			},
+		},
+		{
+			name: "root",
+			a:    []int{1},
+			b:    nil,
+			want: []Operation{
+				{Op: OperationReplace, Path: "", Value: nil},
+			},
+			skipApply: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ObjectOperations(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ops, tt.want) {
+				t.Errorf("ObjectOperations() = %v, want %v", ops, tt.want)
+			}
+
+			patch, err := ObjectJSONPatch(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.skipApply {
+				return
+			}
+			decoded, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				t.Fatalf("Invalid patch %s: %v", patch, err)
+			}
+			aJSON, _ := json.Marshal(tt.a)
+			bJSON, _ := json.Marshal(tt.b)
+			patched, err := decoded.Apply(aJSON)
+			if err != nil {
+				t.Fatalf("Failed to apply patch %s: %v", patch, err)
+			}
+			if !jsonpatch.Equal(patched, bJSON) {
+				t.Errorf("Patch %s produced %s, want %s", patch, patched, bJSON)
+			}
+		})
+	}
+}
+
+func TestOperationString(t *testing.T) {
+	if s := (Operation{Op: OperationReplace, Path: "/spec/replicas", Value: json.Number("3")}).String(); s != "replace /spec/replicas: 3" {
+		t.Errorf("Unexpected string %q", s)
+	}
+	if s := (Operation{Op: OperationRemove, Path: "/spec"}).String(); s != "remove /spec" {
+		t.Errorf("Unexpected string %q", s)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	forkedreflect "k8s.io/apimachinery/third_party/forked/golang/reflect"
+)
+
+// SemanticOptions controls SemanticDiff.
+type SemanticOptions struct {
+	// IgnorePaths are field paths of values which are not compared, along
+	// with everything below them, such as "metadata.resourceVersion",
+	// "metadata.managedFields" or "status". Paths use the JSON names of
+	// fields, and list indexes and map keys in brackets or after a dot, with
+	// "*" matching any of them, as in "spec.containers[*].image". See
+	// equality.Semantic.DeepEqualIgnoringPaths for details.
+	IgnorePaths []string
+}
+
+// SemanticDiff returns a minimal description of the differences between a and
+// b, or "" if they are equal. Values are compared like equalities.DeepEqual,
+// so nil and empty slices and maps are equal, and values of the types
+// equalities has functions for are compared with them. Pass
+// equality.Semantic.Equalities to compare like equality.Semantic, e.g.
+// quantities by value and times by instant. Like equalities.DeepEqual, it
+// panics on unexported fields of types without an equality function.
+//
+// Each difference is reported on its own line as the field path of the
+// differing value, in the format of equality.Semantic.Diff, followed by its
+// JSON encoding in a and in b, with "<unset>" for values missing on one side:
+//
+//	metadata.labels[app]: "nginx" -> <unset>
+//	spec.replicas: 1 -> 3
+//
+// Unstructured objects are compared by their content.
+func SemanticDiff(equalities forkedreflect.Equalities, a, b interface{}, opts SemanticOptions) string {
+	diffs := equalities.DiffIgnoringPaths(unstructuredContent(a), unstructuredContent(b), opts.IgnorePaths)
+	lines := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		path := d.Path
+		if path == "" {
+			path = "<root>"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", path, formatValue(d.A), formatValue(d.B)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unstructuredContent returns the content of unstructured objects, and any
+// other value unchanged.
+func unstructuredContent(v interface{}) interface{} {
+	if u, ok := v.(interface {
+		UnstructuredContent() map[string]interface{}
+	}); ok && !reflect.ValueOf(v).IsNil() {
+		return u.UnstructuredContent()
+	}
+	return v
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	forkedreflect "k8s.io/apimachinery/third_party/forked/golang/reflect"
+)
+
+type semanticObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              semanticSpec   `json:"spec"`
+	Status            semanticStatus `json:"status"`
+}
+
+type semanticSpec struct {
+	Replicas   int                          `json:"replicas"`
+	Memory     resource.Quantity            `json:"memory"`
+	Containers []semanticContainer          `json:"containers"`
+	Limits     map[string]resource.Quantity `json:"limits,omitempty"`
+}
+
+type semanticContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type semanticStatus struct {
+	Ready bool `json:"ready"`
+}
+
+func TestSemanticDiff(t *testing.T) {
+	now := time.Now()
+	base := func() *semanticObject {
+		return &semanticObject{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "obj",
+				ResourceVersion:   "1",
+				Labels:            map[string]string{"app": "nginx"},
+				CreationTimestamp: metav1.NewTime(now),
+			},
+			Spec: semanticSpec{
+				Replicas:   1,
+				Memory:     resource.MustParse("1Gi"),
+				Containers: []semanticContainer{{Name: "a", Image: "nginx:1"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*semanticObject)
+		opts   SemanticOptions
+		want   string
+	}{
+		{
+			name:   "equal",
+			modify: func(*semanticObject) {},
+		},
+		{
+			name: "semantically equal",
+			modify: func(o *semanticObject) {
+				o.Spec.Memory = resource.MustParse("1024Mi")
+				o.Spec.Limits = map[string]resource.Quantity{}
+				o.Annotations = map[string]string{}
+				o.CreationTimestamp = metav1.NewTime(now.In(time.FixedZone("x", 3600)))
+			},
+		},
+		{
+			name: "differences",
+			modify: func(o *semanticObject) {
+				o.Spec.Replicas = 3
+				o.Spec.Memory = resource.MustParse("2Gi")
+				delete(o.Labels, "app")
+				o.Spec.Containers = append(o.Spec.Containers, semanticContainer{Name: "b", Image: "busybox"})
+			},
+			want: `metadata.labels[app]: "nginx" -> <unset>
+spec.replicas: 1 -> 3
+spec.memory: "1Gi" -> "2Gi"
+spec.containers[1]: <unset> -> {"name":"b","image":"busybox"}`,
+		},
+		{
+			name: "ignored",
+			modify: func(o *semanticObject) {
+				o.ResourceVersion = "2"
+				o.Kind = "Object"
+				o.Status.Ready = true
+				o.Spec.Containers[0].Image = "nginx:2"
+				o.Spec.Containers[0].Name = "c"
+			},
+			opts: SemanticOptions{IgnorePaths: []string{"metadata.resourceVersion", "status", "spec.containers[*].image"}},
+			want: `kind: "" -> "Object"
+spec.containers[0].name: "a" -> "c"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base(), base()
+			tt.modify(b)
+			if got := SemanticDiff(equality.Semantic.Equalities, a, b, tt.opts); got != tt.want {
+				t.Errorf("SemanticDiff() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// values are compared with the given equalities
+	a, b := base(), base()
+	b.Labels["app"] = "NGINX"
+	if got := SemanticDiff(equality.Semantic.Equalities, a, b, SemanticOptions{}); got != `metadata.labels[app]: "nginx" -> "NGINX"` {
+		t.Errorf("unexpected differences: %s", got)
+	}
+	equalities := forkedreflect.EqualitiesOrDie(strings.EqualFold)
+	for typ, f := range equality.Semantic.Equalities {
+		equalities[typ] = f
+	}
+	if got := SemanticDiff(equalities, a, b, SemanticOptions{}); got != "" {
+		t.Errorf("expected no differences with case insensitive equality, got %s", got)
+	}
+}
+
+func TestSemanticDiffUnstructured(t *testing.T) {
+	a := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "a", "managedFields": []interface{}{"x"}},
+		"spec":     map[string]interface{}{"a/b": int64(1)},
+	}}
+	b := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "a"},
+		"spec":     map[string]interface{}{"a/b": int64(2)},
+	}}
+	want := `spec.a/b: 1 -> 2`
+	if got := SemanticDiff(nil, a, b, SemanticOptions{IgnorePaths: []string{"metadata.managedFields"}}); got != want {
+		t.Errorf("SemanticDiff() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dump
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces redacted strings and byte slices.
+const Redacted = "<redacted>"
+
+// sensitiveTag is the struct tag marking sensitive fields, e.g.
+// `datapolicy:"password"`. Fields with a non-empty tag are always redacted.
+const sensitiveTag = "datapolicy"
+
+// Redactor dumps objects like Pretty, OneLine and ForHash, after replacing the
+// values of sensitive types and fields with redacted values: strings and byte
+// slices are replaced by Redacted, maps keep their keys, and other scalars are
+// zeroed. Struct fields tagged with a non-empty datapolicy tag are always
+// redacted. The objects themselves are not modified.
+//
+// A Redactor is safe for concurrent use once its rules are registered.
+type Redactor struct {
+	// Strict makes dumping fail if a value which must be redacted is stored in
+	// an unexported struct field, which cannot be redacted. Otherwise such
+	// values are dumped as they are.
+	Strict bool
+
+	lock sync.RWMutex
+	// types holds the types whose values are redacted entirely.
+	types map[reflect.Type]bool
+	// fields holds the paths of the fields redacted for each struct type.
+	fields map[reflect.Type][][]string
+}
+
+// NewRedactor returns a Redactor without rules other than the datapolicy tag.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		types:  map[reflect.Type]bool{},
+		fields: map[reflect.Type][][]string{},
+	}
+}
+
+// RedactType redacts all values of the type of sample, wherever they appear.
+func (r *Redactor) RedactType(sample interface{}) *Redactor {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.types[reflect.TypeOf(sample)] = true
+	return r
+}
+
+// RedactField redacts the field at path in values of the struct type of
+// sample, e.g. RedactField(corev1.Secret{}, "Data"). path is a dot-separated
+// list of Go field names; pointers, slices and maps along the path apply it to
+// their elements. An error is returned if a field along the path does not
+// exist.
+func (r *Redactor) RedactField(sample interface{}, path string) error {
+	t := reflect.TypeOf(sample)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot redact field %q of %T, which is not a struct", path, sample)
+	}
+	fields := strings.Split(path, ".")
+	current := t
+	for _, name := range fields {
+		current = elemType(current)
+		if current.Kind() != reflect.Struct {
+			return fmt.Errorf("cannot redact field %q of %v: %v is not a struct", path, t, current)
+		}
+		field, ok := current.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("cannot redact field %q of %v: %v has no field %s", path, t, current, name)
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("cannot redact field %q of %v: field %s is not exported", path, t, name)
+		}
+		current = field.Type
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.fields[t] = append(r.fields[t], fields)
+	return nil
+}
+
+// Redact returns a redacted deep copy of a, as far as needed for redaction.
+// Parts of a which do not contain sensitive values may be shared with a. In
+// strict mode, an error is returned if sensitive values cannot be redacted.
+func (r *Redactor) Redact(a interface{}) (interface{}, error) {
+	if a == nil {
+		return nil, nil
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	v, err := r.redact(reflect.ValueOf(a), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// Pretty returns the output of the package-level Pretty for a redacted
+// copy of a. If a cannot be redacted in strict mode, the error is returned
+// instead of the dump.
+func (r *Redactor) Pretty(a interface{}) string {
+	redacted, err := r.Redact(a)
+	if err != nil {
+		return err.Error()
+	}
+	return Pretty(redacted)
+}
+
+// ForHash returns the output of the package-level ForHash for a redacted
+// copy of a. If a cannot be redacted in strict mode, the error is returned
+// instead of the dump.
+func (r *Redactor) ForHash(a interface{}) string {
+	redacted, err := r.Redact(a)
+	if err != nil {
+		return err.Error()
+	}
+	return ForHash(redacted)
+}
+
+// OneLine returns the output of the package-level OneLine for a redacted
+// copy of a. If a cannot be redacted in strict mode, the error is returned
+// instead of the dump.
+func (r *Redactor) OneLine(a interface{}) string {
+	redacted, err := r.Redact(a)
+	if err != nil {
+		return err.Error()
+	}
+	return OneLine(redacted)
+}
+
+// redact returns a copy of v with the sensitive values redacted. pending holds
+// the remaining parts of the field paths applying to v, and location describes
+// v in errors.
+func (r *Redactor) redact(v reflect.Value, pending [][]string, location string) (reflect.Value, error) {
+	t := v.Type()
+	if r.types[t] {
+		return redactAll(v), nil
+	}
+	if len(pending) == 0 && !r.mayContainSensitive(t, true, map[reflect.Type]bool{}) {
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := r.redact(v.Elem(), pending, location)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(t.Elem())
+		out.Elem().Set(elem)
+		return out, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := r.redact(v.Elem(), pending, location)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(t).Elem()
+		out.Set(elem)
+		return out, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := r.redact(v.Index(i), pending, fmt.Sprintf("%s[%d]", location, i))
+			if err != nil {
+				return v, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			elem, err := r.redact(v.Index(i), pending, fmt.Sprintf("%s[%d]", location, i))
+			if err != nil {
+				return v, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		out := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, err := r.redact(iter.Value(), pending, fmt.Sprintf("%s[%v]", location, iter.Key()))
+			if err != nil {
+				return v, err
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		return out, nil
+	case reflect.Struct:
+		pending = append(pending[:len(pending):len(pending)], r.fields[t]...)
+		// copy all fields, including unexported ones, before replacing the
+		// exported ones
+		out := reflect.New(t).Elem()
+		out.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldLocation := location + "." + field.Name
+			if !field.IsExported() {
+				if r.Strict && r.mayContainSensitive(field.Type, false, map[reflect.Type]bool{}) {
+					return v, fmt.Errorf("cannot redact unexported field %s of type %v", fieldLocation, field.Type)
+				}
+				continue
+			}
+			redactField := len(field.Tag.Get(sensitiveTag)) > 0
+			var fieldPending [][]string
+			for _, path := range pending {
+				if path[0] != field.Name {
+					continue
+				}
+				if len(path) == 1 {
+					redactField = true
+				} else {
+					fieldPending = append(fieldPending, path[1:])
+				}
+			}
+			if redactField {
+				out.Field(i).Set(redactAll(v.Field(i)))
+				continue
+			}
+			redacted, err := r.redact(v.Field(i), fieldPending, fieldLocation)
+			if err != nil {
+				return v, err
+			}
+			out.Field(i).Set(redacted)
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// mayContainSensitive returns true if values of type t may contain values to
+// redact. If dynamic is true, interfaces are assumed to possibly contain
+// sensitive values.
+func (r *Redactor) mayContainSensitive(t reflect.Type, dynamic bool, seen map[reflect.Type]bool) bool {
+	if r.types[t] {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return dynamic
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return r.mayContainSensitive(t.Elem(), dynamic, seen)
+	case reflect.Map:
+		return r.mayContainSensitive(t.Key(), dynamic, seen) || r.mayContainSensitive(t.Elem(), dynamic, seen)
+	case reflect.Struct:
+		if len(r.fields[t]) > 0 {
+			return true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if len(field.Tag.Get(sensitiveTag)) > 0 || r.mayContainSensitive(field.Type, dynamic, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactAll returns a copy of v with all strings and byte slices replaced by
+// Redacted, and all other scalars zeroed. Map keys are kept.
+func redactAll(v reflect.Value) reflect.Value {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(Redacted).Convert(t)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(t.Elem())
+		out.Elem().Set(redactAll(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(t).Elem()
+		out.Set(redactAll(v.Elem()))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		if t.Elem() == reflect.TypeOf(byte(0)) {
+			return reflect.ValueOf([]byte(Redacted)).Convert(t)
+		}
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactAll(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactAll(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), redactAll(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		// unexported fields are left zero, since they cannot be set
+		out := reflect.New(t).Elem()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				out.Field(i).Set(redactAll(v.Field(i)))
+			}
+		}
+		return out
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+// elemType returns the type reached by dereferencing pointers, slices, arrays
+// and maps from t.
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
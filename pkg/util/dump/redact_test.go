@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dump
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testSecret struct {
+	Name string
+	Data map[string][]byte
+	Spec *testSpec
+}
+
+type testSpec struct {
+	User     string
+	Password string
+	Port     int
+}
+
+type testToken string
+
+type testConfig struct {
+	Name       string
+	Token      testToken
+	Key        string `datapolicy:"security-key"`
+	Secrets    []testSecret
+	Extra      interface{}
+	credential testToken
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor().RedactType(testToken(""))
+	if err := r.RedactField(testSecret{}, "Data"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RedactField(&testSecret{}, "Spec.Password"); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := testSecret{
+		Name: "creds",
+		Data: map[string][]byte{"password": []byte("hunter2")},
+		Spec: &testSpec{User: "admin", Password: "hunter2", Port: 22},
+	}
+	config := testConfig{
+		Name:    "config",
+		Token:   "t0ken",
+		Key:     "s3cret",
+		Secrets: []testSecret{secret},
+		Extra:   &secret,
+	}
+
+	redacted, err := r.Redact(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactedSecret := testSecret{
+		Name: "creds",
+		Data: map[string][]byte{"password": []byte(Redacted)},
+		Spec: &testSpec{User: "admin", Password: Redacted, Port: 22},
+	}
+	expected := testConfig{
+		Name:    "config",
+		Token:   Redacted,
+		Key:     Redacted,
+		Secrets: []testSecret{redactedSecret},
+		Extra:   &redactedSecret,
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("expected %s, got %s", Pretty(expected), Pretty(redacted))
+	}
+	if string(secret.Data["password"]) != "hunter2" || secret.Spec.Password != "hunter2" {
+		t.Errorf("expected the original object not to be modified")
+	}
+
+	for name, dump := range map[string]string{
+		"Pretty":  r.Pretty(config),
+		"OneLine": r.OneLine(config),
+		"ForHash": r.ForHash(config),
+	} {
+		for _, leaked := range []string{"hunter2", "t0ken", "s3cret"} {
+			if strings.Contains(dump, leaked) {
+				t.Errorf("%s leaked %q: %s", name, leaked, dump)
+			}
+		}
+		if !strings.Contains(dump, "admin") {
+			t.Errorf("%s redacted too much: %s", name, dump)
+		}
+	}
+}
+
+func TestRedactorUnchanged(t *testing.T) {
+	r := NewRedactor()
+	obj := &testSpec{User: "admin", Password: "hunter2"}
+	if r.Pretty(obj) != Pretty(obj) || r.ForHash(obj) != ForHash(obj) {
+		t.Errorf("expected objects without sensitive values to be dumped unchanged")
+	}
+	if redacted, err := r.Redact(nil); redacted != nil || err != nil {
+		t.Errorf("expected nil, got %v, %v", redacted, err)
+	}
+}
+
+func TestRedactorStrict(t *testing.T) {
+	config := testConfig{Name: "config", credential: "t0ken"}
+
+	r := NewRedactor().RedactType(testToken(""))
+	if dump := r.Pretty(config); !strings.Contains(dump, "t0ken") {
+		t.Errorf("expected unexported fields to be dumped as they are: %s", dump)
+	}
+
+	r.Strict = true
+	if _, err := r.Redact(config); err == nil || !strings.Contains(err.Error(), ".credential") {
+		t.Errorf("expected an error for an unexported sensitive field, got %v", err)
+	}
+	if dump := r.Pretty(config); strings.Contains(dump, "t0ken") {
+		t.Errorf("expected strict mode not to leak unexported fields: %s", dump)
+	}
+}
+
+func TestRedactFieldErrors(t *testing.T) {
+	r := NewRedactor()
+	for _, tc := range []struct {
+		sample interface{}
+		path   string
+	}{
+		{"string", "Data"},
+		{testSecret{}, "Missing"},
+		{testSecret{}, "Name.Length"},
+		{testConfig{}, "credential"},
+	} {
+		if err := r.RedactField(tc.sample, tc.path); err == nil {
+			t.Errorf("expected an error redacting %q of %T", tc.path, tc.sample)
+		}
+	}
+}
@@ -18,6 +18,9 @@ package duration
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -91,3 +94,114 @@ func HumanDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%dy", int(hours/24/365))
 }
+
+// Style is the style of the durations formatted by HumanDurationWithOptions.
+type Style int
+
+const (
+	// StyleCompact abbreviates units, e.g. "1h30m". Unlike HumanDuration, which
+	// formats the same duration as "90m", every unit from the largest non-zero
+	// one is used.
+	StyleCompact Style = iota
+	// StyleVerbose spells out units, e.g. "1 hour 30 minutes".
+	StyleVerbose
+)
+
+// FormatOptions controls how HumanDurationWithOptions formats durations.
+type FormatOptions struct {
+	// MaxUnits is the maximum number of units, starting from the largest
+	// non-zero one, e.g. 2 for "1h30m" and 1 for "1h". Units whose value is
+	// zero are omitted but still count towards the maximum. Zero means that
+	// all units down to seconds are included.
+	MaxUnits int
+	// Style is the style of the formatted duration.
+	Style Style
+	// Round rounds the duration to the smallest included unit, halves away
+	// from zero, instead of truncating it.
+	Round bool
+}
+
+// formatUnits are the units of formatted durations, from the largest.
+var formatUnits = []struct {
+	d       time.Duration
+	compact string
+	verbose string
+}{
+	{Year, "y", "year"},
+	{Day, "d", "day"},
+	{time.Hour, "h", "hour"},
+	{time.Minute, "m", "minute"},
+	{time.Second, "s", "second"},
+}
+
+// HumanDurationWithOptions returns a representation of the provided duration
+// for consumption by humans, with the precision and style given by opts. Like
+// HumanDuration, it uses 24 hour days and 365 day years, returns "0s" for
+// durations slightly below zero, and "<invalid>" for negative durations below
+// that.
+func HumanDurationWithOptions(d time.Duration, opts FormatOptions) string {
+	if seconds := int(d.Seconds()); seconds < -1 {
+		return "<invalid>"
+	} else if d < 0 {
+		d = 0
+	}
+
+	first := largestUnit(d)
+	last := len(formatUnits) - 1
+	if opts.MaxUnits > 0 && first+opts.MaxUnits-1 < last {
+		last = first + opts.MaxUnits - 1
+	}
+	smallest := formatUnits[last].d
+	if opts.Round && d%smallest >= smallest/2 && d <= math.MaxInt64-smallest {
+		d += smallest
+	}
+	d -= d % smallest
+	if rounded := largestUnit(d); rounded < first {
+		// rounding carried into a larger unit, which can only leave zeros in
+		// the units after it
+		first = rounded
+	}
+
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		unit := formatUnits[i]
+		n := d / unit.d
+		d -= n * unit.d
+		if n == 0 {
+			continue
+		}
+		writeUnit(&b, int64(n), unit.compact, unit.verbose, opts.Style)
+	}
+	if b.Len() == 0 {
+		writeUnit(&b, 0, "s", "second", opts.Style)
+	}
+	return b.String()
+}
+
+// largestUnit returns the index of the largest unit in formatUnits not larger
+// than d, or the index of seconds if d is less than a second.
+func largestUnit(d time.Duration) int {
+	for i, unit := range formatUnits {
+		if d >= unit.d {
+			return i
+		}
+	}
+	return len(formatUnits) - 1
+}
+
+func writeUnit(b *strings.Builder, n int64, compact, verbose string, style Style) {
+	if style == StyleVerbose {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatInt(n, 10))
+		b.WriteByte(' ')
+		b.WriteString(verbose)
+		if n != 1 {
+			b.WriteByte('s')
+		}
+		return
+	}
+	b.WriteString(strconv.FormatInt(n, 10))
+	b.WriteString(compact)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package duration
 
 import (
+	"math"
 	"testing"
 	"time"
 )
@@ -131,3 +132,40 @@ func TestShortHumanDurationBoundaries(t *testing.T) {
 		})
 	}
 }
+
+func TestHumanDurationWithOptions(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		opts FormatOptions
+		want string
+	}{
+		{d: 90 * time.Minute, opts: FormatOptions{}, want: "1h30m"},
+		{d: 90*time.Minute + 5*time.Second, opts: FormatOptions{}, want: "1h30m5s"},
+		{d: 90*time.Minute + 5*time.Second, opts: FormatOptions{MaxUnits: 2}, want: "1h30m"},
+		{d: 90 * time.Minute, opts: FormatOptions{MaxUnits: 1}, want: "1h"},
+		{d: 90 * time.Minute, opts: FormatOptions{MaxUnits: 1, Round: true}, want: "2h"},
+		{d: 89 * time.Minute, opts: FormatOptions{MaxUnits: 1, Round: true}, want: "1h"},
+		{d: time.Hour + 5*time.Second, opts: FormatOptions{MaxUnits: 2}, want: "1h"},
+		{d: time.Hour + 5*time.Second, opts: FormatOptions{MaxUnits: 3}, want: "1h5s"},
+		{d: 59*time.Minute + 59*time.Second, opts: FormatOptions{MaxUnits: 1, Round: true}, want: "1h"},
+		{d: 23*time.Hour + 59*time.Minute + 30*time.Second, opts: FormatOptions{MaxUnits: 2, Round: true}, want: "1d"},
+		{d: Year + 2*Day, opts: FormatOptions{MaxUnits: 2}, want: "1y2d"},
+		{d: 500 * time.Millisecond, opts: FormatOptions{}, want: "0s"},
+		{d: 500 * time.Millisecond, opts: FormatOptions{Round: true}, want: "1s"},
+		{d: 0, opts: FormatOptions{Style: StyleVerbose}, want: "0 seconds"},
+		{d: -time.Second, opts: FormatOptions{}, want: "0s"},
+		{d: -2 * time.Second, opts: FormatOptions{}, want: "<invalid>"},
+		{d: 90 * time.Minute, opts: FormatOptions{Style: StyleCompact, MaxUnits: 2}, want: "1h30m"},
+		{d: 150 * time.Minute, opts: FormatOptions{Style: StyleCompact}, want: "2h30m"},
+		{d: 90 * time.Minute, opts: FormatOptions{Style: StyleVerbose}, want: "1 hour 30 minutes"},
+		{d: 2*Day + time.Second, opts: FormatOptions{Style: StyleVerbose}, want: "2 days 1 second"},
+		{d: math.MaxInt64, opts: FormatOptions{MaxUnits: 1, Round: true}, want: "292y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := HumanDurationWithOptions(tt.d, tt.opts); got != tt.want {
+				t.Errorf("HumanDurationWithOptions(%v, %+v) = %v, want %v", tt.d, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package duration
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Day is the duration of a day, as parsed and formatted by this package.
+	Day = 24 * time.Hour
+	// Week is the duration of a week, as parsed by this package.
+	Week = 7 * Day
+	// Year is the duration of a year, as parsed and formatted by this package.
+	Year = 365 * Day
+)
+
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond, // U+00B5 = micro symbol
+	"μs": time.Microsecond, // U+03BC = Greek letter mu
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+	"w":  Week,
+	"y":  Year,
+}
+
+// strictUnits are the units accepted by ParseHumanDurationStrict, in the order
+// they must appear.
+var strictUnits = []string{"y", "d", "h", "m", "s"}
+
+// ParseHumanDuration parses a duration string like time.ParseDuration, such as
+// "300ms", "-1.5h" or "2h45m", additionally accepting the units "d" for days,
+// "w" for weeks and "y" for years. A day is always 24 hours, a week 7 days,
+// and a year 365 days, as in the output of HumanDuration, so "2d" is 48 hours.
+func ParseHumanDuration(s string) (time.Duration, error) {
+	return parseHumanDuration(s, false)
+}
+
+// ParseHumanDurationStrict parses a duration string in the format emitted by
+// HumanDuration and ShortHumanDuration, such as "3m10s", "2d1h" or "8y". The
+// string must be a sequence of non-negative integers, each followed by one of
+// the units "y", "d", "h", "m" or "s", with each unit used at most once and in
+// that order.
+func ParseHumanDurationStrict(s string) (time.Duration, error) {
+	return parseHumanDuration(s, true)
+}
+
+func parseHumanDuration(s string, strict bool) (time.Duration, error) {
+	orig := s
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	neg := false
+	if !strict && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	// "0" is the only duration without a unit
+	if !strict && s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	var total uint64
+	nextStrictUnit := 0
+	for s != "" {
+		// the integer part
+		i := 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		intPart, fracPart := s[:i], ""
+		s = s[i:]
+		if !strict && s != "" && s[0] == '.' {
+			i = 1
+			for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+				i++
+			}
+			fracPart = s[1:i]
+			s = s[i:]
+		}
+		if intPart == "" && fracPart == "" {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		// the unit
+		i = 0
+		for i < len(s) && s[i] != '.' && (s[i] < '0' || s[i] > '9') {
+			i++
+		}
+		u := s[:i]
+		s = s[i:]
+		if u == "" {
+			return 0, fmt.Errorf("missing unit in duration %q", orig)
+		}
+		unit, ok := units[u]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in duration %q", u, orig)
+		}
+		if strict {
+			for nextStrictUnit < len(strictUnits) && strictUnits[nextStrictUnit] != u {
+				nextStrictUnit++
+			}
+			if nextStrictUnit == len(strictUnits) {
+				return 0, fmt.Errorf("unexpected unit %q in duration %q", u, orig)
+			}
+			nextStrictUnit++
+		}
+
+		v, ok := componentValue(intPart, fracPart, uint64(unit))
+		if !ok || total+v < total || total+v > 1<<63 {
+			return 0, fmt.Errorf("invalid duration %q: overflow", orig)
+		}
+		total += v
+	}
+
+	if neg {
+		return -time.Duration(total), nil
+	}
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: overflow", orig)
+	}
+	return time.Duration(total), nil
+}
+
+// componentValue returns the number of nanoseconds of a component with the
+// given integer and fractional digits in unit, or false on overflow.
+func componentValue(intPart, fracPart string, unit uint64) (uint64, bool) {
+	var v uint64
+	for _, c := range intPart {
+		if v > (1<<63)/10 {
+			return 0, false
+		}
+		v = v*10 + uint64(c-'0')
+		if v > 1<<63 {
+			return 0, false
+		}
+	}
+	if v > (1<<63)/unit {
+		return 0, false
+	}
+	v *= unit
+
+	// the fraction is accumulated until further digits cannot contribute
+	var f, scale uint64 = 0, 1
+	for _, c := range fracPart {
+		if f > (1<<63)/10 || scale > (1<<63)/10 {
+			break
+		}
+		f = f*10 + uint64(c-'0')
+		scale *= 10
+	}
+	if f > 0 {
+		v += uint64(float64(f) * (float64(unit) / float64(scale)))
+		if v > 1<<63 {
+			return 0, false
+		}
+	}
+	return v, true
+}
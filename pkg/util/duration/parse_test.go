@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHumanDuration(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{s: "0", want: 0},
+		{s: "0s", want: 0},
+		{s: "300ms", want: 300 * time.Millisecond},
+		{s: "-1.5h", want: -90 * time.Minute},
+		{s: "+2h45m", want: 2*time.Hour + 45*time.Minute},
+		{s: "2d", want: 48 * time.Hour},
+		{s: "1w", want: 7 * 24 * time.Hour},
+		{s: "1y", want: 365 * 24 * time.Hour},
+		{s: "1.5d", want: 36 * time.Hour},
+		{s: "1y2w3d4h5m6s", want: Year + 2*Week + 3*Day + 4*time.Hour + 5*time.Minute + 6*time.Second},
+		{s: "1h1h", want: 2 * time.Hour},
+		{s: ".5m", want: 30 * time.Second},
+		{s: "1µs1us1μs", want: 3 * time.Microsecond},
+		{s: "", wantErr: true},
+		{s: "-", wantErr: true},
+		{s: "5", wantErr: true},
+		{s: "d", wantErr: true},
+		{s: ".d", wantErr: true},
+		{s: "1x", wantErr: true},
+		{s: "1 d", wantErr: true},
+		{s: "300y", wantErr: true},
+		{s: "9223372036854775808ns", wantErr: true},
+		{s: "-9223372036854775808ns", want: time.Duration(-1 << 63)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseHumanDuration(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHumanDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHumanDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHumanDurationStrict(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{s: "0s", want: 0},
+		{s: "3m10s", want: 190 * time.Second},
+		{s: "2d1h", want: 49 * time.Hour},
+		{s: "2y1d", want: 2*Year + Day},
+		{s: "0", wantErr: true},
+		{s: "-1s", wantErr: true},
+		{s: "1.5h", wantErr: true},
+		{s: "1w", wantErr: true},
+		{s: "1ms", wantErr: true},
+		{s: "1h2d", wantErr: true},
+		{s: "1h1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseHumanDurationStrict(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHumanDurationStrict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHumanDurationStrict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHumanDurationRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{
+		time.Second,
+		190 * time.Second,
+		70 * time.Minute,
+		49 * time.Hour,
+		(8*24 + 2) * time.Hour,
+		(365*2*24 + 25) * time.Hour,
+	} {
+		for _, s := range []string{HumanDuration(d), ShortHumanDuration(d)} {
+			if _, err := ParseHumanDurationStrict(s); err != nil {
+				t.Errorf("Failed to parse %q: %v", s, err)
+			}
+		}
+	}
+	if got, err := ParseHumanDurationStrict(HumanDuration(49 * time.Hour)); err != nil || got != 49*time.Hour {
+		t.Errorf("Expected 49h, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	cborMajorTypeUnsignedInt = iota
+	cborMajorTypeNegativeInt
+	cborMajorTypeByteString
+	cborMajorTypeTextString
+	cborMajorTypeArray
+	cborMajorTypeMap
+	cborMajorTypeTag
+	cborMajorTypeSimple
+
+	// cborIndefiniteLength is the additional information value of items of
+	// indefinite length.
+	cborIndefiniteLength = 31
+	// cborBreak terminates items of indefinite length.
+	cborBreak = 0xff
+
+	// cborMaxNestingDepth bounds the nesting of arrays, maps and tags.
+	cborMaxNestingDepth = 10000
+	// cborMaxContainerLength bounds the number of elements of arrays and the
+	// number of pairs of maps.
+	cborMaxContainerLength = 1 << 32
+)
+
+var errCBORUnexpectedBreak = errors.New("unexpected CBOR break")
+
+type cborSequenceFrameReader struct {
+	r            io.ReadCloser
+	br           *bufio.Reader
+	maxFrameSize int
+	// frame holds the encoded item being read.
+	frame     bytes.Buffer
+	remaining []byte
+}
+
+// NewCBORSequenceFrameReader returns an io.Reader that will split a CBOR
+// Sequence (RFC 8742) into its top-level data items. Items are delimited by
+// walking their headers, without decoding them.
+//
+// Reads behave as for NewJSONFramedReader: if the buffer passed to Read is not
+// long enough to contain an entire item, io.ErrShortBuffer is returned and
+// subsequent calls return the rest of the item. A malformed item terminates
+// the read.
+func NewCBORSequenceFrameReader(r io.ReadCloser) io.ReadCloser {
+	return NewCBORSequenceFrameReaderWithLimit(r, 0)
+}
+
+// NewCBORSequenceFrameReaderWithLimit is like NewCBORSequenceFrameReader, but
+// returns a *FrameTooLargeError once an item exceeds maxFrameSize bytes. Zero
+// means no limit. The stream cannot be read further after an item was rejected.
+func NewCBORSequenceFrameReaderWithLimit(r io.ReadCloser, maxFrameSize int) io.ReadCloser {
+	return &cborSequenceFrameReader{r: r, br: bufio.NewReader(r), maxFrameSize: maxFrameSize}
+}
+
+// Read attempts to read an entire CBOR data item into data. If that is not
+// possible, io.ErrShortBuffer is returned and subsequent calls will return the
+// rest of the item. An item is complete when err is nil.
+func (r *cborSequenceFrameReader) Read(data []byte) (int, error) {
+	if len(r.remaining) == 0 {
+		r.frame.Reset()
+		if _, err := r.br.Peek(1); err != nil {
+			return 0, err
+		}
+		if err := r.readItem(0); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		r.remaining = r.frame.Bytes()
+	}
+
+	n := copy(data, r.remaining)
+	r.remaining = r.remaining[n:]
+	if len(r.remaining) > 0 {
+		return n, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+func (r *cborSequenceFrameReader) Close() error {
+	return r.r.Close()
+}
+
+// readItem appends the next complete data item to the frame.
+func (r *cborSequenceFrameReader) readItem(depth int) error {
+	if depth > cborMaxNestingDepth {
+		return fmt.Errorf("CBOR item exceeds maximum nesting depth of %d", cborMaxNestingDepth)
+	}
+	b, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	major, info := b>>5, b&0x1f
+
+	if info == cborIndefiniteLength {
+		switch major {
+		case cborMajorTypeByteString, cborMajorTypeTextString:
+			// chunks are definite length strings of the same major type
+			for {
+				chunk, err := r.peekByte()
+				if err != nil {
+					return err
+				}
+				if chunk == cborBreak {
+					_, err := r.readByte()
+					return err
+				}
+				if chunk>>5 != major || chunk&0x1f == cborIndefiniteLength {
+					return fmt.Errorf("invalid chunk 0x%x in indefinite length CBOR string", chunk)
+				}
+				if err := r.readItem(depth + 1); err != nil {
+					return err
+				}
+			}
+		case cborMajorTypeArray, cborMajorTypeMap:
+			for {
+				next, err := r.peekByte()
+				if err != nil {
+					return err
+				}
+				if next == cborBreak {
+					_, err := r.readByte()
+					return err
+				}
+				if err := r.readItem(depth + 1); err != nil {
+					return err
+				}
+				if major == cborMajorTypeMap {
+					if err := r.readItem(depth + 1); err != nil {
+						return err
+					}
+				}
+			}
+		case cborMajorTypeSimple:
+			return errCBORUnexpectedBreak
+		default:
+			return fmt.Errorf("invalid indefinite length for CBOR major type %d", major)
+		}
+	}
+
+	arg, err := r.readArgument(info)
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborMajorTypeByteString, cborMajorTypeTextString:
+		return r.readN(arg)
+	case cborMajorTypeArray, cborMajorTypeMap:
+		if arg > cborMaxContainerLength {
+			return fmt.Errorf("CBOR item of %d elements exceeds the maximum length of %d", arg, cborMaxContainerLength)
+		}
+		items := arg
+		if major == cborMajorTypeMap {
+			// each pair is two items
+			items *= 2
+		}
+		// every item takes at least one byte
+		if err := r.checkLimit(items); err != nil {
+			return err
+		}
+		for i := uint64(0); i < items; i++ {
+			if err := r.readItem(depth + 1); err != nil {
+				return err
+			}
+		}
+	case cborMajorTypeTag:
+		return r.readItem(depth + 1)
+	}
+	// integers, simple values and floats are fully described by their argument
+	return nil
+}
+
+// readArgument reads the argument encoded by the additional information of an
+// item header.
+func (r *cborSequenceFrameReader) readArgument(info byte) (uint64, error) {
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, fmt.Errorf("invalid CBOR additional information %d", info)
+	}
+	start := r.frame.Len()
+	if err := r.readN(uint64(size)); err != nil {
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[8-size:], r.frame.Bytes()[start:])
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (r *cborSequenceFrameReader) checkLimit(n uint64) error {
+	if r.maxFrameSize > 0 && uint64(r.frame.Len())+n > uint64(r.maxFrameSize) {
+		return &FrameTooLargeError{Limit: r.maxFrameSize}
+	}
+	return nil
+}
+
+func (r *cborSequenceFrameReader) readByte() (byte, error) {
+	if err := r.checkLimit(1); err != nil {
+		return 0, err
+	}
+	b, err := r.br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.frame.WriteByte(b)
+	return b, nil
+}
+
+func (r *cborSequenceFrameReader) peekByte() (byte, error) {
+	b, err := r.br.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *cborSequenceFrameReader) readN(n uint64) error {
+	if err := r.checkLimit(n); err != nil {
+		return err
+	}
+	// CopyN grows the frame as data arrives, so a bogus length fails on EOF
+	// rather than on allocation.
+	if n > 1<<62 {
+		n = 1 << 62
+	}
+	copied, err := io.CopyN(&r.frame, r.br, int64(n))
+	if err == io.EOF || (err == nil && uint64(copied) != n) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCBORSequenceFrameReader(t *testing.T) {
+	// examples from RFC 8949 Appendix A
+	items := []string{
+		"00",                 // 0
+		"1903e8",             // 1000
+		"1bffffffffffffffff", // 18446744073709551615
+		"3903e7",             // -1000
+		"f97c00",             // Infinity
+		"fb7e37e43c8800759c", // 1.0e+300
+		"f4",                 // false
+		"f8ff",               // simple(255)
+		"c074323031332d30332d32315432303a30343a30305a", // 0("2013-03-21T20:04:00Z")
+		"4401020304",                 // h'01020304'
+		"6449455446",                 // "IETF"
+		"80",                         // []
+		"8301820203820405",           // [1, [2, 3], [4, 5]]
+		"a201020304",                 // {1: 2, 3: 4}
+		"a26161016162820203",         // {"a": 1, "b": [2, 3]}
+		"5f42010243030405ff",         // (_ h'0102', h'030405')
+		"7f657374726561646d696e67ff", // (_ "strea", "ming")
+		"9f018202039f0405ffff",       // [_ 1, [2, 3], [_ 4, 5]]
+		"bf61610161629f0203ffff",     // {_ "a": 1, "b": [_ 2, 3]}
+		"d9d9f7a0",                   // 55799({})
+	}
+	var stream []byte
+	for _, item := range items {
+		b, err := hex.DecodeString(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = append(stream, b...)
+	}
+
+	r := NewCBORSequenceFrameReader(io.NopCloser(bytes.NewReader(stream)))
+	for _, item := range items {
+		buf := make([]byte, 64)
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("item %s: unexpected error: %v", item, err)
+		}
+		if got := hex.EncodeToString(buf[:n]); got != item {
+			t.Fatalf("expected item %s, got %s", item, got)
+		}
+	}
+	if n, err := r.Read(make([]byte, 1)); err != io.EOF || n != 0 {
+		t.Fatalf("unexpected: %v %d", err, n)
+	}
+}
+
+func TestCBORSequenceFrameReaderShortBuffer(t *testing.T) {
+	stream := []byte{0x83, 0x01, 0x02, 0x03, 0x00}
+	r := NewCBORSequenceFrameReader(io.NopCloser(bytes.NewReader(stream)))
+	buf := make([]byte, 3)
+	if n, err := r.Read(buf); err != io.ErrShortBuffer || n != 3 || !bytes.Equal(buf, []byte{0x83, 0x01, 0x02}) {
+		t.Fatalf("unexpected: %v %d %v", err, n, buf)
+	}
+	if n, err := r.Read(buf); err != nil || n != 1 || buf[0] != 0x03 {
+		t.Fatalf("unexpected: %v %d %v", err, n, buf)
+	}
+	if n, err := r.Read(buf); err != nil || n != 1 || buf[0] != 0x00 {
+		t.Fatalf("unexpected: %v %d %v", err, n, buf)
+	}
+}
+
+func TestCBORSequenceFrameReaderInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{name: "truncated argument", data: "19e8", err: io.ErrUnexpectedEOF},
+		{name: "truncated string", data: "440102", err: io.ErrUnexpectedEOF},
+		{name: "truncated array", data: "830102", err: io.ErrUnexpectedEOF},
+		{name: "missing break", data: "9f0102", err: io.ErrUnexpectedEOF},
+		{name: "huge string length", data: "5bffffffffffffffff00", err: io.ErrUnexpectedEOF},
+		{name: "huge map length", data: "bbffffffffffffffff00"},
+		{name: "huge array length", data: "9b000000010000000100"},
+		{name: "unexpected break", data: "ff"},
+		{name: "reserved additional information", data: "1c"},
+		{name: "indefinite length integer", data: "1f"},
+		{name: "invalid string chunk", data: "5f6161ff"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := NewCBORSequenceFrameReader(io.NopCloser(bytes.NewReader(data)))
+			_, err = r.Read(make([]byte, 64))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tc.err != nil && err != tc.err {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCBORSequenceFrameReaderLimit(t *testing.T) {
+	stream := []byte{0x82, 0x01, 0x02, 0x5a, 0x00, 0x10, 0x00, 0x00}
+	r := NewCBORSequenceFrameReaderWithLimit(io.NopCloser(bytes.NewReader(stream)), 5)
+	buf := make([]byte, 8)
+	if n, err := r.Read(buf); err != nil || n != 3 {
+		t.Fatalf("unexpected: %v %d", err, n)
+	}
+	// the declared length is rejected before the string is read
+	if _, err := r.Read(buf); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+
+	// maps whose pairs cannot fit are rejected before they are read
+	r = NewCBORSequenceFrameReaderWithLimit(io.NopCloser(bytes.NewReader([]byte{0xa3, 0x01, 0x02})), 5)
+	if _, err := r.Read(buf); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
@@ -18,11 +18,40 @@ limitations under the License.
 package framer
 
 import (
+	"bufio"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 )
 
+// ErrFrameTooLarge is matched by errors.Is for the errors returned by readers
+// created with a maximum frame size when a frame exceeds it.
+var ErrFrameTooLarge = errors.New("frame exceeds maximum size")
+
+// FrameTooLargeError is returned when a frame exceeds the maximum frame size of
+// a reader.
+type FrameTooLargeError struct {
+	// Limit is the maximum frame size in bytes.
+	Limit int
+	// Size is the size of the frame in bytes, or zero if it is not known.
+	Size uint64
+}
+
+func (e *FrameTooLargeError) Error() string {
+	if e.Size > 0 {
+		return fmt.Sprintf("frame of %d bytes exceeds maximum size of %d bytes", e.Size, e.Limit)
+	}
+	return fmt.Sprintf("frame exceeds maximum size of %d bytes", e.Limit)
+}
+
+// Unwrap returns ErrFrameTooLarge.
+func (e *FrameTooLargeError) Unwrap() error {
+	return ErrFrameTooLarge
+}
+
 type lengthDelimitedFrameWriter struct {
 	w io.Writer
 	h [4]byte
@@ -46,9 +75,39 @@ func (w *lengthDelimitedFrameWriter) Write(data []byte) (int, error) {
 	return w.w.Write(data)
 }
 
+type varintLengthDelimitedFrameWriter struct {
+	w io.Writer
+	h [binary.MaxVarintLen64]byte
+}
+
+// NewVarintLengthDelimitedFrameWriter returns an io.Writer that writes each call
+// to Write as a single frame, prefixed with its length encoded as a protobuf
+// style unsigned varint.
+func NewVarintLengthDelimitedFrameWriter(w io.Writer) io.Writer {
+	return &varintLengthDelimitedFrameWriter{w: w}
+}
+
+// Write writes a single frame to the nested writer, prepending it with the length
+// in bytes of data (as an unsigned varint).
+func (w *varintLengthDelimitedFrameWriter) Write(data []byte) (int, error) {
+	l := binary.PutUvarint(w.h[:], uint64(len(data)))
+	n, err := w.w.Write(w.h[:l])
+	if err != nil {
+		return 0, err
+	}
+	if n != l {
+		return 0, io.ErrShortWrite
+	}
+	return w.w.Write(data)
+}
+
 type lengthDelimitedFrameReader struct {
-	r         io.ReadCloser
-	remaining int
+	r io.Reader
+	c io.Closer
+	// readLength reads the length prefix of the next frame from r.
+	readLength   func(r io.Reader) (uint64, error)
+	maxFrameSize int
+	remaining    int
 }
 
 // NewLengthDelimitedFrameReader returns an io.Reader that will decode length-prefixed
@@ -64,7 +123,56 @@ type lengthDelimitedFrameReader struct {
 // If the buffer passed to Read is not long enough to contain an entire frame, io.ErrShortRead
 // will be returned along with the number of bytes read.
 func NewLengthDelimitedFrameReader(r io.ReadCloser) io.ReadCloser {
-	return &lengthDelimitedFrameReader{r: r}
+	return NewLengthDelimitedFrameReaderWithLimit(r, 0)
+}
+
+// NewLengthDelimitedFrameReaderWithLimit is like NewLengthDelimitedFrameReader,
+// but returns a *FrameTooLargeError for frames larger than maxFrameSize bytes
+// before reading them. Zero means no limit. The stream cannot be read further
+// after a frame was rejected.
+func NewLengthDelimitedFrameReaderWithLimit(r io.ReadCloser, maxFrameSize int) io.ReadCloser {
+	return &lengthDelimitedFrameReader{r: r, c: r, readLength: readFixedLength, maxFrameSize: maxFrameSize}
+}
+
+// NewVarintLengthDelimitedFrameReader returns an io.Reader that will decode
+// frames prefixed with their length as a protobuf style unsigned varint, as
+// written by NewVarintLengthDelimitedFrameWriter.
+//
+// The protocol is:
+//
+//	stream: message ...
+//	message: prefix body
+//	prefix: unsigned varint, denotes length of body
+//	body: bytes (0..prefix)
+//
+// Reads behave as for NewLengthDelimitedFrameReader.
+func NewVarintLengthDelimitedFrameReader(r io.ReadCloser) io.ReadCloser {
+	return NewVarintLengthDelimitedFrameReaderWithLimit(r, 0)
+}
+
+// NewVarintLengthDelimitedFrameReaderWithLimit is like
+// NewVarintLengthDelimitedFrameReader, but returns a *FrameTooLargeError for
+// frames larger than maxFrameSize bytes before reading them. Zero means no
+// limit. The stream cannot be read further after a frame was rejected.
+func NewVarintLengthDelimitedFrameReaderWithLimit(r io.ReadCloser, maxFrameSize int) io.ReadCloser {
+	return &lengthDelimitedFrameReader{r: bufio.NewReader(r), c: r, readLength: readVarintLength, maxFrameSize: maxFrameSize}
+}
+
+func readFixedLength(r io.Reader) (uint64, error) {
+	header := [4]byte{}
+	n, err := io.ReadAtLeast(r, header[:4], 4)
+	if err != nil {
+		return 0, err
+	}
+	if n != 4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return uint64(binary.BigEndian.Uint32(header[:])), nil
+}
+
+func readVarintLength(r io.Reader) (uint64, error) {
+	// r is always a *bufio.Reader, see NewVarintLengthDelimitedFrameReaderWithLimit.
+	return binary.ReadUvarint(r.(io.ByteReader))
 }
 
 // Read attempts to read an entire frame into data. If that is not possible, io.ErrShortBuffer
@@ -72,16 +180,18 @@ func NewLengthDelimitedFrameReader(r io.ReadCloser) io.ReadCloser {
 // err is nil.
 func (r *lengthDelimitedFrameReader) Read(data []byte) (int, error) {
 	if r.remaining <= 0 {
-		header := [4]byte{}
-		n, err := io.ReadAtLeast(r.r, header[:4], 4)
+		frameLength, err := r.readLength(r.r)
 		if err != nil {
 			return 0, err
 		}
-		if n != 4 {
-			return 0, io.ErrUnexpectedEOF
+		limit := r.maxFrameSize
+		if limit <= 0 {
+			limit = math.MaxInt
+		}
+		if frameLength > uint64(limit) {
+			return 0, &FrameTooLargeError{Limit: limit, Size: frameLength}
 		}
-		frameLength := int(binary.BigEndian.Uint32(header[:]))
-		r.remaining = frameLength
+		r.remaining = int(frameLength)
 	}
 
 	expect := r.remaining
@@ -105,13 +215,15 @@ func (r *lengthDelimitedFrameReader) Read(data []byte) (int, error) {
 }
 
 func (r *lengthDelimitedFrameReader) Close() error {
-	return r.r.Close()
+	return r.c.Close()
 }
 
 type jsonFrameReader struct {
 	r         io.ReadCloser
 	decoder   *json.Decoder
 	remaining []byte
+	// limiter is set if the frame size is limited.
+	limiter *frameLimitReader
 }
 
 // NewJSONFramedReader returns an io.Reader that will decode individual JSON objects off
@@ -126,6 +238,24 @@ func NewJSONFramedReader(r io.ReadCloser) io.ReadCloser {
 	}
 }
 
+// NewJSONFramedReaderWithLimit is like NewJSONFramedReader, but returns a
+// *FrameTooLargeError once a JSON object exceeds maxFrameSize bytes, including
+// any whitespace preceding it. Zero means no limit. The object is rejected as
+// soon as the data read for it exceeds the limit, so at most maxFrameSize bytes
+// plus one read from r are buffered per frame. The stream cannot be read
+// further after a frame was rejected.
+func NewJSONFramedReaderWithLimit(r io.ReadCloser, maxFrameSize int) io.ReadCloser {
+	if maxFrameSize <= 0 {
+		return NewJSONFramedReader(r)
+	}
+	limiter := &frameLimitReader{r: r, limit: int64(maxFrameSize)}
+	return &jsonFrameReader{
+		r:       r,
+		decoder: json.NewDecoder(limiter),
+		limiter: limiter,
+	}
+}
+
 // ReadFrame decodes the next JSON object in the stream, or returns an error. The returned
 // byte slice will be modified the next time ReadFrame is invoked and should not be altered.
 func (r *jsonFrameReader) Read(data []byte) (int, error) {
@@ -145,12 +275,23 @@ func (r *jsonFrameReader) Read(data []byte) (int, error) {
 		return n, io.ErrShortBuffer
 	}
 
+	if r.limiter != nil {
+		r.limiter.frameStart = r.decoder.InputOffset()
+	}
+
 	// RawMessage#Unmarshal appends to data - we reset the slice down to 0 and will either see
 	// data written to data, or be larger than data and a different array.
 	m := json.RawMessage(data[:0])
 	if err := r.decoder.Decode(&m); err != nil {
+		var tooLarge *FrameTooLargeError
+		if r.limiter != nil && errors.As(err, &tooLarge) {
+			return 0, tooLarge
+		}
 		return 0, err
 	}
+	if r.limiter != nil && int64(len(m)) > r.limiter.limit {
+		return 0, &FrameTooLargeError{Limit: int(r.limiter.limit), Size: uint64(len(m))}
+	}
 
 	// If capacity of data is less than length of the message, decoder will allocate a new slice
 	// and set m to it, which means we need to copy the partial result back into data and preserve
@@ -174,3 +315,24 @@ func (r *jsonFrameReader) Read(data []byte) (int, error) {
 func (r *jsonFrameReader) Close() error {
 	return r.r.Close()
 }
+
+// frameLimitReader fails reads once the frame being decoded exceeds its limit.
+// A decoder only reads more data while the current frame is incomplete, so when
+// it does, all data read since the frame started belongs to that frame.
+type frameLimitReader struct {
+	r     io.Reader
+	limit int64
+	// read is the total number of bytes read from r.
+	read int64
+	// frameStart is the offset in the stream at which the current frame starts.
+	frameStart int64
+}
+
+func (l *frameLimitReader) Read(p []byte) (int, error) {
+	if l.read-l.frameStart > l.limit {
+		return 0, &FrameTooLargeError{Limit: int(l.limit)}
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
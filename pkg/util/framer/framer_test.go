@@ -188,3 +188,122 @@ func TestJSONFrameReaderShortBufferNoUnderlyingArrayReuse(t *testing.T) {
 		t.Fatalf("unexpected: %v %d %q", err, n, buf)
 	}
 }
+
+func TestVarintFrameRoundTrip(t *testing.T) {
+	frames := [][]byte{
+		{0x01, 0x02, 0x03},
+		{},
+		bytes.Repeat([]byte{0x04}, 300),
+	}
+	b := &bytes.Buffer{}
+	w := NewVarintLengthDelimitedFrameWriter(b)
+	for _, frame := range frames {
+		if _, err := w.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 300 needs a two byte varint prefix
+	if b.Len() != 1+3+1+0+2+300 {
+		t.Fatalf("unexpected encoded length %d", b.Len())
+	}
+
+	r := NewVarintLengthDelimitedFrameReader(io.NopCloser(b))
+	for i, frame := range frames {
+		buf := make([]byte, 512)
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("frame %d: unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(buf[:n], frame) {
+			t.Fatalf("frame %d: unexpected data %v", i, buf[:n])
+		}
+	}
+	if n, err := r.Read(make([]byte, 1)); err != io.EOF || n != 0 {
+		t.Fatalf("unexpected: %v %d", err, n)
+	}
+}
+
+func TestVarintReadShortBuffer(t *testing.T) {
+	b := bytes.NewBuffer([]byte{0x03, 0x01, 0x02, 0x03})
+	r := NewVarintLengthDelimitedFrameReader(io.NopCloser(b))
+	buf := make([]byte, 2)
+	if n, err := r.Read(buf); err != io.ErrShortBuffer || n != 2 || !bytes.Equal(buf, []byte{0x01, 0x02}) {
+		t.Fatalf("unexpected: %v %d %v", err, n, buf)
+	}
+	if n, err := r.Read(buf); err != nil || n != 1 || buf[0] != 0x03 {
+		t.Fatalf("unexpected: %v %d %v", err, n, buf)
+	}
+}
+
+func TestLengthDelimitedFrameLimit(t *testing.T) {
+	testCases := []struct {
+		name   string
+		reader func(io.ReadCloser, int) io.ReadCloser
+		data   []byte
+	}{
+		{
+			name:   "fixed length prefix",
+			reader: NewLengthDelimitedFrameReaderWithLimit,
+			data: []byte{
+				0x00, 0x00, 0x00, 0x02, 0x01, 0x02,
+				0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03,
+			},
+		},
+		{
+			name:   "varint prefix",
+			reader: NewVarintLengthDelimitedFrameReaderWithLimit,
+			data: []byte{
+				0x02, 0x01, 0x02,
+				0x03, 0x01, 0x02, 0x03,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.reader(io.NopCloser(bytes.NewBuffer(tc.data)), 2)
+			buf := make([]byte, 8)
+			if n, err := r.Read(buf); err != nil || n != 2 {
+				t.Fatalf("unexpected: %v %d", err, n)
+			}
+			_, err := r.Read(buf)
+			if !errors.Is(err, ErrFrameTooLarge) {
+				t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+			}
+			var tooLarge *FrameTooLargeError
+			if !errors.As(err, &tooLarge) || tooLarge.Size != 3 || tooLarge.Limit != 2 {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+		})
+	}
+}
+
+func TestJSONFrameReaderLimit(t *testing.T) {
+	large := `{"key":"` + string(bytes.Repeat([]byte("x"), 10000)) + `"}`
+	b := bytes.NewBufferString(`{"a":1}` + "\n" + large + "\n" + `{"b":2}`)
+	r := NewJSONFramedReaderWithLimit(io.NopCloser(b), 100)
+	buf := make([]byte, 128)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != `{"a":1}` {
+		t.Fatalf("unexpected: %v %q", err, buf[:n])
+	}
+	if _, err := r.Read(buf); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+	// the oversized object was rejected before it was read entirely
+	if b.Len() == 0 {
+		t.Errorf("expected the oversized frame to be rejected early")
+	}
+}
+
+func TestJSONFrameReaderLimitBufferedFrame(t *testing.T) {
+	// both objects fit in a single read, the limit still applies to each
+	b := bytes.NewBufferString(`{"a":1} {"key":"0123456789"}`)
+	r := NewJSONFramedReaderWithLimit(io.NopCloser(b), 10)
+	buf := make([]byte, 128)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != `{"a":1}` {
+		t.Fatalf("unexpected: %v %q", err, buf[:n])
+	}
+	if _, err := r.Read(buf); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// ErrDraining is returned when tracking a connection with a
+// ConnectionTracker which is draining.
+var ErrDraining = errors.New("connections are being drained")
+
+// ConnectionTracker tracks long-lived connections, such as hijacked or
+// upgraded connections of exec, attach or port-forward sessions, which
+// http.Server.Shutdown does not wait for, so that servers can drain them on
+// shutdown. Connections which do not end by themselves before the deadline
+// are closed.
+type ConnectionTracker struct {
+	lock        sync.Mutex
+	connections map[*trackedConnection]struct{}
+	draining    bool
+	// drained is closed once draining and no connection is left.
+	drained chan struct{}
+}
+
+type trackedConnection struct {
+	closer io.Closer
+}
+
+// NewConnectionTracker returns a ConnectionTracker without connections.
+func NewConnectionTracker() *ConnectionTracker {
+	return &ConnectionTracker{
+		connections: map[*trackedConnection]struct{}{},
+		drained:     make(chan struct{}),
+	}
+}
+
+// Track tracks conn until release is called, which must be done once conn is
+// closed. conn is closed when draining times out. Returns ErrDraining if
+// draining has started, in which case the caller should reject the connection.
+func (t *ConnectionTracker) Track(conn io.Closer) (release func(), err error) {
+	c := &trackedConnection{closer: conn}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.draining {
+		return nil, ErrDraining
+	}
+	t.connections[c] = struct{}{}
+	var once sync.Once
+	return func() { once.Do(func() { t.release(c) }) }, nil
+}
+
+// TrackStreamConnection tracks a connection upgraded with a ResponseUpgrader
+// until it is closed. Returns ErrDraining if draining has started, in which
+// case the caller should close conn.
+func (t *ConnectionTracker) TrackStreamConnection(conn Connection) error {
+	release, err := t.Track(conn)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-conn.CloseChan()
+		release()
+	}()
+	return nil
+}
+
+func (t *ConnectionTracker) release(c *trackedConnection) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.connections, c)
+	t.checkDrainedLocked()
+}
+
+// checkDrainedLocked closes drained once draining and no connection is left.
+func (t *ConnectionTracker) checkDrainedLocked() {
+	if !t.draining || len(t.connections) != 0 {
+		return
+	}
+	select {
+	case <-t.drained:
+	default:
+		close(t.drained)
+	}
+}
+
+// Active returns the number of tracked connections.
+func (t *ConnectionTracker) Active() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.connections)
+}
+
+// Drain stops accepting new connections and waits for all the tracked
+// connections to be released. Once ctx is done, the remaining connections are
+// closed, and ctx.Err() is returned. Drain can be called again, e.g. with a
+// shorter deadline, while draining.
+func (t *ConnectionTracker) Drain(ctx context.Context) error {
+	t.lock.Lock()
+	t.draining = true
+	t.checkDrainedLocked()
+	t.lock.Unlock()
+
+	select {
+	case <-t.drained:
+		return nil
+	case <-ctx.Done():
+	}
+	t.lock.Lock()
+	connections := t.snapshotLocked()
+	t.lock.Unlock()
+	klog.V(2).Infof("Closing %d connections which did not drain in time", len(connections))
+	for _, c := range connections {
+		if err := c.closer.Close(); err != nil {
+			klog.V(4).Infof("Unable to close connection: %v", err)
+		}
+	}
+	return ctx.Err()
+}
+
+// snapshotLocked returns the tracked connections, with the lock held.
+func (t *ConnectionTracker) snapshotLocked() []*trackedConnection {
+	connections := make([]*trackedConnection, 0, len(t.connections))
+	for c := range t.connections {
+		connections = append(connections, c)
+	}
+	return connections
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fakeConnection releases itself once closed.
+type fakeConnection struct {
+	closed  atomic.Bool
+	release func()
+}
+
+func (c *fakeConnection) Close() error {
+	c.closed.Store(true)
+	c.release()
+	return nil
+}
+
+func TestConnectionTrackerDrain(t *testing.T) {
+	tracker := NewConnectionTracker()
+	track := func() *fakeConnection {
+		conn := &fakeConnection{}
+		release, err := tracker.Track(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.release = release
+		return conn
+	}
+
+	ended := track()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ended.Close()
+	}()
+	if err := tracker.Drain(context.Background()); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if tracker.Active() != 0 {
+		t.Error("expected the connection to be released")
+	}
+	if _, err := tracker.Track(&fakeConnection{}); err != ErrDraining {
+		t.Errorf("expected %v, got %v", ErrDraining, err)
+	}
+
+	tracker = NewConnectionTracker()
+	stuck := track()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracker.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if !stuck.closed.Load() || tracker.Active() != 0 {
+		t.Error("expected the connection to be closed once draining timed out")
+	}
+}
+
+// fakeStreamConnection is a Connection closed by Close.
+type fakeStreamConnection struct {
+	Connection
+	closeCh chan bool
+}
+
+func (c *fakeStreamConnection) Close() error {
+	close(c.closeCh)
+	return nil
+}
+
+func (c *fakeStreamConnection) CloseChan() <-chan bool {
+	return c.closeCh
+}
+
+func TestConnectionTrackerStreamConnection(t *testing.T) {
+	tracker := NewConnectionTracker()
+	conn := &fakeStreamConnection{closeCh: make(chan bool)}
+	if err := tracker.TrackStreamConnection(conn); err != nil {
+		t.Fatal(err)
+	}
+	if tracker.Active() != 1 {
+		t.Errorf("expected 1 active connection, got %d", tracker.Active())
+	}
+	// the connection is released once closed by draining
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracker.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return tracker.Active() == 0, nil
+	}); err != nil {
+		t.Errorf("expected the closed connection to be released: %v", err)
+	}
+}
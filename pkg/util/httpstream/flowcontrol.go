@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	// HeaderStreamWindowSize is the stream header used to request flow control
+	// for a stream. Its value is the window size in bytes enforced by both sides.
+	HeaderStreamWindowSize = "X-Stream-Window-Size"
+	// HeaderStreamWindowUpdate carries window updates for implementations that
+	// send them as stream headers. Its value is the number of bytes consumed.
+	HeaderStreamWindowUpdate = "X-Stream-Window-Update"
+)
+
+// FlowControl configures optional per-stream flow control.
+type FlowControl struct {
+	// WindowSize is the number of bytes that may be written to a stream before
+	// the other side acknowledges reading them. Writers block while the window is
+	// exhausted. Zero disables windowing.
+	WindowSize uint32
+	// MaxBufferedBytes caps the data received on a stream that has not been read
+	// yet. A stream receiving data beyond the cap is reset, rather than stalling
+	// the other streams of the connection; streams with a window buffer up to
+	// the window size. Zero means no cap beyond what the implementation buffers
+	// anyway.
+	MaxBufferedBytes int
+}
+
+// FlowControlConnection is a Connection that supports per-stream flow control.
+// Windowing only applies to streams whose creator requested it, and both sides
+// then wait for window updates from each other. Callers must only enable flow
+// control when the peer is known to support it, e.g. through a negotiated
+// protocol version.
+type FlowControlConnection interface {
+	Connection
+	// SetFlowControl configures flow control for streams created or accepted
+	// after the call.
+	SetFlowControl(FlowControl)
+}
+
+// StreamWindowSize returns the window size requested in the stream headers, or
+// zero if flow control was not requested.
+func StreamWindowSize(headers http.Header) uint32 {
+	size, err := strconv.ParseUint(headers.Get(HeaderStreamWindowSize), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(size)
+}
+
+// SetStreamWindowSize requests flow control with the given window size in the
+// stream headers.
+func SetStreamWindowSize(headers http.Header, size uint32) {
+	headers.Set(HeaderStreamWindowSize, strconv.FormatUint(uint64(size), 10))
+}
+
+// ErrSendWindowClosed is returned by SendWindow.Acquire once the window is closed.
+var ErrSendWindowClosed = errors.New("stream send window closed")
+
+// SendWindow tracks how many bytes a writer may still send on a stream.
+type SendWindow struct {
+	lock      sync.Mutex
+	cond      *sync.Cond
+	available uint32
+	disabled  bool
+	closed    bool
+}
+
+// NewSendWindow returns a SendWindow with size bytes available.
+func NewSendWindow(size uint32) *SendWindow {
+	w := &SendWindow{available: size}
+	w.cond = sync.NewCond(&w.lock)
+	return w
+}
+
+// Acquire blocks until part of the window is available, and returns how many of
+// the n requested bytes may be sent.
+func (w *SendWindow) Acquire(n int) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for w.available == 0 && !w.disabled && !w.closed {
+		w.cond.Wait()
+	}
+	switch {
+	case w.closed:
+		return 0, ErrSendWindowClosed
+	case w.disabled:
+		return n, nil
+	}
+	if uint64(n) > uint64(w.available) {
+		n = int(w.available)
+	}
+	w.available -= uint32(n)
+	return n, nil
+}
+
+// Update makes n more bytes available, as acknowledged by the receiver.
+func (w *SendWindow) Update(n uint32) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.available += n
+	w.cond.Broadcast()
+}
+
+// Disable stops enforcing the window, e.g. once window updates can no longer be
+// received.
+func (w *SendWindow) Disable() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.disabled = true
+	w.cond.Broadcast()
+}
+
+// Close unblocks pending and future calls to Acquire with ErrSendWindowClosed.
+func (w *SendWindow) Close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closed = true
+	w.cond.Broadcast()
+}
+
+// ReceiveWindow tracks how many bytes a reader has consumed since it last sent
+// a window update.
+type ReceiveWindow struct {
+	lock     sync.Mutex
+	size     uint32
+	consumed uint32
+}
+
+// NewReceiveWindow returns a ReceiveWindow for a window of size bytes.
+func NewReceiveWindow(size uint32) *ReceiveWindow {
+	return &ReceiveWindow{size: size}
+}
+
+// Consume records that n bytes were read. It returns the size of the window
+// update to send, or zero if none is due yet. Updates are batched until at least
+// half of the window has been consumed.
+func (w *ReceiveWindow) Consume(n int) uint32 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.consumed += uint32(n)
+	if w.consumed < w.size/2 || w.consumed == 0 {
+		return 0
+	}
+	update := w.consumed
+	w.consumed = 0
+	return update
+}
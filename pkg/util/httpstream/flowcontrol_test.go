@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStreamWindowSizeHeader(t *testing.T) {
+	headers := http.Header{}
+	if size := StreamWindowSize(headers); size != 0 {
+		t.Errorf("expected no window size, got %d", size)
+	}
+	SetStreamWindowSize(headers, 1024)
+	if size := StreamWindowSize(headers); size != 1024 {
+		t.Errorf("expected window size 1024, got %d", size)
+	}
+	headers.Set(HeaderStreamWindowSize, "invalid")
+	if size := StreamWindowSize(headers); size != 0 {
+		t.Errorf("expected invalid window size to be ignored, got %d", size)
+	}
+}
+
+func TestSendWindow(t *testing.T) {
+	w := NewSendWindow(10)
+	if n, err := w.Acquire(4); n != 4 || err != nil {
+		t.Fatalf("expected 4 bytes, got %d: %v", n, err)
+	}
+	if n, err := w.Acquire(20); n != 6 || err != nil {
+		t.Fatalf("expected remaining 6 bytes, got %d: %v", n, err)
+	}
+
+	acquired := make(chan int)
+	go func() {
+		n, _ := w.Acquire(5)
+		acquired <- n
+	}()
+	select {
+	case n := <-acquired:
+		t.Fatalf("expected Acquire to block on an exhausted window, got %d", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+	w.Update(3)
+	if n := <-acquired; n != 3 {
+		t.Errorf("expected 3 bytes after update, got %d", n)
+	}
+
+	w.Disable()
+	if n, err := w.Acquire(100); n != 100 || err != nil {
+		t.Errorf("expected disabled window to allow 100 bytes, got %d: %v", n, err)
+	}
+	w.Close()
+	if _, err := w.Acquire(1); err != ErrSendWindowClosed {
+		t.Errorf("expected ErrSendWindowClosed, got %v", err)
+	}
+}
+
+func TestReceiveWindow(t *testing.T) {
+	w := NewReceiveWindow(10)
+	if update := w.Consume(3); update != 0 {
+		t.Errorf("expected no update before half the window was consumed, got %d", update)
+	}
+	if update := w.Consume(2); update != 5 {
+		t.Errorf("expected update of 5, got %d", update)
+	}
+	if update := w.Consume(0); update != 0 {
+		t.Errorf("expected no update, got %d", update)
+	}
+}
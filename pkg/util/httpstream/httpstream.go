@@ -87,6 +87,10 @@ type Connection interface {
 // upgraded connection.
 type Stream interface {
 	io.ReadWriteCloser
+	// CloseWrite closes the write direction of the stream, signaling EOF to the
+	// other side. Data can still be read until the other side closes its write
+	// direction as well, e.g. to keep reading stdout after closing stdin.
+	CloseWrite() error
 	// Reset closes both directions of the stream, indicating that neither client
 	// or server can use it any more.
 	Reset() error
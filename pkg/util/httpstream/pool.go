@@ -0,0 +1,322 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errSessionClosed is returned when creating a stream on a closed pooled session.
+var errSessionClosed = errors.New("session closed")
+
+// ConnectionPool reuses upgraded connections to the same backend for multiple
+// logical sessions. Each session multiplexes its streams over a shared
+// connection, which saves the upgrade handshake for all but the first session.
+type ConnectionPool struct {
+	// maxSessions is the maximum number of concurrent sessions sharing a
+	// connection. Zero means no limit.
+	maxSessions int
+	// idleTimeout is how long a connection without sessions is kept open.
+	idleTimeout time.Duration
+
+	lock sync.Mutex
+	// conns holds the open connections for each pool key.
+	conns map[string][]*pooledConnection
+}
+
+// NewConnectionPool returns a ConnectionPool sharing each connection between at
+// most maxSessions concurrent sessions (unlimited if zero), and closing
+// connections that had no sessions for idleTimeout (immediately if zero).
+func NewConnectionPool(maxSessions int, idleTimeout time.Duration) *ConnectionPool {
+	return &ConnectionPool{
+		maxSessions: maxSessions,
+		idleTimeout: idleTimeout,
+		conns:       make(map[string][]*pooledConnection),
+	}
+}
+
+// Dialer returns a Dialer that opens sessions on pooled connections. Sessions
+// of Dialers sharing the same key and requested protocols may share a
+// connection, so the key must identify the backend and any credentials used
+// to dial it. dialer is used whenever a new connection is needed.
+//
+// Closing a session resets the streams created through it but leaves the
+// shared connection open for other sessions. SetIdleTimeout on a session has
+// no effect, since it would apply to all sessions of the connection.
+func (p *ConnectionPool) Dialer(key string, dialer Dialer) Dialer {
+	return &pooledDialer{pool: p, key: key, dialer: dialer}
+}
+
+// Len returns the number of open connections in the pool.
+func (p *ConnectionPool) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	n := 0
+	for _, conns := range p.conns {
+		n += len(conns)
+	}
+	return n
+}
+
+// Close closes all connections in the pool, including those with active
+// sessions.
+func (p *ConnectionPool) Close() {
+	p.lock.Lock()
+	conns := p.conns
+	p.conns = make(map[string][]*pooledConnection)
+	p.lock.Unlock()
+	for _, pooled := range conns {
+		for _, c := range pooled {
+			c.close()
+		}
+	}
+}
+
+// acquire returns an open connection for key with room for another session, or
+// nil if a new connection must be dialed. Connections found closed are removed
+// from the pool, since they may be closed before add notices it.
+func (p *ConnectionPool) acquire(key string) *pooledConnection {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.removeClosedLocked(key)
+	for _, c := range p.conns[key] {
+		if p.maxSessions > 0 && c.sessions >= p.maxSessions {
+			continue
+		}
+		c.sessions++
+		if c.idleTimer != nil {
+			c.idleTimer.Stop()
+			c.idleTimer = nil
+		}
+		return c
+	}
+	return nil
+}
+
+// add registers a newly dialed connection with one session.
+func (p *ConnectionPool) add(key string, conn Connection, protocol string) *pooledConnection {
+	c := &pooledConnection{pool: p, key: key, conn: conn, protocol: protocol, sessions: 1}
+	p.lock.Lock()
+	p.conns[key] = append(p.conns[key], c)
+	p.lock.Unlock()
+	go func() {
+		<-conn.CloseChan()
+		p.remove(c)
+	}()
+	return c
+}
+
+// release ends a session on c, closing c if it stays without sessions for the
+// idle timeout.
+func (p *ConnectionPool) release(c *pooledConnection) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	c.sessions--
+	if c.sessions > 0 {
+		return
+	}
+	if c.isClosed() {
+		p.removeLocked(c)
+		return
+	}
+	if p.idleTimeout <= 0 {
+		p.removeLocked(c)
+		go c.close()
+		return
+	}
+	c.idleTimer = time.AfterFunc(p.idleTimeout, func() {
+		p.lock.Lock()
+		idle := c.sessions == 0
+		if idle {
+			p.removeLocked(c)
+		}
+		p.lock.Unlock()
+		if idle {
+			c.close()
+		}
+	})
+}
+
+func (p *ConnectionPool) remove(c *pooledConnection) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.removeLocked(c)
+}
+
+// removeClosedLocked removes the closed connections for key.
+func (p *ConnectionPool) removeClosedLocked(key string) {
+	var open []*pooledConnection
+	for _, c := range p.conns[key] {
+		if !c.isClosed() {
+			open = append(open, c)
+		}
+	}
+	if len(open) == 0 {
+		delete(p.conns, key)
+	} else {
+		p.conns[key] = open
+	}
+}
+
+func (p *ConnectionPool) removeLocked(c *pooledConnection) {
+	conns := p.conns[c.key]
+	for i := range conns {
+		if conns[i] == c {
+			conns = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(p.conns, c.key)
+	} else {
+		p.conns[c.key] = conns
+	}
+}
+
+// pooledConnection is a connection shared by the sessions of a pool key.
+type pooledConnection struct {
+	pool     *ConnectionPool
+	key      string
+	conn     Connection
+	protocol string
+
+	// sessions and idleTimer are guarded by the pool lock.
+	sessions  int
+	idleTimer *time.Timer
+}
+
+// isClosed returns true if the connection is closed, without waiting for it.
+func (c *pooledConnection) isClosed() bool {
+	select {
+	case <-c.conn.CloseChan():
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *pooledConnection) close() {
+	c.conn.Close() //nolint:errcheck
+}
+
+type pooledDialer struct {
+	pool   *ConnectionPool
+	key    string
+	dialer Dialer
+}
+
+// Dial returns a session on a pooled connection, dialing a new connection if
+// none has room for another session.
+func (d *pooledDialer) Dial(protocols ...string) (Connection, string, error) {
+	key := d.key + "\x00" + strings.Join(protocols, ",")
+	if c := d.pool.acquire(key); c != nil {
+		return newPooledSession(c), c.protocol, nil
+	}
+	conn, protocol, err := d.dialer.Dial(protocols...)
+	if err != nil {
+		return nil, "", err
+	}
+	c := d.pool.add(key, conn, protocol)
+	return newPooledSession(c), protocol, nil
+}
+
+// pooledSession is a logical Connection backed by a shared pooled connection.
+type pooledSession struct {
+	conn *pooledConnection
+
+	lock    sync.Mutex
+	streams map[uint32]Stream
+	closed  bool
+}
+
+var _ Connection = &pooledSession{}
+
+func newPooledSession(conn *pooledConnection) *pooledSession {
+	return &pooledSession{conn: conn, streams: make(map[uint32]Stream)}
+}
+
+// CreateStream creates a stream on the shared connection and tracks it as part
+// of the session.
+func (s *pooledSession) CreateStream(headers http.Header) (Stream, error) {
+	s.lock.Lock()
+	closed := s.closed
+	s.lock.Unlock()
+	if closed {
+		return nil, errSessionClosed
+	}
+	stream, err := s.conn.conn.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		stream.Reset() //nolint:errcheck
+		s.conn.conn.RemoveStreams(stream)
+		return nil, errSessionClosed
+	}
+	s.streams[stream.Identifier()] = stream
+	return stream, nil
+}
+
+// Close resets the streams of the session and releases the shared connection.
+func (s *pooledSession) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	streams := make([]Stream, 0, len(s.streams))
+	for _, stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	s.streams = nil
+	s.lock.Unlock()
+
+	for _, stream := range streams {
+		stream.Reset() //nolint:errcheck
+	}
+	s.conn.conn.RemoveStreams(streams...)
+	s.conn.pool.release(s.conn)
+	return nil
+}
+
+// CloseChan returns a channel that is closed when the shared connection is
+// closed.
+func (s *pooledSession) CloseChan() <-chan bool {
+	return s.conn.conn.CloseChan()
+}
+
+// SetIdleTimeout has no effect on pooled sessions.
+func (s *pooledSession) SetIdleTimeout(timeout time.Duration) {}
+
+// RemoveStreams removes streams from the session and the shared connection.
+func (s *pooledSession) RemoveStreams(streams ...Stream) {
+	s.lock.Lock()
+	for _, stream := range streams {
+		if stream != nil {
+			delete(s.streams, stream.Identifier())
+		}
+	}
+	s.lock.Unlock()
+	s.conn.conn.RemoveStreams(streams...)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpstream
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakePoolStream struct {
+	id    uint32
+	reset bool
+}
+
+func (s *fakePoolStream) Read(p []byte) (int, error)  { return 0, nil }
+func (s *fakePoolStream) Write(p []byte) (int, error) { return len(p), nil }
+func (s *fakePoolStream) Close() error                { return nil }
+func (s *fakePoolStream) CloseWrite() error           { return nil }
+func (s *fakePoolStream) Reset() error                { s.reset = true; return nil }
+func (s *fakePoolStream) Headers() http.Header        { return http.Header{} }
+func (s *fakePoolStream) Identifier() uint32          { return s.id }
+
+type fakePoolConnection struct {
+	lock      sync.Mutex
+	nextID    uint32
+	closed    bool
+	closeChan chan bool
+}
+
+func (c *fakePoolConnection) CreateStream(headers http.Header) (Stream, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.nextID += 2
+	return &fakePoolStream{id: c.nextID}, nil
+}
+
+func (c *fakePoolConnection) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.closeChan)
+	}
+	return nil
+}
+
+func (c *fakePoolConnection) isClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.closed
+}
+
+func (c *fakePoolConnection) CloseChan() <-chan bool          { return c.closeChan }
+func (c *fakePoolConnection) SetIdleTimeout(time.Duration)    {}
+func (c *fakePoolConnection) RemoveStreams(streams ...Stream) {}
+
+type fakePoolDialer struct {
+	conns []*fakePoolConnection
+}
+
+func (d *fakePoolDialer) Dial(protocols ...string) (Connection, string, error) {
+	conn := &fakePoolConnection{closeChan: make(chan bool)}
+	d.conns = append(d.conns, conn)
+	protocol := ""
+	if len(protocols) > 0 {
+		protocol = protocols[0]
+	}
+	return conn, protocol, nil
+}
+
+func TestConnectionPoolReusesConnections(t *testing.T) {
+	pool := NewConnectionPool(2, time.Hour)
+	defer pool.Close()
+	dialer := &fakePoolDialer{}
+	d := pool.Dialer("backend", dialer)
+
+	s1, protocol, err := d.Dial("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protocol != "v1" {
+		t.Errorf("expected protocol v1, got %q", protocol)
+	}
+	s2, protocol, err := d.Dial("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protocol != "v1" {
+		t.Errorf("expected pooled session to report protocol v1, got %q", protocol)
+	}
+	if len(dialer.conns) != 1 {
+		t.Fatalf("expected sessions to share one connection, got %d", len(dialer.conns))
+	}
+
+	// the session limit forces a new connection
+	if _, _, err := d.Dial("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialer.conns) != 2 {
+		t.Errorf("expected a second connection once the first is full, got %d", len(dialer.conns))
+	}
+	// different protocols never share a connection
+	if _, _, err := d.Dial("v2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialer.conns) != 3 {
+		t.Errorf("expected a connection per protocol set, got %d", len(dialer.conns))
+	}
+
+	// closing a session frees its slot without closing the connection
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if dialer.conns[0].isClosed() {
+		t.Error("expected shared connection to stay open")
+	}
+	if _, _, err := d.Dial("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialer.conns) != 3 {
+		t.Errorf("expected the freed slot to be reused, got %d connections", len(dialer.conns))
+	}
+	s2.Close()
+}
+
+func TestConnectionPoolSessionClose(t *testing.T) {
+	pool := NewConnectionPool(0, time.Hour)
+	defer pool.Close()
+	d := pool.Dialer("backend", &fakePoolDialer{})
+
+	session, _, err := d.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := session.CreateStream(http.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !stream.(*fakePoolStream).reset {
+		t.Error("expected closing the session to reset its streams")
+	}
+	if _, err := session.CreateStream(http.Header{}); err != errSessionClosed {
+		t.Errorf("expected errSessionClosed, got %v", err)
+	}
+	// closing twice does not release the connection twice
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConnectionPoolIdleTimeout(t *testing.T) {
+	pool := NewConnectionPool(0, 50*time.Millisecond)
+	defer pool.Close()
+	dialer := &fakePoolDialer{}
+	d := pool.Dialer("backend", dialer)
+
+	session, _, err := d.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Close()
+	select {
+	case <-dialer.conns[0].CloseChan():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected idle connection to be closed")
+	}
+	if n := pool.Len(); n != 0 {
+		t.Errorf("expected empty pool, got %d connections", n)
+	}
+}
+
+func TestConnectionPoolRemovesClosedConnections(t *testing.T) {
+	pool := NewConnectionPool(0, time.Hour)
+	defer pool.Close()
+	dialer := &fakePoolDialer{}
+	d := pool.Dialer("backend", dialer)
+
+	if _, _, err := d.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	dialer.conns[0].Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected closed connection to leave the pool")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, _, err := d.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialer.conns) != 2 {
+		t.Errorf("expected a new connection after the pooled one closed, got %d", len(dialer.conns))
+	}
+}
+
+func TestConnectionPoolSkipsClosedConnections(t *testing.T) {
+	pool := NewConnectionPool(0, time.Hour)
+	defer pool.Close()
+	dialer := &fakePoolDialer{}
+	d := pool.Dialer("backend", dialer)
+
+	session, _, err := d.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pool may not have observed the close yet
+	dialer.conns[0].Close()
+	if _, _, err := d.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialer.conns) != 2 {
+		t.Errorf("expected a new connection instead of the closed one, got %d", len(dialer.conns))
+	}
+	// returning a session of the closed connection does not keep it pooled
+	session.Close()
+	if n := pool.Len(); n != 1 {
+		t.Errorf("expected only the open connection to be pooled, got %d", n)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package spdy
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"sync"
@@ -35,8 +36,36 @@ type connection struct {
 	streamLock       sync.Mutex
 	newStreamHandler httpstream.NewStreamHandler
 	ping             func() (time.Duration, error)
+	flowControl      httpstream.FlowControl
+	config           ConnectionConfig
 }
 
+// ConnectionConfig configures keepalives and lifetime limits of a SPDY
+// connection.
+type ConnectionConfig struct {
+	// PingPeriod is the period for sending Ping frames over the connection. Use
+	// this to keep idle connections through certain load balancers and NATs
+	// alive longer. No Pings are sent if zero.
+	PingPeriod time.Duration
+	// PingTimeout is how long to wait for a Ping to be acknowledged before it is
+	// considered failed. Defaults to PingPeriod if zero.
+	PingTimeout time.Duration
+	// MaxPingFailures is the number of consecutive failed Pings after which the
+	// connection is closed. Failed Pings never close the connection if zero.
+	MaxPingFailures int
+	// IdleTimeout is the amount of time the connection may remain idle before
+	// it is automatically closed. There is no idle timeout if zero.
+	IdleTimeout time.Duration
+	// MaxLifetime is the amount of time after which the connection is closed,
+	// regardless of activity. There is no limit if zero.
+	MaxLifetime time.Duration
+	// OnHealthCheckFailure, if set, is called with the number of consecutive
+	// failures and the error every time a Ping fails.
+	OnHealthCheckFailure func(failures int, err error)
+}
+
+var _ httpstream.FlowControlConnection = &connection{}
+
 // NewClientConnection creates a new SPDY client connection.
 func NewClientConnection(conn net.Conn) (httpstream.Connection, error) {
 	return NewClientConnectionWithPings(conn, 0)
@@ -48,13 +77,19 @@ func NewClientConnection(conn net.Conn) (httpstream.Connection, error) {
 // frames to the server. Use this to keep idle connections through certain load
 // balancers alive longer.
 func NewClientConnectionWithPings(conn net.Conn, pingPeriod time.Duration) (httpstream.Connection, error) {
+	return NewClientConnectionWithConfig(conn, ConnectionConfig{PingPeriod: pingPeriod})
+}
+
+// NewClientConnectionWithConfig creates a new SPDY client connection with the
+// given keepalive and lifetime configuration.
+func NewClientConnectionWithConfig(conn net.Conn, config ConnectionConfig) (httpstream.Connection, error) {
 	spdyConn, err := spdystream.NewConnection(conn, false)
 	if err != nil {
 		defer conn.Close()
 		return nil, err
 	}
 
-	return newConnection(spdyConn, httpstream.NoOpNewStreamHandler, pingPeriod, spdyConn.Ping), nil
+	return newConnection(spdyConn, httpstream.NoOpNewStreamHandler, config, spdyConn.Ping), nil
 }
 
 // NewServerConnection creates a new SPDY server connection. newStreamHandler
@@ -72,28 +107,42 @@ func NewServerConnection(conn net.Conn, newStreamHandler httpstream.NewStreamHan
 // frames to the server. Use this to keep idle connections through certain load
 // balancers alive longer.
 func NewServerConnectionWithPings(conn net.Conn, newStreamHandler httpstream.NewStreamHandler, pingPeriod time.Duration) (httpstream.Connection, error) {
+	return NewServerConnectionWithConfig(conn, newStreamHandler, ConnectionConfig{PingPeriod: pingPeriod})
+}
+
+// NewServerConnectionWithConfig creates a new SPDY server connection with the
+// given keepalive and lifetime configuration. newStreamHandler will be invoked
+// when the server receives a newly created stream from the client.
+func NewServerConnectionWithConfig(conn net.Conn, newStreamHandler httpstream.NewStreamHandler, config ConnectionConfig) (httpstream.Connection, error) {
 	spdyConn, err := spdystream.NewConnection(conn, true)
 	if err != nil {
 		defer conn.Close()
 		return nil, err
 	}
 
-	return newConnection(spdyConn, newStreamHandler, pingPeriod, spdyConn.Ping), nil
+	return newConnection(spdyConn, newStreamHandler, config, spdyConn.Ping), nil
 }
 
 // newConnection returns a new connection wrapping conn. newStreamHandler
 // will be invoked when the server receives a newly created stream from the
 // client.
-func newConnection(conn *spdystream.Connection, newStreamHandler httpstream.NewStreamHandler, pingPeriod time.Duration, pingFn func() (time.Duration, error)) httpstream.Connection {
+func newConnection(conn *spdystream.Connection, newStreamHandler httpstream.NewStreamHandler, config ConnectionConfig, pingFn func() (time.Duration, error)) httpstream.Connection {
 	c := &connection{
 		conn:             conn,
 		newStreamHandler: newStreamHandler,
 		ping:             pingFn,
 		streams:          make(map[uint32]httpstream.Stream),
+		config:           config,
 	}
 	go conn.Serve(c.newSpdyStream)
-	if pingPeriod > 0 && pingFn != nil {
-		go c.sendPings(pingPeriod)
+	if config.IdleTimeout > 0 {
+		conn.SetIdleTimeout(config.IdleTimeout)
+	}
+	if config.PingPeriod > 0 && pingFn != nil {
+		go c.sendPings(config.PingPeriod)
+	}
+	if config.MaxLifetime > 0 {
+		go c.closeAfter(config.MaxLifetime)
 	}
 	return c
 }
@@ -134,6 +183,18 @@ func (c *connection) RemoveStreams(streams ...httpstream.Stream) {
 // CreateStream creates a new stream with the specified headers and registers
 // it with the connection.
 func (c *connection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	c.streamLock.Lock()
+	windowSize := c.flowControl.WindowSize
+	c.streamLock.Unlock()
+	if windowSize > 0 {
+		if headers == nil {
+			headers = http.Header{}
+		} else {
+			headers = headers.Clone()
+		}
+		httpstream.SetStreamWindowSize(headers, windowSize)
+	}
+
 	stream, err := c.conn.CreateStream(headers, nil, false)
 	if err != nil {
 		return nil, err
@@ -142,8 +203,9 @@ func (c *connection) CreateStream(headers http.Header) (httpstream.Stream, error
 		return nil, err
 	}
 
-	c.registerStream(stream)
-	return stream, nil
+	s := newStream(stream)
+	c.registerStream(s)
+	return s, nil
 }
 
 // registerStream adds the stream s to the connection's list of streams that
@@ -166,15 +228,16 @@ func (c *connection) CloseChan() <-chan bool {
 // stream is accepted and registered with the connection.
 func (c *connection) newSpdyStream(stream *spdystream.Stream) {
 	replySent := make(chan struct{})
-	err := c.newStreamHandler(stream, replySent)
+	s := newStream(stream)
+	err := c.newStreamHandler(s, replySent)
 	rejectStream := (err != nil)
 	if rejectStream {
 		klog.Warningf("Stream rejected: %v", err)
-		stream.Reset()
+		s.Reset()
 		return
 	}
 
-	c.registerStream(stream)
+	c.registerStream(s)
 	stream.SendReply(http.Header{}, rejectStream)
 	close(replySent)
 }
@@ -185,20 +248,74 @@ func (c *connection) SetIdleTimeout(timeout time.Duration) {
 	c.conn.SetIdleTimeout(timeout)
 }
 
+// SetFlowControl requests flow control for streams created after the call. Streams
+// created by the other side use the flow control they requested, if any. SPDY
+// streams do not buffer received data, so MaxBufferedBytes is ignored.
+func (c *connection) SetFlowControl(flowControl httpstream.FlowControl) {
+	c.streamLock.Lock()
+	c.flowControl = flowControl
+	c.streamLock.Unlock()
+}
+
 func (c *connection) sendPings(period time.Duration) {
+	timeout := c.config.PingTimeout
+	if timeout <= 0 {
+		timeout = period
+	}
 	t := time.NewTicker(period)
 	defer t.Stop()
+	failures := 0
 	for {
 		select {
 		case <-c.conn.CloseChan():
 			return
 		case <-t.C:
 		}
-		if _, err := c.ping(); err != nil {
+		if err := c.pingWithTimeout(timeout); err != nil {
+			failures++
 			klog.V(3).Infof("SPDY Ping failed: %v", err)
+			if c.config.OnHealthCheckFailure != nil {
+				c.config.OnHealthCheckFailure(failures, err)
+			}
+			if c.config.MaxPingFailures > 0 && failures >= c.config.MaxPingFailures {
+				klog.V(3).Infof("Closing SPDY connection after %d failed Pings", failures)
+				c.Close()
+				return
+			}
 			// Continue, in case this is a transient failure.
 			// c.conn.CloseChan above will tell us when the connection is
 			// actually closed.
+			continue
 		}
+		failures = 0
+	}
+}
+
+// pingWithTimeout sends a Ping and waits up to timeout for it to be acknowledged.
+func (c *connection) pingWithTimeout(timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.ping()
+		errCh <- err
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("ping not acknowledged within %v", timeout)
+	}
+}
+
+// closeAfter closes the connection once lifetime has elapsed.
+func (c *connection) closeAfter(lifetime time.Duration) {
+	t := time.NewTimer(lifetime)
+	defer t.Stop()
+	select {
+	case <-c.conn.CloseChan():
+	case <-t.C:
+		klog.V(3).Infof("Closing SPDY connection after reaching its maximum lifetime of %v", lifetime)
+		c.Close()
 	}
 }
@@ -218,7 +218,7 @@ func TestConnectionPings(t *testing.T) {
 				go io.Copy(stream, stream)
 				return nil
 			},
-			pingPeriod,
+			ConnectionConfig{PingPeriod: pingPeriod},
 			func() (time.Duration, error) {
 				atomic.AddInt64(&pingsSent, 1)
 				return 0, nil
@@ -296,6 +296,7 @@ type fakeStream struct{ id uint32 }
 func (*fakeStream) Read(p []byte) (int, error)  { return 0, nil }
 func (*fakeStream) Write(p []byte) (int, error) { return 0, nil }
 func (*fakeStream) Close() error                { return nil }
+func (*fakeStream) CloseWrite() error           { return nil }
 func (*fakeStream) Reset() error                { return nil }
 func (*fakeStream) Headers() http.Header        { return nil }
 func (f *fakeStream) Identifier() uint32        { return f.id }
@@ -331,3 +332,218 @@ func TestConnectionRemoveStreams(t *testing.T) {
 	}
 
 }
+
+func TestConnectionFlowControl(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	streamCh := make(chan httpstream.Stream, 1)
+	server, err := NewServerConnection(serverConn, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		streamCh <- stream
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error creating server connection: %v", err)
+	}
+	defer server.Close()
+	client, err := NewClientConnection(clientConn)
+	if err != nil {
+		t.Fatalf("error creating client connection: %v", err)
+	}
+	defer client.Close()
+
+	client.(httpstream.FlowControlConnection).SetFlowControl(httpstream.FlowControl{WindowSize: 4})
+	clientStream, err := client.CreateStream(http.Header{})
+	if err != nil {
+		t.Fatalf("error creating stream: %v", err)
+	}
+	serverStream := <-streamCh
+	if size := httpstream.StreamWindowSize(serverStream.Headers()); size != 4 {
+		t.Fatalf("expected window size 4 in stream headers, got %d", size)
+	}
+
+	// the server stalls writing once the window is exhausted
+	written := make(chan error, 1)
+	go func() {
+		_, err := serverStream.Write([]byte("12345678"))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("expected write to block on the window, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// reading from the client opens the window again
+	data := make([]byte, 8)
+	if _, err := io.ReadFull(clientStream, data); err != nil {
+		t.Fatalf("error reading: %v", err)
+	}
+	if string(data) != "12345678" {
+		t.Errorf("unexpected data %q", data)
+	}
+	if err := <-written; err != nil {
+		t.Errorf("unexpected write error: %v", err)
+	}
+
+	// resetting the stream unblocks a stalled writer
+	go func() {
+		_, err := serverStream.Write([]byte("12345678"))
+		written <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	serverStream.Reset()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected reset to unblock the writer")
+	}
+}
+
+func TestStreamCloseWrite(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	streamCh := make(chan httpstream.Stream, 1)
+	server, err := NewServerConnection(serverConn, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		streamCh <- stream
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error creating server connection: %v", err)
+	}
+	defer server.Close()
+	client, err := NewClientConnection(clientConn)
+	if err != nil {
+		t.Fatalf("error creating client connection: %v", err)
+	}
+	defer client.Close()
+
+	clientStream, err := client.CreateStream(http.Header{})
+	if err != nil {
+		t.Fatalf("error creating stream: %v", err)
+	}
+	serverStream := <-streamCh
+
+	if _, err := clientStream.Write([]byte("stdin")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := clientStream.CloseWrite(); err != nil {
+		t.Fatalf("unexpected error closing write side: %v", err)
+	}
+	if err := clientStream.CloseWrite(); err != nil {
+		t.Errorf("expected closing the write side twice to be a no-op, got %v", err)
+	}
+	if _, err := clientStream.Write([]byte("late")); err == nil {
+		t.Errorf("expected write after CloseWrite to fail")
+	}
+
+	// the server sees EOF after the data sent before the half-close
+	data, err := io.ReadAll(serverStream)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if string(data) != "stdin" {
+		t.Errorf("unexpected data %q", data)
+	}
+
+	// the other direction is still usable
+	go func() {
+		serverStream.Write([]byte("stdout"))
+		serverStream.CloseWrite()
+	}()
+	data, err = io.ReadAll(clientStream)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if string(data) != "stdout" {
+		t.Errorf("unexpected data %q", data)
+	}
+}
+
+func TestConnectionHealthCheckFailures(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	// discard frames so that writes to the pipe do not block
+	go io.Copy(io.Discard, serverConn)
+	spdyConn, err := spdystream.NewConnection(clientConn, false)
+	if err != nil {
+		t.Fatalf("error creating spdy connection: %v", err)
+	}
+
+	var failures []int
+	var lock sync.Mutex
+	conn := newConnection(spdyConn, httpstream.NoOpNewStreamHandler, ConnectionConfig{
+		PingPeriod:      10 * time.Millisecond,
+		MaxPingFailures: 3,
+		OnHealthCheckFailure: func(failureCount int, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			failures = append(failures, failureCount)
+		},
+	}, func() (time.Duration, error) {
+		return 0, fmt.Errorf("ping failed")
+	})
+
+	select {
+	case <-conn.CloseChan():
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected connection to be closed after repeated ping failures")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(failures) != 3 || failures[0] != 1 || failures[2] != 3 {
+		t.Errorf("unexpected health check failures: %v", failures)
+	}
+}
+
+func TestConnectionPingTimeout(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	// discard frames so that writes to the pipe do not block
+	go io.Copy(io.Discard, serverConn)
+	spdyConn, err := spdystream.NewConnection(clientConn, false)
+	if err != nil {
+		t.Fatalf("error creating spdy connection: %v", err)
+	}
+
+	blockPing := make(chan struct{})
+	defer close(blockPing)
+	failed := make(chan error, 1)
+	conn := newConnection(spdyConn, httpstream.NoOpNewStreamHandler, ConnectionConfig{
+		PingPeriod:  10 * time.Millisecond,
+		PingTimeout: 10 * time.Millisecond,
+		OnHealthCheckFailure: func(failureCount int, err error) {
+			select {
+			case failed <- err:
+			default:
+			}
+		},
+	}, func() (time.Duration, error) {
+		<-blockPing
+		return 0, nil
+	})
+	defer conn.Close()
+
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Error("expected a timeout error")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected an unacknowledged ping to fail")
+	}
+}
+
+func TestConnectionMaxLifetime(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	// discard frames so that writes to the pipe do not block
+	go io.Copy(io.Discard, serverConn)
+	client, err := NewClientConnectionWithConfig(clientConn, ConnectionConfig{MaxLifetime: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error creating client connection: %v", err)
+	}
+
+	select {
+	case <-client.CloseChan():
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected connection to be closed after its maximum lifetime")
+	}
+}
@@ -66,9 +66,9 @@ type SpdyRoundTripper struct {
 	// Used primarily for mocking the proxy discovery in tests.
 	proxier func(req *http.Request) (*url.URL, error)
 
-	// pingPeriod is a period for sending Ping frames over established
+	// connectionConfig configures keepalives and lifetime limits of established
 	// connections.
-	pingPeriod time.Duration
+	connectionConfig ConnectionConfig
 
 	// upgradeTransport is an optional substitute for dialing if present. This field is
 	// mutually exclusive with the "tlsConfig", "Dialer", and "proxier".
@@ -115,10 +115,14 @@ func NewRoundTripperWithConfig(cfg RoundTripperConfig) (*SpdyRoundTripper, error
 	if cfg.Proxier == nil {
 		cfg.Proxier = utilnet.NewProxierWithNoProxyCIDR(http.ProxyFromEnvironment)
 	}
+	connectionConfig := cfg.ConnectionConfig
+	if cfg.PingPeriod > 0 {
+		connectionConfig.PingPeriod = cfg.PingPeriod
+	}
 	return &SpdyRoundTripper{
 		tlsConfig:        cfg.TLS,
 		proxier:          cfg.Proxier,
-		pingPeriod:       cfg.PingPeriod,
+		connectionConfig: connectionConfig,
 		upgradeTransport: cfg.UpgradeTransport,
 	}, nil
 }
@@ -129,9 +133,14 @@ type RoundTripperConfig struct {
 	TLS *tls.Config
 	// Proxier is a proxy function invoked on each request. Optional.
 	Proxier func(*http.Request) (*url.URL, error)
-	// PingPeriod is a period for sending SPDY Pings on the connection.
+	// PingPeriod is a period for sending SPDY Pings on the connection. If set,
+	// it takes precedence over ConnectionConfig.PingPeriod.
 	// Optional.
 	PingPeriod time.Duration
+	// ConnectionConfig configures keepalives, idle timeouts and the maximum
+	// lifetime of upgraded connections.
+	// Optional.
+	ConnectionConfig ConnectionConfig
 	// UpgradeTransport is a subtitute transport used for dialing. If set,
 	// this field will be used instead of "TLS" and "Proxier" for connection creation.
 	// Optional.
@@ -383,7 +392,7 @@ func (s *SpdyRoundTripper) NewConnection(resp *http.Response) (httpstream.Connec
 		return nil, fmt.Errorf("unable to upgrade connection: %s", responseError)
 	}
 
-	return NewClientConnectionWithPings(s.conn, s.pingPeriod)
+	return NewClientConnectionWithConfig(s.conn, s.connectionConfig)
 }
 
 // statusScheme is private scheme for the decoding here until someone fixes the TODO in NewConnection
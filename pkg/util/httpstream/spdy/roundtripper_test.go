@@ -29,6 +29,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/armon/go-socks5"
 	"github.com/stretchr/testify/assert"
@@ -893,3 +894,17 @@ LiAGaec8xjl6QK/DdXmFuQBKqyKJ14rljFODP4QuE9WJid94bGqjpf3j99ltznZP
 KR8NJEkK99Vh/tew6jAMll70xFrE7aF8VLXJVE7w4sQzuvHxl9Q=
 -----END RSA PRIVATE KEY-----
 `)
+
+func TestRoundTripperConnectionConfig(t *testing.T) {
+	rt, err := NewRoundTripperWithConfig(RoundTripperConfig{
+		PingPeriod:       time.Second,
+		ConnectionConfig: ConnectionConfig{PingPeriod: time.Minute, MaxPingFailures: 3, MaxLifetime: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := ConnectionConfig{PingPeriod: time.Second, MaxPingFailures: 3, MaxLifetime: time.Hour}
+	if rt.connectionConfig.PingPeriod != expected.PingPeriod || rt.connectionConfig.MaxPingFailures != expected.MaxPingFailures || rt.connectionConfig.MaxLifetime != expected.MaxLifetime {
+		t.Errorf("expected connection config %+v, got %+v", expected, rt.connectionConfig)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spdy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/moby/spdystream"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/klog/v2"
+)
+
+// errWriteClosed is returned when writing to a stream whose write side was closed.
+var errWriteClosed = errors.New("write to a stream closed for writing")
+
+// stream adapts a spdystream.Stream to httpstream.Stream. Close and CloseWrite
+// both half-close the stream by sending a FIN frame; data sent by the other side
+// can still be read until it half-closes too.
+//
+// If the stream headers request flow control, writes are bounded by a send
+// window. Window updates are exchanged as SPDY HEADERS frames carrying
+// httpstream.HeaderStreamWindowUpdate. spdystream drops header frames received
+// after the other side half-closed the stream, so once that happens the send
+// window is no longer enforced. Received data is not buffered beyond a single
+// frame, so no additional receive cap is needed.
+type stream struct {
+	*spdystream.Stream
+	// send and recv are set if the stream is flow controlled.
+	send *httpstream.SendWindow
+	recv *httpstream.ReceiveWindow
+	// writeClosed is set once this side sent its FIN frame.
+	writeClosed atomic.Bool
+}
+
+var _ httpstream.Stream = &stream{}
+
+func newStream(s *spdystream.Stream) *stream {
+	wrapped := &stream{Stream: s}
+	if size := httpstream.StreamWindowSize(s.Headers()); size > 0 {
+		wrapped.send = httpstream.NewSendWindow(size)
+		wrapped.recv = httpstream.NewReceiveWindow(size)
+		go wrapped.receiveWindowUpdates()
+	}
+	return wrapped
+}
+
+// Write sends p on the stream. With flow control, it blocks until the window
+// allows all of p to be sent.
+func (s *stream) Write(p []byte) (int, error) {
+	if s.writeClosed.Load() {
+		return 0, errWriteClosed
+	}
+	if s.send == nil {
+		return s.Stream.Write(p)
+	}
+	written := 0
+	for len(p) > 0 {
+		n, err := s.send.Acquire(len(p))
+		if err != nil {
+			return written, err
+		}
+		n, err = s.Stream.Write(p[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Read reads from the stream and, with flow control, acknowledges consumed data
+// to the other side.
+func (s *stream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	if s.recv == nil {
+		return n, err
+	}
+	if update := s.recv.Consume(n); update > 0 {
+		header := http.Header{}
+		header.Set(httpstream.HeaderStreamWindowUpdate, strconv.FormatUint(uint64(update), 10))
+		if sendErr := s.Stream.SendHeader(header, false); sendErr != nil {
+			klog.V(4).Infof("Unable to send window update for stream %d: %v", s.Identifier(), sendErr)
+		}
+	}
+	return n, err
+}
+
+// CloseWrite sends a data frame with the FIN flag set, signaling EOF to the
+// other side while data can still be read from it. Closing an already closed
+// write side is a no-op.
+func (s *stream) CloseWrite() error {
+	if s.writeClosed.Swap(true) {
+		return nil
+	}
+	return s.Stream.Close()
+}
+
+// Close half-closes the stream, like CloseWrite.
+func (s *stream) Close() error {
+	return s.CloseWrite()
+}
+
+// Reset closes both directions of the stream and unblocks pending writers.
+func (s *stream) Reset() error {
+	if s.send != nil {
+		s.send.Close()
+	}
+	return s.Stream.Reset()
+}
+
+func (s *stream) receiveWindowUpdates() {
+	// window updates can no longer be received once the stream is closed
+	defer s.send.Disable()
+	for {
+		header, err := s.Stream.ReceiveHeader()
+		if err != nil {
+			return
+		}
+		update, err := strconv.ParseUint(header.Get(httpstream.HeaderStreamWindowUpdate), 10, 32)
+		if err != nil {
+			klog.V(4).Infof("Ignoring invalid window update for stream %d: %v", s.Identifier(), err)
+			continue
+		}
+		s.send.Update(uint32(update))
+	}
+}
@@ -35,7 +35,7 @@ const HeaderSpdy31 = "SPDY/3.1"
 // responseUpgrader knows how to upgrade HTTP responses. It
 // implements the httpstream.ResponseUpgrader interface.
 type responseUpgrader struct {
-	pingPeriod time.Duration
+	config ConnectionConfig
 }
 
 // connWrapper is used to wrap a hijacked connection and its bufio.Reader. All
@@ -77,7 +77,15 @@ func NewResponseUpgrader() httpstream.ResponseUpgrader {
 // goroutine will send periodic Ping frames to the server. Use this to keep
 // idle connections through certain load balancers alive longer.
 func NewResponseUpgraderWithPings(pingPeriod time.Duration) httpstream.ResponseUpgrader {
-	return responseUpgrader{pingPeriod: pingPeriod}
+	return NewResponseUpgraderWithConfig(ConnectionConfig{PingPeriod: pingPeriod})
+}
+
+// NewResponseUpgraderWithConfig returns a new httpstream.ResponseUpgrader that
+// is capable of upgrading HTTP responses using SPDY/3.1 via the spdystream
+// package. Each upgraded connection uses the given keepalive and lifetime
+// configuration.
+func NewResponseUpgraderWithConfig(config ConnectionConfig) httpstream.ResponseUpgrader {
+	return responseUpgrader{config: config}
 }
 
 // UpgradeResponse upgrades an HTTP response to one that supports multiplexed
@@ -110,7 +118,7 @@ func (u responseUpgrader) UpgradeResponse(w http.ResponseWriter, req *http.Reque
 	}
 
 	connWithBuf := &connWrapper{Conn: conn, bufReader: bufrw.Reader}
-	spdyConn, err := NewServerConnectionWithPings(connWithBuf, newStreamHandler, u.pingPeriod)
+	spdyConn, err := NewServerConnectionWithConfig(connWithBuf, newStreamHandler, u.config)
 	if err != nil {
 		runtime.HandleError(fmt.Errorf("unable to upgrade: error creating SPDY server connection: %v", err))
 		return nil
@@ -0,0 +1,608 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/klog/v2"
+)
+
+// Control signals are sent as the first byte of a message, followed by the
+// channel they apply to. Every other message is a data frame prefixed with
+// its channel, as in the remotecommand subprotocols.
+const (
+	// streamClose half-closes a stream; the sender will not write to it again.
+	streamClose = remotecommand.StreamClose
+	// streamCreate opens a stream. The channel byte is followed by the
+	// JSON-encoded stream headers.
+	streamCreate = 254
+	// streamReply accepts a stream opened by the other side.
+	streamReply = 253
+	// streamReset tears down both directions of a stream, and rejects a stream
+	// opened by the other side.
+	streamReset = 252
+	// streamWindowUpdate acknowledges data read from a flow controlled stream.
+	// The channel byte is followed by the number of bytes read as a big-endian
+	// uint32.
+	streamWindowUpdate = 251
+
+	// maxStreamID is the highest channel number usable by a stream.
+	maxStreamID = 250
+)
+
+// createStreamResponseTimeout indicates how long to wait for the other side to
+// acknowledge the new stream before timing out.
+const createStreamResponseTimeout = 30 * time.Second
+
+var (
+	errStreamReset    = errors.New("stream reset")
+	errStreamOverflow = errors.New("stream reset: the other side sent more data than could be buffered")
+	errStreamRejected = errors.New("stream rejected by the remote side")
+	errStreamClosed   = errors.New("stream closed for writing")
+	errConnClosed     = errors.New("connection closed")
+)
+
+var _ httpstream.FlowControlConnection = &connection{}
+
+// messageConn sends and receives the messages carrying the frames of a
+// connection, e.g. over a websocket.
+type messageConn interface {
+	// Send sends a single message. It is safe to call from multiple goroutines.
+	Send(message []byte) error
+	// Receive blocks until the next message is received.
+	Receive() ([]byte, error)
+	// SetDeadline sets the time after which sending and receiving fail.
+	SetDeadline(t time.Time) error
+	// Close closes the underlying transport.
+	Close() error
+}
+
+// websocketConn is a messageConn sending binary websocket messages.
+type websocketConn struct {
+	ws *websocket.Conn
+}
+
+func (c websocketConn) Send(message []byte) error {
+	return websocket.Message.Send(c.ws, message)
+}
+
+func (c websocketConn) Receive() ([]byte, error) {
+	var data []byte
+	err := websocket.Message.Receive(c.ws, &data)
+	return data, err
+}
+
+func (c websocketConn) SetDeadline(t time.Time) error {
+	return c.ws.SetDeadline(t)
+}
+
+func (c websocketConn) Close() error {
+	return c.ws.Close()
+}
+
+// connection implements httpstream.Connection by multiplexing streams over a
+// single websocket or tunnel, one channel per stream.
+type connection struct {
+	messages         messageConn
+	newStreamHandler httpstream.NewStreamHandler
+	// nextID is the parity of the channels this side allocates: clients use
+	// even channels, servers use odd ones.
+	nextID uint32
+
+	streamLock sync.Mutex
+	streams    map[uint32]*stream
+	closed     bool
+	// flowControl applies to streams created or accepted from now on.
+	flowControl httpstream.FlowControl
+
+	timeoutLock sync.Mutex
+	timeout     time.Duration
+
+	closeChan chan bool
+	closeOnce sync.Once
+}
+
+// NewClientConnection creates a new httpstream.Connection on top of a client
+// websocket.
+func NewClientConnection(ws *websocket.Conn) httpstream.Connection {
+	ws.PayloadType = websocket.BinaryFrame
+	c := newConnection(websocketConn{ws: ws}, httpstream.NoOpNewStreamHandler, false)
+	go c.serve()
+	return c
+}
+
+// newServerConnection creates a new connection on top of a server websocket.
+// newStreamHandler will be invoked when the server receives a newly created
+// stream from the client. The caller must invoke serve.
+func newServerConnection(ws *websocket.Conn, newStreamHandler httpstream.NewStreamHandler) *connection {
+	ws.PayloadType = websocket.BinaryFrame
+	return newConnection(websocketConn{ws: ws}, newStreamHandler, true)
+}
+
+func newConnection(messages messageConn, newStreamHandler httpstream.NewStreamHandler, server bool) *connection {
+	c := &connection{
+		messages:         messages,
+		newStreamHandler: newStreamHandler,
+		streams:          make(map[uint32]*stream),
+		closeChan:        make(chan bool),
+	}
+	if server {
+		c.nextID = 1
+	}
+	return c
+}
+
+// CreateStream creates a new stream with the specified headers and waits for
+// the other side to accept it.
+func (c *connection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	c.streamLock.Lock()
+	flowControl := c.flowControl
+	c.streamLock.Unlock()
+	if flowControl.WindowSize > 0 {
+		if headers == nil {
+			headers = http.Header{}
+		} else {
+			headers = headers.Clone()
+		}
+		httpstream.SetStreamWindowSize(headers, flowControl.WindowSize)
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	c.streamLock.Lock()
+	if c.closed {
+		c.streamLock.Unlock()
+		return nil, errConnClosed
+	}
+	id, ok := c.allocateIDLocked()
+	if !ok {
+		c.streamLock.Unlock()
+		return nil, fmt.Errorf("unable to create stream: all %d channels are in use", maxStreamID/2+1)
+	}
+	s := newStream(c, id, headers, c.flowControl.MaxBufferedBytes)
+	s.replyCh = make(chan error, 1)
+	c.streams[id] = s
+	c.streamLock.Unlock()
+
+	if err := c.send(append([]byte{streamCreate, byte(id)}, data...)); err != nil {
+		c.removeStream(id)
+		return nil, err
+	}
+
+	timer := time.NewTimer(createStreamResponseTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-s.replyCh:
+		if err != nil {
+			c.removeStream(id)
+			return nil, err
+		}
+		return s, nil
+	case <-timer.C:
+		s.Reset() //nolint:errcheck
+		return nil, fmt.Errorf("timed out waiting for stream %d to be accepted", id)
+	}
+}
+
+// allocateIDLocked returns the lowest free channel with this side's parity.
+func (c *connection) allocateIDLocked() (uint32, bool) {
+	for id := c.nextID; id <= maxStreamID; id += 2 {
+		if _, used := c.streams[id]; !used {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Close resets all streams and closes the websocket or tunnel.
+func (c *connection) Close() error {
+	c.streamLock.Lock()
+	c.closed = true
+	streams := c.streams
+	c.streams = make(map[uint32]*stream)
+	c.streamLock.Unlock()
+
+	for _, s := range streams {
+		s.Reset() //nolint:errcheck
+	}
+	return c.messages.Close()
+}
+
+// CloseChan returns a channel that is closed when the underlying websocket or
+// tunnel is closed.
+func (c *connection) CloseChan() <-chan bool {
+	return c.closeChan
+}
+
+// SetIdleTimeout sets the amount of time the connection may remain idle before
+// it is automatically closed.
+func (c *connection) SetIdleTimeout(timeout time.Duration) {
+	c.timeoutLock.Lock()
+	c.timeout = timeout
+	c.timeoutLock.Unlock()
+	c.resetTimeout()
+}
+
+// SetFlowControl configures flow control for streams created or accepted after
+// the call. Streams created by the other side use the window size they
+// requested, if any.
+func (c *connection) SetFlowControl(flowControl httpstream.FlowControl) {
+	c.streamLock.Lock()
+	c.flowControl = flowControl
+	c.streamLock.Unlock()
+}
+
+// RemoveStreams can be used to remove a set of streams from the Connection.
+func (c *connection) RemoveStreams(streams ...httpstream.Stream) {
+	for _, s := range streams {
+		// It may be possible that the provided stream is nil if timed out.
+		if s != nil {
+			c.removeStream(s.Identifier())
+		}
+	}
+}
+
+func (c *connection) removeStream(id uint32) {
+	c.streamLock.Lock()
+	delete(c.streams, id)
+	c.streamLock.Unlock()
+}
+
+func (c *connection) getStream(id uint32) *stream {
+	c.streamLock.Lock()
+	defer c.streamLock.Unlock()
+	return c.streams[id]
+}
+
+func (c *connection) resetTimeout() {
+	c.timeoutLock.Lock()
+	timeout := c.timeout
+	c.timeoutLock.Unlock()
+	if timeout > 0 {
+		c.messages.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	}
+}
+
+// send writes a single message.
+func (c *connection) send(frame []byte) error {
+	c.resetTimeout()
+	return c.messages.Send(frame)
+}
+
+// serve reads messages and dispatches them to streams until the websocket or
+// tunnel is closed.
+func (c *connection) serve() {
+	defer c.closeOnce.Do(func() {
+		c.Close() //nolint:errcheck
+		close(c.closeChan)
+	})
+
+	for {
+		c.resetTimeout()
+		data, err := c.messages.Receive()
+		if err != nil {
+			if err != io.EOF {
+				klog.V(4).Infof("Error on socket receive: %v", err)
+			}
+			return
+		}
+		if len(data) < 2 {
+			continue
+		}
+		switch data[0] {
+		case streamCreate:
+			c.handleCreate(uint32(data[1]), data[2:])
+		case streamReply, streamReset, streamClose:
+			if len(data) != 2 {
+				klog.Errorf("Single channel byte should follow signal %d. Got %d bytes", data[0], len(data)-1)
+				return
+			}
+			s := c.getStream(uint32(data[1]))
+			if s == nil {
+				klog.V(6).Infof("Signal %d is targeted for a stream %d that is not valid, possible protocol error", data[0], data[1])
+				continue
+			}
+			switch data[0] {
+			case streamReply:
+				s.replied(nil)
+			case streamReset:
+				s.replied(errStreamRejected)
+				s.remoteReset()
+				c.removeStream(s.id)
+			case streamClose:
+				s.remoteClose()
+			}
+		case streamWindowUpdate:
+			if len(data) != 6 {
+				klog.Errorf("Channel byte and window size should follow window update signal. Got %d bytes", len(data)-1)
+				return
+			}
+			if s := c.getStream(uint32(data[1])); s != nil && s.send != nil {
+				s.send.Update(binary.BigEndian.Uint32(data[2:]))
+			}
+		default:
+			s := c.getStream(uint32(data[0]))
+			if s == nil {
+				klog.V(6).Infof("Frame is targeted for a stream %d that is not valid, possible protocol error", data[0])
+				continue
+			}
+			s.dataFromSocket(data[1:])
+		}
+	}
+}
+
+// handleCreate gives newStreamHandler the opportunity to accept or reject a
+// stream created by the other side. If newStreamHandler returns an error, the
+// stream is rejected. If not, the stream is accepted and registered with the
+// connection.
+func (c *connection) handleCreate(id uint32, data []byte) {
+	if id%2 == c.nextID%2 || id > maxStreamID {
+		klog.Errorf("Stream %d created by the other side is not on one of its channels, possible protocol error", id)
+		c.send([]byte{streamReset, byte(id)}) //nolint:errcheck
+		return
+	}
+	if c.getStream(id) != nil {
+		klog.Errorf("Stream %d created by the other side is already in use, possible protocol error", id)
+		c.send([]byte{streamReset, byte(id)}) //nolint:errcheck
+		return
+	}
+	headers := http.Header{}
+	if err := json.Unmarshal(data, &headers); err != nil {
+		klog.Errorf("Unable to decode headers of stream %d: %v", id, err)
+		c.send([]byte{streamReset, byte(id)}) //nolint:errcheck
+		return
+	}
+
+	c.streamLock.Lock()
+	maxBuffered := c.flowControl.MaxBufferedBytes
+	c.streamLock.Unlock()
+	s := newStream(c, id, headers, maxBuffered)
+	replySent := make(chan struct{})
+	if err := c.newStreamHandler(s, replySent); err != nil {
+		klog.Warningf("Stream rejected: %v", err)
+		c.send([]byte{streamReset, byte(id)}) //nolint:errcheck
+		return
+	}
+
+	c.streamLock.Lock()
+	c.streams[id] = s
+	c.streamLock.Unlock()
+	if err := c.send([]byte{streamReply, byte(id)}); err != nil {
+		klog.V(4).Infof("Unable to accept stream %d: %v", id, err)
+	}
+	close(replySent)
+}
+
+// stream implements httpstream.Stream for a single channel of a connection.
+type stream struct {
+	conn    *connection
+	id      uint32
+	headers http.Header
+	replyCh chan error
+	// send and recv are set if the stream is flow controlled.
+	send *httpstream.SendWindow
+	recv *httpstream.ReceiveWindow
+
+	lock sync.Mutex
+	cond *sync.Cond
+	// buf holds data received from the other side that has not been read yet.
+	buf []byte
+	// readErr is returned once buf is drained.
+	readErr     error
+	writeClosed bool
+	// reset is set once either side reset the stream.
+	reset bool
+	// maxBuffered caps len(buf); zero means no cap.
+	maxBuffered int
+}
+
+var _ httpstream.Stream = &stream{}
+
+func newStream(conn *connection, id uint32, headers http.Header, maxBuffered int) *stream {
+	s := &stream{conn: conn, id: id, headers: headers, maxBuffered: maxBuffered}
+	s.cond = sync.NewCond(&s.lock)
+	if size := httpstream.StreamWindowSize(headers); size > 0 {
+		s.send = httpstream.NewSendWindow(size)
+		s.recv = httpstream.NewReceiveWindow(size)
+		// the other side does not send more than the window
+		s.maxBuffered = max(maxBuffered, int(size))
+	}
+	return s
+}
+
+// Read reads data received on the stream, blocking until data is available or
+// the other side closes or resets the stream.
+func (s *stream) Read(p []byte) (int, error) {
+	s.lock.Lock()
+	for len(s.buf) == 0 && s.readErr == nil {
+		s.cond.Wait()
+	}
+	if len(s.buf) == 0 {
+		err := s.readErr
+		s.lock.Unlock()
+		return 0, err
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.lock.Unlock()
+
+	if s.recv != nil {
+		if update := s.recv.Consume(n); update > 0 {
+			frame := []byte{streamWindowUpdate, byte(s.id), 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(frame[2:], update)
+			if err := s.conn.send(frame); err != nil {
+				klog.V(4).Infof("Unable to send window update for stream %d: %v", s.id, err)
+			}
+		}
+	}
+	return n, nil
+}
+
+// Write sends data on the stream.
+func (s *stream) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	closed := s.writeClosed
+	s.lock.Unlock()
+	if closed {
+		return 0, errStreamClosed
+	}
+	if s.send == nil {
+		return len(p), s.writeFrame(p)
+	}
+	// with flow control, only send as much as the window allows
+	written := 0
+	for len(p) > 0 {
+		n, err := s.send.Acquire(len(p))
+		if err != nil {
+			return written, err
+		}
+		if err := s.writeFrame(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (s *stream) writeFrame(p []byte) error {
+	frame := make([]byte, len(p)+1)
+	frame[0] = byte(s.id)
+	copy(frame[1:], p)
+	return s.conn.send(frame)
+}
+
+// Close half-closes the stream, like CloseWrite.
+func (s *stream) Close() error {
+	return s.CloseWrite()
+}
+
+// CloseWrite half-closes the stream: no more data will be written, but data sent
+// by the other side can still be read.
+func (s *stream) CloseWrite() error {
+	s.lock.Lock()
+	if s.writeClosed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.writeClosed = true
+	s.lock.Unlock()
+	return s.conn.send([]byte{streamClose, byte(s.id)})
+}
+
+// Reset closes both directions of the stream, indicating that neither side can
+// use it any more.
+func (s *stream) Reset() error {
+	return s.resetWithError(errStreamReset)
+}
+
+// resetWithError resets the stream, failing reads with readErr.
+func (s *stream) resetWithError(readErr error) error {
+	s.lock.Lock()
+	alreadyReset := s.reset
+	s.reset = true
+	s.writeClosed = true
+	s.readErr = readErr
+	s.buf = nil
+	s.cond.Broadcast()
+	s.lock.Unlock()
+	if s.send != nil {
+		s.send.Close()
+	}
+
+	s.conn.removeStream(s.id)
+	if alreadyReset {
+		return nil
+	}
+	return s.conn.send([]byte{streamReset, byte(s.id)})
+}
+
+// Headers returns the headers used to create the stream.
+func (s *stream) Headers() http.Header {
+	return s.headers
+}
+
+// Identifier returns the stream's channel number.
+func (s *stream) Identifier() uint32 {
+	return s.id
+}
+
+// dataFromSocket buffers data received for the stream. It is called by the
+// loop serving the connection, so rather than waiting for the stream to be
+// read from, it resets the stream if the data would exceed its buffer cap.
+func (s *stream) dataFromSocket(data []byte) {
+	s.lock.Lock()
+	if s.readErr != nil {
+		s.lock.Unlock()
+		return
+	}
+	if buffered := len(s.buf); s.maxBuffered > 0 && buffered+len(data) > s.maxBuffered {
+		s.lock.Unlock()
+		klog.V(4).Infof("Resetting stream %d: %d bytes buffered, received %d more", s.id, buffered, len(data))
+		s.resetWithError(errStreamOverflow) //nolint:errcheck
+		return
+	}
+	s.buf = append(s.buf, data...)
+	s.cond.Broadcast()
+	s.lock.Unlock()
+}
+
+func (s *stream) replied(err error) {
+	if s.replyCh == nil {
+		return
+	}
+	select {
+	case s.replyCh <- err:
+	default:
+	}
+}
+
+func (s *stream) remoteClose() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.readErr == nil {
+		s.readErr = io.EOF
+	}
+	s.cond.Broadcast()
+}
+
+func (s *stream) remoteReset() {
+	if s.send != nil {
+		s.send.Close()
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset = true
+	s.writeClosed = true
+	s.readErr = errStreamReset
+	s.buf = nil
+	s.cond.Broadcast()
+}
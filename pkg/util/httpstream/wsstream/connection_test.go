@@ -0,0 +1,317 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// newStreamServer starts a server that upgrades to a multiplexed websocket
+// connection and sends every accepted stream on the returned channel.
+func newStreamServer(t *testing.T, serverProtocols []string, handler httpstream.NewStreamHandler) (*httptest.Server, chan httpstream.Stream) {
+	streams := make(chan httpstream.Stream, 10)
+	if handler == nil {
+		handler = func(stream httpstream.Stream, replySent <-chan struct{}) error {
+			streams <- stream
+			return nil
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(serverProtocols) > 0 {
+			if _, err := httpstream.Handshake(req, w, serverProtocols); err != nil {
+				return
+			}
+		}
+		conn := NewResponseUpgrader().UpgradeResponse(w, req, handler)
+		if conn == nil {
+			return
+		}
+		<-conn.CloseChan()
+	}))
+	return server, streams
+}
+
+func dialStreamServer(t *testing.T, server *httptest.Server, protocols ...string) (httpstream.Connection, string) {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	conn, protocol, err := NewDialer(u, nil, nil).Dial(protocols...)
+	require.NoError(t, err)
+	return conn, protocol
+}
+
+func TestStreamConnectionRoundTrip(t *testing.T) {
+	server, serverStreams := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	headers := http.Header{}
+	headers.Set("streamType", "stdout")
+	clientStream, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+	serverStream := <-serverStreams
+	assert.Equal(t, "stdout", serverStream.Headers().Get("streamType"))
+	assert.Equal(t, clientStream.Identifier(), serverStream.Identifier())
+
+	// client to server, then half-close
+	_, err = clientStream.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, clientStream.Close())
+	data, err := io.ReadAll(serverStream)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// the server can still write after the client half-closed
+	_, err = serverStream.Write([]byte("world"))
+	require.NoError(t, err)
+	require.NoError(t, serverStream.Close())
+	data, err = io.ReadAll(clientStream)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+
+	// writes after close fail
+	_, err = clientStream.Write([]byte("late"))
+	assert.Error(t, err)
+}
+
+func TestStreamConnectionMultipleStreams(t *testing.T) {
+	server, serverStreams := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	ids := map[uint32]bool{}
+	for i := 0; i < 3; i++ {
+		s, err := conn.CreateStream(http.Header{})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0), s.Identifier()%2, "client streams use even channels")
+		assert.False(t, ids[s.Identifier()], "duplicate channel %d", s.Identifier())
+		ids[s.Identifier()] = true
+		<-serverStreams
+	}
+}
+
+func TestStreamConnectionRejectedStream(t *testing.T) {
+	server, _ := newStreamServer(t, nil, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		return errors.New("rejected")
+	})
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	_, err := conn.CreateStream(http.Header{})
+	assert.Error(t, err)
+}
+
+func TestStreamConnectionReset(t *testing.T) {
+	server, serverStreams := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	clientStream, err := conn.CreateStream(http.Header{})
+	require.NoError(t, err)
+	serverStream := <-serverStreams
+
+	require.NoError(t, serverStream.Reset())
+	_, err = io.ReadAll(clientStream)
+	assert.Equal(t, errStreamReset, err)
+}
+
+func TestStreamConnectionNegotiation(t *testing.T) {
+	server, _ := newStreamServer(t, []string{"v2.test", "v1.test"}, nil)
+	defer server.Close()
+
+	conn, protocol := dialStreamServer(t, server, "v3.test", "v1.test")
+	defer conn.Close()
+	assert.Equal(t, "v1.test", protocol)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	_, _, err = NewDialer(u, nil, nil).Dial("v4.test")
+	assert.True(t, httpstream.IsUpgradeFailure(err), "unexpected error: %v", err)
+}
+
+func TestStreamConnectionCloseChan(t *testing.T) {
+	server, _ := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+
+	require.NoError(t, conn.Close())
+	select {
+	case <-conn.CloseChan():
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected connection to be closed")
+	}
+}
+
+func TestStreamConnectionFlowControl(t *testing.T) {
+	server, serverStreams := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	conn.(httpstream.FlowControlConnection).SetFlowControl(httpstream.FlowControl{WindowSize: 4})
+	clientStream, err := conn.CreateStream(http.Header{})
+	require.NoError(t, err)
+	serverStream := <-serverStreams
+	assert.Equal(t, uint32(4), httpstream.StreamWindowSize(serverStream.Headers()))
+
+	// the server stalls writing once the window is exhausted
+	written := make(chan error, 1)
+	go func() {
+		_, err := serverStream.Write([]byte("12345678"))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("expected write to block on the window, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, 4, clientStream.(*stream).buffered())
+
+	// reading from the client opens the window again
+	data := make([]byte, 8)
+	_, err = io.ReadFull(clientStream, data)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", string(data))
+	require.NoError(t, <-written)
+
+	// resetting the stream unblocks a stalled writer
+	go func() {
+		_, err := serverStream.Write([]byte("12345678"))
+		written <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, serverStream.Reset())
+	select {
+	case err := <-written:
+		assert.Error(t, err)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected reset to unblock the writer")
+	}
+}
+
+// fakeMessageConn records the messages sent on a connection.
+type fakeMessageConn struct {
+	lock sync.Mutex
+	sent [][]byte
+}
+
+func (c *fakeMessageConn) Send(message []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sent = append(c.sent, append([]byte(nil), message...))
+	return nil
+}
+
+func (c *fakeMessageConn) Receive() ([]byte, error) { return nil, io.EOF }
+
+func (c *fakeMessageConn) SetDeadline(t time.Time) error { return nil }
+
+func (c *fakeMessageConn) Close() error { return nil }
+
+func (c *fakeMessageConn) messages() [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.sent
+}
+
+func TestStreamMaxBufferedBytes(t *testing.T) {
+	messages := &fakeMessageConn{}
+	c := newConnection(messages, httpstream.NoOpNewStreamHandler, false)
+	s := newStream(c, 1, http.Header{}, 4)
+	c.streams[1] = s
+
+	// the connection does not wait for the stream to be read from, but resets it
+	s.dataFromSocket([]byte("1234"))
+	assert.Equal(t, 4, s.buffered())
+	s.dataFromSocket([]byte("5"))
+	_, err := s.Read(make([]byte, 4))
+	assert.Equal(t, errStreamOverflow, err)
+	assert.Nil(t, c.getStream(1))
+	assert.Equal(t, [][]byte{{streamReset, 1}}, messages.messages())
+
+	// streams with a window buffer up to the window size
+	headers := http.Header{}
+	httpstream.SetStreamWindowSize(headers, 8)
+	s = newStream(c, 3, headers, 4)
+	s.dataFromSocket([]byte("12345678"))
+	assert.Equal(t, 8, s.buffered())
+}
+
+func TestStreamConnectionHandleCreate(t *testing.T) {
+	messages := &fakeMessageConn{}
+	var accepted []uint32
+	c := newConnection(messages, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		accepted = append(accepted, stream.Identifier())
+		return nil
+	}, false)
+
+	// clients use even channels, so servers create streams on odd ones
+	c.handleCreate(2, []byte("{}"))
+	c.handleCreate(1, []byte("{}"))
+	c.handleCreate(1, []byte("{}"))
+	assert.Equal(t, []uint32{1}, accepted)
+	assert.Equal(t, [][]byte{{streamReset, 2}, {streamReply, 1}, {streamReset, 1}}, messages.messages())
+	assert.NotNil(t, c.getStream(1), "expected the stream in use to be kept")
+}
+
+// buffered returns the number of bytes received but not read yet.
+func (s *stream) buffered() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.buf)
+}
+
+func TestStreamCloseWrite(t *testing.T) {
+	server, serverStreams := newStreamServer(t, nil, nil)
+	defer server.Close()
+	conn, _ := dialStreamServer(t, server)
+	defer conn.Close()
+
+	clientStream, err := conn.CreateStream(http.Header{})
+	require.NoError(t, err)
+	serverStream := <-serverStreams
+
+	_, err = clientStream.Write([]byte("stdin"))
+	require.NoError(t, err)
+	require.NoError(t, clientStream.CloseWrite())
+	data, err := io.ReadAll(serverStream)
+	require.NoError(t, err)
+	assert.Equal(t, "stdin", string(data))
+
+	_, err = serverStream.Write([]byte("stdout"))
+	require.NoError(t, err)
+	require.NoError(t, serverStream.CloseWrite())
+	data, err = io.ReadAll(clientStream)
+	require.NoError(t, err)
+	assert.Equal(t, "stdout", string(data))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/websocket"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// Dialer implements httpstream.Dialer by opening a websocket carrying
+// multiplexed streams, as served by the upgrader returned from
+// NewResponseUpgrader.
+type Dialer struct {
+	// Location is the http, https, ws or wss URL to connect to.
+	Location *url.URL
+	// TLSConfig is used when connecting to https and wss URLs.
+	TLSConfig *tls.Config
+	// Header holds additional headers sent with the upgrade request, e.g.
+	// for authentication.
+	Header http.Header
+}
+
+var _ httpstream.Dialer = &Dialer{}
+
+// NewDialer returns a Dialer connecting to location.
+func NewDialer(location *url.URL, tlsConfig *tls.Config, header http.Header) *Dialer {
+	return &Dialer{Location: location, TLSConfig: tlsConfig, Header: header}
+}
+
+// Dial opens a streaming connection to the server using one of the protocols
+// specified, in order of most preferred to least preferred. The protocols are
+// sent both as websocket subprotocols and in the X-Stream-Protocol-Version
+// header, so servers can negotiate them with httpstream.Handshake. It returns
+// the connection and the protocol selected by the server, if any.
+func (d *Dialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	location := *d.Location
+	switch location.Scheme {
+	case "http", "":
+		location.Scheme = "ws"
+	case "https":
+		location.Scheme = "wss"
+	}
+	origin := url.URL{Scheme: "http", Host: location.Host}
+	if location.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, "", err
+	}
+	config.Protocol = protocols
+	config.TlsConfig = d.TLSConfig
+	config.Header = d.Header.Clone()
+	if config.Header == nil {
+		config.Header = http.Header{}
+	}
+	for _, protocol := range protocols {
+		config.Header.Add(httpstream.HeaderProtocolVersion, protocol)
+	}
+
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, "", &httpstream.UpgradeFailureError{Cause: fmt.Errorf("unable to dial %s: %w", location.Redacted(), err)}
+	}
+	var negotiated string
+	if len(ws.Config().Protocol) == 1 {
+		negotiated = ws.Config().Protocol[0]
+	}
+	return NewClientConnection(ws), negotiated, nil
+}
@@ -66,4 +66,35 @@ limitations under the License.
 //	WRITE []byte{0, 102, 111, 111, 10} # send "foo\n" on channel 0 (STDIN)
 //	WRITE []byte{255, 0}               # send CLOSE signal (STDIN)
 //	CLOSE
+//
+// # Multiplexed streams
+//
+// NewResponseUpgrader and Dialer provide an httpstream.Connection over a
+// websocket, as an alternative to SPDY. Each stream is assigned its own channel
+// and data frames use the channel framing above. Streams are managed with
+// control signals that, like CLOSE, are sent as the first byte of a message
+// followed by the channel id:
+//
+//	[]byte{254, id, headers...} # CREATE a stream with JSON-encoded headers
+//	[]byte{253, id}             # REPLY, accepting a created stream
+//	[]byte{252, id}             # RESET both directions, or reject a created stream
+//	[]byte{255, id}             # CLOSE, half-closing the sender's side
+//	[]byte{251, id, n...}       # WINDOW UPDATE, acknowledging n bytes (big-endian uint32) read
+//
+// Streams created with the httpstream.HeaderStreamWindowSize header are flow
+// controlled: a writer blocks once it has sent that many bytes that the reader
+// has not acknowledged with window updates yet.
+//
+// Clients create streams on even channels and servers on odd channels, so at
+// most 126 client and 125 server streams can be open at once.
+//
+// # Tunnels
+//
+// NewTunnelResponseUpgrader and TunnelDialer carry the same multiplexed streams
+// over a single HTTP/2 request instead of a websocket, for proxies which do not
+// forward Upgrade headers. The tunnel is opened with a POST request with the
+// X-Stream-Tunnel: v1 header, and protocols are negotiated with
+// httpstream.Handshake as for upgrades. The request and response bodies then
+// carry the messages above, each prefixed with its length as a big-endian
+// uint32.
 package wsstream // import "k8s.io/apimachinery/pkg/util/httpstream/wsstream"
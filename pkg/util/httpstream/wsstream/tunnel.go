@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+const (
+	// HeaderStreamTunnel marks requests opening a tunnel. Its value is the
+	// version of the tunnel framing.
+	HeaderStreamTunnel = "X-Stream-Tunnel"
+	// StreamTunnelV1 frames each message with its length as a big-endian uint32.
+	StreamTunnelV1 = "v1"
+
+	// maxTunnelMessageSize limits the size of the messages received on a tunnel.
+	maxTunnelMessageSize = 16 * 1024 * 1024
+)
+
+var errTunnelClosed = errors.New("tunnel closed")
+
+// IsTunnelRequest returns true if req opens a tunnel over HTTP/2, as sent by
+// TunnelDialer.
+func IsTunnelRequest(req *http.Request) bool {
+	return req.ProtoMajor == 2 && req.Method == http.MethodPost && req.Header.Get(HeaderStreamTunnel) == StreamTunnelV1
+}
+
+// tunnelConn is a messageConn over the body of an HTTP/2 request and the
+// body of its response, which both stay open for the lifetime of the tunnel.
+type tunnelConn struct {
+	r io.Reader
+
+	writeLock sync.Mutex
+	w         io.Writer
+	// flush, if set, sends written data to the other side.
+	flush func() error
+
+	closeOnce sync.Once
+	closers   []io.Closer
+	closed    chan struct{}
+
+	deadlineLock  sync.Mutex
+	deadlineTimer *time.Timer
+}
+
+func newTunnelConn(r io.Reader, w io.Writer, flush func() error, closers ...io.Closer) *tunnelConn {
+	return &tunnelConn{r: r, w: w, flush: flush, closers: closers, closed: make(chan struct{})}
+}
+
+func (c *tunnelConn) Send(message []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	select {
+	case <-c.closed:
+		return errTunnelClosed
+	default:
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(message)))
+	if _, err := c.w.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(message); err != nil {
+		return err
+	}
+	if c.flush != nil {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *tunnelConn) Receive() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxTunnelMessageSize {
+		return nil, fmt.Errorf("tunnel message of %d bytes exceeds the limit of %d bytes", n, maxTunnelMessageSize)
+	}
+	message := make([]byte, n)
+	if _, err := io.ReadFull(c.r, message); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return message, nil
+}
+
+// SetDeadline closes the tunnel at t. A zero t disables the deadline.
+func (c *tunnelConn) SetDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	if c.deadlineTimer != nil {
+		c.deadlineTimer.Stop()
+		c.deadlineTimer = nil
+	}
+	if !t.IsZero() {
+		c.deadlineTimer = time.AfterFunc(time.Until(t), func() {
+			c.Close() //nolint:errcheck
+		})
+	}
+	return nil
+}
+
+// Close closes the underlying readers and writers, which unblocks any pending
+// Send, so it does not wait for the write lock.
+func (c *tunnelConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		for _, closer := range c.closers {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		close(c.closed)
+		c.SetDeadline(time.Time{}) //nolint:errcheck
+	})
+	return err
+}
+
+// closerFunc adapts a function to the io.Closer interface.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// tunnelResponseUpgrader serves tunnels over HTTP/2. It implements the
+// httpstream.ResponseUpgrader interface.
+type tunnelResponseUpgrader struct{}
+
+// NewTunnelResponseUpgrader returns a new httpstream.ResponseUpgrader serving
+// multiplexed streams over a single HTTP/2 request, for clients connecting
+// through proxies which do not forward the Upgrade header. It accepts the
+// requests sent by TunnelDialer, see IsTunnelRequest.
+//
+// Unlike with upgrades, the response can only be written while the handler
+// runs, so the handler must not return before the returned connection is
+// closed, e.g. by waiting for its CloseChan.
+func NewTunnelResponseUpgrader() httpstream.ResponseUpgrader {
+	return tunnelResponseUpgrader{}
+}
+
+// UpgradeResponse responds to a tunnel request, and returns a connection that
+// supports multiplexed streams over the request and response bodies.
+// newStreamHandler will be called synchronously whenever the other end of the
+// connection creates a new stream.
+func (u tunnelResponseUpgrader) UpgradeResponse(w http.ResponseWriter, req *http.Request, newStreamHandler httpstream.NewStreamHandler) httpstream.Connection {
+	if !IsTunnelRequest(req) {
+		errorMsg := fmt.Sprintf("unable to open tunnel: expected an HTTP/2 POST request with %s: %s, got %s %s", HeaderStreamTunnel, StreamTunnelV1, req.Proto, req.Method)
+		http.Error(w, errorMsg, http.StatusBadRequest)
+		return nil
+	}
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil
+	}
+	conn := newConnection(newTunnelConn(req.Body, w, rc.Flush, req.Body), newStreamHandler, true)
+	go conn.serve()
+	return conn
+}
+
+// TunnelDialer implements httpstream.Dialer by opening a tunnel carrying
+// multiplexed streams over a single HTTP/2 request, as served by the upgrader
+// returned from NewTunnelResponseUpgrader.
+//
+// The tunnel is opened with a POST request whose body, like the body of the
+// response, stays open until the connection is closed. This is similar to the
+// extended CONNECT method of RFC 8441, which the HTTP/2 implementation used
+// does not support, and passes through proxies which forward HTTP/2 requests
+// but strip Upgrade headers.
+type TunnelDialer struct {
+	// Location is the http or https URL to connect to. Connections to http
+	// URLs use HTTP/2 with prior knowledge.
+	Location *url.URL
+	// TLSConfig is used when connecting to https URLs.
+	TLSConfig *tls.Config
+	// Header holds additional headers sent with the tunnel request, e.g. for
+	// authentication.
+	Header http.Header
+	// Transport, if set, is used to send the tunnel request instead of a
+	// transport created from TLSConfig. It must use HTTP/2.
+	Transport http.RoundTripper
+
+	// defaultTransport is created from TLSConfig on first use, and shared by
+	// the tunnels of the dialer.
+	defaultTransportOnce sync.Once
+	defaultTransport     *http2.Transport
+}
+
+var _ httpstream.Dialer = &TunnelDialer{}
+
+// NewTunnelDialer returns a TunnelDialer connecting to location.
+func NewTunnelDialer(location *url.URL, tlsConfig *tls.Config, header http.Header) *TunnelDialer {
+	return &TunnelDialer{Location: location, TLSConfig: tlsConfig, Header: header}
+}
+
+// Dial opens a tunnel to the server, offering the protocols specified in order
+// of most preferred to least preferred in the X-Stream-Protocol-Version header,
+// so servers can negotiate them with httpstream.Handshake. It returns the
+// connection and the protocol selected by the server, if any.
+func (d *TunnelDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	body, bodyWriter := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, d.Location.String(), body)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header = d.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(HeaderStreamTunnel, StreamTunnelV1)
+	for _, protocol := range protocols {
+		req.Header.Add(httpstream.HeaderProtocolVersion, protocol)
+	}
+
+	resp, err := d.transport().RoundTrip(req)
+	if err != nil {
+		bodyWriter.Close()
+		d.closeIdleConnections() //nolint:errcheck
+		return nil, "", &httpstream.UpgradeFailureError{Cause: fmt.Errorf("unable to open tunnel to %s: %w", d.Location.Redacted(), err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer d.closeIdleConnections() //nolint:errcheck
+		defer resp.Body.Close()
+		bodyWriter.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, "", &httpstream.UpgradeFailureError{Cause: fmt.Errorf("unable to open tunnel to %s: %s: %s", d.Location.Redacted(), resp.Status, strings.TrimSpace(string(message)))}
+	}
+
+	c := newConnection(newTunnelConn(resp.Body, bodyWriter, nil, bodyWriter, resp.Body, closerFunc(d.closeIdleConnections)), httpstream.NoOpNewStreamHandler, false)
+	go c.serve()
+	return c, resp.Header.Get(httpstream.HeaderProtocolVersion), nil
+}
+
+func (d *TunnelDialer) transport() http.RoundTripper {
+	if d.Transport != nil {
+		return d.Transport
+	}
+	d.defaultTransportOnce.Do(func() {
+		if d.Location.Scheme == "https" {
+			d.defaultTransport = &http2.Transport{TLSClientConfig: d.TLSConfig}
+			return
+		}
+		d.defaultTransport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+	})
+	return d.defaultTransport
+}
+
+// closeIdleConnections closes the connections of the default transport which
+// no longer carry a tunnel, so they do not outlive the tunnels. A Transport
+// set by the caller is left alone.
+func (d *TunnelDialer) closeIdleConnections() error {
+	if d.Transport == nil && d.defaultTransport != nil {
+		d.defaultTransport.CloseIdleConnections()
+	}
+	return nil
+}
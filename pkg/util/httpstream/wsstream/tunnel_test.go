@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// newTunnelServer starts an HTTP/2 server that opens tunnels carrying
+// multiplexed streams and sends every accepted stream on the returned channel.
+func newTunnelServer(t *testing.T, serverProtocols []string) (*httptest.Server, chan httpstream.Stream) {
+	streams := make(chan httpstream.Stream, 10)
+	handler := func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		streams <- stream
+		return nil
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(serverProtocols) > 0 {
+			if _, err := httpstream.Handshake(req, w, serverProtocols); err != nil {
+				return
+			}
+		}
+		conn := NewTunnelResponseUpgrader().UpgradeResponse(w, req, handler)
+		if conn == nil {
+			return
+		}
+		<-conn.CloseChan()
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	return server, streams
+}
+
+func newTunnelDialer(t *testing.T, server *httptest.Server) *TunnelDialer {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+	return NewTunnelDialer(u, tlsConfig, nil)
+}
+
+func TestTunnelRoundTrip(t *testing.T) {
+	server, serverStreams := newTunnelServer(t, []string{"v2", "v1"})
+	defer server.Close()
+
+	conn, protocol, err := newTunnelDialer(t, server).Dial("v3", "v1")
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "v1", protocol)
+
+	headers := http.Header{}
+	headers.Set("streamType", "stdin")
+	stdin, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+	headers = http.Header{}
+	headers.Set("streamType", "stdout")
+	stdout, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+
+	serverStdin, serverStdout := <-serverStreams, <-serverStreams
+	assert.Equal(t, "stdin", serverStdin.Headers().Get("streamType"))
+	assert.Equal(t, "stdout", serverStdout.Headers().Get("streamType"))
+
+	_, err = stdin.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, stdin.CloseWrite())
+	data, err := io.ReadAll(serverStdin)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = serverStdout.Write([]byte("world"))
+	require.NoError(t, err)
+	require.NoError(t, serverStdout.Close())
+	data, err = io.ReadAll(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+
+	require.NoError(t, conn.Close())
+	select {
+	case <-conn.CloseChan():
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the connection to close")
+	}
+}
+
+func TestTunnelNegotiationFailure(t *testing.T) {
+	server, _ := newTunnelServer(t, []string{"v2"})
+	defer server.Close()
+
+	_, _, err := newTunnelDialer(t, server).Dial("v1")
+	require.Error(t, err)
+	assert.True(t, httpstream.IsUpgradeFailure(err))
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestTunnelRequiresHTTP2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if conn := NewTunnelResponseUpgrader().UpgradeResponse(w, req, httpstream.NoOpNewStreamHandler); conn != nil {
+			t.Errorf("expected a tunnel over HTTP/1.1 to be rejected")
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderStreamTunnel, StreamTunnelV1)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTunnelDialerSharesTransport(t *testing.T) {
+	server, _ := newTunnelServer(t, nil)
+	defer server.Close()
+
+	dialer := newTunnelDialer(t, server)
+	first, _, err := dialer.Dial()
+	require.NoError(t, err)
+	transport := dialer.transport()
+	require.NoError(t, first.Close())
+	second, _, err := dialer.Dial()
+	require.NoError(t, err)
+	defer second.Close()
+	assert.Same(t, transport, dialer.transport())
+}
+
+func TestTunnelConnCloseDuringBlockedSend(t *testing.T) {
+	r, w := io.Pipe()
+	conn := newTunnelConn(r, w, nil, w, r)
+
+	sendErr := make(chan error, 1)
+	go func() {
+		// nothing reads the pipe, so the write blocks with the lock held
+		sendErr <- conn.Send([]byte("blocked"))
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		conn.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for Close")
+	}
+	select {
+	case err := <-sendErr:
+		assert.Error(t, err)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the blocked Send to fail")
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wsstream
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// responseUpgrader knows how to upgrade HTTP responses to websockets carrying
+// multiplexed streams. It implements the httpstream.ResponseUpgrader interface.
+type responseUpgrader struct{}
+
+// NewResponseUpgrader returns a new httpstream.ResponseUpgrader that is
+// capable of upgrading HTTP responses using WebSockets. It is an alternative to
+// the SPDY upgrader for clients that request a websocket upgrade.
+//
+// If the protocol was negotiated beforehand with httpstream.Handshake, the
+// negotiated protocol is returned to the client as the websocket subprotocol.
+// Otherwise the first subprotocol requested by the client is selected.
+func NewResponseUpgrader() httpstream.ResponseUpgrader {
+	return responseUpgrader{}
+}
+
+// UpgradeResponse upgrades an HTTP response to a websocket that supports
+// multiplexed streams. newStreamHandler will be called synchronously whenever
+// the other end of the upgraded connection creates a new stream.
+func (u responseUpgrader) UpgradeResponse(w http.ResponseWriter, req *http.Request, newStreamHandler httpstream.NewStreamHandler) httpstream.Connection {
+	if !IsWebSocketRequest(req) {
+		errorMsg := fmt.Sprintf("unable to upgrade: missing upgrade headers in request: %#v", req.Header)
+		http.Error(w, errorMsg, http.StatusBadRequest)
+		return nil
+	}
+
+	// headers set before the upgrade, e.g. by httpstream.Handshake, are sent
+	// back with the websocket handshake response.
+	header := w.Header().Clone()
+	negotiated := header.Get(httpstream.HeaderProtocolVersion)
+
+	connCh := make(chan *connection, 1)
+	// serveHTTPComplete is closed when "websocket#ServeHTTP" finishes.
+	serveHTTPComplete := make(chan struct{})
+	// Ensure panic in spawned goroutine is propagated into the parent goroutine.
+	panicChan := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			} else {
+				close(serveHTTPComplete)
+			}
+		}()
+		websocket.Server{
+			Handshake: func(config *websocket.Config, req *http.Request) error {
+				config.Header = header
+				return selectProtocol(config, negotiated)
+			},
+			Handler: func(ws *websocket.Conn) {
+				conn := newServerConnection(ws, newStreamHandler)
+				connCh <- conn
+				conn.serve()
+			},
+		}.ServeHTTP(w, req)
+	}()
+
+	select {
+	case conn := <-connCh:
+		return conn
+	case <-serveHTTPComplete:
+		// websocket server returned before completing the handshake.
+		return nil
+	case p := <-panicChan:
+		panic(p)
+	}
+}
+
+// selectProtocol chooses the websocket subprotocol returned to the client.
+func selectProtocol(config *websocket.Config, negotiated string) error {
+	if len(config.Protocol) == 0 {
+		return nil
+	}
+	if len(negotiated) == 0 {
+		config.Protocol = config.Protocol[:1]
+		return nil
+	}
+	for _, protocol := range config.Protocol {
+		if protocol == negotiated {
+			config.Protocol = []string{protocol}
+			return nil
+		}
+	}
+	return fmt.Errorf("negotiated protocol %q was not requested by the client: %v", negotiated, config.Protocol)
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
+	"strconv"
+	"strings"
 
 	kjson "sigs.k8s.io/json"
 )
@@ -119,3 +123,146 @@ func convertNumber(n json.Number) (interface{}, error) {
 	// An overflow will return an error
 	return n.Float64()
 }
+
+// NumberMode controls how numbers are decoded into interface{} values.
+type NumberMode int
+
+const (
+	// NumberModeInt64OrFloat64 decodes integers that fit in an int64 to int64,
+	// and all other numbers to float64. This is the behavior of Unmarshal.
+	NumberModeInt64OrFloat64 NumberMode = iota
+	// NumberModeJSONNumber keeps all numbers as json.Number.
+	NumberModeJSONNumber
+	// NumberModePreservePrecision decodes numbers like NumberModeInt64OrFloat64
+	// when that is lossless, and keeps them as json.Number otherwise: integers
+	// that overflow an int64, numbers that overflow a float64, and decimals whose
+	// value differs from that of the shortest representation of their float64.
+	NumberModePreservePrecision
+)
+
+// DecodeOptions controls how JSON is decoded into interface{} values.
+type DecodeOptions struct {
+	// Numbers controls how numbers are decoded.
+	Numbers NumberMode
+}
+
+// UnmarshalWithOptions is like Unmarshal, decoding numbers according to opts
+// if v is a *map[string]interface{}, *[]interface{}, or *interface{}.
+func UnmarshalWithOptions(data []byte, v interface{}, opts DecodeOptions) error {
+	if opts.Numbers == NumberModeInt64OrFloat64 {
+		return Unmarshal(data, v)
+	}
+	switch v := v.(type) {
+	case *map[string]interface{}, *[]interface{}, *interface{}:
+		// object keys are only matched case-insensitively against struct fields,
+		// which these types do not have
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(v); err != nil {
+			return err
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return fmt.Errorf("unexpected data after top-level value")
+		}
+		switch v := v.(type) {
+		case *map[string]interface{}:
+			_, err := ConvertNumbersWithOptions(*v, opts)
+			return err
+		case *[]interface{}:
+			_, err := ConvertNumbersWithOptions(*v, opts)
+			return err
+		case *interface{}:
+			var err error
+			*v, err = ConvertNumbersWithOptions(*v, opts)
+			return err
+		}
+	}
+	return Unmarshal(data, v)
+}
+
+// ConvertNumbersWithOptions converts the json.Number values in v according to
+// opts, where v is a value decoded with json.Decoder.UseNumber. Values which are
+// map[string]interface{} or []interface{} are recursively visited and converted
+// in place. It returns v, or the converted number if v is a json.Number.
+func ConvertNumbersWithOptions(v interface{}, opts DecodeOptions) (interface{}, error) {
+	return convertNumbersWithOptions(v, opts, 0)
+}
+
+func convertNumbersWithOptions(v interface{}, opts DecodeOptions, depth int) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return convertNumberWithOptions(v, opts)
+	case map[string]interface{}:
+		if depth > maxDepth {
+			return nil, fmt.Errorf("exceeded max depth of %d", maxDepth)
+		}
+		for k, item := range v {
+			converted, err := convertNumbersWithOptions(item, opts, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = converted
+		}
+	case []interface{}:
+		if depth > maxDepth {
+			return nil, fmt.Errorf("exceeded max depth of %d", maxDepth)
+		}
+		for i, item := range v {
+			converted, err := convertNumbersWithOptions(item, opts, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+	}
+	return v, nil
+}
+
+// convertNumberWithOptions converts a json.Number according to opts.
+func convertNumberWithOptions(n json.Number, opts DecodeOptions) (interface{}, error) {
+	switch opts.Numbers {
+	case NumberModeJSONNumber:
+		return n, nil
+	case NumberModePreservePrecision:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		s := n.String()
+		if !strings.ContainsAny(s, ".eE") {
+			// an integer overflowing int64
+			return n, nil
+		}
+		f, err := n.Float64()
+		if err != nil || !exponentInRange(s) {
+			return n, nil
+		}
+		original, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		shortest, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+		if original.Cmp(shortest) != 0 {
+			return n, nil
+		}
+		return f, nil
+	default:
+		return convertNumber(n)
+	}
+}
+
+// maxExactExponent bounds the explicit exponents of numbers whose precision is
+// checked exactly. A float64 has a decimal exponent between -324 and 308, and
+// the exact check expands the exponent into a number of as many digits.
+const maxExactExponent = 400
+
+// exponentInRange reports whether the explicit exponent of the number s, if
+// any, is within maxExactExponent. Numbers with larger exponents are kept as
+// json.Number rather than checked.
+func exponentInRange(s string) bool {
+	i := strings.IndexAny(s, "eE")
+	if i < 0 {
+		return true
+	}
+	exp, err := strconv.ParseInt(s[i+1:], 10, 64)
+	return err == nil && exp >= -maxExactExponent && exp <= maxExactExponent
+}
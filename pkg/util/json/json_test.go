@@ -417,3 +417,56 @@ func TestUnmarshalNil(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	testCases := []struct {
+		In      string
+		Numbers NumberMode
+		Out     interface{}
+	}{
+		{`{"a":1,"b":1.5}`, NumberModeInt64OrFloat64, map[string]interface{}{"a": int64(1), "b": float64(1.5)}},
+		{`{"a":1,"b":1.5}`, NumberModeJSONNumber, map[string]interface{}{"a": gojson.Number("1"), "b": gojson.Number("1.5")}},
+		{`[1,1.5,0.1,1.50,1e3]`, NumberModePreservePrecision, []interface{}{int64(1), float64(1.5), float64(0.1), float64(1.5), float64(1000)}},
+		{`[9223372036854775808]`, NumberModeInt64OrFloat64, []interface{}{float64(9223372036854775808)}},
+		{`[9223372036854775808]`, NumberModePreservePrecision, []interface{}{gojson.Number("9223372036854775808")}},
+		{`[1.0000000000000000000001]`, NumberModePreservePrecision, []interface{}{gojson.Number("1.0000000000000000000001")}},
+		{`[1e400]`, NumberModePreservePrecision, []interface{}{gojson.Number("1e400")}},
+		{`[1.5e-5000000]`, NumberModePreservePrecision, []interface{}{gojson.Number("1.5e-5000000")}},
+		{`[1e99999999999999999999]`, NumberModePreservePrecision, []interface{}{gojson.Number("1e99999999999999999999")}},
+		{`[1e-300]`, NumberModePreservePrecision, []interface{}{float64(1e-300)}},
+		{`{"a":[{"b":18446744073709551616}]}`, NumberModePreservePrecision, map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": gojson.Number("18446744073709551616")}}}},
+		{`12345678901234567890`, NumberModePreservePrecision, gojson.Number("12345678901234567890")},
+	}
+
+	for i, tc := range testCases {
+		var out interface{}
+		if err := UnmarshalWithOptions([]byte(tc.In), &out, DecodeOptions{Numbers: tc.Numbers}); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(tc.Out, out) {
+			t.Errorf("%d: expected %#v, got %#v", i, tc.Out, out)
+		}
+	}
+
+	var m map[string]interface{}
+	if err := UnmarshalWithOptions([]byte(`{"a":1}`), &m, DecodeOptions{Numbers: NumberModeJSONNumber}); err != nil {
+		t.Fatal(err)
+	}
+	if m["a"] != gojson.Number("1") {
+		t.Errorf("expected json.Number, got %#v", m["a"])
+	}
+	if err := UnmarshalWithOptions([]byte(`{"a":1} {}`), &m, DecodeOptions{Numbers: NumberModeJSONNumber}); err == nil {
+		t.Error("expected an error for trailing data")
+	}
+
+	var s struct {
+		A interface{} `json:"a"`
+	}
+	if err := UnmarshalWithOptions([]byte(`{"A":1}`), &s, DecodeOptions{Numbers: NumberModeJSONNumber}); err != nil {
+		t.Fatal(err)
+	}
+	if s.A != nil {
+		t.Errorf("expected fields to be matched case-sensitively, got %#v", s.A)
+	}
+}
@@ -71,6 +71,9 @@ func CreateThreeWayJSONMergePatch(original, modified, current []byte, fns ...mer
 		return nil, err
 	}
 
+	if len(fns) == 0 {
+		return patch, nil
+	}
 	var patchMap map[string]interface{}
 	err = json.Unmarshal(patch, &patchMap)
 	if err != nil {
@@ -149,12 +152,97 @@ func keepOrDeleteNullInObj(m map[string]interface{}, keepNull bool) (map[string]
 	return filteredMap, nil
 }
 
+// MinimizeJSONMergePatch returns the smallest JSON merge patch which, applied
+// to original, has the same effect as patch. Keys which would not change
+// original are dropped, nulls deleting keys absent from original are dropped,
+// and objects merged into existing objects are reduced to the keys they change.
+// Lists are always replaced in JSON merge patches, so a list which differs from
+// the original one is kept as a whole. A patch which is not an object replaces
+// the entire document and is returned as is.
+func MinimizeJSONMergePatch(original, patch []byte) ([]byte, error) {
+	if len(original) == 0 {
+		original = []byte(`{}`)
+	}
+
+	var patchObj interface{}
+	if err := json.Unmarshal(patch, &patchObj); err != nil {
+		return nil, mergepatch.ErrBadJSONDoc
+	}
+	patchMap, ok := patchObj.(map[string]interface{})
+	if !ok {
+		return patch, nil
+	}
+
+	var originalObj interface{}
+	if err := json.Unmarshal(original, &originalObj); err != nil {
+		return nil, mergepatch.ErrBadJSONDoc
+	}
+	originalMap, ok := originalObj.(map[string]interface{})
+	if !ok {
+		// Objects are not merged into anything else than objects, the patch
+		// applies to an empty object instead.
+		originalMap = map[string]interface{}{}
+	}
+
+	return json.Marshal(minimizeObj(originalMap, patchMap))
+}
+
+// minimizeObj returns the keys of patch which change original.
+func minimizeObj(original, patch map[string]interface{}) map[string]interface{} {
+	minimized := make(map[string]interface{})
+	for key, val := range patch {
+		originalVal, exists := original[key]
+		switch typedVal := val.(type) {
+		case nil:
+			// Deleting a missing key is a no-op.
+			if exists {
+				minimized[key] = nil
+			}
+		case map[string]interface{}:
+			if originalSubMap, ok := originalVal.(map[string]interface{}); ok {
+				// If the minimized submap is empty, the subtree is unchanged and
+				// the key should not be set.
+				if subMap := minimizeObj(originalSubMap, typedVal); len(subMap) != 0 {
+					minimized[key] = subMap
+				}
+				continue
+			}
+			// The object replaces the original value, without the keys it
+			// deletes.
+			replacement := deleteNullInObj(typedVal)
+			if !exists || !reflect.DeepEqual(originalVal, replacement) {
+				minimized[key] = replacement
+			}
+		default:
+			if !exists || !reflect.DeepEqual(originalVal, val) {
+				minimized[key] = val
+			}
+		}
+	}
+	return minimized
+}
+
+// deleteNullInObj returns m without null values, recursively. Unlike
+// keepOrDeleteNullInObj, it keeps the objects which end up empty, as applying
+// a merge patch does.
+func deleteNullInObj(m map[string]interface{}) map[string]interface{} {
+	filteredMap := make(map[string]interface{}, len(m))
+	for key, val := range m {
+		switch typedVal := val.(type) {
+		case nil:
+		case map[string]interface{}:
+			filteredMap[key] = deleteNullInObj(typedVal)
+		default:
+			filteredMap[key] = val
+		}
+	}
+	return filteredMap
+}
+
 func meetPreconditions(patchObj map[string]interface{}, fns ...mergepatch.PreconditionFunc) (bool, error) {
 	// Apply the preconditions to the patch, and return an error if any of them fail.
-	for _, fn := range fns {
-		if !fn(patchObj) {
-			return false, fmt.Errorf("precondition failed for: %v", patchObj)
-		}
+	if !mergepatch.CheckPreconditions(patchObj, fns...) {
+		return false, fmt.Errorf("precondition failed for: %v", patchObj)
 	}
 	return true, nil
 }
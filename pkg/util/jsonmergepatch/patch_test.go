@@ -745,3 +745,106 @@ func jsonToYAML(j []byte) ([]byte, error) {
 	}
 	return y, nil
 }
+
+func TestMinimizeJSONMergePatch(t *testing.T) {
+	original := `{"kind":"Pod","metadata":{"name":"foo","labels":{"a":"1","b":"2"},"annotations":null},"spec":{"containers":[{"name":"c","image":"i"}],"nodeName":"n"},"status":"Running"}`
+	for _, tc := range []struct {
+		description string
+		original    string
+		patch       string
+		expected    string
+	}{
+		{
+			description: "unchanged keys are dropped",
+			original:    original,
+			patch:       `{"kind":"Pod","metadata":{"name":"foo","labels":{"a":"1","b":"3"}}}`,
+			expected:    `{"metadata":{"labels":{"b":"3"}}}`,
+		},
+		{
+			description: "deleting missing keys is dropped",
+			original:    original,
+			patch:       `{"metadata":{"labels":{"c":null}},"missing":null,"status":null}`,
+			expected:    `{"status":null}`,
+		},
+		{
+			description: "deleting a null key is kept",
+			original:    original,
+			patch:       `{"metadata":{"annotations":null}}`,
+			expected:    `{"metadata":{"annotations":null}}`,
+		},
+		{
+			description: "entire subtree replacement is collapsed",
+			original:    original,
+			patch:       `{"spec":{"containers":[{"name":"c","image":"i"}],"nodeName":"m"}}`,
+			expected:    `{"spec":{"nodeName":"m"}}`,
+		},
+		{
+			description: "changed lists are kept whole",
+			original:    original,
+			patch:       `{"spec":{"containers":[{"name":"c","image":"j"}]}}`,
+			expected:    `{"spec":{"containers":[{"image":"j","name":"c"}]}}`,
+		},
+		{
+			description: "empty objects merged into objects are dropped",
+			original:    original,
+			patch:       `{"metadata":{},"spec":{"nodeName":"n"}}`,
+			expected:    `{}`,
+		},
+		{
+			description: "objects replacing other values are kept without nulls",
+			original:    original,
+			patch:       `{"status":{"phase":"Running","reason":null,"conditions":{}},"metadata":{"annotations":{"x":null}}}`,
+			expected:    `{"metadata":{"annotations":{}},"status":{"conditions":{},"phase":"Running"}}`,
+		},
+		{
+			description: "objects equal to the replaced value are dropped",
+			original:    `{"a":{"b":{"c":1}}}`,
+			patch:       `{"a":{"b":{"c":1,"d":null}}}`,
+			expected:    `{}`,
+		},
+		{
+			description: "empty original",
+			original:    ``,
+			patch:       `{"a":1,"b":null}`,
+			expected:    `{"a":1}`,
+		},
+		{
+			description: "non-object patch",
+			original:    original,
+			patch:       `[1]`,
+			expected:    `[1]`,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			minimized, err := MinimizeJSONMergePatch([]byte(tc.original), []byte(tc.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(minimized) != tc.expected {
+				t.Errorf("expected patch %s, got %s", tc.expected, minimized)
+			}
+
+			if len(tc.original) == 0 {
+				return
+			}
+			expected, err := jsonpatch.MergePatch([]byte(tc.original), []byte(tc.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := jsonpatch.MergePatch([]byte(tc.original), minimized)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonpatch.Equal(result, expected) {
+				t.Errorf("expected the minimized patch to result in %s, got %s", expected, result)
+			}
+		})
+	}
+
+	if _, err := MinimizeJSONMergePatch([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("expected an error for an invalid original document")
+	}
+	if _, err := MinimizeJSONMergePatch([]byte(`{}`), []byte(`{`)); err == nil {
+		t.Error("expected an error for an invalid patch")
+	}
+}
@@ -64,16 +64,30 @@ func ExtractInto(object runtime.Object, objectType typed.ParseableType, fieldMan
 	if !ok {
 		return nil
 	}
-	fieldset := &fieldpath.Set{}
-	err = fieldset.FromJSON(bytes.NewReader(fieldsEntry.FieldsV1.Raw))
+	m, err := extractFields(object, typedObj, fieldsEntry)
 	if err != nil {
-		return fmt.Errorf("error marshalling FieldsV1 to JSON: %w", err)
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, applyConfiguration); err != nil {
+		return fmt.Errorf("error extracting into obj from unstructured: %w", err)
+	}
+	return nil
+}
+
+// extractFields extracts the fields of typedObj owned by fieldsEntry as
+// unstructured content, with the type meta of object.
+func extractFields(object runtime.Object, typedObj *typed.TypedValue, fieldsEntry metav1.ManagedFieldsEntry) (map[string]interface{}, error) {
+	fieldset := &fieldpath.Set{}
+	if fieldsEntry.FieldsV1 != nil {
+		if err := fieldset.FromJSON(bytes.NewReader(fieldsEntry.FieldsV1.Raw)); err != nil {
+			return nil, fmt.Errorf("error marshalling FieldsV1 to JSON: %w", err)
+		}
 	}
 
 	u := typedObj.ExtractItems(fieldset.Leaves()).AsValue().Unstructured()
 	m, ok := u.(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("unable to convert managed fields for %s to unstructured, expected map, got %T", fieldManager, u)
+		return nil, fmt.Errorf("unable to convert managed fields for %s to unstructured, expected map, got %T", fieldsEntry.Manager, u)
 	}
 
 	// set the type meta manually if it doesn't exist to avoid missing kind errors
@@ -82,10 +96,7 @@ func ExtractInto(object runtime.Object, objectType typed.ParseableType, fieldMan
 		m["kind"] = object.GetObjectKind().GroupVersionKind().Kind
 		m["apiVersion"] = object.GetObjectKind().GroupVersionKind().GroupVersion().String()
 	}
-	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, applyConfiguration); err != nil {
-		return fmt.Errorf("error extracting into obj from unstructured: %w", err)
-	}
-	return nil
+	return m, nil
 }
 
 func findManagedFields(accessor metav1.Object, fieldManager string, subresource string) (metav1.ManagedFieldsEntry, bool) {
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/util/managedfields/internal"
+)
+
+// FieldsToSet decodes a set of field paths from the FieldsV1 format.
+func FieldsToSet(f metav1.FieldsV1) (*fieldpath.Set, error) {
+	s, err := internal.FieldsToSet(f)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetToFields encodes a set of field paths in the FieldsV1 format. The
+// encoding is stable: equal sets are always encoded to the same bytes.
+func SetToFields(s *fieldpath.Set) (metav1.FieldsV1, error) {
+	return internal.SetToFields(*s)
+}
+
+// SetToCBOR encodes a set of field paths in CBOR. The encoded set has the
+// structure of the FieldsV1 format, in deterministic CBOR with sorted map
+// keys, so equal sets are always encoded to the same bytes.
+func SetToCBOR(s *fieldpath.Set) ([]byte, error) {
+	f, err := SetToFields(s)
+	if err != nil {
+		return nil, err
+	}
+	var trie map[string]interface{}
+	if err := json.Unmarshal(f.Raw, &trie); err != nil {
+		return nil, err
+	}
+	return direct.Marshal(trie)
+}
+
+// SetFromCBOR decodes a set of field paths encoded by SetToCBOR.
+func SetFromCBOR(data []byte) (*fieldpath.Set, error) {
+	var trie map[string]interface{}
+	if err := direct.Unmarshal(data, &trie); err != nil {
+		return nil, fmt.Errorf("error decoding CBOR field set: %w", err)
+	}
+	if trie == nil {
+		return nil, fmt.Errorf("error decoding CBOR field set: expected a map")
+	}
+	raw, err := json.Marshal(trie)
+	if err != nil {
+		return nil, err
+	}
+	return FieldsToSet(metav1.FieldsV1{Raw: raw})
+}
+
+// FieldsString returns a human-readable representation of the field paths of
+// f for debugging, as rendered by metav1.FieldsV1Tree.
+func FieldsString(f metav1.FieldsV1) (string, error) {
+	return metav1.FieldsV1Tree(&f)
+}
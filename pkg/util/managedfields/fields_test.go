@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"bytes"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testFields = `{"f:metadata":{"f:labels":{".":{},"f:app":{}}},"f:spec":{"f:containers":{"k:{\"name\":\"nginx\"}":{".":{},"f:image":{}}}}}`
+
+func TestSetRoundTrip(t *testing.T) {
+	s, err := FieldsToSet(metav1.FieldsV1{Raw: []byte(testFields)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := SetToFields(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(f.Raw) != testFields {
+		t.Errorf("Expected %s, got %s", testFields, f.Raw)
+	}
+
+	data, err := SetToCBOR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(f.Raw) {
+		t.Errorf("Expected CBOR encoding to be smaller than %d bytes, got %d", len(f.Raw), len(data))
+	}
+	again, err := SetToCBOR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("Expected stable CBOR encoding, got %x and %x", data, again)
+	}
+	decoded, err := SetFromCBOR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equals(s) {
+		t.Errorf("Expected %v, got %v", s, decoded)
+	}
+
+	for _, invalid := range [][]byte{{0xf6}, {0x01}, {0xa1, 0x61, 'x', 0xa0}} {
+		if _, err := SetFromCBOR(invalid); err == nil {
+			t.Errorf("Expected error decoding %x", invalid)
+		}
+	}
+}
+
+func TestFieldsString(t *testing.T) {
+	out, err := FieldsString(metav1.FieldsV1{Raw: []byte(testFields)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `.metadata
+  .labels
+    .app
+.spec
+  .containers
+    [name="nginx"]
+      .image
+`
+	if out != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	if _, err := FieldsString(metav1.FieldsV1{Raw: []byte(`{"x":{}}`)}); err == nil {
+		t.Error("Expected an error for invalid fields")
+	}
+}
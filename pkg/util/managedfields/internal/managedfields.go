@@ -84,7 +84,7 @@ func EncodeObjectManagedFields(obj runtime.Object, managed ManagedInterface) err
 		panic(fmt.Sprintf("couldn't get accessor: %v", err))
 	}
 
-	encodedManagedFields, err := encodeManagedFields(managed)
+	encodedManagedFields, err := EncodeManagedFields(managed)
 	if err != nil {
 		return fmt.Errorf("failed to convert back managed fields to API: %v", err)
 	}
@@ -170,9 +170,9 @@ func decodeVersionedSet(encodedVersionedSet *metav1.ManagedFieldsEntry) (version
 	return fieldpath.NewVersionedSet(&set, fieldpath.APIVersion(encodedVersionedSet.APIVersion), encodedVersionedSet.Operation == metav1.ManagedFieldsOperationApply), nil
 }
 
-// encodeManagedFields converts ManagedFields from the format used by
+// EncodeManagedFields converts ManagedFields from the format used by
 // sigs.k8s.io/structured-merge-diff to the wire format (api format)
-func encodeManagedFields(managed ManagedInterface) (encodedManagedFields []metav1.ManagedFieldsEntry, err error) {
+func EncodeManagedFields(managed ManagedInterface) (encodedManagedFields []metav1.ManagedFieldsEntry, err error) {
 	if len(managed.Fields()) == 0 {
 		return nil, nil
 	}
@@ -280,7 +280,7 @@ func TestRoundTripManagedFields(t *testing.T) {
 			if err != nil {
 				t.Fatalf("did not expect decoding error but got: %v", err)
 			}
-			encoded, err := encodeManagedFields(decoded)
+			encoded, err := EncodeManagedFields(decoded)
 			if err != nil {
 				t.Fatalf("did not expect encoding error but got: %v", err)
 			}
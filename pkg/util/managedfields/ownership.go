@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/managedfields/internal"
+)
+
+// DecodeManagedFields converts the managedFields of an object from the wire
+// format to the format used by sigs.k8s.io/structured-merge-diff. The
+// returned map is keyed by manager identifiers, as built by
+// BuildManagerIdentifier.
+func DecodeManagedFields(encodedManagedFields []metav1.ManagedFieldsEntry) (fieldpath.ManagedFields, error) {
+	managed, err := internal.DecodeManagedFields(encodedManagedFields)
+	if err != nil {
+		return nil, err
+	}
+	return managed.Fields(), nil
+}
+
+// BuildManagerIdentifier returns the identifier of the manager of a managed
+// fields entry. Entries are merged by identifier when managed fields are
+// updated, so two entries with the same identifier are the same manager.
+func BuildManagerIdentifier(entry *metav1.ManagedFieldsEntry) (string, error) {
+	return internal.BuildManagerIdentifier(entry)
+}
+
+// FieldOwners returns the entries of encodedManagedFields whose field set
+// contains path, in the order they appear. An entry owns a path if the path is
+// in its field set; it does not own the children of the path unless they are
+// listed as well.
+func FieldOwners(encodedManagedFields []metav1.ManagedFieldsEntry, path fieldpath.Path) ([]metav1.ManagedFieldsEntry, error) {
+	managed, err := DecodeManagedFields(encodedManagedFields)
+	if err != nil {
+		return nil, err
+	}
+	var owners []metav1.ManagedFieldsEntry
+	for i := range encodedManagedFields {
+		manager, err := BuildManagerIdentifier(&encodedManagedFields[i])
+		if err != nil {
+			return nil, err
+		}
+		if set, ok := managed[manager]; ok && set.Set().Has(path) {
+			owners = append(owners, encodedManagedFields[i])
+		}
+	}
+	return owners, nil
+}
+
+// ExtractOwned extracts the fields of object owned by the managed fields entry
+// as unstructured content. The entry is typically one of the managed fields of
+// the object, as returned by FieldOwners or GetManagedFields. The apiVersion
+// and kind of the object are set in the result if the object has a kind.
+//
+// As for ExtractInto, the object MUST be a root resource object, and
+// objectType must be the type of the object in the apiVersion of the entry.
+func ExtractOwned(object runtime.Object, objectType typed.ParseableType, entry metav1.ManagedFieldsEntry) (map[string]interface{}, error) {
+	typedObj, err := toTyped(object, objectType)
+	if err != nil {
+		return nil, fmt.Errorf("error converting obj to typed: %w", err)
+	}
+	return extractFields(object, typedObj, entry)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFieldOwners(t *testing.T) {
+	update := metav1.ManagedFieldsEntry{
+		Manager:    "kubectl",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "apps/v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:paused":{}}}`)},
+	}
+	managedFields := []metav1.ManagedFieldsEntry{
+		applyFieldsEntry("mgr1", `{"f:spec":{"f:replicas":{}}}`, ""),
+		applyFieldsEntry("mgr2", `{"f:spec":{"f:replicas":{}}}`, ""),
+		update,
+		applyFieldsEntry("mgr1", `{"f:status":{"f:replicas":{}}}`, "status"),
+	}
+
+	managed, err := DecodeManagedFields(managedFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(managed) != 4 {
+		t.Errorf("Expected 4 managers, got %d", len(managed))
+	}
+
+	cases := []struct {
+		path     fieldpath.Path
+		expected []metav1.ManagedFieldsEntry
+	}{
+		{fieldpath.MakePathOrDie("spec", "replicas"), []metav1.ManagedFieldsEntry{managedFields[0], managedFields[1]}},
+		{fieldpath.MakePathOrDie("spec", "paused"), []metav1.ManagedFieldsEntry{update}},
+		{fieldpath.MakePathOrDie("status", "replicas"), []metav1.ManagedFieldsEntry{managedFields[3]}},
+		{fieldpath.MakePathOrDie("spec"), nil},
+	}
+	for _, tc := range cases {
+		owners, err := FieldOwners(managedFields, tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tc.expected, owners); diff != "" {
+			t.Errorf("Unexpected owners of %v: %s", tc.path, diff)
+		}
+	}
+
+	if _, err := FieldOwners([]metav1.ManagedFieldsEntry{{Manager: "mgr"}}, fieldpath.MakePathOrDie("spec")); err == nil {
+		t.Error("Expected invalid managed fields to be rejected")
+	}
+}
+
+func TestExtractOwned(t *testing.T) {
+	parser, err := typed.NewParser(schemaYAML)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	one, two := int32(1), int32(2)
+	obj := &fakeDeployment{
+		Spec:   fakeDeploymentSpec{Replicas: &one, Paused: true},
+		Status: fakeDeploymentStatus{Replicas: &two},
+	}
+
+	out, err := ExtractOwned(obj, parser.Type("io.k8s.api.apps.v1.Deployment"), applyFieldsEntry("mgr1", `{"f:spec":{"f:paused":{}},"f:status":{"f:replicas":{}}}`, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"spec":   map[string]interface{}{"paused": true},
+		"status": map[string]interface{}{"replicas": int64(2)},
+	}
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Errorf("Unexpected extracted fields: %s", diff)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/managedfields/internal"
+)
+
+// TransferOwnership returns a copy of managedFields in which the fields owned
+// by the entries of the manager named from are owned by the manager described
+// by to instead. Only the entries of from for the subresource of to are
+// considered. If fields is nil all their fields are transferred, otherwise only
+// the paths in fields are.
+//
+// The transferred fields are added to the entry with the same manager
+// identifier as to if there is one, or to a new entry otherwise, whose
+// Operation, APIVersion and Time are those of to. Entries of from left without
+// fields are removed. Field paths are not converted between API versions, so
+// the entries of from should have the same apiVersion as to.
+//
+// For example, migrating an object from client-side to server-side apply moves
+// all the fields of the "Update" entries of the client-side manager to an
+// "Apply" entry of the server-side manager. The result can be sent in a
+// subsequent update of the object.
+func TransferOwnership(managedFields []metav1.ManagedFieldsEntry, from string, to metav1.ManagedFieldsEntry, fields *fieldpath.Set) ([]metav1.ManagedFieldsEntry, error) {
+	switch to.Operation {
+	case metav1.ManagedFieldsOperationApply, metav1.ManagedFieldsOperationUpdate:
+	default:
+		return nil, fmt.Errorf("operation must be `Apply` or `Update`")
+	}
+	if len(to.APIVersion) < 1 {
+		return nil, fmt.Errorf("apiVersion must not be empty")
+	}
+	toManager, err := internal.BuildManagerIdentifier(&to)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := internal.DecodeManagedFields(managedFields)
+	if err != nil {
+		return nil, err
+	}
+
+	transferred := fieldpath.NewSet()
+	for i := range managedFields {
+		entry := &managedFields[i]
+		if entry.Manager != from || entry.Subresource != to.Subresource {
+			continue
+		}
+		manager, err := internal.BuildManagerIdentifier(entry)
+		if err != nil {
+			return nil, err
+		}
+		versionedSet, ok := managed.Fields()[manager]
+		if !ok || manager == toManager {
+			continue
+		}
+		owned := versionedSet.Set()
+		if fields != nil {
+			owned = owned.Intersection(fields)
+		}
+		if owned.Empty() {
+			continue
+		}
+		transferred = transferred.Union(owned)
+		remaining := versionedSet.Set().Difference(owned)
+		if remaining.Empty() {
+			delete(managed.Fields(), manager)
+			delete(managed.Times(), manager)
+		} else {
+			managed.Fields()[manager] = fieldpath.NewVersionedSet(remaining, versionedSet.APIVersion(), versionedSet.Applied())
+		}
+	}
+	if transferred.Empty() {
+		unchanged := make([]metav1.ManagedFieldsEntry, len(managedFields))
+		for i := range managedFields {
+			managedFields[i].DeepCopyInto(&unchanged[i])
+		}
+		return unchanged, nil
+	}
+
+	if existing, ok := managed.Fields()[toManager]; ok {
+		transferred = transferred.Union(existing.Set())
+	}
+	managed.Fields()[toManager] = fieldpath.NewVersionedSet(transferred, fieldpath.APIVersion(to.APIVersion), to.Operation == metav1.ManagedFieldsOperationApply)
+	if to.Time != nil {
+		managed.Times()[toManager] = to.Time
+	}
+	return internal.EncodeManagedFields(managed)
+}
+
+// RemoveManager returns a copy of managedFields without the entries of the
+// manager named manager, for all operations and subresources. The fields they
+// owned are no longer owned by that manager.
+//
+// Since updating an object with empty managedFields leaves its managed fields
+// unchanged, if no entries remain the result is a single empty entry, which
+// resets the managed fields of the object when sent in an update.
+func RemoveManager(managedFields []metav1.ManagedFieldsEntry, manager string) []metav1.ManagedFieldsEntry {
+	if len(managedFields) == 0 {
+		return managedFields
+	}
+	remaining := make([]metav1.ManagedFieldsEntry, 0, len(managedFields))
+	for _, entry := range managedFields {
+		if entry.Manager != manager {
+			remaining = append(remaining, entry)
+		}
+	}
+	if len(remaining) == 0 {
+		return []metav1.ManagedFieldsEntry{{}}
+	}
+	return remaining
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedfields
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func updateFieldsEntry(fieldManager string, fieldsJSON string, subresource string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:     fieldManager,
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		APIVersion:  "v1",
+		FieldsType:  "FieldsV1",
+		FieldsV1:    &metav1.FieldsV1{Raw: []byte(fieldsJSON)},
+		Subresource: subresource,
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	to := metav1.ManagedFieldsEntry{Manager: "ssa", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1"}
+	cases := []struct {
+		name          string
+		managedFields []metav1.ManagedFieldsEntry
+		fields        *fieldpath.Set
+		expected      []metav1.ManagedFieldsEntry
+	}{
+		{
+			name: "all fields",
+			managedFields: []metav1.ManagedFieldsEntry{
+				updateFieldsEntry("csa", `{"f:spec":{"f:paused":{},"f:replicas":{}}}`, ""),
+				updateFieldsEntry("csa", `{"f:status":{"f:replicas":{}}}`, "status"),
+				updateFieldsEntry("other", `{"f:metadata":{"f:labels":{}}}`, ""),
+			},
+			expected: []metav1.ManagedFieldsEntry{
+				applyFieldsEntry("ssa", `{"f:spec":{"f:paused":{},"f:replicas":{}}}`, ""),
+				updateFieldsEntry("csa", `{"f:status":{"f:replicas":{}}}`, "status"),
+				updateFieldsEntry("other", `{"f:metadata":{"f:labels":{}}}`, ""),
+			},
+		},
+		{
+			name: "selected fields merged into existing entry",
+			managedFields: []metav1.ManagedFieldsEntry{
+				updateFieldsEntry("csa", `{"f:spec":{"f:paused":{},"f:replicas":{}}}`, ""),
+				applyFieldsEntry("ssa", `{"f:metadata":{"f:labels":{}}}`, ""),
+			},
+			fields: fieldpath.NewSet(fieldpath.MakePathOrDie("spec", "replicas")),
+			expected: []metav1.ManagedFieldsEntry{
+				applyFieldsEntry("ssa", `{"f:metadata":{"f:labels":{}},"f:spec":{"f:replicas":{}}}`, ""),
+				updateFieldsEntry("csa", `{"f:spec":{"f:paused":{}}}`, ""),
+			},
+		},
+		{
+			name: "nothing to transfer",
+			managedFields: []metav1.ManagedFieldsEntry{
+				updateFieldsEntry("other", `{"f:spec":{"f:paused":{}}}`, ""),
+			},
+			expected: []metav1.ManagedFieldsEntry{
+				updateFieldsEntry("other", `{"f:spec":{"f:paused":{}}}`, ""),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := TransferOwnership(tc.managedFields, "csa", to, tc.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, out); diff != "" {
+				t.Errorf("Unexpected managed fields: %s", diff)
+			}
+			if err := ValidateManagedFields(out); err != nil {
+				t.Errorf("Invalid managed fields: %v", err)
+			}
+			// the result is a copy
+			out[0].Manager = "changed"
+			out[0].FieldsV1.Raw[0] = ' '
+			if tc.managedFields[0].Manager == "changed" || tc.managedFields[0].FieldsV1.Raw[0] == ' ' {
+				t.Errorf("Expected the input not to be modified through the result")
+			}
+		})
+	}
+
+	if _, err := TransferOwnership(nil, "csa", metav1.ManagedFieldsEntry{Manager: "ssa", APIVersion: "v1"}, nil); err == nil {
+		t.Error("Expected an error for a missing operation")
+	}
+}
+
+func TestRemoveManager(t *testing.T) {
+	managedFields := []metav1.ManagedFieldsEntry{
+		applyFieldsEntry("mgr1", `{"f:spec":{"f:replicas":{}}}`, ""),
+		updateFieldsEntry("mgr2", `{"f:spec":{"f:paused":{}}}`, ""),
+		updateFieldsEntry("mgr1", `{"f:status":{"f:replicas":{}}}`, "status"),
+	}
+	out := RemoveManager(managedFields, "mgr1")
+	if diff := cmp.Diff([]metav1.ManagedFieldsEntry{managedFields[1]}, out); diff != "" {
+		t.Errorf("Unexpected managed fields: %s", diff)
+	}
+	if out := RemoveManager(out, "mgr2"); !cmp.Equal([]metav1.ManagedFieldsEntry{{}}, out) {
+		t.Errorf("Expected a single empty entry, got %v", out)
+	}
+	if out := RemoveManager(nil, "mgr2"); out != nil {
+		t.Errorf("Expected nil, got %v", out)
+	}
+}
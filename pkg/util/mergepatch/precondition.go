@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mergepatch
+
+// Patch is a decoded JSON patch object, as passed to a PreconditionFunc, which
+// can be navigated by key paths.
+type Patch struct {
+	obj interface{}
+}
+
+// NewPatch returns a Patch navigating the decoded JSON patch obj.
+func NewPatch(obj interface{}) *Patch {
+	return &Patch{obj: obj}
+}
+
+// Object returns the decoded JSON patch.
+func (p *Patch) Object() interface{} {
+	return p.obj
+}
+
+// Get returns the value at the given key path, such as "metadata", "labels",
+// and true if all keys of the path are present in the nested objects of the
+// patch. An empty path refers to the whole patch.
+func (p *Patch) Get(path ...string) (interface{}, bool) {
+	value := p.obj
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// HasAny returns true if any of the given key paths is present in the patch.
+func (p *Patch) HasAny(paths ...[]string) bool {
+	for _, path := range paths {
+		if _, ok := p.Get(path...); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Precondition asserts that an incompatible change is not present within the
+// parts of a patch it declares.
+type Precondition struct {
+	// Paths are the key paths below which Check inspects the patch. If the
+	// patch contains none of them, Check is not called and the precondition
+	// holds. If Paths is empty, Check is always called.
+	Paths [][]string
+	// Check returns false if the patch contains an incompatible change.
+	Check func(patch *Patch) bool
+}
+
+// Func returns the PreconditionFunc evaluating p, which can be passed to the
+// functions creating patches.
+func (p Precondition) Func() PreconditionFunc {
+	return func(obj interface{}) bool {
+		return p.holds(NewPatch(obj))
+	}
+}
+
+func (p Precondition) holds(patch *Patch) bool {
+	if len(p.Paths) > 0 && !patch.HasAny(p.Paths...) {
+		return true
+	}
+	return p.Check(patch)
+}
+
+// CheckPreconditions evaluates fns against the decoded JSON patch obj, in order,
+// and returns false as soon as one of them fails.
+func CheckPreconditions(obj interface{}, fns ...PreconditionFunc) bool {
+	for _, fn := range fns {
+		if !fn(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPatchPreconditions evaluates preconditions against the decoded JSON
+// patch obj, in order, and returns false as soon as one of them fails. Unlike
+// with CheckPreconditions, the patch is wrapped once in a Patch shared by all
+// of preconditions.
+func CheckPatchPreconditions(obj interface{}, preconditions ...Precondition) bool {
+	patch := NewPatch(obj)
+	for _, p := range preconditions {
+		if !p.holds(patch) {
+			return false
+		}
+	}
+	return true
+}
+
+// changed is the Check of preconditions failing whenever one of their paths is
+// present in the patch.
+func changed(*Patch) bool {
+	return false
+}
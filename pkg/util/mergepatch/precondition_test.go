@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mergepatch
+
+import (
+	"testing"
+)
+
+func TestPatchGet(t *testing.T) {
+	patch := NewPatch(map[string]interface{}{
+		"kind": "Pod",
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": []interface{}{"not an object"},
+	})
+	testCases := []struct {
+		path  []string
+		found bool
+	}{
+		{path: nil, found: true},
+		{path: []string{"kind"}, found: true},
+		{path: []string{"metadata", "labels", "app"}, found: true},
+		{path: []string{"metadata", "name"}, found: false},
+		{path: []string{"kind", "name"}, found: false},
+		{path: []string{"spec", "0"}, found: false},
+	}
+	for _, tc := range testCases {
+		if _, found := patch.Get(tc.path...); found != tc.found {
+			t.Errorf("%v: expected found to be %v, got %v", tc.path, tc.found, found)
+		}
+	}
+	if !patch.HasAny([]string{"metadata", "name"}, []string{"kind"}) {
+		t.Errorf("expected the patch to have kind")
+	}
+	if patch.HasAny([]string{"metadata", "name"}) {
+		t.Errorf("expected the patch not to have metadata.name")
+	}
+}
+
+func TestPreconditionShortCircuits(t *testing.T) {
+	calls := 0
+	precondition := Precondition{
+		Paths: [][]string{{"metadata", "labels"}},
+		Check: func(patch *Patch) bool {
+			calls++
+			labels, _ := patch.Get("metadata", "labels")
+			_, ok := labels.(map[string]interface{})["immutable"]
+			return !ok
+		},
+	}.Func()
+
+	if !precondition(map[string]interface{}{"spec": map[string]interface{}{}}) {
+		t.Errorf("expected the precondition to hold for a patch without labels")
+	}
+	if calls != 0 {
+		t.Errorf("expected Check not to be called for a patch without labels, got %d calls", calls)
+	}
+	if !precondition(map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}}}) {
+		t.Errorf("expected the precondition to hold for a patch with other labels")
+	}
+	if precondition(map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"immutable": "x"}}}) {
+		t.Errorf("expected the precondition to fail for a patch changing the immutable label")
+	}
+	if calls != 2 {
+		t.Errorf("expected Check to be called twice, got %d calls", calls)
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	patch := map[string]interface{}{
+		"kind":     "Pod",
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{}},
+	}
+	testCases := []struct {
+		name     string
+		fns      []PreconditionFunc
+		expected bool
+	}{
+		{name: "none", expected: true},
+		{name: "unchanged", fns: []PreconditionFunc{RequireKeyUnchanged("apiVersion"), RequireMetadataKeyUnchanged("name")}, expected: true},
+		{name: "key changed", fns: []PreconditionFunc{RequireKeyUnchanged("apiVersion"), RequireKeyUnchanged("kind")}, expected: false},
+		{name: "metadata key changed", fns: []PreconditionFunc{RequireMetadataKeyUnchanged("labels")}, expected: false},
+		{name: "first of several fails", fns: []PreconditionFunc{RequireMetadataKeyUnchanged("labels"), func(interface{}) bool { return true }}, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := CheckPreconditions(patch, tc.fns...); result != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+
+	evaluated := false
+	CheckPreconditions(patch, RequireKeyUnchanged("kind"), func(interface{}) bool {
+		evaluated = true
+		return true
+	})
+	if evaluated {
+		t.Errorf("expected evaluation to stop at the first failing precondition")
+	}
+}
+
+func TestCheckPreconditionsPassesDecodedPatch(t *testing.T) {
+	patch := map[string]interface{}{"kind": "Pod"}
+	CheckPreconditions(patch, func(obj interface{}) bool {
+		if _, ok := obj.(map[string]interface{}); !ok {
+			t.Errorf("expected the decoded patch, got %T", obj)
+		}
+		return true
+	})
+}
+
+func TestCheckPatchPreconditions(t *testing.T) {
+	patch := map[string]interface{}{
+		"kind":     "Pod",
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{}},
+	}
+	var received []*Patch
+	record := Precondition{Check: func(p *Patch) bool {
+		received = append(received, p)
+		return true
+	}}
+	if !CheckPatchPreconditions(patch, record, Precondition{Paths: [][]string{{"apiVersion"}}, Check: changed}, record) {
+		t.Errorf("expected the preconditions to hold")
+	}
+	if len(received) != 2 || received[0] != received[1] {
+		t.Errorf("expected the same Patch to be passed to all preconditions, got %v", received)
+	}
+
+	evaluated := false
+	failing := Precondition{Paths: [][]string{{"metadata", "labels"}}, Check: changed}
+	if CheckPatchPreconditions(patch, failing, Precondition{Check: func(*Patch) bool {
+		evaluated = true
+		return true
+	}}) {
+		t.Errorf("expected the preconditions to fail")
+	}
+	if evaluated {
+		t.Errorf("expected evaluation to stop at the first failing precondition")
+	}
+}
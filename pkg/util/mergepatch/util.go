@@ -30,38 +30,15 @@ type PreconditionFunc func(interface{}) bool
 // RequireKeyUnchanged returns a precondition function that fails if the provided key
 // is present in the patch (indicating that its value has changed).
 func RequireKeyUnchanged(key string) PreconditionFunc {
-	return func(patch interface{}) bool {
-		patchMap, ok := patch.(map[string]interface{})
-		if !ok {
-			return true
-		}
-
-		// The presence of key means that its value has been changed, so the test fails.
-		_, ok = patchMap[key]
-		return !ok
-	}
+	// The presence of key means that its value has been changed, so the test fails.
+	return Precondition{Paths: [][]string{{key}}, Check: changed}.Func()
 }
 
 // RequireMetadataKeyUnchanged creates a precondition function that fails
 // if the metadata.key is present in the patch (indicating its value
 // has changed).
 func RequireMetadataKeyUnchanged(key string) PreconditionFunc {
-	return func(patch interface{}) bool {
-		patchMap, ok := patch.(map[string]interface{})
-		if !ok {
-			return true
-		}
-		patchMap1, ok := patchMap["metadata"]
-		if !ok {
-			return true
-		}
-		patchMap2, ok := patchMap1.(map[string]interface{})
-		if !ok {
-			return true
-		}
-		_, ok = patchMap2[key]
-		return !ok
-	}
+	return Precondition{Paths: [][]string{{"metadata", key}}, Check: changed}.Func()
 }
 
 func ToYAMLOrError(v interface{}) string {
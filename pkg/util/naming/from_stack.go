@@ -18,6 +18,8 @@ package naming
 
 import (
 	"fmt"
+	"path"
+	"reflect"
 	"regexp"
 	goruntime "runtime"
 	"runtime/debug"
@@ -25,31 +27,142 @@ import (
 	"strings"
 )
 
+// CallsiteOptions configures GetNameFromCallsiteWithOptions.
+type CallsiteOptions struct {
+	// IgnoredPackages are skipped when walking back through the call stack. They
+	// are matched against the file paths of the callers, and against the paths
+	// of their files within their packages, such as
+	// "k8s.io/apimachinery/pkg/util/naming/from_stack.go".
+	IgnoredPackages []string
+	// PackagePaths names files by the import paths of their packages when they
+	// are known, such as "k8s.io/apimachinery/pkg/util/naming/from_stack.go:12",
+	// so that names do not depend on where modules are checked out. By default,
+	// files are named by their paths with duplicate values trimmed off their
+	// front, like GetNameFromCallsite does.
+	PackagePaths bool
+	// PrunePrefixes are removed from the returned names. The longest matching
+	// prefix is removed, e.g. "k8s.io/kubernetes/" turns
+	// "k8s.io/kubernetes/pkg/controller/foo.go:12" into "pkg/controller/foo.go:12".
+	PrunePrefixes []string
+}
+
 // GetNameFromCallsite walks back through the call stack until we find a caller from outside of the ignoredPackages
 // it returns back a shortpath/filename:line to aid in identification of this reflector when it starts logging
 func GetNameFromCallsite(ignoredPackages ...string) string {
+	return getNameFromCallsite(CallsiteOptions{IgnoredPackages: ignoredPackages})
+}
+
+// GetNameFromCallsiteWithOptions is like GetNameFromCallsite, with the ignored packages, the
+// naming of files and the prefixes pruned from the name configured by opts.
+func GetNameFromCallsiteWithOptions(opts CallsiteOptions) string {
+	return getNameFromCallsite(opts)
+}
+
+// getNameFromCallsite must be called directly by the exported functions, so that the
+// call stack starts at their callers.
+func getNameFromCallsite(opts CallsiteOptions) string {
 	name := "????"
-	const maxStack = 10
-	for i := 1; i < maxStack; i++ {
-		_, file, line, ok := goruntime.Caller(i)
-		if !ok {
-			file, line, ok = extractStackCreator()
+	ignoredPackages := append(opts.IgnoredPackages[:len(opts.IgnoredPackages):len(opts.IgnoredPackages)], "/runtime/asm_")
+	const maxStack = 11
+	for i := 2; i < maxStack; i++ {
+		pc, file, line, ok := goruntime.Caller(i)
+		function := ""
+		if ok {
+			if fn := goruntime.FuncForPC(pc); fn != nil {
+				function = fn.Name()
+			}
+		} else {
+			function, file, line, ok = extractStackCreator()
 			if !ok {
 				break
 			}
 			i += maxStack
 		}
-		if hasPackage(file, append(ignoredPackages, "/runtime/asm_")) {
+		packageFile := packageFilePath(function, file)
+		if hasPackage(file, ignoredPackages) || hasPackage(packageFile, ignoredPackages) {
 			continue
 		}
 
-		file = trimPackagePrefix(file)
-		name = fmt.Sprintf("%s:%d", file, line)
+		if opts.PackagePaths {
+			file = packageFile
+		} else {
+			file = trimPackagePrefix(file)
+		}
+		name = fmt.Sprintf("%s:%d", prunePrefix(file, opts.PrunePrefixes), line)
 		break
 	}
 	return name
 }
 
+// packageFilePath returns the path of file within the package of function, such as
+// "k8s.io/apimachinery/pkg/util/naming/from_stack.go", or file with duplicate values
+// trimmed off its front if the package is not known.
+func packageFilePath(function, file string) string {
+	dir := path.Base(path.Dir(file))
+	// strip the version from directories in the module cache
+	if at := strings.Index(dir, "@"); at >= 0 {
+		dir = dir[:at]
+	}
+	if pkg := packagePath(function, dir); len(pkg) > 0 && pkg != "main" {
+		return pkg + "/" + path.Base(file)
+	}
+	return trimPackagePrefix(file)
+}
+
+// packagePath returns the import path of the package of the fully qualified function name,
+// such as "k8s.io/apimachinery/pkg/util/naming.GetNameFromCallsite". dir is the name of the
+// directory of the file of function, used to recognize package names containing dots.
+func packagePath(function, dir string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	name := function[lastSlash+1:]
+	if strings.HasPrefix(name, dir+".") {
+		return function[:lastSlash+1+len(dir)]
+	}
+	if dot := strings.Index(name, "."); dot >= 0 {
+		return function[:lastSlash+1+dot]
+	}
+	return ""
+}
+
+// prunePrefix removes the longest of prefixes from name.
+func prunePrefix(name string, prefixes []string) string {
+	longest := ""
+	for _, prefix := range prefixes {
+		if len(prefix) > len(longest) && strings.HasPrefix(name, prefix) {
+			longest = prefix
+		}
+	}
+	return name[len(longest):]
+}
+
+// packagePathPrefix matches the import path of a package before the package name in qualified
+// names, such as "k8s.io/apimachinery/pkg/runtime/" in "k8s.io/apimachinery/pkg/runtime/schema.GroupKind".
+var packagePathPrefix = regexp.MustCompile(`(?:[A-Za-z0-9_.~-]+/)+`)
+
+// TypeName returns a short, readable name for t, qualified by the name of its package, such as
+// "cache.TypedExpiring[string,schema.GroupKind]". Unlike t.String(), the names of the type
+// arguments of generic types are not qualified by their full package paths.
+func TypeName(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return packagePathPrefix.ReplaceAllString(t.String(), "")
+}
+
+// FuncName returns a short, readable name for the function fn, qualified by the name of its
+// package, such as "cache.(*TypedExpiring[...]).Set".
+func FuncName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "<nil>"
+	}
+	f := goruntime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "????"
+	}
+	return packagePathPrefix.ReplaceAllString(f.Name(), "")
+}
+
 // hasPackage returns true if the file is in one of the ignored packages.
 func hasPackage(file string, ignoredPackages []string) bool {
 	for _, ignoredPackage := range ignoredPackages {
@@ -74,20 +187,20 @@ func trimPackagePrefix(file string) string {
 	return file
 }
 
-var stackCreator = regexp.MustCompile(`(?m)^created by (.*)\n\s+(.*):(\d+) \+0x[[:xdigit:]]+$`)
+var stackCreator = regexp.MustCompile(`(?m)^created by (\S*)(?: in goroutine \d+)?\n\s+(.*):(\d+) \+0x[[:xdigit:]]+$`)
 
-// extractStackCreator retrieves the goroutine file and line that launched this stack. Returns false
-// if the creator cannot be located.
+// extractStackCreator retrieves the goroutine function, file and line that launched this stack.
+// Returns false if the creator cannot be located.
 // TODO: Go does not expose this via runtime https://github.com/golang/go/issues/11440
-func extractStackCreator() (string, int, bool) {
+func extractStackCreator() (string, string, int, bool) {
 	stack := debug.Stack()
 	matches := stackCreator.FindStringSubmatch(string(stack))
 	if len(matches) != 4 {
-		return "", 0, false
+		return "", "", 0, false
 	}
 	line, err := strconv.Atoi(matches[3])
 	if err != nil {
-		return "", 0, false
+		return "", "", 0, false
 	}
-	return matches[2], line, true
+	return matches[1], matches[2], line, true
 }
@@ -17,11 +17,14 @@ limitations under the License.
 package naming
 
 import (
+	"reflect"
+	goruntime "runtime"
 	"strings"
 	"testing"
 )
 
 func TestGetNameFromCallsite(t *testing.T) {
+	_, file, _, _ := goruntime.Caller(0)
 	tests := []struct {
 		name            string
 		ignoredPackages []string
@@ -29,7 +32,7 @@ func TestGetNameFromCallsite(t *testing.T) {
 	}{
 		{
 			name:     "simple",
-			expected: "k8s.io/apimachinery/pkg/util/naming/from_stack_test.go:",
+			expected: trimPackagePrefix(file) + ":",
 		},
 		{
 			name:            "ignore-package",
@@ -57,3 +60,67 @@ func TestGetNameFromCallsite(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNameFromCallsiteWithOptions(t *testing.T) {
+	actual := GetNameFromCallsiteWithOptions(CallsiteOptions{PackagePaths: true})
+	if expected := "k8s.io/apimachinery/pkg/util/naming/from_stack_test.go:"; !strings.HasPrefix(actual, expected) {
+		t.Errorf("expected string with prefix %q, got %q", expected, actual)
+	}
+	actual = GetNameFromCallsiteWithOptions(CallsiteOptions{PackagePaths: true, PrunePrefixes: []string{"k8s.io/", "k8s.io/apimachinery/"}})
+	if expected := "pkg/util/naming/from_stack_test.go:"; !strings.HasPrefix(actual, expected) {
+		t.Errorf("expected string with prefix %q, got %q", expected, actual)
+	}
+	actual = GetNameFromCallsiteWithOptions(CallsiteOptions{IgnoredPackages: []string{"k8s.io/apimachinery/pkg/util/naming"}, PrunePrefixes: []string{"testing/"}})
+	if expected := "testing.go:"; !strings.HasPrefix(actual, expected) {
+		t.Errorf("expected string with prefix %q, got %q", expected, actual)
+	}
+}
+
+func TestPackageFilePath(t *testing.T) {
+	tests := []struct {
+		function, file, expected string
+	}{
+		{"k8s.io/apimachinery/pkg/util/naming.TestPackageFilePath", "/src/k8s.io/apimachinery/pkg/util/naming/from_stack_test.go", "k8s.io/apimachinery/pkg/util/naming/from_stack_test.go"},
+		{"k8s.io/apimachinery/pkg/util/cache.(*TypedExpiring[...]).Set", "/home/user/apimachinery/pkg/util/cache/expiring.go", "k8s.io/apimachinery/pkg/util/cache/expiring.go"},
+		{"gopkg.in/yaml.v2.Unmarshal", "/go/pkg/mod/gopkg.in/yaml.v2@v2.4.0/yaml.go", "gopkg.in/yaml.v2/yaml.go"},
+		{"gopkg.in/yaml.v2.Unmarshal", "/go/src/vendor/gopkg.in/yaml.v2/yaml.go", "gopkg.in/yaml.v2/yaml.go"},
+		{"testing.tRunner", "/usr/local/go/src/testing/testing.go", "testing/testing.go"},
+		{"main.main", "/home/user/cmd/foo/main.go", "/home/user/cmd/foo/main.go"},
+		{"", "/go/src/k8s.io/foo/bar.go", "k8s.io/foo/bar.go"},
+	}
+	for _, test := range tests {
+		if actual := packageFilePath(test.function, test.file); actual != test.expected {
+			t.Errorf("packageFilePath(%q, %q): expected %q, got %q", test.function, test.file, test.expected, actual)
+		}
+	}
+}
+
+type genericType[K comparable, V any] struct{}
+
+type argumentType struct{}
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		t        reflect.Type
+		expected string
+	}{
+		{reflect.TypeOf(argumentType{}), "naming.argumentType"},
+		{reflect.TypeOf(&genericType[string, *argumentType]{}), "*naming.genericType[string,*naming.argumentType]"},
+		{reflect.TypeOf(map[string][]genericType[int, genericType[string, argumentType]]{}), "map[string][]naming.genericType[int,naming.genericType[string,naming.argumentType]]"},
+		{nil, "<nil>"},
+	}
+	for _, test := range tests {
+		if actual := TypeName(test.t); actual != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	if actual := FuncName(TypeName); actual != "naming.TypeName" {
+		t.Errorf("unexpected name %q", actual)
+	}
+	if actual := FuncName(nil); actual != "<nil>" {
+		t.Errorf("unexpected name %q", actual)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/klog/v2"
+)
+
+// Middleware wraps a RoundTripper with additional behavior.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Chain wraps rt with the given middlewares. The first middleware is the
+// outermost one, so it sees requests first and responses last.
+func Chain(rt http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
+
+// middlewareRoundTripper implements a Middleware with a function, and exposes
+// the wrapped RoundTripper so that DialerFor, TLSClientConfig and
+// CloseIdleConnectionsFor see through it.
+type middlewareRoundTripper struct {
+	rt        http.RoundTripper
+	roundTrip func(rt http.RoundTripper, req *http.Request) (*http.Response, error)
+}
+
+var _ RoundTripperWrapper = &middlewareRoundTripper{}
+
+func (m *middlewareRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.roundTrip(m.rt, req)
+}
+
+func (m *middlewareRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return m.rt
+}
+
+func (m *middlewareRoundTripper) CloseIdleConnections() {
+	CloseIdleConnectionsFor(m.rt)
+}
+
+func middleware(roundTrip func(rt http.RoundTripper, req *http.Request) (*http.Response, error)) Middleware {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &middlewareRoundTripper{rt: rt, roundTrip: roundTrip}
+	}
+}
+
+// WithUserAgent returns a Middleware setting the User-Agent header of requests
+// which have none.
+func WithUserAgent(userAgent string) Middleware {
+	return middleware(func(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+		if len(req.Header.Get("User-Agent")) != 0 {
+			return rt.RoundTrip(req)
+		}
+		req = CloneRequest(req)
+		req.Header.Set("User-Agent", userAgent)
+		return rt.RoundTrip(req)
+	})
+}
+
+// RedactHeaders returns a copy of header with the values of the given headers
+// replaced by "<masked>". Header names are matched case-insensitively.
+func RedactHeaders(header http.Header, names ...string) http.Header {
+	out := CloneHeader(header)
+	for _, name := range names {
+		values := out.Values(name)
+		for i := range values {
+			values[i] = "<masked>"
+		}
+	}
+	return out
+}
+
+// DebugLoggingOptions configures the Middleware returned by WithDebugLogging.
+type DebugLoggingOptions struct {
+	// Verbosity is the klog verbosity at which requests are logged.
+	Verbosity int
+	// RedactHeaders are the headers whose values are not logged. Authorization
+	// and Proxy-Authorization are always redacted.
+	RedactHeaders []string
+	// MaxBodyBytes is the maximum number of bytes of request and response
+	// bodies which are logged. Bodies are not logged if it is zero.
+	MaxBodyBytes int
+}
+
+// WithDebugLogging returns a Middleware logging requests and responses with
+// the logger of the request context. Request bodies are only logged if they
+// can be replayed with GetBody, and response bodies are logged when they are
+// closed, up to the amount read by the caller. The bodies of responses to
+// upgrade requests are the upgraded connection, and are never logged.
+func WithDebugLogging(opts DebugLoggingOptions) Middleware {
+	redact := append([]string{"Authorization", "Proxy-Authorization"}, opts.RedactHeaders...)
+	return middleware(func(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+		logger := klog.FromContext(req.Context()).V(opts.Verbosity)
+		if !logger.Enabled() {
+			return rt.RoundTrip(req)
+		}
+		upgrade := httpstream.IsUpgradeRequest(req)
+		keysAndValues := []interface{}{"method", req.Method, "url", req.URL.String(), "headers", RedactHeaders(req.Header, redact...)}
+		if opts.MaxBodyBytes > 0 && !upgrade && req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(io.LimitReader(body, int64(opts.MaxBodyBytes)))
+				body.Close()
+				keysAndValues = append(keysAndValues, "body", string(data))
+			}
+		}
+		logger.Info("HTTP request", keysAndValues...)
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			logger.Info("HTTP request failed", "method", req.Method, "url", req.URL.String(), "err", err)
+			return resp, err
+		}
+		keysAndValues = []interface{}{"method", req.Method, "url", req.URL.String(), "status", resp.Status, "headers", RedactHeaders(resp.Header, redact...)}
+		if opts.MaxBodyBytes == 0 || upgrade || resp.StatusCode == http.StatusSwitchingProtocols || resp.Body == nil {
+			logger.Info("HTTP response", keysAndValues...)
+			return resp, nil
+		}
+		resp.Body = &loggingBody{
+			ReadCloser: resp.Body,
+			remaining:  opts.MaxBodyBytes,
+			log: func(body []byte) {
+				logger.Info("HTTP response", append(keysAndValues, "body", string(body))...)
+			},
+		}
+		return resp, nil
+	})
+}
+
+// loggingBody records up to remaining bytes read from a response body, and
+// logs them when the body is closed.
+type loggingBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	remaining int
+	log       func(body []byte)
+	logged    bool
+}
+
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.remaining > 0 && n > 0 {
+		recorded := n
+		if recorded > b.remaining {
+			recorded = b.remaining
+		}
+		b.buf.Write(p[:recorded])
+		b.remaining -= recorded
+	}
+	return n, err
+}
+
+func (b *loggingBody) Close() error {
+	if !b.logged {
+		b.logged = true
+		b.log(b.buf.Bytes())
+	}
+	return b.ReadCloser.Close()
+}
+
+// WithRetryOnConnectionReset returns a Middleware retrying requests up to
+// maxRetries times when the connection is reset or refused before a response
+// is received. Only requests with an idempotent method whose body, if any, can
+// be replayed with GetBody are retried. Upgrade requests are retried, since no
+// connection was established for them yet.
+func WithRetryOnConnectionReset(maxRetries int) Middleware {
+	return middleware(func(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		for retries := 0; retries < maxRetries && err != nil && isRetriable(req, err); retries++ {
+			if req.Body != nil && req.Body != http.NoBody {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					return resp, err
+				}
+				req = CloneRequest(req)
+				req.Body = body
+			}
+			resp, err = rt.RoundTrip(req)
+		}
+		return resp, err
+	})
+}
+
+func isRetriable(req *http.Request, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if !IsConnectionReset(err) && !IsConnectionRefused(err) && !IsHTTP2ConnectionLost(err) {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
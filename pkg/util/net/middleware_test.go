@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"k8s.io/klog/v2"
+)
+
+type fakeRoundTripper func(req *http.Request) (*http.Response, error)
+
+func (f fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// recordingSink is a klog.LogSink recording the messages and key/value pairs
+// of all log entries.
+type recordingSink struct {
+	entries *[]string
+}
+
+func (s recordingSink) Init(klog.RuntimeInfo)  {}
+func (s recordingSink) Enabled(level int) bool { return true }
+func (s recordingSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	*s.entries = append(*s.entries, fmt.Sprintf("%s %v", msg, keysAndValues))
+}
+func (s recordingSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	*s.entries = append(*s.entries, fmt.Sprintf("%s %v %v", msg, err, keysAndValues))
+}
+func (s recordingSink) WithValues(keysAndValues ...interface{}) klog.LogSink { return s }
+func (s recordingSink) WithName(name string) klog.LogSink                    { return s }
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return middleware(func(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+			order = append(order, name)
+			return rt.RoundTrip(req)
+		})
+	}
+	rt := Chain(fakeRoundTripper(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "transport")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}), record("outer"), record("inner"))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "outer,inner,transport" {
+		t.Errorf("unexpected order %s", got)
+	}
+
+	transport := &http.Transport{}
+	if tlsConfig, err := TLSClientConfig(Chain(transport, WithUserAgent("test"))); err != nil || tlsConfig != transport.TLSClientConfig {
+		t.Errorf("expected the TLS config of the wrapped transport, got %v, %v", tlsConfig, err)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var userAgent string
+	rt := Chain(fakeRoundTripper(func(req *http.Request) (*http.Response, error) {
+		userAgent = req.UserAgent()
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}), WithUserAgent("test/1.0"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "test/1.0" {
+		t.Errorf("expected the user agent to be set, got %q", userAgent)
+	}
+	if len(req.Header.Get("User-Agent")) != 0 {
+		t.Errorf("expected the original request not to be modified")
+	}
+
+	req.Header.Set("User-Agent", "custom")
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "custom" {
+		t.Errorf("expected the user agent not to be overridden, got %q", userAgent)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{"Authorization": {"Bearer secret"}, "X-Token": {"a", "b"}, "Accept": {"*/*"}}
+	redacted := RedactHeaders(header, "authorization", "x-token")
+	expected := http.Header{"Authorization": {"<masked>"}, "X-Token": {"<masked>", "<masked>"}, "Accept": {"*/*"}}
+	if fmt.Sprint(redacted) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, redacted)
+	}
+	if header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("expected the original header not to be modified")
+	}
+}
+
+func TestWithDebugLogging(t *testing.T) {
+	var entries []string
+	ctx := klog.NewContext(context.Background(), klog.New(recordingSink{entries: &entries}))
+	upgradedConn := io.NopCloser(strings.NewReader("stream"))
+	rt := Chain(fakeRoundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Connection") == "Upgrade" {
+			return &http.Response{StatusCode: http.StatusSwitchingProtocols, Body: upgradedConn}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader("response body"))}, nil
+	}), WithDebugLogging(DebugLoggingOptions{MaxBodyBytes: 8, RedactHeaders: []string{"X-Token"}}))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, "http://example.com", strings.NewReader("request body"))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Token", "secret")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "response body" {
+		t.Errorf("expected the full response body, got %q", data)
+	}
+	log := strings.Join(entries, "\n")
+	if strings.Contains(log, "secret") {
+		t.Errorf("expected secrets to be redacted:\n%s", log)
+	}
+	for _, expected := range []string{"body request", "body response", "200 OK"} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("expected %q in log:\n%s", expected, log)
+		}
+	}
+	if strings.Contains(log, "request body") || strings.Contains(log, "response body") {
+		t.Errorf("expected bodies to be truncated:\n%s", log)
+	}
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Connection", "Upgrade")
+	resp, err = rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != upgradedConn {
+		t.Errorf("expected the body of an upgrade response not to be wrapped")
+	}
+}
+
+func TestWithRetryOnConnectionReset(t *testing.T) {
+	reset := fmt.Errorf("read: %w", syscall.ECONNRESET)
+	testCases := []struct {
+		name          string
+		method        string
+		body          io.Reader
+		err           error
+		expectedCalls int
+	}{
+		{name: "get", method: http.MethodGet, err: reset, expectedCalls: 3},
+		{name: "put with replayable body", method: http.MethodPut, body: strings.NewReader("body"), err: reset, expectedCalls: 3},
+		{name: "put with body which cannot be replayed", method: http.MethodPut, body: io.MultiReader(strings.NewReader("body")), err: reset, expectedCalls: 1},
+		{name: "post", method: http.MethodPost, err: reset, expectedCalls: 1},
+		{name: "other error", method: http.MethodGet, err: errors.New("boom"), expectedCalls: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			rt := Chain(fakeRoundTripper(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if req.Body != nil {
+					data, _ := io.ReadAll(req.Body)
+					if !bytes.Equal(data, []byte("body")) {
+						t.Errorf("unexpected body %q", data)
+					}
+				}
+				return nil, tc.err
+			}), WithRetryOnConnectionReset(2))
+			req, _ := http.NewRequest(tc.method, "http://example.com", tc.body)
+			if _, err := rt.RoundTrip(req); !errors.Is(err, tc.err) {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
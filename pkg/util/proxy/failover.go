@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// DialRetry configures an UpgradeAwareHandler to retry when the connection to
+// its backend cannot be established, e.g. because the backend refuses
+// connections or its name cannot be resolved, and to fail over to alternative
+// backends. Requests are only retried if nothing was sent to a backend, so
+// that they are never processed twice.
+type DialRetry struct {
+	// Backoff is the backoff between rounds of attempts, Backoff.Steps being
+	// the maximum number of rounds. Each round tries the host of the Location
+	// of the handler, and then each of the Endpoints in order. A single round
+	// is made if Backoff.Steps is less than 2.
+	Backoff wait.Backoff
+	// Endpoints are the hosts, as host:port, of alternative backends.
+	Endpoints []string
+	// Served, if set, is called with the host of the backend which served req,
+	// and the number of attempts it took to connect to it.
+	Served func(req *http.Request, host string, attempts int)
+}
+
+// isDialError returns true if err means that a connection could not be
+// established, and that nothing was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// dial calls dialHost with the host of each backend until it succeeds, and
+// returns the host which succeeded and the number of attempts. The last error
+// is returned if no backend could be dialed, or the first error other than a
+// dial error.
+func (r *DialRetry) dial(ctx context.Context, primary string, dialHost func(host string) error) (string, int, error) {
+	hosts := append([]string{primary}, r.Endpoints...)
+	backoff := r.Backoff
+	rounds := backoff.Steps
+	attempts := 0
+	for round := 1; ; round++ {
+		var err error
+		for _, host := range hosts {
+			attempts++
+			if err = dialHost(host); err == nil || !isDialError(err) {
+				return host, attempts, err
+			}
+			klog.V(4).Infof("Proxy failed to connect to backend %s (attempt %d): %v", host, attempts, err)
+		}
+		if round >= rounds {
+			return "", attempts, err
+		}
+		t := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return "", attempts, err
+		case <-t.C:
+		}
+	}
+}
+
+// served reports host as the backend which served req.
+func (r *DialRetry) served(req *http.Request, host string, attempts int) {
+	if r.Served != nil {
+		r.Served(req, host, attempts)
+	}
+}
+
+// retryingTransport retries the requests whose connection to the backend
+// failed, on the alternative backends of its DialRetry.
+type retryingTransport struct {
+	http.RoundTripper
+	retry *DialRetry
+}
+
+var _ = utilnet.RoundTripperWrapper(&retryingTransport{})
+
+func (rt *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body *retryableBody
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &retryableBody{ReadCloser: req.Body}
+	}
+	var resp *http.Response
+	host, attempts, err := rt.retry.dial(req.Context(), req.URL.Host, func(host string) error {
+		if body != nil && body.read.Load() {
+			// The body may have been partially sent, fail without retrying.
+			return errors.New("request body was consumed by a failed attempt")
+		}
+		attempt := req.Clone(req.Context())
+		attempt.URL.Host = host
+		if body != nil {
+			attempt.Body = body
+		}
+		transport := rt.RoundTripper
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		var err error
+		resp, err = transport.RoundTrip(attempt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	rt.retry.served(req, host, attempts)
+	return resp, nil
+}
+
+func (rt *retryingTransport) WrappedRoundTripper() http.RoundTripper {
+	return rt.RoundTripper
+}
+
+// hasRetryingTransport returns true if rt is, or wraps, a retryingTransport.
+func hasRetryingTransport(rt http.RoundTripper) bool {
+	for rt != nil {
+		switch t := rt.(type) {
+		case *retryingTransport:
+			return true
+		case utilnet.RoundTripperWrapper:
+			rt = t.WrappedRoundTripper()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// retryableBody records whether a request body was read, and is not closed
+// by failed attempts. The server closes the body of incoming requests.
+type retryableBody struct {
+	io.ReadCloser
+	read atomic.Bool
+}
+
+func (b *retryableBody) Read(p []byte) (int, error) {
+	b.read.Store(true)
+	return b.ReadCloser.Read(p)
+}
+
+func (b *retryableBody) Close() error {
+	return nil
+}
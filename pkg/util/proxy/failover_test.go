@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// refusingHost returns the address of a closed listener, which refuses
+// connections.
+func refusingHost(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	host := l.Addr().String()
+	require.NoError(t, l.Close())
+	return host
+}
+
+type servedRecorder struct {
+	lock     sync.Mutex
+	host     string
+	attempts int
+}
+
+func (r *servedRecorder) served(req *http.Request, host string, attempts int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.host, r.attempts = host, attempts
+}
+
+func TestDialRetryFailover(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	for _, wrapTransport := range []bool{false, true} {
+		recorder := &servedRecorder{}
+		location := &url.URL{Scheme: "http", Host: refusingHost(t)}
+		handler := NewUpgradeAwareHandler(location, nil, wrapTransport, false, &fakeResponder{t: t})
+		handler.DialRetry = &DialRetry{
+			Endpoints: []string{refusingHost(t), backendURL.Host},
+			Served:    recorder.served,
+		}
+		proxy := httptest.NewServer(handler)
+
+		for _, method := range []string{http.MethodPost, http.MethodPut} {
+			req, _ := http.NewRequest(method, proxy.URL+"/path", strings.NewReader("body"))
+			resp, err := proxy.Client().Do(req)
+			require.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "wrapTransport=%t", wrapTransport)
+			assert.Equal(t, method+" body", string(body), "wrapTransport=%t", wrapTransport)
+			assert.Equal(t, backendURL.Host, recorder.host)
+			assert.Equal(t, 3, recorder.attempts)
+		}
+		proxy.Close()
+	}
+}
+
+type countingRT struct {
+	http.RoundTripper
+	count atomic.Int32
+}
+
+func (rt *countingRT) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.count.Add(1)
+	return rt.RoundTripper.RoundTrip(req)
+}
+
+func TestDialRetryBackoff(t *testing.T) {
+	transport := &countingRT{RoundTripper: http.DefaultTransport}
+	responder := &fakeResponder{t: t}
+	handler := NewUpgradeAwareHandler(&url.URL{Scheme: "http", Host: refusingHost(t)}, transport, false, false, responder)
+	handler.DialRetry = &DialRetry{
+		Backoff: wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3},
+		Served: func(req *http.Request, host string, attempts int) {
+			t.Errorf("unexpected backend %s", host)
+		},
+	}
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+
+	resp, err := proxy.Client().Get(proxy.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fakeStatusCode, resp.StatusCode)
+	assert.EqualValues(t, 3, transport.count.Load(), "expected an attempt per round")
+	assert.True(t, isDialError(responder.err), "expected the dial error to be reported, got %v", responder.err)
+}
+
+func TestDialRetryDoesNotRetryOtherErrors(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	transport := &countingRT{RoundTripper: &http.Transport{}}
+	handler := NewUpgradeAwareHandler(backendURL, transport, false, false, &fakeResponder{t: t})
+	handler.DialRetry = &DialRetry{
+		Backoff:   wait.Backoff{Duration: time.Millisecond, Steps: 3},
+		Endpoints: []string{backendURL.Host},
+	}
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+
+	resp, err := proxy.Client().Get(proxy.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fakeStatusCode, resp.StatusCode)
+	assert.EqualValues(t, 1, transport.count.Load(), "expected requests reaching a backend not to be retried")
+}
+
+func TestDialRetryUpgradeFailover(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upgrade forbidden", http.StatusForbidden)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	recorder := &servedRecorder{}
+	handler := NewUpgradeAwareHandler(&url.URL{Scheme: "http", Host: refusingHost(t)}, nil, false, false, &fakeResponder{t: t})
+	handler.DialRetry = &DialRetry{
+		Endpoints: []string{backendURL.Host},
+		Served:    recorder.served,
+	}
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	conn, err := net.Dial("tcp", proxyURL.Host)
+	require.NoError(t, err)
+	defer conn.Close()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set(httpstream.HeaderConnection, httpstream.HeaderUpgrade)
+	require.NoError(t, req.Write(conn))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "expected the response of the alternative backend")
+	assert.Equal(t, backendURL.Host, recorder.host)
+	assert.Equal(t, 2, recorder.attempts)
+}
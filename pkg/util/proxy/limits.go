@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// NewRequestBodyTooLargeError returns a 413-shaped error indicating that a request body
+// exceeded the configured proxy limit.
+func NewRequestBodyTooLargeError(limit int64) *errors.StatusError {
+	return errors.NewRequestEntityTooLargeError(fmt.Sprintf("request body exceeds the proxy limit of %d bytes", limit))
+}
+
+// NewResponseBodyTooLargeError returns a 413-shaped error indicating that a backend response
+// body exceeded the configured proxy limit.
+func NewResponseBodyTooLargeError(limit int64) *errors.StatusError {
+	return errors.NewRequestEntityTooLargeError(fmt.Sprintf("response body exceeds the proxy limit of %d bytes", limit))
+}
+
+// NewIdleTimeoutError returns a 504-shaped error indicating that an upgraded connection was
+// closed because no data was transferred in either direction for the given duration.
+func NewIdleTimeoutError(timeout time.Duration) *errors.StatusError {
+	return errors.NewTimeoutError(fmt.Sprintf("upgraded connection was idle for longer than %v", timeout), 0)
+}
+
+// limitedReadCloser returns the error produced by newErr once more than limit bytes have been
+// read from the wrapped ReadCloser. It must not be read concurrently, but limitErr may be called
+// while it is read.
+type limitedReadCloser struct {
+	rc        io.ReadCloser
+	limit     int64
+	remaining int64
+	newErr    func(limit int64) *errors.StatusError
+
+	err atomic.Pointer[errors.StatusError]
+}
+
+func newLimitedReadCloser(rc io.ReadCloser, limit int64, newErr func(int64) *errors.StatusError) *limitedReadCloser {
+	return &limitedReadCloser{rc: rc, limit: limit, remaining: limit, newErr: newErr}
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if err := l.err.Load(); err != nil {
+		return 0, err
+	}
+	// read one byte past the limit so that a body of exactly limit bytes is accepted
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.rc.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		limitErr := l.newErr(l.limit)
+		l.err.Store(limitErr)
+		return n, limitErr
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.rc.Close()
+}
+
+// limitErr returns the limit error encountered while reading, if any.
+func (l *limitedReadCloser) limitErr() error {
+	if err := l.err.Load(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// idleTracker records the time of the last activity observed on an upgraded connection.
+type idleTracker struct {
+	timeout      time.Duration
+	lastActivity atomic.Int64
+}
+
+func newIdleTracker(timeout time.Duration) *idleTracker {
+	t := &idleTracker{timeout: timeout}
+	t.touch()
+	return t
+}
+
+func (t *idleTracker) touch() {
+	t.lastActivity.Store(time.Now().UnixNano())
+}
+
+// reader returns a reader that records activity whenever data is read from r.
+func (t *idleTracker) reader(r io.Reader) io.Reader {
+	return &activityReader{r: r, tracker: t}
+}
+
+// idle returns a channel that is closed once no activity has been recorded for the
+// configured timeout, or when stopCh is closed, whichever happens first.
+func (t *idleTracker) idle(stopCh <-chan struct{}) <-chan struct{} {
+	idleCh := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(t.timeout)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-timer.C:
+			}
+			elapsed := time.Since(time.Unix(0, t.lastActivity.Load()))
+			if elapsed >= t.timeout {
+				close(idleCh)
+				return
+			}
+			timer.Reset(t.timeout - elapsed)
+		}
+	}()
+	return idleCh
+}
+
+type activityReader struct {
+	r       io.Reader
+	tracker *idleTracker
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.tracker.touch()
+	}
+	return n, err
+}
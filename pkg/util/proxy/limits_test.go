@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestLimitedReadCloser(t *testing.T) {
+	testCases := []struct {
+		name      string
+		body      string
+		limit     int64
+		expectErr bool
+	}{
+		{name: "under limit", body: "abc", limit: 5},
+		{name: "exactly at limit", body: "abcde", limit: 5},
+		{name: "over limit", body: "abcdef", limit: 5, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newLimitedReadCloser(io.NopCloser(strings.NewReader(tc.body)), tc.limit, NewRequestBodyTooLargeError)
+			data, err := io.ReadAll(l)
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.True(t, apierrors.IsRequestEntityTooLargeError(err))
+				assert.Equal(t, err, l.limitErr())
+				assert.Equal(t, tc.body[:tc.limit], string(data))
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, l.limitErr())
+			assert.Equal(t, tc.body, string(data))
+		})
+	}
+}
+
+func TestMaxRequestBodyBytes(t *testing.T) {
+	testCases := []struct {
+		name             string
+		body             string
+		chunked          bool
+		expectStatusCode int
+	}{
+		{name: "content length within limit", body: "1234", expectStatusCode: http.StatusOK},
+		{name: "content length over limit", body: "123456789", expectStatusCode: fakeStatusCode},
+		{name: "chunked within limit", body: "1234", chunked: true, expectStatusCode: http.StatusOK},
+		{name: "chunked over limit", body: "123456789", chunked: true, expectStatusCode: fakeStatusCode},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backendServer.Close()
+			backendServerURL, _ := url.Parse(backendServer.URL)
+
+			responder := &fakeResponder{t: t}
+			proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, false, responder)
+			proxyHandler.MaxRequestBodyBytes = 5
+			proxy := httptest.NewServer(proxyHandler)
+			defer proxy.Close()
+
+			var body io.Reader = strings.NewReader(tc.body)
+			if tc.chunked {
+				// hide the length so the client falls back to chunked encoding
+				body = io.MultiReader(body)
+			}
+			req, err := http.NewRequest(http.MethodPost, proxy.URL, body)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectStatusCode, resp.StatusCode)
+			if tc.expectStatusCode == fakeStatusCode {
+				assert.True(t, apierrors.IsRequestEntityTooLargeError(responder.err), "unexpected error: %v", responder.err)
+			}
+		})
+	}
+}
+
+func TestMaxResponseBodyBytes(t *testing.T) {
+	testCases := []struct {
+		name             string
+		body             string
+		flush            bool
+		expectStatusCode int
+		expectBody       string
+	}{
+		{name: "content length within limit", body: "1234", expectStatusCode: http.StatusOK, expectBody: "1234"},
+		{name: "content length over limit", body: "123456789", expectStatusCode: fakeStatusCode},
+		{name: "streamed over limit", body: "123456789", flush: true, expectStatusCode: http.StatusOK, expectBody: "12345"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.flush {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+				}
+				w.Write([]byte(tc.body))
+			}))
+			defer backendServer.Close()
+			backendServerURL, _ := url.Parse(backendServer.URL)
+
+			responder := &fakeResponder{t: t}
+			proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, false, responder)
+			proxyHandler.MaxResponseBodyBytes = 5
+			proxy := httptest.NewServer(proxyHandler)
+			defer proxy.Close()
+
+			resp, err := http.Get(proxy.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectStatusCode, resp.StatusCode)
+			if tc.expectStatusCode == fakeStatusCode {
+				assert.True(t, apierrors.IsRequestEntityTooLargeError(responder.err), "unexpected error: %v", responder.err)
+				return
+			}
+			// a truncated stream is aborted, so only compare what was received
+			data, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tc.expectBody, string(data))
+		})
+	}
+}
+
+func TestMaxBodyBytesWithoutResponder(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("123456789"))
+	}))
+	defer backendServer.Close()
+	backendServerURL, _ := url.Parse(backendServer.URL)
+
+	for name, configure := range map[string]func(h *UpgradeAwareHandler){
+		"request":  func(h *UpgradeAwareHandler) { h.MaxRequestBodyBytes = 5 },
+		"response": func(h *UpgradeAwareHandler) { h.MaxResponseBodyBytes = 5 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, false, nil)
+			configure(proxyHandler)
+			proxy := httptest.NewServer(proxyHandler)
+			defer proxy.Close()
+
+			for _, chunked := range []bool{false, true} {
+				var body io.Reader = strings.NewReader("123456789")
+				if chunked {
+					body = io.MultiReader(body)
+				}
+				req, err := http.NewRequest(http.MethodPost, proxy.URL, body)
+				require.NoError(t, err)
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, "chunked=%v", chunked)
+			}
+		})
+	}
+}
+
+func TestUpgradeIdleTimeout(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+		// echo until the proxy closes the connection
+		io.Copy(conn, conn)
+	}))
+	defer backendServer.Close()
+	backendServerURL, _ := url.Parse(backendServer.URL)
+
+	proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, true, &noErrorsAllowed{t: t})
+	proxyHandler.UpgradeIdleTimeout = 200 * time.Millisecond
+	statsCh := make(chan UpgradeStats, 1)
+	proxyHandler.UpgradeObserver = UpgradeObserverFunc(func(req *http.Request, stats UpgradeStats) {
+		statsCh <- stats
+	})
+	proxy := httptest.NewServer(proxyHandler)
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	conn, err := net.Dial("tcp", proxyURL.Host)
+	require.NoError(t, err)
+	defer conn.Close()
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+	require.NoError(t, req.Write(conn))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// activity keeps the connection open past the idle timeout
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		_, err := conn.Write([]byte("x"))
+		require.NoError(t, err)
+		b, err := reader.ReadByte()
+		require.NoError(t, err)
+		assert.Equal(t, byte('x'), b)
+	}
+
+	// once idle, the proxy closes the connection
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(wait.ForeverTestTimeout)))
+	_, err = reader.ReadByte()
+	assert.Equal(t, io.EOF, err)
+	select {
+	case stats := <-statsCh:
+		assert.Equal(t, UpgradeIdle, stats.Reason)
+		assert.True(t, apierrors.IsTimeout(stats.Err), "expected a timeout error, got %v", stats.Err)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the upgrade to be observed")
+	}
+}
+
+func TestNewIdleTimeoutError(t *testing.T) {
+	err := NewIdleTimeoutError(time.Second)
+	assert.True(t, apierrors.IsTimeout(err))
+	assert.Equal(t, int32(http.StatusGatewayTimeout), err.Status().Code)
+}
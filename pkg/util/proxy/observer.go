@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UpgradeCloseReason describes which side ended an upgraded connection, and how.
+type UpgradeCloseReason string
+
+const (
+	// UpgradeClosedByClient means that the client closed its connection.
+	UpgradeClosedByClient UpgradeCloseReason = "ClientClosed"
+	// UpgradeClosedByBackend means that the backend closed its connection.
+	UpgradeClosedByBackend UpgradeCloseReason = "BackendClosed"
+	// UpgradeClientError means that reading from or writing to the client failed.
+	UpgradeClientError UpgradeCloseReason = "ClientError"
+	// UpgradeBackendError means that reading from or writing to the backend failed.
+	UpgradeBackendError UpgradeCloseReason = "BackendError"
+	// UpgradeIdle means that the connection was closed by the proxy after
+	// UpgradeIdleTimeout.
+	UpgradeIdle UpgradeCloseReason = "Idle"
+)
+
+// UpgradeStats summarizes an upgraded connection proxied by an UpgradeAwareHandler.
+type UpgradeStats struct {
+	// ClientToBackendBytes is the number of bytes copied from the client to the backend.
+	ClientToBackendBytes int64
+	// BackendToClientBytes is the number of bytes copied from the backend to the client,
+	// not including the upgrade response.
+	BackendToClientBytes int64
+	// Duration is the time the connection was upgraded for.
+	Duration time.Duration
+	// Reason describes why the connection was closed.
+	Reason UpgradeCloseReason
+	// Err is the error which ended the connection, if Reason is UpgradeClientError or
+	// UpgradeBackendError, or the idle timeout error if Reason is UpgradeIdle.
+	Err error
+}
+
+// UpgradeObserver is notified about upgraded connections proxied by an UpgradeAwareHandler,
+// e.g. to record exec and attach metrics which distinguish client disconnects from backend
+// failures.
+type UpgradeObserver interface {
+	// UpgradeClosed is called once the upgraded connection for req is closed.
+	UpgradeClosed(req *http.Request, stats UpgradeStats)
+}
+
+// UpgradeObserverFunc implements UpgradeObserver with a function.
+type UpgradeObserverFunc func(req *http.Request, stats UpgradeStats)
+
+// UpgradeClosed calls f(req, stats).
+func (f UpgradeObserverFunc) UpgradeClosed(req *http.Request, stats UpgradeStats) {
+	f(req, stats)
+}
+
+// countingReader counts the bytes read from r, and records the last error other
+// than io.EOF, so that errors returned by io.Copy can be attributed to its reader
+// or its writer.
+type countingReader struct {
+	r     io.Reader
+	count atomic.Int64
+
+	lock sync.Mutex
+	err  error
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.count.Add(int64(n))
+	if err != nil && err != io.EOF {
+		c.lock.Lock()
+		c.err = err
+		c.lock.Unlock()
+	}
+	return n, err
+}
+
+func (c *countingReader) readErr() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+// closeReason returns the reason of a connection whose copy from reader ended with
+// err. from is the side read from, to the side written to.
+func closeReason(reader *countingReader, err error, from, to UpgradeCloseReason) (UpgradeCloseReason, error) {
+	switch {
+	case err == nil:
+		return from, nil
+	case isClosedConnError(err):
+		// the connection was closed by the proxy, e.g. after it was idle
+		return from, nil
+	case reader.readErr() == err:
+		return errorReason(from), err
+	default:
+		return errorReason(to), err
+	}
+}
+
+func errorReason(side UpgradeCloseReason) UpgradeCloseReason {
+	if side == UpgradeClosedByClient {
+		return UpgradeClientError
+	}
+	return UpgradeBackendError
+}
+
+func isClosedConnError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
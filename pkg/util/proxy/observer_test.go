@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestUpgradeObserver(t *testing.T) {
+	testCases := []struct {
+		name           string
+		backend        func(conn net.Conn)
+		client         func(t *testing.T, conn net.Conn, reader *bufio.Reader)
+		expectedReason UpgradeCloseReason
+		expectedIn     int64
+		expectedOut    int64
+	}{
+		{
+			name: "client closes",
+			backend: func(conn net.Conn) {
+				io.Copy(conn, conn)
+			},
+			client: func(t *testing.T, conn net.Conn, reader *bufio.Reader) {
+				_, err := conn.Write([]byte("hello"))
+				require.NoError(t, err)
+				_, err = io.ReadFull(reader, make([]byte, 5))
+				require.NoError(t, err)
+			},
+			expectedReason: UpgradeClosedByClient,
+			expectedIn:     5,
+			expectedOut:    5,
+		},
+		{
+			name: "backend closes",
+			backend: func(conn net.Conn) {
+				conn.Write([]byte("bye"))
+			},
+			client: func(t *testing.T, conn net.Conn, reader *bufio.Reader) {
+				data, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				assert.Equal(t, "bye", string(data))
+			},
+			expectedReason: UpgradeClosedByBackend,
+			expectedOut:    3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				defer conn.Close()
+				conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+				tc.backend(conn)
+			}))
+			defer backendServer.Close()
+			backendServerURL, _ := url.Parse(backendServer.URL)
+
+			statsCh := make(chan UpgradeStats, 1)
+			proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, true, &noErrorsAllowed{t: t})
+			proxyHandler.UpgradeObserver = UpgradeObserverFunc(func(req *http.Request, stats UpgradeStats) {
+				statsCh <- stats
+			})
+			proxy := httptest.NewServer(proxyHandler)
+			defer proxy.Close()
+			proxyURL, _ := url.Parse(proxy.URL)
+
+			conn, err := net.Dial("tcp", proxyURL.Host)
+			require.NoError(t, err)
+			req, _ := http.NewRequest(http.MethodGet, proxy.URL, nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "test")
+			require.NoError(t, req.Write(conn))
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+			tc.client(t, conn, reader)
+			conn.Close()
+
+			select {
+			case stats := <-statsCh:
+				assert.Equal(t, tc.expectedReason, stats.Reason)
+				assert.NoError(t, stats.Err)
+				assert.Equal(t, tc.expectedIn, stats.ClientToBackendBytes)
+				assert.Equal(t, tc.expectedOut, stats.BackendToClientBytes)
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("timed out waiting for the upgrade to be observed")
+			}
+		})
+	}
+}
+
+func TestCloseReason(t *testing.T) {
+	readErr := errors.New("read failed")
+	reader := &countingReader{r: iotest.ErrReader(readErr)}
+	_, err := io.Copy(io.Discard, reader)
+	reason, reasonErr := closeReason(reader, err, UpgradeClosedByClient, UpgradeClosedByBackend)
+	assert.Equal(t, UpgradeClientError, reason)
+	assert.Equal(t, readErr, reasonErr)
+
+	writeErr := errors.New("write failed")
+	reader = &countingReader{r: strings.NewReader("data")}
+	reason, reasonErr = closeReason(reader, writeErr, UpgradeClosedByBackend, UpgradeClosedByClient)
+	assert.Equal(t, UpgradeClientError, reason)
+	assert.Equal(t, writeErr, reasonErr)
+}
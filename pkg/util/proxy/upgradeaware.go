@@ -85,6 +85,28 @@ type UpgradeAwareHandler struct {
 	Responder ErrorResponder
 	// Reject to forward redirect response
 	RejectForwardingRedirects bool
+	// MaxRequestBodyBytes limits the size of request bodies forwarded to the backend. Requests whose
+	// body exceeds the limit are rejected with a 413-shaped error. No limit is imposed if the value is zero.
+	MaxRequestBodyBytes int64
+	// MaxResponseBodyBytes limits the size of response bodies returned from the backend. Responses whose
+	// declared length exceeds the limit are rejected with a 413-shaped error; responses of unknown length
+	// are truncated and the connection aborted once the limit is reached. No limit is imposed if the value is zero.
+	MaxResponseBodyBytes int64
+	// UpgradeIdleTimeout closes upgraded connections once no data has been transferred in either
+	// direction for the given duration. No timeout is imposed if the value is zero.
+	UpgradeIdleTimeout time.Duration
+	// UpgradeObserver, if set, is notified when upgraded connections are closed, with the
+	// number of bytes copied in each direction and whether the client or the backend
+	// ended the connection.
+	UpgradeObserver UpgradeObserver
+	// DialRetry, if set, retries connecting to the backend when the connection
+	// cannot be established, and fails over to alternative backends.
+	DialRetry *DialRetry
+	// ConnectionTracker, if set, tracks upgraded connections so that they can
+	// be drained on shutdown. Upgrade requests are rejected once draining has
+	// started, and draining closes the connection to the backend when it times
+	// out.
+	ConnectionTracker *httpstream.ConnectionTracker
 }
 
 const defaultFlushInterval = 200 * time.Millisecond
@@ -233,6 +255,11 @@ func (h *UpgradeAwareHandler) ServeHTTP(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	if h.MaxRequestBodyBytes > 0 && req.ContentLength > h.MaxRequestBodyBytes {
+		h.proxyError(w, req, NewRequestBodyTooLargeError(h.MaxRequestBodyBytes))
+		return
+	}
+
 	if h.Transport == nil || h.WrapTransport {
 		h.Transport = h.defaultProxyTransport(req.URL, h.Transport)
 	}
@@ -248,6 +275,11 @@ func (h *UpgradeAwareHandler) ServeHTTP(w http.ResponseWriter, req *http.Request
 		// because req.Host has preference over req.URL.Host in filling this header field
 		newReq.Host = h.Location.Host
 	}
+	var limitedBody *limitedReadCloser
+	if h.MaxRequestBodyBytes > 0 && req.Body != nil && req.Body != http.NoBody {
+		limitedBody = newLimitedReadCloser(req.Body, h.MaxRequestBodyBytes, NewRequestBodyTooLargeError)
+		newReq.Body = limitedBody
+	}
 
 	// create the target location to use for the reverse proxy
 	reverseProxyLocation := &url.URL{Scheme: h.Location.Scheme, Host: h.Location.Host}
@@ -257,6 +289,9 @@ func (h *UpgradeAwareHandler) ServeHTTP(w http.ResponseWriter, req *http.Request
 
 	proxy := httputil.NewSingleHostReverseProxy(reverseProxyLocation)
 	proxy.Transport = h.Transport
+	if h.DialRetry != nil && !hasRetryingTransport(h.Transport) {
+		proxy.Transport = &retryingTransport{RoundTripper: h.Transport, retry: h.DialRetry}
+	}
 	proxy.FlushInterval = h.FlushInterval
 	proxy.ErrorLog = log.New(noSuppressPanicError{}, "", log.LstdFlags)
 	if h.RejectForwardingRedirects {
@@ -284,15 +319,57 @@ func (h *UpgradeAwareHandler) ServeHTTP(w http.ResponseWriter, req *http.Request
 			return nil
 		}
 	}
+	if h.MaxResponseBodyBytes > 0 {
+		oldModifyResponse := proxy.ModifyResponse
+		proxy.ModifyResponse = func(response *http.Response) error {
+			if oldModifyResponse != nil {
+				if err := oldModifyResponse(response); err != nil {
+					return err
+				}
+			}
+			if response.ContentLength > h.MaxResponseBodyBytes {
+				return NewResponseBodyTooLargeError(h.MaxResponseBodyBytes)
+			}
+			response.Body = newLimitedReadCloser(response.Body, h.MaxResponseBodyBytes, NewResponseBodyTooLargeError)
+			return nil
+		}
+	}
 	if h.Responder != nil {
 		// if an optional error interceptor/responder was provided wire it
 		// the custom responder might be used for providing a unified error reporting
 		// or supporting retry mechanisms by not sending non-fatal errors to the clients
 		proxy.ErrorHandler = h.Responder.Error
 	}
+	if limitedBody != nil || h.MaxResponseBodyBytes > 0 {
+		proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+			// surface the typed limit error rather than the transport error it caused
+			if limitedBody != nil {
+				if limitErr := limitedBody.limitErr(); limitErr != nil {
+					err = limitErr
+				}
+			}
+			h.proxyError(w, req, err)
+		}
+	}
 	proxy.ServeHTTP(w, newReq)
 }
 
+// proxyError passes err to the Responder. Without a Responder, the status code of
+// API errors such as the body size limit errors is returned to the client, and
+// 502 Bad Gateway for other errors, like the reverse proxy does.
+func (h *UpgradeAwareHandler) proxyError(w http.ResponseWriter, req *http.Request, err error) {
+	if h.Responder != nil {
+		h.Responder.Error(w, req, err)
+		return
+	}
+	code := http.StatusBadGateway
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Code != 0 {
+		code = int(status.Status().Code)
+	}
+	klog.V(6).Infof("Proxy error: %v", err)
+	http.Error(w, err.Error(), code)
+}
+
 type noSuppressPanicError struct{}
 
 func (noSuppressPanicError) Write(p []byte) (n int, err error) {
@@ -338,7 +415,24 @@ func (h *UpgradeAwareHandler) tryUpgrade(w http.ResponseWriter, req *http.Reques
 	}
 	clone.URL = &location
 	klog.V(6).Infof("UpgradeAwareProxy: dialing for SPDY upgrade with headers: %v", clone.Header)
-	backendConn, err = h.DialForUpgrade(clone)
+	if h.DialRetry == nil {
+		backendConn, err = h.DialForUpgrade(clone)
+	} else {
+		var host string
+		var attempts int
+		host, attempts, err = h.DialRetry.dial(req.Context(), location.Host, func(host string) error {
+			attempt := *clone
+			attemptLocation := location
+			attemptLocation.Host = host
+			attempt.URL = &attemptLocation
+			var err error
+			backendConn, err = h.DialForUpgrade(&attempt)
+			return err
+		})
+		if err == nil {
+			h.DialRetry.served(req, host, attempts)
+		}
+	}
 	if err != nil {
 		klog.V(6).Infof("Proxy connection error: %v", err)
 		h.Responder.Error(w, req, err)
@@ -346,6 +440,18 @@ func (h *UpgradeAwareHandler) tryUpgrade(w http.ResponseWriter, req *http.Reques
 	}
 	defer backendConn.Close()
 
+	if h.ConnectionTracker != nil {
+		// closing the backend connection ends the proxying, which closes the
+		// client connection
+		release, err := h.ConnectionTracker.Track(backendConn)
+		if err != nil {
+			klog.V(6).Infof("Proxy upgrade rejected: %v", err)
+			h.Responder.Error(w, req, err)
+			return true
+		}
+		defer release()
+	}
+
 	// determine the http response code from the backend by reading from rawResponse+backendConn
 	backendHTTPResponse, headerBytes, err := getResponse(io.MultiReader(bytes.NewReader(rawResponse), backendConn))
 	if err != nil {
@@ -415,6 +521,26 @@ func (h *UpgradeAwareHandler) tryUpgrade(w http.ResponseWriter, req *http.Reques
 	writerComplete := make(chan struct{})
 	readerComplete := make(chan struct{})
 
+	var clientReader io.Reader = requestHijackedConn
+	var backendReader io.Reader = backendConn
+	var idleCh <-chan struct{}
+	if h.UpgradeIdleTimeout > 0 {
+		tracker := newIdleTracker(h.UpgradeIdleTimeout)
+		clientReader = tracker.reader(requestHijackedConn)
+		backendReader = tracker.reader(backendConn)
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		idleCh = tracker.idle(stopCh)
+	}
+	clientCounter := &countingReader{r: clientReader}
+	backendCounter := &countingReader{r: backendReader}
+	clientReader, backendReader = clientCounter, backendCounter
+	// data sent by the backend right after the upgrade response was already forwarded
+	// with it
+	backendCounter.count.Add(int64(len(rawResponse) - responseHeaderLength(rawResponse)))
+	var writerErr, readerErr error
+	upgradeStart := time.Now()
+
 	go func() {
 		var writer io.WriteCloser
 		if h.MaxBytesPerSec > 0 {
@@ -422,32 +548,52 @@ func (h *UpgradeAwareHandler) tryUpgrade(w http.ResponseWriter, req *http.Reques
 		} else {
 			writer = backendConn
 		}
-		_, err := io.Copy(writer, requestHijackedConn)
-		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		_, err := io.Copy(writer, clientReader)
+		if err != nil && !isClosedConnError(err) {
 			klog.Errorf("Error proxying data from client to backend: %v", err)
 		}
+		writerErr = err
 		close(writerComplete)
 	}()
 
 	go func() {
-		var reader io.ReadCloser
+		var reader io.Reader
 		if h.MaxBytesPerSec > 0 {
-			reader = flowrate.NewReader(backendConn, h.MaxBytesPerSec)
+			reader = flowrate.NewReader(backendReader, h.MaxBytesPerSec)
 		} else {
-			reader = backendConn
+			reader = backendReader
 		}
 		_, err := io.Copy(requestHijackedConn, reader)
-		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		if err != nil && !isClosedConnError(err) {
 			klog.Errorf("Error proxying data from backend to client: %v", err)
 		}
+		readerErr = err
 		close(readerComplete)
 	}()
 
 	// Wait for one half the connection to exit. Once it does the defer will
 	// clean up the other half of the connection.
+	stats := UpgradeStats{}
 	select {
 	case <-writerComplete:
+		stats.Reason, stats.Err = closeReason(clientCounter, writerErr, UpgradeClosedByClient, UpgradeClosedByBackend)
 	case <-readerComplete:
+		stats.Reason, stats.Err = closeReason(backendCounter, readerErr, UpgradeClosedByBackend, UpgradeClosedByClient)
+	case <-idleCh:
+		stats.Reason, stats.Err = UpgradeIdle, NewIdleTimeoutError(h.UpgradeIdleTimeout)
+		klog.V(4).Infof("Proxy upgrade closed: %v", stats.Err)
+	}
+	if h.UpgradeObserver != nil {
+		// close both connections and wait for the copies to finish, so that all bytes
+		// are counted
+		backendConn.Close()
+		requestHijackedConn.Close()
+		<-writerComplete
+		<-readerComplete
+		stats.ClientToBackendBytes = clientCounter.count.Load()
+		stats.BackendToClientBytes = backendCounter.count.Load()
+		stats.Duration = time.Since(upgradeStart)
+		h.UpgradeObserver.UpgradeClosed(req, stats)
 	}
 	klog.V(6).Infof("Disconnecting from backend proxy %s\n  Headers: %v", &location, clone.Header)
 
@@ -492,11 +638,23 @@ func getResponse(r io.Reader) (*http.Response, []byte, error) {
 	return resp, rawResponse.Bytes(), nil
 }
 
+// responseHeaderLength returns the length of the status line and headers at the start
+// of rawResponse.
+func responseHeaderLength(rawResponse []byte) int {
+	if i := bytes.Index(rawResponse, []byte("\r\n\r\n")); i >= 0 {
+		return i + 4
+	}
+	if i := bytes.Index(rawResponse, []byte("\n\n")); i >= 0 {
+		return i + 2
+	}
+	return len(rawResponse)
+}
+
 // dial dials the backend at req.URL and writes req to it.
 func dial(req *http.Request, transport http.RoundTripper) (net.Conn, error) {
 	conn, err := DialURL(req.Context(), req.URL, transport)
 	if err != nil {
-		return nil, fmt.Errorf("error dialing backend: %v", err)
+		return nil, fmt.Errorf("error dialing backend: %w", err)
 	}
 
 	if err = req.Write(conn); err != nil {
@@ -515,6 +673,10 @@ func (h *UpgradeAwareHandler) defaultProxyTransport(url *url.URL, internalTransp
 		suffix += "/"
 	}
 	pathPrepend := strings.TrimSuffix(url.Path, suffix)
+	if h.DialRetry != nil && !hasRetryingTransport(internalTransport) {
+		// retry below the rewriting transport, which hides the dial errors
+		internalTransport = &retryingTransport{RoundTripper: internalTransport, retry: h.DialRetry}
+	}
 	rewritingTransport := &Transport{
 		Scheme:       scheme,
 		Host:         host,
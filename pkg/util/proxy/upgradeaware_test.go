@@ -44,6 +44,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/httpstream"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const fakeStatusCode = 567
@@ -1259,3 +1260,57 @@ wS07a4ECgYEAwLSbmMIVJme0jFjsp5d1wOGA2Qi2ZwGIAVlsbnJtygrU/hSBfnu8
 VfyJSCgg3fPe7kChWKlfcOebVKSb68LKRsz1Lz1KdbY0HOJFp/cT4lKmDAlRY9gq
 LB4rdf46lV0mUkvd2/oofIbTrzukjQSnyfLawb/2uJGV1IkTcZcn9CI=
 -----END RSA PRIVATE KEY-----`)
+
+func TestUpgradeConnectionTracker(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+		io.Copy(conn, conn)
+	}))
+	defer backendServer.Close()
+	backendServerURL, _ := url.Parse(backendServer.URL)
+
+	responder := &fakeResponder{t: t}
+	proxyHandler := NewUpgradeAwareHandler(backendServerURL, nil, false, true, responder)
+	proxyHandler.ConnectionTracker = httpstream.NewConnectionTracker()
+	proxy := httptest.NewServer(proxyHandler)
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	upgrade := func() (net.Conn, *bufio.Reader, *http.Response) {
+		conn, err := net.Dial("tcp", proxyURL.Host)
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, proxy.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "test")
+		require.NoError(t, req.Write(conn))
+		reader := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(reader, nil)
+		require.NoError(t, err)
+		return conn, reader, resp
+	}
+
+	conn, reader, resp := upgrade()
+	defer conn.Close()
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, 1, proxyHandler.ConnectionTracker.Active())
+
+	// the session is closed once draining times out
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, proxyHandler.ConnectionTracker.Drain(ctx))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(wait.ForeverTestTimeout)))
+	_, err := reader.ReadByte()
+	assert.Equal(t, io.EOF, err)
+	require.NoError(t, wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return proxyHandler.ConnectionTracker.Active() == 0, nil
+	}))
+
+	// new sessions are rejected while draining
+	rejected, _, resp := upgrade()
+	defer rejected.Close()
+	assert.Equal(t, fakeStatusCode, resp.StatusCode)
+	assert.Equal(t, httpstream.ErrDraining, responder.err)
+}
@@ -18,53 +18,62 @@ limitations under the License.
 package rand
 
 import (
+	cryptorand "crypto/rand"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 )
 
-var rng = struct {
-	sync.Mutex
+// Rand is a source of pseudo-random values with its own state, providing the
+// functions of this package as methods. It is safe for concurrent use. Code
+// which accepts a *Rand instead of using the package-level functions can be
+// made deterministic in tests by passing a seeded source.
+type Rand struct {
+	lock sync.Mutex
 	rand *rand.Rand
-}{
-	rand: rand.New(rand.NewSource(time.Now().UnixNano())),
 }
 
+// New returns a Rand taking its values from src.
+func New(src rand.Source) *Rand {
+	return &Rand{rand: rand.New(src)}
+}
+
+// NewSeeded returns a Rand seeded with seed, which always produces the same
+// sequence of values for the same seed.
+func NewSeeded(seed int64) *Rand {
+	return New(rand.NewSource(seed))
+}
+
+var rng = NewSeeded(time.Now().UnixNano())
+
 // Int returns a non-negative pseudo-random int.
 func Int() int {
-	rng.Lock()
-	defer rng.Unlock()
-	return rng.rand.Int()
+	return rng.Int()
 }
 
 // Intn generates an integer in range [0,max).
 // By design this should panic if input is invalid, <= 0.
 func Intn(max int) int {
-	rng.Lock()
-	defer rng.Unlock()
-	return rng.rand.Intn(max)
+	return rng.Intn(max)
 }
 
 // IntnRange generates an integer in range [min,max).
 // By design this should panic if input is invalid, <= 0.
 func IntnRange(min, max int) int {
-	rng.Lock()
-	defer rng.Unlock()
-	return rng.rand.Intn(max-min) + min
+	return rng.IntnRange(min, max)
 }
 
 // IntnRange generates an int64 integer in range [min,max).
 // By design this should panic if input is invalid, <= 0.
 func Int63nRange(min, max int64) int64 {
-	rng.Lock()
-	defer rng.Unlock()
-	return rng.rand.Int63n(max-min) + min
+	return rng.Int63nRange(min, max)
 }
 
 // Seed seeds the rng with the provided seed.
 func Seed(seed int64) {
-	rng.Lock()
-	defer rng.Unlock()
+	rng.lock.Lock()
+	defer rng.lock.Unlock()
 
 	rng.rand = rand.New(rand.NewSource(seed))
 }
@@ -72,9 +81,45 @@ func Seed(seed int64) {
 // Perm returns, as a slice of n ints, a pseudo-random permutation of the integers [0,n)
 // from the default Source.
 func Perm(n int) []int {
-	rng.Lock()
-	defer rng.Unlock()
-	return rng.rand.Perm(n)
+	return rng.Perm(n)
+}
+
+// Int returns a non-negative pseudo-random int.
+func (r *Rand) Int() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Int()
+}
+
+// Intn generates an integer in range [0,max).
+// By design this should panic if input is invalid, <= 0.
+func (r *Rand) Intn(max int) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Intn(max)
+}
+
+// IntnRange generates an integer in range [min,max).
+// By design this should panic if input is invalid, <= 0.
+func (r *Rand) IntnRange(min, max int) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Intn(max-min) + min
+}
+
+// Int63nRange generates an int64 integer in range [min,max).
+// By design this should panic if input is invalid, <= 0.
+func (r *Rand) Int63nRange(min, max int64) int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Int63n(max-min) + min
+}
+
+// Perm returns, as a slice of n ints, a pseudo-random permutation of the integers [0,n).
+func (r *Rand) Perm(n int) []int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Perm(n)
 }
 
 const (
@@ -96,15 +141,22 @@ const (
 // - from each int63, we are extracting multiple random letters by bit-shifting and masking
 // - if some index is out of range of alphanums we neglect it (unlikely to happen multiple times in a row)
 func String(n int) string {
+	return rng.String(n)
+}
+
+// String generates a random alphanumeric string, without vowels, which is n
+// characters long, like the String function.  This will panic if n is less
+// than zero.
+func (r *Rand) String(n int) string {
 	b := make([]byte, n)
-	rng.Lock()
-	defer rng.Unlock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
-	randomInt63 := rng.rand.Int63()
+	randomInt63 := r.rand.Int63()
 	remaining := maxAlphanumsPerInt
 	for i := 0; i < n; {
 		if remaining == 0 {
-			randomInt63, remaining = rng.rand.Int63(), maxAlphanumsPerInt
+			randomInt63, remaining = r.rand.Int63(), maxAlphanumsPerInt
 		}
 		if idx := int(randomInt63 & alphanumsIdxMask); idx < len(alphanums) {
 			b[i] = alphanums[idx]
@@ -116,6 +168,32 @@ func String(n int) string {
 	return string(b)
 }
 
+// SecureString generates a random alphanumeric string, without vowels, which
+// is n characters long, using the same characters as String. Unlike String,
+// the characters are read from crypto/rand, so the result is suitable for
+// security-sensitive names and tokens. Every character is equally likely. This
+// will panic if n is less than zero, or if crypto/rand fails.
+func SecureString(n int) string {
+	b := make([]byte, n)
+	buf := make([]byte, n+n/4+1)
+	for i := 0; i < n; {
+		if _, err := cryptorand.Read(buf); err != nil {
+			panic(fmt.Sprintf("failed to read random bytes: %v", err))
+		}
+		for _, c := range buf {
+			// rejecting out of range indexes keeps the distribution uniform
+			if idx := int(c & alphanumsIdxMask); idx < len(alphanums) {
+				b[i] = alphanums[idx]
+				i++
+				if i == n {
+					break
+				}
+			}
+		}
+	}
+	return string(b)
+}
+
 // SafeEncodeString encodes s using the same characters as rand.String. This reduces the chances of bad words and
 // ensures that strings generated from hash functions appear consistent throughout the API.
 func SafeEncodeString(s string) string {
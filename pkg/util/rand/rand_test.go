@@ -18,6 +18,7 @@ package rand
 
 import (
 	"math/rand"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -42,6 +43,48 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestSecureString(t *testing.T) {
+	valid := "bcdfghjklmnpqrstvwxz2456789"
+	for _, l := range []int{0, 1, 2, 10, 123} {
+		s := SecureString(l)
+		if len(s) != l {
+			t.Errorf("expected string of size %d, got %q", l, s)
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(valid, c) {
+				t.Errorf("expected valid characters, got %v", c)
+			}
+		}
+	}
+	if SecureString(testStringLength) == SecureString(testStringLength) {
+		t.Errorf("expected different strings")
+	}
+}
+
+func TestSeededRand(t *testing.T) {
+	a, b := NewSeeded(42), NewSeeded(42)
+	if sa, sb := a.String(testStringLength), b.String(testStringLength); sa != sb {
+		t.Errorf("expected the same strings for the same seed, got %q and %q", sa, sb)
+	}
+	if ia, ib := a.Int(), b.Int(); ia != ib {
+		t.Errorf("expected the same ints for the same seed, got %d and %d", ia, ib)
+	}
+	if pa, pb := a.Perm(10), b.Perm(10); !reflect.DeepEqual(pa, pb) {
+		t.Errorf("expected the same permutations for the same seed, got %v and %v", pa, pb)
+	}
+	for i := 0; i < maxRangeTestCount; i++ {
+		if n := a.IntnRange(10, 20); n < 10 || n >= 20 {
+			t.Errorf("%d is not in range [10,20)", n)
+		}
+		if n := a.Int63nRange(10, 20); n < 10 || n >= 20 {
+			t.Errorf("%d is not in range [10,20)", n)
+		}
+		if n := a.Intn(5); n < 0 || n >= 5 {
+			t.Errorf("%d is not in range [0,5)", n)
+		}
+	}
+}
+
 // Confirm that panic occurs on invalid input.
 func TestRangePanic(t *testing.T) {
 	defer func() {
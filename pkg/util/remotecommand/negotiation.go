@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProtocolCapabilities describes the features of a remote command streaming
+// subprotocol, so that exec and attach implementations can branch on features
+// instead of protocol names.
+type ProtocolCapabilities struct {
+	// Version is the version of the subprotocol, 1 for StreamProtocolV1Name.
+	Version int
+	// TerminalResize is true if terminal size changes are sent on the
+	// StreamResize stream.
+	TerminalResize bool
+	// ExitCode is true if non-zero exit codes are reported on the StreamErr
+	// stream as a Status with NonZeroExitCodeReason and an ExitCodeCauseType
+	// cause.
+	ExitCode bool
+	// CloseSignal is true if a stream can be half-closed by sending its
+	// identifier on the StreamClose stream, e.g. to signal the end of stdin.
+	CloseSignal bool
+}
+
+// streamProtocolVersions maps the known subprotocols to their versions.
+var streamProtocolVersions = map[string]int{
+	StreamProtocolV1Name: 1,
+	StreamProtocolV2Name: 2,
+	StreamProtocolV3Name: 3,
+	StreamProtocolV4Name: 4,
+	StreamProtocolV5Name: 5,
+}
+
+// CapabilitiesForProtocol returns the capabilities of the given subprotocol,
+// or an error if it is unknown.
+func CapabilitiesForProtocol(protocol string) (ProtocolCapabilities, error) {
+	version, ok := streamProtocolVersions[protocol]
+	if !ok {
+		return ProtocolCapabilities{}, fmt.Errorf("unknown streaming protocol %q", protocol)
+	}
+	return ProtocolCapabilities{
+		Version:        version,
+		TerminalResize: version >= 3,
+		ExitCode:       version >= 4,
+		CloseSignal:    version >= 5,
+	}, nil
+}
+
+// NegotiateProtocol selects the highest version of the known subprotocols
+// offered by the client and supported by the server, and returns it together
+// with its capabilities. Client protocols may be given as raw header values
+// holding comma-separated lists. Protocols unknown to this package are
+// ignored. An error is returned if there is no common known protocol.
+func NegotiateProtocol(clientProtocols, serverProtocols []string) (string, ProtocolCapabilities, error) {
+	offered := map[string]bool{}
+	var parsedClientProtocols []string
+	for _, header := range clientProtocols {
+		for _, protocol := range strings.Split(header, ",") {
+			if protocol = strings.TrimSpace(protocol); len(protocol) > 0 {
+				offered[protocol] = true
+				parsedClientProtocols = append(parsedClientProtocols, protocol)
+			}
+		}
+	}
+
+	negotiated := ""
+	for _, protocol := range serverProtocols {
+		if !offered[protocol] {
+			continue
+		}
+		version, known := streamProtocolVersions[protocol]
+		if known && version > streamProtocolVersions[negotiated] {
+			negotiated = protocol
+		}
+	}
+	if len(negotiated) == 0 {
+		return "", ProtocolCapabilities{}, fmt.Errorf("unable to negotiate streaming protocol: client supports %v, server accepts %v", parsedClientProtocols, serverProtocols)
+	}
+	capabilities, err := CapabilitiesForProtocol(negotiated)
+	return negotiated, capabilities, err
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecommand
+
+import (
+	"testing"
+)
+
+func TestNegotiateProtocol(t *testing.T) {
+	allProtocols := []string{StreamProtocolV5Name, StreamProtocolV4Name, StreamProtocolV3Name, StreamProtocolV2Name, StreamProtocolV1Name}
+	tests := []struct {
+		name         string
+		client       []string
+		server       []string
+		expected     string
+		capabilities ProtocolCapabilities
+		expectErr    bool
+	}{
+		{
+			name:         "highest common version",
+			client:       []string{StreamProtocolV1Name, StreamProtocolV4Name, StreamProtocolV3Name},
+			server:       allProtocols,
+			expected:     StreamProtocolV4Name,
+			capabilities: ProtocolCapabilities{Version: 4, TerminalResize: true, ExitCode: true},
+		},
+		{
+			name:         "v5 supports close",
+			client:       []string{StreamProtocolV5Name, StreamProtocolV4Name},
+			server:       allProtocols,
+			expected:     StreamProtocolV5Name,
+			capabilities: ProtocolCapabilities{Version: 5, TerminalResize: true, ExitCode: true, CloseSignal: true},
+		},
+		{
+			name:         "server without v5",
+			client:       []string{StreamProtocolV5Name, StreamProtocolV4Name},
+			server:       SupportedStreamingProtocols,
+			expected:     StreamProtocolV4Name,
+			capabilities: ProtocolCapabilities{Version: 4, TerminalResize: true, ExitCode: true},
+		},
+		{
+			name:         "comma-separated header values",
+			client:       []string{"v2.channel.k8s.io, channel.k8s.io", " v3.channel.k8s.io"},
+			server:       allProtocols,
+			expected:     StreamProtocolV3Name,
+			capabilities: ProtocolCapabilities{Version: 3, TerminalResize: true},
+		},
+		{
+			name:         "unversioned protocol",
+			client:       []string{StreamProtocolV1Name},
+			server:       allProtocols,
+			expected:     StreamProtocolV1Name,
+			capabilities: ProtocolCapabilities{Version: 1},
+		},
+		{
+			name:      "unknown protocols are ignored",
+			client:    []string{"v9.channel.k8s.io"},
+			server:    []string{"v9.channel.k8s.io"},
+			expectErr: true,
+		},
+		{
+			name:      "no common protocol",
+			client:    []string{StreamProtocolV5Name},
+			server:    SupportedStreamingProtocols,
+			expectErr: true,
+		},
+		{
+			name:      "no client protocols",
+			server:    allProtocols,
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			protocol, capabilities, err := NegotiateProtocol(test.client, test.server)
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got protocol %q", protocol)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if protocol != test.expected {
+				t.Errorf("expected protocol %q, got %q", test.expected, protocol)
+			}
+			if capabilities != test.capabilities {
+				t.Errorf("expected capabilities %+v, got %+v", test.capabilities, capabilities)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesForProtocol(t *testing.T) {
+	if _, err := CapabilitiesForProtocol("unknown"); err == nil {
+		t.Errorf("expected error for unknown protocol")
+	}
+	capabilities, err := CapabilitiesForProtocol(StreamProtocolV2Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (ProtocolCapabilities{Version: 2}); capabilities != expected {
+		t.Errorf("expected %+v, got %+v", expected, capabilities)
+	}
+}
@@ -17,11 +17,13 @@ limitations under the License.
 package runtime
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -100,11 +102,7 @@ func logPanic(ctx context.Context, r interface{}) {
 		return
 	}
 
-	// Same as stdlib http server code. Manually allocate stack trace buffer size
-	// to prevent excessively large logs
-	const size = 64 << 10
-	stacktrace := make([]byte, size)
-	stacktrace = stacktrace[:runtime.Stack(stacktrace, false)]
+	stacktrace := stackTrace()
 
 	// We don't really know how many call frames to skip because the Go
 	// panic handler is between us and the code where the panic occurred.
@@ -116,10 +114,28 @@ func logPanic(ctx context.Context, r interface{}) {
 	// is handled here instead of defering to the logging
 	// backend.
 	if _, ok := r.(string); ok {
-		logger.Error(nil, "Observed a panic", "panic", r, "stacktrace", string(stacktrace))
+		logger.Error(nil, "Observed a panic", "panic", r, "stacktrace", stacktrace)
 	} else {
-		logger.Error(nil, "Observed a panic", "panic", fmt.Sprintf("%v", r), "panicGoValue", fmt.Sprintf("%#v", r), "stacktrace", string(stacktrace))
+		logger.Error(nil, "Observed a panic", "panic", fmt.Sprintf("%v", r), "panicGoValue", fmt.Sprintf("%#v", r), "stacktrace", stacktrace)
+	}
+}
+
+// stackTrace returns the stack trace of the current goroutine, limited in size
+// like the stack traces of panics. It starts at the caller of stackTrace.
+func stackTrace() string {
+	// Same as stdlib http server code. Manually allocate stack trace buffer size
+	// to prevent excessively large logs
+	const size = 64 << 10
+	stacktrace := make([]byte, size)
+	stacktrace = stacktrace[:runtime.Stack(stacktrace, false)]
+
+	// drop the frame of stackTrace, i.e. the function and file lines following
+	// the goroutine header
+	lines := bytes.SplitN(stacktrace, []byte("\n"), 4)
+	if len(lines) < 4 {
+		return string(stacktrace)
 	}
+	return string(lines[0]) + "\n" + string(lines[3])
 }
 
 // ErrorHandlers is a list of functions which will be invoked when a nonreturnable
@@ -198,6 +214,43 @@ func logError(ctx context.Context, err error, msg string, keysAndValues ...inter
 	logger.Error(err, msg, keysAndValues...) //nolint:logcheck // logcheck complains about unknown key/value pairs.
 }
 
+// LoggerErrorHandlerOptions configures the error handlers created by
+// NewLoggerErrorHandler.
+type LoggerErrorHandlerOptions struct {
+	// Name is added to the name of the logger. Defaults to "UnhandledError",
+	// like the default handler.
+	Name string
+
+	// StackTraceSampling, if positive, adds the stack trace of the goroutine
+	// which reported the error as "stacktrace" to the first of every
+	// StackTraceSampling errors. Stack traces are expensive to capture and
+	// large, so they are only logged for a sample of the errors.
+	StackTraceSampling int
+}
+
+// NewLoggerErrorHandler returns an ErrorHandler which logs errors to logger,
+// e.g. a logger backed by the logr sink of a component, rather than to the
+// logger of the context the errors are reported with. Like the default
+// handler, it logs the error with the message and key/value pairs of the call
+// site, and the location of the call to HandleError or HandleErrorWithContext.
+// Append it to ErrorHandlers, or replace the default handler with it, before
+// any error is handled.
+func NewLoggerErrorHandler(logger klog.Logger, options LoggerErrorHandlerOptions) ErrorHandler {
+	name := options.Name
+	if len(name) == 0 {
+		name = "UnhandledError"
+	}
+	// Handlers are called as <caller> -> HandleError[WithContext] -> handleError -> handler.
+	logger = klog.LoggerWithName(logger.WithCallDepth(3), name)
+	var reported atomic.Int64
+	return func(_ context.Context, err error, msg string, keysAndValues ...interface{}) {
+		if options.StackTraceSampling > 0 && (reported.Add(1)-1)%int64(options.StackTraceSampling) == 0 {
+			keysAndValues = append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "stacktrace", stackTrace())
+		}
+		logger.Error(err, msg, keysAndValues...) //nolint:logcheck // logcheck complains about unknown key/value pairs.
+	}
+}
+
 type rudimentaryErrorBackoff struct {
 	minPeriod time.Duration // immutable
 	// TODO(lavalamp): use the clock for testability. Need to move that
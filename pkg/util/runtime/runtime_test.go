@@ -24,10 +24,13 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"k8s.io/klog/v2/textlogger"
 )
 
 func TestHandleCrash(t *testing.T) {
@@ -79,6 +82,41 @@ func TestCustomHandleError(t *testing.T) {
 	}
 }
 
+func TestLoggerErrorHandler(t *testing.T) {
+	old := ErrorHandlers
+	defer func() { ErrorHandlers = old }()
+	var buffer bytes.Buffer
+	logger := textlogger.NewLogger(textlogger.NewConfig(textlogger.Output(&buffer)))
+	ErrorHandlers = []ErrorHandler{NewLoggerErrorHandler(logger, LoggerErrorHandlerOptions{Name: "test", StackTraceSampling: 2})}
+
+	_, _, line, _ := runtime.Caller(0)
+	for i := 0; i < 3; i++ {
+		HandleErrorWithContext(context.Background(), fmt.Errorf("error %d", i), "test message", "attempt", i)
+	}
+
+	lines := regexp.MustCompile(`(?m)^E`).Split(buffer.String(), -1)[1:]
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log entries, got %d:\n%s", len(lines), buffer.String())
+	}
+	for i, entry := range lines {
+		for _, expected := range []string{
+			fmt.Sprintf("runtime_test.go:%d]", line+2),
+			`"test message"`,
+			fmt.Sprintf(`err="error %d"`, i),
+			`logger="test"`,
+			fmt.Sprintf("attempt=%d", i),
+		} {
+			if !strings.Contains(entry, expected) {
+				t.Errorf("expected %s in log entry %d, got:\n%s", expected, i, entry)
+			}
+		}
+		// the stack traces of every second error are logged
+		if hasStack := strings.Contains(entry, "stacktrace="); hasStack != (i%2 == 0) {
+			t.Errorf("unexpected stack trace in log entry %d: %t", i, hasStack)
+		}
+	}
+}
+
 func TestHandleCrashLog(t *testing.T) {
 	log, err := captureStderr(func() {
 		defer func() {
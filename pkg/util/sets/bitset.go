@@ -0,0 +1,311 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const wordSize = 64
+
+// BitSet is a set of non-negative integers, implemented as a bitset. It uses
+// one bit per integer up to the largest one inserted, so it is much smaller
+// than a Set[int] for dense sets of small integers such as ports, indices or
+// ordinals, but not suitable for sparse sets of large integers.
+//
+// Methods panic if given negative integers, except Has, HasAll and HasAny,
+// which return false for them. The zero value is an empty set.
+type BitSet struct {
+	words []uint64
+}
+
+// NewBitSet creates a BitSet from a list of values.
+func NewBitSet(items ...int) *BitSet {
+	return (&BitSet{}).Insert(items...)
+}
+
+func checkBitSetItem(item int) {
+	if item < 0 {
+		panic(fmt.Sprintf("negative item %d cannot be stored in a BitSet", item))
+	}
+}
+
+func (s *BitSet) grow(item int) {
+	if n := item/wordSize + 1; n > len(s.words) {
+		if n <= cap(s.words) {
+			// clear the words left over from deletions
+			old := len(s.words)
+			s.words = s.words[:n]
+			clear(s.words[old:])
+		} else {
+			words := make([]uint64, n, 2*n)
+			copy(words, s.words)
+			s.words = words
+		}
+	}
+}
+
+// trim drops the trailing empty words.
+func (s *BitSet) trim() {
+	n := len(s.words)
+	for n > 0 && s.words[n-1] == 0 {
+		n--
+	}
+	s.words = s.words[:n]
+}
+
+// Insert adds items to the set.
+func (s *BitSet) Insert(items ...int) *BitSet {
+	for _, item := range items {
+		checkBitSetItem(item)
+		s.grow(item)
+		s.words[item/wordSize] |= 1 << (item % wordSize)
+	}
+	return s
+}
+
+// InsertRange adds the integers from start up to, but not including, end to
+// the set.
+func (s *BitSet) InsertRange(start, end int) *BitSet {
+	checkBitSetItem(start)
+	if end <= start {
+		return s
+	}
+	s.grow(end - 1)
+	for i := start; i < end; {
+		word, bit := i/wordSize, i%wordSize
+		n := min(wordSize-bit, end-i)
+		if n == wordSize {
+			s.words[word] = ^uint64(0)
+		} else {
+			s.words[word] |= ((1 << n) - 1) << bit
+		}
+		i += n
+	}
+	return s
+}
+
+// Delete removes items from the set.
+func (s *BitSet) Delete(items ...int) *BitSet {
+	for _, item := range items {
+		checkBitSetItem(item)
+		if word := item / wordSize; word < len(s.words) {
+			s.words[word] &^= 1 << (item % wordSize)
+		}
+	}
+	s.trim()
+	return s
+}
+
+// Clear empties the set.
+func (s *BitSet) Clear() *BitSet {
+	s.words = s.words[:0]
+	return s
+}
+
+// Has returns true if and only if item is contained in the set.
+func (s *BitSet) Has(item int) bool {
+	if item < 0 || item/wordSize >= len(s.words) {
+		return false
+	}
+	return s.words[item/wordSize]&(1<<(item%wordSize)) != 0
+}
+
+// HasAll returns true if and only if all items are contained in the set.
+func (s *BitSet) HasAll(items ...int) bool {
+	for _, item := range items {
+		if !s.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAny returns true if any items are contained in the set.
+func (s *BitSet) HasAny(items ...int) bool {
+	for _, item := range items {
+		if s.Has(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of elements in the set.
+func (s *BitSet) Len() int {
+	n := 0
+	for _, word := range s.words {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// Clone returns a new set which is a copy of the current set.
+func (s *BitSet) Clone() *BitSet {
+	return &BitSet{words: append([]uint64(nil), s.words...)}
+}
+
+// Union returns a new set which includes items in either s1 or s2.
+func (s1 *BitSet) Union(s2 *BitSet) *BitSet {
+	longer, shorter := s1, s2
+	if len(shorter.words) > len(longer.words) {
+		longer, shorter = shorter, longer
+	}
+	result := longer.Clone()
+	for i, word := range shorter.words {
+		result.words[i] |= word
+	}
+	return result
+}
+
+// Intersection returns a new set which includes the items in both s1 and s2.
+func (s1 *BitSet) Intersection(s2 *BitSet) *BitSet {
+	result := &BitSet{words: make([]uint64, min(len(s1.words), len(s2.words)))}
+	for i := range result.words {
+		result.words[i] = s1.words[i] & s2.words[i]
+	}
+	result.trim()
+	return result
+}
+
+// Difference returns a set of objects that are not in s2.
+func (s1 *BitSet) Difference(s2 *BitSet) *BitSet {
+	result := s1.Clone()
+	for i := 0; i < len(result.words) && i < len(s2.words); i++ {
+		result.words[i] &^= s2.words[i]
+	}
+	result.trim()
+	return result
+}
+
+// SymmetricDifference returns a set of elements which are in either of the
+// sets, but not in their intersection.
+func (s1 *BitSet) SymmetricDifference(s2 *BitSet) *BitSet {
+	longer, shorter := s1, s2
+	if len(shorter.words) > len(longer.words) {
+		longer, shorter = shorter, longer
+	}
+	result := longer.Clone()
+	for i, word := range shorter.words {
+		result.words[i] ^= word
+	}
+	result.trim()
+	return result
+}
+
+// IsSuperset returns true if and only if s1 is a superset of s2.
+func (s1 *BitSet) IsSuperset(s2 *BitSet) bool {
+	for i, word := range s2.words {
+		if i >= len(s1.words) || word&^s1.words[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if and only if s1 is equal (as a set) to s2.
+func (s1 *BitSet) Equal(s2 *BitSet) bool {
+	if len(s1.words) != len(s2.words) {
+		return false
+	}
+	for i := range s1.words {
+		if s1.words[i] != s2.words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the smallest item of the set which is greater than or equal to
+// from, and false if there is none.
+func (s *BitSet) Next(from int) (int, bool) {
+	if from < 0 {
+		from = 0
+	}
+	word := from / wordSize
+	if word >= len(s.words) {
+		return 0, false
+	}
+	// ignore the bits below from in the first word
+	w := s.words[word] >> (from % wordSize)
+	if w != 0 {
+		return from + bits.TrailingZeros64(w), true
+	}
+	for word++; word < len(s.words); word++ {
+		if s.words[word] != 0 {
+			return word*wordSize + bits.TrailingZeros64(s.words[word]), true
+		}
+	}
+	return 0, false
+}
+
+// NextAbsent returns the smallest non-negative integer greater than or equal
+// to from which is not in the set, e.g. to allocate the lowest free ordinal.
+func (s *BitSet) NextAbsent(from int) int {
+	if from < 0 {
+		from = 0
+	}
+	word := from / wordSize
+	if word >= len(s.words) {
+		return from
+	}
+	w := ^s.words[word] >> (from % wordSize)
+	if w != 0 {
+		return from + bits.TrailingZeros64(w)
+	}
+	for word++; word < len(s.words); word++ {
+		if s.words[word] != ^uint64(0) {
+			return word*wordSize + bits.TrailingZeros64(^s.words[word])
+		}
+	}
+	return len(s.words) * wordSize
+}
+
+// Range calls fn for the items of the set in ascending order, until fn
+// returns false. The set must not be modified by fn.
+func (s *BitSet) Range(fn func(item int) bool) {
+	for i, word := range s.words {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			if !fn(i*wordSize + bit) {
+				return
+			}
+			word &^= 1 << bit
+		}
+	}
+}
+
+// List returns the contents as a sorted int slice.
+func (s *BitSet) List() []int {
+	result := make([]int, 0, s.Len())
+	s.Range(func(item int) bool {
+		result = append(result, item)
+		return true
+	})
+	return result
+}
+
+// Set returns the contents as a Set[int].
+func (s *BitSet) Set() Set[int] {
+	result := make(Set[int], s.Len())
+	s.Range(func(item int) bool {
+		result.Insert(item)
+		return true
+	})
+	return result
+}
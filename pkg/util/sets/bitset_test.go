@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBitSet(t *testing.T) {
+	s := NewBitSet()
+	if s.Len() != 0 || s.Has(0) || s.Has(-1) {
+		t.Errorf("expected empty set, got %v", s.List())
+	}
+	s.Insert(3, 64, 200, 3)
+	if !s.HasAll(3, 64, 200) || s.HasAny(0, 63, 65, 1000) || s.Len() != 3 {
+		t.Errorf("unexpected contents %v", s.List())
+	}
+	if list := s.List(); !reflect.DeepEqual(list, []int{3, 64, 200}) {
+		t.Errorf("unexpected list %v", list)
+	}
+	s.Delete(200, 5000)
+	if list := s.List(); !reflect.DeepEqual(list, []int{3, 64}) {
+		t.Errorf("unexpected list after delete %v", list)
+	}
+	s.Clear()
+	if s.Len() != 0 {
+		t.Errorf("expected empty set after clear, got %v", s.List())
+	}
+	// words reused after clear must not keep old items
+	s.Insert(100)
+	if list := s.List(); !reflect.DeepEqual(list, []int{100}) {
+		t.Errorf("unexpected list after reuse %v", list)
+	}
+
+	var zero BitSet
+	zero.Insert(1)
+	if !zero.Has(1) {
+		t.Errorf("expected the zero value to be usable")
+	}
+}
+
+func TestBitSetRange(t *testing.T) {
+	s := NewBitSet().InsertRange(60, 200).InsertRange(5, 5).InsertRange(300, 301)
+	expected := NewBitSet(300)
+	for i := 60; i < 200; i++ {
+		expected.Insert(i)
+	}
+	if !s.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected.List(), s.List())
+	}
+
+	var visited []int
+	s.Range(func(item int) bool {
+		visited = append(visited, item)
+		return len(visited) < 3
+	})
+	if !reflect.DeepEqual(visited, []int{60, 61, 62}) {
+		t.Errorf("unexpected iteration %v", visited)
+	}
+
+	for _, tc := range []struct {
+		from, next int
+		ok         bool
+	}{
+		{from: -5, next: 60, ok: true},
+		{from: 64, next: 64, ok: true},
+		{from: 199, next: 199, ok: true},
+		{from: 200, next: 300, ok: true},
+		{from: 301, ok: false},
+	} {
+		if next, ok := s.Next(tc.from); next != tc.next || ok != tc.ok {
+			t.Errorf("Next(%d): expected %d, %v, got %d, %v", tc.from, tc.next, tc.ok, next, ok)
+		}
+	}
+	for from, expected := range map[int]int{0: 0, 60: 200, 128: 200, 300: 301, 1000: 1000} {
+		if next := s.NextAbsent(from); next != expected {
+			t.Errorf("NextAbsent(%d): expected %d, got %d", from, expected, next)
+		}
+	}
+	if next := NewBitSet().InsertRange(0, 128).NextAbsent(0); next != 128 {
+		t.Errorf("expected 128 after a full range, got %d", next)
+	}
+}
+
+func TestBitSetOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a, b := randomItems(rnd), randomItems(rnd)
+		bitA, bitB := NewBitSet(a...), NewBitSet(b...)
+		setA, setB := New(a...), New(b...)
+
+		check := func(name string, bitSet *BitSet, set Set[int]) {
+			if !reflect.DeepEqual(bitSet.Set(), set) || bitSet.Len() != set.Len() {
+				t.Fatalf("%s: expected %v, got %v", name, List(set), bitSet.List())
+			}
+		}
+		check("union", bitA.Union(bitB), setA.Union(setB))
+		check("intersection", bitA.Intersection(bitB), setA.Intersection(setB))
+		check("difference", bitA.Difference(bitB), setA.Difference(setB))
+		check("symmetric difference", bitA.SymmetricDifference(bitB), setA.SymmetricDifference(setB))
+		if bitA.IsSuperset(bitB) != setA.IsSuperset(setB) {
+			t.Fatalf("unexpected IsSuperset for %v and %v", a, b)
+		}
+		if bitA.Equal(bitB) != setA.Equal(setB) {
+			t.Fatalf("unexpected Equal for %v and %v", a, b)
+		}
+		if !bitA.Equal(bitA.Clone()) || !bitA.Union(bitB).IsSuperset(bitB) {
+			t.Fatalf("unexpected results for %v and %v", a, b)
+		}
+		check("unmodified", bitA, setA)
+	}
+}
+
+func randomItems(rnd *rand.Rand) []int {
+	items := make([]int, rnd.Intn(10))
+	for i := range items {
+		items[i] = rnd.Intn(300)
+	}
+	return items
+}
+
+func TestBitSetNegative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for negative item")
+		}
+	}()
+	NewBitSet(-1)
+}
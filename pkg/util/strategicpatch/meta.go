@@ -31,6 +31,15 @@ import (
 const patchMergeKey = "x-kubernetes-patch-merge-key"
 const patchStrategy = "x-kubernetes-patch-strategy"
 
+// Extensions of structural schemas, see
+// https://kubernetes.io/docs/reference/using-api/server-side-apply/#merge-strategy
+const (
+	listTypeExtension              = "x-kubernetes-list-type"
+	listMapKeysExtension           = "x-kubernetes-list-map-keys"
+	mapTypeExtension               = "x-kubernetes-map-type"
+	preserveUnknownFieldsExtension = "x-kubernetes-preserve-unknown-fields"
+)
+
 type PatchMeta struct {
 	patchStrategies []string
 	patchMergeKey   string
@@ -76,15 +85,15 @@ func NewPatchMetaFromStruct(dataStruct interface{}) (PatchMetaFromStruct, error)
 var _ LookupPatchMeta = PatchMetaFromStruct{}
 
 func (s PatchMetaFromStruct) LookupPatchMetadataForStruct(key string) (LookupPatchMeta, PatchMeta, error) {
-	fieldType, fieldPatchStrategies, fieldPatchMergeKey, err := forkedjson.LookupPatchMetadataForStruct(s.T, key)
+	metadata, err := forkedjson.CachedLookupPatchMetadataForStruct(s.T, key)
 	if err != nil {
 		return nil, PatchMeta{}, err
 	}
 
-	return PatchMetaFromStruct{T: fieldType},
+	return PatchMetaFromStruct{T: metadata.Type},
 		PatchMeta{
-			patchStrategies: fieldPatchStrategies,
-			patchMergeKey:   fieldPatchMergeKey,
+			patchStrategies: metadata.PatchStrategies,
+			patchMergeKey:   metadata.PatchMergeKey,
 		}, nil
 }
 
@@ -157,23 +166,38 @@ type PatchMetaFromOpenAPIV3 struct {
 	// SchemaList is required to resolve OpenAPI V3 references
 	SchemaList map[string]*spec.Schema
 	Schema     *spec.Schema
+	// StructuralSchema maps the list and map type extensions of structural
+	// schemas, which CustomResourceDefinitions use instead of patch
+	// extensions, to patch metadata. It is meant for the schemas of custom
+	// resources, and leaves the patch metadata of other schemas unchanged
+	// when false.
+	StructuralSchema bool
 }
 
 func (s PatchMetaFromOpenAPIV3) traverse(key string) (PatchMetaFromOpenAPIV3, error) {
 	if s.Schema == nil {
 		return PatchMetaFromOpenAPIV3{}, nil
 	}
-	if len(s.Schema.Properties) == 0 {
-		return PatchMetaFromOpenAPIV3{}, fmt.Errorf("unable to find api field \"%s\"", key)
+	if subschema, ok := s.Schema.Properties[key]; ok {
+		return PatchMetaFromOpenAPIV3{SchemaList: s.SchemaList, Schema: &subschema, StructuralSchema: s.StructuralSchema}, nil
+	}
+	// maps, e.g. map[string]Item in CustomResourceDefinitions, declare their
+	// values in additionalProperties
+	if s.Schema.AdditionalProperties != nil && s.Schema.AdditionalProperties.Schema != nil {
+		return PatchMetaFromOpenAPIV3{SchemaList: s.SchemaList, Schema: s.Schema.AdditionalProperties.Schema, StructuralSchema: s.StructuralSchema}, nil
 	}
-	subschema, ok := s.Schema.Properties[key]
-	if !ok {
-		return PatchMetaFromOpenAPIV3{}, fmt.Errorf("unable to find api field \"%s\"", key)
+	// fields of objects preserving unknown fields have no schema, and are
+	// patched without patch metadata
+	if preserve, _ := s.Schema.Extensions.GetBool(preserveUnknownFieldsExtension); preserve {
+		return PatchMetaFromOpenAPIV3{SchemaList: s.SchemaList, StructuralSchema: s.StructuralSchema}, nil
 	}
-	return PatchMetaFromOpenAPIV3{SchemaList: s.SchemaList, Schema: &subschema}, nil
+	return PatchMetaFromOpenAPIV3{}, fmt.Errorf("unable to find api field \"%s\"", key)
 }
 
 func resolve(l *PatchMetaFromOpenAPIV3) error {
+	if l.Schema == nil {
+		return nil
+	}
 	if len(l.Schema.AllOf) > 0 {
 		l.Schema = &l.Schema.AllOf[0]
 	}
@@ -189,19 +213,65 @@ func resolve(l *PatchMetaFromOpenAPIV3) error {
 	return nil
 }
 
+// patchMetaFromExtensions returns the patch metadata declared by the
+// extensions of schema. The x-kubernetes-patch-strategy and
+// x-kubernetes-patch-merge-key extensions of built-in types take precedence.
+// Without them, and if structural is true, the list and map type extensions of
+// structural schemas are mapped to the equivalent patch semantics: lists of
+// type map with a single key are merged by that key, lists of type set are
+// merged as lists of primitives, and atomic maps are replaced. This gives
+// patches of custom resources the retainKeys and setElementOrder handling of
+// built-in types.
+func patchMetaFromExtensions(schema *spec.Schema, structural bool) (PatchMeta, error) {
+	p := PatchMeta{}
+	if schema == nil {
+		return p, nil
+	}
+	if f, ok := schema.Extensions[patchMergeKey]; ok {
+		mergeKey, ok := f.(string)
+		if !ok {
+			return p, mergepatch.ErrBadArgType(mergeKey, f)
+		}
+		p.SetPatchMergeKey(mergeKey)
+	}
+	if g, ok := schema.Extensions[patchStrategy]; ok {
+		strategy, ok := g.(string)
+		if !ok {
+			return p, mergepatch.ErrBadArgType(strategy, g)
+		}
+		p.SetPatchStrategies(strings.Split(strategy, ","))
+		return p, nil
+	}
+	if !structural {
+		return p, nil
+	}
+
+	if listType, ok := schema.Extensions.GetString(listTypeExtension); ok {
+		switch listType {
+		case "map":
+			// strategic merge patch merges lists by a single key only, lists
+			// with composite keys are replaced
+			if keys, _ := schema.Extensions.GetStringSlice(listMapKeysExtension); len(keys) == 1 {
+				p.SetPatchMergeKey(keys[0])
+				p.SetPatchStrategies([]string{mergeDirective})
+			}
+		case "set":
+			p.SetPatchStrategies([]string{mergeDirective})
+		}
+	} else if mapType, ok := schema.Extensions.GetString(mapTypeExtension); ok && mapType == "atomic" {
+		p.SetPatchStrategies([]string{replaceDirective})
+	}
+	return p, nil
+}
+
 func (s PatchMetaFromOpenAPIV3) LookupPatchMetadataForStruct(key string) (LookupPatchMeta, PatchMeta, error) {
 	l, err := s.traverse(key)
 	if err != nil {
 		return l, PatchMeta{}, err
 	}
-	p := PatchMeta{}
-	f, ok := l.Schema.Extensions[patchMergeKey]
-	if ok {
-		p.SetPatchMergeKey(f.(string))
-	}
-	g, ok := l.Schema.Extensions[patchStrategy]
-	if ok {
-		p.SetPatchStrategies(strings.Split(g.(string), ","))
+	p, err := patchMetaFromExtensions(l.Schema, s.StructuralSchema)
+	if err != nil {
+		return l, PatchMeta{}, err
 	}
 
 	err = resolve(&l)
@@ -213,16 +283,11 @@ func (s PatchMetaFromOpenAPIV3) LookupPatchMetadataForSlice(key string) (LookupP
 	if err != nil {
 		return l, PatchMeta{}, err
 	}
-	p := PatchMeta{}
-	f, ok := l.Schema.Extensions[patchMergeKey]
-	if ok {
-		p.SetPatchMergeKey(f.(string))
-	}
-	g, ok := l.Schema.Extensions[patchStrategy]
-	if ok {
-		p.SetPatchStrategies(strings.Split(g.(string), ","))
+	p, err := patchMetaFromExtensions(l.Schema, s.StructuralSchema)
+	if err != nil {
+		return l, PatchMeta{}, err
 	}
-	if l.Schema.Items != nil {
+	if l.Schema != nil && l.Schema.Items != nil {
 		l.Schema = l.Schema.Items.Schema
 	}
 	err = resolve(&l)
@@ -231,7 +296,7 @@ func (s PatchMetaFromOpenAPIV3) LookupPatchMetadataForSlice(key string) (LookupP
 
 func (s PatchMetaFromOpenAPIV3) Name() string {
 	schema := s.Schema
-	if len(schema.Type) > 0 {
+	if schema != nil && len(schema.Type) > 0 {
 		return strings.Join(schema.Type, "")
 	}
 	return "Struct"
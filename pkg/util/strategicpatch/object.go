@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategicpatch
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// StrategicMergePatchObject applies a strategic merge patch to original, and
+// returns the patched object, of the same type as original, which is not
+// modified. Typed objects are converted to and from their unstructured
+// representation instead of being marshaled to and unmarshaled from JSON.
+// Unstructured objects are patched as they are.
+//
+// If the patched content has fields unknown to the type of original, the
+// patched object is returned along with a strict decoding error listing them,
+// for which runtime.IsStrictDecodingError returns true, so that callers decide
+// whether to reject it. Other errors are returned without an object.
+func StrategicMergePatchObject(original runtime.Object, patch []byte, schema LookupPatchMeta) (runtime.Object, error) {
+	if original == nil {
+		return nil, fmt.Errorf("unable to patch a nil object")
+	}
+	patchMap, err := handleUnmarshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if u, ok := original.(runtime.Unstructured); ok {
+		result, err := StrategicMergeMapPatchUsingLookupPatchMeta(runtime.DeepCopyJSON(u.UnstructuredContent()), patchMap, schema)
+		if err != nil {
+			return nil, err
+		}
+		patched := u.NewEmptyInstance()
+		patched.SetUnstructuredContent(result)
+		return patched, nil
+	}
+
+	t := reflect.TypeOf(original)
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to patch %T, expected a pointer to a struct", original)
+	}
+	originalMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(original)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert %T to unstructured: %w", original, err)
+	}
+	result, err := StrategicMergeMapPatchUsingLookupPatchMeta(originalMap, patchMap, schema)
+	if err != nil {
+		return nil, err
+	}
+	patched, ok := reflect.New(t.Elem()).Interface().(runtime.Object)
+	if !ok {
+		return nil, fmt.Errorf("unable to create a new %T", original)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(result, patched, true); err != nil {
+		if runtime.IsStrictDecodingError(err) {
+			return patched, err
+		}
+		return nil, fmt.Errorf("unable to convert the patched object to %T: %w", original, err)
+	}
+	return patched, nil
+}
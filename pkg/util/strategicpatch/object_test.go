@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategicpatch
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type mergeItemObject struct {
+	Value string      `json:"value,omitempty"`
+	Items []MergeItem `json:"items,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+func (obj *mergeItemObject) GetObjectKind() schema.ObjectKind { return schema.EmptyObjectKind }
+func (obj *mergeItemObject) DeepCopyObject() runtime.Object {
+	copied := *obj
+	copied.Items = append([]MergeItem(nil), obj.Items...)
+	return &copied
+}
+
+func TestStrategicMergePatchObject(t *testing.T) {
+	original := &mergeItemObject{
+		Value: "a",
+		Items: []MergeItem{{Name: "1", Value: "one"}, {Name: "2", Value: "two"}},
+	}
+	lookup, err := NewPatchMetaFromStruct(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := StrategicMergePatchObject(original, []byte(`{"value":"b","items":[{"name":"2","value":"deux"},{"name":"3"}]}`), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &mergeItemObject{
+		Value: "b",
+		Items: []MergeItem{{Name: "1", Value: "one"}, {Name: "2", Value: "deux"}, {Name: "3"}},
+	}
+	if !reflect.DeepEqual(patched, expected) {
+		t.Errorf("expected %#v, got %#v", expected, patched)
+	}
+	if original.Value != "a" || len(original.Items) != 2 || original.Items[1].Value != "two" {
+		t.Errorf("expected the original object to be unchanged, got %#v", original)
+	}
+
+	patched, err = StrategicMergePatchObject(original, []byte(`{"unknown":true,"value":"c"}`), lookup)
+	if !runtime.IsStrictDecodingError(err) {
+		t.Errorf("expected a strict decoding error, got %v", err)
+	}
+	if typed, ok := patched.(*mergeItemObject); !ok || typed.Value != "c" {
+		t.Errorf("expected the patched object along with the strict error, got %#v", patched)
+	}
+
+	if _, err := StrategicMergePatchObject(original, []byte(`{"items":"not a list"}`), lookup); err == nil || runtime.IsStrictDecodingError(err) {
+		t.Errorf("expected a conversion error, got %v", err)
+	}
+	if _, err := StrategicMergePatchObject(original, []byte(`not json`), lookup); err == nil {
+		t.Error("expected an invalid patch to fail")
+	}
+}
+
+func TestStrategicMergePatchUnstructuredObject(t *testing.T) {
+	original := &unstructured.Unstructured{Object: map[string]interface{}{
+		"value": "a",
+		"items": []interface{}{map[string]interface{}{"name": "1", "value": "one"}},
+	}}
+	lookup, err := NewPatchMetaFromStruct(&mergeItemObject{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := StrategicMergePatchObject(original, []byte(`{"items":[{"name":"2"}],"extra":1}`), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &unstructured.Unstructured{Object: map[string]interface{}{
+		"value": "a",
+		"extra": int64(1),
+		"items": []interface{}{map[string]interface{}{"name": "2"}, map[string]interface{}{"name": "1", "value": "one"}},
+	}}
+	if !reflect.DeepEqual(patched, expected) {
+		t.Errorf("expected %#v, got %#v", expected, patched)
+	}
+	if _, found := original.Object["extra"]; found {
+		t.Error("expected the original object to be unchanged")
+	}
+}
@@ -146,10 +146,8 @@ func CreateTwoWayMergeMapPatchUsingLookupPatchMeta(original, modified JSONMap, s
 	}
 
 	// Apply the preconditions to the patch, and return an error if any of them fail.
-	for _, fn := range fns {
-		if !fn(patchMap) {
-			return nil, mergepatch.NewErrPreconditionFailed(patchMap)
-		}
+	if !mergepatch.CheckPreconditions(patchMap, fns...) {
+		return nil, mergepatch.NewErrPreconditionFailed(patchMap)
 	}
 
 	return patchMap, nil
@@ -2152,10 +2150,8 @@ func CreateThreeWayMergePatch(original, modified, current []byte, schema LookupP
 	}
 
 	// Apply the preconditions to the patch, and return an error if any of them fail.
-	for _, fn := range fns {
-		if !fn(patchMap) {
-			return nil, mergepatch.NewErrPreconditionFailed(patchMap)
-		}
+	if !mergepatch.CheckPreconditions(patchMap, fns...) {
+		return nil, mergepatch.NewErrPreconditionFailed(patchMap)
 	}
 
 	// If overwrite is false, and the patch contains any keys that were changed differently,
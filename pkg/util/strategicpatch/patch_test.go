@@ -37,8 +37,9 @@ var (
 	fakeMergeItemSchema     = sptest.Fake{Path: filepath.Join("testdata", "swagger-merge-item.json")}
 	fakePrecisionItemSchema = sptest.Fake{Path: filepath.Join("testdata", "swagger-precision-item.json")}
 
-	fakeMergeItemV3Schema     = sptest.OpenAPIV3Getter{Path: filepath.Join("testdata", "swagger-merge-item-v3.json")}
-	fakePrecisionItemV3Schema = sptest.OpenAPIV3Getter{Path: filepath.Join("testdata", "swagger-precision-item-v3.json")}
+	fakeMergeItemV3Schema      = sptest.OpenAPIV3Getter{Path: filepath.Join("testdata", "swagger-merge-item-v3.json")}
+	fakePrecisionItemV3Schema  = sptest.OpenAPIV3Getter{Path: filepath.Join("testdata", "swagger-precision-item-v3.json")}
+	fakeCustomResourceV3Schema = sptest.OpenAPIV3Getter{Path: filepath.Join("testdata", "swagger-custom-resource-v3.json")}
 )
 
 type SortMergeListTestCases struct {
@@ -7045,3 +7046,136 @@ func TestUnknownField(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomResourcePatchMetaFromOpenAPIV3(t *testing.T) {
+	schema := PatchMetaFromOpenAPIV3{
+		SchemaList:       fakeCustomResourceV3Schema.SchemaOrDie().Components.Schemas,
+		Schema:           fakeCustomResourceV3Schema.SchemaOrDie().Components.Schemas["customResource"],
+		StructuralSchema: true,
+	}
+
+	testCases := []struct {
+		name           string
+		original       string
+		modified       string
+		current        string
+		expectedPatch  string
+		expectedResult string
+	}{
+		{
+			name:           "list of type map is merged by its key and keeps its order",
+			original:       `{"spec":{"ports":[{"name":"a","port":1},{"name":"b","port":2}]}}`,
+			modified:       `{"spec":{"ports":[{"name":"c","port":3},{"name":"a","port":1}]}}`,
+			current:        `{"spec":{"ports":[{"name":"a","port":1},{"name":"b","port":2},{"name":"d","port":4}]}}`,
+			expectedPatch:  `{"spec":{"$setElementOrder/ports":[{"name":"c"},{"name":"a"}],"ports":[{"name":"c","port":3},{"$patch":"delete","name":"b"}]}}`,
+			expectedResult: `{"spec":{"ports":[{"name":"d","port":4},{"name":"c","port":3},{"name":"a","port":1}]}}`,
+		},
+		{
+			name:           "list of type map with a composite key is replaced",
+			original:       `{"spec":{"endpoints":[{"host":"a","port":1}]}}`,
+			modified:       `{"spec":{"endpoints":[{"host":"a","port":2}]}}`,
+			current:        `{"spec":{"endpoints":[{"host":"a","port":1},{"host":"b","port":1}]}}`,
+			expectedPatch:  `{"spec":{"endpoints":[{"host":"a","port":2}]}}`,
+			expectedResult: `{"spec":{"endpoints":[{"host":"a","port":2}]}}`,
+		},
+		{
+			name:           "list of type set is merged",
+			original:       `{"spec":{"tags":["a","b"]}}`,
+			modified:       `{"spec":{"tags":["c","a"]}}`,
+			current:        `{"spec":{"tags":["a","b","d"]}}`,
+			expectedPatch:  `{"spec":{"$deleteFromPrimitiveList/tags":["b"],"$setElementOrder/tags":["c","a"],"tags":["c"]}}`,
+			expectedResult: `{"spec":{"tags":["c","a","d"]}}`,
+		},
+		{
+			name:           "atomic list is replaced",
+			original:       `{"spec":{"args":["a","b"]}}`,
+			modified:       `{"spec":{"args":["a"]}}`,
+			current:        `{"spec":{"args":["a","b","c"]}}`,
+			expectedPatch:  `{"spec":{"args":["a"]}}`,
+			expectedResult: `{"spec":{"args":["a"]}}`,
+		},
+		{
+			name:           "atomic map is replaced",
+			original:       `{"spec":{"selector":{"a":"1"}}}`,
+			modified:       `{"spec":{"selector":{"b":"2"}}}`,
+			current:        `{"spec":{"selector":{"a":"1","c":"3"}}}`,
+			expectedPatch:  `{"spec":{"selector":{"b":"2"}}}`,
+			expectedResult: `{"spec":{"selector":{"b":"2"}}}`,
+		},
+		{
+			name:           "retainKeys clears unset members",
+			original:       `{"spec":{"strategy":{"type":"RollingUpdate","rollingUpdate":{"maxSurge":1}}}}`,
+			modified:       `{"spec":{"strategy":{"type":"Recreate","recreate":{"delay":2}}}}`,
+			current:        `{"spec":{"strategy":{"type":"RollingUpdate","rollingUpdate":{"maxSurge":1}}}}`,
+			expectedPatch:  `{"spec":{"strategy":{"$retainKeys":["recreate","type"],"recreate":{"delay":2},"rollingUpdate":null,"type":"Recreate"}}}`,
+			expectedResult: `{"spec":{"strategy":{"recreate":{"delay":2},"type":"Recreate"}}}`,
+		},
+		{
+			name:           "map values are looked up in additionalProperties",
+			original:       `{"spec":{"backends":{"x":{"ports":[{"name":"a","port":1}]}}}}`,
+			modified:       `{"spec":{"backends":{"x":{"ports":[{"name":"a","port":2}]}}}}`,
+			current:        `{"spec":{"backends":{"x":{"ports":[{"name":"a","port":1},{"name":"b","port":3}]}}}}`,
+			expectedPatch:  `{"spec":{"backends":{"x":{"$setElementOrder/ports":[{"name":"a"}],"ports":[{"name":"a","port":2}]}}}}`,
+			expectedResult: `{"spec":{"backends":{"x":{"ports":[{"name":"a","port":2},{"name":"b","port":3}]}}}}`,
+		},
+		{
+			name:           "fields preserving unknown fields are patched without metadata",
+			original:       `{"spec":{"config":{"list":[1,2],"nested":{"a":1}}}}`,
+			modified:       `{"spec":{"config":{"list":[1],"nested":{"b":2}}}}`,
+			current:        `{"spec":{"config":{"list":[1,2],"nested":{"a":1,"c":3}}}}`,
+			expectedPatch:  `{"spec":{"config":{"list":[1],"nested":{"a":null,"b":2}}}}`,
+			expectedResult: `{"spec":{"config":{"list":[1],"nested":{"b":2,"c":3}}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := CreateThreeWayMergePatch([]byte(tc.original), []byte(tc.modified), []byte(tc.current), schema, true)
+			if err != nil {
+				t.Fatalf("unexpected error creating patch: %v", err)
+			}
+			if !jsonEqual(t, patch, tc.expectedPatch) {
+				t.Errorf("expected patch:\n\t%s\ngot\n\t%s", tc.expectedPatch, patch)
+			}
+
+			result, err := StrategicMergePatchUsingLookupPatchMeta([]byte(tc.current), patch, schema)
+			if err != nil {
+				t.Fatalf("unexpected error applying patch: %v", err)
+			}
+			if !jsonEqual(t, result, tc.expectedResult) {
+				t.Errorf("expected result:\n\t%s\ngot\n\t%s", tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestCustomResourcePatchMetaFromOpenAPIV3WithoutStructuralSchema(t *testing.T) {
+	schema := PatchMetaFromOpenAPIV3{
+		SchemaList: fakeCustomResourceV3Schema.SchemaOrDie().Components.Schemas,
+		Schema:     fakeCustomResourceV3Schema.SchemaOrDie().Components.Schemas["customResource"],
+	}
+
+	// list type extensions are ignored, and lists without patch extensions
+	// are replaced
+	original := `{"spec":{"ports":[{"name":"a","port":1},{"name":"b","port":2}]}}`
+	modified := `{"spec":{"ports":[{"name":"c","port":3},{"name":"a","port":1}]}}`
+	patch, err := CreateThreeWayMergePatch([]byte(original), []byte(modified), []byte(original), schema, true)
+	if err != nil {
+		t.Fatalf("unexpected error creating patch: %v", err)
+	}
+	if expected := `{"spec":{"ports":[{"name":"c","port":3},{"name":"a","port":1}]}}`; !jsonEqual(t, patch, expected) {
+		t.Errorf("expected patch:\n\t%s\ngot\n\t%s", expected, patch)
+	}
+}
+
+func jsonEqual(t *testing.T, actual []byte, expected string) bool {
+	t.Helper()
+	var actualObj, expectedObj interface{}
+	if err := json.Unmarshal(actual, &actualObj); err != nil {
+		t.Fatalf("unable to unmarshal %s: %v", actual, err)
+	}
+	if err := json.Unmarshal([]byte(expected), &expectedObj); err != nil {
+		t.Fatalf("unable to unmarshal %s: %v", expected, err)
+	}
+	return reflect.DeepEqual(actualObj, expectedObj)
+}
@@ -17,11 +17,122 @@ limitations under the License.
 package uuid
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
 	"github.com/google/uuid"
+	"k8s.io/utils/clock"
 
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// NewUUID returns a random (version 4) UUID.
 func NewUUID() types.UID {
 	return types.UID(uuid.New().String())
 }
+
+// NewUUIDv7 returns a time-ordered (version 7) UUID, as defined by RFC 9562.
+// UUIDs returned by successive calls in a process are strictly increasing,
+// both as strings and as bytes, which improves the locality of indexes keyed
+// by UID.
+func NewUUIDv7() types.UID {
+	return defaultTimeOrdered.NewUID()
+}
+
+// Generator generates UIDs. Code which generates UIDs can accept a Generator
+// so that tests can inject deterministic UIDs.
+type Generator interface {
+	NewUID() types.UID
+}
+
+// GeneratorFunc is a function implementing Generator.
+type GeneratorFunc func() types.UID
+
+// NewUID calls f.
+func (f GeneratorFunc) NewUID() types.UID {
+	return f()
+}
+
+var (
+	// RandomGenerator generates UIDs with NewUUID.
+	RandomGenerator Generator = GeneratorFunc(NewUUID)
+	// TimeOrderedGenerator generates UIDs with NewUUIDv7.
+	TimeOrderedGenerator Generator = GeneratorFunc(NewUUIDv7)
+)
+
+var defaultTimeOrdered = NewTimeOrderedGenerator(clock.RealClock{}, rand.Reader)
+
+// timeOrderedGenerator generates version 7 UUIDs. The 12 bits following the
+// millisecond timestamp are a counter, which starts at a random value in the
+// lower half of its range for each millisecond, keeping UUIDs generated
+// within the same millisecond ordered.
+type timeOrderedGenerator struct {
+	clock clock.PassiveClock
+	rand  io.Reader
+
+	lock   sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+// NewTimeOrderedGenerator returns a Generator of version 7 UUIDs, which takes
+// the time from clock and random bits from rand. With a fake clock and a
+// seeded reader it generates deterministic UIDs for tests.
+func NewTimeOrderedGenerator(clock clock.PassiveClock, rand io.Reader) Generator {
+	return &timeOrderedGenerator{clock: clock, rand: rand}
+}
+
+func (g *timeOrderedGenerator) NewUID() types.UID {
+	var u uuid.UUID
+	if _, err := io.ReadFull(g.rand, u[6:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bits: %v", err))
+	}
+
+	g.lock.Lock()
+	ms := g.clock.Now().UnixMilli()
+	if ms <= g.lastMs {
+		// within the same millisecond, or the clock went backwards
+		ms = g.lastMs
+		g.seq++
+		if g.seq > 0xfff {
+			// the counter overflowed, borrow the next millisecond
+			ms++
+			g.seq = binary.BigEndian.Uint16(u[6:8]) & 0x7ff
+		}
+	} else {
+		g.seq = binary.BigEndian.Uint16(u[6:8]) & 0x7ff
+	}
+	g.lastMs = ms
+	seq := g.seq
+	g.lock.Unlock()
+
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|seq)
+	u[8] = u[8]&0x3f | 0x80
+	return types.UID(u.String())
+}
+
+// sequentialGenerator generates UIDs from a counter.
+type sequentialGenerator struct {
+	counter atomic.Uint64
+}
+
+// NewSequentialGenerator returns a Generator of deterministic UIDs for tests:
+// "00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002",
+// and so on.
+func NewSequentialGenerator() Generator {
+	return &sequentialGenerator{}
+}
+
+func (g *sequentialGenerator) NewUID() types.UID {
+	return types.UID(fmt.Sprintf("00000000-0000-0000-0000-%012x", g.counter.Add(1)))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uuid
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNewUUIDv7(t *testing.T) {
+	previous := ""
+	for i := 0; i < 10000; i++ {
+		uid := string(NewUUIDv7())
+		u, err := uuid.Parse(uid)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", uid, err)
+		}
+		if u.Version() != 7 || u.Variant() != uuid.RFC4122 {
+			t.Fatalf("Expected a version 7 RFC 4122 UUID, got %q", uid)
+		}
+		if uid <= previous {
+			t.Fatalf("Expected %q to sort after %q", uid, previous)
+		}
+		previous = uid
+	}
+}
+
+func TestTimeOrderedGenerator(t *testing.T) {
+	start := time.UnixMilli(1700000000000)
+	newGenerator := func() (Generator, *testingclock.FakePassiveClock) {
+		clock := testingclock.NewFakePassiveClock(start)
+		return NewTimeOrderedGenerator(clock, rand.New(rand.NewSource(1))), clock
+	}
+
+	g, clock := newGenerator()
+	var uids []string
+	for i := 0; i < 5000; i++ {
+		uids = append(uids, string(g.NewUID()))
+	}
+	clock.SetTime(start.Add(-time.Second))
+	uids = append(uids, string(g.NewUID()))
+	clock.SetTime(start.Add(time.Hour))
+	uids = append(uids, string(g.NewUID()))
+
+	for i, uid := range uids {
+		u := uuid.MustParse(uid)
+		if i > 0 && uid <= uids[i-1] {
+			t.Fatalf("Expected %q to sort after %q", uid, uids[i-1])
+		}
+		ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+		if i == 0 && ms != start.UnixMilli() {
+			t.Errorf("Expected timestamp %d, got %d", start.UnixMilli(), ms)
+		}
+		if i == len(uids)-1 && ms != start.Add(time.Hour).UnixMilli() {
+			t.Errorf("Expected timestamp %d, got %d", start.Add(time.Hour).UnixMilli(), ms)
+		}
+	}
+
+	// the same clock and random source generate the same UIDs
+	again, _ := newGenerator()
+	for i := 0; i < 10; i++ {
+		if uid := string(again.NewUID()); uid != uids[i] {
+			t.Fatalf("Expected deterministic UID %q, got %q", uids[i], uid)
+		}
+	}
+
+	failing := NewTimeOrderedGenerator(testingclock.NewFakePassiveClock(start), bytes.NewReader(nil))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic when random bits cannot be read")
+		}
+	}()
+	failing.NewUID()
+}
+
+func TestSequentialGenerator(t *testing.T) {
+	g := NewSequentialGenerator()
+	if uid := g.NewUID(); uid != "00000000-0000-0000-0000-000000000001" {
+		t.Errorf("Unexpected UID %q", uid)
+	}
+	if uid := g.NewUID(); uid != "00000000-0000-0000-0000-000000000002" {
+		t.Errorf("Unexpected UID %q", uid)
+	}
+	var _ Generator = RandomGenerator
+	if uid := GeneratorFunc(func() types.UID { return "x" }).NewUID(); uid != "x" {
+		t.Errorf("Unexpected UID %q", uid)
+	}
+}
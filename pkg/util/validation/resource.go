@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// quotaRequestsPrefix is the prefix of extended resource names in resource
+// quotas.
+const quotaRequestsPrefix = "requests."
+
+// IsResourceName tests that the argument is a valid resource name, i.e. a
+// qualified name, such as "cpu" or "example.com/gpu".
+func IsResourceName(fldPath *field.Path, name string) field.ErrorList {
+	var allErrors field.ErrorList
+	for _, msg := range IsQualifiedName(name) {
+		allErrors = append(allErrors, field.Invalid(fldPath, name, msg))
+	}
+	return allErrors
+}
+
+// IsExtendedResourceName tests that the argument is a valid extended resource
+// name: a resource name prefixed by a domain outside of kubernetes.io, which
+// can also be quoted in resource quotas as "requests.<name>".
+func IsExtendedResourceName(fldPath *field.Path, name string) field.ErrorList {
+	allErrors := IsResourceName(fldPath, name)
+	if len(allErrors) != 0 {
+		return allErrors
+	}
+	domain, _, found := strings.Cut(name, "/")
+	switch {
+	case !found:
+		allErrors = append(allErrors, field.Invalid(fldPath, name, "an extended resource name must be prefixed by a domain (e.g. 'example.com/gpu')"))
+	case domain == "kubernetes.io" || strings.HasSuffix(domain, ".kubernetes.io"):
+		allErrors = append(allErrors, field.Invalid(fldPath, name, "an extended resource name must not be prefixed by a kubernetes.io domain"))
+	case strings.HasPrefix(name, quotaRequestsPrefix):
+		allErrors = append(allErrors, field.Invalid(fldPath, name, fmt.Sprintf("an extended resource name must not start with %q", quotaRequestsPrefix)))
+	default:
+		for _, msg := range IsQualifiedName(quotaRequestsPrefix + name) {
+			allErrors = append(allErrors, field.Invalid(fldPath, name, fmt.Sprintf("%q %s", quotaRequestsPrefix+name, msg)))
+		}
+	}
+	return allErrors
+}
+
+// IsNonNegativeQuantity tests that the argument is greater than or equal to 0.
+func IsNonNegativeQuantity(fldPath *field.Path, value resource.Quantity) field.ErrorList {
+	var allErrors field.ErrorList
+	if value.Sign() < 0 {
+		allErrors = append(allErrors, field.Invalid(fldPath, value.String(), "must be greater than or equal to 0"))
+	}
+	return allErrors
+}
+
+// IsPositiveQuantity tests that the argument is greater than 0.
+func IsPositiveQuantity(fldPath *field.Path, value resource.Quantity) field.ErrorList {
+	var allErrors field.ErrorList
+	if value.Sign() <= 0 {
+		allErrors = append(allErrors, field.Invalid(fldPath, value.String(), "must be greater than 0"))
+	}
+	return allErrors
+}
+
+// IsIntegerQuantity tests that the argument is a whole number, e.g. 2 or 1k
+// but not 500m.
+func IsIntegerQuantity(fldPath *field.Path, value resource.Quantity) field.ErrorList {
+	var allErrors field.ErrorList
+	if !isWithinScale(value, 0) {
+		allErrors = append(allErrors, field.Invalid(fldPath, value.String(), "must be an integer"))
+	}
+	return allErrors
+}
+
+// IsQuantityWithinScale tests that the argument can be represented at scale
+// without losing precision, e.g. that it has no more than three decimal
+// places when scale is resource.Milli.
+func IsQuantityWithinScale(fldPath *field.Path, value resource.Quantity, scale resource.Scale) field.ErrorList {
+	var allErrors field.ErrorList
+	if !isWithinScale(value, scale) {
+		allErrors = append(allErrors, field.Invalid(fldPath, value.String(), fmt.Sprintf("must not have a precision finer than 1e%d", scale)))
+	}
+	return allErrors
+}
+
+// IsResourceQuantity tests that the argument is a valid quantity of the named
+// resource: quantities must not be negative, and quantities of extended
+// resources must be integers. Validation of other resource specific
+// constraints is left to the callers.
+func IsResourceQuantity(fldPath *field.Path, name string, value resource.Quantity) field.ErrorList {
+	allErrors := IsNonNegativeQuantity(fldPath, value)
+	if len(IsExtendedResourceName(fldPath, name)) == 0 {
+		allErrors = append(allErrors, IsIntegerQuantity(fldPath, value)...)
+	}
+	return allErrors
+}
+
+// isWithinScale returns true if value can be represented at scale without
+// losing precision. value is a copy and can be rounded.
+func isWithinScale(value resource.Quantity, scale resource.Scale) bool {
+	return value.RoundUp(scale)
+}
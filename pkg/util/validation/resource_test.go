@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestIsExtendedResourceName(t *testing.T) {
+	// A domain which is valid on its own, but too long when quoted in
+	// resource quotas.
+	longDomain := strings.Repeat(strings.Repeat("a", 60)+".", 4) + "io"
+	goodValues := []string{
+		"example.com/gpu", "a.b.c/foo-bar", "example.com/" + strings.Repeat("a", 63),
+	}
+	for _, val := range goodValues {
+		if errs := IsExtendedResourceName(field.NewPath(""), val); len(errs) != 0 {
+			t.Errorf("expected no errors for %q: %v", val, errs)
+		}
+	}
+
+	badValues := []string{
+		"", "cpu", "example.com/", "/gpu", "Example.com/gpu", "example.com/-gpu",
+		"kubernetes.io/gpu", "node.kubernetes.io/gpu", "requests.example.com/gpu",
+		"example.com/" + strings.Repeat("a", 64), longDomain + "/gpu",
+	}
+	for _, val := range badValues {
+		if errs := IsExtendedResourceName(field.NewPath(""), val); len(errs) == 0 {
+			t.Errorf("expected errors for %q", val)
+		}
+	}
+}
+
+func TestIsResourceName(t *testing.T) {
+	for _, val := range []string{"cpu", "hugepages-2Mi", "example.com/gpu"} {
+		if errs := IsResourceName(field.NewPath(""), val); len(errs) != 0 {
+			t.Errorf("expected no errors for %q: %v", val, errs)
+		}
+	}
+	for _, val := range []string{"", "a/b/c", "-cpu", "cpu us"} {
+		if errs := IsResourceName(field.NewPath(""), val); len(errs) == 0 {
+			t.Errorf("expected errors for %q", val)
+		}
+	}
+}
+
+func TestQuantityValidation(t *testing.T) {
+	for _, tc := range []struct {
+		value       string
+		nonNegative bool
+		positive    bool
+		integer     bool
+		milli       bool
+	}{
+		{value: "0", nonNegative: true, integer: true, milli: true},
+		{value: "1", nonNegative: true, positive: true, integer: true, milli: true},
+		{value: "1k", nonNegative: true, positive: true, integer: true, milli: true},
+		{value: "1Gi", nonNegative: true, positive: true, integer: true, milli: true},
+		{value: "500m", nonNegative: true, positive: true, milli: true},
+		{value: "1.5", nonNegative: true, positive: true, milli: true},
+		{value: "1n", nonNegative: true, positive: true},
+		{value: "0.0001", nonNegative: true, positive: true},
+		{value: "-1", integer: true, milli: true},
+		{value: "-100m", milli: true},
+		{value: "12345678901234567890.5", nonNegative: true, positive: true, milli: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			q := resource.MustParse(tc.value)
+			for _, check := range []struct {
+				name  string
+				valid bool
+				errs  field.ErrorList
+			}{
+				{"IsNonNegativeQuantity", tc.nonNegative, IsNonNegativeQuantity(field.NewPath("value"), q)},
+				{"IsPositiveQuantity", tc.positive, IsPositiveQuantity(field.NewPath("value"), q)},
+				{"IsIntegerQuantity", tc.integer, IsIntegerQuantity(field.NewPath("value"), q)},
+				{"IsQuantityWithinScale", tc.milli, IsQuantityWithinScale(field.NewPath("value"), q, resource.Milli)},
+			} {
+				if valid := len(check.errs) == 0; valid != check.valid {
+					t.Errorf("%s: expected valid=%t, got errors %v", check.name, check.valid, check.errs)
+				}
+				for _, err := range check.errs {
+					if err.Field != "value" || err.Type != field.ErrorTypeInvalid {
+						t.Errorf("%s: unexpected error %v", check.name, err)
+					}
+				}
+			}
+			if expected := resource.MustParse(tc.value); q.Cmp(expected) != 0 {
+				t.Errorf("expected the quantity not to be modified, got %s", q.String())
+			}
+		})
+	}
+}
+
+func TestIsResourceQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{name: "cpu", value: "500m", valid: true},
+		{name: "cpu", value: "-1"},
+		{name: "example.com/gpu", value: "2", valid: true},
+		{name: "example.com/gpu", value: "500m"},
+		{name: "example.com/gpu", value: "-1"},
+		{name: "kubernetes.io/other", value: "500m", valid: true},
+	} {
+		errs := IsResourceQuantity(field.NewPath("resources").Key(tc.name), tc.name, resource.MustParse(tc.value))
+		if valid := len(errs) == 0; valid != tc.valid {
+			t.Errorf("%s=%s: expected valid=%t, got errors %v", tc.name, tc.value, tc.valid, errs)
+		}
+	}
+}
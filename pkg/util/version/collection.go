@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"sort"
+)
+
+// Sort sorts versions in increasing order. Equivalent versions, such as "1.29"
+// and "1.29.0", keep their relative order.
+func Sort(versions []*Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LessThan(versions[j])
+	})
+}
+
+// Filter returns the versions satisfying constraint, in their original order. A
+// nil constraint is satisfied by all versions.
+func Filter(versions []*Version, constraint *Constraint) []*Version {
+	var filtered []*Version
+	for _, v := range versions {
+		if constraint == nil || constraint.Check(v) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+// Latest returns the greatest of versions satisfying constraint, or nil if there
+// is none. A nil constraint is satisfied by all versions. If several equivalent
+// versions are the greatest, the first of them is returned.
+func Latest(versions []*Version, constraint *Constraint) *Version {
+	var latest *Version
+	for _, v := range versions {
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// Dedup returns versions without the versions equivalent to an earlier one, such
+// as "1.29.0" following "1.29". The remaining versions keep their order.
+func Dedup(versions []*Version) []*Version {
+	var deduped []*Version
+	// Version lists are short, so a quadratic scan is cheaper than sorting a copy.
+	for _, v := range versions {
+		duplicate := false
+		for _, seen := range deduped {
+			if v.EqualTo(seen) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			deduped = append(deduped, v)
+		}
+	}
+	return deduped
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parseAll(t *testing.T, versions ...string) []*Version {
+	t.Helper()
+	parsed := make([]*Version, 0, len(versions))
+	for _, v := range versions {
+		parsed = append(parsed, MustParse(v))
+	}
+	return parsed
+}
+
+func versionStrings(versions []*Version) []string {
+	strs := make([]string, 0, len(versions))
+	for _, v := range versions {
+		strs = append(strs, v.String())
+	}
+	return strs
+}
+
+func TestSort(t *testing.T) {
+	versions := parseAll(t, "1.30.0", "1.29", "1.30.0-alpha.1", "1.29.0", "1.2.10", "1.10.0")
+	Sort(versions)
+	want := []string{"1.2.10", "1.10.0", "1.29", "1.29.0", "1.30.0-alpha.1", "1.30.0"}
+	if got := versionStrings(versions); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFilterAndLatest(t *testing.T) {
+	versions := parseAll(t, "1.28.3", "1.31.0", "1.29.1", "1.32.0", "1.29.1+build")
+	c := MustParseConstraint(">=1.29 <1.32")
+
+	if got, want := versionStrings(Filter(versions, c)), []string{"1.31.0", "1.29.1", "1.29.1+build"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := Latest(versions, c); got == nil || got.String() != "1.31.0" {
+		t.Errorf("expected 1.31.0, got %v", got)
+	}
+	if got := Latest(versions, nil); got == nil || got.String() != "1.32.0" {
+		t.Errorf("expected 1.32.0, got %v", got)
+	}
+	if got := Latest(versions, MustParseConstraint(">=2.0")); got != nil {
+		t.Errorf("expected no version, got %v", got)
+	}
+	if got := Latest(nil, nil); got != nil {
+		t.Errorf("expected no version, got %v", got)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	versions := parseAll(t, "1.29", "1.30.0", "1.29.0", "v1.30", "1.31.0")
+	want := []string{"1.29", "1.30.0", "1.31.0"}
+	if got := versionStrings(Dedup(versions)); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
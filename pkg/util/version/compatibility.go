@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CompatibilityVersions holds the versions a component is running with: the
+// version of its binary, the version whose behavior it emulates, and the
+// minimum version it must stay compatible with, e.g. because peers of that
+// version may read data it persists.
+type CompatibilityVersions struct {
+	binary           *Version
+	emulation        *Version
+	minCompatibility *Version
+}
+
+// NewCompatibilityVersions returns the CompatibilityVersions of a component
+// with the given binary version. The emulation version defaults to the
+// major.minor version of the binary, and the minimum compatibility version to
+// the minor version preceding the emulation version. Only major and minor
+// versions are compared, patch versions are ignored.
+//
+// An error is returned if the emulation version is greater than the binary
+// version, or the minimum compatibility version greater than the emulation
+// version.
+func NewCompatibilityVersions(binary, emulation, minCompatibility *Version) (*CompatibilityVersions, error) {
+	if binary == nil {
+		return nil, fmt.Errorf("binary version is required")
+	}
+	binaryMajorMinor := MajorMinor(binary.Major(), binary.Minor())
+	if emulation == nil {
+		emulation = binaryMajorMinor
+	} else {
+		emulation = MajorMinor(emulation.Major(), emulation.Minor())
+	}
+	if minCompatibility == nil {
+		minCompatibility = emulation.SubtractMinor(1)
+	} else {
+		minCompatibility = MajorMinor(minCompatibility.Major(), minCompatibility.Minor())
+	}
+	if emulation.GreaterThan(binaryMajorMinor) {
+		return nil, fmt.Errorf("emulation version %s is greater than binary version %s", emulation, binary)
+	}
+	if minCompatibility.GreaterThan(emulation) {
+		return nil, fmt.Errorf("minimum compatibility version %s is greater than emulation version %s", minCompatibility, emulation)
+	}
+	return &CompatibilityVersions{binary: binary, emulation: emulation, minCompatibility: minCompatibility}, nil
+}
+
+// BinaryVersion returns the version of the binary.
+func (c *CompatibilityVersions) BinaryVersion() *Version {
+	return c.binary
+}
+
+// EmulationVersion returns the major.minor version whose behavior is emulated.
+func (c *CompatibilityVersions) EmulationVersion() *Version {
+	return c.emulation
+}
+
+// MinCompatibilityVersion returns the minimum major.minor version the
+// component must stay compatible with.
+func (c *CompatibilityVersions) MinCompatibilityVersion() *Version {
+	return c.minCompatibility
+}
+
+// Lifecycle declares the versions in which a feature or behavior was
+// introduced and, if it was, removed. Like CompatibilityVersions, only major
+// and minor versions are compared, patch versions are ignored.
+type Lifecycle struct {
+	// Introduced is the first version with the feature.
+	Introduced *Version
+	// Removed, if set, is the first version without the feature.
+	Removed *Version
+}
+
+// majorMinor returns the lifecycle with the patch versions of Introduced and
+// Removed dropped.
+func (l Lifecycle) majorMinor() Lifecycle {
+	if l.Introduced != nil {
+		l.Introduced = MajorMinor(l.Introduced.Major(), l.Introduced.Minor())
+	}
+	if l.Removed != nil {
+		l.Removed = MajorMinor(l.Removed.Major(), l.Removed.Minor())
+	}
+	return l
+}
+
+// availableAt returns true if the major.minor lifecycle l makes the feature
+// available at the major.minor version v.
+func (l Lifecycle) availableAt(v *Version) bool {
+	if l.Introduced != nil && v.LessThan(l.Introduced) {
+		return false
+	}
+	return l.Removed == nil || v.LessThan(l.Removed)
+}
+
+// CompatibilityMatrix answers whether version-gated features are allowed for a
+// component, given its CompatibilityVersions and the declared lifecycles of
+// the features. It is safe for concurrent use.
+type CompatibilityMatrix struct {
+	versions *CompatibilityVersions
+
+	lock       sync.RWMutex
+	lifecycles map[string]Lifecycle
+}
+
+// NewCompatibilityMatrix returns an empty CompatibilityMatrix for a component
+// running with versions.
+func NewCompatibilityMatrix(versions *CompatibilityVersions) *CompatibilityMatrix {
+	return &CompatibilityMatrix{versions: versions, lifecycles: map[string]Lifecycle{}}
+}
+
+// Versions returns the versions of the component.
+func (m *CompatibilityMatrix) Versions() *CompatibilityVersions {
+	return m.versions
+}
+
+// Register declares the lifecycle of feature. It returns an error if feature
+// is already registered, or if it is not removed in a later major.minor version
+// than it is introduced.
+func (m *CompatibilityMatrix) Register(feature string, lifecycle Lifecycle) error {
+	lifecycle = lifecycle.majorMinor()
+	if lifecycle.Introduced != nil && lifecycle.Removed != nil && !lifecycle.Removed.GreaterThan(lifecycle.Introduced) {
+		return fmt.Errorf("feature %q is removed in %s before it is introduced in %s", feature, lifecycle.Removed, lifecycle.Introduced)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, exists := m.lifecycles[feature]; exists {
+		return fmt.Errorf("feature %q is already registered", feature)
+	}
+	m.lifecycles[feature] = lifecycle
+	return nil
+}
+
+// MustRegister is like Register, but panics on errors.
+func (m *CompatibilityMatrix) MustRegister(feature string, lifecycle Lifecycle) {
+	if err := m.Register(feature, lifecycle); err != nil {
+		panic(err)
+	}
+}
+
+// Allowed returns true if feature is available at the emulation version of the
+// component. Unregistered features are not allowed.
+func (m *CompatibilityMatrix) Allowed(feature string) bool {
+	return m.Check(feature) == nil
+}
+
+// AllowedForPeers returns true if feature is available both at the emulation
+// version and at the minimum compatibility version of the component, so that
+// peers of any compatible version understand it, e.g. before persisting data
+// in a new format. Unregistered features are not allowed.
+func (m *CompatibilityMatrix) AllowedForPeers(feature string) bool {
+	lifecycle, err := m.lookup(feature)
+	if err != nil {
+		return false
+	}
+	return lifecycle.availableAt(m.versions.emulation) && lifecycle.availableAt(m.versions.minCompatibility)
+}
+
+// Check returns an error describing why feature is not allowed, or nil if it
+// is allowed, see Allowed.
+func (m *CompatibilityMatrix) Check(feature string) error {
+	lifecycle, err := m.lookup(feature)
+	if err != nil {
+		return err
+	}
+	if lifecycle.availableAt(m.versions.emulation) {
+		return nil
+	}
+	if lifecycle.Removed != nil && !m.versions.emulation.LessThan(lifecycle.Removed) {
+		return fmt.Errorf("feature %q was removed in %s, emulation version is %s", feature, lifecycle.Removed, m.versions.emulation)
+	}
+	return fmt.Errorf("feature %q is introduced in %s, emulation version is %s", feature, lifecycle.Introduced, m.versions.emulation)
+}
+
+func (m *CompatibilityMatrix) lookup(feature string) (Lifecycle, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	lifecycle, ok := m.lifecycles[feature]
+	if !ok {
+		return Lifecycle{}, fmt.Errorf("feature %q is not registered", feature)
+	}
+	return lifecycle, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCompatibilityVersions(t *testing.T) {
+	versions, err := NewCompatibilityVersions(MustParse("1.31.2"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions.EmulationVersion().String() != "1.31" || versions.MinCompatibilityVersion().String() != "1.30" {
+		t.Errorf("unexpected defaults: emulation %s, min compatibility %s", versions.EmulationVersion(), versions.MinCompatibilityVersion())
+	}
+
+	if _, err := NewCompatibilityVersions(MustParse("1.31.0"), MustParse("1.32"), nil); err == nil {
+		t.Errorf("expected an error for an emulation version greater than the binary version")
+	}
+	if _, err := NewCompatibilityVersions(MustParse("1.31.0"), MustParse("1.29"), MustParse("1.30")); err == nil {
+		t.Errorf("expected an error for a minimum compatibility version greater than the emulation version")
+	}
+	if _, err := NewCompatibilityVersions(nil, nil, nil); err == nil {
+		t.Errorf("expected an error without a binary version")
+	}
+}
+
+func TestCompatibilityMatrix(t *testing.T) {
+	lifecycles := map[string]Lifecycle{
+		"Old":     {Introduced: MustParse("1.20")},
+		"New":     {Introduced: MustParse("1.30")},
+		"Future":  {Introduced: MustParse("1.32")},
+		"Removed": {Introduced: MustParse("1.20"), Removed: MustParse("1.30")},
+		"Leaving": {Introduced: MustParse("1.20"), Removed: MustParse("1.31")},
+	}
+	tests := []struct {
+		name                    string
+		emulation               string
+		expectedAllowed         []string
+		expectedAllowedForPeers []string
+	}{
+		{
+			name:                    "binary version",
+			expectedAllowed:         []string{"Old", "New"},
+			expectedAllowedForPeers: []string{"Old", "New"},
+		},
+		{
+			name:                    "emulating the previous version",
+			emulation:               "1.30",
+			expectedAllowed:         []string{"Old", "New", "Leaving"},
+			expectedAllowedForPeers: []string{"Old", "Leaving"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var emulation *Version
+			if len(test.emulation) > 0 {
+				emulation = MustParse(test.emulation)
+			}
+			versions, err := NewCompatibilityVersions(MustParse("1.31.4"), emulation, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := NewCompatibilityMatrix(versions)
+			for feature, lifecycle := range lifecycles {
+				m.MustRegister(feature, lifecycle)
+			}
+			for feature := range lifecycles {
+				if allowed := m.Allowed(feature); allowed != contains(test.expectedAllowed, feature) {
+					t.Errorf("expected Allowed(%q) to be %t", feature, !allowed)
+				}
+				if err := m.Check(feature); (err == nil) != contains(test.expectedAllowed, feature) {
+					t.Errorf("unexpected Check(%q) result: %v", feature, err)
+				}
+				if allowed := m.AllowedForPeers(feature); allowed != contains(test.expectedAllowedForPeers, feature) {
+					t.Errorf("expected AllowedForPeers(%q) to be %t", feature, !allowed)
+				}
+			}
+			if m.Allowed("Unknown") || m.Check("Unknown") == nil {
+				t.Errorf("expected unregistered features not to be allowed")
+			}
+		})
+	}
+}
+
+func TestCompatibilityMatrixRegister(t *testing.T) {
+	versions, _ := NewCompatibilityVersions(MustParse("1.31.0"), nil, nil)
+	m := NewCompatibilityMatrix(versions)
+	if err := m.Register("A", Lifecycle{Introduced: MustParse("1.30")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register("A", Lifecycle{Introduced: MustParse("1.30")}); err == nil {
+		t.Errorf("expected an error registering a feature twice")
+	}
+	if err := m.Register("B", Lifecycle{Introduced: MustParse("1.30"), Removed: MustParse("1.30")}); err == nil {
+		t.Errorf("expected an error for a feature removed when it is introduced")
+	}
+	if err := m.Register("C", Lifecycle{Introduced: MustParse("1.30.0"), Removed: MustParse("1.30.2")}); err == nil {
+		t.Errorf("expected an error for a feature removed in the minor version it is introduced in")
+	}
+
+	// patch versions are ignored when checking features too
+	if err := m.Register("D", Lifecycle{Introduced: MustParse("1.29.3"), Removed: MustParse("1.31.1")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check("D"); err == nil || !strings.Contains(err.Error(), "was removed in 1.31") {
+		t.Errorf("expected D to be removed at 1.31, got %v", err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Constraint is a parsed version constraint expression, such as ">=1.28 <1.32".
+//
+// An expression is a list of alternatives separated by "||", and a version
+// satisfies the expression if it satisfies any alternative. An alternative is a
+// list of requirements separated by whitespace or commas, all of which must be
+// satisfied. A requirement is an operator followed by a version:
+//
+//	=, ==  equal to the version (the default if the operator is omitted)
+//	!=     not equal to the version
+//	>, >=  greater than, or greater than or equal to, the version
+//	<, <=  less than, or less than or equal to, the version
+//	~      at least the version, and less than the next minor release
+//	       ("~1.29.2" is ">=1.29.2 <1.30")
+//	^      at least the version, and less than the next major release
+//	       ("^1.29" is ">=1.29 <2.0")
+//
+// Versions in requirements are parsed as semantic versions if possible, and as
+// generic versions otherwise, so "1.29" and "1.29.0" are both accepted and are
+// equivalent. Comparisons follow the Version methods: missing components are
+// zero, pre-release identifiers are only considered when both the checked
+// version and the requirement version are semantic versions, and build metadata
+// is always ignored. In particular "1.30.0-alpha.1" satisfies ">=1.30" but not
+// ">=1.30.0", and satisfies "<1.30.0" but not "<1.30".
+type Constraint struct {
+	raw          string
+	alternatives [][]requirement
+}
+
+type requirement struct {
+	op      string
+	version *Version
+}
+
+// requirementRE matches a single requirement at the start of its input.
+var requirementRE = regexp.MustCompile(`^(==|=|!=|>=|<=|>|<|~|\^)?\s*(v?[0-9][^\s,|]*)`)
+
+// ParseConstraint parses a version constraint expression. See Constraint for
+// the syntax.
+func ParseConstraint(str string) (*Constraint, error) {
+	c := &Constraint{raw: str}
+	for _, alternative := range strings.Split(str, "||") {
+		var requirements []requirement
+		rest := strings.TrimSpace(alternative)
+		for rest != "" {
+			m := requirementRE.FindStringSubmatch(rest)
+			if m == nil {
+				return nil, fmt.Errorf("could not parse %q in version constraint %q", rest, str)
+			}
+			v, err := ParseSemantic(m[2])
+			if err != nil {
+				if v, err = ParseGeneric(m[2]); err != nil {
+					return nil, fmt.Errorf("invalid version constraint %q: %v", str, err)
+				}
+			}
+			op := m[1]
+			if op == "" || op == "==" {
+				op = "="
+			}
+			requirements = append(requirements, requirement{op: op, version: v})
+			rest = strings.TrimLeft(rest[len(m[0]):], " \t,")
+		}
+		if len(requirements) == 0 {
+			return nil, fmt.Errorf("empty alternative in version constraint %q", str)
+		}
+		c.alternatives = append(c.alternatives, requirements)
+	}
+	return c, nil
+}
+
+// MustParseConstraint is like ParseConstraint except that it panics on error.
+func MustParseConstraint(str string) *Constraint {
+	c, err := ParseConstraint(str)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Check returns true if v satisfies the constraint.
+func (c *Constraint) Check(v *Version) bool {
+	for _, requirements := range c.alternatives {
+		satisfied := true
+		for _, r := range requirements {
+			if !r.check(v) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the constraint expression as it was parsed.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+func (r requirement) check(v *Version) bool {
+	cmp := v.compareInternal(r.version)
+	switch r.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~":
+		return cmp >= 0 && v.compareInternal(MajorMinor(r.version.Major(), r.version.Minor()+1)) < 0
+	case "^":
+		return cmp >= 0 && v.compareInternal(MajorMinor(r.version.Major()+1, 0)) < 0
+	}
+	return false
+}
+
+// Satisfies parses the version constraint expression constraint and returns
+// true if v satisfies it. See Constraint for the syntax.
+func (v *Version) Satisfies(constraint string) (bool, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"testing"
+)
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		satisfied  []string
+		rejected   []string
+	}{
+		{
+			constraint: ">=1.28 <1.32",
+			satisfied:  []string{"1.28", "1.28.0", "1.29.5", "1.31.99", "v1.30.0+build"},
+			rejected:   []string{"1.27.9", "1.32", "1.32.0", "2.0.0"},
+		},
+		{
+			constraint: ">= 1.28, < 1.32",
+			satisfied:  []string{"1.28.0", "1.31.0"},
+			rejected:   []string{"1.27.0", "1.32.0"},
+		},
+		{
+			constraint: "~1.29",
+			satisfied:  []string{"1.29", "1.29.0", "1.29.12"},
+			rejected:   []string{"1.28.9", "1.30.0", "1.30.0-alpha.1"},
+		},
+		{
+			constraint: "~1.29.2",
+			satisfied:  []string{"1.29.2", "1.29.3"},
+			rejected:   []string{"1.29.1", "1.30.0"},
+		},
+		{
+			constraint: "^1.29",
+			satisfied:  []string{"1.29.0", "1.99.0"},
+			rejected:   []string{"1.28.0", "2.0.0"},
+		},
+		{
+			constraint: "!=1.30.0",
+			satisfied:  []string{"1.29.0", "1.30.1"},
+			rejected:   []string{"1.30.0", "1.30", "1.30.0+build"},
+		},
+		{
+			constraint: "1.30.1",
+			satisfied:  []string{"1.30.1", "v1.30.1"},
+			rejected:   []string{"1.30.0", "1.30.2"},
+		},
+		{
+			constraint: "<1.28 || >=1.30 <1.31",
+			satisfied:  []string{"1.27.0", "1.30.5"},
+			rejected:   []string{"1.28.0", "1.29.0", "1.31.0"},
+		},
+		{
+			// pre-releases only compare lower when both sides are semantic versions
+			constraint: ">=1.30",
+			satisfied:  []string{"1.30.0-alpha.1", "1.30.0"},
+		},
+		{
+			constraint: ">=1.30.0",
+			satisfied:  []string{"1.30.0", "1.30.1-alpha.0"},
+			rejected:   []string{"1.30.0-alpha.1", "1.30.0-rc.1"},
+		},
+		{
+			constraint: "<1.30.0",
+			satisfied:  []string{"1.30.0-alpha.1"},
+			rejected:   []string{"1.30.0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(test.constraint)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.String() != test.constraint {
+				t.Errorf("expected %q, got %q", test.constraint, c.String())
+			}
+			for _, v := range test.satisfied {
+				if !c.Check(MustParse(v)) {
+					t.Errorf("expected %q to satisfy %q", v, test.constraint)
+				}
+			}
+			for _, v := range test.rejected {
+				if c.Check(MustParse(v)) {
+					t.Errorf("expected %q not to satisfy %q", v, test.constraint)
+				}
+			}
+		})
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, constraint := range []string{
+		"",
+		"  ",
+		">=1.28 ||",
+		">=",
+		">=foo",
+		"=>1.28",
+		">=1",
+		"~1.29 bar",
+	} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("expected an error parsing %q", constraint)
+		}
+	}
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	ok, err := MustParseGeneric("1.29.3").Satisfies(">=1.28 <1.32")
+	if err != nil || !ok {
+		t.Errorf("expected 1.29.3 to satisfy the constraint, got %v, %v", ok, err)
+	}
+	if _, err := MustParseGeneric("1.29.3").Satisfies("!!1.28"); err == nil {
+		t.Errorf("expected an invalid constraint to fail")
+	}
+}
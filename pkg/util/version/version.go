@@ -18,6 +18,7 @@ package version
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -34,6 +35,8 @@ type Version struct {
 	preRelease    string
 	buildMetadata string
 	info          apimachineryversion.Info
+	// original is the string the version was parsed from, if any.
+	original string
 }
 
 var (
@@ -58,6 +61,7 @@ func parse(str string, semver bool) (*Version, error) {
 	v := &Version{
 		components: make([]uint, len(components)),
 		semver:     semver,
+		original:   strings.TrimSpace(str),
 	}
 	for i, comp := range components {
 		if (i == 0 || semver) && strings.HasPrefix(comp, "0") && comp != "0" {
@@ -245,6 +249,7 @@ func (v *Version) Components() []uint {
 func (v *Version) WithMajor(major uint) *Version {
 	result := *v
 	result.components = []uint{major, v.Minor(), v.Patch()}
+	result.original = ""
 	return &result
 }
 
@@ -252,6 +257,7 @@ func (v *Version) WithMajor(major uint) *Version {
 func (v *Version) WithMinor(minor uint) *Version {
 	result := *v
 	result.components = []uint{v.Major(), minor, v.Patch()}
+	result.original = ""
 	return &result
 }
 
@@ -285,6 +291,7 @@ func (v *Version) AddMinor(diff uint) *Version {
 func (v *Version) WithPatch(patch uint) *Version {
 	result := *v
 	result.components = []uint{v.Major(), v.Minor(), patch}
+	result.original = ""
 	return &result
 }
 
@@ -296,6 +303,7 @@ func (v *Version) WithPreRelease(preRelease string) *Version {
 	result := *v
 	result.components = []uint{v.Major(), v.Minor(), v.Patch()}
 	result.preRelease = preRelease
+	result.original = ""
 	return &result
 }
 
@@ -304,6 +312,7 @@ func (v *Version) WithBuildMetadata(buildMetadata string) *Version {
 	result := *v
 	result.components = []uint{v.Major(), v.Minor(), v.Patch()}
 	result.buildMetadata = buildMetadata
+	result.original = ""
 	return &result
 }
 
@@ -482,3 +491,52 @@ func itoa(i uint) string {
 	}
 	return strconv.Itoa(int(i))
 }
+
+// MarshalText implements encoding.TextMarshaler. Parsed versions are marshaled
+// in the exact form they were parsed from, other versions as returned by String.
+func (v *Version) MarshalText() ([]byte, error) {
+	if v.original != "" {
+		return []byte(v.original), nil
+	}
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. If v already holds a
+// version, text is parsed the same way v was, so that a version created by
+// ParseGeneric stays generic and keeps ignoring trailing data. Otherwise text is
+// parsed with Parse.
+func (v *Version) UnmarshalText(text []byte) error {
+	parse := Parse
+	if v.components != nil && !v.semver {
+		parse = ParseGeneric
+	}
+	parsed, err := parse(string(text))
+	if err != nil {
+		return err
+	}
+	*v = *parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the version as a JSON string
+// as returned by MarshalText.
+func (v *Version) MarshalJSON() ([]byte, error) {
+	text, err := v.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a JSON string with
+// UnmarshalText. A JSON null leaves the version unchanged.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(str))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package version
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -548,3 +549,87 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalJSON(t *testing.T) {
+	type config struct {
+		Version *Version `json:"version,omitempty"`
+	}
+	tests := []struct {
+		name     string
+		version  *Version
+		expected string
+	}{
+		{name: "semantic", version: MustParseSemantic("v1.30.0-alpha.1+abc"), expected: `{"version":"v1.30.0-alpha.1+abc"}`},
+		{name: "whitespace is trimmed", version: MustParseGeneric(" 1.29 "), expected: `{"version":"1.29"}`},
+		{name: "constructed", version: MajorMinor(1, 29), expected: `{"version":"1.29"}`},
+		{name: "modified", version: MustParseSemantic("v1.29.0").WithPatch(2), expected: `{"version":"1.29.2"}`},
+		{name: "nil", expected: `{}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(config{Version: test.version})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, data)
+			}
+
+			var decoded config
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.version == nil {
+				if decoded.Version != nil {
+					t.Errorf("expected nil version, got %v", decoded.Version)
+				}
+				return
+			}
+			if !decoded.Version.EqualTo(test.version) {
+				t.Errorf("expected %v, got %v", test.version, decoded.Version)
+			}
+			// the round trip preserves the original form
+			if roundTripped, _ := json.Marshal(decoded); string(roundTripped) != test.expected {
+				t.Errorf("expected %s after round trip, got %s", test.expected, roundTripped)
+			}
+		})
+	}
+}
+
+func TestMarshalGenericVersion(t *testing.T) {
+	v := MustParseGeneric("1.29.3-gke.100")
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `"1.29.3-gke.100"` {
+		t.Fatalf("expected the original form to be kept, got %s", data)
+	}
+
+	decoded := MustParseGeneric("0.0")
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, v) {
+		t.Errorf("expected %#v, got %#v", v, decoded)
+	}
+	if decoded.PreRelease() != "" || !decoded.AtLeast(MustParseGeneric("1.29.3")) {
+		t.Errorf("expected the version to stay generic, got %#v", decoded)
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	v := &Version{}
+	if err := v.UnmarshalText([]byte("1.30.0-rc.1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.PreRelease() != "rc.1" {
+		t.Errorf("expected version to be parsed as semantic, got %v", v)
+	}
+	if err := v.UnmarshalText([]byte("invalid")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := json.Unmarshal([]byte(`1.30`), v); err == nil {
+		t.Errorf("expected an error decoding a JSON number")
+	}
+}
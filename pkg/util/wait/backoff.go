@@ -64,6 +64,34 @@ func (b *Backoff) Step() time.Duration {
 	return nextDuration
 }
 
+// BackoffState is the state of a backoff which changes with every step. It can
+// be serialized, e.g. to JSON, to persist a backoff across process restarts.
+type BackoffState struct {
+	// Duration is the duration of the next step, before jitter.
+	Duration time.Duration `json:"duration"`
+	// Steps is the remaining number of steps which may change the duration.
+	Steps int `json:"steps"`
+	// LastStart is the time of the last step of a backoff which is reset
+	// after a period without steps. It is zero for a Backoff.
+	LastStart time.Time `json:"lastStart"`
+}
+
+// State returns the current state of the backoff.
+func (b Backoff) State() BackoffState {
+	return BackoffState{Duration: b.Duration, Steps: b.Steps}
+}
+
+// Restore sets the state of the backoff to a state previously returned by
+// State, so that the next Step continues where the original backoff stopped.
+// The other parameters of the backoff are not changed, and the restored
+// duration is limited to Cap.
+func (b *Backoff) Restore(state BackoffState) {
+	b.Duration, b.Steps = state.Duration, state.Steps
+	if b.Cap > 0 && b.Duration > b.Cap {
+		b.Duration = b.Cap
+	}
+}
+
 // DelayFunc returns a function that will compute the next interval to
 // wait given the arguments in b. It does not mutate the original backoff
 // but the function is safe to use only from a single goroutine.
@@ -317,6 +345,17 @@ type BackoffManager interface {
 	Backoff() clock.Timer
 }
 
+// StatefulBackoffManager is a BackoffManager whose state can be saved and
+// restored.
+type StatefulBackoffManager interface {
+	BackoffManager
+	// State returns the current state of the backoff.
+	State() BackoffState
+	// Restore sets the state of the backoff to a state previously returned
+	// by State.
+	Restore(state BackoffState)
+}
+
 // Deprecated: Will be removed when the legacy polling functions are removed.
 type exponentialBackoffManagerImpl struct {
 	backoff              *Backoff
@@ -330,6 +369,7 @@ type exponentialBackoffManagerImpl struct {
 // NewExponentialBackoffManager returns a manager for managing exponential backoff. Each backoff is jittered and
 // backoff will not exceed the given max. If the backoff is not called within resetDuration, the backoff is reset.
 // This backoff manager is used to reduce load during upstream unhealthiness.
+// The returned BackoffManager implements StatefulBackoffManager.
 //
 // Deprecated: Will be removed when the legacy Poll methods are removed. Callers should construct a
 // Backoff struct, use DelayWithReset() to get a DelayFunc that periodically resets itself, and then
@@ -380,6 +420,21 @@ func (b *exponentialBackoffManagerImpl) getNextBackoff() time.Duration {
 	return b.backoff.Step()
 }
 
+// State implements StatefulBackoffManager.State.
+func (b *exponentialBackoffManagerImpl) State() BackoffState {
+	state := b.backoff.State()
+	state.LastStart = b.lastBackoffStart
+	return state
+}
+
+// Restore implements StatefulBackoffManager.Restore. The backoff is reset by
+// the next call to Backoff if the restored state started longer ago than the
+// reset duration.
+func (b *exponentialBackoffManagerImpl) Restore(state BackoffState) {
+	b.backoff.Restore(state)
+	b.lastBackoffStart = state.LastStart
+}
+
 // Backoff implements BackoffManager.Backoff, it returns a timer so caller can block on the timer for exponential backoff.
 // The returned timer must be drained before calling Backoff() the second time
 func (b *exponentialBackoffManagerImpl) Backoff() clock.Timer {
@@ -19,6 +19,7 @@ package wait
 import (
 	"context"
 	"errors"
+	"fmt"
 )
 
 // ErrWaitTimeout is returned when the condition was not satisfied in time.
@@ -94,3 +95,15 @@ func (e errInterrupted) Error() string {
 	}
 	return e.cause.Error()
 }
+
+// lastConditionError is returned when polling is interrupted after the
+// condition returned an error, see PollUntilContextCancelWithLastError.
+type lastConditionError struct {
+	interrupted error
+	last        error
+}
+
+func (e *lastConditionError) Unwrap() []error { return []error{e.interrupted, e.last} }
+func (e *lastConditionError) Error() string {
+	return fmt.Sprintf("%v, last error: %v", e.interrupted, e.last)
+}
@@ -48,6 +48,39 @@ func PollUntilContextTimeout(ctx context.Context, interval, timeout time.Duratio
 	return loopConditionUntilContext(deadlineCtx, Backoff{Duration: interval}.Timer(), immediate, false, condition)
 }
 
+// PollUntilContextCancelWithLastError is like PollUntilContextCancel, but
+// errors returned by condition together with false do not stop polling. If the
+// context is cancelled or hits its deadline, the returned error wraps both the
+// context error and the error returned by the last call to condition, if any,
+// so that Interrupted returns true for it and errors.Is and errors.As find the
+// condition error. A call returning false and no error clears the errors of
+// earlier calls. An error returned by condition together with true is returned
+// as it is.
+func PollUntilContextCancelWithLastError(ctx context.Context, interval time.Duration, immediate bool, condition ConditionWithContextFunc) error {
+	var lastErr error
+	err := loopConditionUntilContext(ctx, Backoff{Duration: interval}.Timer(), immediate, false, func(ctx context.Context) (bool, error) {
+		done, err := condition(ctx)
+		if done {
+			return true, err
+		}
+		// cleared by attempts which are not done but return no error
+		lastErr = err
+		return false, nil
+	})
+	if err != nil && lastErr != nil && Interrupted(err) {
+		return &lastConditionError{interrupted: err, last: lastErr}
+	}
+	return err
+}
+
+// PollUntilContextTimeoutWithLastError is like PollUntilContextTimeout, with
+// the condition errors handled as by PollUntilContextCancelWithLastError.
+func PollUntilContextTimeoutWithLastError(ctx context.Context, interval, timeout time.Duration, immediate bool, condition ConditionWithContextFunc) error {
+	deadlineCtx, deadlineCancel := context.WithTimeout(ctx, timeout)
+	defer deadlineCancel()
+	return PollUntilContextCancelWithLastError(deadlineCtx, interval, immediate, condition)
+}
+
 // Poll tries a condition func until it returns true, an error, or the timeout
 // is reached.
 //
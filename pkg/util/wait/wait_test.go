@@ -18,6 +18,7 @@ package wait
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -756,6 +757,60 @@ func TestExponentialBackoffManagerGetNextBackoff(t *testing.T) {
 	}
 }
 
+func TestBackoffStateRestore(t *testing.T) {
+	backoff := Backoff{Duration: 1, Factor: 2, Steps: 10, Cap: 10}
+	backoff.Step()
+	backoff.Step()
+
+	data, err := json.Marshal(backoff.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state BackoffState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := Backoff{Duration: 1, Factor: 2, Steps: 10, Cap: 10}
+	restored.Restore(state)
+	if restored != backoff {
+		t.Errorf("expected restored backoff %#v, got %#v", backoff, restored)
+	}
+	for i, expected := range []time.Duration{4, 8, 10, 10} {
+		if d := restored.Step(); d != expected {
+			t.Errorf("unexpected %d-th step after restore: %d, expecting %d", i, d, expected)
+		}
+	}
+
+	capped := Backoff{Duration: 1, Cap: 5}
+	capped.Restore(BackoffState{Duration: 8, Steps: 3})
+	if capped.Duration != 5 || capped.Steps != 3 {
+		t.Errorf("expected the restored duration to be capped, got %#v", capped)
+	}
+}
+
+func TestExponentialBackoffManagerStateRestore(t *testing.T) {
+	fc := testingclock.NewFakeClock(time.Now())
+	backoff := NewExponentialBackoffManager(1, 10, 10, 2.0, 0.0, fc).(StatefulBackoffManager)
+	for i := 0; i < 3; i++ {
+		backoff.(*exponentialBackoffManagerImpl).getNextBackoff()
+	}
+	state := backoff.State()
+
+	restored := NewExponentialBackoffManager(1, 10, 10, 2.0, 0.0, fc).(StatefulBackoffManager)
+	restored.Restore(state)
+	if next := restored.(*exponentialBackoffManagerImpl).getNextBackoff(); next != 8 {
+		t.Errorf("expected the restored backoff to continue with 8, got %d", next)
+	}
+
+	fc.Step(11)
+	restored = NewExponentialBackoffManager(1, 10, 10, 2.0, 0.0, fc).(StatefulBackoffManager)
+	restored.Restore(state)
+	if next := restored.(*exponentialBackoffManagerImpl).getNextBackoff(); next != 1 {
+		t.Errorf("expected a backoff restored after the reset duration to be reset, got %d", next)
+	}
+}
+
 func TestJitteredBackoffManagerGetNextBackoff(t *testing.T) {
 	// positive jitter
 	backoffMgr := NewJitteredBackoffManager(1, 1, testingclock.NewFakeClock(time.Now()))
@@ -1536,3 +1591,70 @@ func Benchmark_poll(b *testing.B) {
 		}
 	}
 }
+
+type pollTestError struct{ attempt int }
+
+func (e *pollTestError) Error() string { return fmt.Sprintf("attempt %d failed", e.attempt) }
+
+func TestPollUntilContextTimeoutWithLastError(t *testing.T) {
+	attempts := 0
+	err := PollUntilContextTimeoutWithLastError(context.Background(), time.Millisecond, 50*time.Millisecond, true, func(ctx context.Context) (bool, error) {
+		attempts++
+		return false, &pollTestError{attempt: attempts}
+	})
+	if !Interrupted(err) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	var lastErr *pollTestError
+	if !errors.As(err, &lastErr) || lastErr.attempt != attempts {
+		t.Errorf("expected the error of attempt %d, got %v", attempts, err)
+	}
+	if expected := fmt.Sprintf("context deadline exceeded, last error: attempt %d failed", attempts); err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+
+	// errors are retried until the condition is done
+	attempts = 0
+	err = PollUntilContextTimeoutWithLastError(context.Background(), time.Millisecond, ForeverTestTimeout, false, func(ctx context.Context) (bool, error) {
+		attempts++
+		if attempts < 3 {
+			return false, errors.New("not yet")
+		}
+		return true, nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("expected success after 3 attempts, got %v after %d", err, attempts)
+	}
+
+	// errors returned with true end polling
+	terminal := errors.New("terminal")
+	err = PollUntilContextTimeoutWithLastError(context.Background(), time.Millisecond, ForeverTestTimeout, true, func(ctx context.Context) (bool, error) {
+		return true, terminal
+	})
+	if err != terminal {
+		t.Errorf("expected terminal error, got %v", err)
+	}
+
+	// without condition errors, the context error is returned as it is
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = PollUntilContextCancelWithLastError(ctx, time.Millisecond, false, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context error, got %v", err)
+	}
+
+	// an attempt without error clears the errors of earlier attempts
+	attempts = 0
+	err = PollUntilContextTimeoutWithLastError(context.Background(), time.Millisecond, 50*time.Millisecond, true, func(ctx context.Context) (bool, error) {
+		attempts++
+		if attempts == 1 {
+			return false, &pollTestError{attempt: attempts}
+		}
+		return false, nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the context error only, got %v", err)
+	}
+}
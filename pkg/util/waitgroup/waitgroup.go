@@ -17,17 +17,34 @@ limitations under the License.
 package waitgroup
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // SafeWaitGroup must not be copied after first use.
 type SafeWaitGroup struct {
+	// OnDrainTimeout, if set, is called when WaitWithContext or WaitTimeout
+	// give up while members are still active. It receives the number of active
+	// members per label passed to AddWithLabel; members added with Add are
+	// counted under the empty label. It must not be changed concurrently with
+	// calls to WaitWithContext or WaitTimeout.
+	OnDrainTimeout func(holders map[string]int)
+
 	wg sync.WaitGroup
 	mu sync.RWMutex
 	// wait indicate whether Wait is called, if true,
 	// then any Add with positive delta will return error.
 	wait bool
+	// active is the number of active members.
+	active atomic.Int64
+
+	// labelsLock protects labels.
+	labelsLock sync.Mutex
+	// labels counts the active members added with AddWithLabel.
+	labels map[string]int
 }
 
 // Add adds delta, which may be negative, similar to sync.WaitGroup.
@@ -40,11 +57,44 @@ func (wg *SafeWaitGroup) Add(delta int) error {
 		return fmt.Errorf("add with positive delta after Wait is forbidden")
 	}
 	wg.wg.Add(delta)
+	wg.active.Add(int64(delta))
 	return nil
 }
 
+// AddWithLabel adds a single member identified by label, which is reported by
+// OnDrainTimeout while the member is active. The returned function marks the
+// member done; calling it more than once has no effect.
+func (wg *SafeWaitGroup) AddWithLabel(label string) (func(), error) {
+	wg.mu.RLock()
+	defer wg.mu.RUnlock()
+	if wg.wait {
+		return nil, fmt.Errorf("add with positive delta after Wait is forbidden")
+	}
+	wg.labelsLock.Lock()
+	if wg.labels == nil {
+		wg.labels = map[string]int{}
+	}
+	wg.labels[label]++
+	wg.labelsLock.Unlock()
+	wg.wg.Add(1)
+	wg.active.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			wg.labelsLock.Lock()
+			if wg.labels[label]--; wg.labels[label] == 0 {
+				delete(wg.labels, label)
+			}
+			wg.labelsLock.Unlock()
+			wg.Done()
+		})
+	}, nil
+}
+
 // Done decrements the WaitGroup counter.
 func (wg *SafeWaitGroup) Done() {
+	wg.active.Add(-1)
 	wg.wg.Done()
 }
 
@@ -55,3 +105,59 @@ func (wg *SafeWaitGroup) Wait() {
 	wg.mu.Unlock()
 	wg.wg.Wait()
 }
+
+// WaitWithContext blocks until the WaitGroup counter is zero or ctx is done.
+// If ctx is done first, it returns the number of members still active along
+// with the context error. Like Wait, it forbids further positive Adds.
+func (wg *SafeWaitGroup) WaitWithContext(ctx context.Context) (int, error) {
+	// forbid Adds before returning, even if ctx is already done
+	wg.mu.Lock()
+	wg.wait = true
+	wg.mu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		// Returns once the group drains, even if the caller gave up waiting.
+		wg.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return 0, nil
+	case <-ctx.Done():
+	}
+	select {
+	case <-drained:
+		// the group drained concurrently with ctx
+		return 0, nil
+	default:
+	}
+	active := int(wg.active.Load())
+	if wg.OnDrainTimeout != nil {
+		wg.OnDrainTimeout(wg.holders(active))
+	}
+	return active, ctx.Err()
+}
+
+// WaitTimeout is like WaitWithContext, giving up after timeout.
+func (wg *SafeWaitGroup) WaitTimeout(timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return wg.WaitWithContext(ctx)
+}
+
+// holders returns the number of active members per label, out of active
+// members in total.
+func (wg *SafeWaitGroup) holders(active int) map[string]int {
+	wg.labelsLock.Lock()
+	defer wg.labelsLock.Unlock()
+	holders := make(map[string]int, len(wg.labels)+1)
+	for label, n := range wg.labels {
+		holders[label] += n
+		active -= n
+	}
+	if active > 0 {
+		holders[""] += active
+	}
+	return holders
+}
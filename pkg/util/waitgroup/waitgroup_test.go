@@ -18,7 +18,10 @@ limitations under the License.
 package waitgroup
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestWaitGroup(t *testing.T) {
@@ -58,3 +61,89 @@ func TestWaitGroupAddFail(t *testing.T) {
 		t.Errorf("Should return error when add positive after Wait")
 	}
 }
+
+func TestWaitGroupWaitTimeout(t *testing.T) {
+	wg := &SafeWaitGroup{}
+	wg.Add(3)
+	wg.Done()
+
+	active, err := wg.WaitTimeout(10 * time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if active != 2 {
+		t.Errorf("Expected 2 active members, got %d", active)
+	}
+	if err := wg.Add(1); err == nil {
+		t.Errorf("Should return error when add positive after WaitTimeout")
+	}
+
+	wg.Done()
+	wg.Done()
+	if active, err := wg.WaitTimeout(time.Minute); err != nil || active != 0 {
+		t.Errorf("Expected the group to drain, got %d, %v", active, err)
+	}
+}
+
+func TestWaitGroupWaitWithContext(t *testing.T) {
+	wg := &SafeWaitGroup{}
+	wg.Add(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		wg.Done()
+	}()
+	if active, err := wg.WaitWithContext(context.Background()); err != nil || active != 0 {
+		t.Errorf("Expected the group to drain, got %d, %v", active, err)
+	}
+
+	wg = &SafeWaitGroup{}
+	wg.Add(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if active, err := wg.WaitWithContext(ctx); err != context.Canceled || active != 1 {
+		t.Errorf("Expected 1, context.Canceled, got %d, %v", active, err)
+	}
+	// Adds are forbidden as soon as WaitWithContext returns
+	if err := wg.Add(1); err == nil {
+		t.Errorf("Should return error when add positive after WaitWithContext")
+	}
+	if _, err := wg.AddWithLabel("late"); err == nil {
+		t.Errorf("Should return error when adding a label after WaitWithContext")
+	}
+}
+
+func TestWaitGroupDrainTimeoutHolders(t *testing.T) {
+	var holders map[string]int
+	wg := &SafeWaitGroup{
+		OnDrainTimeout: func(h map[string]int) {
+			holders = h
+		},
+	}
+	wg.Add(1)
+	doneWatch, err := wg.AddWithLabel("watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wg.AddWithLabel("watch"); err != nil {
+		t.Fatal(err)
+	}
+	doneExec, err := wg.AddWithLabel("exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doneExec()
+	// marking a member done twice has no effect
+	doneExec()
+	doneWatch()
+
+	if active, _ := wg.WaitTimeout(10 * time.Millisecond); active != 2 {
+		t.Errorf("Expected 2 active members, got %d", active)
+	}
+	want := map[string]int{"": 1, "watch": 1}
+	if !reflect.DeepEqual(want, holders) {
+		t.Errorf("Expected holders %v, got %v", want, holders)
+	}
+	if _, err := wg.AddWithLabel("late"); err == nil {
+		t.Errorf("Should return error when adding a labeled member after Wait")
+	}
+}
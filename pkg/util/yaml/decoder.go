@@ -20,8 +20,12 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -103,6 +107,8 @@ func ToJSON(data []byte) ([]byte, error) {
 // body to JSON, then unmarshals the JSON.
 type YAMLToJSONDecoder struct {
 	reader Reader
+	// document is the index of the next document in the stream.
+	document int
 }
 
 // NewYAMLToJSONDecoder decodes YAML documents from the provided
@@ -122,18 +128,51 @@ func NewYAMLToJSONDecoder(r io.Reader) *YAMLToJSONDecoder {
 func (d *YAMLToJSONDecoder) Decode(into interface{}) error {
 	bytes, err := d.reader.Read()
 	if err != nil && err != io.EOF {
+		var syntaxErr YAMLSyntaxError
+		if errors.As(err, &syntaxErr) {
+			syntaxErr.Position.Document = d.document
+			return syntaxErr
+		}
 		return err
 	}
 
 	if len(bytes) != 0 {
+		document := d.document
+		d.document++
 		err := yaml.Unmarshal(bytes, into)
 		if err != nil {
-			return YAMLSyntaxError{err}
+			position := Position{Document: document}
+			if line, column := yamlErrorLine(err); line > 0 {
+				position.Line, position.Column = line, column
+				if r, ok := d.reader.(*YAMLReader); ok {
+					// the error line is relative to the start of the document
+					position.Line += r.documentLine - 1
+				}
+			}
+			return YAMLSyntaxError{err: err, Position: position}
 		}
 	}
 	return err
 }
 
+// yamlErrorLineRE extracts the line, and if present the column, reported by a
+// YAML parser error.
+var yamlErrorLineRE = regexp.MustCompile(`yaml: line (\d+)(?:, column (\d+))?:`)
+
+// yamlErrorLine returns the 1-based line and column reported by a YAML parser
+// error, or zero if they are not known.
+func yamlErrorLine(err error) (line, column int) {
+	m := yamlErrorLineRE.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, 0
+	}
+	line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		column, _ = strconv.Atoi(m[2])
+	}
+	return line, column
+}
+
 // YAMLDecoder reads chunks of objects and returns ErrShortBuffer if
 // the data is not sufficient.
 type YAMLDecoder struct {
@@ -241,25 +280,66 @@ type YAMLOrJSONDecoder struct {
 	bufferSize int
 
 	decoder decoder
+	// lines tracks the line offsets of JSON streams.
+	lines *LineTracker
+	// document is the index of the next JSON document in the stream.
+	document int
+}
+
+// Position is the location of a decode error in a stream of documents.
+type Position struct {
+	// Document is the 0-based index of the document in the stream.
+	Document int
+	// Line is the 1-based line in the stream, or 0 if unknown.
+	Line int
+	// Column is the 1-based column in the line, or 0 if unknown.
+	Column int
 }
 
 type JSONSyntaxError struct {
 	Offset int64
 	Err    error
+	// Position is the location of the error in the stream.
+	Position Position
 }
 
 func (e JSONSyntaxError) Error() string {
 	return fmt.Sprintf("json: offset %d: %s", e.Offset, e.Err.Error())
 }
 
+func (e JSONSyntaxError) Unwrap() error {
+	return e.Err
+}
+
 type YAMLSyntaxError struct {
 	err error
+	// Position is the location of the error in the stream. Errors that are not
+	// reported by the YAML parser, such as type mismatches, have no line.
+	Position Position
 }
 
 func (e YAMLSyntaxError) Error() string {
 	return e.err.Error()
 }
 
+func (e YAMLSyntaxError) Unwrap() error {
+	return e.err
+}
+
+// ErrorPosition returns the position of a JSONSyntaxError or YAMLSyntaxError
+// in err's chain, as returned by the decoders of this package.
+func ErrorPosition(err error) (Position, bool) {
+	var jsonErr JSONSyntaxError
+	if errors.As(err, &jsonErr) {
+		return jsonErr.Position, true
+	}
+	var yamlErr YAMLSyntaxError
+	if errors.As(err, &yamlErr) {
+		return yamlErr.Position, true
+	}
+	return Position{}, false
+}
+
 // NewYAMLOrJSONDecoder returns a decoder that will process YAML documents
 // or JSON documents from the given reader as a stream. bufferSize determines
 // how far into the stream the decoder will look to figure out whether this
@@ -277,27 +357,96 @@ func (d *YAMLOrJSONDecoder) Decode(into interface{}) error {
 	if d.decoder == nil {
 		buffer, _, isJSON := GuessJSONStream(d.r, d.bufferSize)
 		if isJSON {
-			d.decoder = json.NewDecoder(buffer)
+			d.lines = NewLineTracker(buffer)
+			d.decoder = json.NewDecoder(d.lines)
 		} else {
 			d.decoder = NewYAMLToJSONDecoder(buffer)
 		}
 	}
 	err := d.decoder.Decode(into)
 	if syntax, ok := err.(*json.SyntaxError); ok {
+		// the offset counts the bytes read up to and including the invalid one
+		line, column := d.lines.Position(syntax.Offset - 1)
 		return JSONSyntaxError{
-			Offset: syntax.Offset,
-			Err:    syntax,
+			Offset:   syntax.Offset,
+			Err:      syntax,
+			Position: Position{Document: d.document, Line: line, Column: column},
 		}
 	}
+	if d.lines != nil && err == nil {
+		d.document++
+		// errors are only reported in the documents after this one
+		d.lines.Forget(d.decoder.(*json.Decoder).InputOffset())
+	}
 	return err
 }
 
+// LineTracker is a reader recording the offsets of the line breaks read from
+// the reader it wraps, so that stream offsets can be converted to lines and
+// columns, e.g. to locate the documents and errors of a JSON stream.
+type LineTracker struct {
+	r    io.Reader
+	read int64
+	// breaks holds the offsets of the line breaks read since the offset passed
+	// to Forget, so that it does not grow with the stream.
+	breaks []int64
+	// forgotten is the number of line breaks dropped from breaks.
+	forgotten int
+	// lastForgotten is the offset of the last line break dropped, or -1.
+	lastForgotten int64
+}
+
+// NewLineTracker returns a LineTracker reading r.
+func NewLineTracker(r io.Reader) *LineTracker {
+	return &LineTracker{r: r, lastForgotten: -1}
+}
+
+func (t *LineTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			t.breaks = append(t.breaks, t.read+int64(i))
+		}
+	}
+	t.read += int64(n)
+	return n, err
+}
+
+// Forget drops the line breaks before offset, typically the end of the last
+// document decoded, so that the tracker does not grow with the stream.
+// Positions of offsets before it are no longer accurate.
+func (t *LineTracker) Forget(offset int64) {
+	i := sort.Search(len(t.breaks), func(i int) bool { return t.breaks[i] >= offset })
+	if i == 0 {
+		return
+	}
+	t.lastForgotten = t.breaks[i-1]
+	t.forgotten += i
+	t.breaks = append(t.breaks[:0], t.breaks[i:]...)
+}
+
+// Position returns the 1-based line and column of the byte at offset.
+func (t *LineTracker) Position(offset int64) (line, column int) {
+	// the number of line breaks before offset
+	i := sort.Search(len(t.breaks), func(i int) bool { return t.breaks[i] >= offset })
+	lineStart := t.lastForgotten + 1
+	if i > 0 {
+		lineStart = t.breaks[i-1] + 1
+	}
+	return t.forgotten + i + 1, int(offset-lineStart) + 1
+}
+
 type Reader interface {
 	Read() ([]byte, error)
 }
 
 type YAMLReader struct {
 	reader Reader
+	// line is the number of lines read so far.
+	line int
+	// documentLine is the 1-based line at which the last document returned
+	// by Read starts.
+	documentLine int
 }
 
 func NewYAMLReader(r *bufio.Reader) *YAMLReader {
@@ -306,6 +455,12 @@ func NewYAMLReader(r *bufio.Reader) *YAMLReader {
 	}
 }
 
+// DocumentLine returns the 1-based line of the stream at which the document
+// last returned by Read starts.
+func (r *YAMLReader) DocumentLine() int {
+	return r.documentLine
+}
+
 // Read returns a full YAML document.
 func (r *YAMLReader) Read() ([]byte, error) {
 	var buffer bytes.Buffer
@@ -314,6 +469,7 @@ func (r *YAMLReader) Read() ([]byte, error) {
 		if err != nil && err != io.EOF {
 			return nil, err
 		}
+		r.line++
 
 		sep := len([]byte(separator))
 		if i := bytes.Index(line, []byte(separator)); i == 0 {
@@ -323,7 +479,8 @@ func (r *YAMLReader) Read() ([]byte, error) {
 			// We only allow comments and spaces following the yaml doc separator, otherwise we'll return an error
 			if len(trimmed) > 0 && string(trimmed[0]) != "#" {
 				return nil, YAMLSyntaxError{
-					err: fmt.Errorf("invalid Yaml document separator: %s", trimmed),
+					err:      fmt.Errorf("invalid Yaml document separator: %s", trimmed),
+					Position: Position{Line: r.line, Column: 1},
 				}
 			}
 			if buffer.Len() != 0 {
@@ -340,6 +497,9 @@ func (r *YAMLReader) Read() ([]byte, error) {
 			}
 			return nil, err
 		}
+		if buffer.Len() == 0 {
+			r.documentLine = r.line
+		}
 		buffer.Write(line)
 	}
 }
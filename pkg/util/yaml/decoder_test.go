@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -509,3 +510,115 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatalf(`Expected number to be float64 but got "%T"`, otherType[123])
 	}
 }
+
+func TestDecodeErrorPosition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		docs     int
+		expected Position
+	}{
+		{
+			name: "yaml error in second document",
+			input: `a: 1
+---
+b: 2
+c: [1, 2
+d: 3
+`,
+			docs:     1,
+			expected: Position{Document: 1, Line: 4},
+		},
+		{
+			name: "yaml error after leading separator and comments",
+			input: `---
+# comment
+a: b: c
+`,
+			expected: Position{Document: 0, Line: 3},
+		},
+		{
+			name: "invalid yaml separator",
+			input: `a: 1
+---
+b: 2
+--- c
+`,
+			docs:     1,
+			expected: Position{Document: 1, Line: 4, Column: 1},
+		},
+		{
+			name: "json error in second document",
+			input: `{"a": 1}
+{
+  "b": 2
+  "c": 3
+}`,
+			docs:     1,
+			expected: Position{Document: 1, Line: 4, Column: 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewYAMLOrJSONDecoder(strings.NewReader(tc.input), 100)
+			for i := 0; i < tc.docs; i++ {
+				obj := generic{}
+				if err := d.Decode(&obj); err != nil {
+					t.Fatalf("document %d: unexpected error: %v", i, err)
+				}
+			}
+			obj := generic{}
+			err := d.Decode(&obj)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			position, ok := ErrorPosition(err)
+			if !ok {
+				t.Fatalf("expected a positioned error, got %T: %v", err, err)
+			}
+			if position != tc.expected {
+				t.Errorf("expected position %+v, got %+v (%v)", tc.expected, position, err)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorPositionAfterManyDocuments(t *testing.T) {
+	const docs = 10000
+	input := strings.Repeat("{\n  \"a\": 1\n}\n", docs) + "{\n  \"b\": 2\n  \"c\": 3\n}"
+	d := NewYAMLOrJSONDecoder(strings.NewReader(input), 100)
+	for i := 0; i < docs; i++ {
+		obj := generic{}
+		if err := d.Decode(&obj); err != nil {
+			t.Fatalf("document %d: unexpected error: %v", i, err)
+		}
+	}
+	obj := generic{}
+	position, ok := ErrorPosition(d.Decode(&obj))
+	if !ok {
+		t.Fatal("expected a positioned error")
+	}
+	if expected := (Position{Document: docs, Line: 3*docs + 3, Column: 3}); position != expected {
+		t.Errorf("expected position %+v, got %+v", expected, position)
+	}
+	if len(d.lines.breaks) > 100 {
+		t.Errorf("expected the line breaks of decoded documents to be dropped, %d are kept", len(d.lines.breaks))
+	}
+}
+
+func TestYAMLErrorLine(t *testing.T) {
+	testCases := []struct {
+		err          string
+		line, column int
+	}{
+		{err: "error converting YAML to JSON: yaml: line 3: mapping values are not allowed in this context", line: 3},
+		{err: "yaml: line 10, column 4: did not find expected key", line: 10, column: 4},
+		{err: "error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type int"},
+	}
+	for _, tc := range testCases {
+		line, column := yamlErrorLine(errors.New(tc.err))
+		if line != tc.line || column != tc.column {
+			t.Errorf("%q: expected %d:%d, got %d:%d", tc.err, tc.line, tc.column, line, column)
+		}
+	}
+}
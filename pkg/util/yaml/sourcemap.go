@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"strconv"
+	"strings"
+
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// SourcePosition is a position in a YAML document.
+type SourcePosition struct {
+	// Line is the 1-based line.
+	Line int
+	// Column is the 1-based column.
+	Column int
+}
+
+// SourceMap maps JSON pointers (RFC 6901), such as "/spec/containers/0/image",
+// to the position in the YAML document the JSON value was converted from. The
+// position of an object member is the position of its key, and the root of the
+// document has the empty pointer "".
+type SourceMap map[string]SourcePosition
+
+// Lookup returns the position of the value at pointer. If the value does not
+// exist in the document, e.g. because an error refers to a missing field, the
+// position of its closest existing ancestor is returned. It returns false if
+// no ancestor exists either.
+func (m SourceMap) Lookup(pointer string) (SourcePosition, bool) {
+	for {
+		if position, ok := m[pointer]; ok {
+			return position, true
+		}
+		i := strings.LastIndexByte(pointer, '/')
+		if i < 0 {
+			return SourcePosition{}, false
+		}
+		pointer = pointer[:i]
+	}
+}
+
+// ToJSONWithSourceMap converts a single YAML document into a JSON document like
+// ToJSON, and also returns a SourceMap from the paths of the JSON document to
+// lines of the YAML document.
+//
+// Map keys are recorded as written in the YAML document. Values reached through
+// aliases are attributed to the alias, and members added by merge keys ("<<")
+// are not recorded, so Lookup returns the position of their parent object.
+func ToJSONWithSourceMap(data []byte) ([]byte, SourceMap, error) {
+	jsonData, err := ToJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, nil, err
+	}
+	sourceMap := SourceMap{}
+	if root.Kind == yamlv3.DocumentNode && len(root.Content) > 0 {
+		addToSourceMap(sourceMap, "", root.Content[0], root.Content[0])
+	}
+	return jsonData, sourceMap, nil
+}
+
+// addToSourceMap records the position of node at pointer, and the positions
+// of its children. at is the node whose position is recorded for pointer,
+// which is the key of object members.
+func addToSourceMap(sourceMap SourceMap, pointer string, at, node *yamlv3.Node) {
+	sourceMap[pointer] = SourcePosition{Line: at.Line, Column: at.Column}
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != yamlv3.ScalarNode || key.Tag == "!!merge" {
+				continue
+			}
+			addToSourceMap(sourceMap, pointer+"/"+escapeJSONPointer(key.Value), key, value)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			addToSourceMap(sourceMap, pointer+"/"+strconv.Itoa(i), item, item)
+		}
+	}
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapeJSONPointer escapes a reference token of a JSON pointer.
+func escapeJSONPointer(token string) string {
+	return jsonPointerEscaper.Replace(token)
+}
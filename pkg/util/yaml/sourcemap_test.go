@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"testing"
+)
+
+func TestToJSONWithSourceMap(t *testing.T) {
+	data := []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+  annotations:
+    a/b: c
+spec:
+  containers:
+  - name: app
+    image: nginx
+  -   name: sidecar
+      args: ["--x", "--y"]
+`)
+	jsonData, sourceMap, err := ToJSONWithSourceMap(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedJSON, err := ToJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonData) != string(expectedJSON) {
+		t.Errorf("expected JSON %s, got %s", expectedJSON, jsonData)
+	}
+
+	for pointer, expected := range map[string]SourcePosition{
+		"":                              {Line: 1, Column: 1},
+		"/kind":                         {Line: 2, Column: 1},
+		"/metadata/name":                {Line: 4, Column: 3},
+		"/metadata/annotations/a~1b":    {Line: 6, Column: 5},
+		"/spec/containers/0":            {Line: 9, Column: 5},
+		"/spec/containers/0/image":      {Line: 10, Column: 5},
+		"/spec/containers/1":            {Line: 11, Column: 7},
+		"/spec/containers/1/args/1":     {Line: 12, Column: 21},
+		"/spec/containers/1/args/1/foo": {Line: 12, Column: 21},
+		"/spec/missing/field":           {Line: 7, Column: 1},
+	} {
+		position, ok := sourceMap.Lookup(pointer)
+		if !ok {
+			t.Errorf("%q: expected a position", pointer)
+			continue
+		}
+		if position != expected {
+			t.Errorf("%q: expected %+v, got %+v", pointer, expected, position)
+		}
+	}
+}
+
+func TestToJSONWithSourceMapJSON(t *testing.T) {
+	data := []byte(`{
+  "kind": "Pod",
+  "spec": {"nodeName": "a"}
+}`)
+	jsonData, sourceMap, err := ToJSONWithSourceMap(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(jsonData) != string(data) {
+		t.Errorf("expected JSON to be returned unchanged, got %s", jsonData)
+	}
+	if position, _ := sourceMap.Lookup("/spec/nodeName"); position.Line != 3 {
+		t.Errorf("expected line 3, got %+v", position)
+	}
+}
+
+func TestToJSONWithSourceMapErrors(t *testing.T) {
+	if _, _, err := ToJSONWithSourceMap([]byte("a: b: c")); err == nil {
+		t.Errorf("expected an error")
+	}
+	_, sourceMap, err := ToJSONWithSourceMap(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := sourceMap.Lookup("/a"); ok {
+		t.Errorf("expected no position in an empty document")
+	}
+}
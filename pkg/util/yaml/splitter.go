@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrDocumentTooLarge is matched by errors.Is for the errors returned by a
+	// DocumentSplitter when a document exceeds the maximum document size.
+	ErrDocumentTooLarge = errors.New("YAML document exceeds the maximum size")
+	// ErrStreamTooLarge is matched by errors.Is for the errors returned by a
+	// DocumentSplitter when the stream exceeds the maximum total size.
+	ErrStreamTooLarge = errors.New("YAML stream exceeds the maximum size")
+)
+
+// Document is a single document of a multi-document YAML stream.
+type Document struct {
+	// Data is the content of the document, without document separators.
+	Data []byte
+	// Index is the 0-based index of the document in the stream.
+	Index int
+	// Offset is the byte offset of the start of Data in the stream.
+	Offset int64
+	// Line is the 1-based line of the start of Data in the stream.
+	Line int
+}
+
+// DocumentSplitter iterates over the documents of a multi-document YAML stream,
+// separated by "---" lines as for YAMLReader. It buffers a single document at
+// a time, and keeps track of the position of each document in the stream.
+type DocumentSplitter struct {
+	r               *bufio.Reader
+	maxDocumentSize int64
+	maxTotalSize    int64
+
+	// offset and line are the number of bytes and lines read so far.
+	offset int64
+	line   int
+	// index is the index of the next document.
+	index int
+	// err is returned by all calls to Next once set.
+	err error
+}
+
+// NewDocumentSplitter returns a DocumentSplitter reading from r. Documents
+// larger than maxDocumentSize bytes, and streams larger than maxTotalSize
+// bytes, are rejected with an error. Zero means no limit.
+func NewDocumentSplitter(r io.Reader, maxDocumentSize, maxTotalSize int64) *DocumentSplitter {
+	return &DocumentSplitter{
+		r:               bufio.NewReader(r),
+		maxDocumentSize: maxDocumentSize,
+		maxTotalSize:    maxTotalSize,
+	}
+}
+
+// Next returns the next document in the stream, or io.EOF once there are no
+// more documents. Documents consisting of separators only are skipped. After
+// an error, all subsequent calls return the same error.
+func (s *DocumentSplitter) Next() (*Document, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	doc, err := s.next()
+	if err != nil {
+		s.err = err
+	}
+	return doc, err
+}
+
+func (s *DocumentSplitter) next() (*Document, error) {
+	var doc *Document
+	for {
+		lineOffset, lineNumber := s.offset, s.line+1
+		line, err := s.readLine()
+		if err != nil && err != io.EOF {
+			if errors.Is(err, ErrDocumentTooLarge) {
+				return nil, s.documentTooLarge(doc, lineNumber)
+			}
+			return nil, err
+		}
+
+		if len(line) > 0 {
+			isSeparator, sepErr := s.checkSeparator(line, lineNumber)
+			if sepErr != nil {
+				return nil, sepErr
+			}
+			if isSeparator {
+				if doc != nil {
+					return doc, nil
+				}
+				if err == io.EOF {
+					return nil, io.EOF
+				}
+				continue
+			}
+
+			if doc == nil {
+				doc = &Document{Index: s.index, Offset: lineOffset, Line: lineNumber}
+				s.index++
+			}
+			if s.maxDocumentSize > 0 && int64(len(doc.Data)+len(line)) > s.maxDocumentSize {
+				return nil, s.documentTooLarge(doc, lineNumber)
+			}
+			doc.Data = append(doc.Data, line...)
+		}
+
+		if err == io.EOF {
+			if doc != nil {
+				return doc, nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+// readLine reads the next line, including its line break, enforcing the size
+// limits.
+func (s *DocumentSplitter) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		if s.maxTotalSize > 0 && s.offset+int64(len(chunk)) > s.maxTotalSize {
+			return nil, fmt.Errorf("%w of %d bytes", ErrStreamTooLarge, s.maxTotalSize)
+		}
+		// a line longer than a document can never be part of one
+		if s.maxDocumentSize > 0 && int64(len(line)+len(chunk)) > s.maxDocumentSize {
+			return nil, ErrDocumentTooLarge
+		}
+		s.offset += int64(len(chunk))
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			s.line++
+		}
+		return line, err
+	}
+}
+
+// checkSeparator returns true if line is a document separator. Like YAMLReader,
+// only comments and whitespace may follow the separator.
+func (s *DocumentSplitter) checkSeparator(line []byte, lineNumber int) (bool, error) {
+	if !bytes.HasPrefix(line, []byte(separator)) {
+		return false, nil
+	}
+	trimmed := bytes.TrimSpace(line[len(separator):])
+	if len(trimmed) > 0 && trimmed[0] != '#' {
+		return false, YAMLSyntaxError{
+			err:      fmt.Errorf("invalid Yaml document separator: %s", trimmed),
+			Position: Position{Document: s.index, Line: lineNumber, Column: 1},
+		}
+	}
+	return true, nil
+}
+
+func (s *DocumentSplitter) documentTooLarge(doc *Document, lineNumber int) error {
+	index, line := s.index, lineNumber
+	if doc != nil {
+		index, line = doc.Index, doc.Line
+	}
+	return fmt.Errorf("document %d at line %d: %w of %d bytes", index, line, ErrDocumentTooLarge, s.maxDocumentSize)
+}
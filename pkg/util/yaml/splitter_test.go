@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func splitAll(s *DocumentSplitter) ([]Document, error) {
+	var docs []Document
+	for {
+		doc, err := s.Next()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return docs, err
+		}
+		docs = append(docs, *doc)
+	}
+}
+
+func TestDocumentSplitter(t *testing.T) {
+	input := `---
+a: 1
+b: 2
+--- # comment
+---
+c: 3
+---
+d: 4`
+	docs, err := splitAll(NewDocumentSplitter(strings.NewReader(input), 0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Document{
+		{Data: []byte("a: 1\nb: 2\n"), Index: 0, Offset: 4, Line: 2},
+		{Data: []byte("c: 3\n"), Index: 1, Offset: 32, Line: 6},
+		{Data: []byte("d: 4"), Index: 2, Offset: 41, Line: 8},
+	}
+	if !reflect.DeepEqual(expected, docs) {
+		t.Errorf("expected %#v, got %#v", expected, docs)
+	}
+	for _, doc := range docs {
+		if got := input[doc.Offset : doc.Offset+int64(len(doc.Data))]; got != string(doc.Data) {
+			t.Errorf("document %d: offset does not point at its data: %q", doc.Index, got)
+		}
+	}
+}
+
+func TestDocumentSplitterMatchesYAMLReader(t *testing.T) {
+	input := "a: 1\n---\n\n---\nb: 2\n---\n"
+	docs, err := splitAll(NewDocumentSplitter(strings.NewReader(input), 0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := NewYAMLReader(bufio.NewReader(strings.NewReader(input)))
+	for i := 0; ; i++ {
+		data, err := r.Read()
+		if err == io.EOF {
+			if i != len(docs) {
+				t.Errorf("expected %d documents, got %d", i, len(docs))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i >= len(docs) || string(docs[i].Data) != string(data) {
+			t.Fatalf("document %d: expected %q, got %#v", i, data, docs)
+		}
+	}
+}
+
+func TestDocumentSplitterLimits(t *testing.T) {
+	input := "a: 1\n---\nb: 22222222\n---\nc: 3\n"
+
+	s := NewDocumentSplitter(strings.NewReader(input), 8, 0)
+	doc, err := s.Next()
+	if err != nil || string(doc.Data) != "a: 1\n" {
+		t.Fatalf("unexpected: %v %v", doc, err)
+	}
+	_, err = s.Next()
+	if !errors.Is(err, ErrDocumentTooLarge) {
+		t.Fatalf("expected ErrDocumentTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "document 1 at line 3") {
+		t.Errorf("expected the error to locate the document, got %v", err)
+	}
+	// errors are sticky
+	if _, err := s.Next(); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Fatalf("expected ErrDocumentTooLarge, got %v", err)
+	}
+
+	s = NewDocumentSplitter(strings.NewReader(input), 0, 20)
+	if _, err := s.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Next(); !errors.Is(err, ErrStreamTooLarge) {
+		t.Fatalf("expected ErrStreamTooLarge, got %v", err)
+	}
+
+	// a single long line is rejected before it is buffered entirely
+	long := strings.Repeat("x", 1<<20)
+	s = NewDocumentSplitter(strings.NewReader(long), 1024, 0)
+	if _, err := s.Next(); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Fatalf("expected ErrDocumentTooLarge, got %v", err)
+	}
+}
+
+func TestDocumentSplitterInvalidSeparator(t *testing.T) {
+	s := NewDocumentSplitter(strings.NewReader("a: 1\n--- b\n"), 0, 0)
+	_, err := s.Next()
+	position, ok := ErrorPosition(err)
+	if !ok {
+		t.Fatalf("expected a YAMLSyntaxError, got %v", err)
+	}
+	if expected := (Position{Document: 1, Line: 2, Column: 1}); position != expected {
+		t.Errorf("expected position %+v, got %+v", expected, position)
+	}
+}
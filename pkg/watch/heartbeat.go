@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Heartbeat tracks the liveness of a watch: it records when the last event,
+// including bookmarks and errors, was received, and calls an optional callback
+// for every event, so that components can report the health of long-running
+// watch loops, e.g. in their healthz checks, without instrumenting the loops
+// consuming the events.
+type Heartbeat struct {
+	Interface
+
+	clock clock.PassiveClock
+	alive func()
+
+	lock    sync.RWMutex
+	started time.Time
+	last    time.Time
+}
+
+var _ Interface = &Heartbeat{}
+
+// NewHeartbeat wraps w and tracks the events sent across it. alive, if not
+// nil, is called whenever an event is received, before it is passed on.
+func NewHeartbeat(w Interface, alive func()) *Heartbeat {
+	return NewHeartbeatWithClock(w, alive, clock.RealClock{})
+}
+
+// NewHeartbeatWithClock is like NewHeartbeat, with the current time obtained
+// from clock.
+func NewHeartbeatWithClock(w Interface, alive func(), clock clock.PassiveClock) *Heartbeat {
+	h := &Heartbeat{
+		clock:   clock,
+		alive:   alive,
+		started: clock.Now(),
+	}
+	h.Interface = Filter(w, h.beat)
+	return h
+}
+
+// beat is a FilterFunc and records each received event.
+func (h *Heartbeat) beat(in Event) (Event, bool) {
+	h.lock.Lock()
+	h.last = h.clock.Now()
+	h.lock.Unlock()
+	if h.alive != nil {
+		h.alive()
+	}
+	return in, true
+}
+
+// LastEvent returns the time the last event was received, or the zero time if
+// no event was received yet.
+func (h *Heartbeat) LastEvent() time.Time {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.last
+}
+
+// SinceLastEvent returns the time elapsed since the last event was received,
+// or since the watch was wrapped if no event was received yet.
+func (h *Heartbeat) SinceLastEvent() time.Duration {
+	h.lock.RLock()
+	last := h.last
+	if last.IsZero() {
+		last = h.started
+	}
+	h.lock.RUnlock()
+	return h.clock.Since(last)
+}
+
+// Check returns an error if no event was received for longer than maxIdle,
+// and nil otherwise. A healthz check can wrap it in a func(*http.Request) error
+// passing the limit of the component. Watches without regular events should
+// request bookmarks to keep it passing.
+func (h *Heartbeat) Check(maxIdle time.Duration) error {
+	if idle := h.SinceLastEvent(); idle > maxIdle {
+		return fmt.Errorf("no watch event received for %v, exceeding %v", idle, maxIdle)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+
+	. "k8s.io/apimachinery/pkg/watch"
+)
+
+func TestHeartbeat(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := testingclock.NewFakePassiveClock(start)
+	source := NewFake()
+	var beats atomic.Int32
+	heartbeat := NewHeartbeatWithClock(source, func() { beats.Add(1) }, clock)
+
+	if !heartbeat.LastEvent().IsZero() {
+		t.Errorf("expected no last event, got %v", heartbeat.LastEvent())
+	}
+	clock.SetTime(start.Add(time.Minute))
+	if idle := heartbeat.SinceLastEvent(); idle != time.Minute {
+		t.Errorf("expected the watch to be idle since it started, got %v", idle)
+	}
+	if err := heartbeat.Check(30 * time.Second); err == nil {
+		t.Error("expected the check to fail")
+	}
+
+	for _, eventType := range []EventType{Added, Bookmark} {
+		go source.Action(eventType, testType("foo"))
+		if event := <-heartbeat.ResultChan(); event.Type != eventType {
+			t.Errorf("expected %s event to be passed on, got %v", eventType, event)
+		}
+	}
+	if beats.Load() != 2 {
+		t.Errorf("expected the callback to be called for both events, got %d calls", beats.Load())
+	}
+	if last := heartbeat.LastEvent(); !last.Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected last event time %v", last)
+	}
+	clock.SetTime(start.Add(time.Minute + 10*time.Second))
+	if idle := heartbeat.SinceLastEvent(); idle != 10*time.Second {
+		t.Errorf("expected the watch to be idle for 10s, got %v", idle)
+	}
+	if err := heartbeat.Check(30 * time.Second); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	heartbeat.Stop()
+	if _, ok := <-heartbeat.ResultChan(); ok {
+		t.Error("expected the result channel to be closed")
+	}
+}
+
+func TestHeartbeatWithoutCallback(t *testing.T) {
+	source := NewFake()
+	heartbeat := NewHeartbeat(source, nil)
+	go source.Add(testType("foo"))
+	<-heartbeat.ResultChan()
+	if heartbeat.LastEvent().IsZero() {
+		t.Error("expected the event to be recorded")
+	}
+}
@@ -19,6 +19,7 @@ package watch
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -60,6 +61,43 @@ type Broadcaster struct {
 	// "incoming" channel, which would allow one slow watcher to prevent all
 	// other watchers from getting new events.
 	fullChannelBehavior FullChannelBehavior
+
+	// observer and distributed are only accessed by the goroutine
+	// distributing events.
+	observer BroadcasterObserver
+	// distributed counts the events distributed while observed, to sample
+	// the queue lengths of the watchers.
+	distributed int
+}
+
+// queueLengthSamplingInterval is the number of events after which the queue
+// lengths of the watchers are reported to the observer again.
+const queueLengthSamplingInterval = 100
+
+// BroadcasterObserver observes how a Broadcaster distributes events, e.g. to
+// export metrics revealing slow watchers. Its methods are called by the
+// goroutine distributing events, so they must not block.
+type BroadcasterObserver interface {
+	// WatcherAdded is called when the watcher with the given id is added.
+	WatcherAdded(id int64)
+	// WatcherRemoved is called when the watcher with the given id is stopped,
+	// or removed by Shutdown.
+	WatcherRemoved(id int64)
+	// EventDistributed is called after an event was distributed to all
+	// watchers, with the number of events left in the incoming queue, the time
+	// it took to distribute the event, and the number of watchers the event
+	// was dropped for because their queue was full.
+	EventDistributed(incomingQueueLength int, latency time.Duration, dropped int)
+	// WatcherQueueFull is called when the queue of the watcher with the given
+	// id is full as an event is distributed, before the event is dropped for
+	// it or, with WaitIfChannelFull, before waiting for the watcher to
+	// receive it, which blocks the distribution of events to all watchers.
+	WatcherQueueFull(id int64)
+	// WatcherQueueLength is called with the number of events queued for the
+	// watcher with the given id after the first event was distributed, and
+	// then again every 100 events, since reporting them for every event would
+	// be costly with many watchers.
+	WatcherQueueLength(id int64, length int)
 }
 
 // NewBroadcaster creates a new Broadcaster. queueLength is the maximum number of events to queue per watcher.
@@ -134,6 +172,15 @@ func (m *Broadcaster) blockQueue(f func()) {
 	wg.Wait()
 }
 
+// SetObserver sets the observer notified about the distribution of events
+// from now on, replacing any previous observer. A nil observer disables
+// observation. It will block until the observer is actually set.
+func (m *Broadcaster) SetObserver(observer BroadcasterObserver) {
+	m.blockQueue(func() {
+		m.observer = observer
+	})
+}
+
 // Watch adds a new watcher to the list and returns an Interface for it.
 // Note: new watchers will only receive new events. They won't get an entire history
 // of previous events. It will block until the watcher is actually added to the
@@ -150,6 +197,9 @@ func (m *Broadcaster) Watch() (Interface, error) {
 			m:       m,
 		}
 		m.watchers[id] = w
+		if m.observer != nil {
+			m.observer.WatcherAdded(id)
+		}
 	})
 	if w == nil {
 		return nil, fmt.Errorf("broadcaster already stopped")
@@ -178,6 +228,9 @@ func (m *Broadcaster) WatchWithPrefix(queuedEvents []Event) (Interface, error) {
 			m:       m,
 		}
 		m.watchers[id] = w
+		if m.observer != nil {
+			m.observer.WatcherAdded(id)
+		}
 		for _, e := range queuedEvents {
 			w.result <- e
 		}
@@ -198,13 +251,19 @@ func (m *Broadcaster) stopWatching(id int64) {
 		}
 		delete(m.watchers, id)
 		close(w.result)
+		if m.observer != nil {
+			m.observer.WatcherRemoved(id)
+		}
 	})
 }
 
 // closeAll disconnects all watchers (presumably in response to a Shutdown call).
 func (m *Broadcaster) closeAll() {
-	for _, w := range m.watchers {
+	for id, w := range m.watchers {
 		close(w.result)
+		if m.observer != nil {
+			m.observer.WatcherRemoved(id)
+		}
 	}
 	// Delete everything from the map, since presence/absence in the map is used
 	// by stopWatching to avoid double-closing the channel.
@@ -278,22 +337,51 @@ func (m *Broadcaster) loop() {
 
 // distribute sends event to all watchers. Blocking.
 func (m *Broadcaster) distribute(event Event) {
+	var start time.Time
+	if m.observer != nil {
+		start = time.Now()
+	}
+	dropped := 0
 	if m.fullChannelBehavior == DropIfChannelFull {
-		for _, w := range m.watchers {
+		for id, w := range m.watchers {
 			select {
 			case w.result <- event:
 			case <-w.stopped:
 			default: // Don't block if the event can't be queued.
+				dropped++
+				if m.observer != nil {
+					m.observer.WatcherQueueFull(id)
+				}
 			}
 		}
 	} else {
-		for _, w := range m.watchers {
+		for id, w := range m.watchers {
+			if m.observer != nil {
+				// report the watcher before blocking on it
+				select {
+				case w.result <- event:
+					continue
+				case <-w.stopped:
+					continue
+				default:
+					m.observer.WatcherQueueFull(id)
+				}
+			}
 			select {
 			case w.result <- event:
 			case <-w.stopped:
 			}
 		}
 	}
+	if m.observer != nil {
+		m.observer.EventDistributed(len(m.incoming), time.Since(start), dropped)
+		if m.distributed%queueLengthSamplingInterval == 0 {
+			for id, w := range m.watchers {
+				m.observer.WatcherQueueLength(id, len(w.result))
+			}
+		}
+		m.distributed++
+	}
 }
 
 // broadcasterWatcher handles a single watcher of a broadcaster
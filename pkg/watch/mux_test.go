@@ -17,6 +17,7 @@ limitations under the License.
 package watch
 
 import (
+	"context"
 	"reflect"
 	"sync"
 	"testing"
@@ -289,3 +290,104 @@ func TestBroadcasterShutdownRace(t *testing.T) {
 	})
 	m.distributing.Wait()
 }
+
+// recordingObserver records the notifications of a BroadcasterObserver.
+type recordingObserver struct {
+	lock         sync.Mutex
+	added        []int64
+	removed      []int64
+	distributed  int
+	dropped      int
+	full         []int64
+	queueLengths map[int64]int
+}
+
+func (o *recordingObserver) WatcherAdded(id int64) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.added = append(o.added, id)
+}
+
+func (o *recordingObserver) WatcherRemoved(id int64) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.removed = append(o.removed, id)
+}
+
+func (o *recordingObserver) EventDistributed(incomingQueueLength int, latency time.Duration, dropped int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.distributed++
+	o.dropped += dropped
+}
+
+func (o *recordingObserver) WatcherQueueFull(id int64) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.full = append(o.full, id)
+}
+
+func (o *recordingObserver) WatcherQueueLength(id int64, length int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.queueLengths[id] = length
+}
+
+func TestBroadcasterObserver(t *testing.T) {
+	observer := &recordingObserver{queueLengths: map[int64]int{}}
+	m := NewBroadcaster(1, DropIfChannelFull)
+	m.SetObserver(observer)
+
+	w1, err := m.Watch()
+	if err != nil {
+		t.Fatalf("Unable start event watcher: '%v' (will not retry!)", err)
+	}
+	w2, err := m.Watch()
+	if err != nil {
+		t.Fatalf("Unable start event watcher: '%v' (will not retry!)", err)
+	}
+
+	// w2 is drained, w1 is not, so w1 drops the second event.
+	m.Action(Added, &myType{"foo", "hello world 1"})
+	<-w2.ResultChan()
+	m.Action(Added, &myType{"bar", "hello world 2"})
+	<-w2.ResultChan()
+	w2.Stop()
+	m.Shutdown()
+	<-w1.ResultChan()
+
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	assert.Equal(t, []int64{0, 1}, observer.added)
+	assert.Equal(t, []int64{1, 0}, observer.removed)
+	assert.Equal(t, 2, observer.distributed)
+	assert.Equal(t, 1, observer.dropped)
+	assert.Equal(t, []int64{0}, observer.full)
+	// queue lengths are sampled at the first event
+	assert.Equal(t, 1, observer.queueLengths[0])
+}
+
+func TestBroadcasterObserverWaitIfChannelFull(t *testing.T) {
+	observer := &recordingObserver{queueLengths: map[int64]int{}}
+	m := NewBroadcaster(1, WaitIfChannelFull)
+	m.SetObserver(observer)
+
+	w, err := m.Watch()
+	if err != nil {
+		t.Fatalf("Unable start event watcher: '%v' (will not retry!)", err)
+	}
+	m.Action(Added, &myType{"foo", "hello world 1"})
+	m.Action(Added, &myType{"bar", "hello world 2"})
+
+	// the watcher is reported while the distribution waits for it
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		observer.lock.Lock()
+		defer observer.lock.Unlock()
+		return len(observer.full) == 1, nil
+	}); err != nil {
+		t.Fatalf("expected the full watcher to be reported: %v", err)
+	}
+	<-w.ResultChan()
+	<-w.ResultChan()
+	m.Shutdown()
+}
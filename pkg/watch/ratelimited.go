@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/utils/clock"
+)
+
+// OverflowPolicy defines what a RateLimitedWatcher does with the events
+// received while the rate limit is exceeded.
+type OverflowPolicy int
+
+const (
+	// DropOverflow drops the events exceeding the rate limit, except errors.
+	// Consumers must tolerate missing events, e.g. by relisting.
+	DropOverflow OverflowPolicy = iota
+	// CoalesceOverflow queues the events exceeding the rate limit, and
+	// replaces the queued Added or Modified event of an object by a later
+	// Modified event of the same object, so that consumers only see its latest
+	// state. Other events are delivered in order. The queue is bounded by the
+	// number of objects changed faster than the rate limit.
+	CoalesceOverflow
+)
+
+// RateLimitedWatcher delivers the events of a watch at a limited rate, to
+// protect consumers such as UI websockets from event storms during mass
+// updates. The rate is enforced by a token bucket, refilled at qps tokens per
+// second up to burst tokens, each delivered event taking one token.
+type RateLimitedWatcher struct {
+	incoming Interface
+	result   chan Event
+	limiter  *rate.Limiter
+	clock    clock.Clock
+	policy   OverflowPolicy
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+
+	dropped   atomic.Int64
+	coalesced atomic.Int64
+}
+
+var _ Interface = &RateLimitedWatcher{}
+
+// NewRateLimitedWatcher wraps w and delivers at most qps events per second,
+// with bursts of up to burst events, handling the exceeding events according
+// to policy.
+func NewRateLimitedWatcher(w Interface, qps float64, burst int, policy OverflowPolicy) *RateLimitedWatcher {
+	return NewRateLimitedWatcherWithClock(w, qps, burst, policy, clock.RealClock{})
+}
+
+// NewRateLimitedWatcherWithClock is like NewRateLimitedWatcher, with the time
+// obtained from clock.
+func NewRateLimitedWatcherWithClock(w Interface, qps float64, burst int, policy OverflowPolicy, clock clock.Clock) *RateLimitedWatcher {
+	rw := &RateLimitedWatcher{
+		incoming: w,
+		result:   make(chan Event),
+		limiter:  rate.NewLimiter(rate.Limit(qps), burst),
+		clock:    clock,
+		policy:   policy,
+		stopCh:   make(chan struct{}),
+	}
+	if policy == CoalesceOverflow {
+		go rw.coalesceLoop()
+	} else {
+		go rw.dropLoop()
+	}
+	return rw
+}
+
+// ResultChan returns a channel which will receive the rate-limited events.
+func (rw *RateLimitedWatcher) ResultChan() <-chan Event {
+	return rw.result
+}
+
+// Stop stops the upstream watch and discards the events not delivered yet.
+func (rw *RateLimitedWatcher) Stop() {
+	rw.stopOnce.Do(func() {
+		close(rw.stopCh)
+		rw.incoming.Stop()
+	})
+}
+
+// Dropped returns the number of events dropped with DropOverflow.
+func (rw *RateLimitedWatcher) Dropped() int64 {
+	return rw.dropped.Load()
+}
+
+// Coalesced returns the number of events replaced by later events with
+// CoalesceOverflow.
+func (rw *RateLimitedWatcher) Coalesced() int64 {
+	return rw.coalesced.Load()
+}
+
+// dropLoop delivers the incoming events for which a token is available, and
+// drops the others.
+func (rw *RateLimitedWatcher) dropLoop() {
+	defer close(rw.result)
+	for event := range rw.incoming.ResultChan() {
+		// Errors are always delivered, since they may explain why the
+		// watch ends.
+		if !rw.limiter.AllowN(rw.clock.Now(), 1) && event.Type != Error {
+			rw.dropped.Add(1)
+			continue
+		}
+		select {
+		case rw.result <- event:
+		case <-rw.stopCh:
+			return
+		}
+	}
+}
+
+// objectKey identifies the objects whose events can be coalesced.
+type objectKey struct {
+	namespace, name string
+}
+
+// namedObject is implemented by the objects whose events can be coalesced.
+type namedObject interface {
+	GetNamespace() string
+	GetName() string
+}
+
+// coalesceLoop queues the incoming events, and delivers them as tokens become
+// available.
+func (rw *RateLimitedWatcher) coalesceLoop() {
+	defer close(rw.result)
+
+	incoming := rw.incoming.ResultChan()
+	var queue []Event
+	// base is the sequence number of queue[0], and pending holds the sequence
+	// numbers of the queued events later Modified events can replace.
+	base := 0
+	pending := map[objectKey]int{}
+	var token bool
+	var wait <-chan time.Time
+
+	for {
+		var out chan Event
+		var next Event
+		if len(queue) == 0 {
+			if incoming == nil {
+				return
+			}
+		} else if !token && wait == nil {
+			now := rw.clock.Now()
+			if rw.limiter.AllowN(now, 1) {
+				token = true
+			} else {
+				reservation := rw.limiter.ReserveN(now, 1)
+				delay := reservation.DelayFrom(now)
+				reservation.CancelAt(now)
+				wait = rw.clock.After(delay)
+			}
+		}
+		if token {
+			out = rw.result
+			next = queue[0]
+		}
+
+		select {
+		case event, ok := <-incoming:
+			if !ok {
+				incoming = nil
+				continue
+			}
+			obj, named := event.Object.(namedObject)
+			if !named {
+				queue = append(queue, event)
+				continue
+			}
+			key := objectKey{namespace: obj.GetNamespace(), name: obj.GetName()}
+			if seq, ok := pending[key]; ok && event.Type == Modified {
+				queue[seq-base].Object = event.Object
+				rw.coalesced.Add(1)
+				continue
+			}
+			delete(pending, key)
+			if event.Type == Added || event.Type == Modified {
+				pending[key] = base + len(queue)
+			}
+			queue = append(queue, event)
+		case out <- next:
+			token = false
+			if obj, named := next.Object.(namedObject); named {
+				key := objectKey{namespace: obj.GetNamespace(), name: obj.GetName()}
+				if seq, ok := pending[key]; ok && seq == base {
+					delete(pending, key)
+				}
+			}
+			queue[0] = Event{}
+			queue = queue[1:]
+			base++
+		case <-wait:
+			wait = nil
+		case <-rw.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	testingclock "k8s.io/utils/clock/testing"
+
+	. "k8s.io/apimachinery/pkg/watch"
+)
+
+type namedTestType struct {
+	Name  string
+	Value int
+}
+
+func (obj *namedTestType) GetObjectKind() schema.ObjectKind { return schema.EmptyObjectKind }
+func (obj *namedTestType) DeepCopyObject() runtime.Object   { copied := *obj; return &copied }
+func (obj *namedTestType) GetNamespace() string             { return "" }
+func (obj *namedTestType) GetName() string                  { return obj.Name }
+
+func TestRateLimitedWatcherDrop(t *testing.T) {
+	clock := testingclock.NewFakeClock(time.Now())
+	source := NewFake()
+	w := NewRateLimitedWatcherWithClock(source, 1, 2, DropOverflow, clock)
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			source.Add(testType("foo"))
+		}
+		source.Error(testType("error"))
+	}()
+	for _, expected := range []EventType{Added, Added, Error} {
+		if event := <-w.ResultChan(); event.Type != expected {
+			t.Errorf("expected %s event, got %v", expected, event)
+		}
+	}
+	if w.Dropped() != 3 {
+		t.Errorf("expected 3 dropped events, got %d", w.Dropped())
+	}
+
+	clock.Step(time.Second)
+	go source.Modify(testType("foo"))
+	if event := <-w.ResultChan(); event.Type != Modified {
+		t.Errorf("expected the event to be delivered once a token is available, got %v", event)
+	}
+
+	w.Stop()
+	if _, ok := <-w.ResultChan(); ok {
+		t.Error("expected the result channel to be closed")
+	}
+}
+
+func TestRateLimitedWatcherCoalesce(t *testing.T) {
+	clock := testingclock.NewFakeClock(time.Now())
+	source := NewFake()
+	w := NewRateLimitedWatcherWithClock(source, 1, 1, CoalesceOverflow, clock)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		source.Add(&namedTestType{Name: "a", Value: 1})
+		source.Modify(&namedTestType{Name: "a", Value: 2})
+		source.Modify(&namedTestType{Name: "b", Value: 1})
+		source.Modify(&namedTestType{Name: "b", Value: 2})
+		source.Delete(&namedTestType{Name: "b", Value: 3})
+		source.Modify(&namedTestType{Name: "b", Value: 4})
+		source.Action(Bookmark, testType("bookmark"))
+		source.Modify(&namedTestType{Name: "a", Value: 3})
+		source.Stop()
+	}()
+	<-sent
+
+	expected := []Event{
+		{Type: Added, Object: &namedTestType{Name: "a", Value: 3}},
+		{Type: Modified, Object: &namedTestType{Name: "b", Value: 2}},
+		{Type: Deleted, Object: &namedTestType{Name: "b", Value: 3}},
+		{Type: Modified, Object: &namedTestType{Name: "b", Value: 4}},
+		{Type: Bookmark, Object: testType("bookmark")},
+	}
+	for i, e := range expected {
+		if i > 0 {
+			// Wait for the watcher to wait for the next token.
+			for !clock.HasWaiters() {
+				time.Sleep(time.Millisecond)
+			}
+			select {
+			case event := <-w.ResultChan():
+				t.Fatalf("expected no event before the next token, got %v", event)
+			default:
+			}
+			clock.Step(time.Second)
+		}
+		if event := <-w.ResultChan(); !reflect.DeepEqual(event, e) {
+			t.Errorf("expected event %v, got %v", e, event)
+		}
+	}
+	if _, ok := <-w.ResultChan(); ok {
+		t.Error("expected the result channel to be closed once the queue is drained")
+	}
+	if w.Coalesced() != 3 {
+		t.Errorf("expected 3 coalesced events, got %d", w.Coalesced())
+	}
+}
+
+func TestRateLimitedWatcherStop(t *testing.T) {
+	source := NewFake()
+	w := NewRateLimitedWatcher(source, 1, 1, CoalesceOverflow)
+	source.Add(testType("foo"))
+	source.Add(testType("bar"))
+	w.Stop()
+	w.Stop()
+	for range w.ResultChan() {
+	}
+	if !source.IsStopped() {
+		t.Error("expected the source to be stopped")
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// WatchFunc starts a watch from resourceVersion, e.g. by calling the Watch
+// method of a client with ListOptions{ResourceVersion: resourceVersion}. It
+// should stop starting the watch when ctx is canceled.
+type WatchFunc func(ctx context.Context, resourceVersion string) (Interface, error)
+
+// ResourceVersionFunc returns the resourceVersion of the object of an event,
+// e.g. using meta.Accessor.
+type ResourceVersionFunc func(obj runtime.Object) (string, error)
+
+// ResumableOptions configures a Resumable watcher.
+type ResumableOptions struct {
+	// Backoff is the backoff between the attempts to re-establish the watch
+	// which fail, or end without any event. It is reset by every delivered
+	// event. Defaults to DefaultResumableBackoff.
+	Backoff *wait.Backoff
+	// MaxRetries is the maximum number of consecutive attempts to re-establish
+	// the watch, after which the Resumable watcher ends. Zero means no limit.
+	MaxRetries int
+	// Retryable returns true if an error returned by the WatchFunc is
+	// transient, and the watch should be re-established. Defaults to retrying
+	// all errors.
+	Retryable func(err error) bool
+	// RetryableEvent returns true if an Error event is transient: such events
+	// are not delivered, and the watch is re-established. Other Error events
+	// are delivered and end the Resumable watcher, as should e.g. an expired
+	// resourceVersion (HTTP 410 Gone), after which the consumer must relist.
+	// Defaults to delivering all Error events.
+	RetryableEvent func(event Event) bool
+	// Clock is used to wait between attempts. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// DefaultResumableBackoff is the default backoff of Resumable watchers.
+var DefaultResumableBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      30 * time.Second,
+}
+
+// Resumable delivers the events of a watch which is transparently
+// re-established from the resourceVersion of the last delivered event when it
+// ends or fails transiently, so that consumers do not miss events across
+// reconnections. Its result channel is closed when it is stopped, or when the
+// watch cannot be re-established, see Err.
+type Resumable struct {
+	watchFunc       WatchFunc
+	resourceVersion ResourceVersionFunc
+	options         ResumableOptions
+	clock           clock.Clock
+
+	result chan Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock sync.Mutex
+	// lastResourceVersion is the resourceVersion of the last delivered event.
+	lastResourceVersion string
+	err                 error
+}
+
+var _ Interface = &Resumable{}
+
+// NewResumable starts a watch with watchFunc from resourceVersion, and
+// re-establishes it from the resourceVersion of the last delivered event,
+// obtained with resourceVersionFunc, whenever it ends.
+// resourceVersion must be a specific version: "" and "0" do not guarantee to
+// resume where the consumer is, and are rejected.
+func NewResumable(resourceVersion string, watchFunc WatchFunc, resourceVersionFunc ResourceVersionFunc, options ResumableOptions) (*Resumable, error) {
+	switch resourceVersion {
+	case "", "0":
+		return nil, fmt.Errorf("resumable watches must start from a specific resourceVersion, got %q", resourceVersion)
+	}
+	clk := options.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rw := &Resumable{
+		watchFunc:           watchFunc,
+		resourceVersion:     resourceVersionFunc,
+		options:             options,
+		clock:               clk,
+		result:              make(chan Event),
+		ctx:                 ctx,
+		cancel:              cancel,
+		done:                make(chan struct{}),
+		lastResourceVersion: resourceVersion,
+	}
+	go rw.loop()
+	return rw, nil
+}
+
+// ResultChan returns a channel which receives the events of the successive
+// watches.
+func (rw *Resumable) ResultChan() <-chan Event {
+	return rw.result
+}
+
+// Stop stops the current watch and does not re-establish it.
+func (rw *Resumable) Stop() {
+	rw.cancel()
+}
+
+// Done returns a channel which is closed once the Resumable watcher has ended
+// and its result channel is closed.
+func (rw *Resumable) Done() <-chan struct{} {
+	return rw.done
+}
+
+// ResourceVersion returns the resourceVersion of the last delivered event, or
+// the initial resourceVersion if no event was delivered. A new watch started
+// from it receives the events the consumer has not seen.
+func (rw *Resumable) ResourceVersion() string {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.lastResourceVersion
+}
+
+// Err returns the reason why the Resumable watcher ended without being
+// stopped: the last error of the WatchFunc, or the error of the
+// ResourceVersionFunc. It returns nil while the watcher is running, and when
+// it was stopped or ended with a delivered Error event.
+func (rw *Resumable) Err() error {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.err
+}
+
+func (rw *Resumable) setErr(err error) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.err = err
+}
+
+func (rw *Resumable) loop() {
+	defer close(rw.done)
+	defer close(rw.result)
+	defer rw.cancel()
+
+	initialBackoff := DefaultResumableBackoff
+	if rw.options.Backoff != nil {
+		initialBackoff = *rw.options.Backoff
+	}
+	backoff := initialBackoff
+	retries := 0
+	for {
+		resourceVersion := rw.ResourceVersion()
+		w, err := rw.watchFunc(rw.ctx, resourceVersion)
+		if rw.ctx.Err() != nil {
+			if w != nil {
+				w.Stop()
+			}
+			return
+		}
+		if err != nil {
+			if rw.options.Retryable != nil && !rw.options.Retryable(err) {
+				rw.setErr(err)
+				return
+			}
+			klog.V(4).Infof("Failed to watch from resourceVersion %s: %v", resourceVersion, err)
+		} else {
+			delivered, done := rw.consume(w)
+			if done {
+				return
+			}
+			if delivered {
+				retries = 0
+				backoff = initialBackoff
+				continue
+			}
+		}
+
+		retries++
+		if rw.options.MaxRetries > 0 && retries > rw.options.MaxRetries {
+			if err == nil {
+				err = fmt.Errorf("watch from resourceVersion %s ended without events %d times", resourceVersion, retries)
+			}
+			rw.setErr(err)
+			return
+		}
+		t := rw.clock.NewTimer(backoff.Step())
+		select {
+		case <-rw.ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// consume delivers the events of w until it ends, recording their
+// resourceVersion. It returns whether any event was delivered, and true if
+// the Resumable watcher must end.
+func (rw *Resumable) consume(w Interface) (delivered, done bool) {
+	defer w.Stop()
+	for {
+		select {
+		case <-rw.ctx.Done():
+			return delivered, true
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return delivered, false
+			}
+			var resourceVersion string
+			if event.Type == Error {
+				if rw.options.RetryableEvent != nil && rw.options.RetryableEvent(event) {
+					klog.V(4).Infof("Watch from resourceVersion %s failed with a transient error: %v", rw.ResourceVersion(), event.Object)
+					return delivered, false
+				}
+			} else {
+				var err error
+				if resourceVersion, err = rw.resourceVersion(event.Object); err != nil {
+					rw.setErr(fmt.Errorf("unable to get the resourceVersion of a %s event: %w", event.Type, err))
+					return delivered, true
+				}
+			}
+			// The resourceVersion is recorded before the event is sent, so that
+			// it is up to date once the consumer receives the event, and
+			// restored if the event is not delivered.
+			previous := rw.recordResourceVersion(resourceVersion)
+			select {
+			case rw.result <- event:
+			case <-rw.ctx.Done():
+				rw.recordResourceVersion(previous)
+				return delivered, true
+			}
+			if event.Type == Error {
+				return true, true
+			}
+			delivered = true
+		}
+	}
+}
+
+// recordResourceVersion records resourceVersion, if not empty, as the
+// resourceVersion of the last delivered event, and returns the previous one.
+func (rw *Resumable) recordResourceVersion(resourceVersion string) string {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	previous := rw.lastResourceVersion
+	if len(resourceVersion) != 0 {
+		rw.lastResourceVersion = resourceVersion
+	}
+	return previous
+}
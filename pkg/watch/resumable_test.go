@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	. "k8s.io/apimachinery/pkg/watch"
+)
+
+// testResourceVersion uses the value of testType objects as resourceVersion.
+func testResourceVersion(obj runtime.Object) (string, error) {
+	s, ok := obj.(testType)
+	if !ok {
+		return "", fmt.Errorf("unexpected object %T", obj)
+	}
+	return string(s), nil
+}
+
+// watchRecorder returns fake watches from a WatchFunc, recording the
+// resourceVersions they are started from.
+type watchRecorder struct {
+	lock     sync.Mutex
+	versions []string
+	errs     []error
+	watches  chan *FakeWatcher
+}
+
+func newWatchRecorder() *watchRecorder {
+	return &watchRecorder{watches: make(chan *FakeWatcher, 10)}
+}
+
+func (r *watchRecorder) watch(ctx context.Context, resourceVersion string) (Interface, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.versions = append(r.versions, resourceVersion)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	w := NewFakeWithChanSize(10, false)
+	r.watches <- w
+	return w, nil
+}
+
+func (r *watchRecorder) startedFrom() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.versions...)
+}
+
+func TestResumable(t *testing.T) {
+	recorder := newWatchRecorder()
+	recorder.errs = []error{errors.New("connection refused")}
+	rw, err := NewResumable("1", recorder.watch, testResourceVersion, ResumableOptions{
+		Backoff: &wait.Backoff{Duration: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Stop()
+
+	w := <-recorder.watches
+	w.Add(testType("2"))
+	w.Modify(testType("3"))
+	for _, expected := range []string{"2", "3"} {
+		if event := <-rw.ResultChan(); event.Object != testType(expected) {
+			t.Fatalf("expected %s, got %v", expected, event)
+		}
+	}
+	if rv := rw.ResourceVersion(); rv != "3" {
+		t.Errorf("expected resourceVersion 3, got %s", rv)
+	}
+
+	// The watch is re-established from the last delivered event when it ends.
+	w.Stop()
+	w = <-recorder.watches
+	w.Delete(testType("4"))
+	if event := <-rw.ResultChan(); event.Type != Deleted || event.Object != testType("4") {
+		t.Fatalf("unexpected event %v", event)
+	}
+	if versions := recorder.startedFrom(); !reflect.DeepEqual(versions, []string{"1", "1", "3"}) {
+		t.Errorf("unexpected resourceVersions %v", versions)
+	}
+
+	rw.Stop()
+	<-rw.Done()
+	if _, ok := <-rw.ResultChan(); ok {
+		t.Error("expected the result channel to be closed")
+	}
+	if err := rw.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestResumableErrors(t *testing.T) {
+	if _, err := NewResumable("", nil, testResourceVersion, ResumableOptions{}); err == nil {
+		t.Error("expected an empty resourceVersion to be rejected")
+	}
+
+	t.Run("non retryable", func(t *testing.T) {
+		recorder := newWatchRecorder()
+		forbidden := errors.New("forbidden")
+		recorder.errs = []error{forbidden}
+		rw, err := NewResumable("1", recorder.watch, testResourceVersion, ResumableOptions{
+			Retryable: func(err error) bool { return err != forbidden },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-rw.Done()
+		if err := rw.Err(); err != forbidden {
+			t.Errorf("expected %v, got %v", forbidden, err)
+		}
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		recorder := newWatchRecorder()
+		recorder.errs = []error{errors.New("1"), errors.New("2"), errors.New("3")}
+		rw, err := NewResumable("1", recorder.watch, testResourceVersion, ResumableOptions{
+			Backoff:    &wait.Backoff{Duration: time.Millisecond},
+			MaxRetries: 2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-rw.Done()
+		if err := rw.Err(); err == nil || err.Error() != "3" {
+			t.Errorf("expected the last error, got %v", err)
+		}
+	})
+
+	t.Run("error events", func(t *testing.T) {
+		recorder := newWatchRecorder()
+		rw, err := NewResumable("1", recorder.watch, testResourceVersion, ResumableOptions{
+			Backoff:        &wait.Backoff{Duration: time.Millisecond},
+			RetryableEvent: func(event Event) bool { return event.Object == testType("transient") },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := <-recorder.watches
+		w.Add(testType("2"))
+		w.Error(testType("transient"))
+		if event := <-rw.ResultChan(); event.Object != testType("2") {
+			t.Fatalf("unexpected event %v", event)
+		}
+		w = <-recorder.watches
+		w.Error(testType("gone"))
+		if event := <-rw.ResultChan(); event.Type != Error || event.Object != testType("gone") {
+			t.Fatalf("expected the error event to be delivered, got %v", event)
+		}
+		<-rw.Done()
+		if versions := recorder.startedFrom(); !reflect.DeepEqual(versions, []string{"1", "2"}) {
+			t.Errorf("unexpected resourceVersions %v", versions)
+		}
+		if err := rw.Err(); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
@@ -28,45 +28,97 @@ const (
 // TODO: fix the returned errors to be introspectable.
 func LookupPatchMetadataForStruct(t reflect.Type, jsonField string) (
 	elemType reflect.Type, patchStrategies []string, patchMergeKey string, e error) {
+	metadata, err := CachedLookupPatchMetadataForStruct(t, jsonField)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	patchStrategies = append([]string(nil), metadata.PatchStrategies...)
+	return metadata.Type, patchStrategies, metadata.PatchMergeKey, nil
+}
+
+// PatchMetadata is the patch metadata of a struct field.
+type PatchMetadata struct {
+	// Type is the type of the field.
+	Type reflect.Type
+	// PatchStrategies are the values of the patchStrategy struct tag.
+	PatchStrategies []string
+	// PatchMergeKey is the value of the patchMergeKey struct tag.
+	PatchMergeKey string
+}
+
+// CachedLookupPatchMetadataForStruct is like LookupPatchMetadataForStruct, but
+// returns the patch metadata of fields whose name matches jsonField exactly
+// from a cache, which is populated with all fields of t on first use. Fields
+// matched case-insensitively are resolved on every call. The returned
+// PatchStrategies are shared between callers and must not be modified.
+func CachedLookupPatchMetadataForStruct(t reflect.Type, jsonField string) (PatchMetadata, error) {
 	if t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
 
 	if t.Kind() != reflect.Struct {
-		e = fmt.Errorf("merging an object in json but data type is not struct, instead is: %s",
+		return PatchMetadata{}, fmt.Errorf("merging an object in json but data type is not struct, instead is: %s",
 			t.Kind().String())
-		return
+	}
+	if metadata, ok := cachedPatchMetadata(t)[jsonField]; ok {
+		return metadata, nil
 	}
 	jf := []byte(jsonField)
 	// Find the field that the JSON library would use.
-	var f *field
 	fields := cachedTypeFields(t)
 	for i := range fields {
-		ff := &fields[i]
-		if bytes.Equal(ff.nameBytes, jf) {
-			f = ff
-			break
-		}
-		// Do case-insensitive comparison.
-		if f == nil && ff.equalFold(ff.nameBytes, jf) {
-			f = ff
+		if fields[i].equalFold(fields[i].nameBytes, jf) {
+			return patchMetadataForField(t, &fields[i]), nil
 		}
 	}
-	if f != nil {
-		// Find the reflect.Value of the most preferential struct field.
-		tjf := t.Field(f.index[0])
-		// we must navigate down all the anonymously included structs in the chain
-		for i := 1; i < len(f.index); i++ {
-			tjf = tjf.Type.Field(f.index[i])
-		}
-		patchStrategy := tjf.Tag.Get(patchStrategyTagKey)
-		patchMergeKey = tjf.Tag.Get(patchMergeKeyTagKey)
-		patchStrategies = strings.Split(patchStrategy, ",")
-		elemType = tjf.Type
-		return
-	}
-	e = fmt.Errorf("unable to find api field in struct %s for the json field %q", t.Name(), jsonField)
-	return
+	return PatchMetadata{}, fmt.Errorf("unable to find api field in struct %s for the json field %q", t.Name(), jsonField)
+}
+
+// patchMetadataForField reads the patch metadata of f from the struct tags of t.
+func patchMetadataForField(t reflect.Type, f *field) PatchMetadata {
+	// Find the reflect.Value of the most preferential struct field.
+	tjf := t.Field(f.index[0])
+	// we must navigate down all the anonymously included structs in the chain
+	for i := 1; i < len(f.index); i++ {
+		tjf = tjf.Type.Field(f.index[i])
+	}
+	return PatchMetadata{
+		Type:            tjf.Type,
+		PatchStrategies: strings.Split(tjf.Tag.Get(patchStrategyTagKey), ","),
+		PatchMergeKey:   tjf.Tag.Get(patchMergeKeyTagKey),
+	}
+}
+
+var patchMetadataCache struct {
+	sync.RWMutex
+	m map[reflect.Type]map[string]PatchMetadata
+}
+
+// cachedPatchMetadata returns the patch metadata of the fields of the struct
+// type t by JSON name, using a cache to avoid repeated work.
+func cachedPatchMetadata(t reflect.Type) map[string]PatchMetadata {
+	patchMetadataCache.RLock()
+	m := patchMetadataCache.m[t]
+	patchMetadataCache.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	// Compute metadata without lock.
+	// Might duplicate effort but won't hold other computations back.
+	fields := cachedTypeFields(t)
+	m = make(map[string]PatchMetadata, len(fields))
+	for i := range fields {
+		m[fields[i].name] = patchMetadataForField(t, &fields[i])
+	}
+
+	patchMetadataCache.Lock()
+	if patchMetadataCache.m == nil {
+		patchMetadataCache.m = map[reflect.Type]map[string]PatchMetadata{}
+	}
+	patchMetadataCache.m[t] = m
+	patchMetadataCache.Unlock()
+	return m
 }
 
 // A field represents a single field found in a struct.
@@ -28,3 +28,67 @@ func TestLookupPtrToStruct(t *testing.T) {
 		t.Errorf("patchMergeKey = %v, want: %v", patchMergeKey, "key")
 	}
 }
+
+func TestCachedLookupPatchMetadataForStruct(t *testing.T) {
+	type Elem struct {
+		Key   string
+		Value string
+	}
+	type Embedded struct {
+		Items []Elem `json:"items" patchStrategy:"merge,retainKeys" patchMergeKey:"key"`
+	}
+	type Outer struct {
+		Embedded
+		Name string `json:"name"`
+	}
+	typ := reflect.TypeOf(&Outer{})
+
+	for _, jsonField := range []string{"items", "Items", "items"} {
+		metadata, err := CachedLookupPatchMetadataForStruct(typ, jsonField)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := PatchMetadata{
+			Type:            reflect.TypeOf([]Elem{}),
+			PatchStrategies: []string{"merge", "retainKeys"},
+			PatchMergeKey:   "key",
+		}
+		if !reflect.DeepEqual(metadata, expected) {
+			t.Errorf("%s: metadata = %+v, want: %+v", jsonField, metadata, expected)
+		}
+	}
+
+	metadata, err := CachedLookupPatchMetadataForStruct(typ, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Type != reflect.TypeOf("") || metadata.PatchMergeKey != "" || !reflect.DeepEqual(metadata.PatchStrategies, []string{""}) {
+		t.Errorf("name: unexpected metadata %+v", metadata)
+	}
+
+	if _, err := CachedLookupPatchMetadataForStruct(typ, "missing"); err == nil {
+		t.Errorf("expected an error for a missing field")
+	}
+	if _, err := CachedLookupPatchMetadataForStruct(reflect.TypeOf(""), "name"); err == nil {
+		t.Errorf("expected an error for a non-struct type")
+	}
+}
+
+func TestLookupPatchMetadataForStructReturnsCopy(t *testing.T) {
+	type Outer struct {
+		Inner []string `json:"inner" patchStrategy:"merge"`
+	}
+	typ := reflect.TypeOf(Outer{})
+	_, patchStrategies, _, err := LookupPatchMetadataForStruct(typ, "inner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patchStrategies[0] = "replace"
+	_, patchStrategies, _, err = LookupPatchMetadataForStruct(typ, "inner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patchStrategies, []string{"merge"}) {
+		t.Errorf("patchStrategies = %v, want: %v", patchStrategies, []string{"merge"})
+	}
+}
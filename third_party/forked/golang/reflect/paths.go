@@ -0,0 +1,363 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reflect
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DeepEqualIgnoringPaths is like DeepEqual, except that the values at the
+// field paths in ignorePaths, and everything below them, are not compared.
+//
+// Field paths use the JSON names of struct fields, separated by dots, with
+// list indexes and map keys in brackets, such as
+// "status.conditions[0].lastTransitionTime" or
+// "metadata.labels[app.kubernetes.io/name]". Map keys may also follow a dot,
+// as in "metadata.labels.app", which is the natural form for unstructured
+// content. A "*" element matches any field, index or key, as in
+// "status.conditions[*].lastTransitionTime". Embedded structs and fields with
+// the inline JSON option do not add an element to the path.
+//
+// Unlike DeepEqual, cyclic values are not supported.
+func (e Equalities) DeepEqualIgnoringPaths(a1, a2 interface{}, ignorePaths []string) bool {
+	if a1 == nil || a2 == nil {
+		return a1 == a2
+	}
+	v1 := reflect.ValueOf(a1)
+	v2 := reflect.ValueOf(a2)
+	if v1.Type() != v2.Type() {
+		return false
+	}
+	return newPathComparison(e, ignorePaths, false).equal(v1, v2)
+}
+
+// FieldDifference is a difference between two values found by Diff.
+type FieldDifference struct {
+	// Path is the field path of the differing values, in the format of
+	// DeepEqualIgnoringPaths, e.g. "spec.containers[0].image". It is empty if
+	// the compared values themselves differ.
+	Path string
+	// A and B are the differing values, or nil if a value is missing on one
+	// side, such as a map key or a list element.
+	A, B interface{}
+}
+
+// String returns the difference as "path: a -> b". Values are formatted with
+// their String method if they or pointers to them have one.
+func (d FieldDifference) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, stringer(d.A), stringer(d.B))
+}
+
+// stringer returns v as a fmt.Stringer if a pointer to it is one, such as
+// for resource.Quantity, and v otherwise.
+func stringer(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if _, ok := v.(fmt.Stringer); ok {
+		return v
+	}
+	p := reflect.New(reflect.TypeOf(v))
+	p.Elem().Set(reflect.ValueOf(v))
+	if s, ok := p.Interface().(fmt.Stringer); ok {
+		return s
+	}
+	return v
+}
+
+// Diff compares a1 and a2 like DeepEqual, and returns the differences between
+// them, or nil if they are equal. Differences are reported at the deepest
+// differing field: values with an equality func, values of different types,
+// nil and non-nil pointers, scalars, and map keys or list elements missing on
+// one side.
+//
+// Like DeepEqualIgnoringPaths, cyclic values are not supported.
+func (e Equalities) Diff(a1, a2 interface{}) []FieldDifference {
+	return e.DiffIgnoringPaths(a1, a2, nil)
+}
+
+// DiffIgnoringPaths is like Diff, except that the values at the field paths in
+// ignorePaths, and everything below them, are not compared. See
+// DeepEqualIgnoringPaths for the format of paths.
+func (e Equalities) DiffIgnoringPaths(a1, a2 interface{}, ignorePaths []string) []FieldDifference {
+	if a1 == nil || a2 == nil {
+		if a1 == a2 {
+			return nil
+		}
+		return []FieldDifference{{A: a1, B: a2}}
+	}
+	v1 := reflect.ValueOf(a1)
+	v2 := reflect.ValueOf(a2)
+	if v1.Type() != v2.Type() {
+		return []FieldDifference{{A: a1, B: a2}}
+	}
+	c := newPathComparison(e, ignorePaths, true)
+	c.equal(v1, v2)
+	return c.diffs
+}
+
+// pathComparison compares values like deepValueEqual with nil and empty
+// equated, keeping track of the field path of the compared values.
+type pathComparison struct {
+	equalities Equalities
+	ignored    [][]string
+	path       []pathElement
+	// collect is true if all the differences are collected in diffs, rather
+	// than stopping at the first one
+	collect bool
+	diffs   []FieldDifference
+}
+
+// pathElement is an element of a field path: a struct field or object key,
+// or a list index or map key which is formatted in brackets.
+type pathElement struct {
+	name    string
+	bracket bool
+}
+
+func newPathComparison(e Equalities, ignorePaths []string, collect bool) *pathComparison {
+	c := &pathComparison{equalities: e, collect: collect}
+	for _, p := range ignorePaths {
+		c.ignored = append(c.ignored, parseFieldPath(p))
+	}
+	return c
+}
+
+// differ records v1 and v2 as a difference if differences are collected, and
+// returns false.
+func (c *pathComparison) differ(v1, v2 reflect.Value) bool {
+	if c.collect {
+		c.diffs = append(c.diffs, FieldDifference{Path: c.formatPath(), A: valueInterface(v1), B: valueInterface(v2)})
+	}
+	return false
+}
+
+func (c *pathComparison) formatPath() string {
+	var b strings.Builder
+	for _, element := range c.path {
+		if element.bracket {
+			b.WriteString("[" + element.name + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(element.name)
+	}
+	return b.String()
+}
+
+func valueInterface(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func (c *pathComparison) equal(v1, v2 reflect.Value) bool {
+	defer makeUsefulPanic(v1)
+
+	if c.isIgnored() {
+		return true
+	}
+	if !v1.IsValid() || !v2.IsValid() {
+		if v1.IsValid() == v2.IsValid() {
+			return true
+		}
+		return c.differ(v1, v2)
+	}
+	if v1.Type() != v2.Type() {
+		return c.differ(v1, v2)
+	}
+	if fv, ok := c.equalities[v1.Type()]; ok {
+		if fv.Call([]reflect.Value{v1, v2})[0].Bool() {
+			return true
+		}
+		return c.differ(v1, v2)
+	}
+
+	switch v1.Kind() {
+	case reflect.Array, reflect.Slice:
+		if v1.Len() != v2.Len() && !c.collect {
+			return false
+		}
+		n := v1.Len()
+		if v2.Len() > n {
+			n = v2.Len()
+		}
+		equal := true
+		for i := 0; i < n; i++ {
+			var e1, e2 reflect.Value
+			if i < v1.Len() {
+				e1 = v1.Index(i)
+			}
+			if i < v2.Len() {
+				e2 = v2.Index(i)
+			}
+			if !c.equalAt(pathElement{name: strconv.Itoa(i), bracket: true}, e1, e2) {
+				equal = false
+				if !c.collect {
+					break
+				}
+			}
+		}
+		return equal
+	case reflect.Interface, reflect.Ptr:
+		if v1.IsNil() || v2.IsNil() {
+			if v1.IsNil() == v2.IsNil() {
+				return true
+			}
+			return c.differ(v1, v2)
+		}
+		return c.equal(v1.Elem(), v2.Elem())
+	case reflect.Struct:
+		equal := true
+		for i, n := 0, v1.NumField(); i < n; i++ {
+			var fieldEqual bool
+			if name, inline := jsonFieldName(v1.Type().Field(i)); inline {
+				fieldEqual = c.equal(v1.Field(i), v2.Field(i))
+			} else {
+				fieldEqual = c.equalAt(pathElement{name: name}, v1.Field(i), v2.Field(i))
+			}
+			if !fieldEqual {
+				equal = false
+				if !c.collect {
+					break
+				}
+			}
+		}
+		return equal
+	case reflect.Map:
+		// keys missing on one side are only a difference if not ignored, so
+		// the union of the keys is compared, which also equates nil and empty
+		keys := v1.MapKeys()
+		for _, k := range v2.MapKeys() {
+			if !v1.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		if c.collect {
+			sort.Slice(keys, func(i, j int) bool {
+				return mapKeyName(keys[i]) < mapKeyName(keys[j])
+			})
+		}
+		// the keys of objects are formatted like struct fields
+		bracket := !(v1.Type().Key().Kind() == reflect.String && v1.Type().Elem().Kind() == reflect.Interface)
+		equal := true
+		for _, k := range keys {
+			if !c.equalAt(pathElement{name: mapKeyName(k), bracket: bracket}, v1.MapIndex(k), v2.MapIndex(k)) {
+				equal = false
+				if !c.collect {
+					break
+				}
+			}
+		}
+		return equal
+	case reflect.Func:
+		if v1.IsNil() && v2.IsNil() {
+			return true
+		}
+		return c.differ(v1, v2)
+	default:
+		if !v1.CanInterface() || !v2.CanInterface() {
+			panic(unexportedTypePanic{})
+		}
+		if v1.Interface() == v2.Interface() {
+			return true
+		}
+		return c.differ(v1, v2)
+	}
+}
+
+// equalAt compares v1 and v2 at element below the current path.
+func (c *pathComparison) equalAt(element pathElement, v1, v2 reflect.Value) bool {
+	c.path = append(c.path, element)
+	defer func() { c.path = c.path[:len(c.path)-1] }()
+	return c.equal(v1, v2)
+}
+
+// isIgnored returns true if the current path is equal to, or below, any of
+// the ignored paths.
+func (c *pathComparison) isIgnored() bool {
+	for _, ignore := range c.ignored {
+		if len(c.path) < len(ignore) {
+			continue
+		}
+		match := true
+		for i, element := range ignore {
+			if element != "*" && element != c.path[i].name {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName returns the JSON name of a struct field, or true if the field
+// is inlined in its parent.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if strings.Contains(","+options+",", ",inline,") || (f.Anonymous && name == "") {
+		return "", true
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+func mapKeyName(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+// parseFieldPath splits a field path into its elements.
+func parseFieldPath(path string) []string {
+	var elements []string
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				end = len(path)
+				path += "]"
+			}
+			elements = append(elements, path[1:end])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			elements = append(elements, path[:end])
+			path = path[end:]
+		}
+	}
+	return elements
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reflect
+
+import (
+	"reflect"
+	"testing"
+)
+
+type pathsMeta struct {
+	Name            string            `json:"name"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+}
+
+type pathsCondition struct {
+	Type               string `json:"type"`
+	LastTransitionTime int    `json:"lastTransitionTime"`
+}
+
+type pathsObject struct {
+	pathsMeta  `json:"metadata"`
+	Inline     pathsCondition   `json:",inline"`
+	Conditions []pathsCondition `json:"conditions"`
+	Untagged   *int
+}
+
+func TestDeepEqualIgnoringPaths(t *testing.T) {
+	e := Equalities{}
+	one, two := 1, 2
+	base := func() pathsObject {
+		return pathsObject{
+			pathsMeta:  pathsMeta{Name: "a", ResourceVersion: "1", Labels: map[string]string{"app.kubernetes.io/name": "x"}},
+			Conditions: []pathsCondition{{Type: "Ready", LastTransitionTime: 1}},
+			Untagged:   &one,
+		}
+	}
+	table := []struct {
+		modify func(*pathsObject)
+		ignore []string
+		equal  bool
+	}{
+		{func(*pathsObject) {}, nil, true},
+		{func(o *pathsObject) { o.Labels = nil }, nil, false},
+		{func(o *pathsObject) { o.Labels = map[string]string{} }, []string{"metadata.labels"}, true},
+		{func(o *pathsObject) { o.Labels["other"] = "y" }, []string{"metadata.labels[other]"}, true},
+		{func(o *pathsObject) { o.Labels["app.kubernetes.io/name"] = "y" }, []string{"metadata.labels[app.kubernetes.io/name]"}, true},
+		{func(o *pathsObject) { o.Labels["app.kubernetes.io/name"] = "y" }, []string{"metadata.labels[other]"}, false},
+		{func(o *pathsObject) { o.ResourceVersion = "2" }, []string{"metadata.resourceVersion"}, true},
+		{func(o *pathsObject) { o.ResourceVersion = "2" }, []string{"metadata.name"}, false},
+		{func(o *pathsObject) { o.Conditions[0].LastTransitionTime = 2 }, []string{"conditions[*].lastTransitionTime"}, true},
+		{func(o *pathsObject) { o.Conditions[0].LastTransitionTime = 2 }, []string{"conditions[0].lastTransitionTime"}, true},
+		{func(o *pathsObject) { o.Conditions[0].LastTransitionTime = 2 }, []string{"conditions[1].lastTransitionTime"}, false},
+		{func(o *pathsObject) { o.Conditions = append(o.Conditions, pathsCondition{}) }, []string{"conditions[*].lastTransitionTime"}, false},
+		{func(o *pathsObject) { o.Inline.Type = "x" }, []string{"type"}, true},
+		{func(o *pathsObject) { o.Untagged = &two }, nil, false},
+		{func(o *pathsObject) { o.Untagged = nil }, []string{"Untagged"}, true},
+	}
+	for i, item := range table {
+		a, b := base(), base()
+		item.modify(&b)
+		if e, a := item.equal, e.DeepEqualIgnoringPaths(a, b, item.ignore); e != a {
+			t.Errorf("%d: expected %v, got %v", i, e, a)
+		}
+	}
+
+	if !e.DeepEqualIgnoringPaths(map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}}, map[string]interface{}{"spec": map[string]interface{}{"replicas": 2}}, []string{"spec.replicas"}) {
+		t.Errorf("expected unstructured paths to be ignored")
+	}
+	if e.DeepEqualIgnoringPaths(nil, 1, nil) || !e.DeepEqualIgnoringPaths(nil, nil, nil) {
+		t.Errorf("unexpected result for nil")
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	table := map[string][]string{
+		"":         nil,
+		"a":        {"a"},
+		"a.b[0].c": {"a", "b", "0", "c"},
+		"a[x.y/z]": {"a", "x.y/z"},
+		"status.conditions[*].lastTransitionTime": {"status", "conditions", "*", "lastTransitionTime"},
+		"a[b": {"a", "b"},
+	}
+	for in, expected := range table {
+		if got := parseFieldPath(in); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%q: expected %v, got %v", in, expected, got)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	e := EqualitiesOrDie(func(a, b pathsCondition) bool {
+		return a.Type == b.Type
+	})
+	one, two := 1, 2
+	a := pathsObject{
+		pathsMeta:  pathsMeta{Name: "a", ResourceVersion: "1", Labels: map[string]string{"x": "1", "y": "1"}},
+		Conditions: []pathsCondition{{Type: "Ready", LastTransitionTime: 1}},
+		Untagged:   &one,
+	}
+	b := pathsObject{
+		pathsMeta:  pathsMeta{Name: "b", ResourceVersion: "2", Labels: map[string]string{"y": "2", "z": "1"}},
+		Conditions: []pathsCondition{{Type: "Ready", LastTransitionTime: 2}, {Type: "Synced"}},
+		Untagged:   &two,
+	}
+	expected := []FieldDifference{
+		{Path: "metadata.name", A: "a", B: "b"},
+		{Path: "metadata.labels[x]", A: "1", B: nil},
+		{Path: "metadata.labels[y]", A: "1", B: "2"},
+		{Path: "metadata.labels[z]", A: nil, B: "1"},
+		{Path: "conditions[1]", A: nil, B: pathsCondition{Type: "Synced"}},
+		{Path: "Untagged", A: 1, B: 2},
+	}
+	if got := e.DiffIgnoringPaths(a, b, []string{"metadata.resourceVersion"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := e.Diff(a, a); got != nil {
+		t.Errorf("expected no differences, got %v", got)
+	}
+
+	u1 := map[string]interface{}{"spec": map[string]interface{}{"replicas": 1, "list": []interface{}{}}}
+	u2 := map[string]interface{}{"spec": map[string]interface{}{"replicas": "1", "list": []interface{}(nil)}}
+	expected = []FieldDifference{{Path: "spec.replicas", A: 1, B: "1"}}
+	if got := e.Diff(u1, u2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := e.Diff(1, "1"); !reflect.DeepEqual(got, []FieldDifference{{A: 1, B: "1"}}) {
+		t.Errorf("unexpected differences of different types: %v", got)
+	}
+	if s := (FieldDifference{Path: "a.b", A: 1, B: 2}).String(); s != "a.b: 1 -> 2" {
+		t.Errorf("unexpected string %q", s)
+	}
+}